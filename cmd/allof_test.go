@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const interfacesSDL = `
+type Query { node(id: ID!): Node }
+interface Node { id: ID! }
+type User implements Node { id: ID! name: String }
+`
+
+func TestFlattenAllOfFlag(t *testing.T) {
+	input := writeFile(t, "schema.graphql", interfacesSDL)
+	for _, tt := range []struct {
+		name    string
+		args    []string
+		flatten bool
+	}{
+		{"interfaces in allOf", []string{"--interface-allof"}, false},
+		{"flattened", []string{"--interface-allof", "--flatten-allof"}, true},
+		{"forms preset", []string{"--interface-allof", "--preset", "forms"}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			user := readSchema(t, output).Definitions["User"]
+			if flattened := len(user.AllOf) == 0 && user.Properties["id"] != nil; flattened != tt.flatten {
+				t.Errorf("User flattened = %v, want %v", flattened, tt.flatten)
+			}
+		})
+	}
+}
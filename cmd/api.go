@@ -0,0 +1,519 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	apiListen         string
+	apiMaxBody        int64
+	apiRequestTimeout time.Duration
+	apiMaxConcurrent  int
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Serve schema conversion as an HTTP API",
+	Long: `Serve an HTTP API that converts schemas supplied by the client. Unlike serve,
+which converts a fixed upstream, every request carries its own schema.
+
+Endpoints:
+  POST /convert   convert the introspection result in the request body
+  GET  /healthz   liveness check
+
+The body of POST /convert is an introspection result as application/json, either
+{"__schema": ...} or {"data": {"__schema": ...}}, or the schema in GraphQL
+schema language as application/graphql (also application/graphql-sdl or
+text/x-graphql), and the response is the JSON Schema. Conversion options are given as query parameters named like the root
+command's flags (id-type, operation, root, definitions-only, only-input-types, prune, draft,
+ignore-internals, nullable-array-items, nullable-fields, use-integer-type, int-bounds,
+well-known-scalars, additional-properties-false, interface-allof,
+interface-implementations, interface-keep-fields, enum-style, field-shape, operations-layout,
+exclude-field, include-field, exclude-type, include-type, include-type-dependencies,
+extract-examples, strip-examples, embed-sdl, continue-on-error, shared-pagination-args,
+inline-refs, inline-omit-definitions, dedupe, select-type). Alternatively the body is an envelope
+{"introspection": ..., "options": {...}} whose options use the library's
+pkg.Options field names; query parameters override them. Envelope requests get
+an envelope back: {"schema": ..., "warnings": [...]}.
+
+Errors are returned as {"error": "...", "warnings": [...]}: 400 for malformed
+requests, 413 for bodies over --max-body, 415 for other content types, 422 when
+the conversion fails, 503 when --max-concurrent conversions are already running
+and 504 when a conversion exceeds --request-timeout.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAPI()
+	},
+}
+
+func init() {
+	apiCmd.Flags().StringVar(&apiListen, "listen", ":8080", "address to listen on")
+	apiCmd.Flags().Int64Var(&apiMaxBody, "max-body", 32<<20, "largest request body accepted, in bytes")
+	apiCmd.Flags().DurationVar(&apiRequestTimeout, "request-timeout", 30*time.Second, "longest a conversion may take")
+	apiCmd.Flags().IntVar(&apiMaxConcurrent, "max-concurrent", 4, "number of conversions run at once; further requests are rejected with 503")
+
+	bindFlag("api.listen", apiCmd.Flags().Lookup("listen"))
+	bindFlag("api.max-body", apiCmd.Flags().Lookup("max-body"))
+	bindFlag("api.request-timeout", apiCmd.Flags().Lookup("request-timeout"))
+	bindFlag("api.max-concurrent", apiCmd.Flags().Lookup("max-concurrent"))
+
+	rootCmd.AddCommand(apiCmd)
+}
+
+// apiServer converts the schemas posted to it, limiting body size, duration and concurrency
+type apiServer struct {
+	maxBody int64
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+// apiRequest is the envelope form of a POST /convert body
+type apiRequest struct {
+	Introspection json.RawMessage `json:"introspection"`
+	Options       json.RawMessage `json:"options"`
+}
+
+// apiResponse is the response to an envelope request
+type apiResponse struct {
+	Schema   *pkg.JSONSchema6 `json:"schema"`
+	Warnings []apiWarning     `json:"warnings"`
+}
+
+// apiErrorResponse is the body of every error response
+type apiErrorResponse struct {
+	Error    string       `json:"error"`
+	Warnings []apiWarning `json:"warnings,omitempty"`
+}
+
+// apiWarning is a warning logged by the conversion of one request
+type apiWarning struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Type    string            `json:"type,omitempty"`
+	Field   string            `json:"field,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// warningCollector is an slog handler keeping the warnings of one conversion
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []apiWarning
+}
+
+func (c *warningCollector) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn
+}
+
+func (c *warningCollector) Handle(_ context.Context, r slog.Record) error {
+	warning := apiWarning{Message: r.Message}
+	r.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case pkg.LogKeyWarning:
+			warning.Code = attr.Value.String()
+		case pkg.LogKeyType:
+			warning.Type = attr.Value.String()
+		case pkg.LogKeyField:
+			warning.Field = attr.Value.String()
+		default:
+			if warning.Details == nil {
+				warning.Details = make(map[string]string)
+			}
+			warning.Details[attr.Key] = attr.Value.String()
+		}
+		return true
+	})
+	if warning.Code == "" {
+		return nil
+	}
+	c.mu.Lock()
+	c.warnings = append(c.warnings, warning)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *warningCollector) WithAttrs([]slog.Attr) slog.Handler { return c }
+func (c *warningCollector) WithGroup(string) slog.Handler      { return c }
+
+// list returns the warnings collected so far
+func (c *warningCollector) list() []apiWarning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]apiWarning{}, c.warnings...)
+}
+
+func runAPI() error {
+	maxBody := viper.GetInt64("api.max-body")
+	timeout := viper.GetDuration("api.request-timeout")
+	maxConcurrent := viper.GetInt("api.max-concurrent")
+	switch {
+	case maxBody <= 0:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --max-body: %d (must be positive)", maxBody))
+	case timeout <= 0:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --request-timeout: %s (must be positive)", timeout))
+	case maxConcurrent <= 0:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --max-concurrent: %d (must be positive)", maxConcurrent))
+	}
+
+	api := &apiServer{maxBody: maxBody, timeout: timeout, slots: make(chan struct{}, maxConcurrent)}
+	server := &http.Server{
+		Addr:              viper.GetString("api.listen"),
+		Handler:           api.mux(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return listenUntilSignal(server, "Serving conversion API")
+}
+
+func (s *apiServer) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("POST /convert", s.handleConvert)
+	return mux
+}
+
+func (s *apiServer) handleConvert(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	status := http.StatusOK
+	defer func() {
+		logger.Info("Converted", "status", status, "duration", time.Since(start).Round(time.Millisecond), "remote", r.RemoteAddr)
+	}()
+	fail := func(code int, err error, warnings []apiWarning) {
+		status = code
+		writeAPIError(w, code, err, warnings)
+	}
+
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			fail(http.StatusUnsupportedMediaType, fmt.Errorf("invalid Content-Type: %w", err), nil)
+			return
+		}
+		mediaType = parsed
+	}
+	sdl := false
+	switch mediaType {
+	case "application/json":
+	case "application/graphql", "application/graphql-sdl", "text/x-graphql":
+		sdl = true
+	default:
+		fail(http.StatusUnsupportedMediaType, fmt.Errorf("unsupported Content-Type %s; send an introspection result as application/json or SDL as application/graphql", mediaType), nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			fail(http.StatusRequestEntityTooLarge, fmt.Errorf("request body is over the %s limit", formatBytes(int(s.maxBody))), nil)
+			return
+		}
+		fail(http.StatusBadRequest, fmt.Errorf("error reading request body: %w", err), nil)
+		return
+	}
+
+	// SDL bodies are the schema alone, options coming from the query parameters
+	var envelope apiRequest
+	isEnvelope := false
+	if !sdl {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			fail(http.StatusBadRequest, fmt.Errorf("error parsing request body: %w", err), nil)
+			return
+		}
+		if _, isEnvelope = fields["introspection"]; isEnvelope {
+			if err := json.Unmarshal(body, &envelope); err != nil {
+				fail(http.StatusBadRequest, fmt.Errorf("error parsing request body: %w", err), nil)
+				return
+			}
+		} else {
+			envelope.Introspection = body
+		}
+	}
+
+	opts := pkg.DefaultOptions()
+	if len(envelope.Options) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(envelope.Options))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&opts); err != nil {
+			fail(http.StatusBadRequest, fmt.Errorf("invalid options: %w", err), nil)
+			return
+		}
+	}
+	selectType, err := applyAPIQuery(&opts, r.URL.Query())
+	if err != nil {
+		fail(http.StatusBadRequest, err, nil)
+		return
+	}
+	if err := validateAPIOptions(&opts); err != nil {
+		fail(http.StatusBadRequest, err, nil)
+		return
+	}
+
+	var introspection *pkg.IntrospectionQuery
+	if sdl {
+		introspection, err = pkg.IntrospectionFromSDL(string(body))
+		if err != nil {
+			fail(http.StatusBadRequest, fmt.Errorf("error parsing SDL: %w", err), nil)
+			return
+		}
+	} else {
+		introspection, err = pkg.DecodeIntrospection(bytes.NewReader(envelope.Introspection), nil)
+		if err != nil {
+			fail(http.StatusBadRequest, fmt.Errorf("error parsing introspection: %w", err), nil)
+			return
+		}
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		w.Header().Set("Retry-After", "1")
+		fail(http.StatusServiceUnavailable, fmt.Errorf("too many conversions in progress, retry later"), nil)
+		return
+	}
+	collector := &warningCollector{}
+	opts.Logger = slog.New(collector)
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	type result struct {
+		schema *pkg.JSONSchema6
+		err    error
+	}
+	done := make(chan result, 1)
+	// The selected type is extracted from the converted definitions, so inlining has to follow it
+	convertOpts := opts
+	if selectType != "" {
+		convertOpts.InlineRefs = false
+	}
+	// A timed out or abandoned request stops the conversion at its next progress report
+	convertOpts.Progress = func(done, total int) error { return ctx.Err() }
+	go func() {
+		schema, err := pkg.FromIntrospectionQuery(*introspection, &convertOpts)
+		if err == nil && selectType != "" {
+			pkg.UseDefinitions(schema)
+			schema, err = pkg.ExtractDefinition(schema, selectType)
+			if err == nil && opts.InlineRefs {
+				schema = pkg.InlineDefinitions(schema, opts.InlineDepth)
+				if opts.InlineOmitDefinitions {
+					pkg.DropDefinitions(schema)
+				}
+			}
+			if err == nil {
+				pkg.RestoreDraftLayout(schema)
+			}
+		}
+		<-s.slots
+		done <- result{schema, err}
+	}()
+
+	var converted result
+	select {
+	case converted = <-done:
+	case <-ctx.Done():
+		// Wait for the stopped conversion to give its slot back, so that a timeout never leaves the
+		// server with less capacity than it reports
+		<-done
+		fail(http.StatusGatewayTimeout, fmt.Errorf("conversion did not finish within %s", s.timeout), collector.list())
+		return
+	}
+	if converted.err != nil {
+		fail(http.StatusUnprocessableEntity, fmt.Errorf("error converting to JSON Schema: %w", converted.err), collector.list())
+		return
+	}
+
+	var output []byte
+	if isEnvelope {
+		output, err = json.MarshalIndent(apiResponse{Schema: converted.schema, Warnings: collector.list()}, "", "  ")
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		output, err = json.MarshalIndent(converted.schema, "", "  ")
+		w.Header().Set("Content-Type", "application/schema+json")
+	}
+	if err != nil {
+		w.Header().Del("Content-Type")
+		fail(http.StatusInternalServerError, fmt.Errorf("error marshaling JSON Schema: %w", err), nil)
+		return
+	}
+	w.Write(output)
+}
+
+// applyAPIQuery sets the conversion options given as query parameters and returns select-type,
+// which is applied to the converted schema
+func applyAPIQuery(opts *pkg.Options, query map[string][]string) (string, error) {
+	var selectType string
+	for name, values := range query {
+		value := values[len(values)-1]
+		var err error
+		switch name {
+		case "id-type":
+			opts.IDTypeMapping = pkg.IDTypeMapping(value)
+		case "operation":
+			operation := pkg.OperationType(value)
+			opts.Operation = &operation
+		case "root":
+			opts.Roots = nil
+			for _, root := range splitQueryList(values) {
+				opts.Roots = append(opts.Roots, pkg.OperationType(root))
+			}
+		case "operations-layout":
+			opts.OperationsLayout = pkg.OperationsLayout(value)
+		case "nullable-fields":
+			opts.NullableFields = pkg.NullableFields(value)
+		case "interface-allof":
+			opts.InterfaceAllOf, err = parseQueryBool(value)
+		case "interface-implementations":
+			opts.InterfaceImplementations = pkg.InterfaceImplementations(value)
+		case "interface-keep-fields":
+			opts.InterfaceKeepFields, err = parseQueryBool(value)
+		case "enum-style":
+			opts.EnumStyle = pkg.EnumStyle(value)
+		case "field-shape":
+			opts.FieldShape = pkg.FieldShape(value)
+		case "draft":
+			opts.Draft = pkg.Draft(value)
+		case "exclude-field":
+			opts.ExcludeFields = splitQueryList(values)
+		case "include-field":
+			opts.IncludeFields = splitQueryList(values)
+		case "exclude-type":
+			opts.ExcludeTypes = splitQueryList(values)
+		case "include-type":
+			opts.IncludeTypes = splitQueryList(values)
+		case "include-type-dependencies":
+			opts.IncludeTypeDependencies, err = parseQueryBool(value)
+		case "select-type":
+			selectType = value
+		case "definitions-only":
+			opts.DefinitionsOnly, err = parseQueryBool(value)
+		case "only-input-types":
+			opts.OnlyInputTypes, err = parseQueryBool(value)
+		case "prune":
+			opts.PruneToRoots, err = parseQueryBool(value)
+		case "ignore-internals":
+			opts.IgnoreInternals, err = parseQueryBool(value)
+		case "nullable-array-items":
+			opts.NullableArrayItems, err = parseQueryBool(value)
+		case "use-integer-type":
+			opts.UseIntegerType, err = parseQueryBool(value)
+		case "int-bounds":
+			opts.IntBounds, err = parseQueryBool(value)
+		case "well-known-scalars":
+			opts.WellKnownScalars, err = parseQueryBool(value)
+		case "additional-properties-false":
+			opts.AdditionalPropertiesFalse, err = parseQueryBool(value)
+		case "extract-examples":
+			opts.ExtractExamples, err = parseQueryBool(value)
+		case "strip-examples":
+			opts.StripExamples, err = parseQueryBool(value)
+		case "embed-sdl":
+			opts.EmbedSDL, err = parseQueryBool(value)
+		case "continue-on-error":
+			opts.Lenient, err = parseQueryBool(value)
+		case "shared-pagination-args":
+			opts.SharedPaginationArgs, err = parseQueryBool(value)
+		case "inline-refs":
+			// An empty value is the flag given without one
+			if value == "" {
+				value = "true"
+			}
+			opts.InlineRefs, opts.InlineDepth, err = parseInlineRefs(value)
+		case "inline-omit-definitions":
+			opts.InlineOmitDefinitions, err = parseQueryBool(value)
+			opts.InlineRefs = opts.InlineRefs || opts.InlineOmitDefinitions
+		case "dedupe":
+			opts.DedupeDefinitions, err = parseQueryBool(value)
+		default:
+			return "", fmt.Errorf("unknown query parameter: %s", name)
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return selectType, nil
+}
+
+// validateAPIOptions rejects option values the conversion would otherwise misinterpret
+func validateAPIOptions(opts *pkg.Options) error {
+	if !pkg.IsValidIDTypeMapping(opts.IDTypeMapping) {
+		return fmt.Errorf("invalid id-type: %s", opts.IDTypeMapping)
+	}
+	if opts.Operation != nil && !pkg.IsValidOperationType(*opts.Operation) {
+		return fmt.Errorf("invalid operation: %s", *opts.Operation)
+	}
+	for _, root := range opts.Roots {
+		if !pkg.IsValidOperationType(root) {
+			return fmt.Errorf("invalid root: %s", root)
+		}
+	}
+	if !pkg.IsValidOperationsLayout(opts.OperationsLayout) {
+		return fmt.Errorf("invalid operations-layout: %s", opts.OperationsLayout)
+	}
+	if !pkg.IsValidDraft(opts.Draft) {
+		return fmt.Errorf("invalid draft: %s", opts.Draft)
+	}
+	if !pkg.IsValidNullableFields(opts.NullableFields) {
+		return fmt.Errorf("invalid nullable-fields: %s", opts.NullableFields)
+	}
+	if !pkg.IsValidEnumStyle(opts.EnumStyle) {
+		return fmt.Errorf("invalid enum-style: %s", opts.EnumStyle)
+	}
+	if !pkg.IsValidFieldShape(opts.FieldShape) {
+		return fmt.Errorf("invalid field-shape: %s", opts.FieldShape)
+	}
+	if opts.InlineDepth < 0 {
+		return fmt.Errorf("invalid inline-depth: %d", opts.InlineDepth)
+	}
+	if !pkg.IsValidInterfaceImplementations(opts.InterfaceImplementations) {
+		return fmt.Errorf("invalid interface-implementations: %s", opts.InterfaceImplementations)
+	}
+	return nil
+}
+
+// splitQueryList accepts list parameters both repeated and comma separated
+func splitQueryList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// parseQueryBool parses a boolean query parameter, where a bare ?name means true
+func parseQueryBool(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error, warnings []apiWarning) {
+	data, _ := json.MarshalIndent(apiErrorResponse{Error: err.Error(), Warnings: warnings}, "", "  ")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
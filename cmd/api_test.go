@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// newTestAPI returns an API server with the given limits, logging to the test's buffer
+func newTestAPI(t *testing.T, maxBody int64, timeout time.Duration, maxConcurrent int) *apiServer {
+	t.Helper()
+	captureLog(t)
+	return &apiServer{maxBody: maxBody, timeout: timeout, slots: make(chan struct{}, maxConcurrent)}
+}
+
+// postConvert sends a body to POST /convert and returns the recorded response
+func postConvert(api *apiServer, query, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/convert"+query, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	api.mux().ServeHTTP(w, r)
+	return w
+}
+
+// introspectionJSON returns the introspection result of an SDL as a response body
+func introspectionJSON(t *testing.T, sdl string) string {
+	t.Helper()
+	introspection, err := pkg.IntrospectionFromSDL(sdl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]interface{}{"data": introspection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// apiError decodes an error response
+func apiError(t *testing.T, w *httptest.ResponseRecorder) apiErrorResponse {
+	t.Helper()
+	var response apiErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error response %q is not JSON: %v", w.Body.String(), err)
+	}
+	return response
+}
+
+func TestAPIConvertJSON(t *testing.T) {
+	api := newTestAPI(t, 1<<20, time.Minute, 1)
+	w := postConvert(api, "?definitions-only=true", "application/json", introspectionJSON(t, fixtureSDL))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/schema+json" {
+		t.Errorf("Content-Type = %s", contentType)
+	}
+	schema := decodeSchema(t, w.Body.Bytes())
+	if schema.Properties != nil || schema.Definitions["User"] == nil {
+		t.Errorf("definitions-only was not applied: %s", w.Body)
+	}
+}
+
+func TestAPIConvertEnvelope(t *testing.T) {
+	api := newTestAPI(t, 1<<20, time.Minute, 1)
+	body := fmt.Sprintf(`{"introspection": %s, "options": {"definitionsOnly": true, "pruneToRoots": true}}`, introspectionJSON(t, fixtureSDL))
+
+	// The query parameter overrides the envelope
+	w := postConvert(api, "?definitions-only=false", "application/json", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var response apiResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Schema == nil || response.Schema.Properties["Query"] == nil {
+		t.Errorf("definitions-only query parameter did not override the envelope: %s", w.Body)
+	}
+	if response.Schema != nil && response.Schema.Definitions["Unused"] != nil {
+		t.Error("envelope option pruneToRoots was not applied")
+	}
+	if response.Warnings == nil {
+		t.Error("envelope response has no warnings list")
+	}
+}
+
+func TestAPIConvertSDL(t *testing.T) {
+	api := newTestAPI(t, 1<<20, time.Minute, 1)
+	for _, contentType := range []string{"application/graphql", "application/graphql-sdl; charset=utf-8", "text/x-graphql"} {
+		t.Run(contentType, func(t *testing.T) {
+			w := postConvert(api, "?select-type=User", contentType, fixtureSDL)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			schema := decodeSchema(t, w.Body.Bytes())
+			if schema.Properties["address"] == nil || schema.Definitions["Address"] == nil {
+				t.Errorf("response is not the selected User type: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestAPIConvertOversized(t *testing.T) {
+	api := newTestAPI(t, 256, time.Minute, 1)
+	w := postConvert(api, "", "application/graphql", fixtureSDL)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusRequestEntityTooLarge, w.Body)
+	}
+	if response := apiError(t, w); !strings.Contains(response.Error, "over the") {
+		t.Errorf("error = %q", response.Error)
+	}
+}
+
+func TestAPIConvertErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		status      int
+		want        string
+	}{
+		{"unsupported content type", "", "text/plain", fixtureSDL, http.StatusUnsupportedMediaType, "unsupported Content-Type"},
+		{"malformed JSON", "", "application/json", "{", http.StatusBadRequest, "error parsing request body"},
+		{"malformed SDL", "", "application/graphql", "type Query {", http.StatusBadRequest, "error parsing SDL"},
+		{"unknown envelope option", "", "application/json", `{"introspection": {}, "options": {"inline": true}}`, http.StatusBadRequest, "invalid options"},
+		{"invalid query parameter", "?inline-refs=-1", "application/graphql", fixtureSDL, http.StatusBadRequest, "inline-refs"},
+		{"unknown selected type", "?select-type=Adress", "application/graphql", fixtureSDL, http.StatusUnprocessableEntity, "did you mean: Address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, 1<<20, time.Minute, 1)
+			w := postConvert(api, tt.query, tt.contentType, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if response := apiError(t, w); !strings.Contains(response.Error, tt.want) {
+				t.Errorf("error %q does not contain %q", response.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIConvertBusy(t *testing.T) {
+	api := newTestAPI(t, 1<<20, time.Minute, 1)
+	api.slots <- struct{}{}
+	w := postConvert(api, "", "application/graphql", fixtureSDL)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("status = %d, Retry-After = %q, want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestAPIConvertTimeoutFreesSlot(t *testing.T) {
+	// Enough types for the conversion to report progress, where it notices the timeout
+	body := introspectionBody(t, 1200)
+	api := newTestAPI(t, 8<<20, time.Nanosecond, 1)
+	w := postConvert(api, "", "application/json", string(body))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusGatewayTimeout, w.Body)
+	}
+	if len(api.slots) != 0 {
+		t.Fatal("the timed out conversion still holds its slot")
+	}
+
+	api.timeout = time.Minute
+	if w := postConvert(api, "", "application/graphql", fixtureSDL); w.Code != http.StatusOK {
+		t.Errorf("status after a timeout = %d: %s", w.Code, w.Body)
+	}
+}
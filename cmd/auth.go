@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// authKeyring is the profile auth setting that retrieves credentials from the secret store
+const authKeyring = "keyring"
+
+var (
+	profile string
+
+	// credentialSecrets holds the credential values retrieved from the secret store or
+	// --token-command, for redaction
+	credentialSecrets []string
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage endpoint credentials stored in the OS keyring",
+	Long: `Store endpoint credentials in the OS keyring instead of the config file.
+
+Credentials are stored per profile. A profile in the config file that declares
+"auth: keyring" has them added to its requests automatically:
+
+  profiles:
+    prod:
+      endpoint: https://api.example.com/graphql
+      auth: keyring
+
+Select the profile with --profile prod or "profile: prod" in the config file.
+
+macOS uses the Keychain, Windows the Credential Manager and Linux the Secret
+Service (GNOME Keyring, KWallet). Where none is available, credentials are stored
+in a file in the user config directory, encrypted with a key derived from the
+passphrase in GRAPHQL2JSON_KEYRING_PASSPHRASE, and a warning is printed.`,
+}
+
+var authSetCmd = &cobra.Command{
+	Use:   "set PROFILE",
+	Short: "Store credentials for a profile, read from stdin",
+	Long: `Store credentials for a profile, read from stdin. A single line without a colon is
+a bearer token sent as "Authorization: Bearer <token>"; otherwise each line is a
+"Name: Value" header. Existing credentials for the profile are replaced.
+
+  echo "$API_TOKEN" | gql2jsonschema auth set prod`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAuthSet(args[0], os.Stdin)
+	},
+}
+
+var authRemoveCmd = &cobra.Command{
+	Use:   "remove PROFILE",
+	Short: "Remove the stored credentials for a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAuthRemove(args[0])
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "use the endpoint and credentials of this profile from the config file")
+	bindFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+
+	authCmd.AddCommand(authSetCmd)
+	authCmd.AddCommand(authRemoveCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+func runAuthSet(name string, r io.Reader) error {
+	if !validProfileName(name) {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_' and '.'", name))
+	}
+
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Enter a token or \"Name: Value\" header lines, then press Ctrl-D:")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading credentials: %w", err)
+	}
+	secret, err := normalizeCredentials(string(data))
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	store, err := openSecretStore()
+	if err != nil {
+		return err
+	}
+	if file, ok := store.(*fileSecretStore); ok {
+		logger.Warn("No OS keyring available; storing credentials in a file encrypted with the passphrase from "+keyringPassphraseEnv, "path", file.path())
+	}
+	if err := store.set(name, secret); err != nil {
+		return fmt.Errorf("error storing credentials: %w", err)
+	}
+	logger.Info("Stored credentials", "profile", name, "store", store.description())
+	return nil
+}
+
+func runAuthRemove(name string) error {
+	if !validProfileName(name) {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_' and '.'", name))
+	}
+
+	store, err := openSecretStore()
+	if err != nil {
+		return err
+	}
+	if err := store.remove(name); err != nil {
+		if errors.Is(err, errSecretNotFound) {
+			return withExitCode(ExitUsage, fmt.Errorf("no credentials stored for profile %s", name))
+		}
+		return fmt.Errorf("error removing credentials: %w", err)
+	}
+	logger.Info("Removed credentials", "profile", name, "store", store.description())
+	return nil
+}
+
+// normalizeCredentials turns a bare token into an Authorization header and validates header lines,
+// returning the header lines that are stored
+func normalizeCredentials(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("no credentials provided on stdin")
+	}
+	if !strings.ContainsAny(input, ":\n") {
+		input = "Authorization: Bearer " + input
+	}
+
+	headers, err := parseHeaders(input)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(headerLines(headers), "\n"), nil
+}
+
+// applyProfile uses the selected profile's endpoint when no endpoint is set elsewhere
+func applyProfile() {
+	name := viper.GetString("profile")
+	if name == "" {
+		return
+	}
+	if endpoint := viper.GetString("profiles." + name + ".endpoint"); endpoint != "" {
+		viper.SetDefault("endpoint", interpolate(endpoint))
+	}
+}
+
+// requestHeaders returns the headers sent to the endpoint: the profile headers plus the token
+// printed by --token-command, if set
+func requestHeaders() (http.Header, error) {
+	headers, err := profileHeaders()
+	if err != nil {
+		return nil, err
+	}
+	if viper.GetString("token-command") != "" {
+		if err := applyTokenCommand(headers); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// profileHeaders returns the configured headers plus, when the selected profile declares
+// auth: keyring, the credentials stored for it
+func profileHeaders() (http.Header, error) {
+	headers, err := configHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	name := viper.GetString("profile")
+	if name == "" {
+		return headers, nil
+	}
+	if !viper.IsSet("profiles." + name) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("profile %s not found in the config file", name))
+	}
+	switch auth := viper.GetString("profiles." + name + ".auth"); auth {
+	case "":
+		return headers, nil
+	case authKeyring:
+	default:
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid auth for profile %s: %s (must be '%s')", name, auth, authKeyring))
+	}
+
+	store, err := openSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := store.get(name)
+	if errors.Is(err, errSecretNotFound) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no credentials stored for profile %s: run 'gql2jsonschema auth set %s'", name, name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading credentials for profile %s: %w", name, err)
+	}
+
+	stored, err := parseHeaders(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored credentials for profile %s: %w", name, err)
+	}
+	for header, values := range stored {
+		// Stored credentials replace configured values of the same header rather than adding to them
+		headers.Del(header)
+		for _, value := range values {
+			headers.Add(header, value)
+			credentialSecrets = append(credentialSecrets, value)
+		}
+	}
+	logger.Debug("Loaded credentials", "profile", name, "store", store.description())
+	return headers, nil
+}
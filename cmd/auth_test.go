@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+// withStdin makes content the standard input of the commands run by the test
+func withStdin(t *testing.T, content string) {
+	t.Helper()
+	f, err := os.Open(writeFile(t, "stdin", content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = saved
+		f.Close()
+	})
+}
+
+// authServer serves an introspection result only to requests carrying the given Authorization header
+func authServer(t *testing.T, authorization string) *httptest.Server {
+	t.Helper()
+	body := introspectionBody(t, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors": [{"message": "unauthorized"}]}`))
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// profileConfig writes a config file with a prod profile that reads its credentials from the keyring
+func profileConfig(t *testing.T, endpoint string) string {
+	t.Helper()
+	return writeFile(t, "config.yaml", "profiles:\n  prod:\n    endpoint: "+endpoint+"\n    auth: keyring\n")
+}
+
+func TestAuthKeyring(t *testing.T) {
+	keyring.MockInit()
+	server := authServer(t, "Bearer tok-123")
+	config := profileConfig(t, server.URL)
+
+	withStdin(t, "tok-123\n")
+	if result := runCLI(t, "--config", config, "auth", "set", "prod"); result.err != nil {
+		t.Fatalf("auth set: %v\n%s", result.err, result.stderr)
+	}
+	if secret, err := keyring.Get(keyringService, "prod"); err != nil || secret != "Authorization: Bearer tok-123" {
+		t.Errorf("stored secret = %q (%v), want the Authorization header", secret, err)
+	}
+
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--config", config, "--profile", "prod", "-v", "-o", output)
+	if result.err != nil {
+		t.Fatalf("convert with the stored token: %v\n%s", result.err, result.stderr)
+	}
+	if strings.Contains(result.stderr, "tok-123") {
+		t.Errorf("the stored token was logged:\n%s", result.stderr)
+	}
+	if got := redactSecrets("Authorization: Bearer tok-123"); strings.Contains(got, "tok-123") {
+		t.Errorf("the stored token is not redacted: %s", got)
+	}
+	readSchema(t, output)
+
+	if result := runCLI(t, "--config", config, "auth", "remove", "prod"); result.err != nil {
+		t.Fatalf("auth remove: %v", result.err)
+	}
+	result = runCLI(t, "--config", config, "auth", "remove", "prod")
+	if result.err == nil || !strings.Contains(result.err.Error(), "no credentials stored for profile prod") {
+		t.Errorf("expected removing twice to fail, got %v", result.err)
+	}
+	result = runCLI(t, "--config", config, "--profile", "prod", "-o", output)
+	if result.err == nil || !strings.Contains(result.err.Error(), "run 'gql2jsonschema auth set prod'") {
+		t.Errorf("expected a missing credentials error, got %v", result.err)
+	}
+	if code := ExitCode(result.err); code != ExitUsage {
+		t.Errorf("exit code = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestAuthSetHeaders(t *testing.T) {
+	keyring.MockInit()
+	withStdin(t, "X-Api-Key: abc\nAuthorization: Basic dXNlcg==\n")
+	if result := runCLI(t, "--no-config", "auth", "set", "staging"); result.err != nil {
+		t.Fatalf("auth set: %v", result.err)
+	}
+	secret, err := keyring.Get(keyringService, "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(secret, "X-Api-Key: abc") || !strings.Contains(secret, "Authorization: Basic dXNlcg==") {
+		t.Errorf("stored secret = %q, want both header lines", secret)
+	}
+
+	result := runCLI(t, "--no-config", "auth", "set", "bad/name")
+	if result.err == nil || ExitCode(result.err) != ExitUsage {
+		t.Errorf("expected an invalid profile name to be a usage error, got %v", result.err)
+	}
+}
+
+func TestAuthEncryptedFileFallback(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no secret service"))
+	server := authServer(t, "Bearer tok-456")
+	config := profileConfig(t, server.URL)
+
+	withStdin(t, "tok-456\n")
+	result := runCLI(t, "--config", config, "auth", "set", "prod")
+	if result.err == nil || !strings.Contains(result.err.Error(), keyringPassphraseEnv) {
+		t.Fatalf("expected storing without a keyring or passphrase to fail, got %v", result.err)
+	}
+
+	t.Setenv(keyringPassphraseEnv, "correct horse")
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	store, err := openSecretStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, ok := store.(*fileSecretStore)
+	if !ok {
+		t.Fatalf("expected the encrypted file store, got %s", store.description())
+	}
+	if err := store.set("prod", "Authorization: Bearer tok-456"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file.path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "tok-456") {
+		t.Error("the credentials file holds the token in plain text")
+	}
+	if _, err := os.Stat(filepath.Join(file.dir, "credentials.key")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("a key file was written next to the credentials")
+	}
+	if secret, err := store.get("prod"); err != nil || secret != "Authorization: Bearer tok-456" {
+		t.Errorf("get = %q (%v)", secret, err)
+	}
+
+	wrong := &fileSecretStore{dir: file.dir, passphrase: "wrong"}
+	if _, err := wrong.get("prod"); err == nil || !strings.Contains(err.Error(), "cannot decrypt") {
+		t.Errorf("expected a wrong passphrase to fail, got %v", err)
+	}
+	if err := wrong.set("other", "x"); err == nil || !strings.Contains(err.Error(), "does not match the passphrase") {
+		t.Errorf("expected storing with a wrong passphrase to fail, got %v", err)
+	}
+	if err := store.remove("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.get("prod"); !errors.Is(err, errSecretNotFound) {
+		t.Errorf("expected the removed secret to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// generateBigQuery exports the type named by --select-type as a BigQuery table schema.
+// Scalar mappings can be extended with the bigquery.scalar-types config block.
+func generateBigQuery(introspection *pkg.IntrospectionQuery) ([]byte, error) {
+	typeName := viper.GetString("select-type")
+	if typeName == "" {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("--target bigquery requires --select-type"))
+	}
+
+	opts := pkg.DefaultBigQueryOptions()
+	// Viper lowercases config keys, so scalar names are matched against the schema case-insensitively
+	scalarTypes := viper.GetStringMapString("bigquery.scalar-types")
+	for _, t := range introspection.Schema.Types {
+		if columnType, ok := scalarTypes[strings.ToLower(t.Name)]; ok && t.Kind == "SCALAR" {
+			opts.ScalarTypes[t.Name] = strings.ToUpper(columnType)
+		}
+	}
+
+	fields, err := pkg.BigQuerySchema(*introspection, typeName, opts)
+	if err != nil {
+		return nil, withExitCode(ExitConversion, err)
+	}
+
+	output, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling BigQuery schema: %w", err)
+	}
+	return output, nil
+}
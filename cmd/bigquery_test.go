@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+func TestTargetBigQuery(t *testing.T) {
+	sdl := "scalar Money\n\ntype Query { order: Order }\n\ntype Order { id: ID! total: Money lines: [String!]! }\n"
+	input := writeFile(t, "schema.graphql", sdl)
+	config := writeFile(t, "config.yaml", "bigquery:\n  scalar-types:\n    Money: numeric\n")
+
+	result := runCLI(t, "--config", config, "-i", input, "--target", "bigquery", "--select-type", "Order")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	var fields []pkg.BigQueryField
+	if err := json.Unmarshal([]byte(result.stdout), &fields); err != nil {
+		t.Fatalf("decoding BigQuery schema: %v\n%s", err, result.stdout)
+	}
+	want := []pkg.BigQueryField{
+		{Name: "id", Type: "STRING", Mode: pkg.BigQueryRequired},
+		{Name: "total", Type: "NUMERIC", Mode: pkg.BigQueryNullable},
+		{Name: "lines", Type: "STRING", Mode: pkg.BigQueryRepeated},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("fields = %+v, want %+v", fields, want)
+	}
+	for i := range want {
+		if fields[i].Name != want[i].Name || fields[i].Type != want[i].Type || fields[i].Mode != want[i].Mode {
+			t.Errorf("field %d = %+v, want %+v", i, fields[i], want[i])
+		}
+	}
+}
+
+func TestTargetBigQueryErrors(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	tests := []struct {
+		name string
+		args []string
+		want string
+		code int
+	}{
+		{"missing type", nil, "--target bigquery requires --select-type", ExitUsage},
+		{"union", []string{"--select-type", "SearchResult"}, "type SearchResult is a union, not an object", ExitConversion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "--target", "bigquery"}, tt.args...)...)
+			if result.err == nil || !strings.Contains(result.err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, result.err)
+			}
+			if code := ExitCode(result.err); code != tt.code {
+				t.Errorf("exit code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var browseSchemaFile string
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "Explore a generated JSON Schema in an interactive terminal browser",
+	Long: `Open an interactive browser over a JSON Schema generated by this tool, read from
+--schema or converted from --endpoint or --input.
+
+The left pane lists the root properties and definitions; "/" searches them. The
+right pane shows the selected schema's properties with their types, whether they
+are required (*) and descriptions. Enter follows a $ref to its definition or opens
+the selected property, and b/f (or left/right) go back and forward through the
+history. y copies the JSON pointer of the selection to the clipboard of terminals
+that support OSC 52, and e exports the selected subtree, with the definitions it
+references, to a file. Tab switches panes, q quits.
+
+Terminals narrower than 80 columns show one pane at a time. The browser needs an
+interactive Unix terminal.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBrowse()
+	},
+}
+
+func init() {
+	browseCmd.Flags().StringVarP(&browseSchemaFile, "schema", "s", "", "previously generated JSON Schema file")
+
+	rootCmd.AddCommand(browseCmd)
+}
+
+func runBrowse() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return withExitCode(ExitUsage, fmt.Errorf("browse needs an interactive terminal: %w", err))
+	}
+	defer tty.Close()
+
+	schema, err := loadSchema(browseSchemaFile)
+	if err != nil {
+		return err
+	}
+	program := tea.NewProgram(newBrowserModel(schema, tty), tea.WithInput(tty), tea.WithOutput(tty), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		return fmt.Errorf("error running the browser: %w", err)
+	}
+	return nil
+}
+
+// browserModel drives the browser from Bubble Tea messages, carrying out the effects of its keys
+type browserModel struct {
+	b      *browser
+	width  int
+	height int
+	// clipboard receives the OSC 52 sequences copying pointers, normally the terminal
+	clipboard io.Writer
+}
+
+func newBrowserModel(schema *pkg.JSONSchema6, clipboard io.Writer) *browserModel {
+	return &browserModel{b: newBrowser(schema), width: 80, height: 24, clipboard: clipboard}
+}
+
+func (m *browserModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		var cmds []tea.Cmd
+		for _, k := range keyNames(msg) {
+			m.b.key(k)
+			if m.b.copyPointer != "" {
+				cmds = append(cmds, m.copyCmd(m.b.copyPointer))
+			}
+			if m.b.exportFile != "" {
+				m.b.status = exportSubtree(m.b.schema, m.b.exportTarget, m.b.exportFile)
+			}
+			if m.b.quit {
+				return m, tea.Quit
+			}
+		}
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+func (m *browserModel) View() string {
+	return strings.Join(m.b.render(m.width, m.height), "\n")
+}
+
+// copyCmd copies text to the clipboard of terminals that support OSC 52
+func (m *browserModel) copyCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		fmt.Fprintf(m.clipboard, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+		return nil
+	}
+}
+
+// browseKeys names the special keys the browser handles
+var browseKeys = map[tea.KeyType]string{
+	tea.KeyUp: "up", tea.KeyDown: "down", tea.KeyLeft: "left", tea.KeyRight: "right",
+	tea.KeyPgUp: "pgup", tea.KeyPgDown: "pgdown", tea.KeyHome: "home", tea.KeyEnd: "end",
+	tea.KeyEnter: "enter", tea.KeyTab: "tab", tea.KeyBackspace: "backspace", tea.KeyEsc: "esc",
+	tea.KeyCtrlH: "backspace", tea.KeyCtrlC: "ctrl-c", tea.KeySpace: " ",
+}
+
+// keyNames translates a key message into the key names of browser.key: the names of browseKeys, or
+// each typed character, as several arrive at once when text is pasted
+func keyNames(msg tea.KeyMsg) []string {
+	if msg.Type == tea.KeyRunes {
+		names := make([]string, 0, len(msg.Runes))
+		for _, r := range msg.Runes {
+			names = append(names, string(r))
+		}
+		return names
+	}
+	if name, ok := browseKeys[msg.Type]; ok {
+		return []string{name}
+	}
+	return nil
+}
+
+// exportSubtree writes the schema at pointer, with the definitions it references, to file and
+// returns a status line describing the outcome
+func exportSubtree(schema *pkg.JSONSchema6, pointer, file string) string {
+	subtree, err := pkg.ExtractSchema(schema, "#"+pointer)
+	if err != nil {
+		return "Export failed: " + err.Error()
+	}
+	data, err := json.MarshalIndent(subtree, "", "  ")
+	if err != nil {
+		return "Export failed: " + err.Error()
+	}
+	if err := writeFileAtomic(file, append(data, '\n'), 0644); err != nil {
+		return "Export failed: " + err.Error()
+	}
+	return fmt.Sprintf("Exported #%s to %s", pointer, file)
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// newTestBrowser returns a browser model over the fixture schema sized to width and height, and the
+// buffer its clipboard writes to
+func newTestBrowser(t *testing.T, width, height int) (*browserModel, *bytes.Buffer) {
+	t.Helper()
+	schema, err := pkg.FromSDL(fixtureSDL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clipboard := &bytes.Buffer{}
+	m := newBrowserModel(schema, clipboard)
+	m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	return m, clipboard
+}
+
+// press sends keys to the model and returns the command of the last one
+func press(m *browserModel, keys ...tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		_, cmd = m.Update(k)
+	}
+	return cmd
+}
+
+// typed is the key message of typing or pasting text
+func typed(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+func key(t tea.KeyType) tea.KeyMsg {
+	return tea.KeyMsg{Type: t}
+}
+
+func TestBrowserModelView(t *testing.T) {
+	m, _ := newTestBrowser(t, 100, 20)
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("view has %d lines, want 20", len(lines))
+	}
+	if !strings.Contains(lines[0], "#/properties/Mutation") {
+		t.Errorf("title %q does not show the first root", lines[0])
+	}
+	if !strings.Contains(lines[1], "» Mutation") || !strings.Contains(lines[1], "│") {
+		t.Errorf("first line %q does not show the selected list entry beside the detail pane", lines[1])
+	}
+	if !strings.Contains(m.View(), "createUser") {
+		t.Errorf("detail pane does not list the Mutation fields:\n%s", m.View())
+	}
+	for i, line := range lines {
+		if n := len([]rune(line)); n > 100 {
+			t.Errorf("line %d is %d columns wide", i, n)
+		}
+	}
+}
+
+func TestBrowserModelNarrow(t *testing.T) {
+	m, _ := newTestBrowser(t, 60, 12)
+	if strings.Contains(m.View(), "│") {
+		t.Errorf("narrow view shows both panes:\n%s", m.View())
+	}
+	if !strings.Contains(m.View(), "Subscription") {
+		t.Errorf("narrow view does not start on the list:\n%s", m.View())
+	}
+	press(m, key(tea.KeyTab))
+	if !strings.Contains(m.View(), "createUser") {
+		t.Errorf("tab did not switch to the detail pane:\n%s", m.View())
+	}
+}
+
+func TestBrowserModelNavigation(t *testing.T) {
+	m, _ := newTestBrowser(t, 100, 20)
+
+	// Search narrows the list, and enter opens the selected match
+	press(m, typed("/"), typed("user"))
+	if footer := lastLine(m.View()); footer != "/user" {
+		t.Errorf("search footer = %q", footer)
+	}
+	press(m, key(tea.KeyDown), key(tea.KeyEnter))
+	if m.b.current != "/definitions/User" {
+		t.Fatalf("enter opened %s, want /definitions/User", m.b.current)
+	}
+
+	// The first row opens the address field, whose return references Address
+	press(m, key(tea.KeyEnter))
+	if m.b.current != "/definitions/User/properties/address" {
+		t.Fatalf("enter opened %s, want the address field", m.b.current)
+	}
+	press(m, key(tea.KeyDown), key(tea.KeyEnter))
+	if m.b.current != "/definitions/Address" || !strings.Contains(m.View(), "street") {
+		t.Errorf("enter did not follow the $ref to Address: %s\n%s", m.b.current, m.View())
+	}
+	press(m, typed("b"))
+	if m.b.current != "/definitions/User/properties/address" {
+		t.Errorf("back went to %s", m.b.current)
+	}
+	press(m, key(tea.KeyLeft))
+	if m.b.current != "/definitions/User" {
+		t.Errorf("second back went to %s", m.b.current)
+	}
+	press(m, key(tea.KeyRight), key(tea.KeyRight))
+	if m.b.current != "/definitions/Address" {
+		t.Errorf("forward went to %s", m.b.current)
+	}
+	press(m, typed("f"))
+	if footer := lastLine(m.View()); footer != "No later schema" {
+		t.Errorf("footer after forward at the end = %q", footer)
+	}
+}
+
+func TestBrowserModelSearchCancel(t *testing.T) {
+	m, _ := newTestBrowser(t, 100, 20)
+	press(m, typed("/"), typed("zzz"))
+	if !strings.Contains(m.View(), "no matches") {
+		t.Errorf("unmatched search does not say so:\n%s", m.View())
+	}
+	press(m, key(tea.KeyBackspace), key(tea.KeyEsc))
+	if m.b.mode != modeNormal || len(m.b.filtered) != len(m.b.entries) {
+		t.Errorf("esc did not clear the search: mode %d, %d of %d entries", m.b.mode, len(m.b.filtered), len(m.b.entries))
+	}
+	// The q typed while searching belongs to the search, not the browser
+	if cmd := press(m, typed("/"), typed("q")); cmd != nil {
+		if _, quit := cmd().(tea.QuitMsg); quit {
+			t.Error("q quit while searching")
+		}
+	}
+}
+
+func TestBrowserModelCopy(t *testing.T) {
+	m, clipboard := newTestBrowser(t, 100, 20)
+	press(m, key(tea.KeyTab))
+	cmd := press(m, typed("y"))
+	if cmd == nil {
+		t.Fatal("y returned no command")
+	}
+	cmd()
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("#/properties/Mutation/properties/createUser")) + "\x07"
+	if clipboard.String() != want {
+		t.Errorf("clipboard got %q, want %q", clipboard.String(), want)
+	}
+	if footer := lastLine(m.View()); footer != "Copied #/properties/Mutation/properties/createUser" {
+		t.Errorf("footer = %q", footer)
+	}
+}
+
+func TestBrowserModelExport(t *testing.T) {
+	m, _ := newTestBrowser(t, 100, 20)
+	file := filepath.Join(t.TempDir(), "user.json")
+	press(m, typed("/"), typed("User"), key(tea.KeyDown), key(tea.KeyEnter), key(tea.KeyTab), typed("e"))
+	if footer := lastLine(m.View()); footer != "Export to: User.schema.json" {
+		t.Errorf("export prompt = %q", footer)
+	}
+	for range "User.schema.json" {
+		press(m, key(tea.KeyBackspace))
+	}
+	press(m, typed(file), key(tea.KeyEnter))
+	if footer := lastLine(m.View()); footer != "Exported #/definitions/User to "+file {
+		t.Fatalf("footer = %q", footer)
+	}
+	schema := readSchema(t, file)
+	if schema.Properties["address"] == nil || schema.Definitions["Address"] == nil {
+		t.Errorf("export is not User with its definitions: %+v", schema)
+	}
+}
+
+func TestBrowserModelQuit(t *testing.T) {
+	for _, k := range []tea.KeyMsg{typed("q"), key(tea.KeyCtrlC)} {
+		m, _ := newTestBrowser(t, 100, 20)
+		cmd := press(m, k)
+		if cmd == nil {
+			t.Fatalf("%s returned no command", k)
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf("%s did not quit", k)
+		}
+	}
+}
+
+func TestKeyNames(t *testing.T) {
+	tests := []struct {
+		msg  tea.KeyMsg
+		want []string
+	}{
+		{key(tea.KeyUp), []string{"up"}},
+		{key(tea.KeyPgDown), []string{"pgdown"}},
+		{key(tea.KeyEnter), []string{"enter"}},
+		{key(tea.KeyCtrlH), []string{"backspace"}},
+		{key(tea.KeySpace), []string{" "}},
+		{typed("a/é"), []string{"a", "/", "é"}},
+		{key(tea.KeyCtrlZ), nil},
+	}
+	for _, tt := range tests {
+		if got := keyNames(tt.msg); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("keyNames(%s) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+// lastLine is the footer of a view
+func lastLine(view string) string {
+	return view[strings.LastIndex(view, "\n")+1:]
+}
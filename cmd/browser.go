@@ -0,0 +1,595 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// browserNarrowWidth is the terminal width below which the browser shows one pane at a time
+const browserNarrowWidth = 80
+
+// browserPane is the pane that receives navigation keys
+type browserPane int
+
+const (
+	paneList browserPane = iota
+	paneDetail
+)
+
+// browserMode is what typed characters go to
+type browserMode int
+
+const (
+	modeNormal browserMode = iota
+	modeSearch
+	modeExport
+)
+
+// browserEntry is an item of the definitions list: a definition or a root property
+type browserEntry struct {
+	name    string
+	pointer string
+}
+
+// browserRow is a selectable child of the schema shown in the detail pane
+type browserRow struct {
+	label    string
+	pointer  string
+	schema   *pkg.JSONSchema6
+	required bool
+}
+
+// browser is the state of the schema browser. It is driven by key names and rendered to lines, so
+// it is independent of the terminal; see browserModel for the terminal side.
+type browser struct {
+	schema  *pkg.JSONSchema6
+	entries []browserEntry
+
+	filter   string
+	filtered []browserEntry
+	cursor   int
+	offset   int
+
+	focus         browserPane
+	mode          browserMode
+	current       string
+	rows          []browserRow
+	detailCursor  int
+	detailOffset  int
+	back, forward []string
+
+	input  string
+	status string
+
+	// Effects for the driver to carry out after a key
+	quit         bool
+	copyPointer  string
+	exportFile   string
+	exportTarget string
+}
+
+func newBrowser(schema *pkg.JSONSchema6) *browser {
+	b := &browser{schema: schema}
+	for _, name := range sortedSchemaKeys(schema.Properties) {
+		b.entries = append(b.entries, browserEntry{name: name, pointer: "/properties/" + escapeJSONPointer(name)})
+	}
+	for _, name := range sortedSchemaKeys(schema.Definitions) {
+		b.entries = append(b.entries, browserEntry{name: name, pointer: "/definitions/" + escapeJSONPointer(name)})
+	}
+	b.applyFilter()
+	if len(b.filtered) > 0 {
+		b.show(b.filtered[0].pointer)
+	}
+	b.status = "/ search  enter open  tab switch pane  b/f back/forward  y copy pointer  e export  q quit"
+	return b
+}
+
+// key handles one key press, named as by keyNames
+func (b *browser) key(k string) {
+	b.copyPointer, b.exportFile, b.exportTarget = "", "", ""
+	switch b.mode {
+	case modeSearch:
+		b.searchKey(k)
+		return
+	case modeExport:
+		b.exportKey(k)
+		return
+	}
+
+	switch k {
+	case "q", "ctrl-c":
+		b.quit = true
+	case "/":
+		b.mode, b.focus = modeSearch, paneList
+	case "tab":
+		if b.focus == paneList {
+			b.focus = paneDetail
+		} else {
+			b.focus = paneList
+		}
+	case "b", "left", "backspace":
+		b.goBack()
+	case "f", "right":
+		b.goForward()
+	case "y":
+		pointer := b.selectedPointer()
+		b.copyPointer = "#" + pointer
+		b.status = "Copied #" + pointer
+	case "e":
+		b.mode = modeExport
+		b.input = exportFileName(b.selectedPointer())
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup":
+		b.move(-10)
+	case "pgdown":
+		b.move(10)
+	case "home", "g":
+		b.move(-1 << 30)
+	case "end", "G":
+		b.move(1 << 30)
+	case "enter":
+		b.open()
+	}
+}
+
+func (b *browser) searchKey(k string) {
+	switch k {
+	case "enter":
+		b.mode = modeNormal
+		b.open()
+	case "esc", "ctrl-c":
+		b.mode, b.filter = modeNormal, ""
+		b.applyFilter()
+	case "backspace":
+		if b.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(b.filter)
+			b.filter = b.filter[:len(b.filter)-size]
+			b.applyFilter()
+		}
+	case "up", "down", "pgup", "pgdown":
+		b.move(map[string]int{"up": -1, "down": 1, "pgup": -10, "pgdown": 10}[k])
+	default:
+		if utf8.RuneCountInString(k) == 1 {
+			b.filter += k
+			b.applyFilter()
+		}
+	}
+}
+
+func (b *browser) exportKey(k string) {
+	switch k {
+	case "enter":
+		b.mode = modeNormal
+		if strings.TrimSpace(b.input) != "" {
+			b.exportFile, b.exportTarget = strings.TrimSpace(b.input), b.selectedPointer()
+		}
+	case "esc", "ctrl-c":
+		b.mode = modeNormal
+	case "backspace":
+		if b.input != "" {
+			_, size := utf8.DecodeLastRuneInString(b.input)
+			b.input = b.input[:len(b.input)-size]
+		}
+	default:
+		if utf8.RuneCountInString(k) == 1 {
+			b.input += k
+		}
+	}
+}
+
+// applyFilter narrows the list to the entries whose names contain the filter, ignoring case
+func (b *browser) applyFilter() {
+	b.filtered = b.filtered[:0]
+	needle := strings.ToLower(b.filter)
+	for _, entry := range b.entries {
+		if strings.Contains(strings.ToLower(entry.name), needle) {
+			b.filtered = append(b.filtered, entry)
+		}
+	}
+	b.cursor, b.offset = 0, 0
+	if b.filter != "" {
+		b.status = fmt.Sprintf("%d of %d match %q", len(b.filtered), len(b.entries), b.filter)
+	}
+}
+
+// move moves the cursor of the focused pane by delta, clamped to its items
+func (b *browser) move(delta int) {
+	if b.focus == paneList || b.mode == modeSearch {
+		b.cursor = clamp(b.cursor+delta, 0, len(b.filtered)-1)
+		if b.mode == modeNormal && len(b.filtered) > 0 {
+			b.current = b.filtered[b.cursor].pointer
+			b.loadRows()
+		}
+		return
+	}
+	b.detailCursor = clamp(b.detailCursor+delta, 0, len(b.rows)-1)
+}
+
+// open opens the selected list entry, or follows the selected detail row: into the definition it
+// references, or down into the row's own schema
+func (b *browser) open() {
+	if b.focus == paneList {
+		if len(b.filtered) > 0 {
+			b.navigate(b.filtered[b.cursor].pointer)
+			b.focus = paneDetail
+		}
+		return
+	}
+	if len(b.rows) == 0 {
+		return
+	}
+	row := b.rows[b.detailCursor]
+	if strings.HasPrefix(row.schema.Ref, "#/") {
+		b.navigate(strings.TrimPrefix(row.schema.Ref, "#"))
+		return
+	}
+	b.navigate(row.pointer)
+}
+
+// navigate shows pointer, recording the current schema in the back history
+func (b *browser) navigate(pointer string) {
+	if _, err := b.schema.GetByPointer(pointer); err != nil {
+		b.status = err.Error()
+		return
+	}
+	if pointer == b.current {
+		return
+	}
+	if b.current != "" {
+		b.back = append(b.back, b.current)
+	}
+	b.forward = nil
+	b.show(pointer)
+}
+
+func (b *browser) goBack() {
+	if len(b.back) == 0 {
+		b.status = "No earlier schema"
+		return
+	}
+	b.forward = append(b.forward, b.current)
+	pointer := b.back[len(b.back)-1]
+	b.back = b.back[:len(b.back)-1]
+	b.show(pointer)
+}
+
+func (b *browser) goForward() {
+	if len(b.forward) == 0 {
+		b.status = "No later schema"
+		return
+	}
+	b.back = append(b.back, b.current)
+	pointer := b.forward[len(b.forward)-1]
+	b.forward = b.forward[:len(b.forward)-1]
+	b.show(pointer)
+}
+
+// show makes pointer the schema of the detail pane without touching the history
+func (b *browser) show(pointer string) {
+	b.current = pointer
+	b.loadRows()
+	b.status = "#" + pointer
+}
+
+// loadRows lists the children of the current schema
+func (b *browser) loadRows() {
+	b.rows, b.detailCursor, b.detailOffset = nil, 0, 0
+	schema, err := b.schema.GetByPointer(b.current)
+	if err != nil {
+		return
+	}
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	for _, name := range sortedSchemaKeys(schema.Properties) {
+		b.rows = append(b.rows, browserRow{
+			label:    name,
+			pointer:  b.current + "/properties/" + escapeJSONPointer(name),
+			schema:   schema.Properties[name],
+			required: required[name],
+		})
+	}
+	if schema.Items != nil {
+		b.rows = append(b.rows, browserRow{label: "items", pointer: b.current + "/items", schema: schema.Items})
+	}
+	for _, branches := range []struct {
+		keyword  string
+		branches []*pkg.JSONSchema6
+	}{{"anyOf", schema.AnyOf}, {"oneOf", schema.OneOf}, {"allOf", schema.AllOf}} {
+		for i, branch := range branches.branches {
+			if branch == nil {
+				continue
+			}
+			b.rows = append(b.rows, browserRow{
+				label:   fmt.Sprintf("%s[%d]", branches.keyword, i),
+				pointer: fmt.Sprintf("%s/%s/%d", b.current, branches.keyword, i),
+				schema:  branch,
+			})
+		}
+	}
+}
+
+// selectedPointer is the pointer acted on by copy and export: the selected detail row when the
+// detail pane has focus, otherwise the schema it shows
+func (b *browser) selectedPointer() string {
+	if b.focus == paneDetail && len(b.rows) > 0 {
+		return b.rows[b.detailCursor].pointer
+	}
+	return b.current
+}
+
+// render draws the browser into height lines of at most width columns. Narrow terminals show only
+// the focused pane.
+func (b *browser) render(width, height int) []string {
+	if width < 10 || height < 4 {
+		return []string{truncate("Terminal too small", width)}
+	}
+	bodyHeight := height - 2
+	title := truncate(" gql2jsonschema browse  #"+b.current, width)
+
+	var list, detail []string
+	var body []string
+	if width < browserNarrowWidth {
+		if b.focus == paneList || b.mode == modeSearch {
+			body = b.renderList(width, bodyHeight)
+		} else {
+			body = b.renderDetail(width, bodyHeight)
+		}
+	} else {
+		listWidth := min(36, width/3)
+		list = b.renderList(listWidth, bodyHeight)
+		detail = b.renderDetail(width-listWidth-3, bodyHeight)
+		for i := 0; i < bodyHeight; i++ {
+			body = append(body, pad(list[i], listWidth)+" │ "+detail[i])
+		}
+	}
+
+	footer := b.status
+	switch b.mode {
+	case modeSearch:
+		footer = "/" + b.filter
+	case modeExport:
+		footer = "Export to: " + b.input
+	}
+	lines := append([]string{title}, body...)
+	return append(lines, truncate(footer, width))
+}
+
+func (b *browser) renderList(width, height int) []string {
+	lines := make([]string, height)
+	if len(b.filtered) == 0 {
+		lines[0] = truncate("  no matches", width)
+		return lines
+	}
+	b.offset = scrollOffset(b.offset, b.cursor, height, len(b.filtered))
+	for i := 0; i < height && b.offset+i < len(b.filtered); i++ {
+		index := b.offset + i
+		marker := "  "
+		if index == b.cursor {
+			marker = "> "
+			if b.focus == paneList {
+				marker = "» "
+			}
+		}
+		lines[i] = truncate(marker+b.filtered[index].name, width)
+	}
+	return lines
+}
+
+func (b *browser) renderDetail(width, height int) []string {
+	lines := make([]string, 0, height)
+	schema, err := b.schema.GetByPointer(b.current)
+	if err != nil {
+		return padLines([]string{truncate(err.Error(), width)}, height)
+	}
+
+	lines = append(lines, truncate(pointerName(b.current)+": "+schemaSummary(schema), width))
+	if schema.GraphQLType != "" {
+		lines = append(lines, truncate("GraphQL type: "+schema.GraphQLType, width))
+	}
+	for _, line := range wrapText(schema.Description, width) {
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+
+	rowsHeight := height - len(lines)
+	if rowsHeight < 1 {
+		return lines[:height]
+	}
+	if len(b.rows) == 0 {
+		lines = append(lines, truncate("  no properties", width))
+		return padLines(lines, height)
+	}
+	b.detailOffset = scrollOffset(b.detailOffset, b.detailCursor, rowsHeight, len(b.rows))
+	for i := 0; i < rowsHeight && b.detailOffset+i < len(b.rows); i++ {
+		index := b.detailOffset + i
+		row := b.rows[index]
+		marker := "  "
+		if index == b.detailCursor {
+			marker = "> "
+			if b.focus == paneDetail {
+				marker = "» "
+			}
+		}
+		label := row.label
+		if row.required {
+			label += "*"
+		}
+		line := fmt.Sprintf("%s%-20s %s", marker, label, schemaSummary(row.schema))
+		if description := firstLine(row.schema.Description); description != "" {
+			line += "  — " + description
+		}
+		lines = append(lines, truncate(line, width))
+	}
+	return padLines(lines, height)
+}
+
+// schemaSummary describes a schema in a few words: its type, reference, enum or composition
+func schemaSummary(schema *pkg.JSONSchema6) string {
+	if schema == nil {
+		return "any"
+	}
+	if schema.Ref != "" {
+		return "→ " + pointerName(strings.TrimPrefix(schema.Ref, "#"))
+	}
+	if ret, ok := schema.Properties["return"]; ok {
+		if _, hasArgs := schema.Properties["arguments"]; hasArgs {
+			args := ""
+			if arguments := schema.Properties["arguments"]; arguments != nil && len(arguments.Properties) > 0 {
+				args = strings.Join(sortedSchemaKeys(arguments.Properties), ", ")
+			}
+			return "(" + args + ") " + schemaSummary(ret)
+		}
+	}
+	if len(schema.Enum) > 0 {
+		return "enum " + strings.Join(schema.Enum, " | ")
+	}
+	for _, composition := range []struct {
+		keyword  string
+		branches []*pkg.JSONSchema6
+	}{{"oneOf", schema.OneOf}, {"anyOf", schema.AnyOf}, {"allOf", schema.AllOf}} {
+		if len(composition.branches) == 0 {
+			continue
+		}
+		parts := make([]string, len(composition.branches))
+		for i, branch := range composition.branches {
+			parts[i] = schemaSummary(branch)
+		}
+		return composition.keyword + "(" + strings.Join(parts, " | ") + ")"
+	}
+	if schema.Items != nil {
+		return "[]" + schemaSummary(schema.Items)
+	}
+
+	var types []string
+	switch t := schema.Type.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			types = append(types, fmt.Sprint(item))
+		}
+	case []string:
+		types = t
+	}
+	summary := strings.Join(types, " | ")
+	if summary == "" {
+		summary = "any"
+	}
+	if schema.Title != "" && schema.Title != summary {
+		summary += " (" + schema.Title + ")"
+	}
+	return summary
+}
+
+// pointerName is the last segment of a pointer, unescaped
+func pointerName(pointer string) string {
+	if pointer == "" {
+		return "root"
+	}
+	segment := pointer[strings.LastIndex(pointer, "/")+1:]
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+}
+
+// exportFileName suggests a file name for exporting the schema at pointer
+func exportFileName(pointer string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, pointerName(pointer))
+	return name + ".schema.json"
+}
+
+func escapeJSONPointer(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+}
+
+func sortedSchemaKeys(schemas map[string]*pkg.JSONSchema6) []string {
+	keys := make([]string, 0, len(schemas))
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scrollOffset returns the first visible index so that cursor stays within a window of height
+func scrollOffset(offset, cursor, height, total int) int {
+	if cursor < offset {
+		offset = cursor
+	}
+	if cursor >= offset+height {
+		offset = cursor - height + 1
+	}
+	return clamp(offset, 0, max(0, total-height))
+}
+
+func clamp(value, low, high int) int {
+	if high < low {
+		return low
+	}
+	return max(low, min(value, high))
+}
+
+// truncate shortens s to width columns, counting runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func padLines(lines []string, height int) []string {
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines[:height]
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// wrapText wraps s into lines of at most width columns at spaces
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(s), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, truncate(line, width))
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, truncate(line, width))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var (
+	cacheDir string
+	noCache  bool
+)
+
+// maxCacheEntries is how many converted schemas are kept; older entries are removed first
+const maxCacheEntries = 32
+
+// cacheEntrySuffix is the file extension of cache entries
+const cacheEntrySuffix = ".schema"
+
+// conversionCache stores generated schemas keyed by a hash of the introspection, the options and the tool version
+type conversionCache struct {
+	dir string
+}
+
+// openConversionCache returns the cache configured by --cache-dir and --no-cache, or nil when caching is disabled
+func openConversionCache() *conversionCache {
+	if viper.GetBool("no-cache") {
+		return nil
+	}
+
+	dir := viper.GetString("cache-dir")
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			logger.Debug("Conversion cache disabled", "error", err)
+			return nil
+		}
+		dir = filepath.Join(userCacheDir, "gql2jsonschema")
+	}
+	return &conversionCache{dir: dir}
+}
+
+// cacheKey hashes everything that affects the generated output
+func cacheKey(introspection *pkg.IntrospectionQuery, opts *pkg.Options) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", toolVersion())
+
+	settings := conversionSettings(opts)
+	// The overlay's content, not its path, decides the output
+	if path := viper.GetString("overlay"); path != "" {
+		overlay, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading overlay: %w", err)
+		}
+		settings["overlay"] = string(overlay)
+	}
+	if path := viper.GetString("rename-map"); path != "" {
+		renames, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading rename map: %w", err)
+		}
+		settings["rename-map"] = string(renames)
+	}
+	encoder := json.NewEncoder(hash)
+	if err := encoder.Encode(settings); err != nil {
+		return "", fmt.Errorf("error hashing options: %w", err)
+	}
+	// The introspection is streamed into the hash rather than marshaled into memory first
+	if err := encoder.Encode(introspection); err != nil {
+		return "", fmt.Errorf("error hashing introspection: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// conversionSettings are the options and post-processing flags that decide the generated output
+func conversionSettings(opts *pkg.Options) map[string]interface{} {
+	return map[string]interface{}{
+		"options":                 opts,
+		"select-type":             viper.GetString("select-type"),
+		"select-pointer":          viper.GetString("select-pointer"),
+		"inline-refs":             viper.GetString("inline-refs"),
+		"inline-omit-definitions": viper.GetBool("inline-omit-definitions"),
+		"dedupe":                  viper.GetBool("dedupe"),
+		"flatten-allof":           viper.GetBool("flatten-allof"),
+	}
+}
+
+// toolVersion identifies the build, so entries written by other versions are never used
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	version := info.Main.Version
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			version += " " + setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	// Builds from a modified checkout share a revision, so tell them apart by the binary itself
+	if modified || info.Main.Version == "(devel)" {
+		if executable, err := os.Executable(); err == nil {
+			if stat, err := os.Stat(executable); err == nil {
+				version += fmt.Sprintf(" %d %d", stat.Size(), stat.ModTime().UnixNano())
+			}
+		}
+	}
+	return version
+}
+
+// get returns the cached output for key. Missing, unreadable or corrupt entries are cache misses;
+// corrupt entries are removed.
+func (c *conversionCache) get(key string) ([]byte, bool) {
+	path := filepath.Join(c.dir, key+cacheEntrySuffix)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Debug("Cache read failed", "path", path, "error", err)
+		}
+		return nil, false
+	}
+
+	// Entries start with a checksum of the output so truncated or damaged files are detected
+	checksum, output, found := bytes.Cut(data, []byte("\n"))
+	sum := sha256.Sum256(output)
+	if !found || string(checksum) != hex.EncodeToString(sum[:]) {
+		logger.Warn("Removing corrupt cache entry", "path", path)
+		os.Remove(path)
+		return nil, false
+	}
+
+	// Touch the entry so pruning removes the least recently used ones
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return output, true
+}
+
+// put stores output under key. Failures only disable caching for this run.
+func (c *conversionCache) put(key string, output []byte) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		logger.Debug("Cache write failed", "error", err)
+		return
+	}
+
+	sum := sha256.Sum256(output)
+	data := make([]byte, 0, hex.EncodedLen(len(sum))+1+len(output))
+	data = append(data, hex.EncodeToString(sum[:])...)
+	data = append(data, '\n')
+	data = append(data, output...)
+
+	path := filepath.Join(c.dir, key+cacheEntrySuffix)
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		logger.Debug("Cache write failed", "path", path, "error", err)
+		return
+	}
+	c.prune()
+}
+
+// prune removes the least recently used entries beyond maxCacheEntries
+func (c *conversionCache) prune() {
+	entries, err := filepath.Glob(filepath.Join(c.dir, "*"+cacheEntrySuffix))
+	if err != nil || len(entries) <= maxCacheEntries {
+		return
+	}
+
+	modTimes := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		if info, err := os.Stat(entry); err == nil {
+			modTimes[entry] = info.ModTime().UnixNano()
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return modTimes[entries[i]] > modTimes[entries[j]] })
+	for _, entry := range entries[maxCacheEntries:] {
+		os.Remove(entry)
+	}
+}
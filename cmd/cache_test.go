@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// convertedFresh reports whether a verbose run converted the schema rather than reading the cache
+func convertedFresh(t *testing.T, result cliResult) bool {
+	t.Helper()
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	converted := strings.Contains(result.stderr, "phase=convert")
+	if cached := strings.Contains(result.stderr, "Using cached conversion"); cached == converted {
+		t.Fatalf("expected either a conversion or a cache hit to be logged:\n%s", result.stderr)
+	}
+	return converted
+}
+
+func TestConversionCache(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	cache := t.TempDir()
+	run := func(args ...string) (bool, string) {
+		output := filepath.Join(t.TempDir(), "schema.json")
+		base := []string{"--no-config", "-v", "-i", input, "-o", output, "--cache-dir", cache}
+		converted := convertedFresh(t, runCLI(t, append(base, args...)...))
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return converted, string(data)
+	}
+
+	converted, first := run()
+	if !converted {
+		t.Fatal("the first run read a cache that should be empty")
+	}
+	converted, second := run()
+	if converted {
+		t.Error("the second run with identical input converted again")
+	}
+	if second != first {
+		t.Error("the cached output differs from the converted output")
+	}
+
+	if converted, _ := run("--id-type", "number"); !converted {
+		t.Error("a run with different options used the cached output")
+	}
+	if converted, _ := run("--no-cache"); !converted {
+		t.Error("--no-cache used the cached output")
+	}
+}
+
+func TestConversionCacheCorruptEntry(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	cache := t.TempDir()
+	args := []string{"--no-config", "-v", "-i", input, "-o", filepath.Join(t.TempDir(), "schema.json"), "--cache-dir", cache}
+	convertedFresh(t, runCLI(t, args...))
+
+	entries, err := filepath.Glob(filepath.Join(cache, "*"+cacheEntrySuffix))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one cache entry, found %v (%v)", entries, err)
+	}
+	data, err := os.ReadFile(entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entries[0], data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := runCLI(t, args...)
+	if !convertedFresh(t, result) {
+		t.Fatal("a truncated cache entry was used")
+	}
+	if !strings.Contains(result.stderr, "Removing corrupt cache entry") {
+		t.Errorf("the corrupt entry was not reported:\n%s", result.stderr)
+	}
+	if convertedFresh(t, runCLI(t, args...)) {
+		t.Error("the entry rewritten after the corrupt one was not used")
+	}
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	changelogOld                 string
+	changelogNew                 string
+	changelogSince               string
+	changelogDir                 string
+	changelogFormat              string
+	changelogAppend              string
+	changelogTitle               string
+	changelogIncludeDescriptions bool
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Describe schema changes as Markdown release notes",
+	Long: `Compare two schemas and describe the changes in prose, grouped into Added,
+Changed, Deprecated and Removed sections and named by GraphQL coordinate, e.g.
+"` + "`User.email`" + ` is now required" or "Enum ` + "`Status`" + ` gained value ` + "`ARCHIVED`" + `".
+Breaking changes are marked.
+
+--old and --new are generated JSON Schema files or, when both are introspection
+results, compared at the GraphQL level, which also reports deprecations. Instead
+of --old, --since picks the baseline from the snapshot directory (--dir): a hash
+prefix or file name of a snapshot, or a date or RFC 3339 time, selecting the newest
+snapshot taken at or before it. When --new is omitted the schema is converted from
+--endpoint or --input, or else the latest snapshot is used.
+
+--append inserts the notes into a CHANGELOG file under a dated heading, above the
+newest existing "## " section, instead of printing them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChangelog()
+	},
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogOld, "old", "", "baseline schema or introspection file")
+	changelogCmd.Flags().StringVar(&changelogNew, "new", "", "changed schema or introspection file (default --endpoint, --input or the latest snapshot)")
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "use a snapshot as the baseline: hash prefix, file name, date or RFC 3339 time")
+	changelogCmd.Flags().StringVar(&changelogDir, "dir", "", "snapshot directory for --since (default the snapshot command's --dir)")
+	changelogCmd.Flags().StringVarP(&changelogFormat, "format", "f", "markdown", "output format (markdown or json)")
+	changelogCmd.Flags().StringVar(&changelogAppend, "append", "", "insert the notes into this CHANGELOG file instead of printing them")
+	changelogCmd.Flags().StringVar(&changelogTitle, "title", "Schema changes", "heading of the notes, followed by the date")
+	changelogCmd.Flags().BoolVar(&changelogIncludeDescriptions, "include-descriptions", false, "also report changed descriptions")
+
+	rootCmd.AddCommand(changelogCmd)
+}
+
+func runChangelog() error {
+	if changelogFormat != "markdown" && changelogFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'markdown' or 'json')", changelogFormat))
+	}
+	if changelogAppend != "" && changelogFormat != "markdown" {
+		return withExitCode(ExitUsage, fmt.Errorf("--append requires --format markdown"))
+	}
+
+	dir := changelogDir
+	if dir == "" {
+		dir = viper.GetString("snapshot.dir")
+	}
+	oldFile := changelogOld
+	switch {
+	case oldFile != "" && changelogSince != "":
+		return withExitCode(ExitUsage, fmt.Errorf("--old and --since cannot be combined"))
+	case changelogSince != "":
+		entry, err := findSnapshot(dir, changelogSince)
+		if err != nil {
+			return err
+		}
+		oldFile = filepath.Join(dir, entry.File)
+		logger.Debug("Using snapshot as baseline", "file", entry.File, "timestamp", entry.Timestamp)
+	case oldFile == "":
+		return withExitCode(ExitUsage, fmt.Errorf("no baseline: use --old or --since"))
+	}
+
+	newFile := changelogNew
+	if newFile == "" && len(configEndpoints()) == 0 && viper.GetString("input") == "" {
+		newFile = filepath.Join(dir, snapshotLatestFile)
+		if _, err := os.Stat(newFile); err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("no schema to compare: use --new, --endpoint or --input"))
+		}
+	}
+
+	entries, err := changelogEntries(oldFile, newFile)
+	if err != nil {
+		return err
+	}
+
+	if changelogFormat == "json" {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		if changelogAppend != "" {
+			logger.Info("No schema changes, leaving the changelog unchanged", "file", changelogAppend)
+			return nil
+		}
+		fmt.Println("No schema changes.")
+		return nil
+	}
+
+	notes := formatChangelog(entries, changelogTitle, time.Now())
+	if changelogAppend == "" {
+		fmt.Print(notes)
+		return nil
+	}
+	if err := appendChangelog(changelogAppend, notes); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error updating %s: %w", changelogAppend, err))
+	}
+	logger.Info("Updated changelog", "file", changelogAppend, "entries", len(entries))
+	return nil
+}
+
+// changelogEntries compares oldFile with newFile, or with the converted schema when newFile is
+// empty. Two introspection results are compared at the GraphQL level, anything else as JSON Schema.
+func changelogEntries(oldFile, newFile string) ([]pkg.ChangelogEntry, error) {
+	oldIsIntrospection, err := isIntrospectionFile(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newIsIntrospection := newFile == ""
+	if newFile != "" {
+		if newIsIntrospection, err = isIntrospectionFile(newFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if oldIsIntrospection && newIsIntrospection {
+		oldIntrospection, err := loadIntrospectionFile(oldFile)
+		if err != nil {
+			return nil, err
+		}
+		var newIntrospection *pkg.IntrospectionQuery
+		if newFile != "" {
+			newIntrospection, err = loadIntrospectionFile(newFile)
+		} else {
+			newIntrospection, err = loadIntrospection()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return pkg.GraphQLChangelog(pkg.DiffIntrospections(*oldIntrospection, *newIntrospection)), nil
+	}
+	if oldIsIntrospection {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("%s is an introspection result but the new schema is not: compare two introspection results or two JSON Schema files", oldFile))
+	}
+
+	oldSchema, err := loadSchema(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newSchema, err := loadSchema(newFile)
+	if err != nil {
+		return nil, err
+	}
+	opts := pkg.DiffOptions{IgnoreDescriptions: !changelogIncludeDescriptions}
+	return pkg.SchemaChangelog(pkg.DiffSchemas(oldSchema, newSchema, opts)), nil
+}
+
+// isIntrospectionFile reports whether path holds an introspection result rather than a JSON Schema
+func isIntrospectionFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	var document struct {
+		Schema json.RawMessage `json:"__schema"`
+		Data   struct {
+			Schema json.RawMessage `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return false, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return document.Schema != nil || document.Data.Schema != nil, nil
+}
+
+// findSnapshot picks the snapshot in dir that since refers to: a file name, a hash prefix, or a
+// date or time, which selects the newest snapshot taken at or before it
+func findSnapshot(dir, since string) (*snapshotEntry, error) {
+	index, err := readSnapshotIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(index.Snapshots) == 0 {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no snapshots in %s", dir))
+	}
+
+	for i := range index.Snapshots {
+		if index.Snapshots[i].File == since {
+			return &index.Snapshots[i], nil
+		}
+	}
+
+	at, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		at, err = time.Parse(time.DateOnly, since)
+	}
+	if err == nil {
+		// The index is oldest first
+		for i := len(index.Snapshots) - 1; i >= 0; i-- {
+			if !index.Snapshots[i].Timestamp.After(at) {
+				return &index.Snapshots[i], nil
+			}
+		}
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no snapshot in %s was taken at or before %s", dir, since))
+	}
+
+	var matches []*snapshotEntry
+	for i := range index.Snapshots {
+		if strings.HasPrefix(index.Snapshots[i].Hash, strings.ToLower(since)) {
+			matches = append(matches, &index.Snapshots[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no snapshot in %s matches --since %s", dir, since))
+	case 1:
+		return matches[0], nil
+	}
+	return nil, withExitCode(ExitUsage, fmt.Errorf("--since %s matches %d snapshots: use a longer hash prefix", since, len(matches)))
+}
+
+// formatChangelog renders entries as a Markdown section headed by title and the date
+func formatChangelog(entries []pkg.ChangelogEntry, title string, date time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s - %s\n", title, date.Format(time.DateOnly))
+	for _, section := range pkg.ChangelogSections {
+		lines := make([]string, 0)
+		for _, entry := range entries {
+			if entry.Section != section {
+				continue
+			}
+			line := "- " + entry.Text
+			if entry.Breaking {
+				line += " (breaking)"
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n%s\n", section, strings.Join(lines, "\n"))
+	}
+	return b.String()
+}
+
+// appendChangelog inserts notes above the first "## " heading of the changelog at path, so the
+// newest notes come first, or at the end when it has none. A missing file is created.
+func appendChangelog(path, notes string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	existing := string(data)
+
+	var updated string
+	switch {
+	case strings.HasPrefix(existing, "## "):
+		updated = notes + "\n" + existing
+	case strings.Contains(existing, "\n## "):
+		i := strings.Index(existing, "\n## ") + 1
+		updated = existing[:i] + notes + "\n" + existing[i:]
+	case strings.TrimSpace(existing) == "":
+		updated = notes
+	default:
+		updated = strings.TrimRight(existing, "\n") + "\n\n" + notes
+	}
+	return writeFileAtomic(path, []byte(updated), 0644)
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const (
+	changelogBeforeSDL = "type Query { user: User }\n\ntype User { id: ID! name: String legacy: String }\n\nenum Role { ADMIN }\n"
+	changelogAfterSDL  = "type Query { user: User }\n\ntype User { id: ID! name: String! legacy: String @deprecated(reason: \"gone soon\") }\n\nenum Role { ADMIN MEMBER }\n"
+)
+
+// introspectionFixture writes the introspection result of an SDL fixture to a file
+func introspectionFixture(t *testing.T, name, sdl string) string {
+	t.Helper()
+	introspection, err := pkg.IntrospectionFromSDL(sdl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(introspection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, name, string(data))
+}
+
+func TestChangelogIntrospections(t *testing.T) {
+	before := introspectionFixture(t, "before.json", changelogBeforeSDL)
+	after := introspectionFixture(t, "after.json", changelogAfterSDL)
+
+	result := runCLI(t, "changelog", "--no-config", "--old", before, "--new", after, "--title", "API")
+	if result.err != nil {
+		t.Fatalf("changelog: %v\n%s", result.err, result.stderr)
+	}
+	want := "## API - " + time.Now().Format(time.DateOnly) + `
+
+### Added
+
+- Enum ` + "`Role`" + ` gained value ` + "`MEMBER`" + `
+
+### Changed
+
+- ` + "`User.name`" + ` is now non-null
+
+### Deprecated
+
+- ` + "`User.legacy`" + ` was deprecated: gone soon
+`
+	if result.stdout != want {
+		t.Errorf("notes:\n%s\nwant:\n%s", result.stdout, want)
+	}
+}
+
+func TestChangelogSchemas(t *testing.T) {
+	dir := t.TempDir()
+	before, after := filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")
+	for path, sdl := range map[string]string{before: changelogBeforeSDL, after: changelogAfterSDL} {
+		input := writeFile(t, "schema.graphql", sdl)
+		if result := runCLI(t, "--no-config", "-i", input, "-o", path); result.err != nil {
+			t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+		}
+	}
+
+	result := runCLI(t, "changelog", "--no-config", "--old", before, "--new", after, "-f", "json")
+	if result.err != nil {
+		t.Fatalf("changelog: %v\n%s", result.err, result.stderr)
+	}
+	var entries []pkg.ChangelogEntry
+	if err := json.Unmarshal([]byte(result.stdout), &entries); err != nil {
+		t.Fatalf("decoding %s: %v", result.stdout, err)
+	}
+	texts := make(map[string]pkg.ChangelogEntry)
+	for _, entry := range entries {
+		texts[entry.Text] = entry
+	}
+	if entry, ok := texts["`User.name` is now required"]; !ok || !entry.Breaking {
+		t.Errorf("missing breaking required change in %+v", entries)
+	}
+	if _, ok := texts["Enum `Role` gained value `MEMBER`"]; !ok {
+		t.Errorf("missing enum change in %+v", entries)
+	}
+
+	// Mixing an introspection result with a JSON Schema is refused
+	introspection := introspectionFixture(t, "before.json", changelogBeforeSDL)
+	result = runCLI(t, "changelog", "--no-config", "--old", introspection, "--new", after)
+	if code := ExitCode(result.err); code != ExitUsage {
+		t.Errorf("mixed inputs: exit code %d (%v), want a usage error", code, result.err)
+	}
+}
+
+func TestChangelogSince(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	for _, sdl := range []string{changelogBeforeSDL, changelogAfterSDL} {
+		input := writeFile(t, "schema.graphql", sdl)
+		if result := runCLI(t, "snapshot", "--no-config", "--dir", dir, "-i", input); result.err != nil {
+			t.Fatalf("snapshot: %v\n%s", result.err, result.stderr)
+		}
+	}
+	index, err := readSnapshotIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Snapshots) != 2 {
+		t.Fatalf("%d snapshots, want 2", len(index.Snapshots))
+	}
+	first := index.Snapshots[0]
+
+	// Without --new the latest snapshot is compared with the one --since selects
+	for _, since := range []string{first.File, first.Hash[:8], first.Timestamp.Format(time.RFC3339Nano)} {
+		result := runCLI(t, "changelog", "--no-config", "--dir", dir, "--since", since)
+		if result.err != nil {
+			t.Fatalf("--since %s: %v\n%s", since, result.err, result.stderr)
+		}
+		if !strings.Contains(result.stdout, "`User.name` is now required (breaking)") {
+			t.Errorf("--since %s:\n%s", since, result.stdout)
+		}
+	}
+
+	for _, since := range []string{"ffffffffffff", "2000-01-01"} {
+		result := runCLI(t, "changelog", "--no-config", "--dir", dir, "--since", since)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("--since %s: exit code %d (%v), want a usage error", since, code, result.err)
+		}
+	}
+}
+
+func TestChangelogAppend(t *testing.T) {
+	before := introspectionFixture(t, "before.json", changelogBeforeSDL)
+	after := introspectionFixture(t, "after.json", changelogAfterSDL)
+	today := time.Now().Format(time.DateOnly)
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{"missing file", "", "## Schema changes - " + today + "\n"},
+		{"above the newest section", "# Changelog\n\nIntro.\n\n## 1.0.0\n\n- First\n", "# Changelog\n\nIntro.\n\n## Schema changes - " + today + "\n"},
+		{"no sections yet", "# Changelog\n", "# Changelog\n\n## Schema changes - " + today + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			result := runCLI(t, "changelog", "--no-config", "--old", before, "--new", after, "--append", path)
+			if result.err != nil {
+				t.Fatalf("changelog: %v\n%s", result.err, result.stderr)
+			}
+			if result.stdout != "" {
+				t.Errorf("--append printed the notes:\n%s", result.stdout)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(data)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("changelog:\n%s\nwant it to start with:\n%s", got, tt.want)
+			}
+			if tt.existing != "" && strings.Contains(tt.existing, "## 1.0.0") && !strings.HasSuffix(got, "\n## 1.0.0\n\n- First\n") {
+				t.Errorf("the earlier section was not kept below the notes:\n%s", got)
+			}
+		})
+	}
+
+	// Unchanged schemas leave the file alone
+	path := writeFile(t, "CHANGELOG.md", "# Changelog\n")
+	if result := runCLI(t, "changelog", "--no-config", "--old", before, "--new", before, "--append", path); result.err != nil {
+		t.Fatal(result.err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Changelog\n" {
+		t.Errorf("unchanged schemas modified the changelog:\n%s", data)
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	compatOld    string
+	compatNew    string
+	compatFailOn string
+	compatRules  []string
+	compatFormat string
+)
+
+var compatCmd = &cobra.Command{
+	Use:   "compat",
+	Short: "Classify schema changes as breaking or non-breaking",
+	Long: `Compare two generated JSON Schema documents and classify each change by severity.
+Removed definitions/properties, narrowed types, newly required properties and
+removed enum values are breaking; additions and relaxations are not.
+
+Severities can be overridden per rule with --rule (e.g. --rule required-added=warning)
+or the compat-rules map in the config file. When --new is omitted it is converted on
+the fly from --endpoint or --input.
+
+Exit code is 0 when no change reaches --fail-on, 7 for breaking changes and 8 for
+warnings (with --fail-on warning); errors use the usual exit codes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompat()
+	},
+}
+
+func init() {
+	compatCmd.Flags().StringVar(&compatOld, "old", "", "previous JSON Schema file")
+	compatCmd.Flags().StringVar(&compatNew, "new", "", "new JSON Schema file (default converts --endpoint or --input)")
+	compatCmd.Flags().StringVar(&compatFailOn, "fail-on", "breaking", "lowest severity that fails the check (breaking or warning)")
+	compatCmd.Flags().StringArrayVar(&compatRules, "rule", []string{}, "override a rule severity (format: 'rule=severity')")
+	compatCmd.Flags().StringVarP(&compatFormat, "format", "f", "text", "output format (text or json)")
+	compatCmd.MarkFlagRequired("old")
+
+	rootCmd.AddCommand(compatCmd)
+}
+
+// resolveCompatRules merges the config file rules with --rule overrides
+func resolveCompatRules() (pkg.CompatRules, error) {
+	rules := pkg.CompatRules{}
+	for rule, severity := range viper.GetStringMapString("compat-rules") {
+		rules[rule] = pkg.Severity(severity)
+	}
+
+	for _, override := range compatRules {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --rule %q (format: 'rule=severity')", override)
+		}
+		rules[strings.TrimSpace(parts[0])] = pkg.Severity(strings.TrimSpace(parts[1]))
+	}
+	return rules, nil
+}
+
+func runCompat() error {
+	failOn := pkg.Severity(compatFailOn)
+	if failOn != pkg.SeverityBreaking && failOn != pkg.SeverityWarning {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --fail-on: %s (must be 'breaking' or 'warning')", compatFailOn))
+	}
+
+	rules, err := resolveCompatRules()
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	oldSchema, err := loadSchema(compatOld)
+	if err != nil {
+		return err
+	}
+	newSchema, err := loadSchema(compatNew)
+	if err != nil {
+		return err
+	}
+
+	findings, err := pkg.ClassifyChanges(pkg.DiffSchemas(oldSchema, newSchema, pkg.DiffOptions{}), rules)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	switch compatFormat {
+	case "text":
+		fmt.Print(formatFindings(findings))
+	case "json":
+		if err := printJSON(findings); err != nil {
+			return err
+		}
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", compatFormat))
+	}
+
+	highest := pkg.HighestSeverity(findings)
+	if highest == "" || !highest.AtLeast(failOn) {
+		return nil
+	}
+	if highest == pkg.SeverityBreaking {
+		return withExitCode(ExitMismatch, fmt.Errorf("breaking changes found"))
+	}
+	return withExitCode(ExitWarning, fmt.Errorf("warnings found"))
+}
+
+// formatFindings renders findings grouped by severity, most severe first
+func formatFindings(findings []pkg.CompatFinding) string {
+	if len(findings) == 0 {
+		return "No changes\n"
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		severity pkg.Severity
+		title    string
+	}{
+		{pkg.SeverityBreaking, "Breaking changes"},
+		{pkg.SeverityWarning, "Warnings"},
+		{pkg.SeverityInfo, "Non-breaking changes"},
+	} {
+		lines := make([]string, 0)
+		for _, finding := range findings {
+			if finding.Severity == group.severity {
+				lines = append(lines, fmt.Sprintf("%s: %s", finding.Rule, formatChange(finding.SchemaChange)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s (%d):\n", group.title, len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,414 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of environment variables that set options, e.g. GRAPHQL2JSON_ENDPOINT
+const envPrefix = "GRAPHQL2JSON"
+
+// envKeyReplacer maps option keys such as serve.listen or id-type to environment variable suffixes
+var envKeyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// boundFlags records the flag bound to each option key so the source of a value can be reported
+var boundFlags = make(map[string]*pflag.Flag)
+
+// bindFlag binds an option key to a flag. Values resolve as explicit flag, then environment,
+// then config file, then the flag default.
+func bindFlag(key string, flag *pflag.Flag) {
+	boundFlags[key] = flag
+	viper.BindPFlag(key, flag)
+}
+
+// envVarName returns the environment variable that sets an option key
+func envVarName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// configSource reports where the resolved value of an option key came from
+func configSource(key string) string {
+	if flag, ok := boundFlags[key]; ok && flag.Changed {
+		return "flag"
+	}
+	if _, ok := os.LookupEnv(envVarName(key)); ok {
+		return "env"
+	}
+	if viper.InConfig(key) {
+		return "config"
+	}
+	if presetDefaults[key] {
+		return "preset"
+	}
+	return "default"
+}
+
+// showConfig prints every resolved option with its source, redacting credentials in headers
+func showConfig(w io.Writer) {
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	if file := viper.ConfigFileUsed(); file != "" && !noConfig {
+		fmt.Fprintf(w, "# config file: %s\n", file)
+	}
+	for _, key := range keys {
+		// Headers written as a config map are flattened into headers.<name> keys; show them as one option
+		if strings.HasPrefix(key, "headers.") {
+			continue
+		}
+		value := viper.Get(key)
+		if key == "headers" {
+			value = redactHeaders()
+		}
+		fmt.Fprintf(w, "%s = %s (%s)\n", key, redactSecrets(fmt.Sprint(value)), configSource(key))
+	}
+}
+
+// redactHeaders returns the configured headers with the values of those carrying credentials replaced
+func redactHeaders() []string {
+	headers, err := parseHeaders(viper.Get("headers"))
+	if err != nil {
+		return []string{"[invalid: " + err.Error() + "]"}
+	}
+	for name, values := range headers {
+		if isSensitiveHeader(name) {
+			for i := range values {
+				values[i] = "[redacted]"
+			}
+		}
+	}
+	return headerLines(headers)
+}
+
+// configSections are config keys read as maps; the keys below them are not flags and are only
+// checked for shape
+var configSections = []string{"profiles", "headers", "compat-rules", "bigquery", "cue", "pydantic", "ui-schema-rules"}
+
+// configChoices lists the values accepted by options that take one of a fixed set
+var configChoices = map[string][]string{
+	"id-type":                   {"string", "number", "both"},
+	"operation":                 {"", "query", "mutation", "subscription"},
+	"root":                      {"query", "mutation", "subscription", "all"},
+	"operations-layout":         {"nested", "flat", "both"},
+	"nullable-fields":           {"off", "typeArray", "anyOf"},
+	"interface-implementations": {"off", "anyOf", "oneOf"},
+	"enum-style":                {"anyOf", "flat"},
+	"field-shape":               {"wrapped", "plain"},
+	"draft":                     {"draft-06", "draft-07", "2020-12"},
+	"log-format":                {"text", "json"},
+	"preset":                    {"", "forms", "llm-tools", "strict-validation", "docs", "hasura", "postgraphile", "shopify-admin"},
+	"target":                    {targetJSONSchema, targetBigQuery, targetCUE, targetResponseEnvelope, targetK8sConfigMap, targetPydantic, targetOpenAPI},
+	"k8s-configmap.format":      {"json", "yaml"},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and edit the config file",
+	Long: `View the resolved configuration and edit the config file without touching its YAML by
+hand. set and unset change the file in effect (see config path), keeping its comments,
+and check values against the option they set before writing:
+
+  gql2jsonschema config set id-type number
+  gql2jsonschema config set profiles.staging.endpoint https://staging.example.com/graphql
+  gql2jsonschema config set root '[query, mutation]'
+  gql2jsonschema config unset profiles.staging`,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print every resolved option with its source, redacting credentials",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		showConfig(os.Stdout)
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file in effect",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			logger.Info("Config file does not exist yet", "path", path)
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Set an option in the config file",
+	Long: `Set an option in the config file. VALUE is read as YAML, so lists can be written as
+'[a, b]'. The file is created if it does not exist.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigSet(args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset KEY",
+	Short: "Remove an option from the config file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigUnset(args[0])
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configFilePath returns the config file viper reads, or the default one when none exists yet
+func configFilePath() (string, error) {
+	if file := viper.ConfigFileUsed(); file != "" {
+		return file, nil
+	}
+	return resolveInitPath("")
+}
+
+func runConfigSet(key, raw string) error {
+	key = strings.ToLower(key)
+	var value yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid value for %s: %w", key, err))
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}
+	if len(value.Content) > 0 {
+		node = value.Content[0]
+	}
+	node, err := checkConfigValue(key, raw, node)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	return editConfigFile(func(doc *yaml.Node) (bool, error) {
+		mapping := doc.Content[0]
+		segments := strings.Split(key, ".")
+		for i, segment := range segments[:len(segments)-1] {
+			child := mappingValue(mapping, segment)
+			if child == nil {
+				child = &yaml.Node{Kind: yaml.MappingNode}
+				mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: segment}, child)
+			}
+			if child.Kind != yaml.MappingNode {
+				return false, withExitCode(ExitUsage, fmt.Errorf("cannot set %s: %s is not a mapping", key, strings.Join(segments[:i+1], ".")))
+			}
+			mapping = child
+		}
+
+		last := segments[len(segments)-1]
+		if existing := mappingValue(mapping, last); existing != nil {
+			// Keep the comments attached to the old value
+			node.HeadComment, node.LineComment, node.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+			*existing = *node
+		} else {
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, node)
+		}
+		logger.Info("Set config option", "key", key)
+		return true, nil
+	})
+}
+
+func runConfigUnset(key string) error {
+	key = strings.ToLower(key)
+	return editConfigFile(func(doc *yaml.Node) (bool, error) {
+		if !removeMappingKey(doc.Content[0], strings.Split(key, ".")) {
+			logger.Info("Config option is not set", "key", key)
+			return false, nil
+		}
+		logger.Info("Removed config option", "key", key)
+		return true, nil
+	})
+}
+
+// checkConfigValue validates a value for key against the flag bound to it and returns the node to
+// write. Keys that are neither flags nor below a config section are rejected.
+func checkConfigValue(key, raw string, node *yaml.Node) (*yaml.Node, error) {
+	segments := strings.Split(key, ".")
+	if segments[0] == "profiles" && len(segments) > 1 {
+		if !validProfileName(segments[1]) {
+			return nil, fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_' and '.'", segments[1])
+		}
+		if len(segments) == 3 && segments[2] == "auth" && raw != authKeyring {
+			return nil, fmt.Errorf("invalid auth for profile %s: %s (must be '%s')", segments[1], raw, authKeyring)
+		}
+	}
+
+	flag, ok := boundFlags[key]
+	if !ok {
+		if slices.Contains(configSections, segments[0]) && len(segments) > 1 {
+			return node, nil
+		}
+		for bound := range boundFlags {
+			if strings.HasPrefix(bound, key+".") {
+				return nil, fmt.Errorf("%s is a section; set one of its options, e.g. %s", key, bound)
+			}
+		}
+		return nil, fmt.Errorf("unknown option: %s", key)
+	}
+
+	values := []string{raw}
+	switch flag.Value.Type() {
+	case "stringArray", "stringSlice":
+		if node.Kind == yaml.SequenceNode {
+			values = make([]string, len(node.Content))
+			for i, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("invalid %s: list items must be plain values", key)
+				}
+				values[i] = item.Value
+			}
+		}
+		var list yaml.Node
+		if err := list.Encode(values); err != nil {
+			return nil, err
+		}
+		node = &list
+	default:
+		if node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("invalid %s: expected a single value", key)
+		}
+	}
+
+	for _, value := range values {
+		var err error
+		var expected string
+		switch flag.Value.Type() {
+		case "bool":
+			_, err = strconv.ParseBool(value)
+			expected = "true or false"
+		case "int":
+			_, err = strconv.Atoi(value)
+			expected = "an integer"
+		case "duration":
+			_, err = time.ParseDuration(value)
+			expected = "a duration such as 30s"
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s (must be %s)", key, value, expected)
+		}
+		if choices, ok := configChoices[key]; ok && !slices.Contains(choices, value) {
+			return nil, fmt.Errorf("invalid %s: %s (must be one of %s)", key, value, strings.Join(choices, ", "))
+		}
+	}
+	if key == "exclude-field" || key == "include-field" {
+		if err := pkg.CheckFieldPatterns(values); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	if key == "exclude-type" || key == "include-type" {
+		if err := pkg.CheckTypePatterns(values); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	if node.Kind == yaml.ScalarNode && flag.Value.Type() == "string" {
+		// Strings such as "true" or "30" stay strings
+		node = &yaml.Node{}
+		if err := node.Encode(raw); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// editConfigFile parses the config file in effect, lets edit change it and writes it back when edit
+// reports a change. A missing file starts out empty.
+func editConfigFile(edit func(doc *yaml.Node) (bool, error)) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if ext := filepath.Ext(path); ext != "" && ext != ".yaml" && ext != ".yml" {
+		return withExitCode(ExitUsage, fmt.Errorf("%s: only YAML config files can be edited", path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return withExitCode(ExitUsage, fmt.Errorf("%s: %w", path, err))
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, HeadComment: doc.HeadComment, FootComment: doc.FootComment}
+	}
+	if len(doc.Content) == 0 || (doc.Content[0].Kind == yaml.ScalarNode && doc.Content[0].Tag == "!!null") {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return withExitCode(ExitUsage, fmt.Errorf("%s: the config file must be a mapping", path))
+	}
+
+	changed, err := edit(&doc)
+	if err != nil || !changed {
+		return err
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	encoder.Close()
+	return replaceOutput(path, b.Bytes())
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeMappingKey removes the key at path, along with the mappings it leaves empty, and reports
+// whether it was there
+func removeMappingKey(mapping *yaml.Node, path []string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if !strings.EqualFold(mapping.Content[i].Value, path[0]) {
+			continue
+		}
+		value := mapping.Content[i+1]
+		if len(path) > 1 {
+			if value.Kind != yaml.MappingNode || !removeMappingKey(value, path[1:]) {
+				return false
+			}
+			if len(value.Content) > 0 {
+				return true
+			}
+		}
+		mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolvedOption returns the "value (source)" line config view prints for key
+func resolvedOption(t *testing.T, output, key string) string {
+	t.Helper()
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(line, key+" = "); ok {
+			return value
+		}
+	}
+	t.Fatalf("config view does not list %s:\n%s", key, output)
+	return ""
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		env    map[string]string
+		args   []string
+		key    string
+		want   string
+	}{
+		{"default", "", nil, nil, "timeout", "30 (default)"},
+		{"config only", "timeout: 45\n", nil, nil, "timeout", "45 (config)"},
+		{"env only", "", map[string]string{"GRAPHQL2JSON_TIMEOUT": "50"}, nil, "timeout", "50 (env)"},
+		{"env beats config", "timeout: 45\n", map[string]string{"GRAPHQL2JSON_TIMEOUT": "50"}, nil, "timeout", "50 (env)"},
+		{"flag beats env and config", "timeout: 45\n", map[string]string{"GRAPHQL2JSON_TIMEOUT": "50"}, []string{"--timeout", "60"}, "timeout", "60 (flag)"},
+		{"flag beats config", "timeout: 45\n", nil, []string{"-t", "60"}, "timeout", "60 (flag)"},
+		{"no-config skips the file", "timeout: 45\n", nil, []string{"--no-config"}, "timeout", "30 (default)"},
+		{"no-config keeps env", "timeout: 45\n", map[string]string{"GRAPHQL2JSON_TIMEOUT": "50"}, []string{"--no-config"}, "timeout", "50 (env)"},
+		{"config overrides a true default", "ignore-internals: false\n", nil, nil, "ignore-internals", "false (config)"},
+		{"env overrides a true default", "", map[string]string{"GRAPHQL2JSON_IGNORE_INTERNALS": "false"}, nil, "ignore-internals", "false (env)"},
+		{"flag default does not beat config", "id-type: number\n", nil, nil, "id-type", "number (config)"},
+		{"nested key from env", "", map[string]string{"GRAPHQL2JSON_SERVE_LISTEN": ":9090"}, nil, "serve.listen", ":9090 (env)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			args := []string{"config", "view"}
+			if tt.config != "" {
+				args = append(args, "--config", writeFile(t, "config.yaml", tt.config))
+			}
+			result := runCLI(t, append(args, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("config view: %v\n%s", result.err, result.stderr)
+			}
+			if got := resolvedOption(t, result.stdout, tt.key); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConfigTimeoutApplies checks that a timeout set only in the config file is the one requests
+// use, which flag defaults used to override
+func TestConfigTimeoutApplies(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := writeFile(t, "config.yaml", "timeout: 1\n")
+	start := time.Now()
+	result := runCLI(t, "--config", config, "-e", server.URL)
+	if result.err == nil {
+		t.Fatal("expected the request to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("request took %s, so the config timeout of 1s was not applied", elapsed)
+	}
+}
+
+func TestShowConfig(t *testing.T) {
+	config := writeFile(t, "config.yaml", "headers:\n  - 'Authorization: Bearer secret-token'\n")
+	result := runCLI(t, "config", "view", "--config", config)
+	if result.err != nil {
+		t.Fatalf("config view: %v", result.err)
+	}
+	if strings.Contains(result.stdout, "secret-token") {
+		t.Errorf("credentials were not redacted:\n%s", result.stdout)
+	}
+	if !strings.Contains(result.stdout, "# config file: "+config) {
+		t.Errorf("config file in effect is not reported:\n%s", result.stdout)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	config := writeFile(t, "config.yaml", "timeout: 45\n")
+	result := runCLI(t, "config", "path", "--config", config)
+	if result.err != nil {
+		t.Fatalf("config path: %v", result.err)
+	}
+	if got := strings.TrimSpace(result.stdout); got != config {
+		t.Errorf("config path = %s, want %s", got, config)
+	}
+
+	result = runCLI(t, "config", "path")
+	if result.err != nil {
+		t.Fatalf("config path: %v", result.err)
+	}
+	if got, want := strings.TrimSpace(result.stdout), filepath.Join(os.Getenv("HOME"), configFileName); got != want {
+		t.Errorf("config path without a config file = %s, want %s", got, want)
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		view  string
+		want  string
+	}{
+		{"choice", "id-type", "number", "id-type", "number (config)"},
+		{"integer", "timeout", "45", "timeout", "45 (config)"},
+		{"list", "root", "[query, mutation]", "root", "[query mutation] (config)"},
+		{"single value for a list", "exclude-type", "Internal*", "exclude-type", "[Internal*] (config)"},
+		{"string that looks like a bool", "operations-key", "true", "operations-key", "true (config)"},
+		{"nested section", "profiles.staging.endpoint", "https://staging.example.com/graphql", "profiles.staging.endpoint", "https://staging.example.com/graphql (config)"},
+		{"case-insensitive key", "ID-Type", "both", "id-type", "both (config)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := writeFile(t, "config.yaml", "")
+			if result := runCLI(t, "config", "set", tt.key, tt.value, "--config", config); result.err != nil {
+				t.Fatalf("config set: %v\n%s", result.err, result.stderr)
+			}
+			result := runCLI(t, "config", "view", "--config", config)
+			if result.err != nil {
+				t.Fatalf("config view: %v", result.err)
+			}
+			if got := resolvedOption(t, result.stdout, tt.view); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.view, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigSetInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{"unknown choice", "id-type", "uuid", "invalid id-type: uuid (must be one of string, number, both)"},
+		{"not an integer", "timeout", "soon", "invalid timeout: soon (must be an integer)"},
+		{"not a bool", "ignore-internals", "maybe", "(must be true or false)"},
+		{"unknown option", "tiemout", "30", "unknown option: tiemout"},
+		{"section", "serve", "x", "serve is a section"},
+		{"invalid profile name", "profiles.stag ing.endpoint", "x", `invalid profile name "stag ing"`},
+		{"invalid auth", "profiles.staging.auth", "plain", "invalid auth for profile staging"},
+		{"invalid list item", "root", "[query, nope]", "invalid root: nope"},
+		{"invalid field pattern", "exclude-field", "email", "invalid exclude-field"},
+		{"mapping for a scalar", "timeout", "{a: 1}", "expected a single value"},
+		{"invalid YAML", "timeout", "[1", "invalid value for timeout"},
+	}
+	const original = "# team defaults\ntimeout: 45\n"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := writeFile(t, "config.yaml", original)
+			result := runCLI(t, "config", "set", tt.key, tt.value, "--config", config)
+			if result.err == nil || !strings.Contains(result.err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, result.err)
+			}
+			if code := ExitCode(result.err); code != ExitUsage {
+				t.Errorf("exit code = %d, want %d", code, ExitUsage)
+			}
+			if data, _ := os.ReadFile(config); string(data) != original {
+				t.Errorf("the config file changed after a rejected value:\n%s", data)
+			}
+		})
+	}
+}
+
+// TestConfigSetUnsetRoundTrip repeats set and unset operations and checks that the file stays valid,
+// keeps its comments and ends up where it started
+func TestConfigSetUnsetRoundTrip(t *testing.T) {
+	const original = `# team defaults
+timeout: 45 # seconds
+# the staging server
+profiles:
+  staging:
+    endpoint: https://staging.example.com/graphql
+`
+	config := writeFile(t, "config.yaml", original)
+	run := func(args ...string) {
+		t.Helper()
+		if result := runCLI(t, append(append([]string{"config"}, args...), "--config", config)...); result.err != nil {
+			t.Fatalf("config %s: %v\n%s", strings.Join(args, " "), result.err, result.stderr)
+		}
+	}
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			t.Fatalf("the config file is no longer valid YAML: %v\n%s", err, data)
+		}
+		return string(data)
+	}
+
+	// The first cycle may normalize the formatting; every later one must leave the file as it was
+	var baseline string
+	for cycle := 0; cycle < 3; cycle++ {
+		run("set", "timeout", "60")
+		run("set", "timeout", "60")
+		run("set", "id-type", "number")
+		run("set", "profiles.prod.endpoint", "https://example.com/graphql")
+		run("set", "root", "[query]")
+		edited := read()
+		if strings.Count(edited, "timeout:") != 1 || strings.Count(edited, "prod:") != 1 {
+			t.Fatalf("cycle %d: repeated sets duplicated keys:\n%s", cycle, edited)
+		}
+		for _, comment := range []string{"# team defaults", "# seconds", "# the staging server"} {
+			if !strings.Contains(edited, comment) {
+				t.Fatalf("cycle %d: comment %q was lost:\n%s", cycle, comment, edited)
+			}
+		}
+
+		run("set", "timeout", "45")
+		run("unset", "id-type")
+		run("unset", "profiles.prod.endpoint")
+		run("unset", "root")
+		run("unset", "root")
+		restored := read()
+		if strings.Contains(restored, "prod") {
+			t.Fatalf("cycle %d: the emptied profile was left behind:\n%s", cycle, restored)
+		}
+		if cycle == 0 {
+			baseline = restored
+		} else if restored != baseline {
+			t.Fatalf("cycle %d changed the file:\n%s\nwant:\n%s", cycle, restored, baseline)
+		}
+	}
+
+	result := runCLI(t, "config", "view", "--config", config)
+	if got := resolvedOption(t, result.stdout, "timeout"); got != "45 (config)" {
+		t.Errorf("timeout = %s after the round trips", got)
+	}
+	if got := resolvedOption(t, result.stdout, "profiles.staging.endpoint"); !strings.HasPrefix(got, "https://staging.example.com/graphql") {
+		t.Errorf("staging endpoint = %s after the round trips", got)
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// maxConfigMapSize is the limit Kubernetes puts on the data of a ConfigMap
+const maxConfigMapSize = 1 << 20
+
+var (
+	configMapName        string
+	configMapNamespace   string
+	configMapKey         string
+	configMapFormat      string
+	configMapLabels      []string
+	configMapAnnotations []string
+	configMapImmutable   bool
+	configMapGzip        bool
+)
+
+// configMapNamePattern is a DNS subdomain name, which ConfigMap names must be
+var configMapNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+func init() {
+	rootCmd.Flags().StringVar(&configMapName, "configmap-name", "graphql-schema", "name of the --target k8s-configmap ConfigMap")
+	rootCmd.Flags().StringVar(&configMapNamespace, "configmap-namespace", "", "namespace of the --target k8s-configmap ConfigMap")
+	rootCmd.Flags().StringVar(&configMapKey, "configmap-key", "", "data key holding the schema (default schema.json or schema.yaml)")
+	rootCmd.Flags().StringVar(&configMapFormat, "configmap-format", "json", "format of the schema in the ConfigMap (json or yaml)")
+	rootCmd.Flags().StringArrayVar(&configMapLabels, "configmap-label", nil, "label of the ConfigMap as key=value (repeatable)")
+	rootCmd.Flags().StringArrayVar(&configMapAnnotations, "configmap-annotation", nil, "annotation of the ConfigMap as key=value (repeatable)")
+	rootCmd.Flags().BoolVar(&configMapImmutable, "configmap-immutable", false, "mark the ConfigMap immutable and suffix its name with a hash of the schema")
+	rootCmd.Flags().BoolVar(&configMapGzip, "configmap-gzip", false, "store the schema gzipped in binaryData, for schemas over the 1 MiB ConfigMap limit")
+	bindFlag("k8s-configmap.name", rootCmd.Flags().Lookup("configmap-name"))
+	bindFlag("k8s-configmap.namespace", rootCmd.Flags().Lookup("configmap-namespace"))
+	bindFlag("k8s-configmap.key", rootCmd.Flags().Lookup("configmap-key"))
+	bindFlag("k8s-configmap.format", rootCmd.Flags().Lookup("configmap-format"))
+	bindFlag("k8s-configmap.labels", rootCmd.Flags().Lookup("configmap-label"))
+	bindFlag("k8s-configmap.annotations", rootCmd.Flags().Lookup("configmap-annotation"))
+	bindFlag("k8s-configmap.immutable", rootCmd.Flags().Lookup("configmap-immutable"))
+	bindFlag("k8s-configmap.gzip", rootCmd.Flags().Lookup("configmap-gzip"))
+}
+
+// configMap is a Kubernetes ConfigMap manifest
+type configMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   configMapMetadata `yaml:"metadata"`
+	Immutable  bool              `yaml:"immutable,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	BinaryData map[string]string `yaml:"binaryData,omitempty"`
+}
+
+type configMapMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// generateConfigMap wraps the generated JSON Schema in a ConfigMap manifest
+func generateConfigMap(introspection *pkg.IntrospectionQuery) ([]byte, error) {
+	name := viper.GetString("k8s-configmap.name")
+	if !configMapNamePattern.MatchString(name) || len(name) > 253 {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --configmap-name %q: must be lowercase letters, digits, '-' and '.'", name))
+	}
+	format := viper.GetString("k8s-configmap.format")
+	if format != "json" && format != "yaml" {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --configmap-format: %s (must be 'json' or 'yaml')", format))
+	}
+	labels, err := parseKeyValues("--configmap-label", viper.GetStringSlice("k8s-configmap.labels"))
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := parseKeyValues("--configmap-annotation", viper.GetStringSlice("k8s-configmap.annotations"))
+	if err != nil {
+		return nil, err
+	}
+
+	_, schema, err := generateSchema(introspection)
+	if err != nil {
+		return nil, err
+	}
+	if format == "yaml" {
+		if schema, err = jsonToYAML(schema); err != nil {
+			return nil, withExitCode(ExitConversion, err)
+		}
+	}
+
+	key := viper.GetString("k8s-configmap.key")
+	if key == "" {
+		key = "schema." + format
+	}
+	manifest := configMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata: configMapMetadata{
+			Name:        name,
+			Namespace:   viper.GetString("k8s-configmap.namespace"),
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+
+	size := len(key) + len(schema)
+	if viper.GetBool("k8s-configmap.gzip") {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		zw.Write(schema)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		key += ".gz"
+		manifest.BinaryData = map[string]string{key: base64.StdEncoding.EncodeToString(compressed.Bytes())}
+		size = len(key) + compressed.Len()
+	} else {
+		manifest.Data = map[string]string{key: string(schema)}
+	}
+	if size > maxConfigMapSize {
+		suggestion := "store it compressed with --configmap-gzip, shrink"
+		if viper.GetBool("k8s-configmap.gzip") {
+			suggestion = "shrink"
+		}
+		return nil, withExitCode(ExitOutput, fmt.Errorf("schema is %s, over the 1 MiB ConfigMap limit: %s it with --dedupe, --prune or --exclude-field, or split it into several ConfigMaps with --select-type",
+			formatBytes(size), suggestion))
+	}
+
+	if viper.GetBool("k8s-configmap.immutable") {
+		sum := sha256.Sum256(schema)
+		manifest.Metadata.Name = name + "-" + hex.EncodeToString(sum[:])[:10]
+		manifest.Immutable = true
+		if len(manifest.Metadata.Name) > 253 {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("--configmap-name %q is too long for the hash suffix", name))
+		}
+	}
+
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("error marshaling ConfigMap: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("error marshaling ConfigMap: %w", err)
+	}
+	return output.Bytes(), nil
+}
+
+// parseKeyValues parses key=value flag values into a map
+func parseKeyValues(flag string, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid %s %q: must be key=value", flag, value))
+		}
+		result[strings.TrimSpace(key)] = v
+	}
+	return result, nil
+}
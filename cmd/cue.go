@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var cuePackage string
+
+// generateCUE exports the types as CUE definitions, limited to --select-type and the types it references
+// when set. Scalar mappings can be extended with the cue.scalar-types config block.
+func generateCUE(introspection *pkg.IntrospectionQuery) ([]byte, error) {
+	opts := pkg.DefaultCUEOptions(pkg.IDTypeMapping(viper.GetString("id-type")))
+	opts.Package = viper.GetString("cue.package")
+	opts.IgnoreInternals = viper.GetBool("ignore-internals")
+
+	// Viper lowercases config keys, so scalar names are matched against the schema case-insensitively
+	scalarTypes := viper.GetStringMapString("cue.scalar-types")
+	for _, t := range introspection.Schema.Types {
+		if expr, ok := scalarTypes[strings.ToLower(t.Name)]; ok && t.Kind == "SCALAR" {
+			opts.ScalarTypes[t.Name] = expr
+		}
+	}
+
+	output, err := pkg.CUESchema(*introspection, viper.GetString("select-type"), opts)
+	if err != nil {
+		return nil, withExitCode(ExitConversion, err)
+	}
+	return output, nil
+}
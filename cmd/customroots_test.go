@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCustomRootTypeNames(t *testing.T) {
+	sdl := `
+schema { query: RootQuery mutation: RootMutation }
+type RootQuery { user(id: ID!): User }
+type RootMutation { rename(id: ID!, name: String!): User }
+type User { id: ID! name: String }
+`
+	input := writeFile(t, "schema.graphql", sdl)
+	tests := []struct {
+		name  string
+		args  []string
+		root  string
+		field string
+	}{
+		{"whole schema", nil, "Query", "user"},
+		{"method", []string{"--method", "rename"}, "Mutation", "rename"},
+		{"operation", []string{"--operation", "mutation"}, "Mutation", "rename"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			schema := readSchema(t, output)
+			if root := schema.Properties[tt.root]; root == nil || root.Properties[tt.field] == nil {
+				t.Errorf("no %s.%s root property: %v", tt.root, tt.field, schema.Properties)
+			}
+			if schema.Definitions["RootQuery"] != nil || schema.Definitions["RootMutation"] != nil {
+				t.Error("the root types leaked into the definitions")
+			}
+			if schema.Definitions["User"] == nil {
+				t.Error("User is missing")
+			}
+		})
+	}
+}
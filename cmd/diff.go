@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat string
+	diffIgnore []string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD [NEW]",
+	Short: "Compare two JSON Schema outputs",
+	Long: `Compare two generated JSON Schema documents and print a grouped report of
+added/removed definitions, added/removed/retyped properties and changed
+required/enum sets. When NEW is omitted it is converted on the fly from
+--endpoint or --input using the same options as the root command.
+
+Exit code is 0 when the schemas are identical and 7 when they differ; errors use
+the usual exit codes.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDiff(args)
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", "text", "output format (text, json or jsonpatch)")
+	diffCmd.Flags().StringSliceVar(&diffIgnore, "ignore", []string{}, "differences to ignore (descriptions)")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(args []string) error {
+	opts := pkg.DiffOptions{}
+	for _, ignore := range diffIgnore {
+		switch ignore {
+		case "descriptions":
+			opts.IgnoreDescriptions = true
+		default:
+			return withExitCode(ExitUsage, fmt.Errorf("invalid --ignore value: %s (must be 'descriptions')", ignore))
+		}
+	}
+
+	oldSchema, err := loadSchema(args[0])
+	if err != nil {
+		return err
+	}
+
+	newFile := ""
+	if len(args) > 1 {
+		newFile = args[1]
+	}
+	newSchema, err := loadSchema(newFile)
+	if err != nil {
+		return err
+	}
+
+	changes := pkg.DiffSchemas(oldSchema, newSchema, opts)
+
+	switch diffFormat {
+	case "text":
+		fmt.Print(formatChanges(changes))
+	case "json":
+		if err := printJSON(changes); err != nil {
+			return err
+		}
+	case "jsonpatch":
+		patch, err := pkg.JSONPatch(oldSchema, newSchema, opts)
+		if err != nil {
+			return err
+		}
+		if err := printJSON(patch); err != nil {
+			return err
+		}
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text', 'json' or 'jsonpatch')", diffFormat))
+	}
+
+	if len(changes) > 0 {
+		return withExitCode(ExitMismatch, fmt.Errorf("schemas differ: %d changes", len(changes)))
+	}
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling output: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+// changeGroups is the order in which change groups are reported
+var changeGroups = []struct {
+	kind     pkg.ChangeKind
+	category pkg.ChangeCategory
+	title    string
+}{
+	{pkg.ChangeAdded, pkg.CategoryDefinition, "Definitions added"},
+	{pkg.ChangeRemoved, pkg.CategoryDefinition, "Definitions removed"},
+	{pkg.ChangeAdded, pkg.CategoryProperty, "Properties added"},
+	{pkg.ChangeRemoved, pkg.CategoryProperty, "Properties removed"},
+	{pkg.ChangeChanged, pkg.CategoryType, "Types changed"},
+	{pkg.ChangeChanged, pkg.CategoryRef, "References changed"},
+	{pkg.ChangeAdded, pkg.CategoryItems, "Array items added"},
+	{pkg.ChangeRemoved, pkg.CategoryItems, "Array items removed"},
+	{pkg.ChangeAdded, pkg.CategoryRequired, "Required added"},
+	{pkg.ChangeRemoved, pkg.CategoryRequired, "Required removed"},
+	{pkg.ChangeAdded, pkg.CategoryEnum, "Enum values added"},
+	{pkg.ChangeRemoved, pkg.CategoryEnum, "Enum values removed"},
+	{pkg.ChangeAdded, pkg.CategoryVariant, "Variants added"},
+	{pkg.ChangeRemoved, pkg.CategoryVariant, "Variants removed"},
+	{pkg.ChangeChanged, pkg.CategoryDefault, "Defaults changed"},
+	{pkg.ChangeChanged, pkg.CategoryDescription, "Descriptions changed"},
+}
+
+// formatChanges renders changes as a grouped, human-readable report
+func formatChanges(changes []pkg.SchemaChange) string {
+	if len(changes) == 0 {
+		return "No differences\n"
+	}
+
+	var b strings.Builder
+	for _, group := range changeGroups {
+		lines := make([]string, 0)
+		for _, change := range changes {
+			if change.Kind == group.kind && change.Category == group.category {
+				lines = append(lines, formatChange(change))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s:\n", group.title)
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+func formatChange(change pkg.SchemaChange) string {
+	path := change.Path
+	if path == "" {
+		path = "/"
+	}
+
+	switch change.Kind {
+	case pkg.ChangeAdded:
+		if change.Category == pkg.CategoryDefinition || change.Category == pkg.CategoryProperty || change.Category == pkg.CategoryItems {
+			return "+ " + path
+		}
+		return fmt.Sprintf("+ %s at %s", change.Name, path)
+	case pkg.ChangeRemoved:
+		if change.Category == pkg.CategoryDefinition || change.Category == pkg.CategoryProperty || change.Category == pkg.CategoryItems {
+			return "- " + path
+		}
+		return fmt.Sprintf("- %s at %s", change.Name, path)
+	}
+
+	if change.Category == pkg.CategoryDescription {
+		return "~ " + path
+	}
+	return fmt.Sprintf("~ %s: %v -> %v", path, change.Old, change.New)
+}
+
+// summarizeChanges renders the number of changes per group
+func summarizeChanges(changes []pkg.SchemaChange) string {
+	if len(changes) == 0 {
+		return "No structural differences\n"
+	}
+
+	var b strings.Builder
+	for _, group := range changeGroups {
+		count := 0
+		for _, change := range changes {
+			if change.Kind == group.kind && change.Category == group.category {
+				count++
+			}
+		}
+		if count > 0 {
+			fmt.Fprintf(&b, "%s: %d\n", group.title, count)
+		}
+	}
+	return b.String()
+}
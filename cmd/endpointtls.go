@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	caCertFile         string
+	clientCertFile     string
+	clientKeyFile      string
+	insecureSkipVerify bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&caCertFile, "ca-cert", "", "PEM file of CA certificates to trust for https:// and wss:// endpoints, in addition to the system ones")
+	rootCmd.PersistentFlags().StringVar(&clientCertFile, "client-cert", "", "PEM client certificate presented to endpoints requiring mutual TLS (needs --client-key)")
+	rootCmd.PersistentFlags().StringVar(&clientKeyFile, "client-key", "", "PEM private key of --client-cert")
+	rootCmd.PersistentFlags().BoolVar(&insecureSkipVerify, "insecure", false, "do not verify the TLS certificates of endpoints")
+	bindFlag("ca-cert", rootCmd.PersistentFlags().Lookup("ca-cert"))
+	bindFlag("client-cert", rootCmd.PersistentFlags().Lookup("client-cert"))
+	bindFlag("client-key", rootCmd.PersistentFlags().Lookup("client-key"))
+	bindFlag("insecure", rootCmd.PersistentFlags().Lookup("insecure"))
+}
+
+// endpointTLSConfig returns the TLS settings of --ca-cert, --client-cert, --client-key and
+// --insecure for connections to endpoints, or nil when none is set
+func endpointTLSConfig() (*tls.Config, error) {
+	caCert := viper.GetString("ca-cert")
+	clientCert, clientKey := viper.GetString("client-cert"), viper.GetString("client-key")
+	insecure := viper.GetBool("insecure")
+	if caCert == "" && clientCert == "" && clientKey == "" && !insecure {
+		return nil, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("error reading --ca-cert: %w", err))
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("--ca-cert %s contains no PEM certificates", caCert))
+		}
+		config.RootCAs = pool
+	}
+	switch {
+	case clientCert != "" && clientKey != "":
+		certificate, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("error loading --client-cert: %w", err))
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	case clientCert != "" || clientKey != "":
+		return nil, withExitCode(ExitUsage, fmt.Errorf("--client-cert and --client-key must be given together"))
+	}
+	return config, nil
+}
+
+// endpointClient returns an HTTP client for endpoints with --timeout and the TLS settings
+func endpointClient() (*http.Client, error) {
+	client := &http.Client{Timeout: time.Duration(viper.GetInt("timeout")) * time.Second}
+	config, err := endpointTLSConfig()
+	if err != nil || config == nil {
+		return client, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	client.Transport = transport
+	return client, nil
+}
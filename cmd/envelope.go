@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var (
+	envelopeOperationFile string
+	envelopeOperationName string
+)
+
+// generateResponseEnvelope exports the schema of complete GraphQL HTTP responses. With
+// --envelope-operation the data is that of the operation; otherwise it is any response data for the
+// root type selected by --operation, the query type by default.
+func generateResponseEnvelope(introspection *pkg.IntrospectionQuery) ([]byte, error) {
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope *pkg.JSONSchema6
+	if operationFile := viper.GetString("response-envelope.operation"); operationFile != "" {
+		source, err := os.ReadFile(operationFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading operation file: %w", err)
+		}
+		doc, err := pkg.ParseDocument(string(source))
+		if err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("%s: %w", operationFile, err))
+		}
+		responseSchema, err := pkg.OperationResponseSchema(*introspection, doc, viper.GetString("response-envelope.operation-name"), opts)
+		if err != nil {
+			return nil, withExitCode(ExitConversion, fmt.Errorf("%s: %w", operationFile, err))
+		}
+		envelope = pkg.ResponseEnvelope(responseSchema.Schema)
+	} else {
+		operation := pkg.OperationQuery
+		if opts.Operation != nil {
+			operation = *opts.Operation
+		}
+		envelope, err = pkg.ResponseEnvelopeSchema(*introspection, operation, opts)
+		if err != nil {
+			return nil, withExitCode(ExitConversion, err)
+		}
+	}
+
+	output, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON Schema: %w", err)
+	}
+	return output, nil
+}
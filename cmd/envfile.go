@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	// envFileVars holds the variables loaded with --env-file; they are not exported to the process environment
+	envFileVars = make(map[string]string)
+
+	// envFileKeyPattern matches valid variable names in env files
+	envFileKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// interpolationPattern matches ${VAR} references in option values
+	interpolationPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// loadEnvFiles reads KEY=VALUE pairs from each file in order, later files overriding earlier ones
+func loadEnvFiles(paths []string) error {
+	for _, path := range paths {
+		vars, err := parseEnvFile(path)
+		if err != nil {
+			return err
+		}
+		for key, value := range vars {
+			envFileVars[key] = value
+		}
+	}
+	return nil
+}
+
+// parseEnvFile parses a dotenv-style file. Blank lines and # comments are skipped, an optional
+// export prefix is allowed and values may be wrapped in single or double quotes.
+func parseEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading env file: %w", err)
+	}
+	defer file.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envFileKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: malformed line, expected KEY=VALUE", path, lineNumber)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+			if value[len(value)-1] != value[0] {
+				return nil, fmt.Errorf("%s:%d: unterminated quoted value", path, lineNumber)
+			}
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading env file: %w", err)
+	}
+	return vars, nil
+}
+
+// lookupVar resolves a variable, preferring the process environment over env files
+func lookupVar(name string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return envFileVars[name]
+}
+
+// interpolate replaces ${VAR} references in s
+func interpolate(s string) string {
+	return interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return lookupVar(interpolationPattern.FindStringSubmatch(match)[1])
+	})
+}
+
+// interpolateOptions expands ${VAR} references in every resolved string option, including headers,
+// endpoints and config file values
+func interpolateOptions() {
+	for _, key := range viper.AllKeys() {
+		// Headers in map form are expanded by parseHeaders, as setting the flattened keys would not affect them
+		if strings.HasPrefix(key, "headers.") {
+			continue
+		}
+		switch value := viper.Get(key).(type) {
+		case string:
+			if strings.Contains(value, "${") {
+				viper.Set(key, interpolate(value))
+			}
+		case []string, []interface{}:
+			values := viper.GetStringSlice(key)
+			changed := false
+			for i, item := range values {
+				if strings.Contains(item, "${") {
+					values[i] = interpolate(item)
+					changed = true
+				}
+			}
+			if changed {
+				viper.Set(key, values)
+			}
+		}
+	}
+}
+
+// redactSecrets masks values loaded from env files, the keyring or --token-command wherever they
+// appear in s
+func redactSecrets(s string) string {
+	for _, value := range envFileVars {
+		if len(value) > 0 {
+			s = strings.ReplaceAll(s, value, "[redacted]")
+		}
+	}
+	for _, value := range credentialSecrets {
+		if len(value) > 0 {
+			s = strings.ReplaceAll(s, value, "[redacted]")
+		}
+	}
+	return s
+}
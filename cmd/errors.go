@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// Process exit codes. 1 is the generic fallback for errors that fit no other category.
+const (
+	ExitOK            = 0
+	ExitGeneric       = 1
+	ExitUsage         = 2 // invalid flags, arguments or options
+	ExitNetwork       = 3 // the endpoint could not be reached or the response could not be read
+	ExitIntrospection = 4 // GraphQL errors, introspection disabled or an unparseable introspection result
+	ExitConversion    = 5 // the introspection could not be converted to JSON Schema
+	ExitOutput        = 6 // the output could not be written
+	ExitMismatch      = 7 // --check, diff, compat, schema-diff or validate found differences, or lint found errors
+	ExitWarning       = 8 // compat found warnings with --fail-on warning, or schema-diff dangerous changes with --fail-on dangerous
+)
+
+// ExitError carries the process exit code for an error returned by a command
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// withExitCode wraps err so that the process exits with code
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// classifyError maps errors that carry no exit code, such as typed errors from pkg, onto the exit code taxonomy
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+
+	var notFoundErr *pkg.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return withExitCode(ExitConversion, err)
+	}
+
+	return err
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitGeneric
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	selectType    string
+	selectPointer string
+	inlineRefs    string
+	inlineOmitDef bool
+
+	extractSchemaFile string
+	extractOutput     string
+)
+
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Extract a self-contained sub-schema by type name or JSON pointer",
+	Long: `Extract a single type or subtree from a generated JSON Schema as a standalone
+document. The selected node becomes the root and every definition it transitively
+references is carried along, or fully inlined with --inline-refs. --inline-refs=N
+limits how deeply definitions are nested into each other, and
+--inline-omit-definitions leaves out the definitions still referenced at cycles and
+the depth limit. The schema is read from --schema, or generated on the fly from
+--endpoint or --input.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExtract()
+	},
+}
+
+func init() {
+	extractCmd.Flags().StringVarP(&extractSchemaFile, "schema", "s", "", "previously generated JSON Schema file")
+	extractCmd.Flags().StringVar(&selectType, "type", "", "definition name to extract")
+	extractCmd.Flags().StringVar(&selectPointer, "pointer", "", "JSON pointer of the subschema to extract")
+	addInlineRefsFlag(extractCmd, "inline referenced definitions instead of carrying them along, nesting them at most depth deep with --inline-refs=depth")
+	extractCmd.Flags().BoolVar(&inlineOmitDef, "inline-omit-definitions", false, "leave out the definitions, so references kept at cycles and the depth limit accept any value (implies --inline-refs)")
+	extractCmd.Flags().StringVarP(&extractOutput, "output", "o", "", "output file for the extracted schema (default is stdout)")
+
+	rootCmd.AddCommand(extractCmd)
+}
+
+// addInlineRefsFlag adds --inline-refs[=depth] to a command. Given without a value it inlines
+// without a depth limit; pflag only takes the depth after an equals sign.
+func addInlineRefsFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringVar(&inlineRefs, "inline-refs", "false", usage)
+	cmd.Flags().Lookup("inline-refs").NoOptDefVal = "true"
+}
+
+// inlineSettings are the --inline-refs flags of the root and extract commands
+type inlineSettings struct {
+	enabled         bool
+	depth           int
+	omitDefinitions bool
+}
+
+// newInlineSettings resolves the --inline-refs flags: true inlines without a depth limit, false
+// not at all, and a number inlines up to that depth, 0 meaning unlimited. Leaving out the
+// definitions implies inlining.
+func newInlineSettings(value string, omitDefinitions bool) (inlineSettings, error) {
+	enabled, depth, err := parseInlineRefs(value)
+	if err != nil {
+		return inlineSettings{}, fmt.Errorf("invalid --inline-refs: %w", err)
+	}
+	return inlineSettings{enabled || omitDefinitions, depth, omitDefinitions}, nil
+}
+
+// parseInlineRefs parses an --inline-refs value. Numbers are read first, so 1 is a depth and not true.
+func parseInlineRefs(value string) (enabled bool, depth int, err error) {
+	if depth, err := strconv.Atoi(value); err == nil {
+		if depth < 0 {
+			return false, 0, fmt.Errorf("the depth must be 0 for unlimited or positive, got %d", depth)
+		}
+		return true, depth, nil
+	}
+	if value == "" {
+		return false, 0, nil
+	}
+	enabled, err = strconv.ParseBool(value)
+	if err != nil {
+		return false, 0, fmt.Errorf("must be true, false or a depth, got %q", value)
+	}
+	return enabled, 0, nil
+}
+
+// applySelection narrows a generated schema to the selected type or pointer, optionally inlining refs
+func applySelection(schema *pkg.JSONSchema6, typeName, pointer string, inline inlineSettings) (*pkg.JSONSchema6, error) {
+	var err error
+	switch {
+	case typeName != "" && pointer != "":
+		return nil, fmt.Errorf("--select-type and --select-pointer are mutually exclusive")
+	case typeName != "":
+		schema, err = pkg.ExtractDefinition(schema, typeName)
+	case pointer != "":
+		schema, err = pkg.ExtractSchema(schema, pointer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if inline.enabled {
+		schema = pkg.InlineDefinitions(schema, inline.depth)
+		if inline.omitDefinitions {
+			pkg.DropDefinitions(schema)
+		}
+	}
+	return schema, nil
+}
+
+func runExtract() error {
+	if selectType == "" && selectPointer == "" {
+		return fmt.Errorf("one of --type or --pointer is required")
+	}
+
+	schema, err := loadSchema(extractSchemaFile)
+	if err != nil {
+		return err
+	}
+
+	inline, err := newInlineSettings(inlineRefs, inlineOmitDef)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	schema, err = applySelection(schema, selectType, selectPointer, inline)
+	if err != nil {
+		return err
+	}
+	pkg.RestoreDraftLayout(schema)
+
+	output, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling JSON Schema: %w", err)
+	}
+	return writeOutput(extractOutput, output)
+}
+
+// selectionFromConfig applies the root command's --select-type, --select-pointer and --inline-refs flags
+func selectionFromConfig(schema *pkg.JSONSchema6) (*pkg.JSONSchema6, error) {
+	inline, err := newInlineSettings(viper.GetString("inline-refs"), viper.GetBool("inline-omit-definitions"))
+	if err != nil {
+		return nil, err
+	}
+	return applySelection(schema, viper.GetString("select-type"), viper.GetString("select-pointer"), inline)
+}
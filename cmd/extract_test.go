@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractCommand(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	output := filepath.Join(t.TempDir(), "user.json")
+
+	result := runCLI(t, "extract", "--no-config", "-i", input, "--type", "User", "-o", output)
+	if result.err != nil {
+		t.Fatalf("extract: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	if schema.Properties["address"] == nil {
+		t.Errorf("extracted schema is not rooted at User: %+v", schema)
+	}
+	for _, name := range []string{"Address", "Role"} {
+		if schema.Definitions[name] == nil {
+			t.Errorf("definition %s was not carried along", name)
+		}
+	}
+	if schema.Definitions["Post"] != nil || schema.Definitions["Unused"] != nil {
+		t.Error("definitions User does not reference were carried along")
+	}
+}
+
+func TestExtractCommandInline(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	output := filepath.Join(t.TempDir(), "address.json")
+
+	result := runCLI(t, "--no-config", "-i", input, "--select-pointer", "/definitions/User/properties/address", "--inline-refs", "-o", output)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	if len(schema.Definitions) != 0 {
+		t.Errorf("inlined schema kept definitions: %v", schema.Definitions)
+	}
+	address := schema.Properties["return"]
+	if address == nil || address.Ref != "" || address.Properties["street"] == nil {
+		t.Errorf("Address was not inlined: %+v", address)
+	}
+}
+
+func TestExtractCommandUnknownType(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	result := runCLI(t, "--no-config", "-i", input, "--select-type", "Adress")
+	if result.err == nil {
+		t.Fatal("expected an error for an unknown type")
+	}
+	if !strings.Contains(result.err.Error(), "did you mean: Address") {
+		t.Errorf("error %q does not suggest Address", result.err)
+	}
+}
+
+func TestParseInlineRefs(t *testing.T) {
+	tests := []struct {
+		value   string
+		enabled bool
+		depth   int
+		wantErr bool
+	}{
+		{"", false, 0, false},
+		{"false", false, 0, false},
+		{"true", true, 0, false},
+		{"0", true, 0, false},
+		{"2", true, 2, false},
+		{"-1", false, 0, true},
+		{"deep", false, 0, true},
+	}
+	for _, tt := range tests {
+		enabled, depth, err := parseInlineRefs(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseInlineRefs(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if enabled != tt.enabled || depth != tt.depth {
+			t.Errorf("parseInlineRefs(%q) = %t, %d, want %t, %d", tt.value, enabled, depth, tt.enabled, tt.depth)
+		}
+	}
+}
+
+const inlineChainSDL = "type Query { a: A }\n\ntype A { b: B }\n\ntype B { c: C }\n\ntype C { x: Int }\n"
+
+func TestInlineRefsDepth(t *testing.T) {
+	input := writeFile(t, "schema.graphql", inlineChainSDL)
+	output := filepath.Join(t.TempDir(), "a.json")
+
+	result := runCLI(t, "--no-config", "-i", input, "--select-type", "A", "--inline-refs=1", "-o", output)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	b := schema.Properties["b"].Properties["return"]
+	if b == nil || b.Ref != "" {
+		t.Fatalf("B was not inlined: %+v", b)
+	}
+	if c := b.Properties["c"].Properties["return"]; c == nil || c.Ref != "#/definitions/C" {
+		t.Errorf("C below the depth limit was not kept as a $ref: %+v", c)
+	}
+	if len(schema.Definitions) != 1 || schema.Definitions["C"] == nil {
+		t.Errorf("definitions = %v, want only C", schema.Definitions)
+	}
+}
+
+func TestInlineRefsFlagValues(t *testing.T) {
+	input := writeFile(t, "schema.graphql", inlineChainSDL)
+	for _, value := range []string{"-1", "deep"} {
+		result := runCLI(t, "--no-config", "-i", input, "--inline-refs="+value)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("--inline-refs=%s: exit code %d, want %d (%v)", value, code, ExitUsage, result.err)
+		}
+		result = runCLI(t, "extract", "--no-config", "-i", input, "--type", "A", "--inline-refs="+value)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("extract --inline-refs=%s: exit code %d, want %d (%v)", value, code, ExitUsage, result.err)
+		}
+	}
+
+	// The preset turns inlining on and the flag turns it back off
+	output := filepath.Join(t.TempDir(), "tools.json")
+	result := runCLI(t, "--no-config", "-i", input, "--preset", "llm-tools", "--inline-refs=false", "-o", output)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	// The preset writes 2020-12, which keeps definitions under $defs
+	if schema := readSchema(t, output); len(schema.Definitions)+len(schema.Defs) == 0 {
+		t.Error("--inline-refs=false did not override the llm-tools preset")
+	}
+}
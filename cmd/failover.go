@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// servingEndpoint is the endpoint that served the last introspection loaded by loadIntrospection
+var servingEndpoint string
+
+// configEndpoints returns the endpoints of --endpoint in order. The flag may be repeated and each
+// value, like the config and environment values, may list several endpoints separated by commas.
+func configEndpoints() []string {
+	endpoints := make([]string, 0)
+	for _, value := range viper.GetStringSlice("endpoint") {
+		for _, endpoint := range strings.Split(value, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
+
+// fetchIntrospectionFailover runs the introspection query against the endpoints in order, moving
+// on to the next one when an endpoint cannot be reached, times out or answers with a server error.
+// Any other error, such as a GraphQL error, is returned at once as every endpoint serves the same
+// schema. It also returns the endpoint that answered.
+func fetchIntrospectionFailover(endpoints []string, headers http.Header, etag string) (*pkg.IntrospectionQuery, string, string, error) {
+	outcomes := make([]string, 0, len(endpoints))
+	var lastErr error
+	for i, endpoint := range endpoints {
+		introspection, newEtag, err := fetchIntrospection(endpoint, headers, etag)
+		var exitErr *ExitError
+		if err == nil || !errors.As(err, &exitErr) || exitErr.Code != ExitNetwork {
+			if err == nil {
+				logger.Debug("Schema served by endpoint", "endpoint", redactSecrets(endpoint), "attempt", i+1)
+			}
+			return introspection, newEtag, endpoint, err
+		}
+
+		lastErr = err
+		outcomes = append(outcomes, fmt.Sprintf("%s: %s", redactSecrets(endpoint), redactSecrets(err.Error())))
+		if i+1 < len(endpoints) {
+			logger.Warn("Endpoint failed, trying the next one", "endpoint", redactSecrets(endpoint), "error", redactSecrets(err.Error()))
+		}
+	}
+
+	if len(endpoints) == 1 {
+		return nil, "", "", lastErr
+	}
+	return nil, "", "", withExitCode(ExitNetwork, fmt.Errorf("all %d endpoints failed:\n  %s", len(endpoints), strings.Join(outcomes, "\n  ")))
+}
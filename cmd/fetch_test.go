@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// introspectionBody builds the JSON response of an endpoint serving a schema of n object types
+func introspectionBody(tb testing.TB, n int) []byte {
+	tb.Helper()
+	var sdl strings.Builder
+	sdl.WriteString("type Query {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sdl, "  item%d(id: ID!): Item%d\n", i, i)
+	}
+	sdl.WriteString("}\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sdl, "\"\"\"Item %d\"\"\"\ntype Item%d {\n  id: ID!\n  name: String\n  tags(first: Int): [String!]!\n  next: Item%d\n}\n", i, i, (i+1)%n)
+	}
+	introspection, err := pkg.IntrospectionFromSDL(sdl.String())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	body, err := json.Marshal(map[string]interface{}{"data": introspection})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return body
+}
+
+// introspectionServer serves body as the answer to every request
+func introspectionServer(tb testing.TB, body []byte) *httptest.Server {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+// BenchmarkFetchIntrospection measures fetching and decoding introspection results of growing size
+// from a local server. With the body streamed into the decoder, B/op grows with the decoded schema
+// only, not with an extra copy of the response.
+func BenchmarkFetchIntrospection(b *testing.B) {
+	for _, types := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("types=%d", types), func(b *testing.B) {
+			body := introspectionBody(b, types)
+			server := introspectionServer(b, body)
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := getIntrospectionFromEndpoint(server.URL, http.Header{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchIntrospection(t *testing.T) {
+	resetCLI(t)
+	server := introspectionServer(t, introspectionBody(t, 10))
+	introspection, err := getIntrospectionFromEndpoint(server.URL, http.Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(introspection.Schema.Types); got < 11 {
+		t.Errorf("decoded %d types, want at least 11", got)
+	}
+}
+
+func TestFetchIntrospectionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+		code int
+	}{
+		{"malformed body keeps an excerpt", `<html>Bad gateway</html>`, `body starts with "<html>Bad gateway</html>"`, ExitIntrospection},
+		{"truncated body", `{"data": {"__schema": {"types": [`, "error parsing response", ExitIntrospection},
+		{"GraphQL error", `{"errors": [{"message": "introspection is disabled"}]}`, "GraphQL error: introspection is disabled", ExitIntrospection},
+		{"no data", `{}`, "no data in response", ExitIntrospection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCLI(t)
+			server := introspectionServer(t, []byte(tt.body))
+			_, err := getIntrospectionFromEndpoint(server.URL, http.Header{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+			if code := ExitCode(err); code != tt.code {
+				t.Errorf("exit code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFieldFilterFlags(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--no-config", "-i", input, "-o", output,
+		"--exclude-field", "User.email", "--exclude-field", "*.role", "--include-field", "Address.city")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+
+	schema := readSchema(t, output)
+	user := schema.Definitions["User"].Properties
+	for _, name := range []string{"email", "role"} {
+		if user[name] != nil {
+			t.Errorf("User.%s should have been excluded", name)
+		}
+	}
+	if user["name"] == nil {
+		t.Error("User.name should have been kept")
+	}
+	if address := schema.Definitions["Address"].Properties; len(address) != 1 || address["city"] == nil {
+		t.Errorf("Address should only keep city, got %d properties", len(address))
+	}
+	if input := schema.Definitions["CreateUserInput"].Properties; input["role"] != nil {
+		t.Error("CreateUserInput.role should have been excluded by *.role")
+	}
+}
+
+func TestFieldFilterFlagInvalid(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	result := runCLI(t, "--no-config", "-i", input, "--exclude-field", "email")
+	if result.err == nil || !strings.Contains(result.err.Error(), `invalid field pattern "email"`) {
+		t.Fatalf("expected an invalid pattern error, got %v", result.err)
+	}
+	if code := ExitCode(result.err); code != ExitUsage {
+		t.Errorf("exit code = %d, want %d", code, ExitUsage)
+	}
+}
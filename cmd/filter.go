@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	filterIncludeTypes      []string
+	filterExcludeTypes      []string
+	filterIncludeFields     []string
+	filterExcludeFields     []string
+	filterExcludeDeprecated bool
+	filterOutput            string
+)
+
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Write a filtered introspection result",
+	Long: `Read the schema from --endpoint, --input or stdin, drop the types and fields the
+filters exclude, and write the remaining introspection result as JSON. Type patterns
+match type names and field patterns are Type.field, both with * wildcards.
+
+The root types and built-in scalars are always kept, and fields, input fields,
+interfaces and possible types that refer to a dropped type are dropped with it, so
+the output can be fed back to every other command.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runFilter()
+	},
+}
+
+func init() {
+	filterCmd.Flags().StringArrayVar(&filterIncludeTypes, "include-type", nil, "keep only types matching a pattern with * wildcards (repeatable)")
+	filterCmd.Flags().StringArrayVar(&filterExcludeTypes, "exclude-type", nil, "drop types matching a pattern with * wildcards (repeatable)")
+	filterCmd.Flags().StringArrayVar(&filterIncludeFields, "include-field", nil, "keep only matching fields of the types a Type.field pattern names (repeatable)")
+	filterCmd.Flags().StringArrayVar(&filterExcludeFields, "exclude-field", nil, "drop fields matching a Type.field pattern with * wildcards (repeatable)")
+	filterCmd.Flags().BoolVar(&filterExcludeDeprecated, "exclude-deprecated", false, "drop deprecated fields and enum values")
+	filterCmd.Flags().StringVarP(&filterOutput, "output", "o", "", "output file for the introspection result (default is stdout)")
+
+	bindFlag("filter.include-type", filterCmd.Flags().Lookup("include-type"))
+	bindFlag("filter.exclude-type", filterCmd.Flags().Lookup("exclude-type"))
+	bindFlag("filter.include-field", filterCmd.Flags().Lookup("include-field"))
+	bindFlag("filter.exclude-field", filterCmd.Flags().Lookup("exclude-field"))
+	bindFlag("filter.exclude-deprecated", filterCmd.Flags().Lookup("exclude-deprecated"))
+
+	rootCmd.AddCommand(filterCmd)
+}
+
+func runFilter() error {
+	opts := pkg.DefaultFilterOptions()
+	opts.IgnoreInternals = viper.GetBool("ignore-internals")
+	opts.ExcludeDeprecated = viper.GetBool("filter.exclude-deprecated")
+	opts.IncludeTypes = viper.GetStringSlice("filter.include-type")
+	opts.ExcludeTypes = viper.GetStringSlice("filter.exclude-type")
+	opts.IncludeFields = viper.GetStringSlice("filter.include-field")
+	opts.ExcludeFields = viper.GetStringSlice("filter.exclude-field")
+	if err := opts.Validate(); err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+
+	filtered := pkg.FilterIntrospection(*introspection, opts)
+	logger.Info("Introspection filtered", "types", len(filtered.Schema.Types), "dropped", len(introspection.Schema.Types)-len(filtered.Schema.Types))
+
+	output, err := json.MarshalIndent(filtered, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling introspection: %w", err)
+	}
+	return writeOutput(filterOutput, output)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+func TestFilterCommand(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	filtered := filepath.Join(t.TempDir(), "filtered.json")
+
+	result := runCLI(t, "filter", "--no-config", "-i", input, "-o", filtered,
+		"--exclude-type", "Unused", "--exclude-type", "Post", "--exclude-field", "User.email")
+	if result.err != nil {
+		t.Fatalf("filter: %v\n%s", result.err, result.stderr)
+	}
+	data, err := os.ReadFile(filtered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	introspection, err := pkg.DecodeIntrospection(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("the output is not an introspection result: %v", err)
+	}
+	for _, typ := range introspection.Schema.Types {
+		if typ.Name == "Unused" || typ.Name == "Post" || strings.HasPrefix(typ.Name, "__") {
+			t.Errorf("type %s was kept", typ.Name)
+		}
+	}
+
+	// The filtered introspection is input to every other command
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result = runCLI(t, "--no-config", "-i", filtered, "-o", output)
+	if result.err != nil {
+		t.Fatalf("convert the filtered introspection: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	if schema.Definitions["Post"] != nil || schema.Definitions["User"].Properties["email"] != nil {
+		t.Error("the converted schema still has filtered types or fields")
+	}
+	if search := schema.Definitions["SearchResult"]; search == nil || len(search.OneOf) != 1 {
+		t.Errorf("SearchResult should keep User as its only member: %+v", search)
+	}
+}
+
+func TestFilterCommandInvalidPattern(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	result := runCLI(t, "filter", "--no-config", "-i", input, "--exclude-field", "email")
+	if code := ExitCode(result.err); result.err == nil || code != ExitUsage {
+		t.Fatalf("expected a usage error, got %v (exit code %d)", result.err, code)
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// gcsStore reads and writes Google Cloud Storage objects with the JSON API, authorized with
+// Application Default Credentials. STORAGE_EMULATOR_HOST points it at an emulator such as
+// fake-gcs-server, which takes no credentials.
+type gcsStore struct{}
+
+// gcsScope is the OAuth scope requested for service account and metadata server tokens
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+func (s *gcsStore) open(bucket, key string) (io.ReadCloser, error) {
+	resp, err := s.do("GET", "/storage/v1/b/"+url.PathEscape(bucket)+"/o/"+url.PathEscape(key)+"?alt=media", bucket, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *gcsStore) exists(bucket, key string) (bool, error) {
+	resp, err := s.do("GET", "/storage/v1/b/"+url.PathEscape(bucket)+"/o/"+url.PathEscape(key), bucket, key, nil, "")
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// put streams body to the object in a single media upload, which GCS only turns into the object
+// once the whole body has arrived
+func (s *gcsStore) put(bucket, key string, body io.Reader, contentType string) error {
+	path := "/upload/storage/v1/b/" + url.PathEscape(bucket) + "/o?uploadType=media&name=" + url.QueryEscape(key)
+	resp, err := s.do("POST", path, bucket, key, body, contentType)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends an authorized request to the JSON API and returns the successful response
+func (s *gcsStore) do(method, path, bucket, key string, body io.Reader, contentType string) (*http.Response, error) {
+	objectURL := "gs://" + bucket + "/" + key
+	base := "https://storage.googleapis.com"
+	var token *oauth2.Token
+	if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+		base = strings.TrimSuffix(emulator, "/")
+		if !strings.Contains(base, "://") {
+			base = "http://" + base
+		}
+	} else {
+		var err error
+		token, err = gcsDefaultToken()
+		if err != nil {
+			return nil, objectCredentialsError(objectURL, err)
+		}
+		credentialSecrets = append(credentialSecrets, token.AccessToken)
+	}
+
+	req, err := http.NewRequest(method, base+path, body)
+	if err != nil {
+		return nil, objectRequestError(objectURL, err)
+	}
+	if token != nil {
+		token.SetAuthHeader(req)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := objectClient().Do(req)
+	if err != nil {
+		return nil, objectRequestError(objectURL, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, objectResponseError(objectURL, resp, gcsErrorMessage)
+}
+
+// gcsErrorMessage extracts the message of a JSON API error response
+func gcsErrorMessage(body []byte) string {
+	var gcsErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &gcsErr) != nil {
+		return ""
+	}
+	return gcsErr.Error.Message
+}
+
+// gcsDefaultToken returns GOOGLE_OAUTH_ACCESS_TOKEN or an access token of the Application Default
+// Credentials: the GOOGLE_APPLICATION_CREDENTIALS file, the file written by gcloud auth
+// application-default login, and finally the metadata server on Google Cloud
+func gcsDefaultToken() (*oauth2.Token, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return &oauth2.Token{AccessToken: token, TokenType: "Bearer"}, nil
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, objectClient())
+	credentials, err := google.FindDefaultCredentials(ctx, gcsScope)
+	if err != nil {
+		return nil, fmt.Errorf("no Google credentials found: %w", err)
+	}
+	token, err := credentials.TokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error requesting Google access token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	genQueryRoot   string
+	genQueryField  string
+	genQueryDepth  int
+	genQueryOutput string
+)
+
+var genQueryCmd = &cobra.Command{
+	Use:   "gen-query",
+	Short: "Generate a GraphQL query selecting every reachable field of a root field",
+	Long: `Generate a GraphQL query document that selects every reachable field of a
+root field up to a depth limit. Unions and interfaces are expanded with inline
+fragments and required arguments are declared as variables.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenQuery()
+	},
+}
+
+func init() {
+	genQueryCmd.Flags().StringVarP(&genQueryRoot, "root", "r", "query", "root operation (query, mutation or subscription) or root type name")
+	genQueryCmd.Flags().StringVarP(&genQueryField, "field", "f", "", "root field to generate the query for")
+	genQueryCmd.Flags().IntVarP(&genQueryDepth, "depth", "d", 3, "maximum selection depth")
+	genQueryCmd.Flags().StringVarP(&genQueryOutput, "output", "o", "", "output file for the query document (default is stdout)")
+	genQueryCmd.MarkFlagRequired("field")
+
+	rootCmd.AddCommand(genQueryCmd)
+}
+
+func runGenQuery() error {
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+
+	query, err := pkg.GenerateQuery(*introspection, genQueryRoot, genQueryField, genQueryDepth)
+	if err != nil {
+		return fmt.Errorf("error generating query: %w", err)
+	}
+
+	if genQueryOutput == "" {
+		fmt.Print(query)
+		return nil
+	}
+
+	return writeOutput(genQueryOutput, []byte(query))
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	goHints     bool
+	goHintsFile string
+)
+
+func init() {
+	rootCmd.Flags().BoolVar(&goHints, "go-hints", false, "annotate definitions and scalar usages with x-go-type, x-go-type-import and x-go-name hints for Go code generators")
+	rootCmd.Flags().StringVar(&goHintsFile, "go-hints-file", "", "YAML or JSON file mapping type names to Go hints ({type, import, name}), overriding the built-in table; implies --go-hints")
+	bindFlag("go-hints", rootCmd.Flags().Lookup("go-hints"))
+	bindFlag("go-hints-file", rootCmd.Flags().Lookup("go-hints-file"))
+}
+
+// goHintsFromConfig returns the Go type hints to emit: the built-in table with the entries of
+// --go-hints-file on top, or nil when hints are off. An entry without type, import and name removes
+// the type's built-in hint.
+func goHintsFromConfig() (map[string]pkg.GoTypeHint, error) {
+	path := viper.GetString("go-hints-file")
+	if !viper.GetBool("go-hints") && path == "" {
+		return nil, nil
+	}
+	hints := pkg.DefaultGoHints()
+	if path == "" {
+		return hints, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("error reading Go hints: %w", err))
+	}
+	var overrides map[string]pkg.GoTypeHint
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("%s: must map type names to {type, import, name}: %w", path, err))
+	}
+	for name, hint := range overrides {
+		if hint == (pkg.GoTypeHint{}) {
+			delete(hints, name)
+			continue
+		}
+		hints[name] = hint
+	}
+	return hints, nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGoHintsFlags(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL+"\nscalar DateTime\n\ntype Event { at: DateTime! id: ID }\n")
+	overrides := writeFile(t, "go-hints.yaml", "DateTime:\n  type: civil.DateTime\n  import: cloud.google.com/go/civil\nUser:\n  name: Account\nID: {}\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		dateTime string
+		id       string
+		user     string
+	}{
+		{"off by default", nil, "", "", ""},
+		{"built-in table", []string{"--go-hints"}, "time.Time", "ID", ""},
+		{"hints file", []string{"--go-hints-file", overrides}, "civil.DateTime", "", "Account"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			schema := readSchema(t, output)
+			at := schema.Definitions["Event"].Properties["at"].Properties["return"]
+			if at.GoType != tt.dateTime || schema.Definitions["DateTime"].GoType != tt.dateTime {
+				t.Errorf("DateTime x-go-type: usage %q, definition %q; want %q", at.GoType, schema.Definitions["DateTime"].GoType, tt.dateTime)
+			}
+			if id := schema.Definitions["Event"].Properties["id"].Properties["return"]; id.GoName != tt.id {
+				t.Errorf("ID x-go-name = %q, want %q", id.GoName, tt.id)
+			}
+			if user := schema.Definitions["User"]; user.GoName != tt.user {
+				t.Errorf("User x-go-name = %q, want %q", user.GoName, tt.user)
+			}
+		})
+	}
+}
+
+func TestGoHintsFileInvalid(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	for _, path := range []string{
+		filepath.Join(t.TempDir(), "missing.yaml"),
+		writeFile(t, "go-hints.yaml", "- time.Time\n"),
+	} {
+		result := runCLI(t, "--no-config", "-i", input, "--go-hints-file", path)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("%s: exit code %d (%v), want a usage error", path, code, result.err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// graphqlTransportWS is the WebSocket subprotocol of GraphQL over WebSocket, as implemented by the
+// graphql-ws library
+const graphqlTransportWS = "graphql-transport-ws"
+
+// introspectionOperationID identifies the introspection operation on a WebSocket connection
+const introspectionOperationID = "1"
+
+// wsCloseUnauthorized is the close code graphql-transport-ws servers reject connection_init with
+// when its credentials are missing or invalid
+const wsCloseUnauthorized = 4401
+
+// wsMessage is a graphql-transport-ws protocol message
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// fetchIntrospectionWebSocket runs the introspection query over a ws:// or wss:// endpoint with the
+// graphql-transport-ws protocol. The headers are sent with the upgrade request and as the
+// connection_init payload, where servers read connection params such as credentials from.
+// Failures to connect or to be accepted exit with ExitNetwork, GraphQL errors with
+// ExitIntrospection.
+func fetchIntrospectionWebSocket(endpoint string, headers http.Header) (*pkg.IntrospectionQuery, error) {
+	if viper.GetString("record") != "" {
+		logger.Warn("Sessions are not recorded for WebSocket endpoints", "endpoint", redactSecrets(endpoint))
+	}
+
+	config, err := endpointTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(time.Duration(viper.GetInt("timeout")) * time.Second)
+	conn, err := dialWebSocket(endpoint, headers, graphqlTransportWS, config, deadline)
+	if err != nil {
+		var handshakeErr *wsHandshakeError
+		if errors.As(err, &handshakeErr) {
+			if handshakeErr.StatusCode == http.StatusUnauthorized && viper.GetString("token-command") != "" {
+				return nil, errUnauthorized
+			}
+			return nil, withExitCode(ExitNetwork, err)
+		}
+		return nil, withExitCode(ExitNetwork, fmt.Errorf("error connecting: %w", err))
+	}
+	defer closeWebSocket(conn)
+
+	params := make(map[string]string, len(headers))
+	for name, values := range headers {
+		params[name] = strings.Join(values, ", ")
+	}
+	if err := writeWSMessage(conn, wsMessage{Type: "connection_init", Payload: rawJSON(params)}); err != nil {
+		return nil, withExitCode(ExitNetwork, fmt.Errorf("error sending connection_init: %w", err))
+	}
+
+	// Handshake: the server acknowledges connection_init or closes the connection to reject it
+	for acknowledged := false; !acknowledged; {
+		msg, err := readWSMessage(conn)
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				if closeErr.Code == wsCloseUnauthorized && viper.GetString("token-command") != "" {
+					return nil, errUnauthorized
+				}
+				return nil, withExitCode(ExitNetwork, fmt.Errorf("connection_init rejected: %w", err))
+			}
+			return nil, withExitCode(ExitNetwork, fmt.Errorf("error waiting for connection_ack: %w", err))
+		}
+		switch msg.Type {
+		case "connection_ack":
+			acknowledged = true
+		case "ping":
+			if err := writeWSMessage(conn, wsMessage{Type: "pong"}); err != nil {
+				return nil, withExitCode(ExitNetwork, err)
+			}
+		case "pong":
+		default:
+			return nil, withExitCode(ExitNetwork, fmt.Errorf("protocol error: expected connection_ack, got %q", msg.Type))
+		}
+	}
+
+	subscribe := wsMessage{
+		ID:   introspectionOperationID,
+		Type: "subscribe",
+		Payload: rawJSON(map[string]string{
+			"query":         introspectionQuery(),
+			"operationName": "IntrospectionQuery",
+		}),
+	}
+	if err := writeWSMessage(conn, subscribe); err != nil {
+		return nil, withExitCode(ExitNetwork, fmt.Errorf("error sending subscribe: %w", err))
+	}
+
+	var result json.RawMessage
+	for {
+		msg, err := readWSMessage(conn)
+		if err != nil {
+			return nil, withExitCode(ExitNetwork, fmt.Errorf("error reading introspection result: %w", err))
+		}
+		switch msg.Type {
+		case "next":
+			if msg.ID == introspectionOperationID {
+				result = msg.Payload
+			}
+		case "error":
+			if msg.ID != introspectionOperationID {
+				continue
+			}
+			// The payload of an error message is a list of GraphQL errors
+			var graphqlErrors []struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(msg.Payload, &graphqlErrors); err != nil || len(graphqlErrors) == 0 {
+				return nil, withExitCode(ExitIntrospection, fmt.Errorf("GraphQL error: %s", msg.Payload))
+			}
+			return nil, withExitCode(ExitIntrospection, fmt.Errorf("GraphQL error: %s", graphqlErrors[0].Message))
+		case "complete":
+			if msg.ID != introspectionOperationID {
+				continue
+			}
+			if result == nil {
+				return nil, withExitCode(ExitIntrospection, fmt.Errorf("no data in response"))
+			}
+			return decodeIntrospectionBody(bytes.NewReader(result))
+		case "ping":
+			if err := writeWSMessage(conn, wsMessage{Type: "pong"}); err != nil {
+				return nil, withExitCode(ExitNetwork, err)
+			}
+		case "pong":
+		default:
+			return nil, withExitCode(ExitNetwork, fmt.Errorf("protocol error: unexpected %q message", msg.Type))
+		}
+	}
+}
+
+func writeWSMessage(conn *websocket.Conn, msg wsMessage) error {
+	return conn.WriteMessage(websocket.TextMessage, rawJSON(msg))
+}
+
+func readWSMessage(conn *websocket.Conn) (wsMessage, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return wsMessage{}, err
+	}
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return wsMessage{}, fmt.Errorf("protocol error: invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+// rawJSON encodes protocol values, which are string maps and messages that always marshal
+func rawJSON(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// graphqlWSServer is a graphql-transport-ws server answering the introspection subscription. It
+// accepts connection_init only with the Authorization connection param token, closing the connection
+// with 4401 otherwise, and answers with errors instead of data when errors is set.
+type graphqlWSServer struct {
+	token  string
+	errors string
+	body   []byte
+}
+
+func (s *graphqlWSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{graphqlTransportWS}}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var init struct {
+		Type    string            `json:"type"`
+		Payload map[string]string `json:"payload"`
+	}
+	if err := conn.ReadJSON(&init); err != nil || init.Type != "connection_init" {
+		return
+	}
+	if init.Payload["Authorization"] != "Bearer "+s.token {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(wsCloseUnauthorized, "Unauthorized"))
+		return
+	}
+	// A ping before the acknowledgement has to be answered before the handshake completes
+	conn.WriteJSON(wsMessage{Type: "ping"})
+	conn.WriteJSON(wsMessage{Type: "connection_ack"})
+
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Type != "subscribe" {
+			continue
+		}
+		var payload struct {
+			Query string `json:"query"`
+		}
+		json.Unmarshal(msg.Payload, &payload)
+		if !strings.Contains(payload.Query, "__schema") {
+			conn.WriteJSON(wsMessage{ID: msg.ID, Type: "error", Payload: json.RawMessage(`[{"message": "not an introspection query"}]`)})
+			return
+		}
+		if s.errors != "" {
+			conn.WriteJSON(wsMessage{ID: msg.ID, Type: "error", Payload: json.RawMessage(s.errors)})
+			return
+		}
+		conn.WriteJSON(wsMessage{ID: msg.ID, Type: "next", Payload: s.body})
+		conn.WriteJSON(wsMessage{ID: msg.ID, Type: "complete"})
+	}
+}
+
+// startGraphQLWS serves a graphql-transport-ws server for the test, over TLS when secure is set, and
+// returns it with its ws:// or wss:// URL
+func startGraphQLWS(t *testing.T, handler http.Handler, secure bool) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(handler)
+	// Failed TLS handshakes are expected, and logged by the server
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	if secure {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestWebSocketIntrospection(t *testing.T) {
+	_, endpoint := startGraphQLWS(t, &graphqlWSServer{token: "tok-123", body: introspectionBody(t, 3)}, false)
+	output := filepath.Join(t.TempDir(), "schema.json")
+
+	result := runCLI(t, "--no-config", "-e", endpoint, "-H", "Authorization: Bearer tok-123", "-o", output)
+	if result.err != nil {
+		t.Fatalf("introspection over WebSocket: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	if schema.Definitions["Item0"] == nil || schema.Properties["Query"] == nil {
+		t.Errorf("schema was not converted from the WebSocket result: %+v", schema)
+	}
+}
+
+func TestWebSocketIntrospectionErrors(t *testing.T) {
+	refuse := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	tests := []struct {
+		name    string
+		handler http.Handler
+		code    int
+		want    string
+	}{
+		{"connection_init rejected", &graphqlWSServer{token: "other", body: introspectionBody(t, 1)}, ExitNetwork, "connection_init rejected: websocket: close 4401: Unauthorized"},
+		{"upgrade refused", refuse, ExitNetwork, "WebSocket handshake failed: 403 Forbidden"},
+		{"GraphQL error", &graphqlWSServer{token: "tok-123", errors: `[{"message": "introspection is disabled"}]`}, ExitIntrospection, "GraphQL error: introspection is disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, endpoint := startGraphQLWS(t, tt.handler, false)
+			result := runCLI(t, "--no-config", "-e", endpoint, "-H", "Authorization: Bearer tok-123")
+			if code := ExitCode(result.err); code != tt.code {
+				t.Errorf("exit code %d, want %d (%v)", code, tt.code, result.err)
+			}
+			if result.err == nil || !strings.Contains(result.err.Error(), tt.want) {
+				t.Errorf("error %v does not contain %q", result.err, tt.want)
+			}
+		})
+	}
+}
+
+// writeCertificatePEM writes the certificate of a TLS test server to a PEM file
+func writeCertificatePEM(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	return writeFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key to PEM files
+func writeClientCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gql2jsonschema test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = writeFile(t, "client.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	keyFile = writeFile(t, "client-key.pem", string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	return certFile, keyFile
+}
+
+func TestWebSocketIntrospectionTLS(t *testing.T) {
+	server, endpoint := startGraphQLWS(t, &graphqlWSServer{token: "tok-123", body: introspectionBody(t, 1)}, true)
+	ca := writeCertificatePEM(t, server)
+	args := []string{"--no-config", "-e", endpoint, "-H", "Authorization: Bearer tok-123"}
+
+	result := runCLI(t, args...)
+	if code := ExitCode(result.err); code != ExitNetwork || !strings.Contains(result.err.Error(), "certificate") {
+		t.Errorf("untrusted certificate: exit code %d, want %d (%v)", code, ExitNetwork, result.err)
+	}
+	for _, flags := range [][]string{{"--ca-cert", ca}, {"--insecure"}} {
+		if result := runCLI(t, append(args, flags...)...); result.err != nil {
+			t.Errorf("%v: %v\n%s", flags, result.err, result.stderr)
+		}
+	}
+}
+
+func TestEndpointMutualTLS(t *testing.T) {
+	certFile, keyFile := writeClientCertificate(t)
+	requireClientCert := func(handler http.Handler) *httptest.Server {
+		server := httptest.NewUnstartedServer(handler)
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.Config.ErrorLog = log.New(io.Discard, "", 0)
+		server.StartTLS()
+		t.Cleanup(server.Close)
+		return server
+	}
+	body := introspectionBody(t, 1)
+	https := requireClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(body) }))
+	wss := requireClientCert(&graphqlWSServer{token: "tok-123", body: body})
+
+	for _, server := range []*httptest.Server{https, wss} {
+		endpoint := server.URL
+		if server == wss {
+			endpoint = "wss" + strings.TrimPrefix(server.URL, "https")
+		}
+		t.Run(endpoint[:strings.Index(endpoint, ":")], func(t *testing.T) {
+			args := []string{"--no-config", "-e", endpoint, "-H", "Authorization: Bearer tok-123", "--ca-cert", writeCertificatePEM(t, server)}
+			if result := runCLI(t, args...); ExitCode(result.err) != ExitNetwork {
+				t.Errorf("without a client certificate: %v", result.err)
+			}
+			if result := runCLI(t, append(args, "--client-cert", certFile, "--client-key", keyFile)...); result.err != nil {
+				t.Errorf("with a client certificate: %v\n%s", result.err, result.stderr)
+			}
+			if result := runCLI(t, append(args, "--client-cert", certFile)...); ExitCode(result.err) != ExitUsage {
+				t.Errorf("--client-cert without --client-key: %v", result.err)
+			}
+		})
+	}
+}
+
+func TestEndpointTLSConfigErrors(t *testing.T) {
+	notPEM := writeFile(t, "ca.pem", "not a certificate")
+	for _, args := range [][]string{
+		{"--ca-cert", filepath.Join(t.TempDir(), "missing.pem")},
+		{"--ca-cert", notPEM},
+		{"--client-key", notPEM},
+		{"--client-cert", notPEM, "--client-key", notPEM},
+	} {
+		result := runCLI(t, append([]string{"--no-config", "-e", "https://graphql.invalid/graphql"}, args...)...)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("%v: exit code %d, want %d (%v)", args, code, ExitUsage, result.err)
+		}
+	}
+}
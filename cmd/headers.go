@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// configHeaders returns the HTTP headers from --header, GRAPHQL2JSON_HEADERS or the headers config key
+func configHeaders() (http.Header, error) {
+	headers, err := parseHeaders(viper.Get("headers"))
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	return headers, nil
+}
+
+// parseHeaders accepts every form headers can arrive in:
+//
+//   - a list of "Name: Value" strings, from repeated --header flags or a YAML list
+//   - a map of names to a value or a list of values, from a YAML map
+//   - a single string of "Name: Value" lines, from the environment
+//
+// Values may contain colons and commas. Repeated names keep every value. ${VAR} references in the
+// map form are expanded here; the other forms are expanded with the rest of the options.
+func parseHeaders(value interface{}) (http.Header, error) {
+	headers := make(http.Header)
+
+	switch v := value.(type) {
+	case nil:
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if err := addHeaderLine(headers, line); err != nil {
+				return nil, err
+			}
+		}
+	case []string:
+		for _, line := range v {
+			if err := addHeaderLine(headers, line); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			line, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid header %v: expected \"Name: Value\"", item)
+			}
+			if err := addHeaderLine(headers, line); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for name, values := range v {
+			if err := addHeaderValues(headers, name, values); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]string:
+		for name, value := range v {
+			if err := addHeader(headers, name, interpolate(value)); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid headers: expected a list of \"Name: Value\" strings or a map, got %T", value)
+	}
+
+	return headers, nil
+}
+
+func addHeaderLine(headers http.Header, line string) error {
+	name, value, found := strings.Cut(line, ":")
+	if !found {
+		return fmt.Errorf("invalid header %q: expected \"Name: Value\"", line)
+	}
+	return addHeader(headers, name, value)
+}
+
+func addHeaderValues(headers http.Header, name string, values interface{}) error {
+	switch v := values.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := addHeader(headers, name, interpolate(fmt.Sprint(item))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []string:
+		for _, item := range v {
+			if err := addHeader(headers, name, interpolate(item)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nil:
+		return addHeader(headers, name, "")
+	default:
+		return addHeader(headers, name, interpolate(fmt.Sprint(v)))
+	}
+}
+
+func addHeader(headers http.Header, name, value string) error {
+	name = strings.TrimSpace(name)
+	if !validHeaderName(name) {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("invalid value for header %s: line breaks are not allowed", name)
+	}
+	headers.Add(name, value)
+	return nil
+}
+
+// validHeaderName reports whether name is a valid HTTP field name (an RFC 7230 token)
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// headerLines renders headers as sorted "Name: Value" lines, one per value
+func headerLines(headers http.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(headers))
+	for _, name := range names {
+		for _, value := range headers[name] {
+			lines = append(lines, name+": "+value)
+		}
+	}
+	return lines
+}
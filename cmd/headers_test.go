@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseHeaders(t *testing.T) {
+	t.Setenv("HEADERS_TEST_TOKEN", "from-env")
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  http.Header
+	}{
+		{name: "none", value: nil, want: http.Header{}},
+		{name: "flag list", value: []string{"Authorization: Bearer abc", "X-Trace:1"},
+			want: http.Header{"Authorization": {"Bearer abc"}, "X-Trace": {"1"}}},
+		{name: "YAML list", value: []interface{}{"Authorization: Bearer abc", "x-api-key: k"},
+			want: http.Header{"Authorization": {"Bearer abc"}, "X-Api-Key": {"k"}}},
+		{name: "environment lines", value: "Authorization: Bearer abc\n\n  \nX-Trace: 1\n",
+			want: http.Header{"Authorization": {"Bearer abc"}, "X-Trace": {"1"}}},
+		{name: "map of values", value: map[string]interface{}{"Authorization": "Bearer abc", "X-Retries": 3, "X-Empty": nil},
+			want: http.Header{"Authorization": {"Bearer abc"}, "X-Retries": {"3"}, "X-Empty": {""}}},
+		{name: "map of strings", value: map[string]string{"authorization": "Bearer abc"},
+			want: http.Header{"Authorization": {"Bearer abc"}}},
+		{name: "map of lists", value: map[string]interface{}{"Accept": []interface{}{"application/json", "text/plain"}, "X-Tag": []string{"a", "b"}},
+			want: http.Header{"Accept": {"application/json", "text/plain"}, "X-Tag": {"a", "b"}}},
+		{name: "repeated in a list", value: []string{"X-Tag: a", "x-tag: b", "X-TAG: c"},
+			want: http.Header{"X-Tag": {"a", "b", "c"}}},
+		{name: "repeated in lines", value: "Cookie: a=1\nCookie: b=2",
+			want: http.Header{"Cookie": {"a=1", "b=2"}}},
+		{name: "colons in the value", value: []string{"X-Url: https://example.com:8443/path", "X-Time: 12:30:00"},
+			want: http.Header{"X-Url": {"https://example.com:8443/path"}, "X-Time": {"12:30:00"}}},
+		{name: "commas in the value", value: []string{"Accept: text/html, application/json;q=0.9"},
+			want: http.Header{"Accept": {"text/html, application/json;q=0.9"}}},
+		{name: "surrounding space", value: []string{"  X-Trace  :   1  "},
+			want: http.Header{"X-Trace": {"1"}}},
+		{name: "empty value", value: []string{"X-Empty:"},
+			want: http.Header{"X-Empty": {""}}},
+		{name: "token characters", value: []string{"X-A!#$%&'*+-.^_`|~1: v"},
+			want: http.Header{"X-A!#$%&'*+-.^_`|~1": {"v"}}},
+		{name: "map interpolation", value: map[string]interface{}{"Authorization": "Bearer ${HEADERS_TEST_TOKEN}"},
+			want: http.Header{"Authorization": {"Bearer from-env"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaders(tt.value)
+			if err != nil {
+				t.Fatalf("parseHeaders: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("headers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHeadersErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "no colon", value: []string{"Authorization Bearer abc"}, want: `invalid header "Authorization Bearer abc"`},
+		{name: "empty name", value: []string{": value"}, want: `invalid header name ""`},
+		{name: "space in the name", value: []string{"X Trace: 1"}, want: `invalid header name "X Trace"`},
+		{name: "separator in the name", value: []string{"X(Trace): 1"}, want: `invalid header name "X(Trace)"`},
+		{name: "non-ASCII name", value: []string{"X-Tracé: 1"}, want: `invalid header name "X-Tracé"`},
+		{name: "invalid map name", value: map[string]interface{}{"X/Trace": "1"}, want: `invalid header name "X/Trace"`},
+		{name: "CR in a value", value: []string{"X-Trace: 1\rInjected: yes"}, want: "invalid value for header X-Trace: line breaks are not allowed"},
+		{name: "LF in a value", value: []string{"X-Trace: 1\nInjected: yes"}, want: "invalid value for header X-Trace: line breaks are not allowed"},
+		{name: "CRLF in a map value", value: map[string]interface{}{"X-Trace": "1\r\nInjected: yes"}, want: "line breaks are not allowed"},
+		{name: "CRLF in a map list", value: map[string]interface{}{"X-Trace": []interface{}{"1", "2\r\nInjected: yes"}}, want: "line breaks are not allowed"},
+		{name: "CR in a line", value: "X-Trace: 1\rInjected: yes", want: "line breaks are not allowed"},
+		{name: "CR in a string map", value: map[string]string{"X-Trace": "1\rInjected: yes"}, want: "line breaks are not allowed"},
+		{name: "non-string list item", value: []interface{}{"X-Trace: 1", 2}, want: `invalid header 2`},
+		{name: "unsupported type", value: 42, want: "invalid headers: expected a list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers, err := parseHeaders(tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v (headers %v), want it to mention %q", err, headers, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseHeadersConfig reads the headers key of YAML config files, which decode to the generic
+// list and map types
+func TestParseHeadersConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want http.Header
+	}{
+		{name: "list", yaml: "headers:\n  - 'Authorization: Bearer abc'\n  - 'X-Tag: a'\n  - 'X-Tag: b'\n",
+			want: http.Header{"Authorization": {"Bearer abc"}, "X-Tag": {"a", "b"}}},
+		{name: "map", yaml: "headers:\n  Authorization: 'Bearer a:b'\n  X-Tag: [a, b]\n  X-Retries: 3\n",
+			want: http.Header{"Authorization": {"Bearer a:b"}, "X-Tag": {"a", "b"}, "X-Retries": {"3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCLI(t)
+			viper.SetConfigType("yaml")
+			if err := viper.ReadConfig(strings.NewReader(tt.yaml)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := configHeaders()
+			if err != nil {
+				t.Fatalf("configHeaders: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("headers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidHeaderName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"Authorization":   true,
+		"x-api-key":       true,
+		"X_Custom.1":      true,
+		"":                false,
+		"X Trace":         false,
+		"X:Trace":         false,
+		"X\rTrace":        false,
+		"X\nTrace":        false,
+		"X\tTrace":        false,
+		"\"Quoted\"":      false,
+		"X-Tracé":         false,
+		"X@Trace":         false,
+		"{Authorization}": false,
+	} {
+		if got := validHeaderName(name); got != valid {
+			t.Errorf("validHeaderName(%q) = %v, want %v", name, got, valid)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// update rewrites the golden files under testdata instead of comparing against them
+var update = flag.Bool("update", false, "update golden files")
+
+// cliResult is what a command run by runCLI printed and returned
+type cliResult struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// runCLI runs the command line args as main would, with every flag back at its default, no config
+// file in the home directory and a fresh viper state. It captures what the command prints.
+func runCLI(t *testing.T, args ...string) cliResult {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	resetCLI(t)
+
+	stdout, stderr := captureFile(t), captureFile(t)
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	os.Stdout, os.Stderr = savedStdout, savedStderr
+
+	return cliResult{stdout: readCaptured(t, stdout), stderr: readCaptured(t, stderr), err: err}
+}
+
+// resetCLI puts every flag of every command back to its default and rebinds the option keys to a
+// fresh viper, so that runs do not see each other's settings
+func resetCLI(t *testing.T) {
+	t.Helper()
+	var reset func(cmd *cobra.Command)
+	reset = func(cmd *cobra.Command) {
+		for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+			flags.VisitAll(func(flag *pflag.Flag) {
+				resetFlag(t, flag)
+			})
+		}
+		for _, sub := range cmd.Commands() {
+			reset(sub)
+		}
+	}
+	reset(rootCmd)
+
+	viper.Reset()
+	for key, flag := range boundFlags {
+		viper.BindPFlag(key, flag)
+	}
+	for key := range presetDefaults {
+		delete(presetDefaults, key)
+	}
+}
+
+// resetFlag sets a flag back to its default. Slice flags append on Set once they have been set, so
+// they are replaced and their unexported changed field, which pflag offers no way to clear, is reset.
+func resetFlag(t *testing.T, flag *pflag.Flag) {
+	t.Helper()
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		if value := reflect.ValueOf(flag.Value); value.Kind() == reflect.Pointer {
+			if changed := value.Elem().FieldByName("changed"); changed.IsValid() && changed.Kind() == reflect.Bool {
+				reflect.NewAt(changed.Type(), unsafe.Pointer(changed.UnsafeAddr())).Elem().SetBool(false)
+			}
+		}
+		var values []string
+		if defaults := strings.TrimSuffix(strings.TrimPrefix(flag.DefValue, "["), "]"); defaults != "" {
+			var err error
+			if values, err = csv.NewReader(strings.NewReader(defaults)).Read(); err != nil {
+				t.Fatalf("resetting --%s: %v", flag.Name, err)
+			}
+		}
+		if err := slice.Replace(values); err != nil {
+			t.Fatalf("resetting --%s: %v", flag.Name, err)
+		}
+	} else if err := flag.Value.Set(flag.DefValue); err != nil {
+		t.Fatalf("resetting --%s: %v", flag.Name, err)
+	}
+	flag.Changed = false
+}
+
+func captureFile(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func readCaptured(t *testing.T, f *os.File) string {
+	t.Helper()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// writeFile writes a fixture file into a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readSchema reads a JSON Schema document written by a command
+func readSchema(t *testing.T, path string) *pkg.JSONSchema6 {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decodeSchema(t, data)
+}
+
+// decodeSchema decodes a JSON Schema document printed by a command
+func decodeSchema(t *testing.T, data []byte) *pkg.JSONSchema6 {
+	t.Helper()
+	var schema pkg.JSONSchema6
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("decoding schema: %v\n%s", err, data)
+	}
+	return &schema
+}
+
+// assertGolden compares got with testdata/name, rewriting the file with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run with -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// fixtureSDL is a small schema with every kind of type and all three roots
+const fixtureSDL = `
+type Query {
+  user(id: ID!): User
+  search(term: String!): [SearchResult!]!
+}
+
+type Mutation {
+  createUser(input: CreateUserInput!): User
+}
+
+type Subscription {
+  userCreated: User
+}
+
+interface Node { id: ID! }
+
+type User implements Node {
+  id: ID!
+  name: String!
+  email: String
+  address: Address
+  role: Role
+}
+
+type Address {
+  street: String!
+  city: String
+}
+
+type Post implements Node {
+  id: ID!
+  title: String!
+}
+
+union SearchResult = User | Post
+
+enum Role { ADMIN MEMBER }
+
+input CreateUserInput {
+  name: String!
+  role: Role = MEMBER
+}
+
+type Unused { value: Int }
+`
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the config file name searched for in the home directory
+const configFileName = ".gql2jsonschema.yaml"
+
+// configKeys are the options written by init, in order, keyed by flag name with their config key
+var configKeys = []struct {
+	flag string
+	key  string
+}{
+	{"endpoint", "endpoint"},
+	{"input", "input"},
+	{"output", "output"},
+	{"header", "headers"},
+	{"timeout", "timeout"},
+	{"ignore-internals", "ignore-internals"},
+	{"nullable-array-items", "nullable-array-items"},
+	{"id-type", "id-type"},
+	{"operation", "operation"},
+	{"method", "method"},
+	{"root", "root"},
+	{"definitions-only", "definitions-only"},
+	{"prune", "prune"},
+	{"dedupe", "dedupe"},
+	{"fail-on-empty", "fail-on-empty"},
+	{"min-definitions", "min-definitions"},
+}
+
+// configExamples is appended to generated config files as commented-out documentation
+const configExamples = `
+# Examples:
+#
+# headers:
+#   - "X-Request-Source: ci"
+#
+# compat-rules:
+#   required-added: warning
+#   default-changed: info
+#
+# lint:
+#   disable:
+#     - recursive-type
+#   max-enum-values: 200
+`
+
+var (
+	initPath      string
+	initFromFlags bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Scaffold a config file",
+	Long: `Write a .gql2jsonschema.yaml config file to --path (default is the home directory),
+pre-populated with the current option values and commented-out examples.
+
+With --from-flags only the options passed on the command line are written, so a
+working command line can be frozen into config:
+
+  gql2jsonschema init --from-flags -e http://localhost:8080/query --root query --prune
+
+An existing file is never overwritten without --force, and headers carrying
+credentials (Authorization, cookies, tokens, keys) are never written.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInit(cmd)
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVar(&initPath, "path", "", "config file or directory to write to (default is $HOME/"+configFileName+")")
+	initCmd.Flags().BoolVar(&initFromFlags, "from-flags", false, "only write options passed on the command line")
+
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command) error {
+	path, err := resolveInitPath(initPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil && !viper.GetBool("force") {
+		return withExitCode(ExitOutput, fmt.Errorf("refusing to overwrite %s (use --force to overwrite)", path))
+	}
+
+	data, err := buildConfigFile(cmd.Flags(), initFromFlags)
+	if err != nil {
+		return err
+	}
+	if err := replaceOutput(path, data); err != nil {
+		return err
+	}
+
+	logger.Info("Wrote config file", "path", path)
+	return nil
+}
+
+// resolveInitPath returns the config file to write, appending the default file name to directories
+func resolveInitPath(path string) (string, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, configFileName), nil
+	}
+
+	if info, err := os.Stat(path); (err == nil && info.IsDir()) || strings.HasSuffix(path, string(os.PathSeparator)) {
+		return filepath.Join(path, configFileName), nil
+	}
+	return path, nil
+}
+
+// buildConfigFile renders the config as YAML, keeping the order of configKeys
+func buildConfigFile(flags *pflag.FlagSet, fromFlags bool) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	omitted := make([]string, 0)
+
+	for _, option := range configKeys {
+		flag := flags.Lookup(option.flag)
+		if fromFlags && (flag == nil || !flag.Changed) {
+			continue
+		}
+
+		value := viper.Get(option.key)
+		if option.key == "endpoint" {
+			// A single endpoint stays a plain value; failover lists are written as a list
+			switch endpoints := configEndpoints(); len(endpoints) {
+			case 0:
+				value = ""
+			case 1:
+				value = endpoints[0]
+			default:
+				value = endpoints
+			}
+		}
+		if option.key == "headers" {
+			headers, err := configHeaders()
+			if err != nil {
+				return nil, err
+			}
+			for name := range headers {
+				if isSensitiveHeader(name) {
+					omitted = append(omitted, name)
+					headers.Del(name)
+				}
+			}
+			sort.Strings(omitted)
+			value = headerLines(headers)
+		}
+
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return nil, fmt.Errorf("error encoding %s: %w", option.key, err)
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: option.key}, &valueNode)
+	}
+
+	var b strings.Builder
+	b.WriteString("# gql2jsonschema config file\n")
+	for _, name := range omitted {
+		fmt.Fprintf(&b, "# The %s header was not written because it carries credentials; pass it with --header instead.\n", name)
+	}
+	if len(doc.Content) > 0 {
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("error encoding config: %w", err)
+		}
+		encoder.Close()
+	}
+	b.WriteString(configExamples)
+	return []byte(b.String()), nil
+}
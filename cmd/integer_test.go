@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const integerFixtureSDL = `
+type Query { page(limit: Int = 10): Page }
+type Page { count: Int! sizes: [Int!] ratio: Float }
+input PageInput { limit: Int! }
+`
+
+// hasType reports whether typ is the type of node or, with nullable fields, one of its types
+func hasType(node *pkg.JSONSchema6, typ string) bool {
+	switch types := node.Type.(type) {
+	case string:
+		return types == typ
+	case []interface{}:
+		for _, t := range types {
+			if t == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestUseIntegerTypeFlags(t *testing.T) {
+	input := writeFile(t, "schema.graphql", integerFixtureSDL)
+	tests := []struct {
+		name    string
+		args    []string
+		typ     string
+		bounded bool
+	}{
+		{"default", nil, "number", false},
+		{"integer", []string{"--use-integer-type"}, "integer", false},
+		{"bounds", []string{"--use-integer-type", "--int-bounds"}, "integer", true},
+		{"strict-validation preset", []string{"--preset", "strict-validation"}, "integer", true},
+		{"flags override the preset", []string{"--preset", "strict-validation", "--use-integer-type=false", "--int-bounds=false"}, "number", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			schema := readSchema(t, output)
+			page := schema.Definitions["Page"].Properties
+			for name, node := range map[string]*pkg.JSONSchema6{
+				"Page.count":        page["count"].Properties["return"],
+				"Page.sizes items":  page["sizes"].Properties["return"].Items,
+				"Query.page(limit)": schema.Properties["Query"].Properties["page"].Properties["arguments"].Properties["limit"],
+			} {
+				if !hasType(node, tt.typ) {
+					t.Errorf("%s: type %v, want %s", name, node.Type, tt.typ)
+				}
+				if bounded := node.Minimum != nil && node.Maximum != nil; bounded != tt.bounded {
+					t.Errorf("%s: minimum %v, maximum %v", name, node.Minimum, node.Maximum)
+				}
+			}
+			if ratio := page["ratio"].Properties["return"]; !hasType(ratio, "number") {
+				t.Errorf("Float became %v", ratio.Type)
+			}
+		})
+	}
+}
+
+func TestUseIntegerTypeValidate(t *testing.T) {
+	input := writeFile(t, "schema.graphql", integerFixtureSDL)
+	schema := filepath.Join(t.TempDir(), "schema.json")
+	if result := runCLI(t, "--no-config", "-i", input, "-o", schema, "--use-integer-type", "--int-bounds"); result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{`{"limit": 7}`, ""},
+		{`{"limit": 1.5}`, "integer"},
+		{`{"limit": 3000000000}`, "2147483647"},
+	}
+	for _, tt := range tests {
+		payload := writeFile(t, "payload.json", tt.payload)
+		result := runCLI(t, "--no-config", "validate", "-s", schema, "-d", "PageInput", payload)
+		if tt.want == "" {
+			if result.err != nil {
+				t.Errorf("%s: %v\n%s", tt.payload, result.err, result.stdout)
+			}
+			continue
+		}
+		if result.err == nil || !strings.Contains(result.stdout, tt.want) {
+			t.Errorf("%s: want a violation mentioning %q, got %v:\n%s", tt.payload, tt.want, result.err, result.stdout)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+)
+
+// keyringService is the service name credentials are stored under in the OS keyring
+const keyringService = "gql2jsonschema"
+
+// keyringPassphraseEnv names the environment variable holding the passphrase of the encrypted file
+// store. It is deliberately not a config key, so the passphrase never ends up next to the file.
+const keyringPassphraseEnv = "GRAPHQL2JSON_KEYRING_PASSPHRASE"
+
+// errSecretNotFound is returned when no secret is stored for a profile
+var errSecretNotFound = errors.New("secret not found")
+
+// secretStore stores one secret per profile
+type secretStore interface {
+	get(profile string) (string, error)
+	set(profile, secret string) error
+	remove(profile string) error
+	// description names the backend in log messages
+	description() string
+}
+
+// openSecretStore returns the OS keyring: the Keychain on macOS, the Credential Manager on Windows and
+// the Secret Service over D-Bus elsewhere. When none is reachable, secrets go to a file encrypted with
+// a key derived from the passphrase in GRAPHQL2JSON_KEYRING_PASSPHRASE instead.
+func openSecretStore() (secretStore, error) {
+	// Looking up an item that does not exist tells whether the keyring can be reached at all
+	_, probeErr := keyring.Get(keyringService, "")
+	if probeErr == nil || errors.Is(probeErr, keyring.ErrNotFound) {
+		return osKeyring{}, nil
+	}
+	logger.Debug("OS keyring unavailable", "error", probeErr)
+
+	passphrase := os.Getenv(keyringPassphraseEnv)
+	if passphrase == "" {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no OS keyring available (%v): set %s to store credentials in an encrypted file instead", probeErr, keyringPassphraseEnv))
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("no OS keyring available and no config directory for the encrypted file: %w", err)
+	}
+	return &fileSecretStore{dir: filepath.Join(dir, "gql2jsonschema"), passphrase: passphrase}, nil
+}
+
+// validProfileName restricts profile names to characters every keyring backend and config key accepts
+func validProfileName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// osKeyring stores secrets in the keyring of the operating system, one item per profile
+type osKeyring struct{}
+
+func (osKeyring) description() string { return "OS keyring" }
+
+func (osKeyring) get(profile string) (string, error) {
+	secret, err := keyring.Get(keyringService, profile)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", errSecretNotFound
+	}
+	return secret, err
+}
+
+func (osKeyring) set(profile, secret string) error {
+	return keyring.Set(keyringService, profile, secret)
+}
+
+func (osKeyring) remove(profile string) error {
+	err := keyring.Delete(keyringService, profile)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return errSecretNotFound
+	}
+	return err
+}
+
+// scrypt parameters for deriving the key of the encrypted file store, as recommended for interactive use
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltSize     = 16
+)
+
+// fileSecretStore is the fallback when no OS keyring is available. Secrets are encrypted with AES-GCM
+// under a key derived with scrypt from a passphrase that is never written to disk, so the file alone
+// does not reveal them.
+type fileSecretStore struct {
+	dir        string
+	passphrase string
+}
+
+// credentialsFile is the content of the encrypted file store
+type credentialsFile struct {
+	// Salt is the scrypt salt, base64 encoded
+	Salt string `json:"salt"`
+	// Secrets maps profiles to their nonce and sealed secret, base64 encoded
+	Secrets map[string]string `json:"secrets"`
+}
+
+func (s *fileSecretStore) path() string {
+	return filepath.Join(s.dir, "credentials.json")
+}
+
+func (s *fileSecretStore) description() string {
+	return "encrypted file " + s.path()
+}
+
+func (s *fileSecretStore) get(profile string) (string, error) {
+	file, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := file.Secrets[profile]
+	if !ok {
+		return "", errSecretNotFound
+	}
+
+	aead, err := s.cipher(file.Salt)
+	if err != nil {
+		return "", err
+	}
+	secret, err := openSealed(aead, profile, sealed)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt the secret for profile %s: is %s the passphrase it was stored with?", profile, keyringPassphraseEnv)
+	}
+	return string(secret), nil
+}
+
+func (s *fileSecretStore) set(profile, secret string) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	if file.Salt == "" {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("error generating salt: %w", err)
+		}
+		file.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+	aead, err := s.cipher(file.Salt)
+	if err != nil {
+		return err
+	}
+	// Secrets stored earlier must open with this passphrase, or the file would mix keys
+	for name, sealed := range file.Secrets {
+		if _, err := openSealed(aead, name, sealed); err != nil {
+			return fmt.Errorf("%s does not match the passphrase of %s", keyringPassphraseEnv, s.path())
+		}
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("error generating nonce: %w", err)
+	}
+	// The profile is authenticated with the secret so entries cannot be swapped between profiles
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(profile))
+	file.Secrets[profile] = base64.StdEncoding.EncodeToString(sealed)
+	return s.write(file)
+}
+
+func (s *fileSecretStore) remove(profile string) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Secrets[profile]; !ok {
+		return errSecretNotFound
+	}
+	delete(file.Secrets, profile)
+	return s.write(file)
+}
+
+func (s *fileSecretStore) read() (*credentialsFile, error) {
+	file := &credentialsFile{Secrets: make(map[string]string)}
+	data, err := os.ReadFile(s.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("error parsing credentials: %w", err)
+	}
+	if file.Secrets == nil {
+		file.Secrets = make(map[string]string)
+	}
+	return file, nil
+}
+
+func (s *fileSecretStore) write(file *credentialsFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling credentials: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("error creating credentials directory: %w", err)
+	}
+	if err := writeFileAtomic(s.path(), data, 0600); err != nil {
+		return fmt.Errorf("error writing credentials: %w", err)
+	}
+	return nil
+}
+
+// openSealed decrypts a secret sealed by set
+func openSealed(aead cipher.AEAD, profile, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid stored secret for profile %s", profile)
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(profile))
+}
+
+// cipher derives the encryption key from the passphrase and the file's salt
+func (s *fileSecretStore) cipher(encodedSalt string) (cipher.AEAD, error) {
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid salt in %s", s.path())
+	}
+	key, err := scrypt.Key([]byte(s.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving credentials key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating credentials cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOperationsLayoutFlags(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--no-config", "-i", input, "-o", output, "--operations-layout", "flat", "--operations-key", "ops")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+
+	schema := readSchema(t, output)
+	if len(schema.Properties) != 1 || schema.Properties["ops"] == nil {
+		t.Fatalf("expected only the ops property, got %d properties", len(schema.Properties))
+	}
+	for _, coordinate := range []string{"Query.user", "Query.search", "Mutation.createUser", "Subscription.userCreated"} {
+		if schema.Properties["ops"].Properties[coordinate] == nil {
+			t.Errorf("operation %s is missing", coordinate)
+		}
+	}
+
+	result = runCLI(t, "--no-config", "-i", input, "--operations-layout", "sideways")
+	if result.err == nil || ExitCode(result.err) != ExitUsage {
+		t.Errorf("expected an invalid layout to be a usage error, got %v", result.err)
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	lintDisable       []string
+	lintMaxEnumValues int
+	lintFormat        string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report GraphQL patterns that convert poorly to JSON Schema",
+	Long: `Analyze the schema from --endpoint, --input or stdin and report patterns that do not
+translate well to JSON Schema, without writing any output.
+
+Rules:
+  unmapped-scalar     custom scalars that convert to a schema without a type (error)
+  untagged-union      union members that cannot be discriminated (warning)
+  recursive-type      types that reference themselves and cannot be fully inlined (warning)
+  name-collision      fields whose names collide after case transforms (warning)
+  large-enum          enums with more than --max-enum-values values (warning)
+  deprecated-member   deprecated fields and enum values still present (warning)
+
+Rules can be disabled with --disable or the lint.disable list in the config file.
+Exit code is 7 when errors are found; warnings alone do not fail.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLint()
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringSliceVar(&lintDisable, "disable", []string{}, "rules to disable")
+	lintCmd.Flags().IntVar(&lintMaxEnumValues, "max-enum-values", pkg.DefaultLintOptions().MaxEnumValues, "enum size above which large-enum is reported")
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "f", "text", "output format (text or json)")
+
+	bindFlag("lint.disable", lintCmd.Flags().Lookup("disable"))
+	bindFlag("lint.max-enum-values", lintCmd.Flags().Lookup("max-enum-values"))
+
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint() error {
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+
+	opts := pkg.DefaultLintOptions()
+	opts.Disabled = viper.GetStringSlice("lint.disable")
+	opts.MaxEnumValues = viper.GetInt("lint.max-enum-values")
+	opts.IgnoreInternals = viper.GetBool("ignore-internals")
+
+	findings, err := pkg.Lint(*introspection, opts)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	switch lintFormat {
+	case "text":
+		fmt.Print(formatLintFindings(findings))
+	case "json":
+		if err := printJSON(findings); err != nil {
+			return err
+		}
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", lintFormat))
+	}
+
+	for _, finding := range findings {
+		if finding.Severity == pkg.LintError {
+			return withExitCode(ExitMismatch, fmt.Errorf("lint errors found"))
+		}
+	}
+	return nil
+}
+
+// formatLintFindings renders lint findings grouped by severity, errors first
+func formatLintFindings(findings []pkg.LintFinding) string {
+	if len(findings) == 0 {
+		return "No findings\n"
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		severity pkg.LintSeverity
+		title    string
+	}{
+		{pkg.LintError, "Errors"},
+		{pkg.LintWarning, "Warnings"},
+	} {
+		lines := make([]string, 0)
+		for _, finding := range findings {
+			if finding.Severity == group.severity {
+				lines = append(lines, fmt.Sprintf("%s: %s [%s]", finding.Coordinate, finding.Message, finding.Rule))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s (%d):\n", group.title, len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	quiet     bool
+	verbose   bool
+	logFormat string
+)
+
+// logger is the CLI's leveled logger; it writes to stderr once setupLogger has run
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// setupLogger configures the logger from --quiet, --verbose and --log-format
+func setupLogger() error {
+	return configureLogger(os.Stderr, viper.GetBool("quiet"), viper.GetBool("verbose"), viper.GetString("log-format"))
+}
+
+func configureLogger(w io.Writer, quiet, verbose bool, format string) error {
+	if quiet && verbose {
+		return fmt.Errorf("--quiet and --verbose are mutually exclusive")
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case quiet:
+		level = slog.LevelError
+	case verbose:
+		level = slog.LevelDebug
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", "text":
+		logger = slog.New(slog.NewTextHandler(w, handlerOpts))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(w, handlerOpts))
+	default:
+		return fmt.Errorf("invalid log format: %s (must be 'text' or 'json')", format)
+	}
+	return nil
+}
+
+// logPhase logs the duration of a processing phase at debug level
+func logPhase(phase string, start time.Time) {
+	logger.Debug("Phase complete", "phase", phase, "duration", time.Since(start))
+}
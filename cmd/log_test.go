@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+func TestConversionWarningsLogged(t *testing.T) {
+	introspection, err := pkg.IntrospectionFromSDL(fixtureSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range introspection.Schema.Types {
+		if introspection.Schema.Types[i].Name == "Role" {
+			introspection.Schema.Types[i].EnumValues = nil
+		}
+	}
+	data, err := json.Marshal(introspection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := writeFile(t, "introspection.json", string(data))
+
+	result := runCLI(t, "--no-config", "--log-format", "json", "-i", input)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(result.stderr), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %s", line)
+		}
+		if record[pkg.LogKeyWarning] == pkg.WarningEmptyType && record[pkg.LogKeyType] == "Role" {
+			if record["level"] != "WARN" {
+				t.Errorf("level = %v, want WARN", record["level"])
+			}
+			return
+		}
+	}
+	t.Errorf("the empty enum was not logged with %s and %s:\n%s", pkg.LogKeyWarning, pkg.LogKeyType, result.stderr)
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,33 +22,84 @@ import (
 )
 
 var (
-	cfgFile            string
-	inputFile          string
-	outputFile         string
-	endpoint           string
-	headers            []string
-	timeout            int
-	ignoreInternals    bool
-	nullableArrayItems bool
-	idTypeMapping      string
-	operation          string
-	methodName         string
+	cfgFile              string
+	noConfig             bool
+	showConfigFlag       bool
+	envFiles             []string
+	inputFile            string
+	outputFile           string
+	endpoints            []string
+	headers              []string
+	timeout              int
+	noClobber            bool
+	force                bool
+	ignoreInternals      bool
+	nullableArrayItems   bool
+	useIntegerType       bool
+	intBounds            bool
+	wellKnownScalars     bool
+	additionalPropsFalse bool
+	nullableFields       string
+	interfaceAllOf       bool
+	interfaceImpls       string
+	interfaceKeepFields  bool
+	enumStyle            string
+	fieldShape           string
+	idTypeMapping        string
+	operation            string
+	methodName           string
+	definitionsOnly      bool
+	onlyInputTypes       bool
+	roots                []string
+	prune                bool
+	operationsLayout     string
+	operationsKey        string
+	excludeFields        []string
+	includeFields        []string
+	excludeTypes         []string
+	includeTypes         []string
+	includeTypeDeps      bool
+	operationsPath       string
+	overlayFile          string
+	overlayStrict        bool
+	sampleEnabled        bool
+	sampleDepth          int
+	sampleFields         []string
+	sampleRedact         []string
+	sampleListSize       int
+	extractExamples      bool
+	stripExamples        bool
+	embedSDL             bool
+	continueOnError      bool
+	sharedPaginationArgs bool
+	draft                string
+	preset               string
+	dedupe               bool
+	flattenAllOf         bool
+	check                bool
+	failOnEmpty          bool
+	minDefinitions       int
+	watch                bool
+	watchInterval        time.Duration
 )
 
-// Define the introspection query
-const introspectionQuery = `
+// introspectionQueryTemplate is the introspection query. %[1]s is the argument of the fields that
+// list arguments and input fields, %[2]s selects their deprecation and %[3]s the specifiedByURL of
+// scalars; see introspectionQuery.
+const introspectionQueryTemplate = `
 query IntrospectionQuery {
   __schema {
     queryType { name }
     mutationType { name }
+    subscriptionType { name }
     types {
       kind
       name
-      description
-      fields {
+      description%[3]s
+      fields(includeDeprecated: true) {
         name
         description
-        args {
+        args%[1]s {
           name
           description
           type {
@@ -62,7 +118,7 @@ query IntrospectionQuery {
               }
             }
           }
-          defaultValue
+          defaultValue%[2]s
         }
         type {
           kind
@@ -80,8 +136,10 @@ query IntrospectionQuery {
             }
           }
         }
+        isDeprecated
+        deprecationReason
       }
-      inputFields {
+      inputFields%[1]s {
         name
         description
         type {
@@ -100,7 +158,7 @@ query IntrospectionQuery {
             }
           }
         }
-        defaultValue
+        defaultValue%[2]s
       }
       interfaces {
         kind
@@ -118,9 +176,11 @@ query IntrospectionQuery {
           }
         }
       }
-      enumValues {
+      enumValues(includeDeprecated: true) {
         name
         description
+        isDeprecated
+        deprecationReason
       }
       possibleTypes {
         kind
@@ -143,6 +203,25 @@ query IntrospectionQuery {
 }
 `
 
+var (
+	inputValueDeprecation bool
+	specifiedByURL        bool
+)
+
+// introspectionQuery returns the query sent to endpoints. Deprecated arguments and input fields
+// and specifiedByURL are part of the October 2021 spec; --input-value-deprecation=false and
+// --specified-by-url=false leave them out for servers that predate it and reject the query.
+func introspectionQuery() string {
+	args, deprecation, specifiedBy := "", "", ""
+	if viper.GetBool("input-value-deprecation") {
+		args, deprecation = "(includeDeprecated: true)", " isDeprecated deprecationReason"
+	}
+	if viper.GetBool("specified-by-url") {
+		specifiedBy = " specifiedByURL"
+	}
+	return fmt.Sprintf(introspectionQueryTemplate, args, deprecation, specifiedBy)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "gql2jsonschema",
 	Short: "Convert GraphQL Schema to JSON Schema",
@@ -151,6 +230,11 @@ Supports three input methods:
 1. GraphQL endpoint URL (--endpoint)
 2. Input file with introspection query result (--input)
 3. Stdin (pipe or redirect introspection query result)`,
+	// Errors are printed by main; usage is only shown for flag and argument errors
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runConversion()
 	},
@@ -172,114 +256,361 @@ func initConfig() {
 	}
 
 	// Read in environment variables that match
-	viper.SetEnvPrefix("GRAPHQL2JSON")
+	viper.SetEnvPrefix(envPrefix)
 	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.SetEnvKeyReplacer(envKeyReplacer)
 
 	// If a config file is found, read it in
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	var configErr error
+	if !noConfig {
+		configErr = viper.ReadInConfig()
+	}
+
+	cobra.CheckErr(setupLogger())
+	cobra.CheckErr(loadEnvFiles(envFiles))
+	interpolateOptions()
+	applyProfile()
+	applyUseCasePreset()
+
+	if !noConfig && configErr == nil {
+		logger.Info("Using config file", "path", viper.ConfigFileUsed())
+	}
+
+	if showConfigFlag {
+		showConfig(os.Stderr)
 	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withExitCode(ExitUsage, err)
+	})
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gql2jsonschema.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&noConfig, "no-config", false, "do not load any config file")
+	rootCmd.PersistentFlags().StringArrayVar(&envFiles, "env-file", []string{}, "load KEY=VALUE pairs for ${VAR} interpolation from this file (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&showConfigFlag, "show-config", false, "print the resolved options and their sources to stderr")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log errors")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log resolved options, phase timings and warning details")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "log format (text or json)")
+
+	// Input source flags, shared with subcommands
+	rootCmd.PersistentFlags().StringVarP(&inputFile, "input", "i", "", "input file or s3:// or gs:// object containing GraphQL introspection query result, or SDL (.graphql, .graphqls, .gql)")
+	rootCmd.PersistentFlags().StringArrayVarP(&endpoints, "endpoint", "e", nil, "GraphQL endpoint URL; repeat or separate with commas for endpoints tried in order")
+	rootCmd.PersistentFlags().StringArrayVarP(&headers, "header", "H", []string{}, "HTTP headers for endpoint (format: 'Key: Value')")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 30, "timeout in seconds for HTTP requests")
+	rootCmd.PersistentFlags().BoolVar(&inputValueDeprecation, "input-value-deprecation", true, "request the deprecation of arguments and input fields; disable for servers older than the October 2021 spec")
+	rootCmd.PersistentFlags().BoolVar(&specifiedByURL, "specified-by-url", true, "request the specifiedByURL of scalars; disable for servers older than the October 2021 spec")
+	rootCmd.PersistentFlags().BoolVar(&noClobber, "no-clobber", false, "refuse to overwrite existing output files")
+	rootCmd.PersistentFlags().BoolVar(&force, "force", false, "overwrite existing output files even with --no-clobber")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "directory for cached conversions (default is gql2jsonschema in the user cache directory)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "always convert instead of reusing cached conversions")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "write the introspection request and response to this session file, with credentials redacted")
+	rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "serve the introspection recorded in this session file instead of fetching it")
 
 	// Local flags
-	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "input file containing GraphQL introspection query result")
-	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "output file for JSON Schema (default is stdout)")
-	rootCmd.Flags().StringVarP(&endpoint, "endpoint", "e", "", "GraphQL endpoint URL")
-	rootCmd.Flags().StringArrayVarP(&headers, "header", "H", []string{}, "HTTP headers for endpoint (format: 'Key: Value')")
-	rootCmd.Flags().IntVarP(&timeout, "timeout", "t", 30, "timeout in seconds for HTTP requests")
+	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "output file or s3:// or gs:// object for JSON Schema (default is stdout)")
 	rootCmd.Flags().BoolVar(&ignoreInternals, "ignore-internals", true, "ignore GraphQL internal types")
 	rootCmd.Flags().BoolVar(&nullableArrayItems, "nullable-array-items", false, "properly represent nullable items in arrays")
+	rootCmd.Flags().StringVar(&nullableFields, "nullable-fields", "off", "how nullable fields, arguments and input fields accept null: off, typeArray or anyOf")
+	rootCmd.Flags().BoolVar(&interfaceAllOf, "interface-allof", false, "link types to the interfaces they implement with allOf instead of repeating the inherited fields")
+	rootCmd.Flags().StringVar(&interfaceImpls, "interface-implementations", "off", "how interface definitions reference the types implementing them: off, anyOf or oneOf")
+	rootCmd.Flags().BoolVar(&interfaceKeepFields, "interface-keep-fields", false, "keep the fields of interfaces alongside their --interface-implementations")
+	rootCmd.Flags().StringVar(&enumStyle, "enum-style", "anyOf", "how enums list their values: anyOf of single-value enums with descriptions, or flat")
+	rootCmd.Flags().StringVar(&fieldShape, "field-shape", "wrapped", "how object fields are emitted: wrapped in {arguments, return}, or plain as the value in response data")
 	rootCmd.Flags().StringVar(&idTypeMapping, "id-type", "string", "how to represent ID type (string, number, or both)")
-	rootCmd.Flags().StringVarP(&operation, "operation", "p", "", "operation type to process (query or mutation)")
+	rootCmd.Flags().BoolVar(&useIntegerType, "use-integer-type", false, "represent Int as integer instead of number, rejecting values such as 1.5")
+	rootCmd.Flags().BoolVar(&intBounds, "int-bounds", false, "limit Int to the signed 32-bit range with minimum and maximum")
+	rootCmd.Flags().BoolVar(&wellKnownScalars, "well-known-scalars", false, "map DateTime, Timestamp, Date and Time scalars to strings with a date-time, date or time format")
+	rootCmd.Flags().BoolVar(&additionalPropsFalse, "additional-properties-false", false, "close object, interface and input object types and field arguments to unknown keys")
+	rootCmd.Flags().StringVarP(&operation, "operation", "p", "", "operation type to process (query, mutation or subscription)")
 	rootCmd.Flags().StringVarP(&methodName, "method", "m", "", "specific method name to process")
+	rootCmd.Flags().BoolVar(&definitionsOnly, "definitions-only", false, "omit the root properties and emit only definitions")
+	rootCmd.Flags().BoolVar(&onlyInputTypes, "only-input-types", false, "omit the root properties and emit only input types and the enums and input types they reference")
+	rootCmd.Flags().StringSliceVar(&roots, "root", []string{"all"}, "root operation types to emit (query, mutation, subscription or all)")
+	rootCmd.Flags().BoolVar(&prune, "prune", false, "drop definitions not reachable from the selected roots")
+	rootCmd.Flags().StringVar(&operationsLayout, "operations-layout", "nested", "layout of root operation fields: nested, flat or both")
+	rootCmd.Flags().StringVar(&operationsKey, "operations-key", pkg.DefaultOperationsKey, "property holding the flat operations map")
+	rootCmd.Flags().StringArrayVar(&excludeFields, "exclude-field", nil, "drop fields matching a Type.field pattern with * wildcards (repeatable)")
+	rootCmd.Flags().StringArrayVar(&includeFields, "include-field", nil, "keep only matching fields of the types a Type.field pattern names (repeatable)")
+	rootCmd.Flags().StringArrayVar(&excludeTypes, "exclude-type", nil, "drop types matching a name pattern with * wildcards or a /regexp/ (repeatable)")
+	rootCmd.Flags().StringArrayVar(&includeTypes, "include-type", nil, "keep only the root types, built-in scalars and types matching a name pattern with * wildcards or a /regexp/ (repeatable)")
+	rootCmd.Flags().BoolVar(&includeTypeDeps, "include-type-dependencies", false, "also keep the types that --include-type types transitively reference")
+	rootCmd.Flags().StringVar(&operationsPath, "operations", "", "trim the schema to the types and fields used by the operations in this directory, .graphql file or persisted query manifest")
+	rootCmd.Flags().StringVar(&overlayFile, "overlay", "", "YAML or JSON file of keywords to merge into the output, keyed by GraphQL coordinate or JSON pointer")
+	rootCmd.Flags().BoolVar(&overlayStrict, "overlay-strict", false, "fail when an overlay entry matches nothing")
+	rootCmd.Flags().BoolVar(&sampleEnabled, "sample-examples", false, "attach values returned by --endpoint for query fields as examples")
+	rootCmd.Flags().IntVar(&sampleDepth, "sample-depth", 2, "selection depth of the queries run by --sample-examples")
+	rootCmd.Flags().StringArrayVar(&sampleFields, "sample-field", nil, "query field to sample; all fields without required arguments when not given (repeatable)")
+	rootCmd.Flags().StringArrayVar(&sampleRedact, "sample-redact", pkg.DefaultSampleOptions().Redact, "leave out sampled fields whose names match this pattern (repeatable)")
+	rootCmd.Flags().IntVar(&sampleListSize, "sample-list-size", pkg.DefaultSampleOptions().MaxListItems, "number of items kept from sampled lists (0 keeps all)")
+	rootCmd.Flags().BoolVar(&extractExamples, "extract-examples", false, "move Example: lines and ```json blocks in descriptions into examples")
+	rootCmd.Flags().BoolVar(&stripExamples, "strip-examples", false, "remove extracted examples from descriptions")
+	rootCmd.Flags().BoolVar(&embedSDL, "embed-sdl", false, "attach the GraphQL definition of each type to its definition as a $comment")
+	rootCmd.Flags().StringVar(&draft, "draft", "draft-06", "JSON Schema dialect of the output (draft-06, draft-07 or 2020-12)")
+	rootCmd.Flags().BoolVar(&sharedPaginationArgs, "shared-pagination-args", false, "replace the Relay pagination arguments of connection fields by a $ref to a shared RelayPaginationArgs definition")
+	rootCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "skip malformed types, replacing them with schemas that accept any value, instead of converting them")
+	rootCmd.Flags().StringVar(&preset, "preset", "", "option bundle for a use case (forms, llm-tools, strict-validation or docs) or GraphQL server (hasura, postgraphile or shopify-admin); help lists them")
+	rootCmd.Flags().BoolVar(&dedupe, "dedupe", false, "hoist repeated subschemas into shared definitions")
+	rootCmd.Flags().BoolVar(&flattenAllOf, "flatten-allof", false, "merge allOf branches into single schemas for consumers without allOf support")
+	rootCmd.Flags().StringVar(&target, "target", targetJSONSchema, "output format: jsonschema, bigquery for a table schema of --select-type, cue, response-envelope for complete GraphQL responses, k8s-configmap, pydantic or openapi")
+	rootCmd.Flags().StringVar(&cuePackage, "cue-package", "", "package clause for --target cue output")
+	rootCmd.Flags().StringVar(&envelopeOperationFile, "envelope-operation", "", "GraphQL document whose operation's responses --target response-envelope describes")
+	rootCmd.Flags().StringVar(&envelopeOperationName, "envelope-operation-name", "", "operation to use when the --envelope-operation document contains several")
+	rootCmd.Flags().StringVar(&uiSchemaFile, "ui-schema", "", "also write a react-jsonschema-form uiSchema to this file")
+	rootCmd.Flags().StringVar(&subscriptionPayloadsDir, "subscription-payloads", "", "also write the event payload schema of each subscription field to this directory")
+	rootCmd.Flags().BoolVar(&progress, "progress", false, "log progress while converting definitions")
+	rootCmd.Flags().IntVar(&maxMemory, "max-memory", 0, "abort the conversion when heap usage exceeds this many MiB (0 disables)")
+	rootCmd.Flags().BoolVar(&failOnEmpty, "fail-on-empty", true, "fail when the output has no definitions and no root properties")
+	rootCmd.Flags().IntVar(&minDefinitions, "min-definitions", 0, "fail when the output has fewer definitions than this")
+	rootCmd.Flags().BoolVar(&check, "check", false, "compare the result against the existing --output file instead of writing it")
+	rootCmd.Flags().StringVar(&selectType, "select-type", "", "output only this type and the definitions it references")
+	rootCmd.Flags().StringVar(&selectPointer, "select-pointer", "", "output only the subschema at this JSON pointer and the definitions it references")
+	addInlineRefsFlag(rootCmd, "inline referenced definitions instead of using $ref, nesting them at most depth deep with --inline-refs=depth")
+	rootCmd.Flags().BoolVar(&inlineOmitDef, "inline-omit-definitions", false, "leave out the definitions, so references kept at cycles and the depth limit accept any value (implies --inline-refs)")
+	rootCmd.Flags().BoolVar(&watch, "watch", false, "keep running and regenerate --output when the schema changes")
+	rootCmd.Flags().DurationVar(&watchInterval, "interval", 30*time.Second, "polling interval for --watch with --endpoint")
 
 	// Bind flags to viper
-	viper.BindPFlag("input", rootCmd.Flags().Lookup("input"))
-	viper.BindPFlag("output", rootCmd.Flags().Lookup("output"))
-	viper.BindPFlag("endpoint", rootCmd.Flags().Lookup("endpoint"))
-	viper.BindPFlag("headers", rootCmd.Flags().Lookup("header"))
-	viper.BindPFlag("timeout", rootCmd.Flags().Lookup("timeout"))
-	viper.BindPFlag("ignore-internals", rootCmd.Flags().Lookup("ignore-internals"))
-	viper.BindPFlag("nullable-array-items", rootCmd.Flags().Lookup("nullable-array-items"))
-	viper.BindPFlag("id-type", rootCmd.Flags().Lookup("id-type"))
-	viper.BindPFlag("operation", rootCmd.Flags().Lookup("operation"))
-	viper.BindPFlag("method", rootCmd.Flags().Lookup("method"))
+	// Conversion flags are also accepted by init so a working command line can be captured into config
+	initCmd.Flags().AddFlagSet(rootCmd.LocalNonPersistentFlags())
+
+	bindFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	bindFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	bindFlag("log-format", rootCmd.PersistentFlags().Lookup("log-format"))
+	bindFlag("input", rootCmd.PersistentFlags().Lookup("input"))
+	bindFlag("output", rootCmd.Flags().Lookup("output"))
+	bindFlag("endpoint", rootCmd.PersistentFlags().Lookup("endpoint"))
+	bindFlag("headers", rootCmd.PersistentFlags().Lookup("header"))
+	bindFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	bindFlag("input-value-deprecation", rootCmd.PersistentFlags().Lookup("input-value-deprecation"))
+	bindFlag("specified-by-url", rootCmd.PersistentFlags().Lookup("specified-by-url"))
+	bindFlag("no-clobber", rootCmd.PersistentFlags().Lookup("no-clobber"))
+	bindFlag("force", rootCmd.PersistentFlags().Lookup("force"))
+	bindFlag("cache-dir", rootCmd.PersistentFlags().Lookup("cache-dir"))
+	bindFlag("no-cache", rootCmd.PersistentFlags().Lookup("no-cache"))
+	bindFlag("record", rootCmd.PersistentFlags().Lookup("record"))
+	bindFlag("replay", rootCmd.PersistentFlags().Lookup("replay"))
+	bindFlag("ignore-internals", rootCmd.Flags().Lookup("ignore-internals"))
+	bindFlag("nullable-array-items", rootCmd.Flags().Lookup("nullable-array-items"))
+	bindFlag("use-integer-type", rootCmd.Flags().Lookup("use-integer-type"))
+	bindFlag("int-bounds", rootCmd.Flags().Lookup("int-bounds"))
+	bindFlag("well-known-scalars", rootCmd.Flags().Lookup("well-known-scalars"))
+	bindFlag("additional-properties-false", rootCmd.Flags().Lookup("additional-properties-false"))
+	bindFlag("nullable-fields", rootCmd.Flags().Lookup("nullable-fields"))
+	bindFlag("interface-allof", rootCmd.Flags().Lookup("interface-allof"))
+	bindFlag("interface-implementations", rootCmd.Flags().Lookup("interface-implementations"))
+	bindFlag("interface-keep-fields", rootCmd.Flags().Lookup("interface-keep-fields"))
+	bindFlag("enum-style", rootCmd.Flags().Lookup("enum-style"))
+	bindFlag("field-shape", rootCmd.Flags().Lookup("field-shape"))
+	bindFlag("id-type", rootCmd.Flags().Lookup("id-type"))
+	bindFlag("operation", rootCmd.Flags().Lookup("operation"))
+	bindFlag("method", rootCmd.Flags().Lookup("method"))
+	bindFlag("definitions-only", rootCmd.Flags().Lookup("definitions-only"))
+	bindFlag("only-input-types", rootCmd.Flags().Lookup("only-input-types"))
+	bindFlag("root", rootCmd.Flags().Lookup("root"))
+	bindFlag("prune", rootCmd.Flags().Lookup("prune"))
+	bindFlag("operations-layout", rootCmd.Flags().Lookup("operations-layout"))
+	bindFlag("operations-key", rootCmd.Flags().Lookup("operations-key"))
+	bindFlag("exclude-field", rootCmd.Flags().Lookup("exclude-field"))
+	bindFlag("include-field", rootCmd.Flags().Lookup("include-field"))
+	bindFlag("exclude-type", rootCmd.Flags().Lookup("exclude-type"))
+	bindFlag("include-type", rootCmd.Flags().Lookup("include-type"))
+	bindFlag("include-type-dependencies", rootCmd.Flags().Lookup("include-type-dependencies"))
+	bindFlag("operations", rootCmd.Flags().Lookup("operations"))
+	bindFlag("overlay", rootCmd.Flags().Lookup("overlay"))
+	bindFlag("overlay-strict", rootCmd.Flags().Lookup("overlay-strict"))
+	bindFlag("sample-examples", rootCmd.Flags().Lookup("sample-examples"))
+	bindFlag("sample-depth", rootCmd.Flags().Lookup("sample-depth"))
+	bindFlag("sample-field", rootCmd.Flags().Lookup("sample-field"))
+	bindFlag("sample-redact", rootCmd.Flags().Lookup("sample-redact"))
+	bindFlag("sample-list-size", rootCmd.Flags().Lookup("sample-list-size"))
+	bindFlag("extract-examples", rootCmd.Flags().Lookup("extract-examples"))
+	bindFlag("strip-examples", rootCmd.Flags().Lookup("strip-examples"))
+	bindFlag("embed-sdl", rootCmd.Flags().Lookup("embed-sdl"))
+	bindFlag("shared-pagination-args", rootCmd.Flags().Lookup("shared-pagination-args"))
+	bindFlag("draft", rootCmd.Flags().Lookup("draft"))
+	bindFlag("continue-on-error", rootCmd.Flags().Lookup("continue-on-error"))
+	bindFlag("preset", rootCmd.Flags().Lookup("preset"))
+	bindFlag("dedupe", rootCmd.Flags().Lookup("dedupe"))
+	bindFlag("flatten-allof", rootCmd.Flags().Lookup("flatten-allof"))
+	bindFlag("target", rootCmd.Flags().Lookup("target"))
+	bindFlag("cue.package", rootCmd.Flags().Lookup("cue-package"))
+	bindFlag("response-envelope.operation", rootCmd.Flags().Lookup("envelope-operation"))
+	bindFlag("response-envelope.operation-name", rootCmd.Flags().Lookup("envelope-operation-name"))
+	bindFlag("ui-schema", rootCmd.Flags().Lookup("ui-schema"))
+	bindFlag("subscription-payloads", rootCmd.Flags().Lookup("subscription-payloads"))
+	bindFlag("progress", rootCmd.Flags().Lookup("progress"))
+	bindFlag("max-memory", rootCmd.Flags().Lookup("max-memory"))
+	bindFlag("fail-on-empty", rootCmd.Flags().Lookup("fail-on-empty"))
+	bindFlag("min-definitions", rootCmd.Flags().Lookup("min-definitions"))
+	bindFlag("check", rootCmd.Flags().Lookup("check"))
+	bindFlag("select-type", rootCmd.Flags().Lookup("select-type"))
+	bindFlag("select-pointer", rootCmd.Flags().Lookup("select-pointer"))
+	bindFlag("inline-refs", rootCmd.Flags().Lookup("inline-refs"))
+	bindFlag("inline-omit-definitions", rootCmd.Flags().Lookup("inline-omit-definitions"))
+	bindFlag("watch", rootCmd.Flags().Lookup("watch"))
+	bindFlag("interval", rootCmd.Flags().Lookup("interval"))
 }
 
-type GraphQLResponse struct {
-	Data   *pkg.IntrospectionQuery `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors,omitempty"`
+// maxResponseSize caps the size of an introspection response body
+const maxResponseSize = 256 << 20
+
+// bodyExcerptSize is how much of a response body is kept for error messages
+const bodyExcerptSize = 512
+
+// errNotModified is returned by fetchIntrospection when the endpoint answers 304 Not Modified
+var errNotModified = errors.New("introspection not modified")
+
+func getIntrospectionFromEndpoint(endpoint string, headers http.Header) (*pkg.IntrospectionQuery, error) {
+	introspection, _, err := fetchIntrospection(endpoint, headers, "")
+	return introspection, err
+}
+
+// fetchIntrospection runs the introspection query against endpoint. When etag is set it is sent as
+// If-None-Match and errNotModified is returned if the server reports the schema unchanged.
+// The returned string is the ETag of the response, if any. When the endpoint rejects the token from
+// --token-command, the command is run once more, since the token may have expired, and the request
+// is retried with the new token.
+func fetchIntrospection(endpoint string, headers http.Header, etag string) (*pkg.IntrospectionQuery, string, error) {
+	introspection, responseETag, err := requestIntrospection(endpoint, headers, etag)
+	if !errors.Is(err, errUnauthorized) {
+		return introspection, responseETag, err
+	}
+
+	logger.Info("Endpoint rejected the token, running --token-command again", "endpoint", redactSecrets(endpoint))
+	if err := applyTokenCommand(headers); err != nil {
+		return nil, "", err
+	}
+	introspection, responseETag, err = requestIntrospection(endpoint, headers, etag)
+	if errors.Is(err, errUnauthorized) {
+		return nil, "", withExitCode(ExitNetwork, fmt.Errorf("unauthorized: the endpoint rejected the token from --token-command"))
+	}
+	return introspection, responseETag, err
 }
 
-func getIntrospectionFromEndpoint(endpoint string, headers []string) (*pkg.IntrospectionQuery, error) {
+// requestIntrospection makes a single introspection request, see fetchIntrospection
+func requestIntrospection(endpoint string, headers http.Header, etag string) (*pkg.IntrospectionQuery, string, error) {
+	if isWebSocketEndpoint(endpoint) {
+		introspection, err := fetchIntrospectionWebSocket(endpoint, headers)
+		return introspection, "", err
+	}
+
 	// Prepare the request payload
 	payload := map[string]interface{}{
-		"query": introspectionQuery,
+		"query": introspectionQuery(),
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling query: %w", err)
+		return nil, "", fmt.Errorf("error marshaling query: %w", err)
 	}
 
 	// Create request
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return nil, "", fmt.Errorf("error creating request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	for _, header := range headers {
-		parts := strings.SplitN(header, ":", 2)
-		if len(parts) == 2 {
-			req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	for name, values := range headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
 		}
 	}
 
-	// Create client with timeout
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
+	// Create client with timeout and the TLS settings
+	client, err := endpointClient()
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Make request
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
+		return nil, "", withExitCode(ExitNetwork, fmt.Errorf("error making request: %w", err))
 	}
 	defer resp.Body.Close()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, errNotModified
+	}
+	if resp.StatusCode == http.StatusUnauthorized && viper.GetString("token-command") != "" {
+		return nil, "", errUnauthorized
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, "", withExitCode(ExitNetwork, fmt.Errorf("server error: %s", resp.Status))
+	}
+
+	var body io.Reader = http.MaxBytesReader(nil, resp.Body, maxResponseSize)
+	var recording *bytes.Buffer
+	if viper.GetString("record") != "" {
+		recording = &bytes.Buffer{}
+		body = io.TeeReader(body, recording)
+	}
+	data, err := decodeIntrospectionBody(body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+		return nil, "", err
+	}
+
+	if recording != nil {
+		if err := recordSession(viper.GetString("record"), req, resp, recording.Bytes()); err != nil {
+			return nil, "", withExitCode(ExitOutput, err)
+		}
 	}
+	return data, resp.Header.Get("ETag"), nil
+}
 
-	// Parse response
-	var graphqlResp GraphQLResponse
-	if err := json.Unmarshal(body, &graphqlResp); err != nil {
-		return nil, fmt.Errorf("error parsing response: %w", err)
+// decodeIntrospectionBody parses an introspection response as it arrives, keeping only its start
+// for error messages
+func decodeIntrospectionBody(r io.Reader) (*pkg.IntrospectionQuery, error) {
+	excerpt := &headBuffer{limit: bodyExcerptSize}
+	data, graphqlErrors, err := pkg.DecodeIntrospectionResponse(io.TeeReader(r, excerpt), nil)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, withExitCode(ExitNetwork, fmt.Errorf("response exceeds %d bytes", tooLarge.Limit))
+		}
+		if isNetError(err) {
+			return nil, withExitCode(ExitNetwork, fmt.Errorf("error reading response: %w", err))
+		}
+		return nil, withExitCode(ExitIntrospection, fmt.Errorf("error parsing response: %w (body starts with %q)", err, excerpt.String()))
 	}
 
 	// Check for GraphQL errors
-	if len(graphqlResp.Errors) > 0 {
-		return nil, fmt.Errorf("GraphQL error: %s", graphqlResp.Errors[0].Message)
+	if len(graphqlErrors) > 0 {
+		return nil, withExitCode(ExitIntrospection, fmt.Errorf("GraphQL error: %s", graphqlErrors[0].Message))
+	}
+
+	if data == nil {
+		return nil, withExitCode(ExitIntrospection, fmt.Errorf("no data in response"))
 	}
+	return data, nil
+}
+
+// headBuffer keeps the first limit bytes written to it and discards the rest
+type headBuffer struct {
+	bytes.Buffer
+	limit int
+}
 
-	if graphqlResp.Data == nil {
-		return nil, fmt.Errorf("no data in response")
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if room := h.limit - h.Len(); room > 0 {
+		h.Buffer.Write(p[:min(room, len(p))])
 	}
+	return len(p), nil
+}
 
-	return graphqlResp.Data, nil
+// isNetError reports whether err came from the connection rather than the response content
+func isNetError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
 
 func getIntrospectionFromStdin() (*pkg.IntrospectionQuery, error) {
@@ -292,59 +623,130 @@ func getIntrospectionFromStdin() (*pkg.IntrospectionQuery, error) {
 		return nil, nil // stdin is not piped/redirected
 	}
 
-	data, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		return nil, fmt.Errorf("error reading from stdin: %w", err)
+	// SDL is told apart from JSON by its first character
+	stdin := bufio.NewReader(os.Stdin)
+	if isSDLStream(stdin) {
+		introspection, err := readSDL(stdin)
+		if err != nil {
+			return nil, withExitCode(ExitIntrospection, fmt.Errorf("error parsing stdin data: %w", err))
+		}
+		return introspection, nil
 	}
 
-	var introspection pkg.IntrospectionQuery
-	if err := json.Unmarshal(data, &introspection); err != nil {
-		// Try unwrapping from GraphQL response
-		var graphqlResp GraphQLResponse
-		if err2 := json.Unmarshal(data, &graphqlResp); err2 == nil && graphqlResp.Data != nil {
-			return graphqlResp.Data, nil
-		}
-		return nil, fmt.Errorf("error parsing stdin data: %w", err)
+	// The decoder streams the types array and also unwraps a GraphQL response envelope
+	introspection, err := pkg.DecodeIntrospection(stdin, nil)
+	if err != nil {
+		return nil, withExitCode(ExitIntrospection, fmt.Errorf("error parsing stdin data: %w", err))
 	}
 
-	return &introspection, nil
+	return introspection, nil
 }
 
-func runConversion() error {
+// loadIntrospection resolves the introspection result from the endpoint, input file or stdin
+func loadIntrospection() (*pkg.IntrospectionQuery, error) {
 	var introspection *pkg.IntrospectionQuery
 	var err error
+	// Only a fetch or a replay sets it, so watch runs reading a file do not keep an earlier endpoint
+	servingEndpoint = ""
+
+	// A recorded session stands in for the endpoint
+	if replayFile := viper.GetString("replay"); replayFile != "" {
+		return replaySession(replayFile, configEndpoints())
+	}
 
 	// Try getting data from endpoint first
-	if endpoint := viper.GetString("endpoint"); endpoint != "" {
-		introspection, err = getIntrospectionFromEndpoint(endpoint, viper.GetStringSlice("headers"))
+	if endpoints := configEndpoints(); len(endpoints) > 0 {
+		logger.Info("Fetching schema from endpoint", "endpoint", redactSecrets(endpoints[0]))
+		headers, err := requestHeaders()
 		if err != nil {
-			return err
+			return nil, err
 		}
+		start := time.Now()
+		introspection, _, servingEndpoint, err = fetchIntrospectionFailover(endpoints, headers, "")
+		if err != nil {
+			return nil, err
+		}
+		logPhase("fetch", start)
 	} else if inputFile := viper.GetString("input"); inputFile != "" {
 		// Try input file
-		data, err := os.ReadFile(inputFile)
+		introspection, err = loadIntrospectionFile(inputFile)
 		if err != nil {
-			return fmt.Errorf("error reading input file: %w", err)
-		}
-
-		if err := json.Unmarshal(data, &introspection); err != nil {
-			return fmt.Errorf("error parsing input file: %w", err)
+			return nil, err
 		}
 	} else {
 		// Try stdin
 		introspection, err = getIntrospectionFromStdin()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if introspection == nil {
-			return fmt.Errorf("no input provided: use --endpoint, --input, or pipe data to stdin")
+			return nil, withExitCode(ExitUsage, fmt.Errorf("no input provided: use --endpoint, --input, or pipe data to stdin"))
+		}
+	}
+
+	return introspection, nil
+}
+
+// loadIntrospectionFile reads an introspection query result from a file or an s3:// or gs:// object
+func loadIntrospectionFile(inputFile string) (*pkg.IntrospectionQuery, error) {
+	start := time.Now()
+	f, err := openInput(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading input file: %w", err)
+	}
+	defer f.Close()
+
+	var introspection *pkg.IntrospectionQuery
+	if slices.Contains(sdlExtensions, strings.ToLower(filepath.Ext(inputFile))) {
+		introspection, err = readSDL(f)
+	} else {
+		// Reading and parsing happen together as the types array is streamed
+		introspection, err = pkg.DecodeIntrospection(f, nil)
+	}
+	if err != nil {
+		return nil, withExitCode(ExitIntrospection, fmt.Errorf("error parsing input file: %w", err))
+	}
+	logPhase("parse", start)
+	return introspection, nil
+}
+
+// sdlExtensions are the extensions of input files read as GraphQL schema language
+var sdlExtensions = []string{".graphql", ".graphqls", ".gql"}
+
+// readSDL reads a schema in GraphQL schema language into the introspection result it stands for
+func readSDL(r io.Reader) (*pkg.IntrospectionQuery, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.IntrospectionFromSDL(string(data))
+}
+
+// isSDLStream reports whether r holds schema language rather than JSON: an introspection result
+// starts with "{", which no schema document can
+func isSDLStream(r *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		peeked, err := r.Peek(n)
+		if err != nil {
+			return false
+		}
+		switch c := peeked[n-1]; c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return c != '{'
 		}
 	}
+}
 
-	// Create conversion options
+// buildOptions resolves the conversion options from flags, environment and config
+func buildOptions() (*pkg.Options, error) {
 	idMapping := pkg.IDTypeMapping(viper.GetString("id-type"))
 	if !pkg.IsValidIDTypeMapping(idMapping) {
-		return fmt.Errorf("invalid id-type mapping: %s", idMapping)
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid id-type mapping: %s", idMapping))
+	}
+	draft := pkg.Draft(viper.GetString("draft"))
+	if !pkg.IsValidDraft(draft) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid draft: %s (must be 'draft-06', 'draft-07' or '2020-12')", draft))
 	}
 
 	// Set up options
@@ -357,55 +759,444 @@ func runConversion() error {
 		case "mutation":
 			mutationOp := pkg.OperationMutation
 			op = &mutationOp
+		case "subscription":
+			subscriptionOp := pkg.OperationSubscription
+			op = &subscriptionOp
 		default:
-			return fmt.Errorf("invalid operation type: %s (must be 'query' or 'mutation')", opStr)
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid operation type: %s (must be 'query', 'mutation' or 'subscription')", opStr))
 		}
 	}
 
-	opts := pkg.Options{
-		IgnoreInternals:    viper.GetBool("ignore-internals"),
-		NullableArrayItems: viper.GetBool("nullable-array-items"),
-		IDTypeMapping:      idMapping,
-		Operation:          op,
-		MethodName:         viper.GetString("method"),
+	var roots []pkg.OperationType
+	for _, root := range viper.GetStringSlice("root") {
+		if root == "all" {
+			roots = nil
+			break
+		}
+		if !pkg.IsValidOperationType(pkg.OperationType(root)) {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid root: %s (must be 'query', 'mutation', 'subscription' or 'all')", root))
+		}
+		roots = append(roots, pkg.OperationType(root))
 	}
 
-	// Convert to JSON Schema
-	schema, err := pkg.FromIntrospectionQuery(*introspection, &opts)
+	nullable := pkg.NullableFields(viper.GetString("nullable-fields"))
+	if !pkg.IsValidNullableFields(nullable) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid nullable-fields: %s (must be 'off', 'typeArray' or 'anyOf')", nullable))
+	}
+
+	enums := pkg.EnumStyle(viper.GetString("enum-style"))
+	if !pkg.IsValidEnumStyle(enums) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid enum-style: %s (must be 'anyOf' or 'flat')", enums))
+	}
+	shape := pkg.FieldShape(viper.GetString("field-shape"))
+	if !pkg.IsValidFieldShape(shape) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid field-shape: %s (must be 'wrapped' or 'plain')", shape))
+	}
+	implementations := pkg.InterfaceImplementations(viper.GetString("interface-implementations"))
+	if !pkg.IsValidInterfaceImplementations(implementations) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid interface-implementations: %s (must be 'off', 'anyOf' or 'oneOf')", implementations))
+	}
+	if viper.GetBool("interface-allof") && implementations != "" && implementations != pkg.InterfaceImplementationsOff {
+		return nil, withExitCode(ExitUsage, errors.New("--interface-allof cannot be combined with --interface-implementations"))
+	}
+
+	layout := pkg.OperationsLayout(viper.GetString("operations-layout"))
+	if !pkg.IsValidOperationsLayout(layout) {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid operations layout: %s (must be 'nested', 'flat' or 'both')", layout))
+	}
+	// Inlining is applied by the command, see selectionFromConfig, but a bad value is a usage error
+	if _, _, err := parseInlineRefs(viper.GetString("inline-refs")); err != nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --inline-refs: %w", err))
+	}
+
+	for _, key := range []string{"exclude-field", "include-field"} {
+		if err := pkg.CheckFieldPatterns(viper.GetStringSlice(key)); err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --%s: %w", key, err))
+		}
+	}
+	for _, key := range []string{"exclude-type", "include-type"} {
+		if err := pkg.CheckTypePatterns(viper.GetStringSlice(key)); err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --%s: %w", key, err))
+		}
+	}
+
+	goHints, err := goHintsFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	scalarSchemas, err := scalarSchemasFromConfig()
 	if err != nil {
-		return fmt.Errorf("error converting to JSON Schema: %w", err)
+		return nil, err
 	}
 
-	// Marshal the result
-	output, err := json.MarshalIndent(schema, "", "  ")
+	opts := &pkg.Options{
+		IgnoreInternals:           viper.GetBool("ignore-internals"),
+		NullableArrayItems:        viper.GetBool("nullable-array-items"),
+		IDTypeMapping:             idMapping,
+		Operation:                 op,
+		MethodName:                viper.GetString("method"),
+		DefinitionsOnly:           viper.GetBool("definitions-only"),
+		OnlyInputTypes:            viper.GetBool("only-input-types"),
+		Roots:                     roots,
+		PruneToRoots:              viper.GetBool("prune"),
+		Progress:                  progressCallback(),
+		Logger:                    logger,
+		OperationsLayout:          layout,
+		OperationsKey:             viper.GetString("operations-key"),
+		ExcludeFields:             viper.GetStringSlice("exclude-field"),
+		IncludeFields:             viper.GetStringSlice("include-field"),
+		ExcludeTypes:              viper.GetStringSlice("exclude-type"),
+		IncludeTypes:              viper.GetStringSlice("include-type"),
+		IncludeTypeDependencies:   viper.GetBool("include-type-dependencies"),
+		ExtractExamples:           viper.GetBool("extract-examples"),
+		StripExamples:             viper.GetBool("strip-examples"),
+		EmbedSDL:                  viper.GetBool("embed-sdl"),
+		Lenient:                   viper.GetBool("continue-on-error"),
+		GoHints:                   goHints,
+		CustomScalarSchemas:       scalarSchemas,
+		SharedPaginationArgs:      viper.GetBool("shared-pagination-args"),
+		Draft:                     draft,
+		UseIntegerType:            viper.GetBool("use-integer-type"),
+		IntBounds:                 viper.GetBool("int-bounds"),
+		WellKnownScalars:          viper.GetBool("well-known-scalars"),
+		AdditionalPropertiesFalse: viper.GetBool("additional-properties-false"),
+		NullableFields:            nullable,
+		InterfaceAllOf:            viper.GetBool("interface-allof"),
+		InterfaceImplementations:  implementations,
+		InterfaceKeepFields:       viper.GetBool("interface-keep-fields"),
+		EnumStyle:                 enums,
+		FieldShape:                shape,
+	}
+	// Use case presets are applied as defaults, see applyUseCasePreset
+	if name := viper.GetString("preset"); name != "" {
+		_, isUseCase := pkg.LookupUseCasePreset(name)
+		preset, isServer := pkg.LookupPreset(name)
+		switch {
+		case isServer:
+			preset.Apply(opts)
+		case !isUseCase:
+			names := append(pkg.UseCasePresetNames(), pkg.PresetNames()...)
+			return nil, withExitCode(ExitUsage, fmt.Errorf("unknown preset: %s (must be one of %s, or help to list them)", name, strings.Join(names, ", ")))
+		}
+	}
+
+	logger.Debug("Resolved options",
+		"ignoreInternals", opts.IgnoreInternals,
+		"nullableArrayItems", opts.NullableArrayItems,
+		"idTypeMapping", opts.IDTypeMapping,
+		"operation", viper.GetString("operation"),
+		"method", opts.MethodName,
+		"definitionsOnly", opts.DefinitionsOnly,
+		"onlyInputTypes", opts.OnlyInputTypes,
+		"roots", opts.Roots,
+		"pruneToRoots", opts.PruneToRoots,
+		"operationsLayout", opts.OperationsLayout,
+		"excludeFields", opts.ExcludeFields,
+		"includeFields", opts.IncludeFields,
+		"extractExamples", opts.ExtractExamples,
+		"embedSDL", opts.EmbedSDL,
+		"lenient", opts.Lenient,
+		"goHints", len(opts.GoHints),
+		"customScalars", sortedSchemaKeys(opts.CustomScalarSchemas),
+		"sharedPaginationArgs", opts.SharedPaginationArgs,
+		"draft", opts.Draft,
+		"useIntegerType", opts.UseIntegerType,
+		"intBounds", opts.IntBounds,
+		"wellKnownScalars", opts.WellKnownScalars,
+		"additionalPropertiesFalse", opts.AdditionalPropertiesFalse,
+		"nullableFields", opts.NullableFields,
+		"interfaceAllOf", opts.InterfaceAllOf,
+		"interfaceImplementations", opts.InterfaceImplementations,
+		"interfaceKeepFields", opts.InterfaceKeepFields,
+		"enumStyle", opts.EnumStyle,
+		"fieldShape", opts.FieldShape,
+		"preset", viper.GetString("preset"),
+		"excludeTypes", opts.ExcludeTypes,
+		"includeTypes", opts.IncludeTypes,
+		"includeTypeDependencies", opts.IncludeTypeDependencies,
+	)
+
+	return opts, nil
+}
+
+func runConversion() error {
+	if viper.GetString("preset") == presetHelp {
+		printPresets(os.Stdout)
+		return nil
+	}
+	if viper.GetBool("split-roots") {
+		if viper.GetBool("watch") {
+			return withExitCode(ExitUsage, fmt.Errorf("--split-roots is not supported with --watch"))
+		}
+		if t := viper.GetString("target"); t != targetJSONSchema {
+			return withExitCode(ExitUsage, fmt.Errorf("--split-roots is not supported with --target %s", t))
+		}
+	}
+	if viper.GetBool("watch") {
+		return runWatch()
+	}
+
+	switch viper.GetString("target") {
+	case targetJSONSchema:
+	case targetBigQuery:
+		return runTargetExport(targetBigQuery, generateBigQuery)
+	case targetCUE:
+		return runTargetExport(targetCUE, generateCUE)
+	case targetResponseEnvelope:
+		return runTargetExport(targetResponseEnvelope, generateResponseEnvelope)
+	case targetK8sConfigMap:
+		return runTargetExport(targetK8sConfigMap, generateConfigMap)
+	case targetPydantic:
+		return runTargetExport(targetPydantic, generatePydantic)
+	case targetOpenAPI:
+		return runTargetExport(targetOpenAPI, generateOpenAPI)
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("invalid target: %s (must be '%s', '%s', '%s', '%s', '%s', '%s' or '%s')", viper.GetString("target"), targetJSONSchema, targetBigQuery, targetCUE, targetResponseEnvelope, targetK8sConfigMap, targetPydantic, targetOpenAPI))
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+
+	metadataFile := viper.GetString("metadata-out")
+	var stopCounting func() map[string]int
+	if metadataFile != "" {
+		stopCounting = countWarnings()
+	}
+	schema, output, err := generateSchema(introspection)
+	var warnings map[string]int
+	if stopCounting != nil {
+		warnings = stopCounting()
+	}
 	if err != nil {
-		return fmt.Errorf("error marshaling JSON Schema: %w", err)
+		return err
 	}
 
 	// Write output
 	outputFile := viper.GetString("output")
-	if outputFile == "" {
-		// Write to stdout
-		fmt.Println(string(output))
-		return nil
+	if viper.GetBool("split-roots") {
+		if err := writeSplitRoots(outputFile, schema); err != nil || viper.GetBool("check") {
+			return err
+		}
+	} else if viper.GetBool("check") {
+		return checkOutput(outputFile, schema, output)
+	} else if err := writeOutput(outputFile, output); err != nil {
+		return err
+	}
+	if metadataFile != "" {
+		if err := writeMetadata(metadataFile, introspection, schema, output, warnings); err != nil {
+			return err
+		}
+	}
+	if viper.GetBool("size-report") {
+		report, err := newSizeReport(schema, 20)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, formatSizeReport(report))
+	}
+	if path := viper.GetString("ui-schema"); path != "" {
+		if err := writeUISchema(path, introspection, schema); err != nil {
+			return err
+		}
+	}
+	if dir := viper.GetString("subscription-payloads"); dir != "" {
+		return writeSubscriptionPayloads(dir, introspection)
+	}
+	return nil
+}
+
+// generateSchema converts the introspection result and marshals the JSON Schema
+func generateSchema(introspection *pkg.IntrospectionQuery) (*pkg.JSONSchema6, []byte, error) {
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if path := viper.GetString("operations"); path != "" {
+		introspection, err = trimToOperations(introspection, path)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Reuse the output of an earlier conversion of the same introspection with the same options.
+	// Sampled examples are live data and post-process commands may change between runs, so those
+	// conversions are never cached.
+	cache := openConversionCache()
+	if viper.GetBool("sample-examples") || len(viper.GetStringSlice("post-process-cmd")) > 0 {
+		cache = nil
+	}
+	var key string
+	if cache != nil {
+		start := time.Now()
+		key, err = cacheKey(introspection, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if output, ok := cache.get(key); ok {
+			var schema *pkg.JSONSchema6
+			if err := json.Unmarshal(output, &schema); err == nil {
+				logPhase("cache", start)
+				logger.Debug("Using cached conversion", "key", key)
+				pkg.UseDefinitions(schema)
+				if err := checkSanity(schema); err != nil {
+					return nil, nil, withExitCode(ExitConversion, err)
+				}
+				return schema, output, nil
+			}
+		}
 	}
 
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
-		return fmt.Errorf("error creating output directory: %w", err)
+	// Convert to JSON Schema
+	start := time.Now()
+	schema, err := pkg.FromIntrospectionQuery(*introspection, opts)
+	if err != nil {
+		return nil, nil, withExitCode(ExitConversion, fmt.Errorf("error converting to JSON Schema: %w", err))
 	}
+	logPhase("convert", start)
+	// The steps below work on definitions; the layout of the selected draft is restored for output
+	pkg.UseDefinitions(schema)
 
-	// Write to file
-	if err := os.WriteFile(outputFile, output, 0644); err != nil {
-		return fmt.Errorf("error writing output file: %w", err)
+	if viper.GetBool("sample-examples") {
+		sampleExamples(schema, introspection, opts)
+	}
+
+	schema, err = selectionFromConfig(schema)
+	if err != nil {
+		return nil, nil, withExitCode(ExitConversion, err)
+	}
+
+	if viper.GetBool("flatten-allof") {
+		schema, err = pkg.MergeAllOf(schema)
+		if err != nil {
+			return nil, nil, withExitCode(ExitConversion, fmt.Errorf("error flattening allOf: %w", err))
+		}
+	}
+
+	// Renaming follows selection, so --select-type and --select-pointer use the GraphQL names
+	if path := viper.GetString("rename-map"); path != "" {
+		if err := applyRenameMap(schema, path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Deduplication runs after selection so it also applies to extracted and inlined schemas
+	if viper.GetBool("dedupe") {
+		start = time.Now()
+		stats, err := pkg.DedupeSubschemas(schema, 0)
+		if err != nil {
+			return nil, nil, withExitCode(ExitConversion, err)
+		}
+		logPhase("dedupe", start)
+		logger.Info("Deduplicated subschemas", "definitions", stats.Definitions, "replaced", stats.Replaced, "bytesSaved", stats.BytesSaved)
+	}
+
+	pkg.SetDraft(schema, opts.Draft)
+
+	// The overlay is merged last so it applies to the final shape of the document
+	var output []byte
+	if path := viper.GetString("overlay"); path != "" {
+		schema, output, err = applyOverlayFile(schema, path)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Marshal the result
+	if output == nil {
+		start = time.Now()
+		output, err = json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, nil, fmt.Errorf("error marshaling JSON Schema: %w", err)
+		}
+		logPhase("marshal", start)
+	}
+
+	// External commands see the document exactly as it would be written
+	if commands := viper.GetStringSlice("post-process-cmd"); len(commands) > 0 {
+		start = time.Now()
+		schema, output, err = postProcess(schema, output, commands)
+		if err != nil {
+			return nil, nil, err
+		}
+		logPhase("post-process", start)
+	}
+
+	// Callers work on definitions whatever the draft of the output
+	pkg.UseDefinitions(schema)
+	if err := checkSanity(schema); err != nil {
+		return nil, nil, withExitCode(ExitConversion, err)
+	}
+
+	if cache != nil {
+		cache.put(key, output)
+	}
+
+	return schema, output, nil
+}
+
+// checkSanity rejects empty or suspiciously small outputs, after all filtering and selection has been applied
+func checkSanity(schema *pkg.JSONSchema6) error {
+	definitions, properties := len(schema.Definitions), len(schema.Properties)
+
+	if viper.GetBool("fail-on-empty") && definitions == 0 && properties == 0 {
+		return fmt.Errorf("output is empty: 0 definitions and 0 root properties (use --fail-on-empty=false to allow this)")
+	}
+
+	if minimum := viper.GetInt("min-definitions"); definitions < minimum {
+		return fmt.Errorf("output has %d definitions, at least %d required by --min-definitions", definitions, minimum)
 	}
 
 	return nil
 }
 
+// checkOutput compares the generated schema against the existing output file using canonical serialization
+func checkOutput(outputFile string, schema *pkg.JSONSchema6, output []byte) error {
+	if outputFile == "" {
+		return withExitCode(ExitUsage, fmt.Errorf("--check requires --output"))
+	}
+
+	existing, err := readOutput(outputFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return withExitCode(ExitMismatch, fmt.Errorf("check failed: %s does not exist, run without --check to generate it", outputFile))
+	}
+	if err != nil {
+		return fmt.Errorf("error reading output file: %w", err)
+	}
+
+	// Targets that are not JSON, like CUE and YAML manifests, are compared byte for byte
+	if generatedCanonical, err := pkg.CanonicalJSON(output); err != nil {
+		if bytes.Equal(bytes.TrimRight(existing, "\n"), bytes.TrimRight(output, "\n")) {
+			return nil
+		}
+	} else {
+		existingCanonical, err := pkg.CanonicalJSON(existing)
+		if err != nil {
+			return withExitCode(ExitMismatch, fmt.Errorf("check failed: %s is not valid JSON: %w", outputFile, err))
+		}
+		if bytes.Equal(existingCanonical, generatedCanonical) {
+			return nil
+		}
+	}
+
+	// A change summary is only available for JSON Schema output; other targets pass a nil schema
+	var existingSchema pkg.JSONSchema6
+	if schema != nil && json.Unmarshal(existing, &existingSchema) == nil {
+		pkg.UseDefinitions(&existingSchema)
+		changes := pkg.DiffSchemas(&existingSchema, schema, pkg.DiffOptions{})
+		for _, line := range strings.Split(strings.TrimSpace(summarizeChanges(changes)), "\n") {
+			logger.Warn("Output differs", "changes", line)
+		}
+	}
+
+	return withExitCode(ExitMismatch, fmt.Errorf("check failed: %s is out of date, regenerate it", outputFile))
+}
+
 func Execute() error {
 	if err := rootCmd.Execute(); err != nil {
-		return err
+		return classifyError(err)
 	}
 	return nil
 }
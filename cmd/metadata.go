@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// metadataVersion is the version of the --metadata-out format. It is incremented when fields are
+// removed or change meaning; new fields may be added without a new version.
+const metadataVersion = 1
+
+var metadataOut string
+
+func init() {
+	rootCmd.Flags().StringVar(&metadataOut, "metadata-out", "", "write a JSON sidecar describing the generated output (version, source, hashes, options, warnings and stats) to this file")
+	bindFlag("metadata-out", rootCmd.Flags().Lookup("metadata-out"))
+}
+
+// artifactMetadata is the --metadata-out sidecar, describing how an output was generated
+type artifactMetadata struct {
+	MetadataVersion   int                    `json:"metadataVersion"`
+	Tool              metadataTool           `json:"tool"`
+	Source            string                 `json:"source"`
+	Output            string                 `json:"output"`
+	Target            string                 `json:"target"`
+	GeneratedAt       time.Time              `json:"generatedAt"`
+	IntrospectionHash string                 `json:"introspectionHash"`
+	SchemaHash        string                 `json:"schemaHash"`
+	Options           map[string]interface{} `json:"options"`
+	Warnings          map[string]int         `json:"warnings"`
+	Stats             *metadataStats         `json:"stats,omitempty"`
+}
+
+// metadataTool identifies the build that generated the output
+type metadataTool struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Revision string `json:"revision,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// metadataStats summarizes a JSON Schema output
+type metadataStats struct {
+	Definitions    int `json:"definitions"`
+	RootProperties int `json:"rootProperties"`
+	Bytes          int `json:"bytes"`
+}
+
+// warningCounter counts the log records carrying a warning code, including those below the log
+// level, and passes the records on to the handler it wraps
+type warningCounter struct {
+	slog.Handler
+	counts *warningCounts
+}
+
+type warningCounts struct {
+	mu     sync.Mutex
+	byCode map[string]int
+}
+
+func (h *warningCounter) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *warningCounter) Handle(ctx context.Context, r slog.Record) error {
+	r.Attrs(func(attr slog.Attr) bool {
+		if attr.Key != pkg.LogKeyWarning {
+			return true
+		}
+		h.counts.mu.Lock()
+		h.counts.byCode[attr.Value.String()]++
+		h.counts.mu.Unlock()
+		return false
+	})
+	if !h.Handler.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *warningCounter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &warningCounter{Handler: h.Handler.WithAttrs(attrs), counts: h.counts}
+}
+
+func (h *warningCounter) WithGroup(name string) slog.Handler {
+	return &warningCounter{Handler: h.Handler.WithGroup(name), counts: h.counts}
+}
+
+// countWarnings makes the logger count warnings by code until stop is called, which restores it
+// and returns the counts
+func countWarnings() (stop func() map[string]int) {
+	previous := logger
+	counts := &warningCounts{byCode: map[string]int{}}
+	logger = slog.New(&warningCounter{Handler: previous.Handler(), counts: counts})
+	return func() map[string]int {
+		logger = previous
+		counts.mu.Lock()
+		defer counts.mu.Unlock()
+		return counts.byCode
+	}
+}
+
+// newArtifactMetadata describes output generated from introspection. schema is nil for targets
+// other than JSON Schema, which have no stats.
+func newArtifactMetadata(introspection *pkg.IntrospectionQuery, schema *pkg.JSONSchema6, output []byte, warnings map[string]int) (*artifactMetadata, error) {
+	introspectionJSON, err := json.Marshal(introspection)
+	if err != nil {
+		return nil, fmt.Errorf("error hashing introspection: %w", err)
+	}
+	schemaHash := hashBytes(output)
+	if canonical, err := pkg.CanonicalJSON(output); err == nil {
+		schemaHash = canonicalHash(canonical)
+	}
+
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, err
+	}
+	options := conversionSettings(opts)
+	options["preset"] = viper.GetString("preset")
+	options["overlay"] = viper.GetString("overlay")
+	options["operations"] = viper.GetString("operations")
+	options["rename-map"] = viper.GetString("rename-map")
+
+	outputFile := viper.GetString("output")
+	if outputFile == "" {
+		outputFile = "stdout"
+	}
+	metadata := &artifactMetadata{
+		MetadataVersion:   metadataVersion,
+		Tool:              buildTool(),
+		Source:            snapshotSource(),
+		Output:            outputFile,
+		Target:            viper.GetString("target"),
+		GeneratedAt:       time.Now().UTC(),
+		IntrospectionHash: hashBytes(introspectionJSON),
+		SchemaHash:        schemaHash,
+		Options:           options,
+		Warnings:          warnings,
+	}
+	if schema != nil {
+		metadata.Stats = &metadataStats{
+			Definitions:    len(schema.Definitions),
+			RootProperties: len(schema.Properties),
+			Bytes:          len(output),
+		}
+	}
+	return metadata, nil
+}
+
+// writeMetadata writes the --metadata-out sidecar for output, which must already have been written,
+// so a failed generation never leaves a sidecar describing it
+func writeMetadata(path string, introspection *pkg.IntrospectionQuery, schema *pkg.JSONSchema6, output []byte, warnings map[string]int) error {
+	metadata, err := newArtifactMetadata(introspection, schema, output, warnings)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling metadata: %w", err)
+	}
+	if err := replaceOutput(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing --metadata-out: %w", err)
+	}
+	logger.Debug("Wrote metadata", "file", path)
+	return nil
+}
+
+// hashBytes is the SHA-256 hex digest of data
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// buildTool reads the module version and VCS revision the binary was built from
+func buildTool() metadataTool {
+	tool := metadataTool{Name: "gql2jsonschema", Version: "unknown"}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return tool
+	}
+	tool.Version = info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			tool.Revision = setting.Value
+		case "vcs.modified":
+			tool.Modified = setting.Value == "true"
+		}
+	}
+	return tool
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// objectStore reads and writes the objects of a cloud storage service
+type objectStore interface {
+	// open returns the content of an object
+	open(bucket, key string) (io.ReadCloser, error)
+	// exists reports whether an object exists
+	exists(bucket, key string) (bool, error)
+	// put creates or replaces an object with the content read from body
+	put(bucket, key string, body io.Reader, contentType string) error
+}
+
+// objectErrorKind tells apart the ways an object storage request fails
+type objectErrorKind int
+
+const (
+	objectNotFound objectErrorKind = iota
+	objectAccessDenied
+	objectUnavailable
+)
+
+// objectError is a failed object storage request. Missing objects match fs.ErrNotExist, so they
+// are handled like missing files.
+type objectError struct {
+	URL  string
+	Kind objectErrorKind
+	Err  error
+}
+
+func (e *objectError) Error() string {
+	switch e.Kind {
+	case objectNotFound:
+		return fmt.Sprintf("%s does not exist", e.URL)
+	case objectAccessDenied:
+		return fmt.Sprintf("access denied to %s: %v", e.URL, e.Err)
+	default:
+		return fmt.Sprintf("error reaching %s: %v", e.URL, e.Err)
+	}
+}
+
+func (e *objectError) Unwrap() error {
+	return e.Err
+}
+
+func (e *objectError) Is(target error) bool {
+	return e.Kind == objectNotFound && target == fs.ErrNotExist
+}
+
+// isObjectURL reports whether a path names an object in S3 (s3://bucket/key) or Google Cloud
+// Storage (gs://bucket/key) rather than a local file
+func isObjectURL(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://")
+}
+
+// parseObjectURL returns the store, bucket and key of an object URL
+func parseObjectURL(objectURL string) (objectStore, string, string, error) {
+	scheme, rest, _ := strings.Cut(objectURL, "://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return nil, "", "", withExitCode(ExitUsage, fmt.Errorf("invalid object URL %s: must be %s://bucket/key", objectURL, scheme))
+	}
+	switch scheme {
+	case "s3":
+		return &s3Store{}, bucket, key, nil
+	case "gs":
+		return &gcsStore{}, bucket, key, nil
+	}
+	return nil, "", "", withExitCode(ExitUsage, fmt.Errorf("unsupported object URL %s", objectURL))
+}
+
+// openObject returns the content of the object at an object URL
+func openObject(objectURL string) (io.ReadCloser, error) {
+	store, bucket, key, err := parseObjectURL(objectURL)
+	if err != nil {
+		return nil, err
+	}
+	return store.open(bucket, key)
+}
+
+// openInput opens a local file or an object URL for reading
+func openInput(path string) (io.ReadCloser, error) {
+	if isObjectURL(path) {
+		return openObject(path)
+	}
+	return os.Open(path)
+}
+
+// readOutput reads an existing output file or object. Missing outputs match fs.ErrNotExist.
+func readOutput(path string) ([]byte, error) {
+	if !isObjectURL(path) {
+		return os.ReadFile(path)
+	}
+	r, err := openObject(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// outputExists reports whether an output file or object exists
+func outputExists(path string) (bool, error) {
+	if !isObjectURL(path) {
+		_, err := os.Stat(path)
+		return err == nil, nil
+	}
+	store, bucket, key, err := parseObjectURL(path)
+	if err != nil {
+		return false, err
+	}
+	return store.exists(bucket, key)
+}
+
+// putObject streams output to an object URL as JSON. Uploads replace the object at once, so
+// readers never see a partial object, like writeFileAtomic for files.
+func putObject(objectURL string, output io.Reader) error {
+	store, bucket, key, err := parseObjectURL(objectURL)
+	if err != nil {
+		return err
+	}
+	return store.put(bucket, key, output, "application/json")
+}
+
+// objectClient is the HTTP client of object storage requests
+func objectClient() *http.Client {
+	return &http.Client{Timeout: time.Duration(viper.GetInt("timeout")) * time.Second}
+}
+
+// objectResponseError classifies a failed object storage response. message extracts the
+// service's error message from the body.
+func objectResponseError(objectURL string, resp *http.Response, message func([]byte) string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	detail := resp.Status
+	if m := message(bytes.TrimSpace(body)); m != "" {
+		detail += ": " + m
+	}
+	return objectStatusError(objectURL, resp.StatusCode, errors.New(detail))
+}
+
+// objectStatusError classifies a failed object storage request by its response status
+func objectStatusError(objectURL string, status int, err error) error {
+	switch status {
+	case http.StatusNotFound:
+		return &objectError{URL: objectURL, Kind: objectNotFound, Err: err}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return withExitCode(ExitNetwork, &objectError{URL: objectURL, Kind: objectAccessDenied, Err: err})
+	}
+	return withExitCode(ExitNetwork, &objectError{URL: objectURL, Kind: objectUnavailable, Err: err})
+}
+
+// objectRequestError classifies an object storage request that got no response
+func objectRequestError(objectURL string, err error) error {
+	return withExitCode(ExitNetwork, &objectError{URL: objectURL, Kind: objectUnavailable, Err: err})
+}
+
+// objectCredentialsError reports that no credentials could be found or exchanged for a store
+func objectCredentialsError(objectURL string, err error) error {
+	return withExitCode(ExitNetwork, &objectError{URL: objectURL, Kind: objectAccessDenied, Err: err})
+}
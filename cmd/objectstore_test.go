@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// storedObject is an object held by a fake object store
+type storedObject struct {
+	body          []byte
+	contentType   string
+	contentLength int64
+}
+
+// fakeS3 is an S3-compatible service addressed path-style. It accepts requests signed with the
+// test-key access key only, and answers requests for a bucket in regions signed for another region
+// with a redirect naming the bucket's region, as S3 does.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]storedObject
+	regions map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	auth := r.Header.Get("Authorization")
+	if !strings.Contains(auth, "Credential=test-key/") {
+		s3ErrorResponse(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+		return
+	}
+	if region := f.regions[bucket]; region != "" && !strings.Contains(auth, "/"+region+"/s3/") {
+		w.Header().Set("X-Amz-Bucket-Region", region)
+		s3ErrorResponse(w, http.StatusMovedPermanently, "PermanentRedirect", "The bucket is in another region")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[bucket+"/"+key] = storedObject{body: body, contentType: r.Header.Get("Content-Type"), contentLength: r.ContentLength}
+	case http.MethodGet, http.MethodHead:
+		object, ok := f.objects[bucket+"/"+key]
+		if !ok {
+			s3ErrorResponse(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.body)
+	default:
+		s3ErrorResponse(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func s3ErrorResponse(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
+
+// startFakeS3 serves a fake S3 for the test and points the AWS SDK at it with static credentials,
+// keeping the shared config files and instance metadata of the machine out of the way
+func startFakeS3(t *testing.T) *fakeS3 {
+	t.Helper()
+	fake := &fakeS3{objects: make(map[string]storedObject), regions: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	for name, value := range map[string]string{
+		"AWS_ENDPOINT_URL_S3":                server.URL,
+		"AWS_ACCESS_KEY_ID":                  "test-key",
+		"AWS_SECRET_ACCESS_KEY":              "test-secret",
+		"AWS_SESSION_TOKEN":                  "",
+		"AWS_PROFILE":                        "",
+		"AWS_REGION":                         "us-east-1",
+		"AWS_CONFIG_FILE":                    filepath.Join(dir, "config"),
+		"AWS_SHARED_CREDENTIALS_FILE":        filepath.Join(dir, "credentials"),
+		"AWS_EC2_METADATA_DISABLED":          "true",
+		"AWS_WEB_IDENTITY_TOKEN_FILE":        "",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": "",
+	} {
+		t.Setenv(name, value)
+	}
+	return fake
+}
+
+// fakeGCS is a Cloud Storage emulator serving the JSON API requests of gcsStore. The private
+// bucket refuses every request.
+type fakeGCS struct {
+	mu      sync.Mutex
+	objects map[string]storedObject
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch rest, upload := strings.CutPrefix(r.URL.Path, "/upload/storage/v1/b/"); {
+	case upload && r.Method == http.MethodPost:
+		bucket, _, _ := strings.Cut(rest, "/")
+		if bucket == "private" {
+			gcsErrorResponse(w, http.StatusForbidden, "no access to bucket private")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		name := r.URL.Query().Get("name")
+		f.objects[bucket+"/"+name] = storedObject{body: body, contentType: r.Header.Get("Content-Type"), contentLength: r.ContentLength}
+		json.NewEncoder(w).Encode(map[string]string{"bucket": bucket, "name": name})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/"):
+		bucket, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"), "/o/")
+		if bucket == "private" {
+			gcsErrorResponse(w, http.StatusForbidden, "no access to bucket private")
+			return
+		}
+		object, ok := f.objects[bucket+"/"+name]
+		if !ok {
+			gcsErrorResponse(w, http.StatusNotFound, "No such object: "+bucket+"/"+name)
+			return
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			w.Header().Set("Content-Type", object.contentType)
+			w.Write(object.body)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"bucket": bucket, "name": name, "contentType": object.contentType})
+	default:
+		gcsErrorResponse(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func gcsErrorResponse(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": status, "message": message}})
+}
+
+// startFakeGCS serves a Cloud Storage emulator for the test and points STORAGE_EMULATOR_HOST at it
+func startFakeGCS(t *testing.T) *fakeGCS {
+	t.Helper()
+	fake := &fakeGCS{objects: make(map[string]storedObject)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+	return fake
+}
+
+// objectStores runs a test against both fake stores, passing the scheme of their URLs and a function
+// returning a stored object
+func objectStores(t *testing.T, test func(t *testing.T, scheme string, stored func(path string) (storedObject, bool))) {
+	t.Run("s3", func(t *testing.T) {
+		fake := startFakeS3(t)
+		test(t, "s3", func(path string) (storedObject, bool) {
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			object, ok := fake.objects[path]
+			return object, ok
+		})
+	})
+	t.Run("gs", func(t *testing.T) {
+		fake := startFakeGCS(t)
+		test(t, "gs", func(path string) (storedObject, bool) {
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			object, ok := fake.objects[path]
+			return object, ok
+		})
+	})
+}
+
+func TestObjectStorageOutput(t *testing.T) {
+	objectStores(t, func(t *testing.T, scheme string, stored func(string) (storedObject, bool)) {
+		input := writeFile(t, "introspection.json", string(introspectionBody(t, 2)))
+		output := scheme + "://schemas/published/schema.json"
+
+		if result := runCLI(t, "--no-config", "-i", input, "-o", output); result.err != nil {
+			t.Fatalf("upload: %v\n%s", result.err, result.stderr)
+		}
+		object, ok := stored("schemas/published/schema.json")
+		if !ok {
+			t.Fatal("the output was not uploaded")
+		}
+		if object.contentType != "application/json" {
+			t.Errorf("Content-Type %q, want application/json", object.contentType)
+		}
+		if schema := decodeSchema(t, object.body); schema.Definitions["Item1"] == nil {
+			t.Errorf("uploaded output is not the schema: %s", object.body)
+		}
+
+		if result := runCLI(t, "--no-config", "-i", input, "-o", output, "--check"); result.err != nil {
+			t.Errorf("--check against the uploaded output: %v", result.err)
+		}
+		other := writeFile(t, "other.json", string(introspectionBody(t, 3)))
+		if result := runCLI(t, "--no-config", "-i", other, "-o", output, "--check"); ExitCode(result.err) != ExitMismatch {
+			t.Errorf("--check against a stale output: %v", result.err)
+		}
+		if result := runCLI(t, "--no-config", "-i", other, "-o", output, "--no-clobber"); ExitCode(result.err) != ExitOutput {
+			t.Errorf("--no-clobber over an existing object: %v", result.err)
+		}
+	})
+}
+
+func TestObjectStorageInput(t *testing.T) {
+	objectStores(t, func(t *testing.T, scheme string, stored func(string) (storedObject, bool)) {
+		if err := putObject(scheme+"://dumps/introspection.json", bytes.NewReader(introspectionBody(t, 2))); err != nil {
+			t.Fatalf("putObject: %v", err)
+		}
+
+		output := filepath.Join(t.TempDir(), "schema.json")
+		if result := runCLI(t, "--no-config", "-i", scheme+"://dumps/introspection.json", "-o", output); result.err != nil {
+			t.Fatalf("reading the input object: %v\n%s", result.err, result.stderr)
+		}
+		if schema := readSchema(t, output); schema.Definitions["Item1"] == nil {
+			t.Errorf("schema was not converted from the input object: %+v", schema)
+		}
+
+		result := runCLI(t, "--no-config", "-i", scheme+"://dumps/missing.json", "-o", output)
+		if result.err == nil || !strings.Contains(result.err.Error(), scheme+"://dumps/missing.json does not exist") {
+			t.Errorf("missing input object: %v", result.err)
+		}
+	})
+}
+
+// TestObjectStoragePutStreams checks that uploads read bodies that can neither be sized nor rewound
+func TestObjectStoragePutStreams(t *testing.T) {
+	objectStores(t, func(t *testing.T, scheme string, stored func(string) (storedObject, bool)) {
+		body := io.MultiReader(strings.NewReader(`{"streamed": `), strings.NewReader(`true}`))
+		if err := putObject(scheme+"://schemas/streamed.json", body); err != nil {
+			t.Fatalf("putObject: %v", err)
+		}
+		object, ok := stored("schemas/streamed.json")
+		if !ok || string(object.body) != `{"streamed": true}` {
+			t.Errorf("stored %q", object.body)
+		}
+		if scheme == "gs" && object.contentLength != -1 {
+			t.Errorf("GCS upload was sent with Content-Length %d, want a streamed body", object.contentLength)
+		}
+	})
+}
+
+func TestS3BucketRegion(t *testing.T) {
+	fake := startFakeS3(t)
+	fake.regions["eu-schemas"] = "eu-west-1"
+	input := writeFile(t, "introspection.json", string(introspectionBody(t, 1)))
+
+	if result := runCLI(t, "--no-config", "-i", input, "-o", "s3://eu-schemas/schema.json"); result.err != nil {
+		t.Fatalf("upload to a bucket in another region: %v\n%s", result.err, result.stderr)
+	}
+	if _, ok := fake.objects["eu-schemas/schema.json"]; !ok {
+		t.Error("the output was not uploaded in the bucket's region")
+	}
+	// A streamed body cannot be sent again, so its upload fails instead of being retried
+	err := putObject("s3://eu-schemas/streamed.json", io.MultiReader(strings.NewReader("{}")))
+	if err == nil || !strings.Contains(err.Error(), "PermanentRedirect") {
+		t.Errorf("streamed upload to a bucket in another region: %v", err)
+	}
+}
+
+func TestObjectStorageErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+		input string
+		want  string
+	}{
+		{"s3 rejected credentials", func(t *testing.T) {
+			startFakeS3(t)
+			t.Setenv("AWS_ACCESS_KEY_ID", "other-key")
+		}, "s3://schemas/introspection.json", "access denied to s3://schemas/introspection.json: 403 Forbidden: AccessDenied"},
+		{"s3 no credentials", func(t *testing.T) {
+			startFakeS3(t)
+			t.Setenv("AWS_ACCESS_KEY_ID", "")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		}, "s3://schemas/introspection.json", "access denied to s3://schemas/introspection.json"},
+		{"gcs refused", func(t *testing.T) {
+			startFakeGCS(t)
+		}, "gs://private/introspection.json", "access denied to gs://private/introspection.json: 403 Forbidden: no access to bucket private"},
+		{"gcs no credentials", func(t *testing.T) {
+			t.Setenv("STORAGE_EMULATOR_HOST", "")
+			t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+			t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(t.TempDir(), "missing.json"))
+		}, "gs://schemas/introspection.json", "access denied to gs://schemas/introspection.json: no Google credentials found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(t)
+			result := runCLI(t, "--no-config", "-i", tt.input)
+			if code := ExitCode(result.err); code != ExitNetwork {
+				t.Errorf("exit code %d, want %d (%v)", code, ExitNetwork, result.err)
+			}
+			if result.err == nil || !strings.Contains(result.err.Error(), tt.want) {
+				t.Errorf("error %v does not contain %q", result.err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var (
+	onChangeExec    string
+	onChangeWebhook string
+	onChangeStrict  bool
+)
+
+// errChangeHook marks change hook failures, which end watch mode with --on-change-strict
+var errChangeHook = errors.New("change hook failed")
+
+// changeClock stamps changes; tests replace it to get predictable timestamps
+var changeClock = time.Now
+
+func init() {
+	rootCmd.Flags().StringVar(&onChangeExec, "on-change-exec", "", "with --watch, run this shell command whenever the output changes")
+	rootCmd.Flags().StringVar(&onChangeWebhook, "on-change-webhook", "", "with --watch, POST a JSON summary to this URL whenever the output changes")
+	rootCmd.Flags().BoolVar(&onChangeStrict, "on-change-strict", false, "stop watching when a change hook fails instead of logging the failure")
+	bindFlag("on-change.exec", rootCmd.Flags().Lookup("on-change-exec"))
+	bindFlag("on-change.webhook", rootCmd.Flags().Lookup("on-change-webhook"))
+	bindFlag("on-change.strict", rootCmd.Flags().Lookup("on-change-strict"))
+}
+
+// schemaChange is a regeneration that changed the canonical output, as reported to the change hooks
+type schemaChange struct {
+	Event             string            `json:"event"`
+	Output            string            `json:"output"`
+	ChangedAt         time.Time         `json:"changedAt"`
+	PreviousChangedAt *time.Time        `json:"previousChangedAt,omitempty"`
+	OldHash           string            `json:"oldHash,omitempty"`
+	NewHash           string            `json:"newHash"`
+	Summary           changeHookSummary `json:"summary"`
+
+	// oldOutput is the previous content of the output file, empty when there was none
+	oldOutput []byte
+}
+
+// changeHookSummary counts the structural differences between the old and new output
+type changeHookSummary struct {
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Changed int    `json:"changed"`
+	Text    string `json:"text"`
+}
+
+// changeHooksEnabled reports whether any change hook is configured
+func changeHooksEnabled() bool {
+	return viper.GetString("on-change.exec") != "" || viper.GetString("on-change.webhook") != ""
+}
+
+// canonicalHash identifies an output by the SHA-256 of its canonical form
+func canonicalHash(canonical []byte) string {
+	if canonical == nil {
+		return ""
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// newSchemaChange describes the change from the old to the new output, summarizing their diff
+func newSchemaChange(outputFile string, oldOutput, oldCanonical, newOutput, newCanonical []byte) schemaChange {
+	change := schemaChange{
+		Event:     "schema.changed",
+		Output:    outputFile,
+		ChangedAt: changeClock().UTC(),
+		OldHash:   canonicalHash(oldCanonical),
+		NewHash:   canonicalHash(newCanonical),
+		oldOutput: oldOutput,
+	}
+
+	var oldSchema, newSchema *pkg.JSONSchema6
+	if len(oldOutput) > 0 && json.Unmarshal(oldOutput, &oldSchema) != nil {
+		oldSchema = nil
+	}
+	if json.Unmarshal(newOutput, &newSchema) != nil {
+		return change
+	}
+	if oldSchema == nil {
+		oldSchema = &pkg.JSONSchema6{}
+	}
+	changes := pkg.DiffSchemas(oldSchema, newSchema, pkg.DiffOptions{})
+	for _, c := range changes {
+		switch c.Kind {
+		case pkg.ChangeAdded:
+			change.Summary.Added++
+		case pkg.ChangeRemoved:
+			change.Summary.Removed++
+		case pkg.ChangeChanged:
+			change.Summary.Changed++
+		}
+	}
+	change.Summary.Text = summarizeChanges(changes)
+	return change
+}
+
+// runChangeHooks runs --on-change-exec and then --on-change-webhook for a change. They run in the
+// watch loop, so a hook never overlaps another: changes made while one runs are picked up by the
+// next check. Failures are returned wrapping errChangeHook.
+func runChangeHooks(ctx context.Context, change schemaChange) error {
+	var errs []error
+	if command := viper.GetString("on-change.exec"); command != "" {
+		if err := runChangeExec(ctx, command, change); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if url := viper.GetString("on-change.webhook"); url != "" {
+		if err := postChangeWebhook(ctx, url, change); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", errChangeHook, err)
+	}
+	return nil
+}
+
+// runChangeExec runs the hook command through the shell with the change in environment variables:
+// GRAPHQL2JSON_CHANGE_NEW_FILE is the output file, GRAPHQL2JSON_CHANGE_OLD_FILE a temporary copy of
+// its previous content (empty when there was none) and the _HASH variables their canonical hashes.
+// The command's output goes to stderr.
+func runChangeExec(ctx context.Context, command string, change schemaChange) error {
+	oldFile := ""
+	if len(change.oldOutput) > 0 {
+		tmp, err := os.CreateTemp("", "."+filepath.Base(change.Output)+".old-*")
+		if err != nil {
+			return fmt.Errorf("error writing previous output: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(change.oldOutput)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("error writing previous output: %w", err)
+		}
+		oldFile = tmp.Name()
+	}
+
+	args := []string{"sh", "-c", command}
+	if runtime.GOOS == "windows" {
+		args = []string{"cmd", "/C", command}
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		envPrefix+"_CHANGE_NEW_FILE="+change.Output,
+		envPrefix+"_CHANGE_OLD_FILE="+oldFile,
+		envPrefix+"_CHANGE_NEW_HASH="+change.NewHash,
+		envPrefix+"_CHANGE_OLD_HASH="+change.OldHash,
+	)
+
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("--on-change-exec failed: %w", err)
+	}
+	logger.Info("Ran change hook", "hook", "exec", "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// postChangeWebhook posts the change as JSON and expects a 2xx response
+func postChangeWebhook(ctx context.Context, url string, change schemaChange) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("error marshaling change: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(viper.GetInt("timeout"))*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("--on-change-webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("--on-change-webhook failed: %s", redactSecrets(err.Error()))
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("--on-change-webhook failed: %s", resp.Status)
+	}
+	logger.Info("Ran change hook", "hook", "webhook", "status", resp.StatusCode, "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// changedSDL is fixtureSDL with User.email renamed to nickname
+var changedSDL = strings.Replace(fixtureSDL, "  email: String\n", "  nickname: String\n", 1)
+
+// fakeChangeClock makes change timestamps count up by a minute from a fixed time
+func fakeChangeClock(t *testing.T) time.Time {
+	t.Helper()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	next := start
+	saved := changeClock
+	t.Cleanup(func() { changeClock = saved })
+	changeClock = func() time.Time {
+		now := next
+		next = next.Add(time.Minute)
+		return now
+	}
+	return start.UTC()
+}
+
+// mustIntrospectSDL reads an SDL fixture into an introspection result
+func mustIntrospectSDL(t *testing.T, sdl string) *pkg.IntrospectionQuery {
+	t.Helper()
+	introspection, err := pkg.IntrospectionFromSDL(sdl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return introspection
+}
+
+// fileHash is the canonical hash of a written output file, computed independently of canonicalHash
+func fileHash(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := pkg.CanonicalJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// webhookRecorder is a webhook endpoint that records the changes posted to it
+type webhookRecorder struct {
+	*httptest.Server
+	mu      sync.Mutex
+	changes []schemaChange
+	status  int
+}
+
+func newWebhookRecorder(t *testing.T) *webhookRecorder {
+	t.Helper()
+	r := &webhookRecorder{status: http.StatusNoContent}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook got %s with Content-Type %q", req.Method, req.Header.Get("Content-Type"))
+		}
+		var change schemaChange
+		if err := json.NewDecoder(req.Body).Decode(&change); err != nil {
+			t.Errorf("decoding webhook payload: %v", err)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.changes = append(r.changes, change)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookRecorder) received() []schemaChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]schemaChange{}, r.changes...)
+}
+
+func TestChangeWebhook(t *testing.T) {
+	resetCLI(t)
+	start := fakeChangeClock(t)
+	hook := newWebhookRecorder(t)
+	viper.Set("on-change.webhook", hook.URL)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	w := &schemaWatcher{outputFile: output}
+
+	if changed, err := w.regenerate(context.Background(), mustIntrospectSDL(t, fixtureSDL)); !changed || err != nil {
+		t.Fatalf("first regenerate: changed %v, %v", changed, err)
+	}
+	firstHash := fileHash(t, output)
+	if changed, err := w.regenerate(context.Background(), mustIntrospectSDL(t, fixtureSDL)); changed || err != nil {
+		t.Fatalf("unchanged regenerate: changed %v, %v", changed, err)
+	}
+	if changed, err := w.regenerate(context.Background(), mustIntrospectSDL(t, changedSDL)); !changed || err != nil {
+		t.Fatalf("second regenerate: changed %v, %v", changed, err)
+	}
+	secondHash := fileHash(t, output)
+
+	changes := hook.received()
+	if len(changes) != 2 {
+		t.Fatalf("webhook called %d times, want once per change: %+v", len(changes), changes)
+	}
+	first, second := changes[0], changes[1]
+
+	if first.Event != "schema.changed" || first.Output != output {
+		t.Errorf("first change is %q for %q", first.Event, first.Output)
+	}
+	if first.OldHash != "" || first.NewHash != firstHash {
+		t.Errorf("first change hashes %q -> %q, want \"\" -> %q", first.OldHash, first.NewHash, firstHash)
+	}
+	if !first.ChangedAt.Equal(start) || first.PreviousChangedAt != nil {
+		t.Errorf("first change at %v after %v, want %v after nothing", first.ChangedAt, first.PreviousChangedAt, start)
+	}
+	if first.Summary.Added == 0 || first.Summary.Removed != 0 || first.Summary.Changed != 0 {
+		t.Errorf("first change summary = %+v, want only additions", first.Summary)
+	}
+
+	if second.OldHash != firstHash || second.NewHash != secondHash || secondHash == firstHash {
+		t.Errorf("second change hashes %q -> %q, want %q -> %q", second.OldHash, second.NewHash, firstHash, secondHash)
+	}
+	// The unchanged check in between does not take a timestamp
+	if !second.ChangedAt.Equal(start.Add(time.Minute)) || second.PreviousChangedAt == nil || !second.PreviousChangedAt.Equal(start) {
+		t.Errorf("second change at %v after %v, want %v after %v", second.ChangedAt, second.PreviousChangedAt, start.Add(time.Minute), start)
+	}
+	if second.Summary.Added != 1 || second.Summary.Removed != 1 || second.Summary.Changed != 0 {
+		t.Errorf("second change summary = %+v, want one addition and one removal", second.Summary)
+	}
+	for _, want := range []string{"Properties added: 1", "Properties removed: 1"} {
+		if !strings.Contains(second.Summary.Text, want) {
+			t.Errorf("summary text does not say %q:\n%s", want, second.Summary.Text)
+		}
+	}
+}
+
+func TestChangeExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook command is written for sh")
+	}
+	resetCLI(t)
+	dir := t.TempDir()
+	// The hook records its environment and a copy of the previous output
+	viper.Set("on-change.exec", `env | grep '^GRAPHQL2JSON_CHANGE_' | sort > "`+dir+`/env.$GRAPHQL2JSON_CHANGE_NEW_HASH"
+if [ -n "$GRAPHQL2JSON_CHANGE_OLD_FILE" ]; then cp "$GRAPHQL2JSON_CHANGE_OLD_FILE" "`+dir+`/old"; fi`)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	w := &schemaWatcher{outputFile: output}
+
+	readHookEnv := func(hash string) map[string]string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, "env."+hash))
+		if err != nil {
+			t.Fatalf("the hook did not run for %s: %v", hash, err)
+		}
+		env := map[string]string{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			name, value, _ := strings.Cut(line, "=")
+			env[name] = value
+		}
+		return env
+	}
+
+	if _, err := w.regenerate(context.Background(), mustIntrospectSDL(t, fixtureSDL)); err != nil {
+		t.Fatal(err)
+	}
+	firstHash := fileHash(t, output)
+	firstOutput, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := readHookEnv(firstHash)
+	if env["GRAPHQL2JSON_CHANGE_NEW_FILE"] != output || env["GRAPHQL2JSON_CHANGE_OLD_FILE"] != "" || env["GRAPHQL2JSON_CHANGE_OLD_HASH"] != "" {
+		t.Errorf("first change environment = %q", env)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old")); !os.IsNotExist(err) {
+		t.Errorf("the first change has a previous output: %v", err)
+	}
+
+	if _, err := w.regenerate(context.Background(), mustIntrospectSDL(t, changedSDL)); err != nil {
+		t.Fatal(err)
+	}
+	secondHash := fileHash(t, output)
+	env = readHookEnv(secondHash)
+	if env["GRAPHQL2JSON_CHANGE_NEW_FILE"] != output || env["GRAPHQL2JSON_CHANGE_NEW_HASH"] != secondHash || env["GRAPHQL2JSON_CHANGE_OLD_HASH"] != firstHash {
+		t.Errorf("second change environment = %q", env)
+	}
+	old, err := os.ReadFile(filepath.Join(dir, "old"))
+	if err != nil || string(old) != string(firstOutput) {
+		t.Errorf("GRAPHQL2JSON_CHANGE_OLD_FILE does not hold the previous output: %v", err)
+	}
+	if oldFile := env["GRAPHQL2JSON_CHANGE_OLD_FILE"]; oldFile == "" {
+		t.Error("GRAPHQL2JSON_CHANGE_OLD_FILE is not set")
+	} else if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Errorf("the copy of the previous output is left behind: %v", err)
+	}
+}
+
+func TestChangeHookFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+	}{
+		{name: "logged", strict: false},
+		{name: "strict", strict: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCLI(t)
+			log := captureLog(t)
+			hook := newWebhookRecorder(t)
+			hook.status = http.StatusBadGateway
+			viper.Set("on-change.webhook", hook.URL)
+			viper.Set("on-change.strict", tt.strict)
+			w := &schemaWatcher{outputFile: filepath.Join(t.TempDir(), "schema.json")}
+
+			changed, err := w.regenerate(context.Background(), mustIntrospectSDL(t, fixtureSDL))
+			if !changed {
+				t.Error("the output was not written")
+			}
+			if tt.strict {
+				if !errors.Is(err, errChangeHook) || !strings.Contains(err.Error(), "502") {
+					t.Errorf("error = %v, want a change hook failure naming the status", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("error = %v, want the failure only logged", err)
+			}
+			if !strings.Contains(log.String(), "Change hook failed") {
+				t.Errorf("the failure was not logged:\n%s", log.String())
+			}
+		})
+	}
+}
+
+// TestChangeHookStrictStopsWatch runs the watch loops with a failing hook: --on-change-strict ends
+// them with the hook's error, otherwise they keep polling until cancelled
+func TestChangeHookStrictStopsWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook command is written for sh")
+	}
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+
+	t.Run("strict file watch", func(t *testing.T) {
+		result := runCLI(t, "--input", input, "--output", filepath.Join(t.TempDir(), "schema.json"),
+			"--watch", "--on-change-exec", "exit 3", "--on-change-strict")
+		if !errors.Is(result.err, errChangeHook) || !strings.Contains(result.err.Error(), "exit status 3") {
+			t.Errorf("error = %v, want the hook failure", result.err)
+		}
+	})
+
+	for _, strict := range []bool{true, false} {
+		resetCLI(t)
+		captureLog(t)
+		viper.Set("input", input)
+		viper.Set("on-change.exec", "exit 3")
+		viper.Set("on-change.strict", strict)
+		w := &schemaWatcher{outputFile: filepath.Join(t.TempDir(), "schema.json")}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		err := w.pollInput(ctx, 10*time.Millisecond)
+		stopped := ctx.Err() == nil
+		cancel()
+		if strict && (!errors.Is(err, errChangeHook) || !stopped) {
+			t.Errorf("strict poll returned %v before cancellation: %v", err, stopped)
+		}
+		if !strict && (err != nil || stopped) {
+			t.Errorf("poll returned %v before cancellation: %v", err, stopped)
+		}
+	}
+}
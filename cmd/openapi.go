@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var openAPIDiscriminator bool
+
+func init() {
+	rootCmd.Flags().BoolVar(&openAPIDiscriminator, "openapi-discriminator", false, "add __typename to the members of unions in --target openapi output and discriminate the unions by it")
+	bindFlag("openapi.discriminator", rootCmd.Flags().Lookup("openapi-discriminator"))
+}
+
+// generateOpenAPI exports the types as OpenAPI 3.0 components, limited to --select-type and the types it
+// references when set. The conversion options, such as --id-type and --scalar, apply as they do to JSON Schema.
+func generateOpenAPI(introspection *pkg.IntrospectionQuery) ([]byte, error) {
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, err
+	}
+	openAPI := pkg.OpenAPIOptions{Discriminator: viper.GetBool("openapi.discriminator")}
+
+	output, err := pkg.OpenAPIComponents(*introspection, viper.GetString("select-type"), opts, openAPI)
+	if err != nil {
+		return nil, withExitCode(ExitConversion, err)
+	}
+	return output, nil
+}
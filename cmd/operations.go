@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// persistedQueryManifest is an Apollo persisted query manifest
+type persistedQueryManifest struct {
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// loadOperationDocuments reads the operations named by --operations: a directory of .graphql files,
+// which may spread each other's fragments, a single .graphql file, or a persisted query manifest.
+// A manifest is either an Apollo manifest or a JSON object mapping ids to query text.
+func loadOperationDocuments(path string) ([]*pkg.Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading operations: %w", err)
+	}
+	if info.IsDir() {
+		doc, err := loadOperationDir(path)
+		if err != nil {
+			return nil, err
+		}
+		return []*pkg.Document{doc}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading operations: %w", err)
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		doc, err := pkg.ParseDocument(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return []*pkg.Document{doc}, nil
+	}
+
+	// Each manifest entry is a complete document carrying the fragments it spreads
+	entries, err := loadPersistedQueryManifest(path, data)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*pkg.Document, 0, len(entries))
+	for _, entry := range entries {
+		doc, err := pkg.ParseDocument(entry.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: operation %s: %w", path, entry.ID, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// manifestEntry is an operation of a persisted query manifest
+type manifestEntry struct {
+	// ID is the hash or id of the operation, or else its name or position in the manifest
+	ID   string
+	Name string
+	Body string
+}
+
+// loadPersistedQueryManifest parses the persisted query manifest read from path, either an Apollo
+// manifest with an operations array or a JSON object mapping ids to query text. Entries are
+// sorted by id.
+func loadPersistedQueryManifest(path string, data []byte) ([]manifestEntry, error) {
+	entries := make([]manifestEntry, 0)
+	var manifest persistedQueryManifest
+	if err := json.Unmarshal(data, &manifest); err == nil && manifest.Operations != nil {
+		for i, op := range manifest.Operations {
+			id := op.ID
+			if id == "" {
+				id = op.Name
+			}
+			if id == "" {
+				id = fmt.Sprintf("operations[%d]", i)
+			}
+			entries = append(entries, manifestEntry{ID: id, Name: op.Name, Body: op.Body})
+		}
+	} else {
+		bodies := make(map[string]string)
+		if err := json.Unmarshal(data, &bodies); err != nil {
+			return nil, fmt.Errorf("%s: not a persisted query manifest: %w", path, err)
+		}
+		for id, body := range bodies {
+			entries = append(entries, manifestEntry{ID: id, Body: body})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// loadOperationDir parses the .graphql and .gql files under dir into a single document
+func loadOperationDir(dir string) (*pkg.Document, error) {
+	paths := make([]string, 0)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(path); !entry.IsDir() && (ext == ".graphql" || ext == ".gql") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading operations: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .graphql files in operations directory %s", dir)
+	}
+
+	doc := &pkg.Document{}
+	defined := make(map[string]string)
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading operations: %w", err)
+		}
+		parsed, err := pkg.ParseDocument(string(source))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, fragment := range parsed.Fragments {
+			if previous, ok := defined[fragment.Name]; ok {
+				return nil, fmt.Errorf("%s:%s: fragment %s is already defined in %s", path, fragment.Position, fragment.Name, previous)
+			}
+			defined[fragment.Name] = path
+		}
+		doc.Operations = append(doc.Operations, parsed.Operations...)
+		doc.Fragments = append(doc.Fragments, parsed.Fragments...)
+	}
+	return doc, nil
+}
+
+// trimToOperations narrows the introspection to the types and fields the operations at path use
+func trimToOperations(introspection *pkg.IntrospectionQuery, path string) (*pkg.IntrospectionQuery, error) {
+	docs, err := loadOperationDocuments(path)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	operations := 0
+	for _, doc := range docs {
+		operations += len(doc.Operations)
+	}
+	if operations == 0 {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no operations found in %s", path))
+	}
+
+	usage, err := pkg.OperationsUsage(*introspection, docs)
+	if err != nil {
+		return nil, withExitCode(ExitConversion, err)
+	}
+	trimmed, stats := pkg.TrimToUsage(*introspection, usage)
+	logger.Info("Trimmed schema to the operations",
+		"operations", operations,
+		"typesRemoved", stats.TypesBefore-stats.TypesAfter,
+		"typesKept", stats.TypesAfter,
+		"fieldsRemoved", stats.FieldsBefore-stats.FieldsAfter,
+		"fieldsKept", stats.FieldsAfter)
+	return &trimmed, nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	operationsManifestFile string
+	operationSchemasDir    string
+	operationSchemasPrune  bool
+)
+
+var operationSchemasCmd = &cobra.Command{
+	Use:   "operation-schemas",
+	Short: "Write the response schema of every operation in a persisted query manifest",
+	Long: `Write the response schema of every operation in a persisted query manifest to
+--output-dir, so that gateways can validate the responses of exactly the queries
+clients run in production.
+
+The manifest is either an Apollo manifest with an "operations" array of entries
+with id, name and body, or a JSON object mapping operation hashes to query text;
+the shape is detected automatically. Each schema is written to <id>.json, named
+after the entry's id or hash, or its operation name when it has no id. Entries
+that fail to parse or do not match the schema are skipped with a warning.
+
+Files whose canonical JSON is unchanged are not rewritten, so regenerating from
+an unchanged schema and manifest touches no file. --prune-output deletes the
+other .json files in --output-dir, such as those of operations removed from
+the manifest.
+
+The GraphQL schema is read from --endpoint, --input or stdin, as in one-shot mode.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runOperationSchemas()
+	},
+}
+
+func init() {
+	operationSchemasCmd.Flags().StringVar(&operationsManifestFile, "operations-manifest", "", "persisted query manifest listing the operations")
+	operationSchemasCmd.Flags().StringVar(&operationSchemasDir, "output-dir", "", "directory the response schemas are written to")
+	operationSchemasCmd.Flags().BoolVar(&operationSchemasPrune, "prune-output", false, "delete .json files in --output-dir that this run did not write")
+	operationSchemasCmd.MarkFlagRequired("operations-manifest")
+	operationSchemasCmd.MarkFlagRequired("output-dir")
+
+	rootCmd.AddCommand(operationSchemasCmd)
+}
+
+func runOperationSchemas() error {
+	data, err := os.ReadFile(operationsManifestFile)
+	if err != nil {
+		return fmt.Errorf("error reading operations manifest: %w", err)
+	}
+	entries, err := loadPersistedQueryManifest(operationsManifestFile, data)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+
+	outputDir := newDirOutput(operationSchemasDir)
+	written := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		file := manifestFileName(entry.ID) + ".json"
+		if previous, ok := written[file]; ok {
+			logger.Warn("Operation is skipped", "operation", entry.ID, "reason", "its file name is already used by "+previous)
+			continue
+		}
+
+		doc, err := pkg.ParseDocument(entry.Body)
+		if err != nil {
+			logger.Warn("Operation is skipped", "operation", entry.ID, "error", err)
+			continue
+		}
+		responseSchema, err := pkg.OperationResponseSchema(*introspection, doc, entry.Name, opts)
+		if err != nil {
+			logger.Warn("Operation is skipped", "operation", entry.ID, "error", err)
+			continue
+		}
+
+		output, err := json.MarshalIndent(responseSchema.Schema, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON Schema: %w", err)
+		}
+		if err := outputDir.write(file, output); err != nil {
+			return err
+		}
+		written[file] = entry.ID
+	}
+
+	if operationSchemasPrune {
+		if err := outputDir.prune(); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Wrote operation response schemas", "dir", operationSchemasDir, "operations", len(written), "skipped", len(entries)-len(written),
+		"written", outputDir.written, "unchanged", outputDir.unchanged, "deleted", outputDir.deleted)
+	return nil
+}
+
+// manifestFileName turns a manifest id into a file name, replacing the characters that are not
+// safe in file names
+func manifestFileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, id)
+}
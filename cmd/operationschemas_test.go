@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dirState returns the modification time and content hash of every file in dir
+func dirState(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state[entry.Name()] = fmt.Sprintf("%s %x", info.ModTime().Format(time.RFC3339Nano), sha256.Sum256(data))
+	}
+	return state
+}
+
+// backdate sets the modification time of every file in dir an hour back, so a rewrite within the
+// timestamp resolution of the filesystem still shows
+func backdate(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	for _, entry := range entries {
+		if err := os.Chtimes(filepath.Join(dir, entry.Name()), past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func writeManifest(t *testing.T, operations map[string]string) string {
+	t.Helper()
+	data, err := json.Marshal(operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, "manifest.json", string(data))
+}
+
+func TestOperationSchemasIncremental(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	dir := t.TempDir()
+	run := func(manifest string, args ...string) cliResult {
+		t.Helper()
+		base := []string{"operation-schemas", "--no-config", "-i", input, "--operations-manifest", manifest, "--output-dir", dir}
+		result := runCLI(t, append(base, args...)...)
+		if result.err != nil {
+			t.Fatalf("operation-schemas: %v\n%s", result.err, result.stderr)
+		}
+		return result
+	}
+
+	manifest := writeManifest(t, map[string]string{
+		"user":   "query GetUser { user(id: 1) { name } }",
+		"search": "query Search { search(term: \"a\") { ... on Post { title } } }",
+	})
+	if result := run(manifest); !strings.Contains(result.stderr, "written=2 unchanged=0") {
+		t.Fatalf("first run did not write both files:\n%s", result.stderr)
+	}
+	backdate(t, dir)
+	before := dirState(t, dir)
+
+	result := run(manifest)
+	if !strings.Contains(result.stderr, "written=0 unchanged=2 deleted=0") {
+		t.Errorf("no-op run summary:\n%s", result.stderr)
+	}
+	after := dirState(t, dir)
+	for name, state := range before {
+		if after[name] != state {
+			t.Errorf("the no-op run touched %s", name)
+		}
+	}
+
+	// A reformatted file with the same canonical JSON is left alone as well
+	path := filepath.Join(dir, "user.json")
+	var decoded interface{}
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &decoded)
+	compact, _ := json.Marshal(decoded)
+	os.WriteFile(path, compact, 0644)
+	backdate(t, dir)
+	before = dirState(t, dir)
+	run(manifest)
+	if dirState(t, dir)["user.json"] != before["user.json"] {
+		t.Error("a file differing only in formatting was rewritten")
+	}
+
+	// One operation changes and one disappears; the stale file stays without --prune-output
+	changed := writeManifest(t, map[string]string{"user": "query GetUser { user(id: 1) { name email } }"})
+	if result := run(changed); !strings.Contains(result.stderr, "written=1 unchanged=0 deleted=0") {
+		t.Errorf("changed run summary:\n%s", result.stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "search.json")); err != nil {
+		t.Error("the stale file was deleted without --prune-output")
+	}
+	if after := dirState(t, dir); after["user.json"] == before["user.json"] {
+		t.Error("the changed operation was not rewritten")
+	}
+
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("kept"), 0644)
+	if result := run(changed, "--prune-output"); !strings.Contains(result.stderr, "written=0 unchanged=1 deleted=1") {
+		t.Errorf("pruning run summary:\n%s", result.stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "search.json")); err == nil {
+		t.Error("--prune-output kept the stale file")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("--prune-output deleted a file that is not JSON")
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// writeOutput writes the generated output to outputFile, or stdout when outputFile is empty
+func writeOutput(outputFile string, output []byte) error {
+	if outputFile == "" {
+		// Write to stdout
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if err := checkClobber(outputFile); err != nil {
+		return err
+	}
+	return replaceOutput(outputFile, output)
+}
+
+// checkClobber refuses to overwrite an existing file when --no-clobber is set without --force
+func checkClobber(outputFile string) error {
+	if !viper.GetBool("no-clobber") || viper.GetBool("force") {
+		return nil
+	}
+	exists, err := outputExists(outputFile)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return withExitCode(ExitOutput, fmt.Errorf("refusing to overwrite %s (--no-clobber is set, use --force to overwrite)", outputFile))
+	}
+	return nil
+}
+
+// replaceOutput atomically replaces outputFile with output, creating its directory if needed and
+// keeping the permissions of an existing file. s3:// and gs:// outputs are uploaded instead.
+func replaceOutput(outputFile string, output []byte) error {
+	if isObjectURL(outputFile) {
+		if err := putObject(outputFile, bytes.NewReader(output)); err != nil {
+			return withExitCode(ExitOutput, fmt.Errorf("error uploading output: %w", err))
+		}
+		return nil
+	}
+
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error creating output directory: %w", err))
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(outputFile); err == nil && info.Mode().IsRegular() {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(outputFile, output, perm); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error writing output file: %w", err))
+	}
+
+	return nil
+}
+
+// renameFile moves the temporary file into place; tests replace it to simulate a failed rename
+var renameFile = os.Rename
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so readers never observe a partially written file and the original survives a failed write
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return renameFile(tmp.Name(), path)
+}
+
+// dirOutput writes a set of JSON files into a directory, leaving files whose canonical form is
+// unchanged untouched so that regenerating an unchanged schema modifies no file
+type dirOutput struct {
+	dir   string
+	files map[string]bool
+
+	written, unchanged, deleted int
+}
+
+func newDirOutput(dir string) *dirOutput {
+	return &dirOutput{dir: dir, files: make(map[string]bool)}
+}
+
+// write writes output to the named file in the directory unless the file already holds the same
+// canonical JSON
+func (d *dirOutput) write(name string, output []byte) error {
+	path := filepath.Join(d.dir, name)
+	d.files[name] = true
+
+	existing, err := os.ReadFile(path)
+	if err == nil && sameCanonicalJSON(existing, output) {
+		d.unchanged++
+		return nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return withExitCode(ExitOutput, fmt.Errorf("error reading output file: %w", err))
+	}
+
+	if err := writeOutput(path, output); err != nil {
+		return err
+	}
+	d.written++
+	return nil
+}
+
+// prune deletes the .json files in the directory that were not written by this run, such as the
+// files of definitions or operations that no longer exist
+func (d *dirOutput) prune() error {
+	entries, err := os.ReadDir(d.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error reading output directory: %w", err))
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") || d.files[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.dir, name)); err != nil {
+			return withExitCode(ExitOutput, fmt.Errorf("error deleting stale output file: %w", err))
+		}
+		logger.Debug("Deleted stale output file", "file", filepath.Join(d.dir, name))
+		d.deleted++
+	}
+	return nil
+}
+
+// sameCanonicalJSON reports whether two JSON documents have the same canonical form
+func sameCanonicalJSON(a, b []byte) bool {
+	canonicalA, err := pkg.CanonicalJSON(a)
+	if err != nil {
+		return false
+	}
+	canonicalB, err := pkg.CanonicalJSON(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(canonicalA, canonicalB)
+}
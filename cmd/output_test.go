@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// assertOnlyFile checks that dir holds nothing but name, with the given content and permissions
+func assertOnlyFile(t *testing.T, dir, name, content string, perm os.FileMode) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != name {
+			t.Errorf("%s is left behind", entry.Name())
+		}
+	}
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("%s holds %q, want %q", name, data, content)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != perm {
+		t.Errorf("%s has mode %v, want %v", name, info.Mode().Perm(), perm)
+	}
+}
+
+func TestReplaceOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing os.FileMode // 0 when there is no file yet
+		want     os.FileMode
+	}{
+		{name: "new file", want: 0644},
+		{name: "private file", existing: 0600, want: 0600},
+		{name: "group writable file", existing: 0664, want: 0664},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "schema.json")
+			if tt.existing != 0 {
+				if err := os.WriteFile(path, []byte("old"), tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				// Not subject to the umask, unlike the mode WriteFile creates with
+				if err := os.Chmod(path, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := replaceOutput(path, []byte("new")); err != nil {
+				t.Fatalf("replaceOutput: %v", err)
+			}
+			assertOnlyFile(t, dir, "schema.json", "new", tt.want)
+		})
+	}
+}
+
+func TestReplaceOutputFailedRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := renameFile
+	t.Cleanup(func() { renameFile = saved })
+	renamed := ""
+	renameFile = func(from, to string) error {
+		renamed = from
+		// The temporary file is complete when the rename fails
+		if data, err := os.ReadFile(from); err != nil || string(data) != "new" {
+			t.Errorf("temporary file holds %q (%v) before the rename", data, err)
+		}
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: errors.New("simulated failure")}
+	}
+
+	err := replaceOutput(path, []byte("new"))
+	if err == nil || !strings.Contains(err.Error(), "simulated failure") {
+		t.Fatalf("error = %v, want the rename failure", err)
+	}
+	if ExitCode(err) != ExitOutput {
+		t.Errorf("exit code = %d, want %d", ExitCode(err), ExitOutput)
+	}
+	if filepath.Dir(renamed) != dir {
+		t.Errorf("temporary file %s is not next to the output", renamed)
+	}
+	assertOnlyFile(t, dir, "schema.json", "old", 0600)
+}
+
+func TestReplaceOutputReadOnlyDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+
+	err := replaceOutput(path, []byte("new"))
+	if err == nil || ExitCode(err) != ExitOutput {
+		t.Fatalf("error = %v, want an output error", err)
+	}
+	assertOnlyFile(t, dir, "schema.json", "old", 0644)
+}
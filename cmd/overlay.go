@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// loadOverlay reads an overlay file in YAML or JSON
+func loadOverlay(path string) (pkg.Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overlay: %w", err)
+	}
+	var entries map[string]interface{}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	overlay := make(pkg.Overlay, len(entries))
+	for key, entry := range entries {
+		keywords, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: entry %s must be a mapping of JSON Schema keywords", path, key)
+		}
+		overlay[key] = keywords
+	}
+	return overlay, nil
+}
+
+// applyOverlayFile merges the --overlay file into the schema and returns the merged schema with its
+// marshaled output. With --overlay-strict, entries that locate nothing are an error.
+func applyOverlayFile(schema *pkg.JSONSchema6, path string) (*pkg.JSONSchema6, []byte, error) {
+	overlay, err := loadOverlay(path)
+	if err != nil {
+		return nil, nil, withExitCode(ExitUsage, err)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling JSON Schema: %w", err)
+	}
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil, fmt.Errorf("error parsing JSON Schema: %w", err)
+	}
+
+	unmatched, err := pkg.ApplyOverlay(document, overlay)
+	if err != nil {
+		return nil, nil, withExitCode(ExitUsage, fmt.Errorf("%s: %w", path, err))
+	}
+	if len(unmatched) > 0 {
+		if viper.GetBool("overlay-strict") {
+			return nil, nil, withExitCode(ExitConversion, fmt.Errorf("%s: overlay entries match nothing: %s", path, strings.Join(unmatched, ", ")))
+		}
+		for _, key := range unmatched {
+			logger.Warn("Overlay entry matches nothing", "overlay", path, "key", key)
+		}
+	}
+
+	output, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling JSON Schema: %w", err)
+	}
+	// Keywords the schema type cannot hold, such as numeric enums, only live in the output
+	var merged *pkg.JSONSchema6
+	if err := json.Unmarshal(output, &merged); err != nil {
+		logger.Debug("Overlay keywords are only kept in the output", "error", err)
+		merged = schema
+	}
+	return merged, output, nil
+}
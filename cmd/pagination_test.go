@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const relayFixtureSDL = `
+type Query {
+  users(first: Int, after: String, last: Int, before: String): UserConnection
+  search(term: String!, first: Int, after: String, last: Int, before: String): UserConnection
+  top(first: Int): UserConnection
+}
+
+type User { id: ID! }
+type UserConnection { nodes: [User] }
+`
+
+func TestSharedPaginationArgsFlag(t *testing.T) {
+	input := writeFile(t, "schema.graphql", relayFixtureSDL)
+	output := filepath.Join(t.TempDir(), "schema.json")
+
+	result := runCLI(t, "--no-config", "-i", input, "-o", output, "--shared-pagination-args")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	if schema.Definitions[pkg.RelayPaginationDefinition] == nil {
+		t.Fatal("no shared pagination definition")
+	}
+	ref := "#/definitions/" + pkg.RelayPaginationDefinition
+	query := schema.Properties["Query"].Properties
+	if args := query["users"].Properties["arguments"]; args.Ref != ref || args.Pagination == nil {
+		t.Errorf("users arguments = %+v", args)
+	}
+	if args := query["search"].Properties["arguments"]; len(args.AllOf) != 2 || args.AllOf[0].Ref != ref || args.AllOf[1].Properties["term"] == nil {
+		t.Errorf("search arguments = %+v", args)
+	}
+	if args := query["top"].Properties["arguments"]; args.Ref != "" || args.Properties["first"] == nil {
+		t.Errorf("top has partial pagination arguments and should be untouched: %+v", args)
+	}
+
+	// Without the flag every field keeps its own arguments
+	result = runCLI(t, "--no-config", "-i", input, "-o", output)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	if schema := readSchema(t, output); schema.Definitions[pkg.RelayPaginationDefinition] != nil {
+		t.Error("the shared definition is emitted without the flag")
+	}
+}
+
+func TestSharedPaginationArgsFlagNameClash(t *testing.T) {
+	input := writeFile(t, "schema.graphql", relayFixtureSDL+"\ninput RelayPaginationArgs { first: Int }\n")
+	result := runCLI(t, "--no-config", "-i", input, "--shared-pagination-args")
+	if code := ExitCode(result.err); code != ExitConversion {
+		t.Errorf("exit code %d (%v), want a conversion error", code, result.err)
+	}
+}
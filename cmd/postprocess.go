@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var (
+	postProcessCmds    []string
+	postProcessTimeout time.Duration
+	postProcessMaxSize int64
+)
+
+// postProcessStderrLimit is how much of a post-process command's stderr is kept for errors
+const postProcessStderrLimit = 4096
+
+// postProcessWaitDelay is how long a killed post-process command's children may keep its output
+// open; a script run through the shell leaves them behind when only the shell is killed
+const postProcessWaitDelay = time.Second
+
+// errPostProcessTooLarge is returned by the stdout writer of a post-process command when the
+// command prints more than --post-process-max-size
+var errPostProcessTooLarge = errors.New("output too large")
+
+func init() {
+	rootCmd.Flags().StringArrayVar(&postProcessCmds, "post-process-cmd", nil, "pipe the schema through this shell command, which prints the transformed schema (repeatable, run in order)")
+	rootCmd.Flags().DurationVar(&postProcessTimeout, "post-process-timeout", time.Minute, "longest each --post-process-cmd may run")
+	rootCmd.Flags().Int64Var(&postProcessMaxSize, "post-process-max-size", 256<<20, "largest output accepted from a --post-process-cmd, in bytes")
+	bindFlag("post-process-cmd", rootCmd.Flags().Lookup("post-process-cmd"))
+	bindFlag("post-process-timeout", rootCmd.Flags().Lookup("post-process-timeout"))
+	bindFlag("post-process-max-size", rootCmd.Flags().Lookup("post-process-max-size"))
+}
+
+// postProcess pipes the marshaled schema through each --post-process-cmd in turn and returns the
+// schema and output of the last one. The output is re-indented; keywords the schema type cannot
+// hold only live in the output, as with overlays.
+func postProcess(schema *pkg.JSONSchema6, output []byte, commands []string) (*pkg.JSONSchema6, []byte, error) {
+	for i, command := range commands {
+		start := time.Now()
+		transformed, err := runPostProcessCmd(command, output, i+1)
+		if err != nil {
+			return nil, nil, withExitCode(ExitConversion, err)
+		}
+
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, transformed, "", "  "); err != nil {
+			return nil, nil, withExitCode(ExitConversion, fmt.Errorf("--post-process-cmd %q printed invalid JSON: %w", command, err))
+		}
+		if trimmed := bytes.TrimSpace(transformed); len(trimmed) == 0 || trimmed[0] != '{' {
+			return nil, nil, withExitCode(ExitConversion, fmt.Errorf("--post-process-cmd %q did not print a JSON object", command))
+		}
+		output = indented.Bytes()
+
+		var processed *pkg.JSONSchema6
+		if err := json.Unmarshal(output, &processed); err != nil {
+			logger.Debug("Post-processed keywords are only kept in the output", "command", command, "error", err)
+		} else {
+			schema = processed
+		}
+		logger.Debug("Ran post-process command", "command", command, "duration", time.Since(start).Round(time.Millisecond))
+	}
+	return schema, output, nil
+}
+
+// runPostProcessCmd runs command through the shell with input on stdin and returns what it prints
+// on stdout. The contract is stable: the schema is the only thing on stdin, the transformed schema
+// the only thing expected on stdout, stderr is attached to errors, and these environment variables
+// are set:
+//
+//	GRAPHQL2JSON_TOOL_VERSION       version of this tool
+//	GRAPHQL2JSON_SOURCE             endpoint, input file or "stdin" the schema was converted from
+//	GRAPHQL2JSON_POST_PROCESS_STEP  position of the command in the chain, starting at 1
+func runPostProcessCmd(command string, input []byte, step int) ([]byte, error) {
+	args := []string{"sh", "-c", command}
+	if runtime.GOOS == "windows" {
+		args = []string{"cmd", "/C", command}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("post-process-timeout"))
+	defer cancel()
+	stdout := &limitedBuffer{limit: viper.GetInt64("post-process-max-size")}
+	stderr := &limitedBuffer{limit: postProcessStderrLimit, truncate: true}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = postProcessWaitDelay
+	cmd.Env = append(os.Environ(),
+		envPrefix+"_TOOL_VERSION="+toolVersion(),
+		envPrefix+"_SOURCE="+snapshotSource(),
+		fmt.Sprintf("%s_POST_PROCESS_STEP=%d", envPrefix, step),
+	)
+
+	err := cmd.Run()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		err = fmt.Errorf("timed out after %s", viper.GetDuration("post-process-timeout"))
+	case errors.Is(err, errPostProcessTooLarge) || stdout.exceeded:
+		err = fmt.Errorf("printed more than %d bytes (see --post-process-max-size)", stdout.limit)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("--post-process-cmd %q failed: %w: %s", command, err, message)
+		}
+		return nil, fmt.Errorf("--post-process-cmd %q failed: %w", command, err)
+	}
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		logger.Debug("Post-process command wrote to stderr", "command", command, "stderr", message)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer collects at most limit bytes. Beyond that it fails the write, stopping the command,
+// or with truncate silently drops the rest. It has no ReadFrom, so io.Copy cannot bypass the limit.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	truncate bool
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.buf.Len()); int64(len(p)) > room {
+		b.exceeded = true
+		if !b.truncate {
+			return 0, errPostProcessTooLarge
+		}
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte { return b.buf.Bytes() }
+
+func (b *limitedBuffer) String() string { return b.buf.String() }
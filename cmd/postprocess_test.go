@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// helperScript writes an executable shell script and returns its path
+func helperScript(t *testing.T, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the helper scripts need a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// addKeyword is a helper script body adding a keyword as the first member of the schema, whose
+// opening brace is on a line of its own in the indented output. The value is inside single quotes.
+func addKeyword(keyword, value string) string {
+	return `sed '1s/^{$/{"` + keyword + `": "` + value + `",/'`
+}
+
+func TestPostProcessCmd(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	first := helperScript(t, "first.sh", addKeyword("x-first", "yes"))
+	second := helperScript(t, "second.sh", addKeyword("x-step", `'"$GRAPHQL2JSON_POST_PROCESS_STEP"'`))
+	output := filepath.Join(t.TempDir(), "schema.json")
+
+	result := runCLI(t, "--no-config", "-i", input, "-o", output, "--post-process-cmd", first, "--post-process-cmd", second)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second command ran on the output of the first
+	if !strings.HasPrefix(string(data), "{\n  \"x-step\": \"2\",\n  \"x-first\": \"yes\",\n") {
+		t.Errorf("the commands did not run in order:\n%.200s", data)
+	}
+	if schema := readSchema(t, output); schema.Definitions["User"] == nil {
+		t.Error("the post-processed schema lost its definitions")
+	}
+}
+
+func TestPostProcessCmdEnvironment(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	env := filepath.Join(t.TempDir(), "env")
+	script := helperScript(t, "env.sh", `printf '%s\n%s\n%s\n' "$GRAPHQL2JSON_TOOL_VERSION" "$GRAPHQL2JSON_SOURCE" "$GRAPHQL2JSON_POST_PROCESS_STEP" > `+env+"\ncat")
+
+	result := runCLI(t, "--no-config", "-i", input, "--post-process-cmd", script)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	data, err := os.ReadFile(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := toolVersion() + "\n" + input + "\n1\n"
+	if string(data) != want {
+		t.Errorf("environment:\n%s\nwant:\n%s", data, want)
+	}
+	if schema := decodeSchema(t, []byte(result.stdout)); schema.Definitions["User"] == nil {
+		t.Error("cat did not pass the schema through")
+	}
+}
+
+func TestPostProcessCmdFailures(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	tests := []struct {
+		name  string
+		body  string
+		flags []string
+		want  string
+	}{
+		{"non-zero exit", "echo 'rule R1 violated' >&2\nexit 3", nil, "rule R1 violated"},
+		{"invalid JSON", "cat > /dev/null\necho '{not json'", nil, "printed invalid JSON"},
+		{"not an object", "cat > /dev/null\necho '[1, 2]'", nil, "did not print a JSON object"},
+		{"timeout", "exec sleep 10", []string{"--post-process-timeout", "200ms"}, "timed out after 200ms"},
+		{"size limit", "cat", []string{"--post-process-max-size", "64"}, "printed more than 64 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := helperScript(t, "fail.sh", tt.body)
+			output := filepath.Join(t.TempDir(), "schema.json")
+			args := append([]string{"--no-config", "-i", input, "-o", output, "--post-process-cmd", script}, tt.flags...)
+
+			start := time.Now()
+			result := runCLI(t, args...)
+			if code := ExitCode(result.err); code != ExitConversion {
+				t.Fatalf("exit code %d (%v), want a conversion error", code, result.err)
+			}
+			if !strings.Contains(result.err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", result.err, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("the command was not stopped in time: %s", elapsed)
+			}
+			if _, err := os.Stat(output); err == nil {
+				t.Error("a failed post-process still wrote the output")
+			}
+		})
+	}
+}
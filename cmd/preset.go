@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// presetHelp is the --preset value that lists the presets instead of converting
+const presetHelp = "help"
+
+// presetOptionKeys maps the config keys of the root command to the conversion option they set, so
+// a use case preset's options can be applied as defaults of those keys
+var presetOptionKeys = []struct {
+	key   string
+	value func(opts pkg.Options) interface{}
+}{
+	{"ignore-internals", func(opts pkg.Options) interface{} { return opts.IgnoreInternals }},
+	{"nullable-array-items", func(opts pkg.Options) interface{} { return opts.NullableArrayItems }},
+	{"id-type", func(opts pkg.Options) interface{} { return string(opts.IDTypeMapping) }},
+	{"definitions-only", func(opts pkg.Options) interface{} { return opts.DefinitionsOnly }},
+	{"only-input-types", func(opts pkg.Options) interface{} { return opts.OnlyInputTypes }},
+	{"prune", func(opts pkg.Options) interface{} { return opts.PruneToRoots }},
+	{"operations-layout", func(opts pkg.Options) interface{} { return string(opts.OperationsLayout) }},
+	{"extract-examples", func(opts pkg.Options) interface{} { return opts.ExtractExamples }},
+	{"strip-examples", func(opts pkg.Options) interface{} { return opts.StripExamples }},
+	{"embed-sdl", func(opts pkg.Options) interface{} { return opts.EmbedSDL }},
+	{"continue-on-error", func(opts pkg.Options) interface{} { return opts.Lenient }},
+	{"shared-pagination-args", func(opts pkg.Options) interface{} { return opts.SharedPaginationArgs }},
+	{"use-integer-type", func(opts pkg.Options) interface{} { return opts.UseIntegerType }},
+	{"int-bounds", func(opts pkg.Options) interface{} { return opts.IntBounds }},
+	{"well-known-scalars", func(opts pkg.Options) interface{} { return opts.WellKnownScalars }},
+	{"additional-properties-false", func(opts pkg.Options) interface{} { return opts.AdditionalPropertiesFalse }},
+	{"nullable-fields", func(opts pkg.Options) interface{} { return string(opts.NullableFields) }},
+	{"interface-allof", func(opts pkg.Options) interface{} { return opts.InterfaceAllOf }},
+	{"interface-implementations", func(opts pkg.Options) interface{} { return string(opts.InterfaceImplementations) }},
+	{"interface-keep-fields", func(opts pkg.Options) interface{} { return opts.InterfaceKeepFields }},
+	{"enum-style", func(opts pkg.Options) interface{} { return string(opts.EnumStyle) }},
+	{"field-shape", func(opts pkg.Options) interface{} { return string(opts.FieldShape) }},
+	{"draft", func(opts pkg.Options) interface{} { return string(opts.Draft) }},
+}
+
+// presetFlags are the settings of use case presets that are applied by the command rather than
+// the conversion
+var presetFlags = map[string][]presetSetting{
+	"forms":             {{"flatten-allof", true}},
+	"llm-tools":         {{"inline-refs", "true"}},
+	"strict-validation": {{"fail-on-empty", true}},
+}
+
+// presetDefaults records the keys whose defaults were set by applyUseCasePreset
+var presetDefaults = make(map[string]bool)
+
+type presetSetting struct {
+	key   string
+	value interface{}
+}
+
+// useCaseSettings lists what the named use case preset changes from the defaults
+func useCaseSettings(preset *pkg.UseCasePreset) []presetSetting {
+	defaults, opts := pkg.DefaultOptions(), preset.Options()
+	var settings []presetSetting
+	for _, option := range presetOptionKeys {
+		if value := option.value(opts); value != option.value(defaults) {
+			settings = append(settings, presetSetting{option.key, value})
+		}
+	}
+	return append(settings, presetFlags[preset.Name]...)
+}
+
+// applyUseCasePreset makes the settings of the use case preset selected by --preset the defaults
+// of their keys, so flags, environment variables and the config file still override them
+func applyUseCasePreset() {
+	preset, ok := pkg.LookupUseCasePreset(viper.GetString("preset"))
+	if !ok {
+		return
+	}
+	for _, setting := range useCaseSettings(preset) {
+		viper.SetDefault(setting.key, setting.value)
+		presetDefaults[setting.key] = true
+	}
+}
+
+// printPresets describes every preset and the settings it applies, for --preset help
+func printPresets(w io.Writer) {
+	fmt.Fprintln(w, "Use case presets, replacing the defaults of these options:")
+	for _, name := range pkg.UseCasePresetNames() {
+		preset, _ := pkg.LookupUseCasePreset(name)
+		var settings []string
+		for _, setting := range useCaseSettings(preset) {
+			settings = append(settings, fmt.Sprintf("--%s=%v", setting.key, setting.value))
+		}
+		fmt.Fprintf(w, "  %-18s %s\n  %-18s %s\n", name, preset.Description, "", strings.Join(settings, " "))
+	}
+
+	fmt.Fprintln(w, "\nGraphQL server presets, leaving out helper types and mapping scalars:")
+	for _, name := range pkg.PresetNames() {
+		preset, _ := pkg.LookupPreset(name)
+		fmt.Fprintf(w, "  %-18s %s\n", name, preset.Description)
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// hasuraFixture is the trimmed Hasura schema the pkg preset tests use
+var hasuraFixture = filepath.Join("..", "pkg", "testdata", "presets", "hasura.graphql")
+
+func TestServerPresetFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		kept     []string
+		excluded []string
+	}{
+		{"preset alone", nil, []string{"users", "users_insert_input", "uuid"}, []string{"users_bool_exp", "users_aggregate", "users_order_by"}},
+		{"include-type wins", []string{"--include-type", "users", "--include-type", "users_aggregate"}, []string{"users", "users_aggregate"}, []string{"users_bool_exp", "users_insert_input"}},
+		{"exclude-type adds", []string{"--exclude-type", "users_mutation_response"}, []string{"users"}, []string{"users_mutation_response", "users_bool_exp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", hasuraFixture, "-o", output, "--preset", "hasura"}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			schema := readSchema(t, output)
+			for _, name := range tt.kept {
+				if schema.Definitions[name] == nil {
+					t.Errorf("definition %s was dropped", name)
+				}
+			}
+			for _, name := range tt.excluded {
+				if schema.Definitions[name] != nil {
+					t.Errorf("definition %s was kept", name)
+				}
+			}
+			if uuid := schema.Definitions["uuid"]; uuid != nil && uuid.Format != "uuid" {
+				t.Errorf("uuid is not mapped: %+v", uuid)
+			}
+		})
+	}
+}
+
+func TestServerPresetScalarFlagWins(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--no-config", "-i", hasuraFixture, "-o", output, "--preset", "hasura", "--scalar", `uuid={"type":"string","minLength":36}`)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	if uuid := readSchema(t, output).Definitions["uuid"]; uuid.Format == "uuid" {
+		t.Error("the preset's uuid mapping replaced the --scalar mapping")
+	}
+}
+
+func TestPresetHelp(t *testing.T) {
+	result := runCLI(t, "--no-config", "--preset", "help")
+	if result.err != nil {
+		t.Fatalf("--preset help: %v", result.err)
+	}
+	for _, name := range []string{"hasura", "postgraphile", "shopify-admin", "forms", "llm-tools"} {
+		if !strings.Contains(result.stdout, name) {
+			t.Errorf("--preset help does not list %s:\n%s", name, result.stdout)
+		}
+	}
+}
+
+// presetOptions resolves the options of a run with the given flags the way initConfig and the
+// conversion do
+func presetOptions(t *testing.T, flags ...string) *pkg.Options {
+	t.Helper()
+	resetCLI(t)
+	if err := rootCmd.ParseFlags(flags); err != nil {
+		t.Fatal(err)
+	}
+	applyUseCasePreset()
+	opts, err := buildOptions()
+	if err != nil {
+		t.Fatalf("buildOptions: %v", err)
+	}
+	return opts
+}
+
+func TestUseCasePresetEffectiveOptions(t *testing.T) {
+	defaults := reflect.ValueOf(pkg.DefaultOptions())
+	for _, name := range pkg.UseCasePresetNames() {
+		t.Run(name, func(t *testing.T) {
+			preset, _ := pkg.LookupUseCasePreset(name)
+			want := reflect.ValueOf(preset.Options())
+			got := reflect.ValueOf(*presetOptions(t, "--preset", name))
+			// Every option the preset changes reaches the conversion
+			changed := 0
+			for i := 0; i < want.NumField(); i++ {
+				field := want.Type().Field(i)
+				if !field.IsExported() || field.Type.Kind() == reflect.Func || reflect.DeepEqual(want.Field(i).Interface(), defaults.Field(i).Interface()) {
+					continue
+				}
+				changed++
+				if !reflect.DeepEqual(got.Field(i).Interface(), want.Field(i).Interface()) {
+					t.Errorf("--preset %s: %s = %v, want %v", name, field.Name, got.Field(i), want.Field(i))
+				}
+			}
+			if changed == 0 {
+				t.Errorf("--preset %s changes no option", name)
+			}
+		})
+	}
+}
+
+func TestUseCasePresetOverrides(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		check func(t *testing.T, opts *pkg.Options)
+	}{
+		{"strict-validation nullable fields off", []string{"--preset", "strict-validation", "--nullable-fields", "off"}, func(t *testing.T, opts *pkg.Options) {
+			if opts.NullableFields != pkg.NullableFieldsOff {
+				t.Errorf("NullableFields = %q, want off", opts.NullableFields)
+			}
+			if !opts.AdditionalPropertiesFalse || !opts.UseIntegerType {
+				t.Error("the other strict-validation options were lost")
+			}
+		}},
+		{"llm-tools draft-07 and wrapped fields", []string{"--preset", "llm-tools", "--draft", "draft-07", "--field-shape", "wrapped"}, func(t *testing.T, opts *pkg.Options) {
+			if opts.Draft != pkg.Draft07 || opts.FieldShape != pkg.FieldShapeWrapped {
+				t.Errorf("Draft = %q, FieldShape = %q, want draft-07 and wrapped", opts.Draft, opts.FieldShape)
+			}
+			if opts.OperationsLayout != pkg.OperationsLayoutFlat {
+				t.Errorf("OperationsLayout = %q, want the preset's flat layout", opts.OperationsLayout)
+			}
+		}},
+		{"forms keeping examples", []string{"--preset", "forms", "--strip-examples=false"}, func(t *testing.T, opts *pkg.Options) {
+			if opts.StripExamples || !opts.DefinitionsOnly {
+				t.Errorf("StripExamples = %v, DefinitionsOnly = %v", opts.StripExamples, opts.DefinitionsOnly)
+			}
+		}},
+		{"docs without SDL", []string{"--preset", "docs", "--embed-sdl=false"}, func(t *testing.T, opts *pkg.Options) {
+			if opts.EmbedSDL || !opts.ExtractExamples {
+				t.Errorf("EmbedSDL = %v, ExtractExamples = %v", opts.EmbedSDL, opts.ExtractExamples)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, presetOptions(t, tt.flags...))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	progress  bool
+	maxMemory int
+)
+
+// progressCallback returns the Options.Progress callback for --progress and --max-memory,
+// or nil when neither is set so the conversion does no extra work
+func progressCallback() func(done, total int) error {
+	showProgress := viper.GetBool("progress")
+	limit := uint64(viper.GetInt("max-memory")) << 20
+	if !showProgress && limit == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	return func(done, total int) error {
+		if showProgress {
+			logger.Info("Converting", "definitions", fmt.Sprintf("%d/%d", done, total), "elapsed", time.Since(start).Round(time.Millisecond))
+		}
+		if limit == 0 {
+			return nil
+		}
+
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > limit {
+			return fmt.Errorf("heap usage of %d MiB exceeds --max-memory %d MiB after %d of %d definitions; "+
+				"reduce the output with --operation, --root with --prune, or --select-type",
+				(stats.HeapAlloc+1<<20-1)>>20, limit>>20, done, total)
+		}
+		return nil
+	}
+}
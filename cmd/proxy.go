@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// proxyMaxBody bounds the request and response bodies buffered for validation; larger bodies are
+// streamed through unchecked
+const proxyMaxBody = 10 << 20
+
+var (
+	proxyUpstream string
+	proxyListen   string
+	proxyRefresh  time.Duration
+	proxyAnnotate bool
+	proxyEnforce  bool
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Validate GraphQL responses in a reverse proxy in front of the server",
+	Long: `Forward every request to the GraphQL endpoint --upstream and validate the data of
+responses to POSTed operations against the operation's response schema, built with the
+request's variables. The upstream is introspected at startup and every --refresh.
+
+Violations are logged. With --annotate they are also added to the response as
+extensions.schemaViolations; with --enforce the response is replaced by a 502 carrying
+them. Requests that are not GraphQL POSTs, operations the schema cannot be built for,
+and non-JSON or oversized responses are passed through unchecked.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProxy()
+	},
+}
+
+func init() {
+	proxyCmd.Flags().StringVar(&proxyUpstream, "upstream", "", "GraphQL endpoint to forward requests to")
+	proxyCmd.Flags().StringVar(&proxyListen, "listen", ":8080", "address to listen on")
+	proxyCmd.Flags().DurationVar(&proxyRefresh, "refresh", 5*time.Minute, "how often the upstream schema is introspected again")
+	proxyCmd.Flags().BoolVar(&proxyAnnotate, "annotate", false, "add violations to the response as extensions.schemaViolations")
+	proxyCmd.Flags().BoolVar(&proxyEnforce, "enforce", false, "answer responses with violations with a 502")
+
+	bindFlag("proxy.upstream", proxyCmd.Flags().Lookup("upstream"))
+	bindFlag("proxy.listen", proxyCmd.Flags().Lookup("listen"))
+	bindFlag("proxy.refresh", proxyCmd.Flags().Lookup("refresh"))
+	bindFlag("proxy.annotate", proxyCmd.Flags().Lookup("annotate"))
+	bindFlag("proxy.enforce", proxyCmd.Flags().Lookup("enforce"))
+
+	rootCmd.AddCommand(proxyCmd)
+}
+
+// upstreamSchema holds the latest introspection of the upstream
+type upstreamSchema struct {
+	endpoint string
+	headers  http.Header
+
+	mu            sync.RWMutex
+	introspection *pkg.IntrospectionQuery
+}
+
+func (s *upstreamSchema) get() *pkg.IntrospectionQuery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.introspection
+}
+
+func (s *upstreamSchema) refresh() error {
+	introspection, err := getIntrospectionFromEndpoint(s.endpoint, s.headers)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.introspection = introspection
+	s.mu.Unlock()
+	logger.Info("Schema refreshed", "upstream", redactSecrets(s.endpoint), "types", len(introspection.Schema.Types))
+	return nil
+}
+
+// refreshEvery introspects the upstream every interval until ctx is done, keeping the previous
+// schema when it fails
+func (s *upstreamSchema) refreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.refresh(); err != nil {
+				logger.Error("Schema refresh failed", "error", err)
+			}
+		}
+	}
+}
+
+// proxyOperation is the checked operation of a forwarded request, carried in its context
+type proxyOperation struct {
+	name   string
+	schema *pkg.ResponseSchema
+}
+
+type proxyOperationKey struct{}
+
+// graphQLRequest is the body of a GraphQL POST
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// validatingProxy forwards requests to the upstream and validates the responses to GraphQL operations
+type validatingProxy struct {
+	schema   *upstreamSchema
+	opts     *pkg.Options
+	annotate bool
+	enforce  bool
+	proxy    *httputil.ReverseProxy
+}
+
+func runProxy() error {
+	endpoint := viper.GetString("proxy.upstream")
+	if endpoint == "" {
+		return withExitCode(ExitUsage, fmt.Errorf("proxy requires --upstream"))
+	}
+	upstream, err := url.Parse(endpoint)
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --upstream: %s", endpoint))
+	}
+	interval := viper.GetDuration("proxy.refresh")
+	if interval <= 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --refresh: %s", interval))
+	}
+
+	headers, err := requestHeaders()
+	if err != nil {
+		return err
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+
+	schema := &upstreamSchema{endpoint: endpoint, headers: headers}
+	if err := schema.refresh(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go schema.refreshEvery(ctx, interval)
+
+	p := newValidatingProxy(upstream, schema, opts, viper.GetBool("proxy.annotate"), viper.GetBool("proxy.enforce"))
+	server := &http.Server{
+		Addr:    viper.GetString("proxy.listen"),
+		Handler: p,
+	}
+	return listenUntilSignal(server, "Proxying GraphQL requests")
+}
+
+// newValidatingProxy returns a proxy forwarding requests to upstream and checking the responses against schema
+func newValidatingProxy(upstream *url.URL, schema *upstreamSchema, opts *pkg.Options, annotate, enforce bool) *validatingProxy {
+	p := &validatingProxy{
+		schema:   schema,
+		opts:     opts,
+		annotate: annotate,
+		enforce:  enforce,
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = upstream.Scheme
+			r.Out.URL.Host = upstream.Host
+			r.Out.URL.Path, r.Out.URL.RawPath = upstream.Path, upstream.RawPath
+			if upstream.RawQuery != "" {
+				r.Out.URL.RawQuery = upstream.RawQuery
+			}
+			r.Out.Host = ""
+			r.SetXForwarded()
+			if r.In.Context().Value(proxyOperationKey{}) != nil {
+				// Let the transport negotiate compression so the response arrives decoded
+				r.Out.Header.Del("Accept-Encoding")
+			}
+		},
+		ModifyResponse: p.checkResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Error("Upstream request failed", "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	return p
+}
+
+func (p *validatingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && isJSONContentType(r.Header.Get("Content-Type")) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, proxyMaxBody+1))
+		if err != nil {
+			http.Error(w, "error reading request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > proxyMaxBody {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		} else {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if op := p.operation(body); op != nil {
+				r = r.WithContext(context.WithValue(r.Context(), proxyOperationKey{}, op))
+			}
+		}
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+// operation builds the response schema for the operation in a request body, or returns nil when
+// the request is not a single GraphQL operation the schema can be built for
+func (p *validatingProxy) operation(body []byte) *proxyOperation {
+	var request graphQLRequest
+	if err := json.Unmarshal(body, &request); err != nil || request.Query == "" {
+		return nil
+	}
+	doc, err := pkg.ParseDocument(request.Query)
+	if err != nil {
+		logger.Debug("Request is not checked", "reason", "unparsable query", "error", err)
+		return nil
+	}
+	op, err := doc.Operation(request.OperationName)
+	if err != nil {
+		logger.Debug("Request is not checked", "reason", "no operation", "error", err)
+		return nil
+	}
+
+	variables := request.Variables
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+	schema, err := pkg.OperationResponseSchemaForVariables(*p.schema.get(), doc, op.Name, variables, p.opts)
+	if err != nil {
+		logger.Debug("Request is not checked", "operation", op.Name, "error", err)
+		return nil
+	}
+	return &proxyOperation{name: op.Name, schema: schema}
+}
+
+// checkResponse validates the upstream response to a checked operation, then annotates or replaces
+// it according to the mode
+func (p *validatingProxy) checkResponse(resp *http.Response) error {
+	op, _ := resp.Request.Context().Value(proxyOperationKey{}).(*proxyOperation)
+	if op == nil || resp.Header.Get("Content-Encoding") != "" || !isJSONContentType(resp.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, proxyMaxBody+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > proxyMaxBody {
+		logger.Debug("Response is not checked", "operation", op.name, "reason", "too large")
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+
+	violations, err := checkResponse(op.schema, data, false)
+	if err != nil {
+		logger.Debug("Response is not checked", "operation", op.name, "error", err)
+	}
+	for _, violation := range violations {
+		logger.Warn("Response does not match the operation schema", "operation", op.name,
+			"path", violation.InstancePath, "coordinate", violation.Coordinate, "message", violation.Message)
+	}
+
+	switch {
+	case len(violations) == 0:
+	case p.enforce:
+		data, err = json.Marshal(map[string]interface{}{
+			"errors":     []map[string]string{{"message": "upstream response does not match the operation schema"}},
+			"extensions": map[string]interface{}{"schemaViolations": violations},
+		})
+		resp.StatusCode, resp.Status = http.StatusBadGateway, "502 Bad Gateway"
+		resp.Header.Set("Content-Type", "application/json")
+	case p.annotate:
+		data, err = annotateResponse(data, violations)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	return nil
+}
+
+// annotateResponse adds the violations to the response's extensions, keeping every other member
+func annotateResponse(data []byte, violations []ResponseValidationError) ([]byte, error) {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	extensions := make(map[string]json.RawMessage)
+	if raw, ok := response["extensions"]; ok {
+		if err := json.Unmarshal(raw, &extensions); err != nil {
+			return nil, fmt.Errorf("error parsing response extensions: %w", err)
+		}
+	}
+
+	encoded, err := json.Marshal(violations)
+	if err != nil {
+		return nil, err
+	}
+	extensions["schemaViolations"] = encoded
+	if response["extensions"], err = json.Marshal(extensions); err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
+
+// isJSONContentType reports whether a Content-Type is JSON, including application/graphql-response+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// syncBuffer is a buffer the proxy's handlers can log to concurrently
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+// captureLog sends the CLI's log records to a buffer for the rest of the test
+func captureLog(t *testing.T) *syncBuffer {
+	t.Helper()
+	saved := logger
+	t.Cleanup(func() { logger = saved })
+	buffer := &syncBuffer{}
+	if err := configureLogger(buffer, false, true, "text"); err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+// mockUpstream is a GraphQL server answering introspection from an SDL and every other operation with
+// a fixed response
+type mockUpstream struct {
+	*httptest.Server
+	sdl      atomic.Value
+	response atomic.Value
+}
+
+func newMockUpstream(t *testing.T, sdl string) *mockUpstream {
+	t.Helper()
+	m := &mockUpstream{}
+	m.sdl.Store(sdl)
+	m.response.Store(`{"data": null}`)
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(string(body), "__schema") {
+			w.Write([]byte(m.response.Load().(string)))
+			return
+		}
+		introspection, err := pkg.IntrospectionFromSDL(m.sdl.Load().(string))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": introspection})
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// startProxy introspects the upstream and serves a validating proxy in front of it
+func startProxy(t *testing.T, upstream *mockUpstream, annotate, enforce bool) (*httptest.Server, *upstreamSchema) {
+	t.Helper()
+	resetCLI(t)
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := &upstreamSchema{endpoint: upstream.URL, headers: http.Header{}}
+	if err := schema.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	front := httptest.NewServer(newValidatingProxy(target, schema, opts, annotate, enforce))
+	t.Cleanup(front.Close)
+	return front, schema
+}
+
+// postQuery sends a GraphQL request through the proxy
+func postQuery(t *testing.T, url, query string) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+const proxySDL = "type Query { user: User }\n\ntype User { id: ID! name: String }\n"
+
+func TestProxy(t *testing.T) {
+	const (
+		valid   = `{"data":{"user":{"id":"1","name":"Ada"}}}`
+		invalid = `{"data":{"user":{"id":"1","name":42}}}`
+	)
+	tests := []struct {
+		name       string
+		annotate   bool
+		enforce    bool
+		response   string
+		status     int
+		want       string
+		violations bool
+	}{
+		{"valid response passes through", false, false, valid, http.StatusOK, valid, false},
+		{"violations are only logged by default", false, false, invalid, http.StatusOK, invalid, true},
+		{"annotate adds the violations", true, false, invalid, http.StatusOK, `"schemaViolations":[{"instancePath":"/data/user/name"`, true},
+		{"enforce answers 502", false, true, invalid, http.StatusBadGateway, "upstream response does not match the operation schema", true},
+		{"enforce keeps valid responses", false, true, valid, http.StatusOK, valid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := captureLog(t)
+			upstream := newMockUpstream(t, proxySDL)
+			upstream.response.Store(tt.response)
+			front, _ := startProxy(t, upstream, tt.annotate, tt.enforce)
+
+			status, body := postQuery(t, front.URL, "{ user { id name } }")
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("response %s does not contain %s", body, tt.want)
+			}
+			if logged := strings.Contains(log.String(), "Response does not match the operation schema"); logged != tt.violations {
+				t.Errorf("violation logged = %t, want %t:\n%s", logged, tt.violations, log.String())
+			}
+		})
+	}
+}
+
+func TestProxyPassesOtherRequestsThrough(t *testing.T) {
+	captureLog(t)
+	upstream := newMockUpstream(t, proxySDL)
+	upstream.response.Store(`{"data":{"user":{"id":"1","name":42}}}`)
+	front, _ := startProxy(t, upstream, false, true)
+
+	// An unparsable query is forwarded unchecked rather than rejected
+	if status, body := postQuery(t, front.URL, "{ user { "); status != http.StatusOK || !strings.Contains(body, `"name":42`) {
+		t.Errorf("unparsable query: status %d, body %s", status, body)
+	}
+	resp, err := http.Get(front.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET status = %d, want it forwarded", resp.StatusCode)
+	}
+}
+
+func TestProxySchemaRefresh(t *testing.T) {
+	captureLog(t)
+	upstream := newMockUpstream(t, proxySDL)
+	upstream.response.Store(`{"data":{"user":{"id":"1","email":"ada@example.com"}}}`)
+	front, schema := startProxy(t, upstream, false, true)
+
+	// The field does not exist yet, so the operation cannot be checked and is forwarded as is
+	if status, _ := postQuery(t, front.URL, "{ user { id email } }"); status != http.StatusOK {
+		t.Errorf("status before refresh = %d", status)
+	}
+
+	upstream.sdl.Store("type Query { user: User }\n\ntype User { id: ID! email: Int }\n")
+	if err := schema.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := postQuery(t, front.URL, "{ user { id email } }"); status != http.StatusBadGateway {
+		t.Errorf("status after refresh = %d, want the refreshed schema to reject the string email: %s", status, body)
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var pydanticTypename bool
+
+func init() {
+	rootCmd.Flags().BoolVar(&pydanticTypename, "pydantic-typename", false, "add __typename to --target pydantic models and discriminate unions by it")
+	bindFlag("pydantic.typename", rootCmd.Flags().Lookup("pydantic-typename"))
+}
+
+// generatePydantic exports the types as Pydantic v2 models, limited to --select-type and the types it
+// references when set. Scalar mappings can be extended with the pydantic.scalar-types config block.
+func generatePydantic(introspection *pkg.IntrospectionQuery) ([]byte, error) {
+	opts := pkg.DefaultPydanticOptions(pkg.IDTypeMapping(viper.GetString("id-type")))
+	opts.Typename = viper.GetBool("pydantic.typename")
+	opts.IgnoreInternals = viper.GetBool("ignore-internals")
+
+	// Viper lowercases config keys, so scalar names are matched against the schema case-insensitively
+	scalarTypes := viper.GetStringMapString("pydantic.scalar-types")
+	for _, t := range introspection.Schema.Types {
+		if expr, ok := scalarTypes[strings.ToLower(t.Name)]; ok && t.Kind == "SCALAR" {
+			opts.ScalarTypes[t.Name] = expr
+		}
+	}
+
+	output, err := pkg.PydanticModule(*introspection, viper.GetString("select-type"), opts)
+	if err != nil {
+		return nil, withExitCode(ExitConversion, err)
+	}
+	return output, nil
+}
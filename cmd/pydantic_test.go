@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPydanticTarget(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL+"\nscalar DateTime\n\ntype Event { at: DateTime }\n")
+	config := writeFile(t, "config.yaml", "pydantic:\n  scalar-types:\n    DateTime: datetime.datetime\n")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "all types",
+			args: []string{"--no-config"},
+			want: []string{
+				"class User(BaseModel):",
+				"    email: Optional[str] = None",
+				"class Role(str, Enum):",
+				"    role: Optional[Role] = Role.MEMBER",
+				"SearchResult = Union[User, Post]",
+				"User.model_rebuild()",
+				"DateTime = Any",
+			},
+			notWant: []string{"class Query(", "__typename"},
+		},
+		{
+			name: "typename",
+			args: []string{"--no-config", "--pydantic-typename"},
+			want: []string{
+				`    typename__: Literal["User"] = Field(default="User", alias="__typename")`,
+				`SearchResult = Annotated[Union[User, Post], Field(discriminator="typename__")]`,
+			},
+		},
+		{
+			name:    "selected type",
+			args:    []string{"--no-config", "--select-type", "CreateUserInput"},
+			want:    []string{"class CreateUserInput(BaseModel):", "class Role(str, Enum):"},
+			notWant: []string{"class User(", "class Address("},
+		},
+		{
+			name: "configured scalars",
+			args: []string{"--config", config},
+			want: []string{"DateTime = datetime.datetime"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runCLI(t, append(tt.args, "-i", input, "--target", "pydantic")...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result.stdout, want+"\n") {
+					t.Errorf("module has no line %q:\n%s", want, result.stdout)
+				}
+			}
+			for _, unwanted := range tt.notWant {
+				if strings.Contains(result.stdout, unwanted) {
+					t.Errorf("module contains %q:\n%s", unwanted, result.stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestPydanticTargetUnknownType(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	result := runCLI(t, "--no-config", "-i", input, "--target", "pydantic", "--select-type", "Missing")
+	if code := ExitCode(result.err); code != ExitConversion {
+		t.Errorf("exit code %d (%v), want a conversion error", code, result.err)
+	}
+}
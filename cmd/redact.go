@@ -0,0 +1,17 @@
+package cmd
+
+import "strings"
+
+// sensitiveHeaderWords mark header names whose values are credentials
+var sensitiveHeaderWords = []string{"authorization", "cookie", "token", "secret", "key", "password"}
+
+// isSensitiveHeader reports whether a header's value should never be logged or written to disk
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+var renameMapFile string
+
+func init() {
+	rootCmd.Flags().StringVar(&renameMapFile, "rename-map", "", "YAML or JSON file mapping type names to the names they are published under")
+	bindFlag("rename-map", rootCmd.Flags().Lookup("rename-map"))
+}
+
+// loadRenameMap reads a --rename-map file of old: new pairs
+func loadRenameMap(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rename map: %w", err)
+	}
+	var renames map[string]string
+	if err := yaml.Unmarshal(data, &renames); err != nil {
+		return nil, fmt.Errorf("%s: must map type names to new names: %w", path, err)
+	}
+	return renames, nil
+}
+
+// applyRenameMap renames the definitions and root properties listed in the --rename-map file
+func applyRenameMap(schema *pkg.JSONSchema6, path string) error {
+	renames, err := loadRenameMap(path)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if err := pkg.RenameDefinitions(schema, renames); err != nil {
+		return withExitCode(ExitConversion, fmt.Errorf("%s: %w", path, err))
+	}
+	logger.Debug("Renamed types", "renames", len(renames))
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameMapFlag(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	renames := writeFile(t, "renames.yaml", "User: Account\nRole: AccountRole\n")
+	output := filepath.Join(t.TempDir(), "schema.json")
+
+	result := runCLI(t, "--no-config", "-i", input, "-o", output, "--rename-map", renames)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	schema := readSchema(t, output)
+	account := schema.Definitions["Account"]
+	if schema.Definitions["User"] != nil || account == nil || account.GraphQLType != "User" {
+		t.Fatalf("User was not renamed to Account")
+	}
+	if got := account.Properties["role"].Properties["return"].Ref; got != "#/definitions/AccountRole" {
+		t.Errorf("Account.role refers to %q", got)
+	}
+	if got := schema.Properties["Query"].Properties["user"].Properties["return"].Ref; got != "#/definitions/Account" {
+		t.Errorf("Query.user refers to %q", got)
+	}
+}
+
+func TestRenameMapFlagErrors(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	tests := []struct {
+		name    string
+		renames string
+		want    string
+		code    int
+	}{
+		{"collision", "User: Post\nAddress: Post\n", "Address -> Post (also the target of User), User -> Post (also the target of Address)", ExitConversion},
+		{"unknown type", "Usr: Account\n", "Usr -> Account (no such definition or root property)", ExitConversion},
+		{"not a map", "- User\n", "must map type names to new names", ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renames := writeFile(t, "renames.yaml", tt.renames)
+			result := runCLI(t, "--no-config", "-i", input, "--rename-map", renames)
+			if result.err == nil || !strings.Contains(result.err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, result.err)
+			}
+			if code := ExitCode(result.err); code != tt.code {
+				t.Errorf("exit code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat  string
+	reportColumns []string
+)
+
+// inventoryColumns lists the columns of the field inventory in their default order
+var inventoryColumns = []string{
+	"kind", "type", "field", "argument", "graphql_type", "json_type",
+	"required", "nullable", "deprecated", "deprecation_reason", "description",
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Write an inventory of every field as CSV, TSV or JSON",
+	Long: `Write a flat inventory of the schema from --endpoint, --input or stdin for API governance
+spreadsheets: one row per field of the object and interface types, per argument and per input
+field, told apart by the kind column (field, argument or input-field).
+
+Columns, in default order: kind, type, field, argument, graphql_type, json_type, required,
+nullable, deprecated, deprecation_reason and description. --columns selects and orders them.
+json_type is the JSON Schema type of the value as the current options convert it, so --id-type
+and custom scalar mappings apply, and filtered fields and types are left out.
+
+CSV follows RFC 4180: fields containing commas, quotes or line breaks are quoted and quotes are
+doubled, and lines end with CRLF. TSV quotes fields containing tabs, quotes or line breaks the same
+way. JSON is an array of objects holding the selected columns.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReport()
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVarP(&reportFormat, "format", "f", "csv", "output format (csv, tsv or json)")
+	reportCmd.Flags().StringSliceVar(&reportColumns, "columns", nil, "columns to write, in order (default all: "+strings.Join(inventoryColumns, ",")+")")
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReport() error {
+	if reportFormat != "csv" && reportFormat != "tsv" && reportFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'csv', 'tsv' or 'json')", reportFormat))
+	}
+	columns := reportColumns
+	if len(columns) == 0 {
+		columns = inventoryColumns
+	}
+	for _, column := range columns {
+		if !slices.Contains(inventoryColumns, column) {
+			return withExitCode(ExitUsage, fmt.Errorf("unknown column: %s (must be one of %s)", column, strings.Join(inventoryColumns, ", ")))
+		}
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+	rows, err := pkg.FieldInventory(*introspection, opts)
+	if err != nil {
+		return withExitCode(ExitConversion, err)
+	}
+
+	if reportFormat == "json" {
+		records := make([]map[string]interface{}, len(rows))
+		for i, row := range rows {
+			records[i] = make(map[string]interface{}, len(columns))
+			for _, column := range columns {
+				records[i][column] = inventoryValue(row, column)
+			}
+		}
+		return printJSON(records)
+	}
+
+	comma := ','
+	if reportFormat == "tsv" {
+		comma = '\t'
+	}
+	return writeInventory(os.Stdout, rows, columns, comma)
+}
+
+// writeInventory writes rows as delimited text with a header line. Quoting follows RFC 4180 and
+// lines end with CRLF.
+func writeInventory(w io.Writer, rows []pkg.InventoryRow, columns []string, comma rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	writer.UseCRLF = true
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = fmt.Sprint(inventoryValue(row, column))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// inventoryValue returns the value of a column of row
+func inventoryValue(row pkg.InventoryRow, column string) interface{} {
+	switch column {
+	case "kind":
+		return string(row.Kind)
+	case "type":
+		return row.Type
+	case "field":
+		return row.Field
+	case "argument":
+		return row.Argument
+	case "graphql_type":
+		return row.GraphQLType
+	case "json_type":
+		return row.JSONType
+	case "required":
+		return row.Required
+	case "nullable":
+		return row.Nullable
+	case "deprecated":
+		return row.Deprecated
+	case "deprecation_reason":
+		return row.DeprecationReason
+	case "description":
+		return row.Description
+	}
+	return ""
+}
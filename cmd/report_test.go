@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reportSDL has descriptions that need quoting in CSV and TSV
+const reportSDL = `
+type Query {
+  """
+  Look a user up, by "id"
+  or by name
+  """
+  user(id: ID!, "Tab\tseparated" name: String): User
+}
+
+type User {
+  id: ID!
+  tags: [String!]!
+  email: String @deprecated(reason: "Use contact, please")
+}
+
+input UserInput { name: String! }
+`
+
+func TestReport(t *testing.T) {
+	input := writeFile(t, "schema.graphql", reportSDL)
+	for _, format := range []string{"csv", "tsv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			result := runCLI(t, "report", "--no-config", "-i", input, "--format", format)
+			if result.err != nil {
+				t.Fatalf("report: %v\n%s", result.err, result.stderr)
+			}
+			assertGolden(t, "report/inventory."+format, []byte(result.stdout))
+		})
+	}
+}
+
+func TestReportCSVRoundTrip(t *testing.T) {
+	input := writeFile(t, "schema.graphql", reportSDL)
+	result := runCLI(t, "report", "--no-config", "-i", input)
+	if result.err != nil {
+		t.Fatalf("report: %v\n%s", result.err, result.stderr)
+	}
+	if !strings.Contains(result.stdout, "\r\n") {
+		t.Error("lines do not end with CRLF")
+	}
+
+	// A conforming reader gets the descriptions back unchanged
+	records, err := csv.NewReader(strings.NewReader(result.stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("the report is not valid CSV: %v", err)
+	}
+	if !reflect.DeepEqual(records[0], inventoryColumns) {
+		t.Errorf("header = %v", records[0])
+	}
+	descriptions := make(map[string]string)
+	for _, record := range records[1:] {
+		descriptions[record[1]+"."+record[2]+"("+record[3]+")"] = record[10]
+	}
+	if got, want := descriptions["Query.user()"], "Look a user up, by \"id\"\nor by name"; got != want {
+		t.Errorf("Query.user description = %q, want %q", got, want)
+	}
+	if got, want := descriptions["Query.user(name)"], "Tab\tseparated"; got != want {
+		t.Errorf("Query.user(name:) description = %q, want %q", got, want)
+	}
+}
+
+func TestReportColumns(t *testing.T) {
+	input := writeFile(t, "schema.graphql", reportSDL)
+	result := runCLI(t, "report", "--no-config", "-i", input, "--columns", "field,kind,deprecated", "-f", "json")
+	if result.err != nil {
+		t.Fatalf("report: %v\n%s", result.err, result.stderr)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal([]byte(result.stdout), &records); err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if len(record) != 3 {
+			t.Errorf("record has columns other than the selected ones: %v", record)
+		}
+		if record["field"] == "email" && record["deprecated"] != true {
+			t.Errorf("email is not deprecated: %v", record)
+		}
+	}
+
+	// Type filters from the config apply as in a conversion
+	config := writeFile(t, "config.yaml", "exclude-type: [UserInput]\n")
+	result = runCLI(t, "report", "--config", config, "-i", input, "--columns", "type,field")
+	if result.err != nil {
+		t.Fatalf("report: %v\n%s", result.err, result.stderr)
+	}
+	want := "type,field\r\nQuery,user\r\nQuery,user\r\nQuery,user\r\nUser,id\r\nUser,tags\r\nUser,email\r\n"
+	if result.stdout != want {
+		t.Errorf("report:\n%q\nwant:\n%q", result.stdout, want)
+	}
+}
+
+func TestReportInvalidFlags(t *testing.T) {
+	input := writeFile(t, "schema.graphql", reportSDL)
+	for _, args := range [][]string{{"--format", "xlsx"}, {"--columns", "kind,colour"}} {
+		result := runCLI(t, append([]string{"report", "--no-config", "-i", input}, args...)...)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("%v: exit code %d (%v), want a usage error", args, code, result.err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRootFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		properties  []string
+		definitions []string
+		missing     []string
+	}{
+		{"default emits every root", nil, []string{"Query", "Mutation", "Subscription"}, []string{"Unused"}, nil},
+		{"query root", []string{"--root", "query"}, []string{"Query"}, []string{"Unused"}, []string{"Mutation", "Subscription"}},
+		{"two roots", []string{"--root", "mutation,subscription"}, []string{"Mutation", "Subscription"}, nil, []string{"Query"}},
+		{"pruned mutation", []string{"--root", "mutation", "--prune"}, []string{"Mutation"}, []string{"CreateUserInput", "User"}, []string{"Query", "Post", "Unused"}},
+		{"definitions only", []string{"--definitions-only"}, nil, []string{"User", "Unused"}, []string{"Query", "Mutation", "Subscription"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := writeFile(t, "schema.graphql", fixtureSDL)
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+
+			schema := readSchema(t, output)
+			for _, name := range tt.properties {
+				if schema.Properties[name] == nil {
+					t.Errorf("root property %s is missing", name)
+				}
+			}
+			for _, name := range tt.definitions {
+				if schema.Definitions[name] == nil {
+					t.Errorf("definition %s is missing", name)
+				}
+			}
+			for _, name := range tt.missing {
+				if schema.Properties[name] != nil || schema.Definitions[name] != nil {
+					t.Errorf("%s should have been left out", name)
+				}
+			}
+		})
+	}
+}
+
+func TestRootFlagInvalid(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	result := runCLI(t, "--no-config", "-i", input, "--root", "queries")
+	if result.err == nil || !strings.Contains(result.err.Error(), "invalid root: queries") {
+		t.Fatalf("expected an invalid root error, got %v", result.err)
+	}
+	if code := ExitCode(result.err); code != ExitUsage {
+		t.Errorf("exit code = %d, want %d", code, ExitUsage)
+	}
+}
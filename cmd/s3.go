@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/spf13/viper"
+)
+
+// s3Store reads and writes S3 objects with the AWS SDK, which finds credentials and the region
+// the way the AWS CLI does. AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL point it at S3-compatible
+// services such as LocalStack or MinIO, which are addressed path-style.
+type s3Store struct{}
+
+func (s *s3Store) open(bucket, key string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := s.do(bucket, key, true, func(ctx context.Context, client *s3.Client) error {
+		out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err == nil {
+			body = out.Body
+		}
+		return err
+	})
+	return body, err
+}
+
+func (s *s3Store) exists(bucket, key string) (bool, error) {
+	err := s.do(bucket, key, true, func(ctx context.Context, client *s3.Client) error {
+		_, err := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		return err
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// put streams body to the object. Bodies larger than a part are sent as a multipart upload,
+// which S3 only turns into the object once every part has arrived.
+func (s *s3Store) put(bucket, key string, body io.Reader, contentType string) error {
+	seeker, rewindable := body.(io.Seeker)
+	return s.do(bucket, key, rewindable, func(ctx context.Context, client *s3.Client) error {
+		if rewindable {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		_, err := manager.NewUploader(client).Upload(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			Body:        body,
+			ContentType: aws.String(contentType),
+		})
+		return err
+	})
+}
+
+// do runs a request for an object with a client of the default configuration. A request sent to
+// the wrong region is retried once in the region S3 names when it can be repeated.
+func (s *s3Store) do(bucket, key string, repeatable bool, request func(context.Context, *s3.Client) error) error {
+	objectURL := "s3://" + bucket + "/" + key
+	ctx := context.Background()
+	// The SDK's own client, unlike objectClient, takes the CA bundle of AWS_CA_BUNDLE
+	httpClient := awshttp.NewBuildableClient().WithTimeout(time.Duration(viper.GetInt("timeout")) * time.Second)
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithHTTPClient(httpClient))
+	if err != nil {
+		return objectCredentialsError(objectURL, err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	// Resolve the credentials first so their absence is reported as such rather than as a
+	// failed request
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return objectCredentialsError(objectURL, err)
+	}
+	credentialSecrets = append(credentialSecrets, credentials.SecretAccessKey)
+	if credentials.SessionToken != "" {
+		credentialSecrets = append(credentialSecrets, credentials.SessionToken)
+	}
+
+	newClient := func(region string) *s3.Client {
+		return s3.NewFromConfig(cfg, func(o *s3.Options) {
+			o.Region = region
+			o.UsePathStyle = o.BaseEndpoint != nil
+		})
+	}
+	err = request(ctx, newClient(cfg.Region))
+	var respErr *awshttp.ResponseError
+	if repeatable && errors.As(err, &respErr) {
+		if region := respErr.Response.Header.Get("X-Amz-Bucket-Region"); region != "" && region != cfg.Region {
+			logger.Debug("Retrying in the bucket's region", "bucket", bucket, "region", region)
+			err = request(ctx, newClient(region))
+		}
+	}
+	if err != nil {
+		return s3Error(objectURL, err)
+	}
+	return nil
+}
+
+// s3Error classifies a failed S3 request by the status of its response
+func s3Error(objectURL string, err error) error {
+	var respErr *awshttp.ResponseError
+	if !errors.As(err, &respErr) {
+		return objectRequestError(objectURL, err)
+	}
+	detail := respErr.Response.Status
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		detail += ": " + apiErr.ErrorCode()
+		if message := apiErr.ErrorMessage(); message != "" {
+			detail += ": " + message
+		}
+	}
+	return objectStatusError(objectURL, respErr.HTTPStatusCode(), errors.New(detail))
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// sampleExamples runs a generated query for each sampleable query field against --endpoint and
+// attaches the returned values as examples. Sampling is best effort: every failure is logged as a
+// warning and leaves the schema as it was.
+func sampleExamples(schema *pkg.JSONSchema6, introspection *pkg.IntrospectionQuery, opts *pkg.Options) {
+	endpoint := servingEndpoint
+	if endpoints := configEndpoints(); endpoint == "" && len(endpoints) > 0 {
+		endpoint = endpoints[0]
+	}
+	if endpoint == "" {
+		logger.Warn("Examples are not sampled", "reason", "--sample-examples needs --endpoint")
+		return
+	}
+	headers, err := requestHeaders()
+	if err != nil {
+		logger.Warn("Examples are not sampled", "error", err)
+		return
+	}
+
+	sampleOpts := pkg.DefaultSampleOptions()
+	sampleOpts.MaxListItems = viper.GetInt("sample-list-size")
+	sampleOpts.Redact = viper.GetStringSlice("sample-redact")
+	depth := viper.GetInt("sample-depth")
+
+	allow := viper.GetStringSlice("sample-field")
+	fields := pkg.SampleableFields(*introspection, allow)
+	for _, field := range allow {
+		if !slices.Contains(fields, field) {
+			logger.Warn("Field is not sampled", pkg.LogKeyField, field, "reason", "not a query field without required arguments")
+		}
+	}
+
+	start := time.Now()
+	sampled := 0
+	for _, field := range fields {
+		query, err := pkg.GenerateQuery(*introspection, "query", field, depth)
+		if err != nil {
+			logger.Warn("Field is not sampled", pkg.LogKeyField, field, "error", err)
+			continue
+		}
+		data, err := runSampleQuery(endpoint, headers, query)
+		if err != nil {
+			logger.Warn("Field is not sampled", pkg.LogKeyField, field, "error", redactSecrets(err.Error()))
+			continue
+		}
+		value, ok := data[field]
+		if !ok || value == nil {
+			continue
+		}
+		if pkg.AttachQueryExample(schema, opts, field, pkg.PrepareSample(value, sampleOpts)) {
+			sampled++
+		}
+	}
+	logPhase("sample", start)
+	logger.Info("Sampled examples", "fields", sampled)
+}
+
+// runSampleQuery executes a query without variables and returns its data
+func runSampleQuery(endpoint string, headers http.Header, query string) (map[string]interface{}, error) {
+	payload, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	client, err := endpointClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var response struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("GraphQL error: %s", response.Errors[0].Message)
+	}
+	return response.Data, nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const sampleSDL = `
+type Query {
+  me: User
+  users: [User!]!
+  broken: User
+  user(id: ID!): User
+}
+
+type User {
+  id: ID!
+  name: String
+  apiToken: String
+}
+`
+
+// sampledField finds the root field of a query generated by pkg.GenerateQuery
+var sampledField = regexp.MustCompile(`^query \w+ \{\n  (\w+)`)
+
+// sampleServer answers introspection with sampleSDL and sample queries with canned values; broken
+// fails with a GraphQL error
+func sampleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	responses := map[string]string{
+		"me":     `{"data": {"me": {"id": "1", "name": "Ada", "apiToken": "s3cr3t"}}}`,
+		"users":  `{"data": {"users": [{"id": "1"}, {"id": "2"}, {"id": "3"}, {"id": "4"}]}}`,
+		"broken": `{"errors": [{"message": "resolver exploded"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query string `json:"query"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(request.Query, "__schema") {
+			introspection, err := pkg.IntrospectionFromSDL(sampleSDL)
+			if err != nil {
+				t.Error(err)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": introspection})
+			return
+		}
+		match := sampledField.FindStringSubmatch(request.Query)
+		if match == nil || responses[match[1]] == "" {
+			t.Errorf("unexpected query %q", request.Query)
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(responses[match[1]]))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSampleExamples(t *testing.T) {
+	server := sampleServer(t)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--no-config", "-e", server.URL, "-o", output, "--sample-examples", "--sample-list-size", "2")
+	if result.err != nil {
+		t.Fatalf("a failed sample failed the conversion: %v\n%s", result.err, result.stderr)
+	}
+
+	query := readSchema(t, output).Properties["Query"]
+	me := query.Properties["me"].Properties["return"].Examples
+	if want := []interface{}{map[string]interface{}{"id": "1", "name": "Ada"}}; !reflect.DeepEqual(me, want) {
+		t.Errorf("me examples = %v, want %v with the token redacted", me, want)
+	}
+	users := query.Properties["users"].Properties["return"].Examples
+	if len(users) != 1 || len(users[0].([]interface{})) != 2 {
+		t.Errorf("users examples = %v, want one list shortened to two items", users)
+	}
+	for _, field := range []string{"broken", "user"} {
+		if examples := query.Properties[field].Properties["return"].Examples; examples != nil {
+			t.Errorf("%s has examples %v", field, examples)
+		}
+	}
+	if !strings.Contains(result.stderr, "Field is not sampled") || !strings.Contains(result.stderr, "resolver exploded") {
+		t.Errorf("the failed sample was not logged:\n%s", result.stderr)
+	}
+}
+
+func TestSampleExamplesAllowList(t *testing.T) {
+	server := sampleServer(t)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--no-config", "-e", server.URL, "-o", output, "--sample-examples",
+		"--sample-field", "users", "--sample-field", "user")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	query := readSchema(t, output).Properties["Query"]
+	if query.Properties["me"].Properties["return"].Examples != nil {
+		t.Error("a field outside the allow list was sampled")
+	}
+	if query.Properties["users"].Properties["return"].Examples == nil {
+		t.Error("the allowed field was not sampled")
+	}
+	if !strings.Contains(result.stderr, "field=user ") {
+		t.Errorf("the field with a required argument was not reported:\n%s", result.stderr)
+	}
+}
+
+func TestSampleExamplesWithoutEndpoint(t *testing.T) {
+	input := writeFile(t, "schema.graphql", sampleSDL)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	result := runCLI(t, "--no-config", "-i", input, "-o", output, "--sample-examples")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+	if !strings.Contains(result.stderr, "--sample-examples needs --endpoint") {
+		t.Errorf("the missing endpoint was not reported:\n%s", result.stderr)
+	}
+}
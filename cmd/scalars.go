@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	scalarMappings []string
+	scalarMapFile  string
+)
+
+func init() {
+	rootCmd.Flags().StringArrayVar(&scalarMappings, "scalar", nil, `JSON Schema emitted for a scalar, as NAME=JSON, e.g. 'DateTime={"type":"string","format":"date-time"}' (repeatable)`)
+	rootCmd.Flags().StringVar(&scalarMapFile, "scalar-map", "", "YAML or JSON file mapping scalar names to the JSON Schema emitted for them")
+	bindFlag("scalar", rootCmd.Flags().Lookup("scalar"))
+	bindFlag("scalar-map", rootCmd.Flags().Lookup("scalar-map"))
+}
+
+// scalarSchemasFromConfig returns the schemas to emit for scalars: the entries of --scalar-map with
+// those of --scalar on top, or nil when neither is set. Scalar names are kept as written, which
+// is why mappings are not read from a config block, whose keys are lowercased.
+func scalarSchemasFromConfig() (map[string]*pkg.JSONSchema6, error) {
+	var schemas map[string]*pkg.JSONSchema6
+	if path := viper.GetString("scalar-map"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("error reading scalar map: %w", err))
+		}
+		// YAML is a superset of JSON; the decoded document is re-encoded so that the schema's json
+		// tags apply
+		var entries map[string]interface{}
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("%s: must map scalar names to schemas: %w", path, err))
+		}
+		encoded, err := json.Marshal(entries)
+		if err == nil {
+			err = json.Unmarshal(encoded, &schemas)
+		}
+		if err != nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("%s: must map scalar names to schemas: %w", path, err))
+		}
+	}
+
+	for _, mapping := range viper.GetStringSlice("scalar") {
+		name, raw, ok := strings.Cut(mapping, "=")
+		if !ok || name == "" {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --scalar %q: must be NAME=JSON", mapping))
+		}
+		var schema *pkg.JSONSchema6
+		if err := json.Unmarshal([]byte(raw), &schema); err != nil || schema == nil {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid --scalar %q: the schema must be a JSON object", mapping))
+		}
+		if schemas == nil {
+			schemas = make(map[string]*pkg.JSONSchema6)
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScalarFlags(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL+"\nscalar DateTime\nscalar Decimal\n\ntype Invoice { due: DateTime total: Decimal }\n")
+	scalarMap := writeFile(t, "scalars.yaml", "DateTime:\n  type: string\n  format: date\nDecimal:\n  type: string\n  pattern: '^[0-9.]+$'\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		dateTime string
+		decimal  string
+	}{
+		{"scalar flag", []string{"--scalar", `DateTime={"type":"string","format":"date-time"}`}, "date-time", ""},
+		{"scalar map", []string{"--scalar-map", scalarMap}, "date", "^[0-9.]+$"},
+		{"flag wins over the map", []string{"--scalar-map", scalarMap, "--scalar", `DateTime={"type":"string","format":"date-time"}`}, "date-time", "^[0-9.]+$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			schema := readSchema(t, output)
+			invoice := schema.Definitions["Invoice"].Properties
+			if due := invoice["due"].Properties["return"]; due.Format != tt.dateTime || schema.Definitions["DateTime"].Format != tt.dateTime {
+				t.Errorf("DateTime format: usage %q, definition %q; want %q", due.Format, schema.Definitions["DateTime"].Format, tt.dateTime)
+			}
+			if total := invoice["total"].Properties["return"]; total.Pattern != tt.decimal {
+				t.Errorf("Decimal pattern = %q, want %q", total.Pattern, tt.decimal)
+			}
+		})
+	}
+}
+
+func TestScalarFlagsInvalid(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	for _, args := range [][]string{
+		{"--scalar", "DateTime"},
+		{"--scalar", "=" + `{"type":"string"}`},
+		{"--scalar", "DateTime=[1]"},
+		{"--scalar-map", filepath.Join(t.TempDir(), "missing.yaml")},
+		{"--scalar-map", writeFile(t, "scalars.yaml", "DateTime: date-time\n")},
+	} {
+		result := runCLI(t, append([]string{"--no-config", "-i", input}, args...)...)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("%v: exit code %d (%v), want a usage error", args, code, result.err)
+		}
+	}
+}
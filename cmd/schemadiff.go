@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaDiffFormat string
+	schemaDiffFailOn string
+)
+
+var schemaDiffCmd = &cobra.Command{
+	Use:   "schema-diff OLD [NEW]",
+	Short: "Compare two introspection results at the GraphQL level",
+	Long: `Compare two GraphQL introspection results and report the changes as GraphQL
+schema coordinates (Type, Type.field, Type.field(arg:), Enum.VALUE) rather than
+JSON Schema paths. Each change is classified following graphql-inspector:
+removals and incompatible type changes are breaking, new enum values, union
+members, interfaces, optional arguments and changed defaults are dangerous, and
+additions and deprecations are safe. When NEW is omitted it is read from
+--endpoint or --input.
+
+Exit code is 0 when no change reaches --fail-on, 7 for breaking changes and 8 for
+dangerous changes (with --fail-on dangerous); errors use the usual exit codes.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSchemaDiff(args)
+	},
+}
+
+func init() {
+	schemaDiffCmd.Flags().StringVarP(&schemaDiffFormat, "format", "f", "text", "output format (text or json)")
+	schemaDiffCmd.Flags().StringVar(&schemaDiffFailOn, "fail-on", "breaking", "lowest criticality that fails the check (breaking or dangerous)")
+
+	rootCmd.AddCommand(schemaDiffCmd)
+}
+
+func runSchemaDiff(args []string) error {
+	failOn := pkg.Criticality(schemaDiffFailOn)
+	if failOn != pkg.CriticalityBreaking && failOn != pkg.CriticalityDangerous {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --fail-on: %s (must be 'breaking' or 'dangerous')", schemaDiffFailOn))
+	}
+	if schemaDiffFormat != "text" && schemaDiffFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", schemaDiffFormat))
+	}
+
+	oldIntrospection, err := loadIntrospectionFile(args[0])
+	if err != nil {
+		return err
+	}
+	var newIntrospection *pkg.IntrospectionQuery
+	if len(args) > 1 {
+		newIntrospection, err = loadIntrospectionFile(args[1])
+	} else {
+		newIntrospection, err = loadIntrospection()
+	}
+	if err != nil {
+		return err
+	}
+
+	changes := pkg.DiffIntrospections(*oldIntrospection, *newIntrospection)
+	if schemaDiffFormat == "json" {
+		if err := printJSON(changes); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(formatGraphQLChanges(changes))
+	}
+
+	counts := make(map[pkg.Criticality]int)
+	for _, change := range changes {
+		counts[change.Criticality]++
+	}
+	if counts[pkg.CriticalityBreaking] > 0 {
+		return withExitCode(ExitMismatch, fmt.Errorf("%d breaking changes found", counts[pkg.CriticalityBreaking]))
+	}
+	if failOn == pkg.CriticalityDangerous && counts[pkg.CriticalityDangerous] > 0 {
+		return withExitCode(ExitWarning, fmt.Errorf("%d dangerous changes found", counts[pkg.CriticalityDangerous]))
+	}
+	return nil
+}
+
+// formatGraphQLChanges renders changes grouped by criticality, most critical first
+func formatGraphQLChanges(changes []pkg.GraphQLChange) string {
+	if len(changes) == 0 {
+		return "No changes\n"
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		criticality pkg.Criticality
+		title       string
+	}{
+		{pkg.CriticalityBreaking, "Breaking changes"},
+		{pkg.CriticalityDangerous, "Dangerous changes"},
+		{pkg.CriticalitySafe, "Safe changes"},
+	} {
+		lines := make([]string, 0)
+		for _, change := range changes {
+			if change.Criticality == group.criticality {
+				lines = append(lines, fmt.Sprintf("%s: %s", change.Type, change.Message))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s (%d):\n", group.title, len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbedSDLFlag(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"off by default", nil, ""},
+		{"embedded", []string{"--embed-sdl"}, "enum Role {\n  ADMIN\n  MEMBER\n}"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "schema.json")
+			result := runCLI(t, append([]string{"--no-config", "-i", input, "-o", output}, tt.args...)...)
+			if result.err != nil {
+				t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+			}
+			if got := readSchema(t, output).Definitions["Role"].Comment; got != tt.want {
+				t.Errorf("Role $comment = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
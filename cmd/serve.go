@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// serveShutdownTimeout bounds how long in-flight requests may take after SIGTERM
+const serveShutdownTimeout = 10 * time.Second
+
+var (
+	serveListen string
+	serveTTL    time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the converted JSON Schema over HTTP",
+	Long: `Serve the JSON Schema converted from --endpoint or --input over HTTP.
+
+Endpoints:
+  GET /schema.json          the full schema as JSON
+  GET /schema.yaml          the full schema as YAML
+  GET /definitions/{Type}   a self-contained schema for a single definition
+  GET /healthz              liveness check
+
+The schema is converted on demand and cached for --ttl. Conversion failures are
+returned as 502 with the error in the body.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServe()
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", ":8080", "address to listen on")
+	serveCmd.Flags().DurationVar(&serveTTL, "ttl", 5*time.Minute, "how long a converted schema is served before it is refreshed")
+
+	bindFlag("serve.listen", serveCmd.Flags().Lookup("listen"))
+	bindFlag("serve.ttl", serveCmd.Flags().Lookup("ttl"))
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+// schemaCache converts the schema on demand and keeps the result for ttl
+type schemaCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	schema    *pkg.JSONSchema6
+	output    []byte
+	refreshed time.Time
+}
+
+// get returns the cached schema, converting it again when the cache has expired
+func (c *schemaCache) get() (*pkg.JSONSchema6, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.schema != nil && time.Since(c.refreshed) < c.ttl {
+		return c.schema, c.output, nil
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, output, err := generateSchema(introspection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.schema, c.output, c.refreshed = schema, output, time.Now()
+	logger.Info("Schema refreshed", "definitions", len(schema.Definitions))
+	return schema, output, nil
+}
+
+func runServe() error {
+	if len(configEndpoints()) == 0 && viper.GetString("input") == "" {
+		return withExitCode(ExitUsage, fmt.Errorf("serve requires --endpoint or --input"))
+	}
+
+	cache := &schemaCache{ttl: viper.GetDuration("serve.ttl")}
+	server := &http.Server{
+		Addr:    viper.GetString("serve.listen"),
+		Handler: newServeMux(cache),
+	}
+
+	return listenUntilSignal(server, "Serving schema")
+}
+
+// listenUntilSignal runs server until SIGINT or SIGTERM, then shuts it down gracefully
+func listenUntilSignal(server *http.Server, message string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info(message, "listen", server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("error starting server: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error shutting down server: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func newServeMux(cache *schemaCache) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("GET /schema.json", func(w http.ResponseWriter, r *http.Request) {
+		_, output, err := cache.get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(output)
+	})
+
+	mux.HandleFunc("GET /schema.yaml", func(w http.ResponseWriter, r *http.Request) {
+		_, output, err := cache.get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		data, err := jsonToYAML(output)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(data)
+	})
+
+	mux.HandleFunc("GET /definitions/{name}", func(w http.ResponseWriter, r *http.Request) {
+		schema, _, err := cache.get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		definition, err := pkg.ExtractDefinition(schema, r.PathValue("name"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		pkg.RestoreDraftLayout(definition)
+		data, err := json.MarshalIndent(definition, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(data)
+	})
+
+	return mux
+}
+
+// jsonToYAML re-encodes a JSON document as YAML
+func jsonToYAML(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	return yaml.Marshal(doc)
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+var (
+	recordFile string
+	replayFile string
+)
+
+// sessionVersion is the version of the session file format written by --record. Readers reject
+// other versions, so any incompatible change to the format must bump it.
+const sessionVersion = 1
+
+// redactedValue replaces the values of credential headers in session files
+const redactedValue = "[redacted]"
+
+// session is an introspection exchange captured by --record and served by --replay
+type session struct {
+	Version    int             `json:"version"`
+	RecordedAt time.Time       `json:"recordedAt"`
+	Request    sessionRequest  `json:"request"`
+	Response   sessionResponse `json:"response"`
+}
+
+type sessionRequest struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	// QueryHash is the SHA-256 of the introspection query, prefixed with sha256:
+	QueryHash string              `json:"queryHash"`
+	Headers   map[string][]string `json:"headers,omitempty"`
+}
+
+type sessionResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    json.RawMessage     `json:"body"`
+}
+
+// queryHash returns the hash recorded for a query
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// sessionHeaders copies headers with the values of credential headers replaced
+func sessionHeaders(headers http.Header) map[string][]string {
+	copied := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if isSensitiveHeader(name) {
+			values = slices.Repeat([]string{redactedValue}, len(values))
+		} else {
+			values = slices.Clone(values)
+			for i := range values {
+				values[i] = redactSecrets(values[i])
+			}
+		}
+		copied[name] = values
+	}
+	return copied
+}
+
+// recordSession writes a successful introspection exchange to path
+func recordSession(path string, req *http.Request, resp *http.Response, body []byte) error {
+	s := session{
+		Version:    sessionVersion,
+		RecordedAt: time.Now().UTC(),
+		Request: sessionRequest{
+			Method:    req.Method,
+			Endpoint:  redactSecrets(req.URL.String()),
+			QueryHash: queryHash(introspectionQuery()),
+			Headers:   sessionHeaders(req.Header),
+		},
+		Response: sessionResponse{
+			Status:  resp.StatusCode,
+			Headers: sessionHeaders(resp.Header),
+			Body:    json.RawMessage(body),
+		},
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing session: %w", err)
+	}
+	logger.Info("Recorded introspection session", "path", path)
+	return nil
+}
+
+// replaySession serves the introspection recorded in path instead of fetching it. The recorded
+// request must have used the same introspection query, and when endpoints are configured, one of
+// them.
+func replaySession(path string, endpoints []string) (*pkg.IntrospectionQuery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("error reading session: %w", err))
+	}
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("%s: error parsing session: %w", path, err))
+	}
+	if s.Version != sessionVersion {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("%s: unsupported session version %d, expected %d", path, s.Version, sessionVersion))
+	}
+
+	if hash := queryHash(introspectionQuery()); s.Request.QueryHash != hash {
+		return nil, withExitCode(ExitMismatch, fmt.Errorf("%s: recorded with a different introspection query (%s, expected %s)", path, s.Request.QueryHash, hash))
+	}
+	if len(endpoints) > 0 {
+		redacted := make([]string, len(endpoints))
+		for i, endpoint := range endpoints {
+			redacted[i] = redactSecrets(endpoint)
+		}
+		if !slices.Contains(redacted, s.Request.Endpoint) {
+			return nil, withExitCode(ExitMismatch, fmt.Errorf("%s: recorded against %s, not %s", path, s.Request.Endpoint, strings.Join(redacted, ", ")))
+		}
+	}
+
+	logger.Info("Replaying introspection session", "path", path, "endpoint", s.Request.Endpoint, "recordedAt", s.RecordedAt)
+	servingEndpoint = s.Request.Endpoint
+	return decodeIntrospectionBody(bytes.NewReader(s.Response.Body))
+}
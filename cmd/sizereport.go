@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sizeReportFormat string
+	sizeReportTop    int
+	sizeReport       bool
+)
+
+var sizeReportCmd = &cobra.Command{
+	Use:   "size-report",
+	Short: "Break the size of the generated schema down by definition",
+	Long: `Generate the schema from --endpoint, --input or stdin with the current options, without
+writing it, and report where its bytes go: the largest definitions, each serialized on its
+own, and how much of the document is spent on descriptions, field argument wrappers, scalar
+titles, empty $schema keywords, examples, embedded SDL and subschemas that --dedupe would
+share. Each category is measured by serializing the document again without it, so the
+categories overlap and show what enabling the matching option would save.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSizeReport()
+	},
+}
+
+func init() {
+	sizeReportCmd.Flags().StringVarP(&sizeReportFormat, "format", "f", "text", "output format (text or json)")
+	sizeReportCmd.Flags().IntVar(&sizeReportTop, "top", 20, "number of definitions listed (0 lists all)")
+
+	rootCmd.Flags().BoolVar(&sizeReport, "size-report", false, "print the size-report tables to stderr after writing the schema")
+	bindFlag("size-report", rootCmd.Flags().Lookup("size-report"))
+
+	rootCmd.AddCommand(sizeReportCmd)
+}
+
+func runSizeReport() error {
+	if sizeReportFormat != "text" && sizeReportFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", sizeReportFormat))
+	}
+	if sizeReportTop < 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("--top must not be negative"))
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+	schema, _, err := generateSchema(introspection)
+	if err != nil {
+		return err
+	}
+	report, err := newSizeReport(schema, sizeReportTop)
+	if err != nil {
+		return err
+	}
+
+	if sizeReportFormat == "json" {
+		return printJSON(report)
+	}
+	fmt.Print(formatSizeReport(report))
+	return nil
+}
+
+// newSizeReport measures the schema, keeping the top largest definitions
+func newSizeReport(schema *pkg.JSONSchema6, top int) (pkg.SizeReport, error) {
+	report, err := pkg.NewSizeReport(schema)
+	if err != nil {
+		return pkg.SizeReport{}, withExitCode(ExitConversion, err)
+	}
+	if top > 0 && len(report.Definitions) > top {
+		report.Definitions = report.Definitions[:top]
+	}
+	return report, nil
+}
+
+// formatSizeReport renders the report as two tables, definitions then categories
+func formatSizeReport(report pkg.SizeReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Total: %s\n\n", formatBytes(report.TotalBytes))
+
+	width := len("Definition")
+	for _, definition := range report.Definitions {
+		width = max(width, len(definition.Name))
+	}
+	fmt.Fprintf(&b, "%-*s  %10s  %6s\n", width, "Definition", "Size", "Share")
+	for _, definition := range report.Definitions {
+		fmt.Fprintf(&b, "%-*s  %10s  %5.1f%%\n", width, definition.Name, formatBytes(definition.Bytes), definition.Percent)
+	}
+
+	width = len("Category")
+	for _, category := range report.Categories {
+		width = max(width, len(category.Name))
+	}
+	fmt.Fprintf(&b, "\n%-*s  %10s  %6s  %s\n", width, "Category", "Size", "Share", "Reduced by")
+	for _, category := range report.Categories {
+		option := category.Option
+		if option == "" {
+			option = "-"
+		}
+		fmt.Fprintf(&b, "%-*s  %10s  %5.1f%%  %s\n", width, category.Name, formatBytes(category.Bytes), category.Percent, option)
+	}
+	return b.String()
+}
+
+// formatBytes renders a size with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
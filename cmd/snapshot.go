@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// snapshotIndexFile lists the stored snapshots, oldest first
+	snapshotIndexFile = "index.json"
+	// snapshotLatestFile is a copy of the newest snapshot, so consumers need not read the index
+	snapshotLatestFile = "latest.json"
+	// snapshotHashLength is how many hex digits of the hash are used in file names and listings
+	snapshotHashLength = 12
+)
+
+var (
+	snapshotDir       string
+	snapshotRetention int
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Record the converted schema in a local history",
+	Long: `Convert the schema from --endpoint, --input or stdin and store it in --dir when its
+canonical form differs from the latest snapshot.
+
+Snapshots are named after their UTC timestamp and hash. The directory also holds
+latest.json, a copy of the newest snapshot, and index.json, which records the hash,
+timestamp and source of every snapshot. --retention keeps only the newest N
+snapshots; 0 keeps them all.
+
+Use "snapshot list" to print the history and "diff" or "compat" to compare two
+snapshots.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSnapshot()
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the snapshot history",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSnapshotList()
+	},
+}
+
+func init() {
+	snapshotCmd.PersistentFlags().StringVar(&snapshotDir, "dir", ".schema-history", "directory holding the snapshots")
+	snapshotCmd.Flags().IntVar(&snapshotRetention, "retention", 0, "number of snapshots to keep (0 keeps all)")
+
+	bindFlag("snapshot.dir", snapshotCmd.PersistentFlags().Lookup("dir"))
+	bindFlag("snapshot.retention", snapshotCmd.Flags().Lookup("retention"))
+
+	snapshotCmd.AddCommand(snapshotListCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+// snapshotEntry is a stored snapshot as recorded in the index
+type snapshotEntry struct {
+	File      string    `json:"file"`
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+}
+
+// snapshotIndex is the contents of index.json
+type snapshotIndex struct {
+	Snapshots []snapshotEntry `json:"snapshots"`
+}
+
+func runSnapshot() error {
+	dir := viper.GetString("snapshot.dir")
+	retention := viper.GetInt("snapshot.retention")
+	if retention < 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --retention: %d (must be 0 or more)", retention))
+	}
+
+	index, err := readSnapshotIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+	_, output, err := generateSchema(introspection)
+	if err != nil {
+		return err
+	}
+
+	canonical, err := pkg.CanonicalJSON(output)
+	if err != nil {
+		return withExitCode(ExitConversion, fmt.Errorf("error canonicalizing schema: %w", err))
+	}
+	sum := sha256.Sum256(canonical)
+	hash := hex.EncodeToString(sum[:])
+
+	if n := len(index.Snapshots); n > 0 && index.Snapshots[n-1].Hash == hash {
+		logger.Info("Schema unchanged since the latest snapshot", "file", index.Snapshots[n-1].File)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	entry := snapshotEntry{
+		File:      now.Format("20060102T150405Z") + "-" + hash[:snapshotHashLength] + ".json",
+		Hash:      hash,
+		Timestamp: now,
+		Source:    snapshotSource(),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error creating snapshot directory: %w", err))
+	}
+	if err := writeFileAtomic(filepath.Join(dir, entry.File), output, 0644); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error writing snapshot: %w", err))
+	}
+	if err := writeFileAtomic(filepath.Join(dir, snapshotLatestFile), output, 0644); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error writing %s: %w", snapshotLatestFile, err))
+	}
+
+	index.Snapshots = append(index.Snapshots, entry)
+	var expired []snapshotEntry
+	if retention > 0 && len(index.Snapshots) > retention {
+		expired = index.Snapshots[:len(index.Snapshots)-retention]
+		index.Snapshots = index.Snapshots[len(index.Snapshots)-retention:]
+	}
+	if err := writeSnapshotIndex(dir, index); err != nil {
+		return err
+	}
+
+	// Files are only removed once the index no longer refers to them
+	for _, old := range expired {
+		if err := os.Remove(filepath.Join(dir, old.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("Failed to remove expired snapshot", "file", old.File, "error", err)
+		}
+	}
+
+	logger.Info("Wrote snapshot", "file", entry.File, "expired", len(expired))
+	return nil
+}
+
+func runSnapshotList() error {
+	index, err := readSnapshotIndex(viper.GetString("snapshot.dir"))
+	if err != nil {
+		return err
+	}
+	for _, entry := range index.Snapshots {
+		fmt.Printf("%s  %s  %s  %s\n", entry.Timestamp.Format(time.RFC3339), entry.Hash[:snapshotHashLength], entry.File, entry.Source)
+	}
+	return nil
+}
+
+// snapshotSource describes where the schema was loaded from, for the index
+func snapshotSource() string {
+	if servingEndpoint != "" {
+		return redactSecrets(servingEndpoint)
+	}
+	if input := viper.GetString("input"); input != "" {
+		return input
+	}
+	return "stdin"
+}
+
+// readSnapshotIndex returns the index in dir, which is empty when no snapshot has been taken yet
+func readSnapshotIndex(dir string) (*snapshotIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &snapshotIndex{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot index: %w", err)
+	}
+
+	var index snapshotIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error parsing snapshot index %s: %w", filepath.Join(dir, snapshotIndexFile), err)
+	}
+	for _, entry := range index.Snapshots {
+		if len(entry.Hash) < snapshotHashLength {
+			return nil, fmt.Errorf("error parsing snapshot index: invalid hash %q for %s", entry.Hash, entry.File)
+		}
+	}
+	return &index, nil
+}
+
+func writeSnapshotIndex(dir string, index *snapshotIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling snapshot index: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, snapshotIndexFile), append(data, '\n'), 0644); err != nil {
+		return withExitCode(ExitOutput, fmt.Errorf("error writing snapshot index: %w", err))
+	}
+	return nil
+}
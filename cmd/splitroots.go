@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var splitRoots bool
+
+func init() {
+	rootCmd.Flags().BoolVar(&splitRoots, "split-roots", false, "write query.schema.json, mutation.schema.json and subscription.schema.json into the --output directory, each with only the definitions its root reaches")
+	bindFlag("split-roots", rootCmd.Flags().Lookup("split-roots"))
+}
+
+// writeSplitRoots writes a self-contained document per root operation type into dir, leaving
+// unchanged files untouched, or with --check compares them against the existing files
+func writeSplitRoots(dir string, schema *pkg.JSONSchema6) error {
+	if dir == "" || isObjectURL(dir) {
+		return withExitCode(ExitUsage, fmt.Errorf("--split-roots requires --output to be a local directory"))
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return withExitCode(ExitUsage, fmt.Errorf("--split-roots requires --output to be a directory, but %s is a file", dir))
+	}
+
+	documents, err := pkg.SplitRoots(schema)
+	if err != nil {
+		return withExitCode(ExitConversion, err)
+	}
+	operations := make([]string, 0, len(documents))
+	for operation := range documents {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	out := newDirOutput(dir)
+	for _, operation := range operations {
+		pkg.RestoreDraftLayout(documents[operation])
+		output, err := json.MarshalIndent(documents[operation], "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling %s schema: %w", operation, err)
+		}
+		name := operation + ".schema.json"
+		if viper.GetBool("check") {
+			if err := checkOutput(filepath.Join(dir, name), documents[operation], output); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := out.write(name, output); err != nil {
+			return err
+		}
+		logger.Debug("Split root", "operation", operation, "file", name, "definitions", len(documents[operation].Definitions))
+	}
+	if !viper.GetBool("check") {
+		logger.Info("Wrote root schemas", "dir", dir, "written", out.written, "unchanged", out.unchanged)
+	}
+	return nil
+}
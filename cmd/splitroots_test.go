@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+func TestSplitRootsFlag(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	dir := filepath.Join(t.TempDir(), "roots")
+
+	result := runCLI(t, "--no-config", "-i", input, "-o", dir, "--split-roots")
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+
+	tests := []struct {
+		file     string
+		property string
+		has      []string
+		hasNot   []string
+	}{
+		{"query.schema.json", "Query", []string{"User", "Address", "SearchResult", "Post"}, []string{"CreateUserInput", "Unused"}},
+		{"mutation.schema.json", "Mutation", []string{"CreateUserInput", "User", "Role"}, []string{"SearchResult", "Post", "Unused"}},
+		{"subscription.schema.json", "Subscription", []string{"User"}, []string{"CreateUserInput", "SearchResult", "Unused"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			schema := readSchema(t, filepath.Join(dir, tt.file))
+			if len(schema.Properties) != 1 || schema.Properties[tt.property] == nil {
+				t.Errorf("properties = %v, want only %s", schema.Properties, tt.property)
+			}
+			for _, name := range tt.has {
+				if schema.Definitions[name] == nil {
+					t.Errorf("missing definition %s", name)
+				}
+			}
+			for _, name := range tt.hasNot {
+				if schema.Definitions[name] != nil {
+					t.Errorf("unreachable definition %s was kept", name)
+				}
+			}
+			pkg.Walk(schema, func(node *pkg.JSONSchema6) {
+				if node.Ref == "" {
+					return
+				}
+				if _, err := pkg.ResolvePointer(schema, node.Ref); err != nil {
+					t.Errorf("%s does not resolve within %s: %v", node.Ref, tt.file, err)
+				}
+			})
+		})
+	}
+
+	// The written files are up to date, so --check passes
+	if result := runCLI(t, "--no-config", "-i", input, "-o", dir, "--split-roots", "--check"); result.err != nil {
+		t.Errorf("--check: %v\n%s", result.err, result.stderr)
+	}
+}
+
+func TestSplitRootsFlagErrors(t *testing.T) {
+	input := writeFile(t, "schema.graphql", fixtureSDL)
+	file := writeFile(t, "schema.json", "{}")
+
+	for _, args := range [][]string{
+		{"--split-roots"},
+		{"--split-roots", "-o", file},
+	} {
+		result := runCLI(t, append([]string{"--no-config", "-i", input}, args...)...)
+		if code := ExitCode(result.err); code != ExitUsage {
+			t.Errorf("%v: exit code %d (%v), want a usage error", args, code, result.err)
+		}
+	}
+	if data, err := os.ReadFile(file); err != nil || string(data) != "{}" {
+		t.Errorf("the existing file was modified: %s, %v", data, err)
+	}
+}
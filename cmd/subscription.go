@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+var subscriptionPayloadsDir string
+
+// subscriptionPayloadsCombinedFile is the combined payload document written by
+// --subscription-payloads. GraphQL names cannot contain '-', so it never collides with a field.
+const subscriptionPayloadsCombinedFile = "all-subscriptions.json"
+
+// writeSubscriptionPayloads writes the event payload schema of every subscription field to dir,
+// one file per field plus a combined document
+func writeSubscriptionPayloads(dir string, introspection *pkg.IntrospectionQuery) error {
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+	payloads, combined, err := pkg.SubscriptionPayloads(*introspection, opts)
+	if err != nil {
+		return withExitCode(ExitConversion, err)
+	}
+	if len(payloads) == 0 {
+		logger.Warn("No subscription payloads written", "reason", "the schema has no subscription fields")
+		return nil
+	}
+
+	files := make(map[string]*pkg.JSONSchema6, len(payloads)+1)
+	for field, payload := range payloads {
+		files[field+".json"] = payload
+	}
+	files[subscriptionPayloadsCombinedFile] = combined
+	for name, schema := range files {
+		output, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON Schema: %w", err)
+		}
+		if err := writeOutput(filepath.Join(dir, name), output); err != nil {
+			return err
+		}
+	}
+	logger.Info("Wrote subscription payload schemas", "dir", dir, "fields", len(payloads))
+	return nil
+}
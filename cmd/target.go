@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// Output targets for --target
+const (
+	targetJSONSchema       = "jsonschema"
+	targetBigQuery         = "bigquery"
+	targetCUE              = "cue"
+	targetResponseEnvelope = "response-envelope"
+	targetK8sConfigMap     = "k8s-configmap"
+	targetPydantic         = "pydantic"
+	targetOpenAPI          = "openapi"
+)
+
+var target string
+
+// runTargetExport writes or checks the output of a non-JSON Schema target
+func runTargetExport(name string, generate func(*pkg.IntrospectionQuery) ([]byte, error)) error {
+	if viper.GetBool("watch") {
+		return withExitCode(ExitUsage, fmt.Errorf("--watch is not supported with --target %s", name))
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+	metadataFile := viper.GetString("metadata-out")
+	var stopCounting func() map[string]int
+	if metadataFile != "" {
+		stopCounting = countWarnings()
+	}
+	output, err := generate(introspection)
+	var warnings map[string]int
+	if stopCounting != nil {
+		warnings = stopCounting()
+	}
+	if err != nil {
+		return err
+	}
+
+	outputFile := viper.GetString("output")
+	if viper.GetBool("check") {
+		return checkOutput(outputFile, nil, output)
+	}
+	if err := writeOutput(outputFile, output); err != nil {
+		return err
+	}
+	if metadataFile != "" {
+		return writeMetadata(metadataFile, introspection, nil, output, warnings)
+	}
+	return nil
+}
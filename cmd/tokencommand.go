@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	tokenCommand      string
+	tokenCommandShell bool
+)
+
+// errUnauthorized is returned by fetchIntrospection when the endpoint answers 401 Unauthorized and
+// --token-command can provide a fresh token
+var errUnauthorized = errors.New("unauthorized")
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&tokenCommand, "token-command", "", "run this command and send its output as the bearer token, e.g. 'gcloud auth print-identity-token'")
+	rootCmd.PersistentFlags().BoolVar(&tokenCommandShell, "token-command-shell", false, "run --token-command through the shell instead of splitting it on whitespace")
+	bindFlag("token-command", rootCmd.PersistentFlags().Lookup("token-command"))
+	bindFlag("token-command-shell", rootCmd.PersistentFlags().Lookup("token-command-shell"))
+}
+
+// applyTokenCommand runs --token-command and sets its output as the bearer token in headers,
+// replacing any configured Authorization header
+func applyTokenCommand(headers http.Header) error {
+	token, err := runTokenCommand(viper.GetString("token-command"), viper.GetBool("token-command-shell"))
+	if err != nil {
+		return err
+	}
+	credentialSecrets = append(credentialSecrets, token)
+	headers.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// runTokenCommand runs a credential helper and returns what it prints, without trailing whitespace.
+// Without shell the command line is split on whitespace and run directly, so quoting, pipes and
+// variables need shell. The helper's stderr is passed through in errors and the token is never logged.
+func runTokenCommand(command string, shell bool) (string, error) {
+	var args []string
+	switch {
+	case !shell:
+		args = strings.Fields(command)
+	case runtime.GOOS == "windows":
+		args = []string{"cmd", "/C", command}
+	default:
+		args = []string{"sh", "-c", command}
+	}
+	if len(args) == 0 {
+		return "", withExitCode(ExitUsage, fmt.Errorf("--token-command is empty"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(viper.GetInt("timeout"))*time.Second)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("token command failed: %w: %s", err, redactSecrets(message))
+		}
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+	token := strings.TrimRight(stdout.String(), " \t\r\n")
+	if token == "" {
+		return "", fmt.Errorf("token command printed no token")
+	}
+	if strings.ContainsAny(token, "\r\n") {
+		return "", fmt.Errorf("token command printed more than one line")
+	}
+	logger.Debug("Ran token command", "command", args[0], "duration", time.Since(start).Round(time.Millisecond))
+	return token, nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+var uiSchemaFile string
+
+// uiSchemaOptions reads the mapping rules from the ui-schema-rules config block, falling back to the defaults:
+//
+//	ui-schema-rules:
+//	  textarea-min-length: 120
+//	  enum-widget: select
+//	  scalar-widgets:
+//	    DateTime: datetime
+func uiSchemaOptions(introspection *pkg.IntrospectionQuery) pkg.UISchemaOptions {
+	opts := pkg.DefaultUISchemaOptions()
+	if viper.IsSet("ui-schema-rules.textarea-min-length") {
+		opts.TextareaMinLength = viper.GetInt("ui-schema-rules.textarea-min-length")
+	}
+	if viper.IsSet("ui-schema-rules.enum-widget") {
+		opts.EnumWidget = viper.GetString("ui-schema-rules.enum-widget")
+	}
+	// Viper lowercases config keys, so scalar names are matched against the schema case-insensitively
+	widgets := viper.GetStringMapString("ui-schema-rules.scalar-widgets")
+	for _, t := range introspection.Schema.Types {
+		if widget, ok := widgets[strings.ToLower(t.Name)]; ok && t.Kind == "SCALAR" {
+			opts.ScalarWidgets[t.Name] = widget
+		}
+	}
+	return opts
+}
+
+// writeUISchema generates the companion uiSchema for schema and writes it to path
+func writeUISchema(path string, introspection *pkg.IntrospectionQuery, schema *pkg.JSONSchema6) error {
+	uiSchema := pkg.GenerateUISchema(*introspection, schema, uiSchemaOptions(introspection))
+	output, err := json.MarshalIndent(uiSchema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling uiSchema: %w", err)
+	}
+	return writeOutput(path, output)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUISchemaFlag(t *testing.T) {
+	sdl := "scalar DateTime\n\ntype Query { events(since: DateTime, kind: Kind): [String] }\n\nenum Kind { A B }\n"
+	input := writeFile(t, "schema.graphql", sdl)
+	config := writeFile(t, "config.yaml", "ui-schema-rules:\n  enum-widget: radio\n  scalar-widgets:\n    DateTime: date\n")
+	dir := t.TempDir()
+	uiSchemaPath := filepath.Join(dir, "schema.uischema.json")
+
+	result := runCLI(t, "--config", config, "-i", input, "-o", filepath.Join(dir, "schema.json"), "--ui-schema", uiSchemaPath)
+	if result.err != nil {
+		t.Fatalf("convert: %v\n%s", result.err, result.stderr)
+	}
+
+	data, err := os.ReadFile(uiSchemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ui struct {
+		Query struct {
+			Events struct {
+				Arguments map[string]json.RawMessage `json:"arguments"`
+			} `json:"events"`
+		}
+	}
+	if err := json.Unmarshal(data, &ui); err != nil {
+		t.Fatalf("decoding uiSchema: %v\n%s", err, data)
+	}
+	widget := func(argument string) string {
+		var node struct {
+			Widget string `json:"ui:widget"`
+		}
+		json.Unmarshal(ui.Query.Events.Arguments[argument], &node)
+		return node.Widget
+	}
+	if got := widget("since"); got != "date" {
+		t.Errorf("DateTime widget from the config = %q, want date", got)
+	}
+	if got := widget("kind"); got != "radio" {
+		t.Errorf("enum widget from the config = %q, want radio", got)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	usagesType   string
+	usagesUnused bool
+	usagesFormat string
+)
+
+var usagesCmd = &cobra.Command{
+	Use:   "usages",
+	Short: "List everything that references a type",
+	Long: `Report where a type of the schema from --endpoint, --input or stdin is used before deleting
+or renaming it, without writing any output:
+
+  gql2jsonschema usages --type Address
+
+lists every field, argument and input field of that type, the unions it is a member of and the
+types implementing it, by GraphQL coordinate, followed by the JSON pointers of the $refs to it in
+the schema the current options generate. --unused instead lists the types nothing refers to,
+which are candidates for pruning; root types and built-in scalars are never listed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUsages()
+	},
+}
+
+func init() {
+	usagesCmd.Flags().StringVar(&usagesType, "type", "", "type to list the references to")
+	usagesCmd.Flags().BoolVar(&usagesUnused, "unused", false, "list the types with no references instead")
+	usagesCmd.Flags().StringVarP(&usagesFormat, "format", "f", "text", "output format (text or json)")
+
+	rootCmd.AddCommand(usagesCmd)
+}
+
+// typeUsages is the JSON output of usages --type
+type typeUsages struct {
+	Type       string              `json:"type"`
+	References []pkg.TypeReference `json:"references"`
+	Pointers   []string            `json:"pointers"`
+}
+
+func runUsages() error {
+	if (usagesType == "") == !usagesUnused {
+		return withExitCode(ExitUsage, fmt.Errorf("exactly one of --type and --unused is required"))
+	}
+	if usagesFormat != "text" && usagesFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", usagesFormat))
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return err
+	}
+
+	if usagesUnused {
+		unused := pkg.UnusedTypes(*introspection)
+		if usagesFormat == "json" {
+			return printJSON(unused)
+		}
+		if len(unused) == 0 {
+			fmt.Println("No unused types")
+		}
+		for _, name := range unused {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	references, err := pkg.TypeReferences(*introspection, usagesType)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+	schema, err := pkg.FromIntrospectionQuery(*introspection, opts)
+	if err != nil {
+		return withExitCode(ExitConversion, fmt.Errorf("error converting to JSON Schema: %w", err))
+	}
+	pkg.UseDefinitions(schema)
+	usages := typeUsages{
+		Type:       usagesType,
+		References: references,
+		Pointers:   pkg.RefPointers(schema, usagesType),
+	}
+
+	if usagesFormat == "json" {
+		return printJSON(usages)
+	}
+	fmt.Print(formatTypeUsages(usages))
+	return nil
+}
+
+// formatTypeUsages renders the references to a type, then the pointers to its definition
+func formatTypeUsages(usages typeUsages) string {
+	var b strings.Builder
+	if len(usages.References) == 0 {
+		fmt.Fprintf(&b, "%s is not referenced by any type\n", usages.Type)
+	} else {
+		fmt.Fprintf(&b, "References to %s (%d):\n", usages.Type, len(usages.References))
+		for _, reference := range usages.References {
+			if reference.Type != "" {
+				fmt.Fprintf(&b, "  %-15s %s: %s\n", reference.Kind, reference.Coordinate, reference.Type)
+			} else {
+				fmt.Fprintf(&b, "  %-15s %s\n", reference.Kind, reference.Coordinate)
+			}
+		}
+	}
+	if len(usages.Pointers) > 0 {
+		fmt.Fprintf(&b, "JSON Schema $refs (%d):\n", len(usages.Pointers))
+		for _, pointer := range usages.Pointers {
+			fmt.Fprintf(&b, "  %s\n", pointer)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	validateSchemaFile string
+	validateDefinition string
+	validatePointer    string
+	validateFormat     string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [payload files...]",
+	Short: "Validate JSON payloads against the generated JSON Schema",
+	Long: `Validate one or more JSON payloads against a JSON Schema generated by this tool.
+The schema is read from --schema, or generated on the fly from --endpoint or --input.
+Validation is rooted at --definition or --pointer. Payloads are read from the
+given files, or from stdin when no files are given.
+
+With --glob or --ndjson, payloads are validated in batch: they are streamed one at a
+time, only failures are printed, and a summary of the totals and the most frequent
+error paths follows. --report also writes the summary as JSON and --max-failures
+stops early. The same batch mode applies to payload files when --report or
+--max-failures is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runValidate(args)
+	},
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateSchemaFile, "schema", "s", "", "previously generated JSON Schema file")
+	validateCmd.Flags().StringVarP(&validateDefinition, "definition", "d", "", "definition name to validate against")
+	validateCmd.Flags().StringVar(&validatePointer, "pointer", "", "JSON pointer of the subschema to validate against")
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "f", "text", "output format (text or json)")
+	validateCmd.Flags().StringArrayVar(&validateGlobs, "glob", nil, "validate every payload file matching this pattern (repeatable)")
+	validateCmd.Flags().StringArrayVar(&validateNDJSON, "ndjson", nil, "validate every line of this newline-delimited JSON file as a payload (repeatable)")
+	validateCmd.Flags().StringVar(&validateReportFile, "report", "", "write the summary of a batch validation to this JSON file")
+	validateCmd.Flags().IntVar(&validateMaxFailures, "max-failures", 0, "stop a batch validation after this many failed payloads (0 validates all)")
+	validateCmd.Flags().IntVar(&validateTopPaths, "top-paths", 10, "number of most frequent error paths in the batch summary")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+// ValidationResult is the machine-readable validation result for a single payload
+type ValidationResult struct {
+	File   string                `json:"file"`
+	Valid  bool                  `json:"valid"`
+	Errors []pkg.ValidationError `json:"errors,omitempty"`
+}
+
+// loadSchema reads a generated schema from file, or converts the configured input on the fly
+func loadSchema(schemaFile string) (*pkg.JSONSchema6, error) {
+	if schemaFile != "" {
+		data, err := os.ReadFile(schemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading schema file: %w", err)
+		}
+
+		var schema pkg.JSONSchema6
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("error parsing schema file: %w", err)
+		}
+		pkg.UseDefinitions(&schema)
+		return &schema, nil
+	}
+
+	if len(configEndpoints()) == 0 && viper.GetString("input") == "" {
+		return nil, fmt.Errorf("no schema provided: use --schema, --endpoint or --input")
+	}
+
+	introspection, err := loadIntrospection()
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := pkg.FromIntrospectionQuery(*introspection, opts)
+	if err != nil {
+		return nil, withExitCode(ExitConversion, fmt.Errorf("error converting to JSON Schema: %w", err))
+	}
+	pkg.UseDefinitions(schema)
+	return schema, nil
+}
+
+// selectSchema returns the subschema selected by a definition name or JSON pointer
+func selectSchema(root *pkg.JSONSchema6, definition, pointer string) (*pkg.JSONSchema6, error) {
+	switch {
+	case definition != "" && pointer != "":
+		return nil, fmt.Errorf("--definition and --pointer are mutually exclusive")
+	case definition != "":
+		schema, ok := root.Definitions[definition]
+		if !ok {
+			return nil, fmt.Errorf("definition %s not found in schema", definition)
+		}
+		return schema, nil
+	case pointer != "":
+		return pkg.ResolvePointer(root, pointer)
+	}
+	return root, nil
+}
+
+func runValidate(files []string) error {
+	if validateFormat != "text" && validateFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", validateFormat))
+	}
+
+	root, err := loadSchema(validateSchemaFile)
+	if err != nil {
+		return err
+	}
+
+	schema, err := selectSchema(root, validateDefinition, validatePointer)
+	if err != nil {
+		return err
+	}
+
+	if len(validateGlobs) > 0 || len(validateNDJSON) > 0 || validateReportFile != "" || validateMaxFailures > 0 {
+		return runValidateBatch(root, schema, files)
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	results := make([]ValidationResult, 0, len(files))
+	failures := 0
+	for _, file := range files {
+		result, err := validateFile(root, schema, file)
+		if err != nil {
+			return err
+		}
+		if !result.Valid {
+			failures++
+		}
+		results = append(results, result)
+	}
+
+	if validateFormat == "json" {
+		output, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling validation results: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		for _, result := range results {
+			if result.Valid {
+				fmt.Printf("%s: valid\n", result.File)
+				continue
+			}
+			fmt.Printf("%s: invalid\n", result.File)
+			for _, validationErr := range result.Errors {
+				fmt.Printf("  %s\n", validationErr.Error())
+			}
+		}
+	}
+
+	if failures > 0 {
+		return withExitCode(ExitMismatch, fmt.Errorf("%d of %d payloads failed validation", failures, len(results)))
+	}
+	return nil
+}
+
+func validateFile(root, schema *pkg.JSONSchema6, file string) (ValidationResult, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		file = "<stdin>"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return ValidationResult{}, fmt.Errorf("error reading payload %s: %w", file, err)
+	}
+
+	instance, err := pkg.DecodeInstance(data)
+	if err != nil {
+		return ValidationResult{}, fmt.Errorf("error parsing payload %s: %w", file, err)
+	}
+
+	errs := pkg.Validate(root, schema, instance)
+	return ValidationResult{File: file, Valid: len(errs) == 0, Errors: errs}, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// closedSchema writes a 2020-12 document whose SearchResult union is closed with unevaluatedProperties
+func closedSchema(t *testing.T) string {
+	t.Helper()
+	introspection, err := pkg.IntrospectionFromSDL(fixtureSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := pkg.DefaultOptions()
+	opts.Draft = pkg.Draft202012
+	opts.FieldShape = pkg.FieldShapePlain
+	opts.ClosedComposition = true
+	schema, err := pkg.FromIntrospectionQuery(*introspection, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, "schema.json", string(data))
+}
+
+func TestValidateUnevaluatedProperties(t *testing.T) {
+	schema := closedSchema(t)
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"branch match", `{"id": "1", "title": "Hello"}`, ""},
+		{"extra property", `{"id": "1", "title": "Hello", "draft": true}`, `unexpected property "draft"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := writeFile(t, "payload.json", tt.payload)
+			result := runCLI(t, "--no-config", "validate", "-s", schema, "-d", "SearchResult", payload)
+			if tt.want == "" {
+				if result.err != nil {
+					t.Fatalf("expected the payload to be valid: %v\n%s", result.err, result.stdout)
+				}
+				return
+			}
+			if result.err == nil {
+				t.Fatalf("expected validation to fail:\n%s", result.stdout)
+			}
+			if !strings.Contains(result.stdout, tt.want) {
+				t.Errorf("expected the output to contain %q:\n%s", tt.want, result.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+var (
+	validateGlobs       []string
+	validateNDJSON      []string
+	validateReportFile  string
+	validateMaxFailures int
+	validateTopPaths    int
+)
+
+// maxNDJSONLine is the longest NDJSON line accepted, matching the response size limit
+const maxNDJSONLine = maxResponseSize
+
+// errMaxFailures stops a batch validation once --max-failures payloads have failed
+var errMaxFailures = errors.New("maximum number of failures reached")
+
+// ValidationReport summarises a batch validation
+type ValidationReport struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	// Stopped is set when --max-failures ended the run before every payload was validated
+	Stopped bool `json:"stopped,omitempty"`
+	// TopErrorPaths are the most frequent instance paths of errors, with list indices replaced by *
+	TopErrorPaths []ErrorPathCount `json:"topErrorPaths"`
+}
+
+// ErrorPathCount is the number of errors reported at an instance path
+type ErrorPathCount struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// batchValidator validates payloads one at a time, keeping only counts so that memory does not grow
+// with the number of payloads
+type batchValidator struct {
+	root, schema *pkg.JSONSchema6
+	report       ValidationReport
+	paths        map[string]int
+}
+
+// add validates one payload, printing it when it fails, and returns errMaxFailures once the limit
+// is reached
+func (b *batchValidator) add(name string, data []byte) error {
+	b.report.Total++
+	var errs []pkg.ValidationError
+	if instance, err := pkg.DecodeInstance(data); err != nil {
+		errs = []pkg.ValidationError{{Message: fmt.Sprintf("error parsing payload: %s", err)}}
+	} else {
+		errs = pkg.Validate(b.root, b.schema, instance)
+	}
+	if len(errs) == 0 {
+		b.report.Passed++
+		return nil
+	}
+
+	b.report.Failed++
+	if validateFormat == "text" {
+		fmt.Printf("%s: invalid\n", name)
+	}
+	for _, validationErr := range errs {
+		b.paths[errorPathPattern(validationErr.InstancePath)]++
+		if validateFormat == "text" {
+			fmt.Printf("  %s\n", validationErr.Error())
+		}
+	}
+	if validateMaxFailures > 0 && b.report.Failed >= validateMaxFailures {
+		return errMaxFailures
+	}
+	return nil
+}
+
+// errorPathPattern replaces the list indices of an instance path with *, so that errors at the same
+// place in different list items are counted together
+func errorPathPattern(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// addFile validates a payload file
+func (b *batchValidator) addFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading payload %s: %w", file, err)
+	}
+	return b.add(file, data)
+}
+
+// addNDJSON validates every non-blank line of a newline-delimited JSON file as a payload
+func (b *batchValidator) addNDJSON(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("error reading payloads: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		if err := b.add(fmt.Sprintf("%s:%d", file, line), scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading payloads %s: %w", file, err)
+	}
+	return nil
+}
+
+// runValidateBatch validates the payload files, --glob matches and --ndjson lines and prints a summary
+func runValidateBatch(root, schema *pkg.JSONSchema6, files []string) error {
+	for _, pattern := range validateGlobs {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("invalid --glob %q: %w", pattern, err))
+		}
+		if len(matches) == 0 {
+			logger.Warn("Pattern matches no payloads", "glob", pattern)
+		}
+		files = append(files, matches...)
+	}
+
+	b := &batchValidator{root: root, schema: schema, paths: make(map[string]int)}
+	err := func() error {
+		for _, file := range files {
+			if err := b.addFile(file); err != nil {
+				return err
+			}
+		}
+		for _, file := range validateNDJSON {
+			if err := b.addNDJSON(file); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if errors.Is(err, errMaxFailures) {
+		b.report.Stopped = true
+	} else if err != nil {
+		return err
+	}
+
+	b.report.TopErrorPaths = topErrorPaths(b.paths, validateTopPaths)
+	if err := writeValidationReport(b.report); err != nil {
+		return err
+	}
+
+	if b.report.Failed > 0 {
+		return withExitCode(ExitMismatch, fmt.Errorf("%d of %d payloads failed validation", b.report.Failed, b.report.Total))
+	}
+	return nil
+}
+
+// topErrorPaths returns the n most frequent error paths, most frequent first
+func topErrorPaths(paths map[string]int, n int) []ErrorPathCount {
+	counts := make([]ErrorPathCount, 0, len(paths))
+	for path, count := range paths {
+		counts = append(counts, ErrorPathCount{Path: path, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Path < counts[j].Path
+	})
+	if n >= 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// writeValidationReport prints the summary of a batch validation and writes it to --report
+func writeValidationReport(report ValidationReport) error {
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling validation report: %w", err)
+	}
+	if validateReportFile != "" {
+		if err := writeOutput(validateReportFile, output); err != nil {
+			return err
+		}
+	}
+
+	if validateFormat == "json" {
+		fmt.Println(string(output))
+		return nil
+	}
+	fmt.Printf("\n%d payloads: %d passed, %d failed\n", report.Total, report.Passed, report.Failed)
+	if report.Stopped {
+		fmt.Printf("Stopped after %d failures (--max-failures)\n", report.Failed)
+	}
+	if len(report.TopErrorPaths) > 0 {
+		fmt.Println("Most frequent error paths:")
+		for _, path := range report.TopErrorPaths {
+			fmt.Printf("  %6d  %s\n", path.Count, path.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	responseOperationFile string
+	responseOperationName string
+	responseSchemaSource  string
+	responseStrictErrors  bool
+	responseFormat        string
+	responseFragmentDir   string
+	responseVariablesFile string
+)
+
+var validateResponseCmd = &cobra.Command{
+	Use:   "validate-response [response files...]",
+	Short: "Validate captured GraphQL responses against an operation's response schema",
+	Long: `Build the response schema of the operation in --operation and validate captured
+GraphQL HTTP responses against it. The data envelope is unwrapped before validation;
+an errors array is tolerated unless --strict-errors is set.
+
+The GraphQL schema comes from --schema-source, which is either "endpoint" (uses
+--endpoint) or the path to an introspection result. It defaults to --endpoint or
+--input. Mismatches are reported with their JSON pointer and GraphQL field coordinate.
+Responses are read from the given files, or from stdin when no files are given.
+
+Fragments spread by the operation may be defined in the same document or in the
+.graphql files of --fragment-dir. Fields that @skip or @include guard with a variable
+are optional unless --variables gives the variable values of the execution.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runValidateResponse(args)
+	},
+}
+
+func init() {
+	validateResponseCmd.Flags().StringVar(&responseOperationFile, "operation", "", "GraphQL document containing the operation")
+	validateResponseCmd.Flags().StringVar(&responseOperationName, "operation-name", "", "operation to use when the document contains several")
+	validateResponseCmd.Flags().StringVar(&responseSchemaSource, "schema-source", "", "where to read the GraphQL schema from: endpoint or an introspection file")
+	validateResponseCmd.Flags().BoolVar(&responseStrictErrors, "strict-errors", false, "treat a non-empty errors array as a failure")
+	validateResponseCmd.Flags().StringVar(&responseFragmentDir, "fragment-dir", "", "directory of .graphql files defining fragments the operation spreads")
+	validateResponseCmd.Flags().StringVar(&responseVariablesFile, "variables", "", "JSON file with the operation's variable values, for an exact schema under @skip and @include")
+	validateResponseCmd.Flags().StringVarP(&responseFormat, "format", "f", "text", "output format (text or json)")
+	validateResponseCmd.MarkFlagRequired("operation")
+
+	rootCmd.AddCommand(validateResponseCmd)
+}
+
+// ResponseValidationError is a validation error located by GraphQL field coordinate as well as JSON pointer
+type ResponseValidationError struct {
+	pkg.ValidationError
+	Coordinate string `json:"coordinate,omitempty"`
+}
+
+func (e ResponseValidationError) Error() string {
+	if e.Coordinate == "" {
+		return e.ValidationError.Error()
+	}
+	return fmt.Sprintf("%s (%s): %s", e.InstancePath, e.Coordinate, e.Message)
+}
+
+// ResponseValidationResult is the machine-readable validation result for a single response
+type ResponseValidationResult struct {
+	File   string                    `json:"file"`
+	Valid  bool                      `json:"valid"`
+	Errors []ResponseValidationError `json:"errors,omitempty"`
+}
+
+// graphQLResponse is the GraphQL HTTP response envelope
+type graphQLResponse struct {
+	Data   json.RawMessage   `json:"data"`
+	Errors []json.RawMessage `json:"errors"`
+}
+
+// loadResponseIntrospection resolves the introspection result named by --schema-source
+func loadResponseIntrospection(source string) (*pkg.IntrospectionQuery, error) {
+	if source == "" || source == "endpoint" {
+		return loadIntrospection()
+	}
+	return loadIntrospectionFile(source)
+}
+
+// loadFragmentDir adds the fragments defined in the .graphql files of dir to doc. The operation
+// file itself is skipped so it can live in dir; a fragment defined twice is an error.
+func loadFragmentDir(doc *pkg.Document, dir, operationFile string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no .graphql files in fragment directory %s", dir)
+	}
+	sort.Strings(paths)
+
+	operationPath, _ := filepath.Abs(operationFile)
+	defined := make(map[string]string, len(doc.Fragments))
+	for _, fragment := range doc.Fragments {
+		defined[fragment.Name] = operationFile
+	}
+	for _, path := range paths {
+		if abs, _ := filepath.Abs(path); abs == operationPath {
+			continue
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading fragment file: %w", err)
+		}
+		fragments, err := pkg.ParseDocument(string(source))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, fragment := range fragments.Fragments {
+			if previous, ok := defined[fragment.Name]; ok {
+				return fmt.Errorf("%s:%s: fragment %s is already defined in %s", path, fragment.Position, fragment.Name, previous)
+			}
+			defined[fragment.Name] = path
+			doc.Fragments = append(doc.Fragments, fragment)
+		}
+	}
+	return nil
+}
+
+func runValidateResponse(files []string) error {
+	if responseFormat != "text" && responseFormat != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid format: %s (must be 'text' or 'json')", responseFormat))
+	}
+
+	source, err := os.ReadFile(responseOperationFile)
+	if err != nil {
+		return fmt.Errorf("error reading operation file: %w", err)
+	}
+	doc, err := pkg.ParseDocument(string(source))
+	if err != nil {
+		return withExitCode(ExitUsage, fmt.Errorf("%s: %w", responseOperationFile, err))
+	}
+	if responseFragmentDir != "" {
+		if err := loadFragmentDir(doc, responseFragmentDir, responseOperationFile); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+	}
+
+	introspection, err := loadResponseIntrospection(responseSchemaSource)
+	if err != nil {
+		return err
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+
+	var variables map[string]interface{}
+	if responseVariablesFile != "" {
+		data, err := os.ReadFile(responseVariablesFile)
+		if err != nil {
+			return fmt.Errorf("error reading variables file: %w", err)
+		}
+		if err := json.Unmarshal(data, &variables); err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("%s: variables must be a JSON object: %w", responseVariablesFile, err))
+		}
+		if variables == nil {
+			variables = map[string]interface{}{}
+		}
+	}
+
+	responseSchema, err := pkg.OperationResponseSchemaForVariables(*introspection, doc, responseOperationName, variables, opts)
+	if err != nil {
+		return withExitCode(ExitConversion, fmt.Errorf("%s: %w", responseOperationFile, err))
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	results := make([]ResponseValidationResult, 0, len(files))
+	failures := 0
+	for _, file := range files {
+		result, err := validateResponseFile(responseSchema, file)
+		if err != nil {
+			return err
+		}
+		if !result.Valid {
+			failures++
+		}
+		results = append(results, result)
+	}
+
+	if responseFormat == "json" {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range results {
+			if result.Valid {
+				fmt.Printf("%s: valid\n", result.File)
+				continue
+			}
+			fmt.Printf("%s: invalid\n", result.File)
+			for _, validationErr := range result.Errors {
+				fmt.Printf("  %s\n", validationErr.Error())
+			}
+		}
+	}
+
+	if failures > 0 {
+		return withExitCode(ExitMismatch, fmt.Errorf("%d of %d responses failed validation", failures, len(results)))
+	}
+	return nil
+}
+
+func validateResponseFile(responseSchema *pkg.ResponseSchema, file string) (ResponseValidationResult, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		file = "<stdin>"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return ResponseValidationResult{}, fmt.Errorf("error reading response %s: %w", file, err)
+	}
+
+	errs, err := checkResponse(responseSchema, data, responseStrictErrors)
+	if err != nil {
+		return ResponseValidationResult{}, fmt.Errorf("error parsing response %s: %w", file, err)
+	}
+	return ResponseValidationResult{File: file, Valid: len(errs) == 0, Errors: errs}, nil
+}
+
+// checkResponse validates a GraphQL HTTP response body against the operation's response schema.
+// With strictErrors, a non-empty errors array is a failure.
+func checkResponse(responseSchema *pkg.ResponseSchema, data []byte, strictErrors bool) ([]ResponseValidationError, error) {
+	var response graphQLResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	errs := make([]ResponseValidationError, 0)
+	fail := func(path, message string) {
+		errs = append(errs, ResponseValidationError{ValidationError: pkg.ValidationError{InstancePath: path, Message: message}})
+	}
+
+	if len(response.Errors) > 0 && strictErrors {
+		fail("/errors", fmt.Sprintf("response has %d error(s)", len(response.Errors)))
+	}
+
+	switch {
+	case len(response.Data) == 0 && len(response.Errors) == 0:
+		fail("", "response has neither data nor errors")
+	case len(response.Data) == 0 || string(response.Data) == "null":
+		// A null data entry is only valid alongside errors
+		if len(response.Errors) == 0 {
+			fail("/data", "data is null but the response has no errors")
+		}
+	default:
+		instance, err := pkg.DecodeInstance(response.Data)
+		if err != nil {
+			return nil, err
+		}
+		for _, validationErr := range pkg.Validate(responseSchema.Schema, responseSchema.Schema, instance) {
+			coordinate := responseSchema.Coordinate(validationErr.InstancePath)
+			validationErr.InstancePath = "/data" + validationErr.InstancePath
+			errs = append(errs, ResponseValidationError{ValidationError: validationErr, Coordinate: coordinate})
+		}
+	}
+	return errs, nil
+}
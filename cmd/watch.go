@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/spf13/viper"
+)
+
+// maxWatchBackoff caps the retry delay when the endpoint is unavailable
+const maxWatchBackoff = 5 * time.Minute
+
+// watchDebounce groups bursts of file events written by editors into one regeneration
+const watchDebounce = 200 * time.Millisecond
+
+// schemaWatcher regenerates the output file whenever the canonical schema changes
+type schemaWatcher struct {
+	outputFile    string
+	lastOutput    []byte
+	lastCanonical []byte
+	lastChange    *time.Time
+	etag          string
+}
+
+func runWatch() error {
+	outputFile := viper.GetString("output")
+	if outputFile == "" {
+		return fmt.Errorf("--watch requires --output")
+	}
+	if viper.GetBool("check") {
+		return fmt.Errorf("--watch and --check are mutually exclusive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := checkClobber(outputFile); err != nil {
+		return err
+	}
+
+	w := &schemaWatcher{outputFile: outputFile}
+	if existing, err := readOutput(outputFile); err == nil {
+		w.lastOutput = existing
+		w.lastCanonical, _ = pkg.CanonicalJSON(existing)
+	}
+
+	if endpoints := configEndpoints(); len(endpoints) > 0 {
+		return w.pollEndpoints(ctx, endpoints, viper.GetDuration("interval"))
+	}
+	if inputFile := viper.GetString("input"); inputFile != "" {
+		if isObjectURL(inputFile) {
+			return w.pollInput(ctx, viper.GetDuration("interval"))
+		}
+		return w.watchFile(ctx, inputFile)
+	}
+	return fmt.Errorf("--watch requires --endpoint or --input")
+}
+
+// regenerate converts the introspection result and rewrites the output file if the canonical form
+// changed, then runs the change hooks. Hook failures are returned only with --on-change-strict.
+func (w *schemaWatcher) regenerate(ctx context.Context, introspection *pkg.IntrospectionQuery) (bool, error) {
+	metadataFile := viper.GetString("metadata-out")
+	var stopCounting func() map[string]int
+	if metadataFile != "" {
+		stopCounting = countWarnings()
+	}
+	schema, output, err := generateSchema(introspection)
+	var warnings map[string]int
+	if stopCounting != nil {
+		warnings = stopCounting()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	canonical, err := pkg.CanonicalJSON(output)
+	if err != nil {
+		return false, err
+	}
+	if w.lastCanonical != nil && bytes.Equal(canonical, w.lastCanonical) {
+		return false, nil
+	}
+
+	if err := replaceOutput(w.outputFile, output); err != nil {
+		return false, err
+	}
+	if metadataFile != "" {
+		if err := writeMetadata(metadataFile, introspection, schema, output, warnings); err != nil {
+			return false, err
+		}
+	}
+
+	var change schemaChange
+	if changeHooksEnabled() {
+		change = newSchemaChange(w.outputFile, w.lastOutput, w.lastCanonical, output, canonical)
+		change.PreviousChangedAt = w.lastChange
+		w.lastChange = &change.ChangedAt
+	}
+	w.lastOutput, w.lastCanonical = output, canonical
+
+	if changeHooksEnabled() {
+		if err := runChangeHooks(ctx, change); err != nil {
+			if viper.GetBool("on-change.strict") {
+				return true, err
+			}
+			logger.Error("Change hook failed", "error", err)
+		}
+	}
+	return true, nil
+}
+
+func (w *schemaWatcher) report(changed bool, err error) {
+	switch {
+	case err != nil:
+		logger.Error("Schema check failed", "error", err)
+	case changed:
+		logger.Info("Schema check", "status", "changed", "output", w.outputFile)
+	default:
+		logger.Info("Schema check", "status", "unchanged")
+	}
+}
+
+// pollEndpoints re-fetches the introspection every interval, failing over between the endpoints and
+// backing off while all of them are failing
+func (w *schemaWatcher) pollEndpoints(ctx context.Context, endpoints []string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid --interval: %s", interval)
+	}
+
+	headers, err := requestHeaders()
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for {
+		wait := interval
+		introspection, etag, _, err := fetchIntrospectionFailover(endpoints, headers, w.etag)
+		switch {
+		case errors.Is(err, errNotModified):
+			failures = 0
+			logger.Info("Schema check", "status", "unchanged", "reason", "not modified")
+		case err != nil:
+			failures++
+			wait = backoffDelay(interval, failures)
+			logger.Error("Schema check failed", "error", err, "retryIn", wait)
+		default:
+			failures = 0
+			w.etag = etag
+			changed, err := w.regenerate(ctx, introspection)
+			if errors.Is(err, errChangeHook) {
+				w.report(changed, nil)
+				return err
+			}
+			w.report(changed, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopping watch")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// pollInput re-reads an s3:// or gs:// input every interval, since object storage cannot be
+// watched for changes, backing off while it cannot be read
+func (w *schemaWatcher) pollInput(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid --interval: %s", interval)
+	}
+
+	failures := 0
+	for {
+		wait := interval
+		introspection, err := loadIntrospection()
+		if err != nil {
+			failures++
+			wait = backoffDelay(interval, failures)
+			logger.Error("Schema check failed", "error", err, "retryIn", wait)
+		} else {
+			failures = 0
+			changed, err := w.regenerate(ctx, introspection)
+			if errors.Is(err, errChangeHook) {
+				w.report(changed, nil)
+				return err
+			}
+			w.report(changed, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopping watch")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoffDelay doubles the interval for each consecutive failure, up to maxWatchBackoff
+func backoffDelay(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 1; i < failures && delay < maxWatchBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxWatchBackoff {
+		delay = maxWatchBackoff
+	}
+	return delay
+}
+
+// watchFile regenerates whenever the input file changes on disk
+func (w *schemaWatcher) watchFile(ctx context.Context, inputFile string) error {
+	path, err := filepath.Abs(inputFile)
+	if err != nil {
+		return fmt.Errorf("error resolving input file: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directory so files replaced by rename are still picked up
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("error watching %s: %w", inputFile, err)
+	}
+
+	regenerate := func() error {
+		introspection, err := loadIntrospection()
+		if err != nil {
+			w.report(false, err)
+			return nil
+		}
+		changed, err := w.regenerate(ctx, introspection)
+		if errors.Is(err, errChangeHook) {
+			w.report(changed, nil)
+			return err
+		}
+		w.report(changed, err)
+		return nil
+	}
+	if err := regenerate(); err != nil {
+		return err
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopping watch")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				debounce = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error("File watcher failed", "error", err)
+		case <-debounce:
+			debounce = nil
+			if err := regenerate(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsHandshakeError is returned when the server refuses to upgrade the connection
+type wsHandshakeError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *wsHandshakeError) Error() string {
+	return fmt.Sprintf("WebSocket handshake failed: %s", e.Status)
+}
+
+// isWebSocketEndpoint reports whether an endpoint is reached over WebSocket rather than HTTP
+func isWebSocketEndpoint(endpoint string) bool {
+	lower := strings.ToLower(endpoint)
+	return strings.HasPrefix(lower, "ws://") || strings.HasPrefix(lower, "wss://")
+}
+
+// dialWebSocket opens a WebSocket connection to a ws:// or wss:// endpoint, sending headers with
+// the upgrade request and asking for subprotocol. config holds the TLS settings of wss:// endpoints
+// and may be nil. deadline bounds the whole exchange, and messages are limited to maxResponseSize.
+func dialWebSocket(endpoint string, headers http.Header, subprotocol string, config *tls.Config, deadline time.Time) (*websocket.Conn, error) {
+	dialer := &websocket.Dialer{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: config,
+		Subprotocols:    []string{subprotocol},
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	conn, resp, err := dialer.DialContext(ctx, endpoint, headers)
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			return nil, &wsHandshakeError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+		return nil, err
+	}
+	if conn.Subprotocol() != subprotocol {
+		conn.Close()
+		return nil, fmt.Errorf("WebSocket handshake failed: server does not support the %s protocol", subprotocol)
+	}
+	conn.SetReadLimit(maxResponseSize)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// closeWebSocket sends a normal closure and closes the connection
+func closeWebSocket(conn *websocket.Conn) error {
+	err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return errors.Join(err, conn.Close())
+}
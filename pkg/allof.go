@@ -0,0 +1,286 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MergeAllOf returns a copy of schema in which every allOf is collapsed into the schema holding
+// it, for consumers that cannot handle allOf. Local $refs among the branches, and on the schema
+// itself, are resolved and their targets merged in. Merging unions properties and required,
+// intersects types and enums, takes the tighter minimum, maximum and minItems, merges same-named
+// properties and items recursively, keeps the first title, description, default and examples, and
+// closes the result if any part sets additionalProperties to false. Parts whose types, enums or
+// bounds do not overlap, or several parts with different anyOf or oneOf, are errors located by
+// JSON pointer.
+func MergeAllOf(schema *JSONSchema6) (*JSONSchema6, error) {
+	root := cloneSchema(schema)
+	m := &allOfMerger{
+		root:   root,
+		done:   make(map[*JSONSchema6]bool),
+		active: make(map[*JSONSchema6]bool),
+	}
+	if err := m.flatten(root, "#"); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+type allOfMerger struct {
+	root   *JSONSchema6
+	done   map[*JSONSchema6]bool
+	active map[*JSONSchema6]bool
+}
+
+// flatten collapses the allOf of schema and of every schema nested in it
+func (m *allOfMerger) flatten(schema *JSONSchema6, pointer string) error {
+	if schema == nil || m.done[schema] {
+		return nil
+	}
+	if m.active[schema] {
+		return fmt.Errorf("%s: cannot flatten allOf through a recursive reference", pointer)
+	}
+	m.active[schema] = true
+	defer delete(m.active, schema)
+
+	for _, name := range sortedKeys(schema.Definitions) {
+		if err := m.flatten(schema.Definitions[name], pointer+"/definitions/"+escapePointerSegment(name)); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		if err := m.flatten(schema.Properties[name], pointer+"/properties/"+escapePointerSegment(name)); err != nil {
+			return err
+		}
+	}
+	if err := m.flatten(schema.Items, pointer+"/items"); err != nil {
+		return err
+	}
+	for _, combinator := range []struct {
+		keyword  string
+		branches []*JSONSchema6
+	}{{"anyOf", schema.AnyOf}, {"oneOf", schema.OneOf}, {"allOf", schema.AllOf}} {
+		for i, branch := range combinator.branches {
+			if err := m.flatten(branch, fmt.Sprintf("%s/%s/%d", pointer, combinator.keyword, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(schema.AllOf) > 0 {
+		branches := schema.AllOf
+		schema.AllOf = nil
+		if schema.Ref != "" {
+			target, err := m.resolve(schema.Ref, pointer)
+			if err != nil {
+				return err
+			}
+			schema.Ref = ""
+			if err := m.merge(schema, target, pointer); err != nil {
+				return err
+			}
+		}
+		for i, branch := range branches {
+			part, err := m.deref(branch, fmt.Sprintf("%s/allOf/%d", pointer, i))
+			if err != nil {
+				return err
+			}
+			if err := m.merge(schema, part, pointer); err != nil {
+				return err
+			}
+		}
+	}
+
+	m.done[schema] = true
+	return nil
+}
+
+// resolve returns the flattened target of a local $ref
+func (m *allOfMerger) resolve(ref, pointer string) (*JSONSchema6, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("%s: cannot merge non-local $ref %s", pointer, ref)
+	}
+	target, err := m.root.GetByPointer(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+	if err := m.flatten(target, ref); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// deref returns the schema with its $ref replaced by the referenced schema, merged with any siblings
+func (m *allOfMerger) deref(schema *JSONSchema6, pointer string) (*JSONSchema6, error) {
+	if schema.Ref == "" {
+		return schema, nil
+	}
+	target, err := m.resolve(schema.Ref, pointer)
+	if err != nil {
+		return nil, err
+	}
+	siblings := *schema
+	siblings.Ref = ""
+	result := cloneSchema(target)
+	if err := m.merge(result, &siblings, pointer); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// merge merges src into dst so that dst only accepts values both accepted
+func (m *allOfMerger) merge(dst, src *JSONSchema6, pointer string) error {
+	if dst.Type == nil {
+		dst.Type = src.Type
+	} else if src.Type != nil {
+		types := intersectStrings(schemaTypes(dst.Type), schemaTypes(src.Type))
+		switch len(types) {
+		case 0:
+			return fmt.Errorf("%s: conflicting types %s and %s", pointer,
+				strings.Join(schemaTypes(dst.Type), ", "), strings.Join(schemaTypes(src.Type), ", "))
+		case 1:
+			dst.Type = types[0]
+		default:
+			dst.Type = types
+		}
+	}
+
+	if len(dst.Enum) == 0 {
+		dst.Enum = append([]string(nil), src.Enum...)
+		dst.EnumDescriptions = append([]string(nil), src.EnumDescriptions...)
+	} else if len(src.Enum) > 0 {
+		dst.Enum = intersectStrings(dst.Enum, src.Enum)
+		if len(dst.Enum) == 0 {
+			return fmt.Errorf("%s: enums have no value in common", pointer)
+		}
+		// The descriptions no longer line up with the intersected values
+		dst.EnumDescriptions = nil
+	}
+
+	if src.Minimum != nil && (dst.Minimum == nil || *src.Minimum > *dst.Minimum) {
+		dst.Minimum = src.Minimum
+	}
+	if src.Maximum != nil && (dst.Maximum == nil || *src.Maximum < *dst.Maximum) {
+		dst.Maximum = src.Maximum
+	}
+	if dst.Minimum != nil && dst.Maximum != nil && *dst.Minimum > *dst.Maximum {
+		return fmt.Errorf("%s: minimum %v exceeds maximum %v", pointer, *dst.Minimum, *dst.Maximum)
+	}
+	if src.MinItems != nil && (dst.MinItems == nil || *src.MinItems > *dst.MinItems) {
+		dst.MinItems = src.MinItems
+	}
+
+	if dst.Format == "" {
+		dst.Format = src.Format
+	}
+	if dst.Pattern == "" {
+		dst.Pattern = src.Pattern
+	} else if src.Pattern != "" && src.Pattern != dst.Pattern {
+		return fmt.Errorf("%s: conflicting patterns %s and %s", pointer, dst.Pattern, src.Pattern)
+	}
+
+	for _, name := range sortedKeys(src.Properties) {
+		property := src.Properties[name]
+		if dst.Properties == nil {
+			dst.Properties = make(map[string]*JSONSchema6, len(src.Properties))
+		}
+		existing, ok := dst.Properties[name]
+		if !ok {
+			dst.Properties[name] = cloneSchema(property)
+			continue
+		}
+		merged, err := m.mergeSubschemas(existing, property, pointer+"/properties/"+escapePointerSegment(name))
+		if err != nil {
+			return err
+		}
+		dst.Properties[name] = merged
+	}
+
+	for _, name := range src.Required {
+		if !containsString(dst.Required, name) {
+			dst.Required = append(dst.Required, name)
+		}
+	}
+
+	if dst.Items == nil {
+		dst.Items = cloneSchema(src.Items)
+	} else if src.Items != nil {
+		merged, err := m.mergeSubschemas(dst.Items, src.Items, pointer+"/items")
+		if err != nil {
+			return err
+		}
+		dst.Items = merged
+	}
+
+	for _, combinator := range []struct {
+		keyword  string
+		dst, src *[]*JSONSchema6
+	}{{"anyOf", &dst.AnyOf, &src.AnyOf}, {"oneOf", &dst.OneOf, &src.OneOf}} {
+		switch {
+		case len(*combinator.src) == 0:
+		case len(*combinator.dst) == 0:
+			*combinator.dst = cloneSchemas(*combinator.src)
+		case !reflect.DeepEqual(*combinator.dst, *combinator.src):
+			return fmt.Errorf("%s: cannot merge %s from several parts", pointer, combinator.keyword)
+		}
+	}
+
+	for name, definition := range src.Definitions {
+		if _, ok := dst.Definitions[name]; !ok {
+			if dst.Definitions == nil {
+				dst.Definitions = make(map[string]*JSONSchema6)
+			}
+			dst.Definitions[name] = cloneSchema(definition)
+		}
+	}
+
+	if dst.Title == "" {
+		dst.Title = src.Title
+	}
+	if dst.Description == "" {
+		dst.Description = src.Description
+	}
+	if dst.Default == nil {
+		dst.Default = src.Default
+	}
+	if dst.Examples == nil {
+		dst.Examples = src.Examples
+	}
+	if src.AdditionalProperties != nil && !*src.AdditionalProperties {
+		dst.AdditionalProperties = src.AdditionalProperties
+	}
+	return nil
+}
+
+// mergeSubschemas merges two schemas for the same property or items, resolving their $refs unless
+// they are identical
+func (m *allOfMerger) mergeSubschemas(a, b *JSONSchema6, pointer string) (*JSONSchema6, error) {
+	if reflect.DeepEqual(a, b) {
+		return a, nil
+	}
+	left, err := m.deref(a, pointer)
+	if err != nil {
+		return nil, err
+	}
+	right, err := m.deref(b, pointer)
+	if err != nil {
+		return nil, err
+	}
+	merged := cloneSchema(left)
+	if err := m.merge(merged, right, pointer); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// intersectStrings returns the values of a that are also in b, in the order of a
+func intersectStrings(a, b []string) []string {
+	result := make([]string, 0)
+	for _, value := range a {
+		if containsString(b, value) && !containsString(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
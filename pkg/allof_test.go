@@ -0,0 +1,206 @@
+package pkg_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
+
+func TestMergeAllOf(t *testing.T) {
+	closed := false
+	tests := []struct {
+		name  string
+		parts []*pkg.JSONSchema6
+		check func(t *testing.T, merged *pkg.JSONSchema6)
+	}{
+		{
+			name: "properties and required are unioned",
+			parts: []*pkg.JSONSchema6{
+				{Type: "object", Properties: map[string]*pkg.JSONSchema6{"a": {Type: "string"}}, Required: []string{"a"}},
+				{Properties: map[string]*pkg.JSONSchema6{"b": {Type: "integer"}}, Required: []string{"b", "a"}},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				if got := sortedNames(m.Properties); !reflect.DeepEqual(got, []string{"a", "b"}) {
+					t.Errorf("properties = %v", got)
+				}
+				if !reflect.DeepEqual(m.Required, []string{"a", "b"}) {
+					t.Errorf("required = %v", m.Required)
+				}
+			},
+		},
+		{
+			name: "same-named properties are merged recursively",
+			parts: []*pkg.JSONSchema6{
+				{Properties: map[string]*pkg.JSONSchema6{"a": {Type: []string{"string", "null"}, Description: "first"}}},
+				{Properties: map[string]*pkg.JSONSchema6{"a": {Type: "string", Description: "second", Format: "email"}}},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				a := m.Properties["a"]
+				if a.Type != "string" || a.Format != "email" || a.Description != "first" {
+					t.Errorf("merged property = type %v, format %q, description %q", a.Type, a.Format, a.Description)
+				}
+			},
+		},
+		{
+			name: "enums are intersected",
+			parts: []*pkg.JSONSchema6{
+				{Type: "string", Enum: []string{"A", "B", "C"}, EnumDescriptions: []string{"a", "b", "c"}},
+				{Enum: []string{"C", "B", "D"}},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				if !reflect.DeepEqual(m.Enum, []string{"B", "C"}) || m.EnumDescriptions != nil {
+					t.Errorf("enum = %v, descriptions %v", m.Enum, m.EnumDescriptions)
+				}
+			},
+		},
+		{
+			name: "the tighter bounds win",
+			parts: []*pkg.JSONSchema6{
+				{Type: "integer", Minimum: float(0), Maximum: float(100)},
+				{Minimum: float(10), Maximum: float(1000)},
+				{Type: "integer", Maximum: float(50)},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				if *m.Minimum != 10 || *m.Maximum != 50 {
+					t.Errorf("bounds = [%v, %v], want [10, 50]", *m.Minimum, *m.Maximum)
+				}
+			},
+		},
+		{
+			name: "the larger minItems wins",
+			parts: []*pkg.JSONSchema6{
+				{Type: "array", MinItems: integer(1), Items: &pkg.JSONSchema6{Type: "string"}},
+				{MinItems: integer(3)},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				if *m.MinItems != 3 || m.Items.Type != "string" {
+					t.Errorf("minItems = %d, items %v", *m.MinItems, m.Items.Type)
+				}
+			},
+		},
+		{
+			name: "local refs are resolved and closing wins",
+			parts: []*pkg.JSONSchema6{
+				{Ref: "#/definitions/Named"},
+				{Type: "object", Properties: map[string]*pkg.JSONSchema6{"id": {Type: "string"}}, AdditionalProperties: &closed},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				if m.Ref != "" || m.Properties["name"] == nil || m.Properties["id"] == nil {
+					t.Errorf("the referenced definition was not merged: %v", sortedNames(m.Properties))
+				}
+				if m.AdditionalProperties == nil || *m.AdditionalProperties {
+					t.Error("the merged schema is open")
+				}
+			},
+		},
+		{
+			name: "the first title and description are kept",
+			parts: []*pkg.JSONSchema6{
+				{Description: "first"},
+				{Title: "Second", Description: "second"},
+			},
+			check: func(t *testing.T, m *pkg.JSONSchema6) {
+				if m.Title != "Second" || m.Description != "first" {
+					t.Errorf("title %q, description %q", m.Title, m.Description)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &pkg.JSONSchema6{
+				Definitions: map[string]*pkg.JSONSchema6{
+					"Named":  {Type: "object", Properties: map[string]*pkg.JSONSchema6{"name": {Type: "string"}}},
+					"Target": {AllOf: tt.parts},
+				},
+			}
+			merged, err := pkg.MergeAllOf(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := merged.Definitions["Target"]
+			if target.AllOf != nil {
+				t.Fatal("allOf was not collapsed")
+			}
+			tt.check(t, target)
+			if root.Definitions["Target"].AllOf == nil {
+				t.Error("MergeAllOf modified its argument")
+			}
+		})
+	}
+}
+
+func TestMergeAllOfConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []*pkg.JSONSchema6
+		want  string
+	}{
+		{"different types", []*pkg.JSONSchema6{{Type: "string"}, {Type: "integer"}}, "#/definitions/Target: conflicting types string and integer"},
+		{"disjoint enums", []*pkg.JSONSchema6{{Enum: []string{"A"}}, {Enum: []string{"B"}}}, "enums have no value in common"},
+		{"empty range", []*pkg.JSONSchema6{{Minimum: float(10)}, {Maximum: float(5)}}, "minimum 10 exceeds maximum 5"},
+		{"different patterns", []*pkg.JSONSchema6{{Pattern: "^a"}, {Pattern: "^b"}}, "conflicting patterns ^a and ^b"},
+		{
+			"conflicting property types",
+			[]*pkg.JSONSchema6{
+				{Properties: map[string]*pkg.JSONSchema6{"a~b": {Type: "string"}}},
+				{Properties: map[string]*pkg.JSONSchema6{"a~b": {Type: "boolean"}}},
+			},
+			"#/definitions/Target/properties/a~0b: conflicting types string and boolean",
+		},
+		{
+			"different anyOf",
+			[]*pkg.JSONSchema6{
+				{AnyOf: []*pkg.JSONSchema6{{Type: "string"}}},
+				{AnyOf: []*pkg.JSONSchema6{{Type: "integer"}}},
+			},
+			"cannot merge anyOf from several parts",
+		},
+		{"non-local ref", []*pkg.JSONSchema6{{Ref: "other.json#/definitions/A"}}, "cannot merge non-local $ref"},
+		{"missing ref", []*pkg.JSONSchema6{{Ref: "#/definitions/Missing"}}, `segment "Missing" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &pkg.JSONSchema6{Definitions: map[string]*pkg.JSONSchema6{"Target": {AllOf: tt.parts}}}
+			_, err := pkg.MergeAllOf(root)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMergeAllOfInterfaces(t *testing.T) {
+	schema := mustConvert(t, `
+type Query { node(id: ID!): Node }
+interface Node { id: ID! }
+interface Named { name: String }
+type User implements Node & Named { id: ID! name: String email: String }
+`, options(func(o *pkg.Options) { o.InterfaceAllOf = true }))
+	if len(schema.Definitions["User"].AllOf) == 0 {
+		t.Fatal("the fixture produced no allOf")
+	}
+
+	merged, err := pkg.MergeAllOf(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := merged.Definitions["User"]
+	if user.AllOf != nil {
+		t.Error("allOf was not collapsed")
+	}
+	if got := sortedNames(user.Properties); !reflect.DeepEqual(got, []string{"email", "id", "name"}) {
+		t.Errorf("User properties = %v, want the interface fields merged in", got)
+	}
+	if errs := validateJSON(t, merged, user, `{"id": {"return": "1"}, "email": {"return": "a@example.com"}}`); len(errs) > 0 {
+		t.Errorf("a valid user was rejected: %v", errs)
+	}
+}
@@ -0,0 +1,341 @@
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// syntheticSDL generates a schema of roughly n types of every kind, each referencing its
+// neighbours, with arguments, lists, non-null wrappers, descriptions and deprecations
+func syntheticSDL(n int) string {
+	var b strings.Builder
+	objects := max(n*6/10, 2)
+	inputs, enums, unions := max(n/10, 1), max(n/10, 1), max(n/10, 1)
+
+	b.WriteString("type Query {\n")
+	for i := 0; i < objects; i += max(objects/50, 1) {
+		fmt.Fprintf(&b, "  object%d(id: ID!, filter: Input%d, first: Int = 10): Object%d\n", i, i%inputs, i)
+	}
+	b.WriteString("}\n\ntype Mutation {\n")
+	for i := 0; i < inputs; i += max(inputs/20, 1) {
+		fmt.Fprintf(&b, "  update%d(input: Input%d!): Object%d\n", i, i, i%objects)
+	}
+	b.WriteString("}\n\n")
+
+	for i := 0; i < objects; i++ {
+		fmt.Fprintf(&b, "\"\"\"Object number %d\"\"\"\ntype Object%d {\n", i, i)
+		b.WriteString("  id: ID!\n  name: String\n  count: Int!\n  score: Float\n  active: Boolean\n")
+		fmt.Fprintf(&b, "  \"\"\"The next object\"\"\"\n  next: Object%d\n", (i+1)%objects)
+		fmt.Fprintf(&b, "  children(limit: Int, order: Enum%d): [Object%d!]!\n", i%enums, (i+2)%objects)
+		fmt.Fprintf(&b, "  status: Enum%d\n  result: Union%d\n", i%enums, i%unions)
+		b.WriteString("  legacy: String @deprecated(reason: \"Use name\")\n}\n\n")
+	}
+	for i := 0; i < inputs; i++ {
+		fmt.Fprintf(&b, "input Input%d {\n  name: String!\n  tags: [String!]\n  limit: Int = 5\n  nested: Input%d\n  status: Enum%d\n}\n\n", i, (i+1)%inputs, i%enums)
+	}
+	for i := 0; i < enums; i++ {
+		fmt.Fprintf(&b, "enum Enum%d {\n  \"\"\"First\"\"\"\n  A\n  B\n  C @deprecated\n}\n\n", i)
+	}
+	for i := 0; i < unions; i++ {
+		fmt.Fprintf(&b, "union Union%d = Object%d | Object%d\n\n", i, i%objects, (i+3)%objects)
+	}
+	return b.String()
+}
+
+var (
+	syntheticMu    sync.Mutex
+	syntheticCache = make(map[int]pkg.IntrospectionQuery)
+)
+
+// syntheticIntrospection returns the introspection of syntheticSDL(n), read once per size
+func syntheticIntrospection(tb testing.TB, n int) pkg.IntrospectionQuery {
+	tb.Helper()
+	syntheticMu.Lock()
+	defer syntheticMu.Unlock()
+	if introspection, ok := syntheticCache[n]; ok {
+		return introspection
+	}
+	introspection := mustIntrospect(tb, syntheticSDL(n))
+	syntheticCache[n] = introspection
+	return introspection
+}
+
+// benchmarkSizes are the fixtures of the conversion benchmarks
+var benchmarkSizes = []struct {
+	name  string
+	types int
+}{
+	{"small", 50},
+	{"medium", 1000},
+	{"huge", 6000},
+}
+
+func BenchmarkFromIntrospectionQuery(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(size.name, func(b *testing.B) {
+			introspection := syntheticIntrospection(b, size.types)
+			opts := pkg.DefaultOptions()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := pkg.FromIntrospectionQuery(introspection, &opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// findTypeLinear is the scan of the types slice the type index replaced
+func findTypeLinear(types []pkg.IntrospectionType, name string) *pkg.IntrospectionType {
+	for i := range types {
+		if types[i].Name == name {
+			return &types[i]
+		}
+	}
+	return nil
+}
+
+// BenchmarkFindType compares looking every type up by name in the index conversion builds with
+// scanning the types slice; the index is built once per conversion, which build measures
+func BenchmarkFindType(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		types := syntheticIntrospection(b, size.types).Schema.Types
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = t.Name
+		}
+		b.Run(size.name+"/index", func(b *testing.B) {
+			index := pkg.NewTypeIndex(types)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if index[names[i%len(names)]] == nil {
+					b.Fatal("type not found")
+				}
+			}
+		})
+		b.Run(size.name+"/linear", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if findTypeLinear(types, names[i%len(names)]) == nil {
+					b.Fatal("type not found")
+				}
+			}
+		})
+		b.Run(size.name+"/build", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pkg.NewTypeIndex(types)
+			}
+		})
+	}
+}
+
+// BenchmarkConcurrency compares serial processing of definitions with worker pools of growing
+// size on the huge fixture; the speedup is the ratio of the serial ns/op to the others
+func BenchmarkConcurrency(b *testing.B) {
+	introspection := syntheticIntrospection(b, 6000)
+	workers := []int{1, 2, 4, 8}
+	if procs := runtime.GOMAXPROCS(0); procs > 8 {
+		workers = append(workers, procs)
+	}
+	for _, concurrency := range workers {
+		b.Run(fmt.Sprintf("workers=%d", concurrency), func(b *testing.B) {
+			opts := pkg.DefaultOptions()
+			opts.Concurrency = concurrency
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := pkg.FromIntrospectionQuery(introspection, &opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkProgress measures the overhead of Options.Progress on the huge fixture: off, with a
+// callback that does nothing, and with the heap check --max-memory makes on every call
+func BenchmarkProgress(b *testing.B) {
+	introspection := syntheticIntrospection(b, 6000)
+	callbacks := []struct {
+		name     string
+		callback func(done, total int) error
+	}{
+		{"off", nil},
+		{"noop", func(done, total int) error { return nil }},
+		{"memory", func(done, total int) error {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > 1<<40 {
+				return fmt.Errorf("heap usage of %d bytes", stats.HeapAlloc)
+			}
+			return nil
+		}},
+	}
+	for _, concurrency := range []int{1, 0} {
+		for _, c := range callbacks {
+			b.Run(fmt.Sprintf("workers=%d/%s", concurrency, c.name), func(b *testing.B) {
+				opts := pkg.DefaultOptions()
+				opts.Concurrency = concurrency
+				opts.Progress = c.callback
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := pkg.FromIntrospectionQuery(introspection, &opts); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkDecodeIntrospection measures decoding an introspection result from a stream, against
+// reading it whole and unmarshaling it as FromReader did before streaming the types array. The
+// allocated bytes per op show the memory the streaming decoder saves.
+func BenchmarkDecodeIntrospection(b *testing.B) {
+	for _, size := range []struct {
+		name  string
+		types int
+	}{
+		{"huge", 6000},
+		{"50k", 50000},
+	} {
+		data, err := json.Marshal(syntheticIntrospection(b, size.types))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(size.name+"/stream", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := pkg.DecodeIntrospection(bytes.NewReader(data), nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(size.name+"/readall", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				raw, err := io.ReadAll(bytes.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+				var introspection pkg.IntrospectionQuery
+				if err := json.Unmarshal(raw, &introspection); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// concurrencyOptions are option combinations whose output must not depend on the worker count
+var concurrencyOptions = map[string]func(*pkg.Options){
+	"defaults": nil,
+	"interfaces and nullable fields": func(o *pkg.Options) {
+		o.NullableFields = pkg.NullableFieldsTypeArray
+		o.NullableArrayItems = true
+		o.AdditionalPropertiesFalse = true
+	},
+	"plain fields with flat enums": func(o *pkg.Options) {
+		o.FieldShape = pkg.FieldShapePlain
+		o.EnumStyle = pkg.EnumStyleFlat
+		o.UseIntegerType = true
+	},
+	"embedded SDL, deduplicated and 2020-12": func(o *pkg.Options) {
+		o.EmbedSDL = true
+		o.DedupeDefinitions = true
+		o.Draft = pkg.Draft202012
+	},
+	"pruned roots with a flat layout": func(o *pkg.Options) {
+		o.Roots = []pkg.OperationType{pkg.OperationQuery}
+		o.PruneToRoots = true
+		o.OperationsLayout = pkg.OperationsLayoutBoth
+	},
+}
+
+func TestConcurrentOutputMatchesSerial(t *testing.T) {
+	introspection := syntheticIntrospection(t, 300)
+	for name, set := range concurrencyOptions {
+		t.Run(name, func(t *testing.T) {
+			serialOpts, concurrentOpts := options(set), options(set)
+			serialOpts.Concurrency, concurrentOpts.Concurrency = 1, 8
+
+			serial, err := pkg.FromIntrospectionQuery(introspection, serialOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := mustJSON(t, serial)
+			for run := 0; run < 3; run++ {
+				concurrent, err := pkg.FromIntrospectionQuery(introspection, concurrentOpts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := mustJSON(t, concurrent); !bytes.Equal(got, want) {
+					t.Fatalf("run %d: output with 8 workers differs from the serial output", run)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentProgress(t *testing.T) {
+	introspection := syntheticIntrospection(t, 1000)
+	var mu sync.Mutex
+	var calls []int
+	opts := options(func(o *pkg.Options) {
+		o.Concurrency = 8
+		o.Progress = func(done, total int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, done)
+			if done > total {
+				t.Errorf("progress %d exceeds the total %d", done, total)
+			}
+			return nil
+		}
+	})
+	if _, err := pkg.FromIntrospectionQuery(introspection, opts); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i] <= calls[i-1] {
+			t.Errorf("progress went from %d to %d", calls[i-1], calls[i])
+		}
+	}
+	if len(calls) == 0 {
+		t.Error("progress was never reported")
+	}
+
+	stop := fmt.Errorf("stop")
+	opts.Progress = func(done, total int) error { return stop }
+	if _, err := pkg.FromIntrospectionQuery(introspection, opts); err != stop {
+		t.Errorf("expected the progress error to abort the conversion, got %v", err)
+	}
+}
+
+// TestSyntheticGolden pins the output for the benchmark fixture, so that optimizations of the
+// conversion hot path (cached refs, shared scalar schemas, lazy maps) cannot change it
+func TestSyntheticGolden(t *testing.T) {
+	schema, err := pkg.FromIntrospectionQuery(syntheticIntrospection(t, 20), options(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "synthetic.json", append(got, '\n'))
+}
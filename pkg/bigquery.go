@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BigQuery column modes
+const (
+	BigQueryNullable = "NULLABLE"
+	BigQueryRequired = "REQUIRED"
+	BigQueryRepeated = "REPEATED"
+)
+
+// bigQueryMaxDepth is the deepest nesting of RECORD columns BigQuery accepts
+const bigQueryMaxDepth = 15
+
+// BigQueryField is a column in a BigQuery table schema, as accepted by bq mk --schema
+type BigQueryField struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Mode        string          `json:"mode"`
+	Description string          `json:"description,omitempty"`
+	Fields      []BigQueryField `json:"fields,omitempty"`
+}
+
+// BigQueryOptions controls the BigQuery table schema export
+type BigQueryOptions struct {
+	// ScalarTypes maps GraphQL scalars to BigQuery column types; unmapped custom scalars become STRING
+	ScalarTypes map[string]string `json:"scalarTypes"`
+}
+
+// DefaultBigQueryOptions returns the default scalar mapping, which also covers common date and time scalars
+func DefaultBigQueryOptions() BigQueryOptions {
+	return BigQueryOptions{
+		ScalarTypes: map[string]string{
+			"ID":        "STRING",
+			"String":    "STRING",
+			"Int":       "INT64",
+			"Float":     "FLOAT64",
+			"Boolean":   "BOOL",
+			"DateTime":  "TIMESTAMP",
+			"Timestamp": "TIMESTAMP",
+			"Date":      "DATE",
+			"Time":      "TIME",
+			"JSON":      "JSON",
+		},
+	}
+}
+
+// BigQuerySchema converts the named object or input object type into a BigQuery table schema.
+// Objects become nested RECORD columns, lists become REPEATED columns and non-null fields are REQUIRED.
+// Unions, lists of lists and recursive types cannot be represented and are reported as errors.
+func BigQuerySchema(introspection IntrospectionQuery, typeName string, opts BigQueryOptions) ([]BigQueryField, error) {
+	types := newTypeIndex(introspection.Schema.Types)
+	t := types[typeName]
+	if t == nil {
+		names := make([]string, 0, len(types))
+		for name := range types {
+			names = append(names, name)
+		}
+		return nil, notFound("type", typeName, names)
+	}
+
+	e := &bigQueryExporter{types: types, opts: opts, visiting: map[string]bool{}}
+	return e.recordFields(t, typeName, 1)
+}
+
+type bigQueryExporter struct {
+	types    typeIndex
+	opts     BigQueryOptions
+	visiting map[string]bool
+}
+
+// recordFields converts the fields of an object, interface or input object into columns
+func (e *bigQueryExporter) recordFields(t *IntrospectionType, path string, depth int) ([]BigQueryField, error) {
+	switch t.Kind {
+	case "OBJECT", "INTERFACE", "INPUT_OBJECT":
+	default:
+		return nil, fmt.Errorf("%s: type %s is a %s, not an object", path, t.Name, strings.ToLower(t.Kind))
+	}
+	if depth > bigQueryMaxDepth {
+		return nil, fmt.Errorf("%s: nesting exceeds BigQuery's limit of %d levels", path, bigQueryMaxDepth)
+	}
+
+	e.visiting[t.Name] = true
+	defer delete(e.visiting, t.Name)
+
+	columns := make([]BigQueryField, 0, len(t.Fields)+len(t.InputFields))
+	for _, field := range t.Fields {
+		column, err := e.column(field.Name, field.Description, field.Type, path+"."+field.Name, depth)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	for _, field := range t.InputFields {
+		column, err := e.column(field.Name, field.Description, field.Type, path+"."+field.Name, depth)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// column converts a single field, unwrapping non-null and list wrappers into the column mode
+func (e *bigQueryExporter) column(name, description string, typeRef IntrospectionTypeRef, path string, depth int) (BigQueryField, error) {
+	column := BigQueryField{Name: name, Mode: BigQueryNullable, Description: description}
+
+	if typeRef.Kind == "NON_NULL" && typeRef.OfType != nil {
+		column.Mode = BigQueryRequired
+		typeRef = *typeRef.OfType
+	}
+	if typeRef.Kind == "LIST" && typeRef.OfType != nil {
+		column.Mode = BigQueryRepeated
+		typeRef = *typeRef.OfType
+		if typeRef.Kind == "NON_NULL" && typeRef.OfType != nil {
+			typeRef = *typeRef.OfType
+		}
+		if typeRef.Kind == "LIST" {
+			return column, fmt.Errorf("%s: lists of lists cannot be represented in BigQuery", path)
+		}
+	}
+
+	if typeRef.Name == nil {
+		return column, fmt.Errorf("%s: type reference has no name", path)
+	}
+	t := e.types[*typeRef.Name]
+	if t == nil {
+		return column, fmt.Errorf("%s: type %s not found", path, *typeRef.Name)
+	}
+
+	switch t.Kind {
+	case "SCALAR":
+		column.Type = e.opts.ScalarTypes[t.Name]
+		if column.Type == "" {
+			column.Type = "STRING"
+		}
+	case "ENUM":
+		column.Type = "STRING"
+	case "UNION":
+		return column, fmt.Errorf("%s: union %s cannot be represented in BigQuery; select a member type instead", path, t.Name)
+	default:
+		if e.visiting[t.Name] {
+			return column, fmt.Errorf("%s: type %s is recursive and cannot be represented in BigQuery", path, t.Name)
+		}
+		fields, err := e.recordFields(t, path, depth+1)
+		if err != nil {
+			return column, err
+		}
+		column.Type = "RECORD"
+		column.Fields = fields
+	}
+	return column, nil
+}
@@ -0,0 +1,115 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const bigQuerySDL = `
+scalar DateTime
+scalar Date
+scalar Money
+
+type Query { orderEvents: [OrderEvent!]! }
+
+enum EventKind { PLACED SHIPPED CANCELLED }
+
+"""An order lifecycle event as landed in the warehouse"""
+type OrderEvent {
+  id: ID!
+  kind: EventKind!
+  occurredAt: DateTime!
+  deliveryDate: Date
+  total: Money
+  "Whether the order was paid with a gift card"
+  giftCard: Boolean
+  customer: Customer!
+  lines: [OrderLine!]!
+  tags: [String]
+}
+
+type Customer {
+  id: ID!
+  email: String
+  address: Address
+}
+
+type Address {
+  street: String
+  city: String!
+  country: String!
+}
+
+type OrderLine {
+  sku: String!
+  quantity: Int!
+  unitPrice: Float
+}
+
+union Payload = OrderEvent | Customer
+
+type Category { name: String parent: Category }
+
+type Matrix { cells: [[Int]] }
+
+type Holder { payload: Payload }
+`
+
+func TestBigQuerySchemaGolden(t *testing.T) {
+	opts := pkg.DefaultBigQueryOptions()
+	opts.ScalarTypes["Money"] = "NUMERIC"
+	fields, err := pkg.BigQuerySchema(mustIntrospect(t, bigQuerySDL), "OrderEvent", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "bigquery/order_event.json", append(got, '\n'))
+}
+
+func TestBigQuerySchemaErrors(t *testing.T) {
+	tests := []struct {
+		typeName string
+		want     string
+	}{
+		{"Holder", "Holder.payload: union Payload cannot be represented"},
+		{"Category", "Category.parent: type Category is recursive"},
+		{"Matrix", "Matrix.cells: lists of lists cannot be represented"},
+		{"EventKind", "type EventKind is a enum, not an object"},
+		{"OrderEvnt", "did you mean: OrderEvent"},
+	}
+
+	introspection := mustIntrospect(t, bigQuerySDL)
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			_, err := pkg.BigQuerySchema(introspection, tt.typeName, pkg.DefaultBigQueryOptions())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBigQuerySchemaDepthLimit(t *testing.T) {
+	var sdl strings.Builder
+	sdl.WriteString("type Query { level: Level0 }\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&sdl, "type Level%d { value: Int next: Level%d }\n", i, i+1)
+	}
+	sdl.WriteString("type Level20 { value: Int }\n")
+
+	introspection := mustIntrospect(t, sdl.String())
+	if _, err := pkg.BigQuerySchema(introspection, "Level6", pkg.DefaultBigQueryOptions()); err != nil {
+		t.Errorf("15 levels of nesting should be accepted: %v", err)
+	}
+	_, err := pkg.BigQuerySchema(introspection, "Level5", pkg.DefaultBigQueryOptions())
+	if err == nil || !strings.Contains(err.Error(), "nesting exceeds BigQuery's limit of 15 levels") {
+		t.Errorf("expected a nesting error, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChangelogSection groups changelog entries, following the Keep a Changelog headings
+type ChangelogSection string
+
+const (
+	SectionAdded      ChangelogSection = "Added"
+	SectionChanged    ChangelogSection = "Changed"
+	SectionDeprecated ChangelogSection = "Deprecated"
+	SectionRemoved    ChangelogSection = "Removed"
+)
+
+// ChangelogSections lists the sections in the order they are rendered
+var ChangelogSections = []ChangelogSection{SectionAdded, SectionChanged, SectionDeprecated, SectionRemoved}
+
+// ChangelogEntry is a schema change described in prose. Coordinate is the GraphQL schema coordinate
+// of the changed element and Text a sentence describing the change, with names in backticks.
+type ChangelogEntry struct {
+	Section    ChangelogSection `json:"section"`
+	Coordinate string           `json:"coordinate"`
+	Text       string           `json:"text"`
+	Breaking   bool             `json:"breaking,omitempty"`
+}
+
+// SchemaChangelog describes changes between two JSON Schema documents generated by this package.
+// JSON pointer paths are mapped back to GraphQL coordinates, and changes are breaking when the
+// default compat rules classify them as such. JSON Schema has no notion of deprecation, so the
+// Deprecated section stays empty; use GraphQLChangelog for that.
+func SchemaChangelog(changes []SchemaChange) []ChangelogEntry {
+	rules := DefaultCompatRules()
+	entries := make([]ChangelogEntry, 0, len(changes))
+	for _, change := range changes {
+		member := change.Name
+		if change.Category == CategoryDefinition || change.Category == CategoryProperty ||
+			change.Category == CategoryEnum || change.Category == CategoryVariant {
+			member = ""
+		}
+		coordinate := schemaCoordinate(change.Path, member)
+
+		entry := ChangelogEntry{
+			Section:    SectionChanged,
+			Coordinate: coordinate,
+			Breaking:   rules[compatRule(change)] == SeverityBreaking,
+		}
+		switch change.Category {
+		case CategoryDefinition, CategoryProperty, CategoryEnum, CategoryVariant:
+			if change.Kind == ChangeAdded {
+				entry.Section = SectionAdded
+			} else {
+				entry.Section = SectionRemoved
+			}
+		}
+
+		switch change.Category {
+		case CategoryDefinition, CategoryProperty:
+			subject := "`" + coordinate + "`"
+			if !strings.Contains(coordinate, ".") {
+				subject = "Type " + subject
+			}
+			entry.Text = fmt.Sprintf("%s was %s", subject, change.Kind)
+		case CategoryRequired:
+			if change.Kind == ChangeAdded {
+				entry.Text = fmt.Sprintf("`%s` is now required", coordinate)
+			} else {
+				entry.Text = fmt.Sprintf("`%s` is now optional", coordinate)
+			}
+		case CategoryEnum:
+			entry.Coordinate = coordinate + "." + change.Name
+			entry.Text = fmt.Sprintf("Enum `%s` %s value `%s`", coordinate, gainedOrLost(change.Kind), change.Name)
+		case CategoryVariant:
+			entry.Text = fmt.Sprintf("Union `%s` %s member `%s`", coordinate, gainedOrLost(change.Kind), refName(change.Name))
+		case CategoryType:
+			entry.Text = fmt.Sprintf("`%s` changed type from %s to %s", coordinate, describeTypes(change.Old), describeTypes(change.New))
+		case CategoryRef:
+			oldRef, _ := change.Old.(string)
+			newRef, _ := change.New.(string)
+			switch {
+			case oldRef == "":
+				entry.Text = fmt.Sprintf("`%s` now refers to `%s`", coordinate, refName(newRef))
+			case newRef == "":
+				entry.Text = fmt.Sprintf("`%s` no longer refers to `%s`", coordinate, refName(oldRef))
+			default:
+				entry.Text = fmt.Sprintf("`%s` now refers to `%s` instead of `%s`", coordinate, refName(newRef), refName(oldRef))
+			}
+		case CategoryItems:
+			if change.Kind == ChangeAdded {
+				entry.Text = fmt.Sprintf("`%s` is now a list", coordinate)
+			} else {
+				entry.Text = fmt.Sprintf("`%s` is no longer a list", coordinate)
+			}
+		case CategoryDefault:
+			entry.Text = fmt.Sprintf("Default of `%s` changed from %s to %s", coordinate, describeValue(change.Old), describeValue(change.New))
+		case CategoryDescription:
+			entry.Text = fmt.Sprintf("Description of `%s` changed", coordinate)
+		default:
+			entry.Text = fmt.Sprintf("`%s` changed", coordinate)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// GraphQLChangelog describes changes between two introspection results, as found by
+// DiffIntrospections. Changes are breaking when their criticality is breaking.
+func GraphQLChangelog(changes []GraphQLChange) []ChangelogEntry {
+	entries := make([]ChangelogEntry, 0, len(changes))
+	for _, change := range changes {
+		c := change.Coordinate
+		entry := ChangelogEntry{
+			Section:    SectionChanged,
+			Coordinate: c,
+			Breaking:   change.Criticality == CriticalityBreaking,
+		}
+
+		switch change.Type {
+		case ChangeTypeAdded:
+			entry.Section, entry.Text = SectionAdded, fmt.Sprintf("Type `%s` was added", c)
+		case ChangeTypeRemoved:
+			entry.Section, entry.Text = SectionRemoved, fmt.Sprintf("Type `%s` was removed", c)
+		case ChangeTypeKindChanged:
+			entry.Text = fmt.Sprintf("`%s` changed from %s to %s", c, strings.ToLower(change.Old), strings.ToLower(change.New))
+		case ChangeRootTypeChanged:
+			entry.Text = fmt.Sprintf("Root %s type changed from `%s` to `%s`", strings.TrimPrefix(c, "schema."), change.Old, change.New)
+		case ChangeFieldAdded, ChangeArgumentAdded, ChangeInputFieldAdded:
+			entry.Section, entry.Text = SectionAdded, fmt.Sprintf("`%s` was added", c)
+			if entry.Breaking {
+				entry.Text = fmt.Sprintf("Required `%s` was added", c)
+			}
+		case ChangeFieldRemoved, ChangeArgumentRemoved, ChangeInputFieldRemoved:
+			entry.Section, entry.Text = SectionRemoved, fmt.Sprintf("`%s` was removed", c)
+		case ChangeFieldTypeChanged:
+			entry.Text = typeChangeText(c, change.Old, change.New, "non-null", "nullable")
+		case ChangeArgumentTypeChanged, ChangeInputFieldTypeChanged:
+			entry.Text = typeChangeText(c, change.Old, change.New, "required", "optional")
+		case ChangeArgumentDefaultChanged, ChangeInputFieldDefaultChanged:
+			entry.Text = fmt.Sprintf("Default of `%s` changed from %s to %s", c, describeDefault(change.Old), describeDefault(change.New))
+		case ChangeFieldDeprecationAdded, ChangeEnumValueDeprecationAdded:
+			entry.Section, entry.Text = SectionDeprecated, fmt.Sprintf("`%s` was deprecated", c)
+			if change.New != "" {
+				entry.Text += ": " + change.New
+			}
+		case ChangeFieldDeprecationRemoved, ChangeEnumValueDeprecationRemoved:
+			entry.Text = fmt.Sprintf("`%s` is no longer deprecated", c)
+		case ChangeEnumValueAdded, ChangeEnumValueRemoved:
+			entry.Section = SectionAdded
+			verb := "gained"
+			if change.Type == ChangeEnumValueRemoved {
+				entry.Section, verb = SectionRemoved, "lost"
+			}
+			enum, value := c, ""
+			if i := strings.LastIndex(c, "."); i >= 0 {
+				enum, value = c[:i], c[i+1:]
+			}
+			entry.Text = fmt.Sprintf("Enum `%s` %s value `%s`", enum, verb, value)
+		case ChangeUnionMemberAdded:
+			entry.Section, entry.Text = SectionAdded, fmt.Sprintf("Union `%s` gained member `%s`", c, change.New)
+		case ChangeUnionMemberRemoved:
+			entry.Section, entry.Text = SectionRemoved, fmt.Sprintf("Union `%s` lost member `%s`", c, change.Old)
+		case ChangeInterfaceAdded:
+			entry.Section, entry.Text = SectionAdded, fmt.Sprintf("`%s` now implements `%s`", c, change.New)
+		case ChangeInterfaceRemoved:
+			entry.Section, entry.Text = SectionRemoved, fmt.Sprintf("`%s` no longer implements `%s`", c, change.Old)
+		default:
+			entry.Text = change.Message
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// schemaCoordinate maps a JSON pointer into a generated schema, plus an optional member named at
+// that location such as a required property, to a GraphQL coordinate: Type, Type.field or
+// Type.field(arg:). Root properties are the root operation types, so they map like definitions.
+func schemaCoordinate(path, member string) string {
+	var typeName, field, arg string
+	inArguments := false
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		segment := unescapePointerSegment(segments[i])
+		switch segment {
+		case "definitions", "properties":
+			i++
+			name := unescapePointerSegment(segments[i])
+			switch {
+			case typeName == "":
+				typeName = name
+			case field == "":
+				field = name
+			case name == "arguments" && !inArguments:
+				inArguments = true
+			case inArguments && arg == "":
+				arg = name
+			}
+		case "anyOf", "oneOf", "allOf":
+			i++
+		}
+	}
+
+	switch {
+	case member == "":
+	case typeName == "":
+		typeName = member
+	case field == "":
+		field = member
+	case inArguments && arg == "":
+		arg = member
+	}
+
+	coordinate := typeName
+	if field != "" {
+		coordinate += "." + field
+	}
+	if arg != "" {
+		coordinate += "(" + arg + ":)"
+	}
+	return coordinate
+}
+
+// typeChangeText describes a changed GraphQL type reference, naming the common case of a type
+// only gaining or losing its outermost non-null
+func typeChangeText(coordinate, oldRef, newRef, nonNull, nullable string) string {
+	switch {
+	case newRef == oldRef+"!":
+		return fmt.Sprintf("`%s` is now %s", coordinate, nonNull)
+	case oldRef == newRef+"!":
+		return fmt.Sprintf("`%s` is now %s", coordinate, nullable)
+	}
+	return fmt.Sprintf("`%s` changed type from `%s` to `%s`", coordinate, oldRef, newRef)
+}
+
+func gainedOrLost(kind ChangeKind) string {
+	if kind == ChangeAdded {
+		return "gained"
+	}
+	return "lost"
+}
+
+// refName is the definition name a $ref points to, or the ref itself when it points elsewhere
+func refName(ref string) string {
+	if name, ok := strings.CutPrefix(ref, "#/definitions/"); ok {
+		return unescapePointerSegment(name)
+	}
+	return ref
+}
+
+// describeTypes renders the JSON Schema type keyword value for prose
+func describeTypes(value interface{}) string {
+	types := schemaTypes(value)
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, " | ")
+}
+
+// describeValue renders a default value for prose
+func describeValue(value interface{}) string {
+	if value == nil {
+		return "none"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return "`" + string(data) + "`"
+}
+
+// describeDefault renders a GraphQL default value, as reported by DiffIntrospections, for prose
+func describeDefault(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return "`" + value + "`"
+}
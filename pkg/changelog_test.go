@@ -0,0 +1,177 @@
+package pkg_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const changelogBefore = `
+type Query {
+  user(id: ID!): User
+  users(first: Int = 10): [User!]
+  legacy: String
+}
+
+type User {
+  id: ID!
+  name: String
+  email: String!
+  status: Status
+}
+
+type Post { id: ID! }
+
+union SearchResult = User | Post
+
+enum Status { ACTIVE BANNED }
+
+input UserFilter { name: String }
+`
+
+const changelogAfter = `
+type Query {
+  user(id: ID!, locale: String!): User
+  users(first: Int = 20): [User!]
+  search(term: String!): [SearchResult!]!
+}
+
+type User {
+  id: ID!
+  name: String!
+  email: String
+  status: Status @deprecated(reason: "Use state")
+  state: Status
+}
+
+type Comment { id: ID! }
+
+union SearchResult = User | Comment
+
+enum Status { ACTIVE ARCHIVED }
+
+input UserFilter { name: String active: Boolean! }
+`
+
+// renderChangelog writes one line per entry, so a golden file shows the sections, prose and
+// breaking marks at a glance
+func renderChangelog(entries []pkg.ChangelogEntry) []byte {
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%-10s %-28s %s", entry.Section, entry.Coordinate, entry.Text)
+		if entry.Breaking {
+			b.WriteString(" (breaking)")
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+func TestGraphQLChangelog(t *testing.T) {
+	changes := pkg.DiffIntrospections(mustIntrospect(t, changelogBefore), mustIntrospect(t, changelogAfter))
+	entries := pkg.GraphQLChangelog(changes)
+	assertGolden(t, "changelog/graphql.txt", renderChangelog(entries))
+
+	for _, entry := range entries {
+		if entry.Text == "" {
+			t.Errorf("%s has no text", entry.Coordinate)
+		}
+	}
+}
+
+func TestSchemaChangelog(t *testing.T) {
+	before := mustConvert(t, changelogBefore, nil)
+	after := mustConvert(t, changelogAfter, nil)
+	changes := pkg.DiffSchemas(before, after, pkg.DiffOptions{IgnoreDescriptions: true})
+	entries := pkg.SchemaChangelog(changes)
+	assertGolden(t, "changelog/schema.txt", renderChangelog(entries))
+
+	for _, entry := range entries {
+		if entry.Section == pkg.SectionDeprecated {
+			t.Errorf("JSON Schema changes cannot be deprecations: %+v", entry)
+		}
+		if strings.Contains(entry.Coordinate, "/") {
+			t.Errorf("coordinate %q is still a JSON pointer", entry.Coordinate)
+		}
+	}
+}
+
+func TestChangelogEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		section  pkg.ChangelogSection
+		text     string
+		breaking bool
+	}{
+		{
+			name:    "type added",
+			before:  "type Query { a: Int }",
+			after:   "type Query { a: Int }\ntype Extra { id: ID }",
+			section: pkg.SectionAdded,
+			text:    "Type `Extra` was added",
+		},
+		{
+			name:     "field removed",
+			before:   "type Query { a: Int b: Int }",
+			after:    "type Query { a: Int }",
+			section:  pkg.SectionRemoved,
+			text:     "`Query.b` was removed",
+			breaking: true,
+		},
+		{
+			name:     "argument made required",
+			before:   "type Query { a(x: Int): Int }",
+			after:    "type Query { a(x: Int!): Int }",
+			section:  pkg.SectionChanged,
+			text:     "`Query.a(x:)` is now required",
+			breaking: true,
+		},
+		{
+			name:    "field made non-null",
+			before:  "type Query { a: Int }",
+			after:   "type Query { a: Int! }",
+			section: pkg.SectionChanged,
+			text:    "`Query.a` is now non-null",
+		},
+		{
+			name:    "deprecation with reason",
+			before:  "type Query { a: Int }",
+			after:   `type Query { a: Int @deprecated(reason: "Use b") }`,
+			section: pkg.SectionDeprecated,
+			text:    "`Query.a` was deprecated: Use b",
+		},
+		{
+			name:    "enum value added",
+			before:  "type Query { a: E }\nenum E { X }",
+			after:   "type Query { a: E }\nenum E { X Y }",
+			section: pkg.SectionAdded,
+			text:    "Enum `E` gained value `Y`",
+		},
+		{
+			name:    "default changed",
+			before:  "type Query { a(x: Int = 1): Int }",
+			after:   "type Query { a(x: Int = 2): Int }",
+			section: pkg.SectionChanged,
+			text:    "Default of `Query.a(x:)` changed from `1` to `2`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := pkg.GraphQLChangelog(pkg.DiffIntrospections(mustIntrospect(t, tt.before), mustIntrospect(t, tt.after)))
+			for _, entry := range entries {
+				if entry.Text == tt.text {
+					if entry.Section != tt.section || entry.Breaking != tt.breaking {
+						t.Errorf("%q: section %s, breaking %t; want %s, %t", tt.text, entry.Section, entry.Breaking, tt.section, tt.breaking)
+					}
+					return
+				}
+			}
+			t.Errorf("no entry %q in:\n%s", tt.text, renderChangelog(entries))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity classifies how a schema change affects existing consumers
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityBreaking Severity = "breaking"
+)
+
+// severityRank orders severities from least to most severe
+var severityRank = map[Severity]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityBreaking: 2,
+}
+
+// IsValidSeverity checks if the provided Severity is valid
+func IsValidSeverity(severity Severity) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+// AtLeast reports whether s is at least as severe as other
+func (s Severity) AtLeast(other Severity) bool {
+	return severityRank[s] >= severityRank[other]
+}
+
+// CompatRules maps a rule name such as "property-removed" to the severity it is reported with
+type CompatRules map[string]Severity
+
+// DefaultCompatRules returns the default classification: removals and narrowing are breaking,
+// additions and relaxations are not
+func DefaultCompatRules() CompatRules {
+	return CompatRules{
+		"definition-added":    SeverityInfo,
+		"definition-removed":  SeverityBreaking,
+		"property-added":      SeverityInfo,
+		"property-removed":    SeverityBreaking,
+		"type-narrowed":       SeverityBreaking,
+		"type-widened":        SeverityInfo,
+		"type-changed":        SeverityBreaking,
+		"ref-changed":         SeverityBreaking,
+		"items-added":         SeverityBreaking,
+		"items-removed":       SeverityInfo,
+		"required-added":      SeverityBreaking,
+		"required-removed":    SeverityInfo,
+		"enum-added":          SeverityInfo,
+		"enum-removed":        SeverityBreaking,
+		"variant-added":       SeverityInfo,
+		"variant-removed":     SeverityBreaking,
+		"default-changed":     SeverityWarning,
+		"description-changed": SeverityInfo,
+	}
+}
+
+// CompatFinding is a schema change together with its classification
+type CompatFinding struct {
+	SchemaChange
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+}
+
+// ClassifyChanges assigns a rule and severity to each change. Rules missing from rules fall back to the defaults.
+func ClassifyChanges(changes []SchemaChange, rules CompatRules) ([]CompatFinding, error) {
+	defaults := DefaultCompatRules()
+	for rule, severity := range rules {
+		if _, ok := defaults[rule]; !ok {
+			return nil, fmt.Errorf("unknown compat rule: %s", rule)
+		}
+		if !IsValidSeverity(severity) {
+			return nil, fmt.Errorf("invalid severity %s for rule %s", severity, rule)
+		}
+	}
+
+	findings := make([]CompatFinding, 0, len(changes))
+	for _, change := range changes {
+		rule := compatRule(change)
+		severity, ok := rules[rule]
+		if !ok {
+			severity = defaults[rule]
+		}
+		findings = append(findings, CompatFinding{SchemaChange: change, Rule: rule, Severity: severity})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] > severityRank[findings[j].Severity]
+	})
+	return findings, nil
+}
+
+// HighestSeverity returns the most severe classification among findings, or an empty Severity when there are none
+func HighestSeverity(findings []CompatFinding) Severity {
+	var highest Severity
+	for _, finding := range findings {
+		if highest == "" || !highest.AtLeast(finding.Severity) {
+			highest = finding.Severity
+		}
+	}
+	return highest
+}
+
+// compatRule names the rule that applies to a change
+func compatRule(change SchemaChange) string {
+	if change.Category == CategoryType {
+		oldTypes, newTypes := schemaTypes(change.Old), schemaTypes(change.New)
+		switch {
+		case len(oldTypes) == 0:
+			return "type-narrowed"
+		case len(newTypes) == 0:
+			return "type-widened"
+		case typesSubset(newTypes, oldTypes):
+			return "type-narrowed"
+		case typesSubset(oldTypes, newTypes):
+			return "type-widened"
+		}
+		return "type-changed"
+	}
+	return fmt.Sprintf("%s-%s", change.Category, change.Kind)
+}
+
+// typesSubset reports whether every type in a is accepted by b, treating integer as a subset of number
+func typesSubset(a, b []string) bool {
+	for _, t := range a {
+		if !containsString(b, t) && !(t == "integer" && containsString(b, "number")) {
+			return false
+		}
+	}
+	return true
+}
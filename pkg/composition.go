@@ -0,0 +1,14 @@
+package pkg
+
+// closeComposition adds unevaluatedProperties: false to union containers and allOf-composed objects,
+// so that they reject properties that none of the branches they matched define. additionalProperties
+// cannot do this, as it only sees the properties declared next to it and would reject those of the
+// branches.
+func closeComposition(schema *JSONSchema6) {
+	closed := false
+	Walk(schema, func(s *JSONSchema6) {
+		if len(s.OneOf) > 0 || len(s.AllOf) > 0 {
+			s.UnevaluatedProperties = &closed
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package pkg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const compositionSDL = `
+type Query { search: [SearchResult!]! node: Node }
+
+interface Node { id: ID! }
+
+type User implements Node { id: ID! name: String! }
+
+type Post implements Node { id: ID! title: String! }
+
+union SearchResult = User | Post
+`
+
+func TestClosedComposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		closed   bool
+		pointer  string
+		instance string
+		want     string
+	}{
+		{"union branch match", true, "/definitions/SearchResult", `{"id": "1", "name": "Ada"}`, ""},
+		{"union extra property", true, "/definitions/SearchResult", `{"id": "1", "name": "Ada", "admin": true}`, `unexpected property "admin"`},
+		{"union extra property left open", false, "/definitions/SearchResult", `{"id": "1", "name": "Ada", "admin": true}`, ""},
+		{"allOf branch match", true, "/definitions/Post", `{"id": "1", "title": "Hello"}`, ""},
+		{"allOf extra property", true, "/definitions/Post", `{"id": "1", "title": "Hello", "draft": true}`, `unexpected property "draft"`},
+		{"allOf extra property left open", false, "/definitions/Post", `{"id": "1", "title": "Hello", "draft": true}`, ""},
+		{"list of union members", true, "/properties/Query/properties/search", `[{"id": "1", "name": "Ada"}, {"id": "2", "title": "Hi", "x": 1}]`, `/1: unexpected property "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustConvert(t, compositionSDL, options(func(o *pkg.Options) {
+				o.Draft = pkg.Draft202012
+				o.FieldShape = pkg.FieldShapePlain
+				o.InterfaceAllOf = true
+				o.ClosedComposition = tt.closed
+			}))
+			// The package works on the definitions layout, as the commands reading a document do
+			pkg.UseDefinitions(schema)
+			errs := validateJSON(t, schema, mustPointer(t, schema, tt.pointer), tt.instance)
+			if tt.want == "" {
+				if len(errs) > 0 {
+					t.Errorf("expected the instance to be valid, got %v", errs)
+				}
+				return
+			}
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tt.want) {
+					return
+				}
+			}
+			t.Errorf("expected an error containing %q, got %v", tt.want, errs)
+		})
+	}
+}
+
+func TestClosedCompositionRequiresDraft202012(t *testing.T) {
+	introspection := mustIntrospect(t, compositionSDL)
+	_, err := pkg.FromIntrospectionQuery(introspection, options(func(o *pkg.Options) { o.ClosedComposition = true }))
+	if err == nil || !strings.Contains(err.Error(), "ClosedComposition requires unevaluatedProperties") {
+		t.Errorf("expected ClosedComposition to be rejected on draft-06, got %v", err)
+	}
+}
+
+func TestValidateUnevaluatedProperties(t *testing.T) {
+	closed := false
+	schema := &pkg.JSONSchema6{
+		Type: "object",
+		AnyOf: []*pkg.JSONSchema6{
+			{Properties: map[string]*pkg.JSONSchema6{"a": {Type: "string"}}, Required: []string{"a"}},
+			{Properties: map[string]*pkg.JSONSchema6{"b": {Type: "string"}}, Required: []string{"b"}},
+		},
+		UnevaluatedProperties: &closed,
+	}
+	tests := []struct {
+		instance string
+		errors   int
+	}{
+		{`{"a": "x"}`, 0},
+		{`{"a": "x", "b": "y"}`, 0},
+		// b only counts as evaluated by a branch the instance matches
+		{`{"a": "x", "b": 1}`, 1},
+		{`{"a": "x", "c": "z"}`, 1},
+	}
+	for _, tt := range tests {
+		instance, err := pkg.DecodeInstance([]byte(tt.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := pkg.Validate(schema, schema, instance); len(errs) != tt.errors {
+			t.Errorf("%s: got errors %v, want %d", tt.instance, errs, tt.errors)
+		}
+	}
+}
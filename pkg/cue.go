@@ -0,0 +1,312 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CUEOptions controls the CUE export
+type CUEOptions struct {
+	// Package is the package clause of the generated file; empty omits it
+	Package string `json:"package"`
+	// ScalarTypes maps GraphQL scalars to CUE types; unmapped custom scalars accept any value
+	ScalarTypes map[string]string `json:"scalarTypes"`
+	// IgnoreInternals skips the introspection types when exporting every type
+	IgnoreInternals bool `json:"ignoreInternals"`
+}
+
+// DefaultCUEOptions returns the default scalar mapping for the given ID representation
+func DefaultCUEOptions(idMapping IDTypeMapping) CUEOptions {
+	id := "string"
+	switch idMapping {
+	case IDTypeNumber:
+		id = "number"
+	case IDTypeBoth:
+		id = "string | number"
+	}
+	return CUEOptions{
+		ScalarTypes: map[string]string{
+			"ID":      id,
+			"String":  "string",
+			"Int":     "int32",
+			"Float":   "number",
+			"Boolean": "bool",
+		},
+		IgnoreInternals: true,
+	}
+}
+
+// cueBuiltinScalars are referenced inline rather than through a definition
+var cueBuiltinScalars = map[string]bool{"ID": true, "String": true, "Int": true, "Float": true, "Boolean": true}
+
+// cueKeywords cannot be used as unquoted field labels
+var cueKeywords = map[string]bool{
+	"package": true, "import": true, "for": true, "in": true, "if": true, "let": true,
+	"true": true, "false": true, "null": true,
+}
+
+// CUESchema exports types as CUE definitions named #TypeName. When typeName is set, that type and
+// every type it references are exported; otherwise all types except the root operation types that
+// no other type refers to are.
+//
+// Objects and input objects become closed structs whose nullable and defaulted fields are optional,
+// enums and unions become disjunctions, and interfaces become the disjunction of their
+// implementations. Constructs CUE cannot express are reported by coordinate.
+func CUESchema(introspection IntrospectionQuery, typeName string, opts CUEOptions) ([]byte, error) {
+	if opts.Package != "" && !isCUEIdentifier(opts.Package) {
+		return nil, fmt.Errorf("invalid CUE package name %q", opts.Package)
+	}
+
+	types := newTypeIndex(introspection.Schema.Types)
+	g := &cueGenerator{types: types, opts: opts}
+
+	var names []string
+	if typeName != "" {
+		if types[typeName] == nil {
+			candidates := make([]string, 0, len(types))
+			for name := range types {
+				candidates = append(candidates, name)
+			}
+			return nil, notFound("type", typeName, candidates)
+		}
+		names = g.closure(typeName)
+	} else {
+		roots := map[string]bool{}
+		for _, root := range []*TypeRef{introspection.Schema.QueryType, introspection.Schema.MutationType, introspection.Schema.SubscriptionType} {
+			if root != nil {
+				roots[root.Name] = true
+			}
+		}
+		for _, t := range filterTypes(introspection.Schema.Types, opts.IgnoreInternals) {
+			if !roots[t.Name] && !cueBuiltinScalars[t.Name] {
+				names = append(names, t.Name)
+			}
+		}
+		// A root type is still exported when another type refers to it, like the query field of
+		// Relay payloads or a Node interface the root implements, so every reference is defined
+		visit := func(typeRef IntrospectionTypeRef) {
+			if named := namedTypeRef(typeRef); named != nil && named.Name != nil && roots[*named.Name] {
+				roots[*named.Name] = false
+				names = append(names, *named.Name)
+			}
+		}
+		for i := 0; i < len(names); i++ {
+			t := types[names[i]]
+			for _, field := range t.Fields {
+				visit(field.Type)
+			}
+			for _, field := range t.InputFields {
+				visit(field.Type)
+			}
+			for _, member := range t.PossibleTypes {
+				name := member.Name
+				visit(IntrospectionTypeRef{Kind: member.Kind, Name: &name})
+			}
+		}
+		sort.Strings(names)
+	}
+
+	var b strings.Builder
+	if opts.Package != "" {
+		fmt.Fprintf(&b, "package %s\n", opts.Package)
+	}
+	for _, name := range names {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		g.writeDefinition(&b, types[name])
+	}
+
+	if len(g.errs) > 0 {
+		return nil, errors.Join(g.errs...)
+	}
+	return []byte(b.String()), nil
+}
+
+type cueGenerator struct {
+	types typeIndex
+	opts  CUEOptions
+	errs  []error
+}
+
+func (g *cueGenerator) errorf(coordinate, format string, args ...interface{}) {
+	g.errs = append(g.errs, fmt.Errorf("%s: %s", coordinate, fmt.Sprintf(format, args...)))
+}
+
+// closure lists typeName first, followed by every type it references other than the built-in
+// scalars, in name order
+func (g *cueGenerator) closure(typeName string) []string {
+	names := typeClosure(g.types, typeName)
+	result := names[:1]
+	for _, name := range names[1:] {
+		if !(g.types[name].Kind == "SCALAR" && cueBuiltinScalars[name]) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func (g *cueGenerator) writeDefinition(b *strings.Builder, t *IntrospectionType) {
+	if strings.HasPrefix(t.Name, "__") {
+		g.errorf(t.Name, "names starting with __ are reserved in CUE; use --ignore-internals")
+		return
+	}
+	writeCUEComment(b, t.Description, "")
+
+	switch t.Kind {
+	case "OBJECT", "INTERFACE", "INPUT_OBJECT":
+		if t.Kind == "INTERFACE" {
+			// Values of an interface are values of one of its implementations, which have more fields
+			g.writeDisjunction(b, t, "interface has no implementations")
+			return
+		}
+		fmt.Fprintf(b, "#%s: {\n", t.Name)
+		for _, field := range t.Fields {
+			writeCUEComment(b, field.Description, "\t")
+			g.writeField(b, t.Name+"."+field.Name, field.Name, field.Type, false)
+		}
+		for _, field := range t.InputFields {
+			writeCUEComment(b, field.Description, "\t")
+			g.writeField(b, t.Name+"."+field.Name, field.Name, field.Type, field.DefaultValue != nil)
+		}
+		b.WriteString("}\n")
+
+	case "ENUM":
+		if len(t.EnumValues) == 0 {
+			g.errorf(t.Name, "enum has no values")
+			return
+		}
+		values := make([]string, len(t.EnumValues))
+		for i, value := range t.EnumValues {
+			values[i] = strconv.Quote(value.Name)
+		}
+		fmt.Fprintf(b, "#%s: %s\n", t.Name, strings.Join(values, " | "))
+
+	case "UNION":
+		g.writeDisjunction(b, t, "union has no member types")
+
+	case "SCALAR":
+		fmt.Fprintf(b, "#%s: %s\n", t.Name, g.scalarType(t.Name))
+
+	default:
+		g.errorf(t.Name, "unsupported kind %s", t.Kind)
+	}
+}
+
+// writeDisjunction writes a union or interface as the disjunction of its possible types
+func (g *cueGenerator) writeDisjunction(b *strings.Builder, t *IntrospectionType, empty string) {
+	if len(t.PossibleTypes) == 0 {
+		g.errorf(t.Name, "%s", empty)
+		return
+	}
+	members := make([]string, len(t.PossibleTypes))
+	for i, member := range t.PossibleTypes {
+		if g.types[member.Name] == nil {
+			g.errorf(t.Name, "member type %s not found", member.Name)
+		}
+		members[i] = "#" + member.Name
+	}
+	fmt.Fprintf(b, "#%s: %s\n", t.Name, strings.Join(members, " | "))
+}
+
+// writeField writes a struct field; nullable and defaulted fields are optional
+func (g *cueGenerator) writeField(b *strings.Builder, coordinate, name string, typeRef IntrospectionTypeRef, defaulted bool) {
+	expr, nullable := g.typeExpr(coordinate, typeRef)
+	marker := ""
+	if nullable {
+		marker = "?"
+		expr += " | null"
+	} else if defaulted {
+		marker = "?"
+	}
+	fmt.Fprintf(b, "\t%s%s: %s\n", cueLabel(name), marker, expr)
+}
+
+// typeExpr returns the CUE expression for a type reference and whether it accepts null
+func (g *cueGenerator) typeExpr(coordinate string, typeRef IntrospectionTypeRef) (string, bool) {
+	switch typeRef.Kind {
+	case "NON_NULL":
+		if typeRef.OfType == nil {
+			g.errorf(coordinate, "non-null type without an inner type")
+			return "_", false
+		}
+		expr, _ := g.typeExpr(coordinate, *typeRef.OfType)
+		return expr, false
+	case "LIST":
+		if typeRef.OfType == nil {
+			g.errorf(coordinate, "list type without an item type")
+			return "[...]", true
+		}
+		item, nullable := g.typeExpr(coordinate, *typeRef.OfType)
+		if nullable {
+			item = "(" + item + " | null)"
+		}
+		return "[..." + item + "]", true
+	}
+
+	if typeRef.Name == nil {
+		g.errorf(coordinate, "type reference has no name")
+		return "_", true
+	}
+	t := g.types[*typeRef.Name]
+	if t == nil {
+		g.errorf(coordinate, "type %s not found", *typeRef.Name)
+		return "_", true
+	}
+	if t.Kind == "SCALAR" && cueBuiltinScalars[t.Name] {
+		expr := g.scalarType(t.Name)
+		if strings.Contains(expr, "|") {
+			expr = "(" + expr + ")"
+		}
+		return expr, true
+	}
+	return "#" + t.Name, true
+}
+
+// scalarType maps a scalar to its CUE type; unmapped custom scalars accept any value
+func (g *cueGenerator) scalarType(name string) string {
+	if expr := g.opts.ScalarTypes[name]; expr != "" {
+		return expr
+	}
+	return "_"
+}
+
+// writeCUEComment writes a description as line comments
+func writeCUEComment(b *strings.Builder, description, indent string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(description, "\n"), "\n") {
+		b.WriteString(indent)
+		b.WriteString(strings.TrimRight("// "+line, " "))
+		b.WriteString("\n")
+	}
+}
+
+// cueLabel quotes field names that are not plain identifiers: keywords and names starting with _,
+// which CUE treats as hidden fields
+func cueLabel(name string) string {
+	if !isCUEIdentifier(name) || strings.HasPrefix(name, "_") || cueKeywords[name] {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+// isCUEIdentifier reports whether s is a letter or underscore followed by letters, digits and underscores
+func isCUEIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,304 @@
+package pkg_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const cueFixture = `
+"""
+An instant, as an RFC 3339 string.
+Always in UTC.
+"""
+scalar DateTime
+
+type Query { node(id: ID!): Node search(text: String!): [SearchResult!]! }
+
+interface Node { id: ID! }
+
+"A person using the service"
+type User implements Node {
+  id: ID!
+  "Display name"
+  name: String!
+  email: String
+  joined: DateTime
+  role: Role!
+  friends: [User!]
+  tags: [String]!
+  if: Boolean
+  _private: Int
+  package: String
+}
+
+type Post implements Node { id: ID! title: String! author: User score: Float }
+
+union SearchResult = User | Post
+
+enum Role {
+  "Full access"
+  ADMIN
+  MEMBER
+}
+
+input UserFilter {
+  role: Role = MEMBER
+  limit: Int! = 20
+  names: [String!]
+  nested: UserFilter
+}
+`
+
+var (
+	cueDefinitionName = regexp.MustCompile(`^#[A-Za-z_][A-Za-z0-9_]*$`)
+	cueIdentifierName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	cueWord           = regexp.MustCompile(`^#?[A-Za-z_][A-Za-z0-9_]*`)
+	// cueTypes are the predeclared identifiers the export refers to
+	cueTypes = map[string]bool{"_": true, "null": true, "bool": true, "string": true, "bytes": true, "number": true, "int": true, "int32": true, "float": true}
+	// cueReserved labels must be quoted: keywords, and names starting with _, which are hidden fields
+	cueReserved = map[string]bool{"package": true, "import": true, "for": true, "in": true, "if": true, "let": true, "true": true, "false": true, "null": true}
+)
+
+// checkCUEStructure is a stand-in for the CUE parser and evaluator over the subset of CUE the
+// export writes: an optional package clause, then definitions of a struct with one field per line
+// or of a type expression. Labels are identifiers or quoted strings, definitions and the fields of
+// a struct are unique, brackets balance, disjunctions have no empty side and every referenced
+// definition is defined in the file.
+func checkCUEStructure(t *testing.T, source []byte) {
+	t.Helper()
+	defined := map[string]bool{}
+	var referenced []string
+	var fields map[string]bool // labels of the struct being read, nil outside structs
+	for i, line := range strings.Split(strings.TrimSuffix(string(source), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "//"):
+			continue
+		case i == 0 && strings.HasPrefix(line, "package "):
+			if name := strings.TrimPrefix(line, "package "); !cueIdentifierName.MatchString(name) {
+				t.Errorf("line 1: invalid package name %q", name)
+			}
+			continue
+		case fields != nil && line == "}":
+			fields = nil
+			continue
+		}
+
+		label, expr, ok := splitCUEField(trimmed)
+		if !ok {
+			t.Errorf("line %d: not a field: %q", i+1, line)
+			continue
+		}
+		if fields == nil {
+			if line != trimmed || !cueDefinitionName.MatchString(label) {
+				t.Errorf("line %d: top-level field %q is not a definition", i+1, label)
+			}
+			if defined[label] {
+				t.Errorf("line %d: %s is defined twice", i+1, label)
+			}
+			defined[label] = true
+			if expr == "{" {
+				fields = map[string]bool{}
+				continue
+			}
+		} else {
+			if !strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "\t\t") {
+				t.Errorf("line %d: field is not indented by one tab: %q", i+1, line)
+			}
+			name := strings.TrimSuffix(label, "?")
+			switch unquoted, err := strconv.Unquote(name); {
+			case err == nil:
+				name = unquoted
+			case !cueIdentifierName.MatchString(name):
+				t.Errorf("line %d: invalid label %s", i+1, name)
+			case cueReserved[name] || strings.HasPrefix(name, "_"):
+				t.Errorf("line %d: label %s must be quoted", i+1, name)
+			}
+			if fields[name] {
+				t.Errorf("line %d: field %s is declared twice", i+1, name)
+			}
+			fields[name] = true
+		}
+		referenced = append(referenced, checkCUEExpr(t, i+1, expr)...)
+	}
+	if fields != nil {
+		t.Error("the file ends inside a struct")
+	}
+	for _, name := range referenced {
+		if !defined[name] {
+			t.Errorf("%s is referenced but not defined", name)
+		}
+	}
+}
+
+// splitCUEField splits a field into its label, including an optional marker, and its expression
+func splitCUEField(field string) (label, expr string, ok bool) {
+	if strings.HasPrefix(field, `"`) {
+		quoted, err := strconv.QuotedPrefix(field)
+		if err != nil {
+			return "", "", false
+		}
+		label, field = quoted, field[len(quoted):]
+		if strings.HasPrefix(field, "?") {
+			label, field = label+"?", field[1:]
+		}
+		expr, ok = strings.CutPrefix(field, ": ")
+		return label, expr, ok
+	}
+	return strings.Cut(field, ": ")
+}
+
+// checkCUEExpr checks a type expression made of identifiers, definitions, strings, open lists,
+// parentheses and disjunctions, and returns the definitions it references
+func checkCUEExpr(t *testing.T, line int, expr string) []string {
+	t.Helper()
+	var refs []string
+	var closers []byte
+	operand := true // whether an operand is expected next
+	for rest := expr; rest != ""; rest = strings.TrimLeft(rest, " ") {
+		switch c := rest[0]; {
+		case operand && c == '(':
+			closers, rest = append(closers, ')'), rest[1:]
+		case operand && c == '[':
+			if !strings.HasPrefix(rest, "[...") {
+				t.Errorf("line %d: list is not open in %q", line, expr)
+				return refs
+			}
+			closers, rest = append(closers, ']'), rest[len("[..."):]
+			if strings.HasPrefix(rest, "]") {
+				closers, rest, operand = closers[:len(closers)-1], rest[1:], false
+			}
+		case !operand && c == '|':
+			rest, operand = rest[1:], true
+		case !operand && len(closers) > 0 && c == closers[len(closers)-1]:
+			closers, rest = closers[:len(closers)-1], rest[1:]
+		case operand && c == '"':
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				t.Errorf("line %d: invalid string in %q", line, expr)
+				return refs
+			}
+			rest, operand = rest[len(quoted):], false
+		case operand && cueWord.MatchString(rest):
+			word := cueWord.FindString(rest)
+			if strings.HasPrefix(word, "#") {
+				refs = append(refs, word)
+			} else if !cueTypes[word] {
+				t.Errorf("line %d: unknown identifier %s in %q", line, word, expr)
+			}
+			rest, operand = rest[len(word):], false
+		default:
+			t.Errorf("line %d: unexpected %q in %q", line, rest, expr)
+			return refs
+		}
+	}
+	if operand || len(closers) > 0 {
+		t.Errorf("line %d: incomplete expression %q", line, expr)
+	}
+	return refs
+}
+
+// checkCUEEval evaluates the file with the cue command when it is installed
+func checkCUEEval(t *testing.T, source []byte) {
+	t.Helper()
+	cue, err := exec.LookPath("cue")
+	if err != nil {
+		return
+	}
+	file := filepath.Join(t.TempDir(), "schema.cue")
+	if err := os.WriteFile(file, source, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(cue, "eval", file).CombinedOutput(); err != nil {
+		t.Errorf("cue cannot evaluate the export: %v\n%s", err, output)
+	}
+}
+
+func TestCUESchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		golden   string
+		typeName string
+		set      func(*pkg.CUEOptions)
+	}{
+		{name: "all types", golden: "cue/fixture.cue"},
+		{name: "package", golden: "cue/package.cue", set: func(o *pkg.CUEOptions) { o.Package = "schema" }},
+		{name: "selected type", golden: "cue/select-filter.cue", typeName: "UserFilter"},
+		{name: "scalar mapping", golden: "cue/scalars.cue", typeName: "User", set: func(o *pkg.CUEOptions) {
+			o.ScalarTypes["ID"] = "string | int"
+			o.ScalarTypes["DateTime"] = "string"
+		}},
+	}
+
+	introspection := mustIntrospect(t, cueFixture)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := pkg.DefaultCUEOptions(pkg.IDTypeString)
+			if tt.set != nil {
+				tt.set(&opts)
+			}
+			source, err := pkg.CUESchema(introspection, tt.typeName, opts)
+			if err != nil {
+				t.Fatalf("CUESchema: %v", err)
+			}
+			assertGolden(t, tt.golden, source)
+			checkCUEStructure(t, source)
+			checkCUEEval(t, source)
+		})
+	}
+}
+
+// TestCUESchemaFixtures exports the server schemas of testdata/presets
+func TestCUESchemaFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "presets", "*.graphql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no fixture schemas: %v", err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			sdl, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			source, err := pkg.CUESchema(mustIntrospect(t, string(sdl)), "", pkg.DefaultCUEOptions(pkg.IDTypeBoth))
+			if err != nil {
+				t.Fatalf("CUESchema: %v", err)
+			}
+			checkCUEStructure(t, source)
+			checkCUEEval(t, source)
+		})
+	}
+}
+
+func TestCUESchemaErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		set      func(*pkg.CUEOptions)
+		want     string
+	}{
+		{name: "unknown type", typeName: "Missing", want: "Missing"},
+		{name: "invalid package", set: func(o *pkg.CUEOptions) { o.Package = "my-schema" }, want: `invalid CUE package name "my-schema"`},
+		{name: "introspection types", set: func(o *pkg.CUEOptions) { o.IgnoreInternals = false }, want: "__Schema: names starting with __ are reserved in CUE"},
+	}
+	introspection := mustIntrospect(t, cueFixture)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := pkg.DefaultCUEOptions(pkg.IDTypeString)
+			if tt.set != nil {
+				tt.set(&opts)
+			}
+			_, err := pkg.CUESchema(introspection, tt.typeName, opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
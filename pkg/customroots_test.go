@@ -0,0 +1,87 @@
+package pkg_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const customRootsSDL = `
+schema { query: RootQuery mutation: RootMutation subscription: RootSubscription }
+
+type RootQuery { user(id: ID!): User search: Query }
+
+type RootMutation { rename(id: ID!, name: String!): User }
+
+type RootSubscription { renamed: User }
+
+type User { id: ID! name: String }
+
+"An ordinary type that happens to be called Query"
+type Query { text: String }
+`
+
+func TestCustomRootTypeNames(t *testing.T) {
+	schema := mustConvert(t, customRootsSDL, nil)
+
+	if names := sortedNames(schema.Properties); !reflect.DeepEqual(names, []string{"Mutation", "Query", "Subscription"}) {
+		t.Errorf("root properties = %v", names)
+	}
+	for _, root := range []string{"RootQuery", "RootMutation", "RootSubscription"} {
+		if schema.Definitions[root] != nil {
+			t.Errorf("root type %s leaked into the definitions", root)
+		}
+	}
+	if schema.Definitions["Query"] == nil || schema.Definitions["User"] == nil {
+		t.Errorf("definitions = %v, want the ordinary Query type and User kept", sortedNames(schema.Definitions))
+	}
+	if dangling := danglingRefs(schema); len(dangling) > 0 {
+		t.Errorf("dangling refs: %v", dangling)
+	}
+
+	tests := []struct {
+		pointer string
+		ref     string
+	}{
+		{"#/properties/Query/properties/user/properties/return", "#/definitions/User"},
+		{"#/properties/Query/properties/search/properties/return", "#/definitions/Query"},
+		{"#/properties/Mutation/properties/rename/properties/return", "#/definitions/User"},
+		{"#/properties/Subscription/properties/renamed/properties/return", "#/definitions/User"},
+	}
+	for _, tt := range tests {
+		if node := mustPointer(t, schema, tt.pointer); node.Ref != tt.ref {
+			t.Errorf("%s: $ref %q, want %q", tt.pointer, node.Ref, tt.ref)
+		}
+	}
+}
+
+func TestCustomRootTypeNamesOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		set        func(*pkg.Options)
+		properties []string
+		pointer    string
+	}{
+		{"method", func(o *pkg.Options) { o.MethodName = "rename" }, []string{"Mutation"}, "#/properties/Mutation/properties/rename/properties/arguments/properties/name"},
+		{"lenient", func(o *pkg.Options) { o.Lenient = true }, []string{"Mutation", "Query", "Subscription"}, "#/properties/Query/properties/user"},
+		{"definitions only", func(o *pkg.Options) { o.DefinitionsOnly = true }, nil, "#/definitions/Query/properties/text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustConvert(t, customRootsSDL, options(tt.set))
+			if names := sortedNames(schema.Properties); !reflect.DeepEqual(names, tt.properties) {
+				t.Errorf("root properties = %v, want %v", names, tt.properties)
+			}
+			for name := range schema.Definitions {
+				if name == "RootQuery" || name == "RootMutation" || name == "RootSubscription" {
+					t.Errorf("root type %s leaked into the definitions", name)
+				}
+			}
+			mustPointer(t, schema, tt.pointer)
+			if dangling := danglingRefs(schema); len(dangling) > 0 {
+				t.Errorf("dangling refs: %v", dangling)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TypeFilter decides whether a decoded type is kept; it is called once per element of the types array
+type TypeFilter func(t *IntrospectionType) bool
+
+// GraphQLError is an entry of the errors array in a GraphQL response
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
+// DecodeIntrospection decodes an introspection result from r, streaming the types array one element
+// at a time so the raw document is never held in memory. Both a bare {"__schema": ...} document and a
+// GraphQL response envelope {"data": {"__schema": ...}} are accepted. When keep is non-nil, types it
+// rejects are dropped as soon as they are decoded.
+func DecodeIntrospection(r io.Reader, keep TypeFilter) (*IntrospectionQuery, error) {
+	introspection, _, err := DecodeIntrospectionResponse(r, keep)
+	if err != nil {
+		return nil, err
+	}
+	if introspection == nil {
+		return nil, fmt.Errorf("no __schema found in introspection result")
+	}
+	return introspection, nil
+}
+
+// DecodeIntrospectionResponse is DecodeIntrospection for a GraphQL response that may carry errors.
+// The errors array is returned as is; the introspection result is nil when no __schema was found.
+func DecodeIntrospectionResponse(r io.Reader, keep TypeFilter) (*IntrospectionQuery, []GraphQLError, error) {
+	decoder := json.NewDecoder(r)
+	d := &envelopeDecoder{decoder: decoder, keep: keep}
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, nil, err
+	}
+	if err := d.decode(); err != nil {
+		return nil, nil, err
+	}
+	if !d.found {
+		return nil, d.errors, nil
+	}
+	return &IntrospectionQuery{Schema: d.schema}, d.errors, nil
+}
+
+// FromReader decodes an introspection result from r and converts it to a JSON Schema.
+// Internal types are dropped while decoding when opts.IgnoreInternals is set.
+func FromReader(r io.Reader, opts *Options) (*JSONSchema6, error) {
+	if opts == nil {
+		defaultOpts := DefaultOptions()
+		opts = &defaultOpts
+	}
+
+	var keep TypeFilter
+	if opts.IgnoreInternals {
+		keep = func(t *IntrospectionType) bool {
+			return len(t.Name) < 2 || t.Name[:2] != "__"
+		}
+	}
+
+	introspection, err := DecodeIntrospection(r, keep)
+	if err != nil {
+		return nil, err
+	}
+	return FromIntrospectionQuery(*introspection, opts)
+}
+
+// envelopeDecoder collects the schema and errors of an introspection result
+type envelopeDecoder struct {
+	decoder *json.Decoder
+	keep    TypeFilter
+	schema  IntrospectionSchema
+	found   bool
+	errors  []GraphQLError
+}
+
+// decode decodes the rest of an object, after its opening brace, that holds __schema
+// directly or inside data
+func (d *envelopeDecoder) decode() error {
+	for d.decoder.More() {
+		key, err := decodeKey(d.decoder)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "__schema":
+			if err := decodeSchema(d.decoder, &d.schema, d.keep); err != nil {
+				return err
+			}
+			d.found = true
+		case "data":
+			// data is null when the introspection query itself failed
+			isNull, err := openOrNull(d.decoder, '{')
+			if err != nil {
+				return err
+			}
+			if isNull {
+				continue
+			}
+			if err := d.decode(); err != nil {
+				return err
+			}
+		case "errors":
+			if err := d.decoder.Decode(&d.errors); err != nil {
+				return fmt.Errorf("error decoding errors: %w", err)
+			}
+		default:
+			if err := skipValue(d.decoder); err != nil {
+				return err
+			}
+		}
+	}
+
+	return expectDelim(d.decoder, '}')
+}
+
+// decodeSchema decodes the __schema object, streaming the types array
+func decodeSchema(decoder *json.Decoder, schema *IntrospectionSchema, keep TypeFilter) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		key, err := decodeKey(decoder)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "queryType":
+			err = decoder.Decode(&schema.QueryType)
+		case "mutationType":
+			err = decoder.Decode(&schema.MutationType)
+		case "subscriptionType":
+			err = decoder.Decode(&schema.SubscriptionType)
+		case "types":
+			err = decodeTypes(decoder, schema, keep)
+		default:
+			err = skipValue(decoder)
+		}
+		if err != nil {
+			return fmt.Errorf("error decoding __schema.%s: %w", key, err)
+		}
+	}
+
+	return expectDelim(decoder, '}')
+}
+
+func decodeTypes(decoder *json.Decoder, schema *IntrospectionSchema, keep TypeFilter) error {
+	isNull, err := openOrNull(decoder, '[')
+	if err != nil || isNull {
+		return err
+	}
+
+	for decoder.More() {
+		var t IntrospectionType
+		if err := decoder.Decode(&t); err != nil {
+			return err
+		}
+		if keep == nil || keep(&t) {
+			schema.Types = append(schema.Types, t)
+		}
+	}
+
+	return expectDelim(decoder, ']')
+}
+
+func decodeKey(decoder *json.Decoder) (string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", token)
+	}
+	return key, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
+
+// openOrNull consumes either null or the given opening delimiter, reporting whether it was null
+func openOrNull(decoder *json.Decoder, delim json.Delim) (bool, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return false, err
+	}
+	if token == nil {
+		return true, nil
+	}
+	if token != delim {
+		return false, fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return false, nil
+}
+
+func skipValue(decoder *json.Decoder) error {
+	var raw json.RawMessage
+	return decoder.Decode(&raw)
+}
@@ -0,0 +1,204 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultDedupeMinSize is the smallest subschema, in bytes of compact JSON, that DedupeSubschemas hoists
+const DefaultDedupeMinSize = 128
+
+// sharedDefinitionPrefix names the definitions created by DedupeSubschemas
+const sharedDefinitionPrefix = "_shared_"
+
+// DedupeStats describes what DedupeSubschemas changed
+type DedupeStats struct {
+	// Definitions is the number of shared definitions created
+	Definitions int `json:"definitions"`
+	// Replaced is the number of subschemas replaced by a $ref
+	Replaced int `json:"replaced"`
+	// BytesSaved is how much smaller the compact JSON encoding of the document became
+	BytesSaved int `json:"bytesSaved"`
+}
+
+// DedupeSubschemas hoists subschemas that occur more than once into shared definitions named
+// _shared_1, _shared_2 and so on, replacing each occurrence with a $ref. Only subschemas of at least
+// minSize bytes are considered; 0 uses DefaultDedupeMinSize. A $ref to an identical copy validates
+// exactly like the copy, so the document accepts the same instances as before.
+func DedupeSubschemas(doc *JSONSchema6, minSize int) (DedupeStats, error) {
+	if minSize <= 0 {
+		minSize = DefaultDedupeMinSize
+	}
+
+	d := &deduper{
+		minSize: minSize,
+		counts:  make(map[[sha256.Size]byte]int),
+		shared:  make(map[[sha256.Size]byte]*sharedSchema),
+	}
+
+	// Count occurrences first, then replace every repeated subschema in document order
+	if err := d.forEachSlot(doc, d.count); err != nil {
+		return DedupeStats{}, err
+	}
+	if err := d.forEachSlot(doc, d.replace); err != nil {
+		return DedupeStats{}, err
+	}
+
+	return d.finish(doc)
+}
+
+type deduper struct {
+	minSize int
+	counts  map[[sha256.Size]byte]int
+	shared  map[[sha256.Size]byte]*sharedSchema
+	order   []*sharedSchema
+}
+
+// sharedSchema is a subschema hoisted into a definition, with the $ref nodes that point at it
+type sharedSchema struct {
+	schema *JSONSchema6
+	size   int
+	refs   []*JSONSchema6
+}
+
+// forEachSlot calls fn for every subschema below the root and the top-level definitions, parents first.
+// fn returns whether to descend into the subschema.
+func (d *deduper) forEachSlot(doc *JSONSchema6, fn func(slot **JSONSchema6) (bool, error)) error {
+	for _, name := range sortedKeys(doc.Definitions) {
+		if err := d.children(doc.Definitions[name], fn); err != nil {
+			return err
+		}
+	}
+	return d.children(doc, fn)
+}
+
+func (d *deduper) children(schema *JSONSchema6, fn func(slot **JSONSchema6) (bool, error)) error {
+	for _, name := range sortedKeys(schema.Properties) {
+		property := schema.Properties[name]
+		if err := d.visit(&property, fn); err != nil {
+			return err
+		}
+		schema.Properties[name] = property
+	}
+	if err := d.visit(&schema.Items, fn); err != nil {
+		return err
+	}
+	for i := range schema.AnyOf {
+		if err := d.visit(&schema.AnyOf[i], fn); err != nil {
+			return err
+		}
+	}
+	for i := range schema.OneOf {
+		if err := d.visit(&schema.OneOf[i], fn); err != nil {
+			return err
+		}
+	}
+	for i := range schema.AllOf {
+		if err := d.visit(&schema.AllOf[i], fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *deduper) visit(slot **JSONSchema6, fn func(slot **JSONSchema6) (bool, error)) error {
+	if *slot == nil {
+		return nil
+	}
+	descend, err := fn(slot)
+	if err != nil || !descend {
+		return err
+	}
+	return d.children(*slot, fn)
+}
+
+// key returns the content hash and compact size of a subschema, or false if it is not a candidate
+func (d *deduper) key(schema *JSONSchema6) ([sha256.Size]byte, int, bool, error) {
+	// Nested definitions would change what their refs point at once moved
+	if schema.Definitions != nil {
+		return [sha256.Size]byte{}, 0, false, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return [sha256.Size]byte{}, 0, false, fmt.Errorf("error marshaling subschema: %w", err)
+	}
+	if len(data) < d.minSize {
+		return [sha256.Size]byte{}, 0, false, nil
+	}
+	return sha256.Sum256(data), len(data), true, nil
+}
+
+func (d *deduper) count(slot **JSONSchema6) (bool, error) {
+	key, _, ok, err := d.key(*slot)
+	if err != nil || !ok {
+		return false, err
+	}
+	d.counts[key]++
+	return true, nil
+}
+
+func (d *deduper) replace(slot **JSONSchema6) (bool, error) {
+	key, size, ok, err := d.key(*slot)
+	if err != nil || !ok || d.counts[key] < 2 {
+		return ok, err
+	}
+
+	shared := d.shared[key]
+	descend := false
+	if shared == nil {
+		// The first occurrence moves into the shared definition; its own subschemas are still visited
+		shared = &sharedSchema{schema: *slot, size: size}
+		d.shared[key] = shared
+		d.order = append(d.order, shared)
+		descend = true
+	}
+
+	ref := &JSONSchema6{}
+	shared.refs = append(shared.refs, ref)
+	if descend {
+		if err := d.children(shared.schema, d.replace); err != nil {
+			return false, err
+		}
+	}
+	*slot = ref
+	return false, nil
+}
+
+// finish names the shared definitions and undoes any hoisting that ended up referenced only once,
+// which happens when a repeated subschema only ever occurred inside another repeated subschema
+func (d *deduper) finish(doc *JSONSchema6) (DedupeStats, error) {
+	stats := DedupeStats{}
+	next := 1
+	for _, shared := range d.order {
+		if len(shared.refs) < 2 {
+			*shared.refs[0] = *shared.schema
+			continue
+		}
+
+		name := fmt.Sprintf("%s%d", sharedDefinitionPrefix, next)
+		for doc.Definitions[name] != nil {
+			next++
+			name = fmt.Sprintf("%s%d", sharedDefinitionPrefix, next)
+		}
+		next++
+
+		if doc.Definitions == nil {
+			doc.Definitions = make(map[string]*JSONSchema6)
+		}
+		doc.Definitions[name] = shared.schema
+		for _, ref := range shared.refs {
+			ref.Ref = definitionRef(name)
+		}
+
+		refNode, err := json.Marshal(shared.refs[0])
+		if err != nil {
+			return DedupeStats{}, fmt.Errorf("error marshaling subschema: %w", err)
+		}
+		// Every occurrence shrinks to a $ref, and one copy is added back as the definition entry
+		stats.BytesSaved += len(shared.refs)*(shared.size-len(refNode)) - (shared.size + len(name) + 4)
+		stats.Definitions++
+		stats.Replaced += len(shared.refs)
+	}
+	return stats, nil
+}
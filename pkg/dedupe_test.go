@@ -0,0 +1,191 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const dedupeSDL = `
+type Query {
+  members(first: Int = 10, after: String, role: Role, orderBy: MemberOrder): [User!]!
+  team(id: ID!): Team
+}
+
+type Team {
+  id: ID!
+  members(first: Int = 10, after: String, role: Role, orderBy: MemberOrder): [User!]!
+  admins(first: Int = 10, after: String, role: Role, orderBy: MemberOrder): [User!]!
+}
+
+type User { id: ID! name: String role: Role }
+
+enum Role { ADMIN MEMBER GUEST }
+
+input MemberOrder { field: String! descending: Boolean = false }
+`
+
+// dedupeCorpus pairs payloads with the schema they are validated against, valid or not. The
+// schemas are ones dedupe keeps in place, while the field schemas inside them become $refs.
+var dedupeCorpus = []struct {
+	pointer, instance string
+}{
+	{"/properties/Query", `{"members": {"arguments": {"first": 5, "role": "ADMIN", "orderBy": {"field": "name"}}, "return": []}}`},
+	{"/properties/Query", `{"members": {"arguments": {"first": "5", "role": "OWNER"}}}`},
+	{"/properties/Query", `{"members": {"arguments": {"orderBy": {"descending": "yes"}}}}`},
+	{"/properties/Query", `{"team": {"arguments": {"id": "1"}, "return": null}}`},
+	{"/definitions/Team", `{"members": {"arguments": {"after": "abc", "orderBy": {"field": "id", "descending": true}}}}`},
+	{"/definitions/Team", `{"admins": {"arguments": {"after": 1, "role": null}}}`},
+	{"/definitions/Team", `{"admins": {"return": [{"id": {"return": "1"}, "name": {"return": "a"}, "role": {"return": "GUEST"}}]}}`},
+	{"/definitions/Team", `{"admins": {"return": [{"role": {"return": "OWNER"}}, null]}}`},
+	{"/definitions/Team", `{"id": {"return": "1"}, "members": {"arguments": {"first": 1}, "return": []}}`},
+	{"/definitions/Team", `{"members": {"arguments": {"first": true}, "return": {}}}`},
+}
+
+// instancePaths lists where a payload failed validation, which a $ref to an identical copy must not change
+func instancePaths(errs []pkg.ValidationError) []string {
+	paths := make([]string, 0, len(errs))
+	for _, err := range errs {
+		paths = append(paths, err.InstancePath)
+	}
+	return paths
+}
+
+func TestDedupeSubschemasValidation(t *testing.T) {
+	before := mustConvert(t, dedupeSDL, nil)
+	after := mustConvert(t, dedupeSDL, nil)
+	stats, err := pkg.DedupeSubschemas(after, 0)
+	if err != nil {
+		t.Fatalf("DedupeSubschemas: %v", err)
+	}
+	if stats.Definitions == 0 {
+		t.Fatal("the repeated argument lists were not shared")
+	}
+	if dangling := danglingRefs(after); len(dangling) > 0 {
+		t.Errorf("dangling refs: %q", dangling)
+	}
+
+	valid := 0
+	for _, payload := range dedupeCorpus {
+		want := instancePaths(validateJSON(t, before, mustPointer(t, before, payload.pointer), payload.instance))
+		got := instancePaths(validateJSON(t, after, mustPointer(t, after, payload.pointer), payload.instance))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s against %s: errors at %q after dedupe, %q before", payload.instance, payload.pointer, got, want)
+		}
+		if len(want) == 0 {
+			valid++
+		}
+	}
+	if valid == 0 || valid == len(dedupeCorpus) {
+		t.Errorf("%d of %d payloads are valid; the corpus should have both", valid, len(dedupeCorpus))
+	}
+}
+
+// dedupeDoc has a large subschema repeated three times, a smaller one repeated twice, and a
+// subschema that only repeats as part of the large one
+func dedupeDoc() *pkg.JSONSchema6 {
+	inner := func() *pkg.JSONSchema6 {
+		return &pkg.JSONSchema6{Type: "string", Description: strings.Repeat("i", 40)}
+	}
+	large := func() *pkg.JSONSchema6 {
+		return &pkg.JSONSchema6{
+			Type:        "object",
+			Description: strings.Repeat("l", 100),
+			Properties:  map[string]*pkg.JSONSchema6{"a": inner(), "b": {Type: "boolean"}},
+		}
+	}
+	small := func() *pkg.JSONSchema6 {
+		return &pkg.JSONSchema6{Type: "integer", Description: strings.Repeat("s", 60)}
+	}
+	return &pkg.JSONSchema6{
+		Definitions: map[string]*pkg.JSONSchema6{
+			"A": {Type: "object", Properties: map[string]*pkg.JSONSchema6{"x": large(), "y": small()}},
+			"B": {Type: "object", Properties: map[string]*pkg.JSONSchema6{"x": large(), "y": small()}},
+			"C": {Type: "object", Properties: map[string]*pkg.JSONSchema6{"x": large()}},
+		},
+	}
+}
+
+func TestDedupeSubschemasStats(t *testing.T) {
+	size := func(schema *pkg.JSONSchema6) int {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(data)
+	}
+	doc := dedupeDoc()
+	largeSize := size(doc.Definitions["A"].Properties["x"])
+	smallSize := size(doc.Definitions["A"].Properties["y"])
+	innerSize := size(doc.Definitions["A"].Properties["x"].Properties["a"])
+	if !(innerSize < smallSize && smallSize < largeSize) {
+		t.Fatalf("fixture sizes out of order: inner %d, small %d, large %d", innerSize, smallSize, largeSize)
+	}
+
+	tests := []struct {
+		name    string
+		minSize int
+		want    pkg.DedupeStats
+		shared  []string // the definitions the properties of A point at, in property order
+	}{
+		// inner only repeats with the large subschema, which is hoisted as a whole, so it stays inline
+		{name: "all", minSize: innerSize, want: pkg.DedupeStats{Definitions: 2, Replaced: 5},
+			shared: []string{"#/definitions/_shared_1", "#/definitions/_shared_2"}},
+		{name: "minimum is inclusive", minSize: smallSize, want: pkg.DedupeStats{Definitions: 2, Replaced: 5},
+			shared: []string{"#/definitions/_shared_1", "#/definitions/_shared_2"}},
+		{name: "small ones below the minimum", minSize: smallSize + 1, want: pkg.DedupeStats{Definitions: 1, Replaced: 3},
+			shared: []string{"#/definitions/_shared_1", ""}},
+		{name: "nothing large enough", minSize: largeSize + 1, shared: []string{"", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := dedupeDoc()
+			before := size(doc)
+			stats, err := pkg.DedupeSubschemas(doc, tt.minSize)
+			if err != nil {
+				t.Fatalf("DedupeSubschemas: %v", err)
+			}
+			tt.want.BytesSaved = before - size(doc)
+			if stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", stats, tt.want)
+			}
+			a := doc.Definitions["A"].Properties
+			if refs := []string{a["x"].Ref, a["y"].Ref}; !reflect.DeepEqual(refs, tt.shared) {
+				t.Errorf("A.x and A.y refer to %q, want %q", refs, tt.shared)
+			}
+			if dangling := danglingRefs(doc); len(dangling) > 0 {
+				t.Errorf("dangling refs: %q", dangling)
+			}
+		})
+	}
+}
+
+func TestDedupeSubschemasDefaultMinSize(t *testing.T) {
+	empty, err := json.Marshal(&pkg.JSONSchema6{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// length makes the repeated subschema exactly DefaultDedupeMinSize bytes once marshaled
+	length := pkg.DefaultDedupeMinSize - len(empty) - len(`,"description":""`)
+	for _, tt := range []struct {
+		length      int
+		definitions int
+	}{{length - 1, 0}, {length, 1}} {
+		doc := &pkg.JSONSchema6{Definitions: map[string]*pkg.JSONSchema6{}}
+		for _, name := range []string{"A", "B"} {
+			doc.Definitions[name] = &pkg.JSONSchema6{Properties: map[string]*pkg.JSONSchema6{
+				"x": {Description: strings.Repeat("d", tt.length)},
+			}}
+		}
+		stats, err := pkg.DedupeSubschemas(doc, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Definitions != tt.definitions {
+			t.Errorf("%d byte subschema: %d definitions, want %d", pkg.DefaultDedupeMinSize-length+tt.length, stats.Definitions, tt.definitions)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind describes whether something was added, removed or changed
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// ChangeCategory describes which part of a schema a change affects
+type ChangeCategory string
+
+const (
+	CategoryDefinition  ChangeCategory = "definition"
+	CategoryProperty    ChangeCategory = "property"
+	CategoryType        ChangeCategory = "type"
+	CategoryRef         ChangeCategory = "ref"
+	CategoryRequired    ChangeCategory = "required"
+	CategoryEnum        ChangeCategory = "enum"
+	CategoryVariant     ChangeCategory = "variant"
+	CategoryItems       ChangeCategory = "items"
+	CategoryDefault     ChangeCategory = "default"
+	CategoryDescription ChangeCategory = "description"
+)
+
+// SchemaChange is a single structural difference between two schemas
+type SchemaChange struct {
+	Kind     ChangeKind     `json:"kind"`
+	Category ChangeCategory `json:"category"`
+	Path     string         `json:"path"`
+	Name     string         `json:"name,omitempty"`
+	Old      interface{}    `json:"old,omitempty"`
+	New      interface{}    `json:"new,omitempty"`
+}
+
+// DiffOptions controls which differences DiffSchemas reports
+type DiffOptions struct {
+	IgnoreDescriptions bool `json:"ignoreDescriptions"`
+}
+
+// DiffSchemas compares two schema documents structurally
+func DiffSchemas(oldSchema, newSchema *JSONSchema6, opts DiffOptions) []SchemaChange {
+	d := &differ{opts: opts, changes: make([]SchemaChange, 0)}
+	d.diff(oldSchema, newSchema, "")
+	return d.changes
+}
+
+type differ struct {
+	opts    DiffOptions
+	changes []SchemaChange
+}
+
+func (d *differ) add(kind ChangeKind, category ChangeCategory, path, name string, oldValue, newValue interface{}) {
+	d.changes = append(d.changes, SchemaChange{
+		Kind:     kind,
+		Category: category,
+		Path:     path,
+		Name:     name,
+		Old:      oldValue,
+		New:      newValue,
+	})
+}
+
+func (d *differ) diff(oldSchema, newSchema *JSONSchema6, path string) {
+	if oldSchema == nil || newSchema == nil {
+		return
+	}
+
+	d.diffMap(oldSchema.Definitions, newSchema.Definitions, path+"/definitions", CategoryDefinition)
+	d.diffMap(oldSchema.Properties, newSchema.Properties, path+"/properties", CategoryProperty)
+
+	oldTypes, newTypes := schemaTypes(oldSchema.Type), schemaTypes(newSchema.Type)
+	if !reflect.DeepEqual(oldTypes, newTypes) {
+		d.add(ChangeChanged, CategoryType, path, "", oldSchema.Type, newSchema.Type)
+	}
+
+	if oldSchema.Ref != newSchema.Ref {
+		d.add(ChangeChanged, CategoryRef, path, "", oldSchema.Ref, newSchema.Ref)
+	}
+
+	d.diffSet(oldSchema.Required, newSchema.Required, path, CategoryRequired)
+	d.diffSet(enumValues(oldSchema), enumValues(newSchema), path, CategoryEnum)
+	d.diffSet(refVariants(oldSchema), refVariants(newSchema), path, CategoryVariant)
+
+	oldBranches, newBranches := structuralBranches(oldSchema.AnyOf), structuralBranches(newSchema.AnyOf)
+	for i := 0; i < len(oldBranches) && i < len(newBranches); i++ {
+		d.diff(oldBranches[i], newBranches[i], fmt.Sprintf("%s/anyOf/%d", path, i))
+	}
+
+	switch {
+	case oldSchema.Items == nil && newSchema.Items != nil:
+		d.add(ChangeAdded, CategoryItems, path+"/items", "", nil, nil)
+	case oldSchema.Items != nil && newSchema.Items == nil:
+		d.add(ChangeRemoved, CategoryItems, path+"/items", "", nil, nil)
+	default:
+		d.diff(oldSchema.Items, newSchema.Items, path+"/items")
+	}
+
+	if !reflect.DeepEqual(oldSchema.Default, newSchema.Default) {
+		d.add(ChangeChanged, CategoryDefault, path, "", oldSchema.Default, newSchema.Default)
+	}
+
+	if !d.opts.IgnoreDescriptions && oldSchema.Description != newSchema.Description {
+		d.add(ChangeChanged, CategoryDescription, path, "", oldSchema.Description, newSchema.Description)
+	}
+}
+
+func (d *differ) diffMap(oldMap, newMap map[string]*JSONSchema6, path string, category ChangeCategory) {
+	for _, name := range sortedKeys(oldMap) {
+		if _, ok := newMap[name]; !ok {
+			d.add(ChangeRemoved, category, path+"/"+escapePointerSegment(name), name, nil, nil)
+		}
+	}
+	for _, name := range sortedKeys(newMap) {
+		if _, ok := oldMap[name]; !ok {
+			d.add(ChangeAdded, category, path+"/"+escapePointerSegment(name), name, nil, nil)
+		}
+	}
+	for _, name := range sortedKeys(oldMap) {
+		if newValue, ok := newMap[name]; ok {
+			d.diff(oldMap[name], newValue, path+"/"+escapePointerSegment(name))
+		}
+	}
+}
+
+func (d *differ) diffSet(oldValues, newValues []string, path string, category ChangeCategory) {
+	oldSet := make(map[string]bool, len(oldValues))
+	for _, value := range oldValues {
+		oldSet[value] = true
+	}
+	newSet := make(map[string]bool, len(newValues))
+	for _, value := range newValues {
+		newSet[value] = true
+	}
+
+	for _, value := range sortedSet(oldSet) {
+		if !newSet[value] {
+			d.add(ChangeRemoved, category, path, value, nil, nil)
+		}
+	}
+	for _, value := range sortedSet(newSet) {
+		if !oldSet[value] {
+			d.add(ChangeAdded, category, path, value, nil, nil)
+		}
+	}
+}
+
+// enumValues collects enum values from the enum keyword and from anyOf single-value enum branches
+func enumValues(schema *JSONSchema6) []string {
+	values := append([]string{}, schema.Enum...)
+	for _, branch := range schema.AnyOf {
+		values = append(values, branch.Enum...)
+	}
+	return values
+}
+
+// refVariants collects the $ref-only branches of anyOf and oneOf, e.g. union members
+func refVariants(schema *JSONSchema6) []string {
+	refs := make([]string, 0)
+	for _, branches := range [][]*JSONSchema6{schema.AnyOf, schema.OneOf} {
+		for _, branch := range branches {
+			if branch.Ref != "" {
+				refs = append(refs, branch.Ref)
+			}
+		}
+	}
+	return refs
+}
+
+// structuralBranches returns the anyOf branches that are neither enum values nor plain refs
+func structuralBranches(branches []*JSONSchema6) []*JSONSchema6 {
+	result := make([]*JSONSchema6, 0)
+	for _, branch := range branches {
+		if branch.Ref == "" && len(branch.Enum) == 0 {
+			result = append(result, branch)
+		}
+	}
+	return result
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PatchOperation is a single RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatch computes an RFC 6902 patch transforming oldSchema into newSchema
+func JSONPatch(oldSchema, newSchema *JSONSchema6, opts DiffOptions) ([]PatchOperation, error) {
+	oldDoc, err := toGeneric(oldSchema, opts)
+	if err != nil {
+		return nil, err
+	}
+	newDoc, err := toGeneric(newSchema, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]PatchOperation, 0)
+	diffGeneric(oldDoc, newDoc, "", &ops)
+	return ops, nil
+}
+
+// toGeneric converts a schema into plain JSON values, optionally stripping descriptions
+func toGeneric(schema *JSONSchema6, opts DiffOptions) (interface{}, error) {
+	if opts.IgnoreDescriptions {
+		schema = cloneSchema(schema)
+		Walk(schema, func(s *JSONSchema6) {
+			s.Description = ""
+			s.Title = ""
+		})
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling schema: %w", err)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error unmarshaling schema: %w", err)
+	}
+	return doc, nil
+}
+
+func diffGeneric(oldValue, newValue interface{}, path string, ops *[]PatchOperation) {
+	oldObject, oldIsObject := oldValue.(map[string]interface{})
+	newObject, newIsObject := newValue.(map[string]interface{})
+	if !oldIsObject || !newIsObject {
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*ops = append(*ops, PatchOperation{Op: "replace", Path: path, Value: newValue})
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(oldObject)+len(newObject))
+	for key := range oldObject {
+		keys = append(keys, key)
+	}
+	for key := range newObject {
+		if _, ok := oldObject[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + escapePointerSegment(key)
+		oldChild, inOld := oldObject[key]
+		newChild, inNew := newObject[key]
+		switch {
+		case inOld && !inNew:
+			*ops = append(*ops, PatchOperation{Op: "remove", Path: childPath})
+		case !inOld && inNew:
+			*ops = append(*ops, PatchOperation{Op: "add", Path: childPath, Value: newChild})
+		default:
+			diffGeneric(oldChild, newChild, childPath, ops)
+		}
+	}
+}
@@ -0,0 +1,856 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a 1-based line and column in a GraphQL document
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Document is a parsed executable GraphQL document: operations and fragments
+type Document struct {
+	Operations []*OperationDefinition
+	Fragments  []*FragmentDefinition
+}
+
+// OperationDefinition is a query, mutation or subscription in a document
+type OperationDefinition struct {
+	Operation    OperationType
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+	Position     Position
+}
+
+// VariableDefinition is an operation variable; Type is kept in GraphQL syntax, e.g. [ID!]!
+type VariableDefinition struct {
+	Name         string
+	Type         string
+	DefaultValue *Value
+	Position     Position
+}
+
+// FragmentDefinition is a named fragment
+type FragmentDefinition struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Position      Position
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface {
+	Pos() Position
+}
+
+// Field is a field selection
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Position     Position
+}
+
+// ResponseKey returns the key the field appears under in the response: its alias, or its name
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread is a ...FragmentName selection
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Position   Position
+}
+
+// InlineFragment is a ... on Type { } selection; TypeCondition is empty when omitted
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Position      Position
+}
+
+func (f *Field) Pos() Position          { return f.Position }
+func (f *FragmentSpread) Pos() Position { return f.Position }
+func (f *InlineFragment) Pos() Position { return f.Position }
+
+// Argument is a name: value pair in arguments, directives and object values
+type Argument struct {
+	Name     string
+	Value    *Value
+	Position Position
+}
+
+// Directive is an @name(args) annotation
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Position  Position
+}
+
+// ValueKind is the kind of a GraphQL input value
+type ValueKind string
+
+const (
+	ValueVariable ValueKind = "variable"
+	ValueInt      ValueKind = "int"
+	ValueFloat    ValueKind = "float"
+	ValueString   ValueKind = "string"
+	ValueBoolean  ValueKind = "boolean"
+	ValueNull     ValueKind = "null"
+	ValueEnum     ValueKind = "enum"
+	ValueList     ValueKind = "list"
+	ValueObject   ValueKind = "object"
+)
+
+// Value is a GraphQL input value. Raw holds the variable name, the literal text or the decoded string.
+type Value struct {
+	Kind     ValueKind
+	Raw      string
+	List     []*Value
+	Fields   []*Argument
+	Position Position
+}
+
+// Operation returns the operation with the given name, or the only operation when name is empty
+func (d *Document) Operation(name string) (*OperationDefinition, error) {
+	if name == "" {
+		if len(d.Operations) != 1 {
+			return nil, fmt.Errorf("document contains %d operations, an operation name is required", len(d.Operations))
+		}
+		return d.Operations[0], nil
+	}
+
+	names := make([]string, 0, len(d.Operations))
+	for _, op := range d.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+		names = append(names, op.Name)
+	}
+	return nil, notFound("operation", name, names)
+}
+
+// Fragment returns the fragment with the given name, or nil
+func (d *Document) Fragment(name string) *FragmentDefinition {
+	for _, fragment := range d.Fragments {
+		if fragment.Name == name {
+			return fragment
+		}
+	}
+	return nil
+}
+
+// ParseDocument parses an executable GraphQL document
+func ParseDocument(source string) (*Document, error) {
+	p := &documentParser{lexer: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p.parseDocument()
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	position Position
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return t.value
+}
+
+type lexer struct {
+	source string
+	offset int
+	line   int
+	column int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: strings.TrimPrefix(source, "\ufeff"), line: 1, column: 1}
+}
+
+func (l *lexer) errorf(position Position, format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at %s: %s", position, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) peekByte(ahead int) byte {
+	if l.offset+ahead < len(l.source) {
+		return l.source[l.offset+ahead]
+	}
+	return 0
+}
+
+func (l *lexer) consume() rune {
+	r, size := utf8.DecodeRuneInString(l.source[l.offset:])
+	l.offset += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+// skipIgnored skips whitespace, commas, line terminators and comments
+func (l *lexer) skipIgnored() {
+	for l.offset < len(l.source) {
+		switch c := l.source[l.offset]; {
+		case c == ' ' || c == '\t' || c == ',' || c == '\n' || c == '\r':
+			l.consume()
+		case c == '#':
+			for l.offset < len(l.source) && l.source[l.offset] != '\n' {
+				l.consume()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	position := Position{Line: l.line, Column: l.column}
+	if l.offset >= len(l.source) {
+		return token{kind: tokenEOF, position: position}, nil
+	}
+
+	c := l.source[l.offset]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.consume()
+		return token{kind: tokenPunctuator, value: string(c), position: position}, nil
+	case c == '.':
+		if l.peekByte(1) != '.' || l.peekByte(2) != '.' {
+			return token{}, l.errorf(position, "unexpected character %q", c)
+		}
+		l.consume()
+		l.consume()
+		l.consume()
+		return token{kind: tokenPunctuator, value: "...", position: position}, nil
+	case isNameStart(c):
+		start := l.offset
+		for l.offset < len(l.source) && isNameContinue(l.source[l.offset]) {
+			l.consume()
+		}
+		return token{kind: tokenName, value: l.source[start:l.offset], position: position}, nil
+	case c == '-' || isDigit(c):
+		return l.readNumber(position)
+	case c == '"':
+		if l.peekByte(1) == '"' && l.peekByte(2) == '"' {
+			return l.readBlockString(position)
+		}
+		return l.readString(position)
+	}
+	return token{}, l.errorf(position, "unexpected character %q", c)
+}
+
+func (l *lexer) readNumber(position Position) (token, error) {
+	start := l.offset
+	kind := tokenInt
+	if l.source[l.offset] == '-' {
+		l.consume()
+	}
+	if !isDigit(l.peekByte(0)) {
+		return token{}, l.errorf(position, "invalid number")
+	}
+	for isDigit(l.peekByte(0)) {
+		l.consume()
+	}
+	if l.peekByte(0) == '.' {
+		kind = tokenFloat
+		l.consume()
+		if !isDigit(l.peekByte(0)) {
+			return token{}, l.errorf(position, "invalid number")
+		}
+		for isDigit(l.peekByte(0)) {
+			l.consume()
+		}
+	}
+	if c := l.peekByte(0); c == 'e' || c == 'E' {
+		kind = tokenFloat
+		l.consume()
+		if c := l.peekByte(0); c == '+' || c == '-' {
+			l.consume()
+		}
+		if !isDigit(l.peekByte(0)) {
+			return token{}, l.errorf(position, "invalid number")
+		}
+		for isDigit(l.peekByte(0)) {
+			l.consume()
+		}
+	}
+	return token{kind: kind, value: l.source[start:l.offset], position: position}, nil
+}
+
+func (l *lexer) readString(position Position) (token, error) {
+	l.consume()
+	var b strings.Builder
+	for {
+		if l.offset >= len(l.source) || l.source[l.offset] == '\n' {
+			return token{}, l.errorf(position, "unterminated string")
+		}
+		r := l.consume()
+		switch r {
+		case '"':
+			return token{kind: tokenString, value: b.String(), position: position}, nil
+		case '\\':
+			escape := l.consume()
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteRune(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.offset+4 > len(l.source) {
+					return token{}, l.errorf(position, "invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(l.source[l.offset:l.offset+4], 16, 32)
+				if err != nil {
+					return token{}, l.errorf(position, "invalid unicode escape")
+				}
+				for i := 0; i < 4; i++ {
+					l.consume()
+				}
+				b.WriteRune(rune(code))
+			default:
+				return token{}, l.errorf(position, "invalid escape sequence \\%c", escape)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func (l *lexer) readBlockString(position Position) (token, error) {
+	l.consume()
+	l.consume()
+	l.consume()
+	var b strings.Builder
+	for {
+		if l.offset >= len(l.source) {
+			return token{}, l.errorf(position, "unterminated block string")
+		}
+		if strings.HasPrefix(l.source[l.offset:], `\"""`) {
+			b.WriteString(`"""`)
+			for i := 0; i < 4; i++ {
+				l.consume()
+			}
+			continue
+		}
+		if strings.HasPrefix(l.source[l.offset:], `"""`) {
+			l.consume()
+			l.consume()
+			l.consume()
+			return token{kind: tokenString, value: blockStringValue(b.String()), position: position}, nil
+		}
+		b.WriteRune(l.consume())
+	}
+}
+
+// blockStringValue removes the indentation common to all lines but the first and the blank lines
+// around a block string, as the spec's BlockStringValue does, so indented descriptions read as written
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(raw), "\n")
+	common := -1
+	for _, line := range lines[1:] {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			lines[i] = lines[i][min(common, len(lines[i])):]
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type documentParser struct {
+	lexer *lexer
+	token token
+}
+
+func (p *documentParser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *documentParser) errorf(format string, args ...interface{}) error {
+	return p.lexer.errorf(p.token.position, format, args...)
+}
+
+func (p *documentParser) peek(value string) bool {
+	return (p.token.kind == tokenPunctuator || p.token.kind == tokenName) && p.token.value == value
+}
+
+// skip consumes the current token if it is value
+func (p *documentParser) skip(value string) (bool, error) {
+	if !p.peek(value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *documentParser) expect(value string) error {
+	if !p.peek(value) {
+		return p.errorf("expected %q, found %s", value, p.token)
+	}
+	return p.advance()
+}
+
+func (p *documentParser) expectName() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.errorf("expected name, found %s", p.token)
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *documentParser) parseDocument() (*Document, error) {
+	doc := &Document{}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek("{") || p.peek("query") || p.peek("mutation") || p.peek("subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek("fragment"):
+			fragment, err := p.parseFragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			doc.Fragments = append(doc.Fragments, fragment)
+		default:
+			return nil, p.errorf("expected operation or fragment, found %s", p.token)
+		}
+	}
+	if len(doc.Operations) == 0 && len(doc.Fragments) == 0 {
+		return nil, fmt.Errorf("document contains no operations")
+	}
+	return doc, nil
+}
+
+func (p *documentParser) parseOperation() (*OperationDefinition, error) {
+	op := &OperationDefinition{Operation: OperationQuery, Position: p.token.position}
+
+	if !p.peek("{") {
+		op.Operation = OperationType(p.token.value)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.token.kind == tokenName {
+			op.Name = p.token.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.peek("(") {
+			variables, err := p.parseVariableDefinitions()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = variables
+		}
+
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		op.Directives = directives
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.SelectionSet = selections
+	return op, nil
+}
+
+func (p *documentParser) parseVariableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	variables := make([]*VariableDefinition, 0)
+	for !p.peek(")") {
+		variable := &VariableDefinition{Position: p.token.position}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		variable.Name = name
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if variable.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if variable.DefaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+
+		// Variable directives carry no meaning for schema generation
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		variables = append(variables, variable)
+	}
+	return variables, p.advance()
+}
+
+func (p *documentParser) parseType() (string, error) {
+	var t string
+	if ok, err := p.skip("["); err != nil {
+		return "", err
+	} else if ok {
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		t = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		t = name
+	}
+
+	if ok, err := p.skip("!"); err != nil {
+		return "", err
+	} else if ok {
+		t += "!"
+	}
+	return t, nil
+}
+
+func (p *documentParser) parseFragmentDefinition() (*FragmentDefinition, error) {
+	fragment := &FragmentDefinition{Position: p.token.position}
+	if err := p.expect("fragment"); err != nil {
+		return nil, err
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.lexer.errorf(fragment.Position, "fragment cannot be named \"on\"")
+	}
+	fragment.Name = name
+
+	if err := p.expect("on"); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.expectName(); err != nil {
+		return nil, err
+	}
+	if fragment.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *documentParser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	selections := make([]Selection, 0)
+	for !p.peek("}") {
+		if p.token.kind == tokenEOF {
+			return nil, p.errorf("expected \"}\", found %s", p.token)
+		}
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, p.errorf("selection set cannot be empty")
+	}
+	return selections, p.advance()
+}
+
+func (p *documentParser) parseSelection() (Selection, error) {
+	position := p.token.position
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		return p.parseFragment(position)
+	}
+
+	field := &Field{Position: position}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = name
+		if name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+
+	if p.peek("(") {
+		if field.Arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if field.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *documentParser) parseFragment(position Position) (Selection, error) {
+	if p.token.kind == tokenName && p.token.value != "on" {
+		spread := &FragmentSpread{Name: p.token.value, Position: position}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		spread.Directives = directives
+		return spread, nil
+	}
+
+	fragment := &InlineFragment{Position: position}
+	var err error
+	if ok, err := p.skip("on"); err != nil {
+		return nil, err
+	} else if ok {
+		if fragment.TypeCondition, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	if fragment.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *documentParser) parseArguments(constant bool) ([]*Argument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	arguments := make([]*Argument, 0)
+	for !p.peek(")") {
+		argument := &Argument{Position: p.token.position}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		argument.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if argument.Value, err = p.parseValue(constant); err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+	return arguments, p.advance()
+}
+
+func (p *documentParser) parseDirectives() ([]*Directive, error) {
+	directives := make([]*Directive, 0)
+	for p.peek("@") {
+		directive := &Directive{Position: p.token.position}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		directive.Name = name
+		if p.peek("(") {
+			if directive.Arguments, err = p.parseArguments(false); err != nil {
+				return nil, err
+			}
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+func (p *documentParser) parseValue(constant bool) (*Value, error) {
+	value := &Value{Position: p.token.position, Raw: p.token.value}
+
+	switch p.token.kind {
+	case tokenInt:
+		value.Kind = ValueInt
+	case tokenFloat:
+		value.Kind = ValueFloat
+	case tokenString:
+		value.Kind = ValueString
+	case tokenName:
+		switch p.token.value {
+		case "true", "false":
+			value.Kind = ValueBoolean
+		case "null":
+			value.Kind = ValueNull
+		default:
+			value.Kind = ValueEnum
+		}
+	case tokenPunctuator:
+		switch p.token.value {
+		case "$":
+			if constant {
+				return nil, p.errorf("variables are not allowed here")
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			value.Kind, value.Raw = ValueVariable, name
+			return value, nil
+		case "[":
+			value.Kind, value.Raw = ValueList, ""
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek("]") {
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				value.List = append(value.List, item)
+			}
+			return value, p.advance()
+		case "{":
+			value.Kind, value.Raw = ValueObject, ""
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek("}") {
+				field := &Argument{Position: p.token.position}
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				field.Name = name
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if field.Value, err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+				value.Fields = append(value.Fields, field)
+			}
+			return value, p.advance()
+		}
+		return nil, p.errorf("expected value, found %s", p.token)
+	default:
+		return nil, p.errorf("expected value, found %s", p.token)
+	}
+
+	return value, p.advance()
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Draft selects the JSON Schema dialect of generated documents
+type Draft string
+
+const (
+	// Draft06 is JSON Schema draft-06, the default
+	Draft06 Draft = "draft-06"
+	// Draft07 is JSON Schema draft-07. It adds keywords such as $comment, if/then/else and readOnly
+	// but keeps the meaning of every draft-06 keyword the conversion emits, so documents only differ
+	// in their $schema.
+	Draft07 Draft = "draft-07"
+	// Draft202012 is JSON Schema 2020-12. Definitions move from definitions to $defs, and
+	// references to them follow, see SetDraft.
+	Draft202012 Draft = "2020-12"
+)
+
+// defsRefPrefix starts references to the definitions of 2020-12 documents
+const defsRefPrefix = "#/$defs/"
+
+// draftURIs maps each draft to the $schema of its documents
+var draftURIs = map[Draft]string{
+	Draft06:     "http://json-schema.org/draft-06/schema#",
+	Draft07:     "http://json-schema.org/draft-07/schema#",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// IsValidDraft checks if the provided Draft is valid; empty means draft-06
+func IsValidDraft(draft Draft) bool {
+	_, ok := draftURIs[draft]
+	return ok || draft == ""
+}
+
+// checkDraft rejects drafts the conversion does not know
+func checkDraft(draft Draft) error {
+	if !IsValidDraft(draft) {
+		return fmt.Errorf("invalid draft: %s (must be 'draft-06', 'draft-07' or '2020-12')", draft)
+	}
+	return nil
+}
+
+// schemaURI returns the $schema of documents of the draft
+func (d Draft) schemaURI() string {
+	if d == "" {
+		d = Draft06
+	}
+	return draftURIs[d]
+}
+
+// name returns the draft, with empty meaning draft-06
+func (d Draft) name() string {
+	if d == "" {
+		return string(Draft06)
+	}
+	return string(d)
+}
+
+// SetDraft sets the $schema of a document to the draft and lays its definitions out as the draft
+// expects: under $defs for 2020-12 and under definitions otherwise, with every reference to them
+// rewritten to match.
+func SetDraft(schema *JSONSchema6, draft Draft) {
+	UseDefinitions(schema)
+	schema.Schema = draft.schemaURI()
+	if draft != Draft202012 {
+		return
+	}
+	schema.Defs, schema.Definitions = schema.Definitions, nil
+	rewriteDefinitionRefs(schema, definitionsRefPrefix, defsRefPrefix)
+}
+
+// UseDefinitions moves the $defs of a 2020-12 document back under definitions, rewriting the
+// references to them, which is the layout the rest of this package works on. Its $schema is kept.
+func UseDefinitions(schema *JSONSchema6) {
+	if schema == nil || schema.Defs == nil {
+		return
+	}
+	if schema.Definitions == nil {
+		schema.Definitions = make(map[string]*JSONSchema6, len(schema.Defs))
+	}
+	for name, definition := range schema.Defs {
+		schema.Definitions[name] = definition
+	}
+	schema.Defs = nil
+	rewriteDefinitionRefs(schema, defsRefPrefix, definitionsRefPrefix)
+}
+
+// RestoreDraftLayout lays a document out for the draft its $schema names, undoing UseDefinitions
+func RestoreDraftLayout(schema *JSONSchema6) {
+	if schema != nil && schema.Schema == Draft202012.schemaURI() {
+		SetDraft(schema, Draft202012)
+	}
+}
+
+// rewriteDefinitionRefs replaces the prefix of every reference to a definition
+func rewriteDefinitionRefs(schema *JSONSchema6, from, to string) {
+	Walk(schema, func(s *JSONSchema6) {
+		if name, ok := strings.CutPrefix(s.Ref, from); ok {
+			s.Ref = to + name
+		}
+	})
+}
+
+// finishDocument applies the options that depend on the draft to a converted document: closing
+// composed schemas with Options.ClosedComposition and the layout of the draft
+func finishDocument(schema *JSONSchema6, opts *Options) {
+	if opts.ClosedComposition {
+		closeComposition(schema)
+	}
+	SetDraft(schema, opts.Draft)
+}
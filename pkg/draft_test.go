@@ -0,0 +1,165 @@
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const draftSDL = `
+type Query {
+  user(id: ID!): User
+  search(term: String!): [SearchResult!]!
+}
+
+union SearchResult = User | Post
+
+enum Role { ADMIN MEMBER }
+
+type User {
+  id: ID!
+  role: Role
+  friends: [User!]
+}
+
+type Post {
+  title: String!
+  author: User
+}
+`
+
+var drafts = []pkg.Draft{pkg.Draft06, pkg.Draft07, pkg.Draft202012}
+
+// compileDraft compiles a generated document, which checks it against the metaschema its $schema
+// names
+func compileDraft(t *testing.T, schema *pkg.JSONSchema6) *jsonschema.Schema {
+	t.Helper()
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(mustJSON(t, schema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Subschemas carry an empty $schema, which the compiler would try to load as a dialect
+	dropEmptySchemas(doc)
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("https://example.com/schema.json", doc); err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := compiler.Compile("https://example.com/schema.json")
+	if err != nil {
+		t.Fatalf("document does not validate against its metaschema: %v", err)
+	}
+	return compiled
+}
+
+// dropEmptySchemas removes the empty $schema keywords of a decoded document
+func dropEmptySchemas(node any) {
+	switch node := node.(type) {
+	case map[string]any:
+		if node["$schema"] == "" {
+			delete(node, "$schema")
+		}
+		for _, child := range node {
+			dropEmptySchemas(child)
+		}
+	case []any:
+		for _, child := range node {
+			dropEmptySchemas(child)
+		}
+	}
+}
+
+func TestDraftGolden(t *testing.T) {
+	for _, draft := range drafts {
+		t.Run(string(draft), func(t *testing.T) {
+			schema := mustConvert(t, draftSDL, options(func(o *pkg.Options) { o.Draft = draft }))
+			got, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "draft/"+string(draft)+".json", append(got, '\n'))
+		})
+	}
+}
+
+func TestDraftMetaschema(t *testing.T) {
+	const (
+		user    = `{"id": {"return": "1"}, "role": {"return": "ADMIN"}, "friends": {"return": [{"id": {"return": "2"}}]}}`
+		post    = `{"title": {"return": "Hello"}, "author": {"return": {"id": {"return": "1"}}}}`
+		valid   = `{"Query": {"search": {"return": [` + user + `, ` + post + `]}}}`
+		invalid = `{"Query": {"search": {"return": [{"id": {"return": "1"}, "role": {"return": "OWNER"}}]}}}`
+	)
+	for _, draft := range drafts {
+		t.Run(string(draft), func(t *testing.T) {
+			compiled := compileDraft(t, mustConvert(t, draftSDL, options(func(o *pkg.Options) { o.Draft = draft })))
+			for instance, wantValid := range map[string]bool{valid: true, invalid: false} {
+				decoded, err := jsonschema.UnmarshalJSON(strings.NewReader(instance))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := compiled.Validate(decoded); (err == nil) != wantValid {
+					t.Errorf("%s: valid = %t, want %t (%v)", instance, err == nil, wantValid, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDraft202012Layout(t *testing.T) {
+	schema := mustConvert(t, draftSDL, options(func(o *pkg.Options) { o.Draft = pkg.Draft202012 }))
+	if schema.Definitions != nil {
+		t.Errorf("2020-12 document kept definitions: %v", definitionNames(schema))
+	}
+	if len(schema.Defs) == 0 {
+		t.Fatal("2020-12 document has no $defs")
+	}
+
+	var refs []string
+	pkg.Walk(schema, func(s *pkg.JSONSchema6) {
+		if s.Ref != "" {
+			refs = append(refs, s.Ref)
+		}
+	})
+	if len(refs) == 0 {
+		t.Fatal("document has no references")
+	}
+	for _, ref := range refs {
+		name, ok := strings.CutPrefix(ref, "#/$defs/")
+		if !ok || schema.Defs[name] == nil {
+			t.Errorf("reference %s does not point into $defs", ref)
+		}
+	}
+
+	// The union branches are generated references too
+	union := schema.Defs["SearchResult"]
+	if union == nil || len(union.OneOf) != 2 || union.OneOf[0].Ref != "#/$defs/User" || union.OneOf[1].Ref != "#/$defs/Post" {
+		t.Errorf("union branches do not reference $defs: %+v", union)
+	}
+}
+
+func TestSetDraftRoundTrip(t *testing.T) {
+	original := mustConvert(t, draftSDL, nil)
+	schema := mustConvert(t, draftSDL, nil)
+
+	pkg.SetDraft(schema, pkg.Draft202012)
+	if schema.Schema != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("$schema = %s", schema.Schema)
+	}
+	pkg.UseDefinitions(schema)
+	if schema.Defs != nil || len(danglingRefs(schema)) != 0 {
+		t.Errorf("UseDefinitions left $defs or dangling refs: %v", danglingRefs(schema))
+	}
+	pkg.RestoreDraftLayout(schema)
+	if schema.Definitions != nil || len(schema.Defs) != len(original.Definitions) {
+		t.Errorf("RestoreDraftLayout did not move %d definitions back to $defs", len(original.Definitions))
+	}
+
+	pkg.SetDraft(schema, pkg.Draft06)
+	schema.Schema = original.Schema
+	if !bytes.Equal(mustJSON(t, schema), mustJSON(t, original)) {
+		t.Error("converting to 2020-12 and back changed the document")
+	}
+}
@@ -0,0 +1,125 @@
+package pkg
+
+import "fmt"
+
+// Definitions added by ResponseEnvelope. GraphQL reserves names starting with __, so they cannot
+// clash with the definitions of schema types.
+const (
+	errorDefinition         = "__GraphQLError"
+	errorLocationDefinition = "__GraphQLErrorLocation"
+)
+
+// ResponseEnvelope wraps the schema of response data in the GraphQL HTTP response envelope,
+// {"data": ..., "errors": [...], "extensions": {...}}. A response must have data or errors; data
+// may only be null, or left out, when errors are reported, and errors, when present, hold at least
+// one error as the GraphQL spec describes them. The definitions of data move to the envelope,
+// which keeps their references valid.
+func ResponseEnvelope(data *JSONSchema6) *JSONSchema6 {
+	dataSchema := cloneSchema(data)
+	UseDefinitions(dataSchema)
+	definitions := dataSchema.Definitions
+	draft := dataSchema.Schema
+	if draft == "" {
+		draft = Draft06.schemaURI()
+	}
+	dataSchema.Schema = ""
+	dataSchema.Definitions = nil
+	if definitions == nil {
+		definitions = make(map[string]*JSONSchema6, 2)
+	}
+
+	one := 1
+	definitions[errorLocationDefinition] = &JSONSchema6{
+		Type: "object",
+		Properties: map[string]*JSONSchema6{
+			"line":   {Type: "integer"},
+			"column": {Type: "integer"},
+		},
+		Required: []string{"line", "column"},
+	}
+	definitions[errorDefinition] = &JSONSchema6{
+		Type:        "object",
+		Description: "A GraphQL error",
+		Properties: map[string]*JSONSchema6{
+			"message":   {Type: "string", Description: "Description of the error"},
+			"locations": {Type: "array", Items: &JSONSchema6{Ref: definitionRef(errorLocationDefinition)}},
+			"path": {
+				Type:        "array",
+				Description: "Response keys and list indices of the field that failed",
+				Items:       &JSONSchema6{Type: []string{"string", "integer"}},
+			},
+			"extensions": {Type: "object"},
+		},
+		Required: []string{"message"},
+	}
+
+	envelope := &JSONSchema6{
+		Schema: draft,
+		Type:   "object",
+		Properties: map[string]*JSONSchema6{
+			"data":       {AnyOf: []*JSONSchema6{dataSchema, {Type: "null"}}},
+			"errors":     {Type: "array", Items: &JSONSchema6{Ref: definitionRef(errorDefinition)}, MinItems: &one},
+			"extensions": {Type: "object"},
+		},
+		// Data that is present and not null validates against its schema through the properties above
+		AnyOf: []*JSONSchema6{
+			{Required: []string{"data"}, Properties: map[string]*JSONSchema6{"data": {Type: "object"}}},
+			{Required: []string{"errors"}},
+		},
+		Definitions: definitions,
+	}
+	RestoreDraftLayout(envelope)
+	return envelope
+}
+
+// ResponseEnvelopeSchema returns the envelope of any response to an operation of the given type,
+// see ResponseEnvelope. Data has the shape of response data for the root type, in which every field
+// is optional since an operation selects only some of them; aliases are not known and so not
+// accepted.
+func ResponseEnvelopeSchema(introspection IntrospectionQuery, operation OperationType, opts *Options) (*JSONSchema6, error) {
+	introspection, types, opts, err := prepareConversion(introspection, opts)
+	if err != nil {
+		return nil, err
+	}
+	dataShape := *opts
+	dataShape.FieldShape = FieldShapePlain
+	opts = &dataShape
+
+	var root *TypeRef
+	switch operation {
+	case OperationQuery:
+		root = introspection.Schema.QueryType
+	case OperationMutation:
+		root = introspection.Schema.MutationType
+	case OperationSubscription:
+		root = introspection.Schema.SubscriptionType
+	}
+	if root == nil {
+		return nil, fmt.Errorf("schema does not support %s operations", operation)
+	}
+	if types[root.Name] == nil {
+		return nil, fmt.Errorf("root type %s not found", root.Name)
+	}
+
+	used := referencedTypes(types, map[string]bool{root.Name: true}, opts)
+	definitions, err := convertDefinitions(introspection.Schema.Types, opts, func(name string) bool {
+		return used[name]
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, definition := range definitions {
+		Walk(definition, func(schema *JSONSchema6) {
+			if schema.Type == "object" {
+				schema.Required = nil
+			}
+			// With every field optional, an object selected through a union could match several
+			// members, which oneOf rejects
+			if len(schema.OneOf) > 0 {
+				schema.AnyOf, schema.OneOf = schema.OneOf, nil
+			}
+		})
+	}
+
+	return ResponseEnvelope(&JSONSchema6{Schema: opts.Draft.schemaURI(), Ref: definitionRef(root.Name), Definitions: definitions}), nil
+}
@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotFoundError reports a type, field, method or definition that does not exist in the schema
+type NotFoundError struct {
+	Kind        string
+	Name        string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("%s %s not found", e.Kind, e.Name)
+	}
+	return fmt.Sprintf("%s %s not found, did you mean: %s", e.Kind, e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+// notFound builds a NotFoundError suggesting the candidates closest to name
+func notFound(kind, name string, candidates []string) error {
+	return &NotFoundError{Kind: kind, Name: name, Suggestions: closeMatches(name, candidates)}
+}
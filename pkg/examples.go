@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// describe sets the description of a schema, extracting the examples it shows when
+// Options.ExtractExamples is set. typeName and fieldName locate the description in warnings;
+// fieldName is empty for a type's own description.
+func describe(schema *JSONSchema6, description, typeName, fieldName string, opts *Options) {
+	if !opts.ExtractExamples || description == "" {
+		schema.Description = description
+		return
+	}
+
+	text, examples := extractExamples(description, func(sample string) {
+		attrs := []interface{}{LogKeyWarning, WarningInvalidExample, LogKeyType, typeName}
+		if fieldName != "" {
+			attrs = append(attrs, LogKeyField, fieldName)
+		}
+		opts.logger().Warn("Example in description is not valid JSON and is left out", append(attrs, "example", sample)...)
+	})
+	schema.Examples = examples
+	schema.Description = description
+	if opts.StripExamples {
+		schema.Description = text
+	}
+}
+
+// extractExamples finds the samples in a description: the rest of a line starting with Example:,
+// parsed as JSON or else kept as a string, and the body of each ```json fenced block. It returns
+// the description without them and the parsed samples. Fenced blocks that are not valid JSON are
+// passed to invalid and left out.
+func extractExamples(description string, invalid func(sample string)) (string, []interface{}) {
+	var examples []interface{}
+	lines := strings.Split(description, "\n")
+	kept := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if trimmed == "```json" {
+			end := i + 1
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "```" {
+				end++
+			}
+			if end < len(lines) {
+				sample := strings.Join(lines[i+1:end], "\n")
+				var value interface{}
+				if err := json.Unmarshal([]byte(sample), &value); err != nil {
+					invalid(strings.TrimSpace(sample))
+				} else {
+					examples = append(examples, value)
+				}
+				i = end
+				continue
+			}
+		}
+
+		if rest, ok := strings.CutPrefix(trimmed, "Example:"); ok {
+			sample := strings.TrimSpace(rest)
+			// A bare Example: line introduces the fenced block that follows it
+			if sample == "" && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "```json" {
+				continue
+			}
+			if sample != "" {
+				var value interface{}
+				if err := json.Unmarshal([]byte(sample), &value); err != nil {
+					value = sample
+				}
+				examples = append(examples, value)
+				continue
+			}
+		}
+
+		kept = append(kept, lines[i])
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), examples
+}
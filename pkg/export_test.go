@@ -0,0 +1,4 @@
+package pkg
+
+// NewTypeIndex exposes the conversion's type index to the benchmarks
+var NewTypeIndex = newTypeIndex
@@ -0,0 +1,193 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// definitionsRefPrefix is the $ref prefix used for definitions
+const definitionsRefPrefix = "#/definitions/"
+
+// ExtractDefinition returns a self-contained document rooted at the named definition
+func ExtractDefinition(root *JSONSchema6, name string) (*JSONSchema6, error) {
+	if _, ok := root.Definitions[name]; !ok {
+		return nil, notFound("definition", name, sortedKeys(root.Definitions))
+	}
+	return ExtractSchema(root, definitionsRefPrefix+escapePointerSegment(name))
+}
+
+// ExtractSchema returns a self-contained document whose root is the subschema at pointer,
+// carrying along every definition it transitively references
+func ExtractSchema(root *JSONSchema6, pointer string) (*JSONSchema6, error) {
+	selected, err := ResolvePointer(root, pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	extracted := cloneSchema(selected)
+	extracted.Schema = root.Schema
+	extracted.Definitions = nil
+
+	definitions := make(map[string]*JSONSchema6)
+	for name := range reachableDefinitions(extracted, root.Definitions) {
+		definitions[name] = cloneSchema(root.Definitions[name])
+	}
+	if len(definitions) > 0 {
+		extracted.Definitions = definitions
+	}
+
+	return extracted, nil
+}
+
+// reachableDefinitions returns the names of all definitions transitively referenced from schema
+func reachableDefinitions(schema *JSONSchema6, definitions map[string]*JSONSchema6) map[string]bool {
+	reachable := make(map[string]bool)
+	queue := []*JSONSchema6{schema}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		Walk(current, func(s *JSONSchema6) {
+			name, ok := definitionName(s.Ref)
+			if !ok || reachable[name] {
+				return
+			}
+			if definition, exists := definitions[name]; exists {
+				reachable[name] = true
+				queue = append(queue, definition)
+			}
+		})
+	}
+	return reachable
+}
+
+// definitionName returns the definition name a local $ref points at
+func definitionName(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, definitionsRefPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(ref, definitionsRefPrefix)
+	if strings.Contains(name, "/") {
+		return "", false
+	}
+	return unescapePointerSegment(name), true
+}
+
+// InlineDefinitions replaces every $ref in the document with a copy of the referenced definition,
+// up to maxDepth nested expansions (0 means unlimited). References that would recurse into a
+// definition already being expanded are kept as $ref, and only the definitions they need are retained.
+func InlineDefinitions(doc *JSONSchema6, maxDepth int) *JSONSchema6 {
+	inliner := &refInliner{
+		definitions: doc.Definitions,
+		maxDepth:    maxDepth,
+		expanding:   make(map[string]bool),
+	}
+
+	definitions := doc.Definitions
+	root := *doc
+	root.Definitions = nil
+	inlined := inliner.inline(&root, 0)
+
+	remaining := make(map[string]*JSONSchema6)
+	for name := range reachableDefinitions(inlined, definitions) {
+		remaining[name] = cloneSchema(definitions[name])
+	}
+	if len(remaining) > 0 {
+		inlined.Definitions = remaining
+	}
+	return inlined
+}
+
+type refInliner struct {
+	definitions map[string]*JSONSchema6
+	maxDepth    int
+	expanding   map[string]bool
+}
+
+func (r *refInliner) inline(schema *JSONSchema6, depth int) *JSONSchema6 {
+	if schema == nil {
+		return nil
+	}
+
+	if name, ok := definitionName(schema.Ref); ok {
+		definition, exists := r.definitions[name]
+		if !exists || r.expanding[name] || (r.maxDepth > 0 && depth >= r.maxDepth) {
+			return cloneSchema(schema)
+		}
+
+		r.expanding[name] = true
+		result := r.inline(definition, depth+1)
+		delete(r.expanding, name)
+
+		// Keep annotations set next to the $ref, such as argument descriptions and defaults
+		if schema.Description != "" {
+			result.Description = schema.Description
+		}
+		if schema.Default != nil {
+			result.Default = schema.Default
+		}
+		return result
+	}
+
+	result := *schema
+	if schema.Properties != nil {
+		result.Properties = make(map[string]*JSONSchema6, len(schema.Properties))
+		for name, property := range schema.Properties {
+			result.Properties[name] = r.inline(property, depth)
+		}
+	}
+	if schema.Definitions != nil {
+		result.Definitions = make(map[string]*JSONSchema6, len(schema.Definitions))
+		for name, definition := range schema.Definitions {
+			result.Definitions[name] = r.inline(definition, depth)
+		}
+	}
+	result.Items = r.inline(schema.Items, depth)
+	result.AnyOf = r.inlineAll(schema.AnyOf, depth)
+	result.OneOf = r.inlineAll(schema.OneOf, depth)
+	result.AllOf = r.inlineAll(schema.AllOf, depth)
+	if schema.Required != nil {
+		result.Required = append([]string{}, schema.Required...)
+	}
+	if schema.Enum != nil {
+		result.Enum = append([]string{}, schema.Enum...)
+	}
+	return &result
+}
+
+func (r *refInliner) inlineAll(schemas []*JSONSchema6, depth int) []*JSONSchema6 {
+	if schemas == nil {
+		return nil
+	}
+	result := make([]*JSONSchema6, len(schemas))
+	for i, schema := range schemas {
+		result[i] = r.inline(schema, depth)
+	}
+	return result
+}
+
+// DropDefinitions removes the definitions of the document and replaces every $ref to one with an
+// empty schema accepting any value, whose $comment names the definition. After InlineDefinitions
+// such references remain only where expansion stopped at a cycle or the depth limit.
+func DropDefinitions(doc *JSONSchema6) {
+	doc.Definitions = nil
+	Walk(doc, func(s *JSONSchema6) {
+		if name, ok := definitionName(s.Ref); ok {
+			s.Ref = ""
+			s.Comment = fmt.Sprintf("not inlined: %s", name)
+		}
+	})
+}
+
+// inlineRefs expands the references of a converted document as Options.InlineRefs, InlineDepth
+// and InlineOmitDefinitions select
+func inlineRefs(schema *JSONSchema6, opts *Options) *JSONSchema6 {
+	if !opts.InlineRefs {
+		return schema
+	}
+	schema = InlineDefinitions(schema, opts.InlineDepth)
+	if opts.InlineOmitDefinitions {
+		DropDefinitions(schema)
+	}
+	return schema
+}
@@ -0,0 +1,114 @@
+package pkg_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const extractSDL = `
+type Query { user(id: ID!): User }
+
+type User {
+  id: ID!
+  name: String!
+  address: Address
+  role: Role
+  manager: User
+}
+
+type Address {
+  street: String!
+  country: Country
+}
+
+type Country { code: String! }
+
+enum Role { ADMIN MEMBER }
+
+type Unrelated { value: Int }
+`
+
+// extractInstances are checked against the extracted schema and the subtree it came from
+var extractInstances = []string{
+	`{"id": "1", "name": "Ada"}`,
+	`{"id": "1", "name": "Ada", "role": "ADMIN", "address": {"street": "Main", "country": {"code": "NZ"}}}`,
+	`{"id": "1", "name": "Ada", "manager": {"id": "2", "name": "Grace", "role": "MEMBER"}}`,
+	`{"id": "1"}`,
+	`{"id": "1", "name": "Ada", "role": "OWNER"}`,
+	`{"id": "1", "name": "Ada", "address": {"country": {"code": "NZ"}}}`,
+	`{"id": "1", "name": 3}`,
+	`"Ada"`,
+}
+
+func TestExtractDefinition(t *testing.T) {
+	original := mustConvert(t, extractSDL, options(func(o *pkg.Options) { o.FieldShape = pkg.FieldShapePlain }))
+
+	extracted, err := pkg.ExtractDefinition(original, "User")
+	if err != nil {
+		t.Fatalf("ExtractDefinition: %v", err)
+	}
+	if extracted.Schema != original.Schema {
+		t.Errorf("$schema = %q, want %q", extracted.Schema, original.Schema)
+	}
+	names := definitionNames(extracted)
+	for _, want := range []string{"User", "Address", "Country", "Role"} {
+		if !names[want] {
+			t.Errorf("definition %s was not carried along, got %v", want, names)
+		}
+	}
+	if names["Unrelated"] {
+		t.Error("unreferenced definition Unrelated was carried along")
+	}
+
+	inlined := pkg.InlineDefinitions(extracted, 0)
+	subtree := original.Definitions["User"]
+	for _, instance := range extractInstances {
+		want := len(validateJSON(t, original, subtree, instance)) == 0
+		if got := len(validateJSON(t, extracted, extracted, instance)) == 0; got != want {
+			t.Errorf("extracted schema accepts %s: %v, original subtree: %v", instance, got, want)
+		}
+		if got := len(validateJSON(t, inlined, inlined, instance)) == 0; got != want {
+			t.Errorf("inlined schema accepts %s: %v, original subtree: %v", instance, got, want)
+		}
+	}
+}
+
+func TestExtractSchemaPointer(t *testing.T) {
+	original := mustConvert(t, extractSDL, options(func(o *pkg.Options) { o.FieldShape = pkg.FieldShapePlain }))
+
+	extracted, err := pkg.ExtractSchema(original, "/definitions/User/properties/address")
+	if err != nil {
+		t.Fatalf("ExtractSchema: %v", err)
+	}
+	names := definitionNames(extracted)
+	if len(names) != 2 || !names["Address"] || !names["Country"] {
+		t.Errorf("definitions = %v, want Address and Country", names)
+	}
+
+	subtree := mustPointer(t, original, "/definitions/User/properties/address")
+	for _, instance := range []string{`{"street": "Main"}`, `{"country": {"code": "NZ"}}`, `{"street": "Main", "country": {}}`} {
+		want := len(validateJSON(t, original, subtree, instance)) == 0
+		if got := len(validateJSON(t, extracted, extracted, instance)) == 0; got != want {
+			t.Errorf("extracted schema accepts %s: %v, original subtree: %v", instance, got, want)
+		}
+	}
+
+	if _, err := pkg.ExtractSchema(original, "/definitions/User/properties/missing"); err == nil {
+		t.Error("expected an error for a pointer to nothing")
+	}
+}
+
+func TestExtractDefinitionUnknown(t *testing.T) {
+	original := mustConvert(t, extractSDL, nil)
+	_, err := pkg.ExtractDefinition(original, "Usr")
+	var notFound *pkg.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected a NotFoundError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "did you mean: User") {
+		t.Errorf("error %q does not suggest User", err)
+	}
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// fieldPattern is a Type.field pattern; each side may use the wildcards of path.Match, such as *
+type fieldPattern struct {
+	source      string
+	typeName    string
+	fieldName   string
+	matchedOnce bool
+}
+
+// CheckFieldPatterns reports the first pattern that is not a valid Type.field pattern
+func CheckFieldPatterns(patterns []string) error {
+	_, err := parseFieldPatterns(patterns)
+	return err
+}
+
+func parseFieldPatterns(patterns []string) ([]*fieldPattern, error) {
+	parsed := make([]*fieldPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		typeName, fieldName, ok := strings.Cut(pattern, ".")
+		if !ok || typeName == "" || fieldName == "" || strings.Contains(fieldName, ".") {
+			return nil, fmt.Errorf("invalid field pattern %q: must be Type.field", pattern)
+		}
+		for _, part := range []string{typeName, fieldName} {
+			if _, err := path.Match(part, ""); err != nil {
+				return nil, fmt.Errorf("invalid field pattern %q: %w", pattern, err)
+			}
+		}
+		parsed = append(parsed, &fieldPattern{source: pattern, typeName: typeName, fieldName: fieldName})
+	}
+	return parsed, nil
+}
+
+func (p *fieldPattern) matchesType(typeName string) bool {
+	matched, _ := path.Match(p.typeName, typeName)
+	return matched
+}
+
+func (p *fieldPattern) matches(typeName, fieldName string) bool {
+	matched, _ := path.Match(p.fieldName, fieldName)
+	return matched && p.matchesType(typeName)
+}
+
+// filterFields drops the fields of objects, interfaces and input objects matched by an exclude
+// pattern, and those of types named by include patterns that no include pattern selects. Types are
+// copied before their fields are changed. Patterns that match no field are logged.
+func filterFields(types []IntrospectionType, include, exclude []string, logger *slog.Logger) ([]IntrospectionType, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return types, nil
+	}
+	includes, err := parseFieldPatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := parseFieldPatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := func(typeName, fieldName string) bool {
+		allowlisted := false
+		included := false
+		for _, pattern := range includes {
+			if pattern.matchesType(typeName) {
+				allowlisted = true
+				if pattern.matches(typeName, fieldName) {
+					pattern.matchedOnce = true
+					included = true
+				}
+			}
+		}
+		if allowlisted && !included {
+			return false
+		}
+		for _, pattern := range excludes {
+			if pattern.matches(typeName, fieldName) {
+				pattern.matchedOnce = true
+				return false
+			}
+		}
+		return true
+	}
+
+	filtered := make([]IntrospectionType, len(types))
+	for i, t := range types {
+		filtered[i] = t
+		switch t.Kind {
+		case "OBJECT", "INTERFACE":
+			fields := make([]IntrospectionField, 0, len(t.Fields))
+			for _, field := range t.Fields {
+				if keep(t.Name, field.Name) {
+					fields = append(fields, field)
+				}
+			}
+			filtered[i].Fields = fields
+		case "INPUT_OBJECT":
+			fields := make([]IntrospectionInput, 0, len(t.InputFields))
+			for _, field := range t.InputFields {
+				if keep(t.Name, field.Name) {
+					fields = append(fields, field)
+				}
+			}
+			filtered[i].InputFields = fields
+		}
+	}
+
+	for _, pattern := range append(includes, excludes...) {
+		if !pattern.matchedOnce {
+			logger.Warn("Field pattern matches no field", LogKeyWarning, WarningUnmatchedPattern, "pattern", pattern.source)
+		}
+	}
+	return filtered, nil
+}
+
+// excludePresetFields drops the fields matching the patterns of a Preset, except those the include
+// patterns select. Unlike the options' own patterns, preset patterns that match nothing are expected
+// and not logged.
+func excludePresetFields(types []IntrospectionType, patterns, include []string) ([]IntrospectionType, error) {
+	if len(patterns) == 0 {
+		return types, nil
+	}
+	excludes, err := parseFieldPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := parseFieldPatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	matches := func(patterns []*fieldPattern, typeName, fieldName string) bool {
+		for _, pattern := range patterns {
+			if pattern.matches(typeName, fieldName) {
+				return true
+			}
+		}
+		return false
+	}
+
+	filtered := make([]IntrospectionType, len(types))
+	for i, t := range types {
+		filtered[i] = t
+		if t.Kind != "OBJECT" && t.Kind != "INTERFACE" {
+			continue
+		}
+		fields := make([]IntrospectionField, 0, len(t.Fields))
+		for _, field := range t.Fields {
+			if !matches(excludes, t.Name, field.Name) || matches(includes, t.Name, field.Name) {
+				fields = append(fields, field)
+			}
+		}
+		filtered[i].Fields = fields
+	}
+	return filtered, nil
+}
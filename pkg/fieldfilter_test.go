@@ -0,0 +1,125 @@
+package pkg_test
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const fieldFilterSDL = `
+type Query {
+  user(id: ID!): User
+  audit: AuditLog
+}
+
+type Mutation {
+  createUser(input: UserInput!): User
+  deleteUser(id: ID!): Boolean
+  deletePost(id: ID!): Boolean
+}
+
+type User {
+  id: ID!
+  name: String!
+  ssn: String!
+  email: String
+}
+
+input UserInput {
+  name: String!
+  ssn: String!
+}
+
+type AuditLog { entries: [String!]! }
+`
+
+// fieldNames lists the properties of an object definition, or of a root type's fields
+func fieldNames(t *testing.T, schema *pkg.JSONSchema6, pointer string) []string {
+	t.Helper()
+	return sortedNames(mustPointer(t, schema, pointer).Properties)
+}
+
+func TestFieldFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		pointer string
+		want    []string
+	}{
+		{"exclude a field", nil, []string{"User.ssn"}, "/definitions/User", []string{"email", "id", "name"}},
+		{"exclude with a field glob", nil, []string{"Mutation.delete*"}, "/properties/Mutation", []string{"createUser"}},
+		{"exclude with a type glob", nil, []string{"*.ssn"}, "/definitions/UserInput", []string{"name"}},
+		{"exclude everywhere", nil, []string{"*.*"}, "/definitions/User", nil},
+		{"include is an allowlist for its type", []string{"User.id", "User.name"}, nil, "/definitions/User", []string{"id", "name"}},
+		{"include leaves other types alone", []string{"User.id"}, nil, "/definitions/UserInput", []string{"name", "ssn"}},
+		{"exclude narrows an include", []string{"User.*"}, []string{"User.email"}, "/definitions/User", []string{"id", "name", "ssn"}},
+		{"include on a root type", []string{"Query.user"}, nil, "/properties/Query", []string{"user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustConvert(t, fieldFilterSDL, options(func(o *pkg.Options) {
+				o.IncludeFields = tt.include
+				o.ExcludeFields = tt.exclude
+			}))
+			if got := fieldNames(t, schema, tt.pointer); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldFiltersRequired(t *testing.T) {
+	schema := mustConvert(t, fieldFilterSDL, options(func(o *pkg.Options) { o.ExcludeFields = []string{"*.ssn"} }))
+	for _, name := range []string{"User", "UserInput"} {
+		required := schema.Definitions[name].Required
+		for _, field := range required {
+			if field == "ssn" {
+				t.Errorf("%s still requires the excluded ssn field: %v", name, required)
+			}
+		}
+		if len(required) == 0 {
+			t.Errorf("%s lost its other required fields", name)
+		}
+	}
+}
+
+func TestFieldFiltersPruning(t *testing.T) {
+	schema := mustConvert(t, fieldFilterSDL, options(func(o *pkg.Options) {
+		o.ExcludeFields = []string{"Query.audit"}
+		o.PruneToRoots = true
+	}))
+	if schema.Definitions["AuditLog"] != nil {
+		t.Error("AuditLog is only reachable through the excluded field and should have been pruned")
+	}
+	if schema.Definitions["User"] == nil {
+		t.Error("User is still reachable and should have been kept")
+	}
+}
+
+func TestFieldFiltersUnmatchedPattern(t *testing.T) {
+	handler := &recordingHandler{}
+	mustConvert(t, fieldFilterSDL, options(func(o *pkg.Options) {
+		o.ExcludeFields = []string{"User.ssn", "Usr.password"}
+		o.Logger = slog.New(handler)
+	}))
+	record, ok := handler.find(pkg.WarningUnmatchedPattern)
+	if !ok {
+		t.Fatal("the unmatched pattern was not logged")
+	}
+	if record.attrs["pattern"] != "Usr.password" {
+		t.Errorf("pattern = %q, want Usr.password", record.attrs["pattern"])
+	}
+}
+
+func TestFieldFiltersInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"User", "User.", ".ssn", "User.a.b", "User.[ssn"} {
+		if err := pkg.CheckFieldPatterns([]string{pattern}); err == nil || !strings.Contains(err.Error(), "invalid field pattern") {
+			t.Errorf("%s: expected an invalid pattern error, got %v", pattern, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package pkg
+
+// FilterOptions selects the part of an introspection result FilterIntrospection keeps
+type FilterOptions struct {
+	// IgnoreInternals drops GraphQL internal types such as __Type
+	IgnoreInternals bool `json:"ignoreInternals"`
+	// ExcludeDeprecated drops deprecated fields and enum values
+	ExcludeDeprecated bool `json:"excludeDeprecated"`
+	// IncludeTypes keeps only the types whose names match one of its patterns, which may use * wildcards
+	// or be regular expressions between slashes, as for Options.IncludeTypes
+	IncludeTypes []string `json:"includeTypes,omitempty"`
+	// ExcludeTypes drops the types whose names match one of its patterns
+	ExcludeTypes []string `json:"excludeTypes,omitempty"`
+	// ExcludeFields drops fields matching Type.field patterns, as Options.ExcludeFields does
+	ExcludeFields []string `json:"excludeFields,omitempty"`
+	// IncludeFields keeps only the matching fields of the types its patterns name, as Options.IncludeFields does
+	IncludeFields []string `json:"includeFields,omitempty"`
+}
+
+// DefaultFilterOptions returns the default filter options
+func DefaultFilterOptions() FilterOptions {
+	return FilterOptions{
+		IgnoreInternals: true,
+	}
+}
+
+// Validate reports the first type or field pattern that is malformed
+func (o FilterOptions) Validate() error {
+	if err := CheckTypePatterns(append(append([]string(nil), o.IncludeTypes...), o.ExcludeTypes...)); err != nil {
+		return err
+	}
+	if err := CheckFieldPatterns(o.IncludeFields); err != nil {
+		return err
+	}
+	return CheckFieldPatterns(o.ExcludeFields)
+}
+
+// FilterIntrospection returns a copy of an introspection result narrowed by opts. The root types and
+// built-in scalars are always kept. Fields whose type or arguments, and input fields, interfaces and
+// possible types that refer to a dropped type are removed too, so the result stays a consistent
+// schema. Malformed patterns are not applied; check them with FilterOptions.Validate first.
+func FilterIntrospection(q IntrospectionQuery, opts FilterOptions) IntrospectionQuery {
+	keep := make(map[string]bool)
+	for _, root := range []*TypeRef{q.Schema.QueryType, q.Schema.MutationType, q.Schema.SubscriptionType} {
+		if root != nil {
+			keep[root.Name] = true
+		}
+	}
+
+	include, _ := parseTypePatterns(opts.IncludeTypes)
+	exclude, _ := parseTypePatterns(opts.ExcludeTypes)
+	types := make([]IntrospectionType, 0, len(q.Schema.Types))
+	for _, t := range filterTypes(q.Schema.Types, opts.IgnoreInternals) {
+		if keep[t.Name] || (t.Kind == "SCALAR" && builtinScalars[t.Name]) || keepTypeName(t.Name, include, exclude, len(opts.IncludeTypes) > 0) {
+			keep[t.Name] = true
+			types = append(types, t)
+		}
+	}
+	if filtered, err := filterFields(types, opts.IncludeFields, opts.ExcludeFields, discardLogger); err == nil {
+		types = filtered
+	}
+
+	dropDanglingReferences(types, keep)
+	if opts.ExcludeDeprecated {
+		for i := range types {
+			t := &types[i]
+			if t.Fields != nil {
+				fields := make([]IntrospectionField, 0, len(t.Fields))
+				for _, field := range t.Fields {
+					if !field.IsDeprecated {
+						fields = append(fields, field)
+					}
+				}
+				t.Fields = fields
+			}
+			if t.EnumValues != nil {
+				values := make([]IntrospectionEnum, 0, len(t.EnumValues))
+				for _, value := range t.EnumValues {
+					if !value.IsDeprecated {
+						values = append(values, value)
+					}
+				}
+				t.EnumValues = values
+			}
+		}
+	}
+
+	q.Schema.Types = types
+	return q
+}
+
+// keepTypeName reports whether the type patterns select a type name; when restricted, only names
+// matching include are selected
+func keepTypeName(name string, include, exclude []typePattern, restricted bool) bool {
+	if restricted && !matchesTypePattern(include, name) {
+		return false
+	}
+	return !matchesTypePattern(exclude, name)
+}
+
+// dropDanglingReferences removes, in place, the fields whose type or arguments and the input fields,
+// interfaces and possible types that refer to a type not in keep. The slices of types are replaced
+// rather than changed, so types may share them with the introspection they were copied from.
+func dropDanglingReferences(types []IntrospectionType, keep map[string]bool) {
+	refersToKept := func(typeRef IntrospectionTypeRef) bool {
+		named := namedTypeRef(typeRef)
+		return named != nil && named.Name != nil && keep[*named.Name]
+	}
+	for i := range types {
+		t := &types[i]
+		if t.Fields != nil {
+			fields := make([]IntrospectionField, 0, len(t.Fields))
+			for _, field := range t.Fields {
+				if !refersToKept(field.Type) {
+					continue
+				}
+				// Dropping an argument would change what the field accepts, so the field goes instead
+				argsKept := true
+				for _, arg := range field.Args {
+					argsKept = argsKept && refersToKept(arg.Type)
+				}
+				if argsKept {
+					fields = append(fields, field)
+				}
+			}
+			t.Fields = fields
+		}
+		if t.InputFields != nil {
+			inputFields := make([]IntrospectionInput, 0, len(t.InputFields))
+			for _, field := range t.InputFields {
+				if refersToKept(field.Type) {
+					inputFields = append(inputFields, field)
+				}
+			}
+			t.InputFields = inputFields
+		}
+		if t.Interfaces != nil {
+			interfaces := make([]TypeRef, 0, len(t.Interfaces))
+			for _, iface := range t.Interfaces {
+				if keep[iface.Name] {
+					interfaces = append(interfaces, iface)
+				}
+			}
+			t.Interfaces = interfaces
+		}
+		if t.PossibleTypes != nil {
+			possibleTypes := make([]IntrospectionType, 0, len(t.PossibleTypes))
+			for _, member := range t.PossibleTypes {
+				if keep[member.Name] {
+					possibleTypes = append(possibleTypes, member)
+				}
+			}
+			t.PossibleTypes = possibleTypes
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const filterSDL = `
+type Query {
+  user(id: ID!): User
+  search(term: String!): [SearchResult!]!
+  internal(token: Secret): String
+}
+
+interface Node { id: ID! }
+
+type User implements Node {
+  id: ID!
+  name: String!
+  email: String @deprecated(reason: "Use contact")
+  contact: Contact
+  audit: AuditLog
+}
+
+type Contact { email: String }
+type Post implements Node { id: ID! title: String }
+type AuditLog { entries: [String!]! }
+
+union SearchResult = User | Post
+
+enum Role { ADMIN MEMBER GUEST @deprecated }
+
+input Secret { value: String! role: Role }
+`
+
+// typeNames returns the names of the types of an introspection result
+func typeNames(q pkg.IntrospectionQuery) map[string]pkg.IntrospectionType {
+	types := make(map[string]pkg.IntrospectionType, len(q.Schema.Types))
+	for _, t := range q.Schema.Types {
+		types[t.Name] = t
+	}
+	return types
+}
+
+// fieldNamesOf returns the field names of an introspection type
+func fieldNamesOf(t pkg.IntrospectionType) []string {
+	names := make([]string, 0, len(t.Fields))
+	for _, field := range t.Fields {
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+func TestIntrospectionRoundTrip(t *testing.T) {
+	introspection := mustIntrospect(t, filterSDL)
+	data := mustJSON(t, map[string]interface{}{"data": introspection})
+
+	decoded, err := pkg.DecodeIntrospection(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*decoded, introspection) {
+		t.Error("the decoded introspection differs from the marshaled one")
+	}
+	if again := mustJSON(t, map[string]interface{}{"data": decoded}); !bytes.Equal(again, data) {
+		t.Error("marshaling the decoded introspection does not reproduce the input")
+	}
+
+	// Possible types marshal as type references, as a server returns them
+	var raw struct {
+		Data struct {
+			Schema struct {
+				Types []map[string]json.RawMessage `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range raw.Data.Schema.Types {
+		if string(typ["name"]) != `"SearchResult"` {
+			continue
+		}
+		var possibleTypes []map[string]interface{}
+		if err := json.Unmarshal(typ["possibleTypes"], &possibleTypes); err != nil {
+			t.Fatal(err)
+		}
+		for _, member := range possibleTypes {
+			if len(member) != 3 || member["kind"] != "OBJECT" {
+				t.Errorf("possible type %v is not a type reference", member)
+			}
+		}
+	}
+
+	want := mustJSON(t, mustConvert(t, filterSDL, options(nil)))
+	converted, err := pkg.FromIntrospectionQuery(*decoded, options(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustJSON(t, converted); !bytes.Equal(got, want) {
+		t.Error("the round-tripped introspection converts differently")
+	}
+}
+
+func TestFilterIntrospection(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     func(*pkg.FilterOptions)
+		present []string
+		absent  []string
+		check   func(t *testing.T, types map[string]pkg.IntrospectionType)
+	}{
+		{
+			name:    "defaults drop internal types",
+			present: []string{"Query", "User", "Secret", "String"},
+			absent:  []string{"__Type", "__Schema"},
+		},
+		{
+			name:    "internals kept on request",
+			set:     func(o *pkg.FilterOptions) { o.IgnoreInternals = false },
+			present: []string{"__Type", "User"},
+		},
+		{
+			name:    "excluded types take dependent fields with them",
+			set:     func(o *pkg.FilterOptions) { o.ExcludeTypes = []string{"Secret", "Audit*"} },
+			present: []string{"Query", "User", "Role"},
+			absent:  []string{"Secret", "AuditLog"},
+			check: func(t *testing.T, types map[string]pkg.IntrospectionType) {
+				if got := fieldNamesOf(types["Query"]); !reflect.DeepEqual(got, []string{"user", "search"}) {
+					t.Errorf("Query fields = %v, want the field with a Secret argument dropped", got)
+				}
+				if got := fieldNamesOf(types["User"]); !reflect.DeepEqual(got, []string{"id", "name", "email", "contact"}) {
+					t.Errorf("User fields = %v", got)
+				}
+			},
+		},
+		{
+			name:    "included types keep roots and built-in scalars",
+			set:     func(o *pkg.FilterOptions) { o.IncludeTypes = []string{"User", "Node"} },
+			present: []string{"Query", "User", "Node", "ID", "String", "Boolean"},
+			absent:  []string{"Post", "Contact", "SearchResult", "Secret"},
+			check: func(t *testing.T, types map[string]pkg.IntrospectionType) {
+				if got := fieldNamesOf(types["Query"]); !reflect.DeepEqual(got, []string{"user"}) {
+					t.Errorf("Query fields = %v", got)
+				}
+				if got := len(types["User"].Interfaces); got != 1 {
+					t.Errorf("User implements %d interfaces, want Node", got)
+				}
+			},
+		},
+		{
+			name:    "union members follow dropped types",
+			set:     func(o *pkg.FilterOptions) { o.ExcludeTypes = []string{"Post"} },
+			present: []string{"SearchResult"},
+			absent:  []string{"Post"},
+			check: func(t *testing.T, types map[string]pkg.IntrospectionType) {
+				members := types["SearchResult"].PossibleTypes
+				if len(members) != 1 || members[0].Name != "User" {
+					t.Errorf("SearchResult members = %v, want User only", members)
+				}
+			},
+		},
+		{
+			name: "deprecated members are dropped",
+			set:  func(o *pkg.FilterOptions) { o.ExcludeDeprecated = true },
+			check: func(t *testing.T, types map[string]pkg.IntrospectionType) {
+				if got := fieldNamesOf(types["User"]); !reflect.DeepEqual(got, []string{"id", "name", "contact", "audit"}) {
+					t.Errorf("User fields = %v", got)
+				}
+				if got := len(types["Role"].EnumValues); got != 2 {
+					t.Errorf("Role has %d values, want 2", got)
+				}
+			},
+		},
+		{
+			name: "field patterns",
+			set: func(o *pkg.FilterOptions) {
+				o.ExcludeFields = []string{"*.email"}
+				o.IncludeFields = []string{"Secret.value"}
+			},
+			check: func(t *testing.T, types map[string]pkg.IntrospectionType) {
+				if got := fieldNamesOf(types["User"]); !reflect.DeepEqual(got, []string{"id", "name", "contact", "audit"}) {
+					t.Errorf("User fields = %v", got)
+				}
+				if got := fieldNamesOf(types["Contact"]); len(got) != 0 {
+					t.Errorf("Contact fields = %v", got)
+				}
+				if got := types["Secret"].InputFields; len(got) != 1 || got[0].Name != "value" {
+					t.Errorf("Secret input fields = %v", got)
+				}
+			},
+		},
+	}
+
+	introspection := mustIntrospect(t, filterSDL)
+	original := mustJSON(t, introspection)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := pkg.DefaultFilterOptions()
+			if tt.set != nil {
+				tt.set(&opts)
+			}
+			if err := opts.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			filtered := pkg.FilterIntrospection(introspection, opts)
+			types := typeNames(filtered)
+			for _, name := range tt.present {
+				if _, ok := types[name]; !ok {
+					t.Errorf("type %s was dropped", name)
+				}
+			}
+			for _, name := range tt.absent {
+				if _, ok := types[name]; ok {
+					t.Errorf("type %s was kept", name)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, types)
+			}
+			if _, err := pkg.FromIntrospectionQuery(filtered, options(nil)); err != nil {
+				t.Errorf("the filtered introspection does not convert: %v", err)
+			}
+		})
+	}
+
+	if !bytes.Equal(mustJSON(t, introspection), original) {
+		t.Error("FilterIntrospection modified its argument")
+	}
+}
+
+func TestFilterOptionsValidate(t *testing.T) {
+	for _, opts := range []pkg.FilterOptions{
+		{ExcludeFields: []string{"email"}},
+		{IncludeFields: []string{"User."}},
+		{IncludeTypes: []string{"/(/"}},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
@@ -0,0 +1,766 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// introspectionSDL declares the types every schema exposes for introspection, as of the October
+// 2021 spec. They are added to schemas read from SDL, which never declare them.
+const introspectionSDL = `
+type __Schema {
+  description: String
+  types: [__Type!]!
+  queryType: __Type!
+  mutationType: __Type
+  subscriptionType: __Type
+  directives: [__Directive!]!
+}
+
+type __Type {
+  kind: __TypeKind!
+  name: String
+  description: String
+  specifiedByURL: String
+  fields(includeDeprecated: Boolean = false): [__Field!]
+  interfaces: [__Type!]
+  possibleTypes: [__Type!]
+  enumValues(includeDeprecated: Boolean = false): [__EnumValue!]
+  inputFields(includeDeprecated: Boolean = false): [__InputValue!]
+  ofType: __Type
+}
+
+type __Field {
+  name: String!
+  description: String
+  args(includeDeprecated: Boolean = false): [__InputValue!]!
+  type: __Type!
+  isDeprecated: Boolean!
+  deprecationReason: String
+}
+
+type __InputValue {
+  name: String!
+  description: String
+  type: __Type!
+  defaultValue: String
+  isDeprecated: Boolean!
+  deprecationReason: String
+}
+
+type __EnumValue {
+  name: String!
+  description: String
+  isDeprecated: Boolean!
+  deprecationReason: String
+}
+
+type __Directive {
+  name: String!
+  description: String
+  isRepeatable: Boolean!
+  locations: [__DirectiveLocation!]!
+  args(includeDeprecated: Boolean = false): [__InputValue!]!
+}
+
+enum __TypeKind { SCALAR OBJECT INTERFACE UNION ENUM INPUT_OBJECT LIST NON_NULL }
+
+enum __DirectiveLocation {
+  QUERY MUTATION SUBSCRIPTION FIELD FRAGMENT_DEFINITION FRAGMENT_SPREAD INLINE_FRAGMENT
+  VARIABLE_DEFINITION SCHEMA SCALAR OBJECT FIELD_DEFINITION ARGUMENT_DEFINITION INTERFACE UNION
+  ENUM ENUM_VALUE INPUT_OBJECT INPUT_FIELD_DEFINITION
+}
+`
+
+// scalarOrder lists the built-in scalars in the order schemas read from SDL declare them. String
+// and Boolean are always present as introspection uses them; the others only when referenced.
+var scalarOrder = []string{"String", "Int", "Float", "Boolean", "ID"}
+
+// scalarDescriptions are the descriptions graphql-js gives the built-in scalars, which servers
+// return in their introspection
+var scalarDescriptions = map[string]string{
+	"String":  "The `String` scalar type represents textual data, represented as UTF-8 character sequences. The String type is most often used by GraphQL to represent free-form human-readable text.",
+	"Int":     "The `Int` scalar type represents non-fractional signed whole numeric values. Int can represent values between -(2^31) and 2^31 - 1.",
+	"Float":   "The `Float` scalar type represents signed double-precision fractional values as specified by [IEEE 754](https://en.wikipedia.org/wiki/IEEE_floating_point).",
+	"Boolean": "The `Boolean` scalar type represents `true` or `false`.",
+	"ID":      "The `ID` scalar type represents a unique identifier, often used to refetch an object or as key for a cache. The ID type appears in a JSON response as a String; however, it is not intended to be human-readable. When expected as an input type, any string (such as `\"4\"`) or integer (such as `4`) input value will be accepted as an ID.",
+}
+
+// FromSDL converts a GraphQL schema in the schema definition language to a JSON Schema. The schema
+// is read into the introspection result a server would return for it, so the output is the same
+// as converting that server's introspection.
+func FromSDL(sdl string, opts *Options) (*JSONSchema6, error) {
+	introspection, err := IntrospectionFromSDL(sdl)
+	if err != nil {
+		return nil, err
+	}
+	return FromIntrospectionQuery(*introspection, opts)
+}
+
+// IntrospectionFromSDL reads a GraphQL schema in the schema definition language into the result of
+// the standard introspection query. Type extensions are merged into the types they extend, the
+// built-in scalars in use, described as graphql-js describes them, and the introspection types are
+// added, and without a schema definition the types named Query, Mutation and Subscription are the
+// roots. Directive definitions and
+// directives other than @deprecated are parsed but, as introspection does not expose them, dropped.
+func IntrospectionFromSDL(sdl string) (*IntrospectionQuery, error) {
+	p, err := parseSDL(sdl)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyExtensions(); err != nil {
+		return nil, err
+	}
+
+	referenced := p.referencedNames()
+	for _, name := range scalarOrder {
+		if _, ok := p.index[name]; !ok && (name == "String" || name == "Boolean" || referenced[name]) {
+			p.add(IntrospectionType{Kind: "SCALAR", Name: name, Description: scalarDescriptions[name]})
+		}
+	}
+	internal, err := parseSDL(introspectionSDL)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range internal.types {
+		if _, ok := p.index[t.Name]; !ok {
+			p.add(t)
+		}
+	}
+
+	if err := p.resolveKinds(); err != nil {
+		return nil, err
+	}
+	schema, err := p.schema()
+	if err != nil {
+		return nil, err
+	}
+	return &IntrospectionQuery{Schema: schema}, nil
+}
+
+// sdlParser reads type system definitions into introspection types
+type sdlParser struct {
+	documentParser
+	types      []IntrospectionType
+	index      map[string]int
+	extensions []IntrospectionType
+	// roots maps query, mutation and subscription to their types when a schema definition is given
+	roots map[string]string
+}
+
+func parseSDL(sdl string) (*sdlParser, error) {
+	p := &sdlParser{documentParser: documentParser{lexer: newLexer(sdl)}, index: make(map[string]int)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for p.token.kind != tokenEOF {
+		if err := p.parseDefinition(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// add appends a type to the schema
+func (p *sdlParser) add(t IntrospectionType) {
+	p.index[t.Name] = len(p.types)
+	p.types = append(p.types, t)
+}
+
+func (p *sdlParser) parseDefinition() error {
+	description, err := p.parseDescription()
+	if err != nil {
+		return err
+	}
+	extend, err := p.skip("extend")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case p.peek("schema"):
+		return p.parseSchemaDefinition()
+	case p.peek("directive") && !extend:
+		return p.skipDirectiveDefinition()
+	case p.peek("type"), p.peek("interface"), p.peek("input"), p.peek("enum"), p.peek("union"), p.peek("scalar"):
+		position := p.token.position
+		t, err := p.parseTypeDefinition()
+		if err != nil {
+			return err
+		}
+		if extend {
+			p.extensions = append(p.extensions, t)
+			return nil
+		}
+		if _, ok := p.index[t.Name]; ok {
+			return p.lexer.errorf(position, "type %s is defined more than once", t.Name)
+		}
+		t.Description = description
+		p.add(t)
+		return nil
+	case p.peek("{"), p.peek("query"), p.peek("mutation"), p.peek("subscription"), p.peek("fragment"):
+		return p.errorf("executable definitions are not allowed in a schema, found %s", p.token)
+	}
+	return p.errorf("expected a type system definition, found %s", p.token)
+}
+
+// parseDescription consumes the string preceding a definition, if any
+func (p *sdlParser) parseDescription() (string, error) {
+	if p.token.kind != tokenString {
+		return "", nil
+	}
+	description := p.token.value
+	return description, p.advance()
+}
+
+// parseSchemaDefinition reads "schema { query: Query ... }" or an extension of it
+func (p *sdlParser) parseSchemaDefinition() error {
+	if err := p.expect("schema"); err != nil {
+		return err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return err
+	}
+	if !p.peek("{") {
+		return nil
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.roots == nil {
+		p.roots = make(map[string]string)
+	}
+	for !p.peek("}") {
+		operation, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if operation != "query" && operation != "mutation" && operation != "subscription" {
+			return p.errorf("unknown operation type %s", operation)
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if p.roots[operation], err = p.expectName(); err != nil {
+			return err
+		}
+	}
+	return p.advance()
+}
+
+// skipDirectiveDefinition reads "directive @name(args) repeatable on LOCATION | ..." and drops it
+func (p *sdlParser) skipDirectiveDefinition() error {
+	if err := p.expect("directive"); err != nil {
+		return err
+	}
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+	if _, err := p.expectName(); err != nil {
+		return err
+	}
+	if p.peek("(") {
+		if _, err := p.parseArgumentDefinitions(); err != nil {
+			return err
+		}
+	}
+	if _, err := p.skip("repeatable"); err != nil {
+		return err
+	}
+	if err := p.expect("on"); err != nil {
+		return err
+	}
+	if _, err := p.skip("|"); err != nil {
+		return err
+	}
+	for {
+		if _, err := p.expectName(); err != nil {
+			return err
+		}
+		if ok, err := p.skip("|"); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// parseTypeDefinition reads a type definition, or the body of a type extension, without its description
+func (p *sdlParser) parseTypeDefinition() (IntrospectionType, error) {
+	keyword := p.token.value
+	if err := p.advance(); err != nil {
+		return IntrospectionType{}, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return IntrospectionType{}, err
+	}
+
+	var t IntrospectionType
+	switch keyword {
+	case "type", "interface":
+		t = IntrospectionType{Kind: "OBJECT", Name: name, Fields: []IntrospectionField{}, Interfaces: []TypeRef{}}
+		if keyword == "interface" {
+			t.Kind, t.PossibleTypes = "INTERFACE", []IntrospectionType{}
+		}
+		if t.Interfaces, err = p.parseImplements(); err != nil {
+			return t, err
+		}
+		if _, err := p.parseDirectives(); err != nil {
+			return t, err
+		}
+		if p.peek("{") {
+			t.Fields, err = p.parseFieldDefinitions()
+		}
+	case "input":
+		t = IntrospectionType{Kind: "INPUT_OBJECT", Name: name, InputFields: []IntrospectionInput{}}
+		if _, err := p.parseDirectives(); err != nil {
+			return t, err
+		}
+		if p.peek("{") {
+			t.InputFields, err = p.parseInputFieldDefinitions()
+		}
+	case "enum":
+		t = IntrospectionType{Kind: "ENUM", Name: name, EnumValues: []IntrospectionEnum{}}
+		if _, err := p.parseDirectives(); err != nil {
+			return t, err
+		}
+		if p.peek("{") {
+			t.EnumValues, err = p.parseEnumValueDefinitions()
+		}
+	case "union":
+		t = IntrospectionType{Kind: "UNION", Name: name, PossibleTypes: []IntrospectionType{}}
+		if _, err := p.parseDirectives(); err != nil {
+			return t, err
+		}
+		t.PossibleTypes, err = p.parseUnionMembers()
+	case "scalar":
+		t = IntrospectionType{Kind: "SCALAR", Name: name}
+		var directives []*Directive
+		directives, err = p.parseDirectives()
+		t.SpecifiedByURL = specifiedBy(directives)
+	}
+	return t, err
+}
+
+// parseImplements reads "implements A & B", which may also start with "&"
+func (p *sdlParser) parseImplements() ([]TypeRef, error) {
+	interfaces := []TypeRef{}
+	if ok, err := p.skip("implements"); err != nil || !ok {
+		return interfaces, err
+	}
+	if _, err := p.skip("&"); err != nil {
+		return nil, err
+	}
+	for {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		interfaces = append(interfaces, TypeRef{Kind: "INTERFACE", Name: name})
+		if ok, err := p.skip("&"); err != nil {
+			return nil, err
+		} else if !ok {
+			return interfaces, nil
+		}
+	}
+}
+
+// parseUnionMembers reads "= A | B", which may also start with "|"
+func (p *sdlParser) parseUnionMembers() ([]IntrospectionType, error) {
+	members := []IntrospectionType{}
+	if ok, err := p.skip("="); err != nil || !ok {
+		return members, err
+	}
+	if _, err := p.skip("|"); err != nil {
+		return nil, err
+	}
+	for {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, IntrospectionType{Kind: "OBJECT", Name: name})
+		if ok, err := p.skip("|"); err != nil {
+			return nil, err
+		} else if !ok {
+			return members, nil
+		}
+	}
+}
+
+func (p *sdlParser) parseFieldDefinitions() ([]IntrospectionField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	fields := []IntrospectionField{}
+	for !p.peek("}") {
+		description, err := p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		field := IntrospectionField{Description: description, Args: []IntrospectionArg{}}
+		if field.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		if p.peek("(") {
+			if field.Args, err = p.parseArgumentDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if field.Type, err = p.parseTypeRef(); err != nil {
+			return nil, err
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		field.IsDeprecated, field.DeprecationReason = deprecation(directives)
+		fields = append(fields, field)
+	}
+	return fields, p.advance()
+}
+
+func (p *sdlParser) parseArgumentDefinitions() ([]IntrospectionArg, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := []IntrospectionArg{}
+	for !p.peek(")") {
+		arg, err := p.parseInputValueDefinition()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, IntrospectionArg(arg))
+	}
+	return args, p.advance()
+}
+
+func (p *sdlParser) parseInputFieldDefinitions() ([]IntrospectionInput, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	fields := []IntrospectionInput{}
+	for !p.peek("}") {
+		field, err := p.parseInputValueDefinition()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, p.advance()
+}
+
+// parseInputValueDefinition reads an argument or input field: "name: Type = default @directives"
+func (p *sdlParser) parseInputValueDefinition() (IntrospectionInput, error) {
+	description, err := p.parseDescription()
+	if err != nil {
+		return IntrospectionInput{}, err
+	}
+	value := IntrospectionInput{Description: description}
+	if value.Name, err = p.expectName(); err != nil {
+		return value, err
+	}
+	if err := p.expect(":"); err != nil {
+		return value, err
+	}
+	if value.Type, err = p.parseTypeRef(); err != nil {
+		return value, err
+	}
+	if ok, err := p.skip("="); err != nil {
+		return value, err
+	} else if ok {
+		defaultValue, err := p.parseValue(true)
+		if err != nil {
+			return value, err
+		}
+		printed := printValue(defaultValue)
+		value.DefaultValue = &printed
+	}
+	directives, err := p.parseDirectives()
+	value.IsDeprecated, value.DeprecationReason = deprecation(directives)
+	return value, err
+}
+
+func (p *sdlParser) parseEnumValueDefinitions() ([]IntrospectionEnum, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	values := []IntrospectionEnum{}
+	for !p.peek("}") {
+		description, err := p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		value := IntrospectionEnum{Description: description}
+		if value.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		value.IsDeprecated, value.DeprecationReason = deprecation(directives)
+		values = append(values, value)
+	}
+	return values, p.advance()
+}
+
+// parseTypeRef reads a type such as [String!]! into the nested references introspection returns.
+// Kinds of named types are filled in by resolveKinds once every type is known.
+func (p *sdlParser) parseTypeRef() (IntrospectionTypeRef, error) {
+	var typeRef IntrospectionTypeRef
+	if ok, err := p.skip("["); err != nil {
+		return typeRef, err
+	} else if ok {
+		ofType, err := p.parseTypeRef()
+		if err != nil {
+			return typeRef, err
+		}
+		if err := p.expect("]"); err != nil {
+			return typeRef, err
+		}
+		typeRef = IntrospectionTypeRef{Kind: "LIST", OfType: &ofType}
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return typeRef, err
+		}
+		typeRef = IntrospectionTypeRef{Name: &name}
+	}
+
+	if ok, err := p.skip("!"); err != nil {
+		return typeRef, err
+	} else if ok {
+		ofType := typeRef
+		typeRef = IntrospectionTypeRef{Kind: "NON_NULL", OfType: &ofType}
+	}
+	return typeRef, nil
+}
+
+// deprecation returns what @deprecated among directives makes of a field, input value or enum value
+func deprecation(directives []*Directive) (bool, *string) {
+	for _, directive := range directives {
+		if directive.Name != "deprecated" {
+			continue
+		}
+		reason := defaultDeprecationReason
+		for _, argument := range directive.Arguments {
+			if argument.Name == "reason" && argument.Value.Kind == ValueString {
+				reason = argument.Value.Raw
+			}
+		}
+		return true, &reason
+	}
+	return false, nil
+}
+
+// specifiedBy returns the url of @specifiedBy among the directives of a scalar
+func specifiedBy(directives []*Directive) string {
+	for _, directive := range directives {
+		if directive.Name != "specifiedBy" {
+			continue
+		}
+		for _, argument := range directive.Arguments {
+			if argument.Name == "url" && argument.Value.Kind == ValueString {
+				return argument.Value.Raw
+			}
+		}
+	}
+	return ""
+}
+
+// applyExtensions merges type extensions into the types they extend
+func (p *sdlParser) applyExtensions() error {
+	for _, extension := range p.extensions {
+		i, ok := p.index[extension.Name]
+		if !ok {
+			return fmt.Errorf("cannot extend type %s: it is not defined", extension.Name)
+		}
+		t := &p.types[i]
+		if t.Kind != extension.Kind {
+			return fmt.Errorf("cannot extend type %s: it is %s, not %s", t.Name, t.Kind, extension.Kind)
+		}
+		t.Fields = append(t.Fields, extension.Fields...)
+		t.InputFields = append(t.InputFields, extension.InputFields...)
+		t.Interfaces = append(t.Interfaces, extension.Interfaces...)
+		t.EnumValues = append(t.EnumValues, extension.EnumValues...)
+		if t.Kind == "UNION" {
+			t.PossibleTypes = append(t.PossibleTypes, extension.PossibleTypes...)
+		}
+		if extension.SpecifiedByURL != "" {
+			t.SpecifiedByURL = extension.SpecifiedByURL
+		}
+	}
+	return nil
+}
+
+// referencedNames returns the names of all types referenced by fields, arguments and input fields
+func (p *sdlParser) referencedNames() map[string]bool {
+	names := make(map[string]bool)
+	p.eachTypeRef(func(typeRef *IntrospectionTypeRef) error {
+		names[*typeRef.Name] = true
+		return nil
+	})
+	return names
+}
+
+// eachTypeRef calls fn with the named type at the end of every field, argument and input field type
+func (p *sdlParser) eachTypeRef(fn func(typeRef *IntrospectionTypeRef) error) error {
+	named := func(typeRef *IntrospectionTypeRef) error {
+		for typeRef.OfType != nil {
+			typeRef = typeRef.OfType
+		}
+		return fn(typeRef)
+	}
+	for i := range p.types {
+		t := &p.types[i]
+		for j := range t.Fields {
+			if err := named(&t.Fields[j].Type); err != nil {
+				return err
+			}
+			for k := range t.Fields[j].Args {
+				if err := named(&t.Fields[j].Args[k].Type); err != nil {
+					return err
+				}
+			}
+		}
+		for j := range t.InputFields {
+			if err := named(&t.InputFields[j].Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveKinds fills in the kinds of named type references, checks that interfaces and union members
+// exist, and lists the objects implementing each interface as its possible types
+func (p *sdlParser) resolveKinds() error {
+	err := p.eachTypeRef(func(typeRef *IntrospectionTypeRef) error {
+		i, ok := p.index[*typeRef.Name]
+		if !ok {
+			return fmt.Errorf("unknown type %s", *typeRef.Name)
+		}
+		typeRef.Kind = p.types[i].Kind
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range p.types {
+		for _, iface := range t.Interfaces {
+			if i, ok := p.index[iface.Name]; !ok || p.types[i].Kind != "INTERFACE" {
+				return fmt.Errorf("type %s implements %s, which is not an interface", t.Name, iface.Name)
+			}
+		}
+		if t.Kind != "UNION" {
+			continue
+		}
+		for _, member := range t.PossibleTypes {
+			if i, ok := p.index[member.Name]; !ok || p.types[i].Kind != "OBJECT" {
+				return fmt.Errorf("union %s includes %s, which is not an object type", t.Name, member.Name)
+			}
+		}
+	}
+
+	for _, t := range p.types {
+		if t.Kind != "OBJECT" {
+			continue
+		}
+		for _, iface := range t.Interfaces {
+			i := p.index[iface.Name]
+			p.types[i].PossibleTypes = append(p.types[i].PossibleTypes, IntrospectionType{Kind: "OBJECT", Name: t.Name})
+		}
+	}
+	return nil
+}
+
+// schema returns the introspected schema with its root types
+func (p *sdlParser) schema() (IntrospectionSchema, error) {
+	roots := p.roots
+	if roots == nil {
+		roots = make(map[string]string)
+		for operation, name := range map[string]string{"query": "Query", "mutation": "Mutation", "subscription": "Subscription"} {
+			if _, ok := p.index[name]; ok {
+				roots[operation] = name
+			}
+		}
+	}
+
+	schema := IntrospectionSchema{Types: p.types}
+	for _, root := range []struct {
+		operation string
+		typeRef   **TypeRef
+	}{
+		{"query", &schema.QueryType},
+		{"mutation", &schema.MutationType},
+		{"subscription", &schema.SubscriptionType},
+	} {
+		name, ok := roots[root.operation]
+		if !ok {
+			continue
+		}
+		if i, ok := p.index[name]; !ok || p.types[i].Kind != "OBJECT" {
+			return schema, fmt.Errorf("%s root %s is not an object type", root.operation, name)
+		}
+		*root.typeRef = &TypeRef{Name: name}
+	}
+	return schema, nil
+}
+
+// printValue prints a value as a GraphQL literal the way introspection reports default values
+func printValue(value *Value) string {
+	switch value.Kind {
+	case ValueString:
+		return quoteString(value.Raw)
+	case ValueVariable:
+		return "$" + value.Raw
+	case ValueList:
+		items := make([]string, len(value.List))
+		for i, item := range value.List {
+			items[i] = printValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ValueObject:
+		fields := make([]string, len(value.Fields))
+		for i, field := range value.Fields {
+			fields[i] = field.Name + ": " + printValue(field.Value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return value.Raw
+}
+
+// quoteString prints a GraphQL string literal, escaping quotes, backslashes and control characters
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
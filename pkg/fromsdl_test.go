@@ -0,0 +1,67 @@
+package pkg_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// TestFromSDLParity converts testdata/fromsdl/schema.graphql with FromSDL and the introspection
+// response graphql-js returns for the same schema with FromReader, which must agree byte for byte
+func TestFromSDLParity(t *testing.T) {
+	sdl, err := os.ReadFile(filepath.Join("testdata", "fromsdl", "schema.graphql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		set  func(*pkg.Options)
+	}{
+		{"defaults", nil},
+		{"nullable anyOf and closed objects", func(o *pkg.Options) {
+			o.NullableFields = pkg.NullableFieldsAnyOf
+			o.AdditionalPropertiesFalse = true
+		}},
+		{"interface implementations", func(o *pkg.Options) {
+			o.InterfaceImplementations = pkg.InterfaceImplementationsOneOf
+			o.InterfaceKeepFields = true
+		}},
+		{"interface allOf", func(o *pkg.Options) { o.InterfaceAllOf = true }},
+		{"plain fields on 2020-12", func(o *pkg.Options) {
+			o.FieldShape = pkg.FieldShapePlain
+			o.Draft = pkg.Draft202012
+			o.EnumStyle = pkg.EnumStyleAnyOf
+		}},
+		{"integers and well-known scalars", func(o *pkg.Options) {
+			o.UseIntegerType = true
+			o.IntBounds = true
+			o.WellKnownScalars = true
+		}},
+		{"embedded SDL", func(o *pkg.Options) { o.EmbedSDL = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromSDL, err := pkg.FromSDL(string(sdl), options(tt.set))
+			if err != nil {
+				t.Fatalf("FromSDL: %v", err)
+			}
+			fixture, err := os.Open(filepath.Join("testdata", "fromsdl", "introspection.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fixture.Close()
+			fromIntrospection, err := pkg.FromReader(fixture, options(tt.set))
+			if err != nil {
+				t.Fatalf("FromReader: %v", err)
+			}
+
+			got, want := mustJSON(t, fromSDL), mustJSON(t, fromIntrospection)
+			if !bytes.Equal(got, want) {
+				t.Errorf("FromSDL differs from the introspection:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
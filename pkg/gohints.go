@@ -0,0 +1,46 @@
+package pkg
+
+// GoTypeHint tells Go code generators which type to use for a GraphQL type. It is emitted as the
+// x-go-type, x-go-type-import and x-go-name extensions read by oapi-codegen and go-jsonschema
+// style generators.
+type GoTypeHint struct {
+	// Type is the Go type expression to use, e.g. time.Time
+	Type string `json:"type,omitempty" yaml:"type"`
+	// Import is the import path Type needs, e.g. time
+	Import string `json:"import,omitempty" yaml:"import"`
+	// Name is the name of the Go type generated for the schema, e.g. ID for a named string type
+	Name string `json:"name,omitempty" yaml:"name"`
+}
+
+// GoTypeImport is the x-go-type-import extension
+type GoTypeImport struct {
+	Path string `json:"path"`
+}
+
+// DefaultGoHints returns the Go types of the common scalars: date-times as time.Time, big integers
+// as int64, JSON as json.RawMessage and ID as a named string type
+func DefaultGoHints() map[string]GoTypeHint {
+	return map[string]GoTypeHint{
+		"ID":       {Name: "ID"},
+		"DateTime": {Type: "time.Time", Import: "time"},
+		"Date":     {Type: "time.Time", Import: "time"},
+		"BigInt":   {Type: "int64"},
+		"Long":     {Type: "int64"},
+		"Int64":    {Type: "int64"},
+		"JSON":     {Type: "json.RawMessage", Import: "encoding/json"},
+	}
+}
+
+// applyGoHint annotates schema with the hint for the named type, if the options have one
+func applyGoHint(schema *JSONSchema6, name string, opts *Options) {
+	hint, ok := opts.GoHints[name]
+	if !ok {
+		return
+	}
+	schema.GoType = hint.Type
+	schema.GoName = hint.Name
+	schema.GoTypeImport = nil
+	if hint.Import != "" {
+		schema.GoTypeImport = &GoTypeImport{Path: hint.Import}
+	}
+}
@@ -0,0 +1,87 @@
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const goHintsSDL = `
+scalar DateTime
+scalar BigInt
+
+type Query { user(id: ID!, since: DateTime): User }
+
+type User {
+  id: ID!
+  joined: DateTime!
+  visits: [BigInt!]
+  name: String
+}
+
+input UserFilter { after: DateTime ids: [ID!] }
+`
+
+func TestGoHints(t *testing.T) {
+	schema := mustConvert(t, goHintsSDL, options(func(o *pkg.Options) { o.GoHints = pkg.DefaultGoHints() }))
+
+	tests := []struct {
+		pointer string
+		goType  string
+		imports string
+		goName  string
+	}{
+		{"#/definitions/DateTime", "time.Time", "time", ""},
+		{"#/definitions/BigInt", "int64", "", ""},
+		{"#/definitions/ID", "", "", "ID"},
+		{"#/definitions/User/properties/joined/properties/return", "time.Time", "time", ""},
+		{"#/definitions/User/properties/visits/properties/return/items", "int64", "", ""},
+		{"#/definitions/User/properties/id/properties/return", "", "", "ID"},
+		{"#/definitions/UserFilter/properties/after", "time.Time", "time", ""},
+		{"#/definitions/UserFilter/properties/ids/items", "", "", "ID"},
+		{"#/properties/Query/properties/user/properties/arguments/properties/since", "time.Time", "time", ""},
+		{"#/definitions/User/properties/name/properties/return", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			node := mustPointer(t, schema, tt.pointer)
+			if node.GoType != tt.goType || node.GoName != tt.goName {
+				t.Errorf("x-go-type %q, x-go-name %q; want %q, %q", node.GoType, node.GoName, tt.goType, tt.goName)
+			}
+			switch {
+			case tt.imports == "" && node.GoTypeImport != nil:
+				t.Errorf("unexpected x-go-type-import %+v", node.GoTypeImport)
+			case tt.imports != "" && (node.GoTypeImport == nil || node.GoTypeImport.Path != tt.imports):
+				t.Errorf("x-go-type-import = %+v, want %s", node.GoTypeImport, tt.imports)
+			}
+		})
+	}
+}
+
+func TestGoHintsOverrides(t *testing.T) {
+	hints := pkg.DefaultGoHints()
+	hints["User"] = pkg.GoTypeHint{Name: "Account"}
+	hints["DateTime"] = pkg.GoTypeHint{Type: "civil.DateTime", Import: "cloud.google.com/go/civil"}
+	delete(hints, "ID")
+	schema := mustConvert(t, goHintsSDL, options(func(o *pkg.Options) { o.GoHints = hints }))
+
+	if user := mustPointer(t, schema, "#/definitions/User"); user.GoName != "Account" {
+		t.Errorf("User x-go-name = %q, want Account", user.GoName)
+	}
+	joined := mustPointer(t, schema, "#/definitions/User/properties/joined/properties/return")
+	if joined.GoType != "civil.DateTime" || joined.GoTypeImport == nil || joined.GoTypeImport.Path != "cloud.google.com/go/civil" {
+		t.Errorf("DateTime usage hint = %q %+v", joined.GoType, joined.GoTypeImport)
+	}
+	if id := mustPointer(t, schema, "#/definitions/ID"); id.GoName != "" {
+		t.Errorf("the removed ID hint is still emitted: %q", id.GoName)
+	}
+}
+
+func TestGoHintsOffByDefault(t *testing.T) {
+	schema := mustConvert(t, goHintsSDL, nil)
+	pkg.Walk(schema, func(node *pkg.JSONSchema6) {
+		if node.GoType != "" || node.GoName != "" || node.GoTypeImport != nil {
+			t.Errorf("hint emitted without the option: %+v", node)
+		}
+	})
+}
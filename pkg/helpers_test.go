@@ -0,0 +1,119 @@
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// update rewrites the golden files under testdata instead of comparing against them
+var update = flag.Bool("update", false, "update golden files")
+
+// mustIntrospect reads an SDL fixture into an introspection result
+func mustIntrospect(t testing.TB, sdl string) pkg.IntrospectionQuery {
+	t.Helper()
+	introspection, err := pkg.IntrospectionFromSDL(sdl)
+	if err != nil {
+		t.Fatalf("IntrospectionFromSDL: %v", err)
+	}
+	return *introspection
+}
+
+// mustConvert converts an SDL fixture with FromIntrospectionQuery
+func mustConvert(t testing.TB, sdl string, opts *pkg.Options) *pkg.JSONSchema6 {
+	t.Helper()
+	schema, err := pkg.FromIntrospectionQuery(mustIntrospect(t, sdl), opts)
+	if err != nil {
+		t.Fatalf("FromIntrospectionQuery: %v", err)
+	}
+	return schema
+}
+
+// options returns the default options after applying set
+func options(set func(*pkg.Options)) *pkg.Options {
+	opts := pkg.DefaultOptions()
+	if set != nil {
+		set(&opts)
+	}
+	return &opts
+}
+
+// mustJSON marshals v as indented JSON
+func mustJSON(t testing.TB, v interface{}) []byte {
+	t.Helper()
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+// mustPointer resolves a JSON pointer into a schema document
+func mustPointer(t testing.TB, schema *pkg.JSONSchema6, pointer string) *pkg.JSONSchema6 {
+	t.Helper()
+	node, err := schema.GetByPointer(pointer)
+	if err != nil {
+		t.Fatalf("GetByPointer(%q): %v", pointer, err)
+	}
+	return node
+}
+
+// danglingRefs returns the local $refs of a schema document that resolve to nothing
+func danglingRefs(schema *pkg.JSONSchema6) []string {
+	var dangling []string
+	pkg.Walk(schema, func(node *pkg.JSONSchema6) {
+		if node.Ref == "" {
+			return
+		}
+		if _, err := schema.GetByPointer(node.Ref); err != nil {
+			dangling = append(dangling, node.Ref)
+		}
+	})
+	return dangling
+}
+
+// validateJSON validates a JSON instance against a schema, resolving references against root
+func validateJSON(t testing.TB, root, schema *pkg.JSONSchema6, instance string) []pkg.ValidationError {
+	t.Helper()
+	decoded, err := pkg.DecodeInstance([]byte(instance))
+	if err != nil {
+		t.Fatalf("decode %s: %v", instance, err)
+	}
+	return pkg.Validate(root, schema, decoded)
+}
+
+// assertGolden compares got with testdata/name, rewriting the file with -update
+func assertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run with -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// definitionNames lists the definitions of a schema
+func definitionNames(schema *pkg.JSONSchema6) map[string]bool {
+	names := make(map[string]bool, len(schema.Definitions))
+	for name := range schema.Definitions {
+		names[name] = true
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+package pkg_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const integerSDL = `
+type Query {
+  items(limit: Int = 10, offsets: [Int!] = [0, 5]): Page
+}
+
+type Page {
+  count: Int!
+  sizes: [Int]
+  matrix: [[Int!]!]
+  ratio: Float
+}
+
+input PageInput { limit: Int! = 20 weights: [Float!] }
+`
+
+func TestUseIntegerType(t *testing.T) {
+	tests := []struct {
+		pointer string
+		def     interface{}
+	}{
+		{"#/definitions/Page/properties/count/properties/return", nil},
+		{"#/definitions/Page/properties/sizes/properties/return/items", nil},
+		{"#/definitions/Page/properties/matrix/properties/return/items/items", nil},
+		{"#/definitions/PageInput/properties/limit", float64(20)},
+		{"#/properties/Query/properties/items/properties/arguments/properties/limit", float64(10)},
+		{"#/properties/Query/properties/items/properties/arguments/properties/offsets/items", nil},
+	}
+	floats := []string{
+		"#/definitions/Page/properties/ratio/properties/return",
+		"#/definitions/PageInput/properties/weights/items",
+	}
+
+	for _, mode := range []struct {
+		name    string
+		set     func(*pkg.Options)
+		typ     string
+		bounded bool
+	}{
+		{"default", nil, "number", false},
+		{"integer", func(o *pkg.Options) { o.UseIntegerType = true }, "integer", false},
+		{"integer with bounds", func(o *pkg.Options) { o.UseIntegerType, o.IntBounds = true, true }, "integer", true},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			schema := mustConvert(t, integerSDL, options(mode.set))
+			for _, tt := range tests {
+				node := mustPointer(t, schema, tt.pointer)
+				if node.Type != mode.typ {
+					t.Errorf("%s: type %v, want %s", tt.pointer, node.Type, mode.typ)
+				}
+				if bounded := node.Minimum != nil && *node.Minimum == -2147483648 && node.Maximum != nil && *node.Maximum == 2147483647; bounded != mode.bounded {
+					t.Errorf("%s: minimum %v, maximum %v", tt.pointer, node.Minimum, node.Maximum)
+				}
+				// Defaults are kept as they are
+				if tt.def != nil && !reflect.DeepEqual(node.Default, tt.def) {
+					t.Errorf("%s: default %v, want %v", tt.pointer, node.Default, tt.def)
+				}
+			}
+			for _, pointer := range floats {
+				if node := mustPointer(t, schema, pointer); node.Type != "number" || node.Minimum != nil {
+					t.Errorf("%s: Float became %v with minimum %v", pointer, node.Type, node.Minimum)
+				}
+			}
+		})
+	}
+}
+
+func TestUseIntegerTypeValidation(t *testing.T) {
+	schema := mustConvert(t, integerSDL, options(func(o *pkg.Options) { o.UseIntegerType, o.IntBounds = true, true }))
+	input := mustPointer(t, schema, "#/definitions/PageInput")
+	tests := []struct {
+		instance string
+		valid    bool
+	}{
+		{`{"limit": 3, "weights": [1.5]}`, true},
+		{`{"limit": 3.0}`, true},
+		{`{"limit": 1.5}`, false},
+		{`{"limit": 2147483648}`, false},
+		{`{"limit": -2147483648}`, true},
+	}
+	for _, tt := range tests {
+		errs := validateJSON(t, schema, input, tt.instance)
+		if valid := len(errs) == 0; valid != tt.valid {
+			t.Errorf("%s: valid = %t, want %t: %+v", tt.instance, valid, tt.valid, errs)
+		}
+	}
+}
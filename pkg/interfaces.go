@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"reflect"
+	"slices"
+)
+
+// InterfaceImplementations selects how interface definitions list the object types implementing them
+type InterfaceImplementations string
+
+const (
+	// InterfaceImplementationsOff converts interfaces like objects, with their fields only
+	InterfaceImplementationsOff InterfaceImplementations = "off"
+	// InterfaceImplementationsAnyOf lists the implementations in anyOf, as references
+	InterfaceImplementationsAnyOf InterfaceImplementations = "anyOf"
+	// InterfaceImplementationsOneOf lists the implementations in oneOf, as references, so that a value
+	// must match exactly one of them
+	InterfaceImplementationsOneOf InterfaceImplementations = "oneOf"
+)
+
+// IsValidInterfaceImplementations checks if the provided InterfaceImplementations mode is valid;
+// empty means off
+func IsValidInterfaceImplementations(mode InterfaceImplementations) bool {
+	switch mode {
+	case "", InterfaceImplementationsOff, InterfaceImplementationsAnyOf, InterfaceImplementationsOneOf:
+		return true
+	}
+	return false
+}
+
+// on reports whether interfaces list their implementations in the mode
+func (i InterfaceImplementations) on() bool {
+	return i != "" && i != InterfaceImplementationsOff
+}
+
+// listImplementations sets the references to the object types implementing an interface as its
+// anyOf or oneOf, as Options.InterfaceImplementations selects. It reports whether it did, which it
+// does not for interfaces without implementations.
+func listImplementations(nodes *schemaSlab, schema *JSONSchema6, t IntrospectionType, opts *Options) bool {
+	if t.Kind != "INTERFACE" || !opts.InterfaceImplementations.on() || len(t.PossibleTypes) == 0 {
+		return false
+	}
+	implementations := make([]*JSONSchema6, len(t.PossibleTypes))
+	for i, possibleType := range t.PossibleTypes {
+		ref := nodes.next()
+		ref.Ref = opts.definitionRef(possibleType.Name)
+		implementations[i] = ref
+	}
+	if opts.InterfaceImplementations == InterfaceImplementationsOneOf {
+		schema.OneOf = implementations
+	} else {
+		schema.AnyOf = implementations
+	}
+	return true
+}
+
+// withInterfaces indexes the interface types for Options.InterfaceAllOf, so that processType can
+// compare the fields of an object with those of the interfaces it implements
+func withInterfaces(types typeIndex, opts *Options) *Options {
+	if !opts.InterfaceAllOf {
+		return opts
+	}
+	interfaces := make(map[string]*IntrospectionType)
+	for name, t := range types {
+		if t.Kind == "INTERFACE" {
+			interfaces[name] = t
+		}
+	}
+	resolved := *opts
+	resolved.interfaces = interfaces
+	return &resolved
+}
+
+// implementedInterfaces returns the converted interfaces an object or interface type inherits from
+// with Options.InterfaceAllOf, in the order it declares them
+func implementedInterfaces(t IntrospectionType, opts *Options) []*IntrospectionType {
+	if !opts.InterfaceAllOf {
+		return nil
+	}
+	var interfaces []*IntrospectionType
+	for _, ref := range t.Interfaces {
+		if iface := opts.interfaces[ref.Name]; iface != nil {
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return interfaces
+}
+
+// inheritedField reports whether one of the interfaces declares the field exactly as the type does,
+// so that its schema comes from that interface through allOf. Fields that narrow the interface's
+// type, or differ in arguments, description or deprecation, are not inherited and keep their own
+// schema, which applies alongside the interface's.
+func inheritedField(field IntrospectionField, interfaces []*IntrospectionType) bool {
+	for _, iface := range interfaces {
+		declared := findField(iface.Fields, field.Name)
+		if declared == nil {
+			continue
+		}
+		if declared.Description == field.Description &&
+			reflect.DeepEqual(declared.Type, field.Type) &&
+			declared.IsDeprecated == field.IsDeprecated &&
+			reflect.DeepEqual(declared.DeprecationReason, field.DeprecationReason) &&
+			slices.EqualFunc(declared.Args, field.Args, func(a, b IntrospectionArg) bool { return reflect.DeepEqual(a, b) }) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// interfacesSDL has an interface with three implementations, which inherits from another interface
+// and redeclares its field as the spec requires
+const interfacesSDL = `
+type Query { actor(id: ID!): Actor node(id: ID!): Node }
+
+interface Node { id: ID! }
+
+"Something that acts"
+interface Actor implements Node {
+  id: ID!
+  name: String!
+}
+
+type User implements Node & Actor { id: ID! name: String! email: String! }
+
+type Team implements Node & Actor { id: ID! name: String! members: [User!]! }
+
+type Bot implements Node & Actor { id: ID! name: String! owner: User! }
+`
+
+// interfaceDefinitions is the part of a converted schema the interface options change
+func interfaceDefinitions(t *testing.T, schema *pkg.JSONSchema6) []byte {
+	t.Helper()
+	subset := make(map[string]*pkg.JSONSchema6)
+	for _, name := range []string{"Node", "Actor", "User", "Team", "Bot"} {
+		subset[name] = schema.Definitions[name]
+	}
+	data, err := json.MarshalIndent(subset, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(data, '\n')
+}
+
+func TestInterfaceImplementationsGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		set    func(*pkg.Options)
+	}{
+		// Leaving the mode empty is the same as off
+		{"interfaces/off.json", nil},
+		{"interfaces/off.json", func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsOff }},
+		{"interfaces/anyof.json", func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsAnyOf }},
+		{"interfaces/oneof.json", func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsOneOf }},
+		{"interfaces/oneof-keep-fields.json", func(o *pkg.Options) {
+			o.InterfaceImplementations = pkg.InterfaceImplementationsOneOf
+			o.InterfaceKeepFields = true
+		}},
+		{"interfaces/allof.json", func(o *pkg.Options) { o.InterfaceAllOf = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			schema := mustConvert(t, interfacesSDL, options(tt.set))
+			if dangling := danglingRefs(schema); len(dangling) > 0 {
+				t.Errorf("dangling refs: %q", dangling)
+			}
+			assertGolden(t, tt.golden, interfaceDefinitions(t, schema))
+		})
+	}
+}
+
+func TestInterfaceImplementations(t *testing.T) {
+	const (
+		user = `{"id": {"return": "1"}, "name": {"return": "Ada"}, "email": {"return": "ada@example.com"}}`
+		// both matches User and Team
+		both    = `{"id": {"return": "1"}, "name": {"return": "Ada"}, "email": {"return": "ada@example.com"}, "members": {"return": []}}`
+		neither = `{"id": {"return": "1"}, "name": {"return": "Ada"}}`
+	)
+	tests := []struct {
+		name        string
+		set         func(*pkg.Options)
+		implementer []string // the references listed by Actor
+		properties  []string // Actor's own properties
+		valid       map[string]bool
+	}{
+		{"unset", nil, nil, []string{"id", "name"},
+			map[string]bool{user: true, both: true, neither: true}},
+		{"off", func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsOff }, nil, []string{"id", "name"},
+			map[string]bool{user: true, both: true, neither: true}},
+		{"anyOf", func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsAnyOf },
+			[]string{"#/definitions/User", "#/definitions/Team", "#/definitions/Bot"}, nil,
+			map[string]bool{user: true, both: true, neither: false}},
+		{"oneOf", func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsOneOf },
+			[]string{"#/definitions/User", "#/definitions/Team", "#/definitions/Bot"}, nil,
+			map[string]bool{user: true, both: false, neither: false}},
+		// The fields Actor redeclares from Node are kept too, as interfaces listing their
+		// implementations do not inherit through allOf
+		{"oneOf keeping fields", func(o *pkg.Options) {
+			o.InterfaceImplementations = pkg.InterfaceImplementationsOneOf
+			o.InterfaceKeepFields = true
+		}, []string{"#/definitions/User", "#/definitions/Team", "#/definitions/Bot"}, []string{"id", "name"},
+			map[string]bool{user: true, both: false, neither: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustConvert(t, interfacesSDL, options(tt.set))
+			actor := schema.Definitions["Actor"]
+			var refs []string
+			for _, ref := range append(actor.AnyOf, actor.OneOf...) {
+				refs = append(refs, ref.Ref)
+			}
+			if !reflect.DeepEqual(refs, tt.implementer) {
+				t.Errorf("Actor lists %q, want %q", refs, tt.implementer)
+			}
+			if got := sortedNames(actor.Properties); !reflect.DeepEqual(got, tt.properties) {
+				t.Errorf("Actor properties = %q, want %q", got, tt.properties)
+			}
+			// Node lists the same implementations as Actor, as they implement both
+			if node := schema.Definitions["Node"]; len(node.AnyOf)+len(node.OneOf) != len(tt.implementer) {
+				t.Errorf("Node lists %d implementations, want %d", len(node.AnyOf)+len(node.OneOf), len(tt.implementer))
+			}
+			for instance, valid := range tt.valid {
+				if errs := validateJSON(t, schema, actor, instance); (len(errs) == 0) != valid {
+					t.Errorf("%s: valid = %v, want %v: %v", instance, len(errs) == 0, valid, errs)
+				}
+			}
+		})
+	}
+}
+
+// TestInterfaceAllOfInheritedFields checks that with allOf the redeclared fields come from the
+// interface, and that InterfaceKeepFields, which only applies to listed implementations, changes
+// nothing
+func TestInterfaceAllOfInheritedFields(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		schema := mustConvert(t, interfacesSDL, options(func(o *pkg.Options) {
+			o.InterfaceAllOf = true
+			o.InterfaceKeepFields = keep
+		}))
+		for name, want := range map[string][]string{"Actor": {"name"}, "User": {"email"}, "Node": {"id"}} {
+			if got := sortedNames(schema.Definitions[name].Properties); !reflect.DeepEqual(got, want) {
+				t.Errorf("keep %v: %s properties = %q, want %q", keep, name, got, want)
+			}
+		}
+		var refs []string
+		for _, ref := range schema.Definitions["User"].AllOf {
+			refs = append(refs, ref.Ref)
+		}
+		if want := []string{"#/definitions/Node", "#/definitions/Actor"}; !reflect.DeepEqual(refs, want) {
+			t.Errorf("keep %v: User allOf = %q, want %q", keep, refs, want)
+		}
+	}
+}
+
+func TestInterfaceImplementationsWithAllOf(t *testing.T) {
+	_, err := pkg.FromIntrospectionQuery(mustIntrospect(t, interfacesSDL), options(func(o *pkg.Options) {
+		o.InterfaceAllOf = true
+		o.InterfaceImplementations = pkg.InterfaceImplementationsAnyOf
+	}))
+	if err == nil {
+		t.Error("InterfaceAllOf with InterfaceImplementations was accepted")
+	}
+}
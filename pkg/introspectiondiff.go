@@ -0,0 +1,440 @@
+package pkg
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Criticality classifies a GraphQL schema change by its effect on existing clients, following the
+// conventions of graphql-inspector
+type Criticality string
+
+const (
+	// CriticalityBreaking changes break existing operations
+	CriticalityBreaking Criticality = "breaking"
+	// CriticalityDangerous changes keep operations valid but may change how clients behave, such as
+	// a new enum value a client does not handle
+	CriticalityDangerous Criticality = "dangerous"
+	// CriticalitySafe changes cannot affect existing clients
+	CriticalitySafe Criticality = "safe"
+)
+
+// GraphQLChangeType identifies the kind of a GraphQL schema change
+type GraphQLChangeType string
+
+const (
+	ChangeTypeAdded                   GraphQLChangeType = "TYPE_ADDED"
+	ChangeTypeRemoved                 GraphQLChangeType = "TYPE_REMOVED"
+	ChangeTypeKindChanged             GraphQLChangeType = "TYPE_KIND_CHANGED"
+	ChangeRootTypeChanged             GraphQLChangeType = "ROOT_TYPE_CHANGED"
+	ChangeFieldAdded                  GraphQLChangeType = "FIELD_ADDED"
+	ChangeFieldRemoved                GraphQLChangeType = "FIELD_REMOVED"
+	ChangeFieldTypeChanged            GraphQLChangeType = "FIELD_TYPE_CHANGED"
+	ChangeFieldDeprecationAdded       GraphQLChangeType = "FIELD_DEPRECATION_ADDED"
+	ChangeFieldDeprecationRemoved     GraphQLChangeType = "FIELD_DEPRECATION_REMOVED"
+	ChangeArgumentAdded               GraphQLChangeType = "FIELD_ARGUMENT_ADDED"
+	ChangeArgumentRemoved             GraphQLChangeType = "FIELD_ARGUMENT_REMOVED"
+	ChangeArgumentTypeChanged         GraphQLChangeType = "FIELD_ARGUMENT_TYPE_CHANGED"
+	ChangeArgumentDefaultChanged      GraphQLChangeType = "FIELD_ARGUMENT_DEFAULT_CHANGED"
+	ChangeInputFieldAdded             GraphQLChangeType = "INPUT_FIELD_ADDED"
+	ChangeInputFieldRemoved           GraphQLChangeType = "INPUT_FIELD_REMOVED"
+	ChangeInputFieldTypeChanged       GraphQLChangeType = "INPUT_FIELD_TYPE_CHANGED"
+	ChangeInputFieldDefaultChanged    GraphQLChangeType = "INPUT_FIELD_DEFAULT_VALUE_CHANGED"
+	ChangeEnumValueAdded              GraphQLChangeType = "ENUM_VALUE_ADDED"
+	ChangeEnumValueRemoved            GraphQLChangeType = "ENUM_VALUE_REMOVED"
+	ChangeEnumValueDeprecationAdded   GraphQLChangeType = "ENUM_VALUE_DEPRECATION_ADDED"
+	ChangeEnumValueDeprecationRemoved GraphQLChangeType = "ENUM_VALUE_DEPRECATION_REMOVED"
+	ChangeUnionMemberAdded            GraphQLChangeType = "UNION_MEMBER_ADDED"
+	ChangeUnionMemberRemoved          GraphQLChangeType = "UNION_MEMBER_REMOVED"
+	ChangeInterfaceAdded              GraphQLChangeType = "OBJECT_TYPE_INTERFACE_ADDED"
+	ChangeInterfaceRemoved            GraphQLChangeType = "OBJECT_TYPE_INTERFACE_REMOVED"
+)
+
+// GraphQLChange is a single difference between two GraphQL schemas. Coordinate is the schema
+// coordinate of the changed element: Type, Type.field, Type.field(arg:) or Enum.VALUE.
+type GraphQLChange struct {
+	Type        GraphQLChangeType `json:"type"`
+	Criticality Criticality       `json:"criticality"`
+	Coordinate  string            `json:"coordinate"`
+	Message     string            `json:"message"`
+	Old         string            `json:"old,omitempty"`
+	New         string            `json:"new,omitempty"`
+}
+
+// DiffIntrospections compares two introspection results at the GraphQL level and returns the
+// changes sorted by coordinate. Unlike DiffSchemas it works on the GraphQL types, so changes are
+// reported as types, fields, arguments and enum values rather than JSON Schema paths. Introspection
+// types (__Schema and friends) are ignored.
+func DiffIntrospections(oldIntrospection, newIntrospection IntrospectionQuery) []GraphQLChange {
+	d := &introspectionDiffer{changes: make([]GraphQLChange, 0)}
+
+	oldSchema, newSchema := oldIntrospection.Schema, newIntrospection.Schema
+	d.diffRoot("query", oldSchema.QueryType, newSchema.QueryType)
+	d.diffRoot("mutation", oldSchema.MutationType, newSchema.MutationType)
+	d.diffRoot("subscription", oldSchema.SubscriptionType, newSchema.SubscriptionType)
+
+	oldTypes, newTypes := namedTypes(oldSchema.Types), namedTypes(newSchema.Types)
+	for _, name := range slices.Sorted(maps.Keys(oldTypes)) {
+		oldType := oldTypes[name]
+		newType, ok := newTypes[name]
+		if !ok {
+			d.add(ChangeTypeRemoved, CriticalityBreaking, name, "", "", "Type %s was removed", name)
+			continue
+		}
+		if oldType.Kind != newType.Kind {
+			d.add(ChangeTypeKindChanged, CriticalityBreaking, name, oldType.Kind, newType.Kind,
+				"Type %s changed from %s to %s", name, oldType.Kind, newType.Kind)
+			continue
+		}
+		d.diffType(oldType, newType)
+	}
+	for _, name := range slices.Sorted(maps.Keys(newTypes)) {
+		if _, ok := oldTypes[name]; !ok {
+			d.add(ChangeTypeAdded, CriticalitySafe, name, "", "", "Type %s was added", name)
+		}
+	}
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return d.changes[i].Coordinate < d.changes[j].Coordinate
+	})
+	return d.changes
+}
+
+type introspectionDiffer struct {
+	changes []GraphQLChange
+}
+
+func (d *introspectionDiffer) add(changeType GraphQLChangeType, criticality Criticality, coordinate, oldValue, newValue, format string, args ...interface{}) {
+	d.changes = append(d.changes, GraphQLChange{
+		Type:        changeType,
+		Criticality: criticality,
+		Coordinate:  coordinate,
+		Message:     fmt.Sprintf(format, args...),
+		Old:         oldValue,
+		New:         newValue,
+	})
+}
+
+// namedTypes indexes the types of a schema by name, leaving out the introspection types
+func namedTypes(types []IntrospectionType) map[string]IntrospectionType {
+	index := make(map[string]IntrospectionType, len(types))
+	for _, t := range types {
+		if !strings.HasPrefix(t.Name, "__") {
+			index[t.Name] = t
+		}
+	}
+	return index
+}
+
+func (d *introspectionDiffer) diffRoot(operation string, oldRoot, newRoot *TypeRef) {
+	var oldName, newName string
+	if oldRoot != nil {
+		oldName = oldRoot.Name
+	}
+	if newRoot != nil {
+		newName = newRoot.Name
+	}
+	if oldName == newName {
+		return
+	}
+
+	criticality := CriticalityBreaking
+	if oldName == "" {
+		criticality = CriticalitySafe
+	}
+	d.add(ChangeRootTypeChanged, criticality, "schema."+operation, oldName, newName,
+		"Root %s type changed from %q to %q", operation, oldName, newName)
+}
+
+func (d *introspectionDiffer) diffType(oldType, newType IntrospectionType) {
+	switch oldType.Kind {
+	case "OBJECT", "INTERFACE":
+		d.diffFields(oldType, newType)
+		d.diffInterfaces(oldType, newType)
+	case "INPUT_OBJECT":
+		d.diffInputFields(oldType, newType)
+	case "ENUM":
+		d.diffEnumValues(oldType, newType)
+	case "UNION":
+		d.diffUnionMembers(oldType, newType)
+	}
+}
+
+func (d *introspectionDiffer) diffFields(oldType, newType IntrospectionType) {
+	newFields := make(map[string]IntrospectionField, len(newType.Fields))
+	for _, field := range newType.Fields {
+		newFields[field.Name] = field
+	}
+	oldFields := make(map[string]bool, len(oldType.Fields))
+
+	for _, oldField := range oldType.Fields {
+		oldFields[oldField.Name] = true
+		coordinate := oldType.Name + "." + oldField.Name
+		newField, ok := newFields[oldField.Name]
+		if !ok {
+			d.add(ChangeFieldRemoved, CriticalityBreaking, coordinate, "", "", "Field %s was removed", coordinate)
+			continue
+		}
+
+		oldRef, newRef := typeRefString(oldField.Type), typeRefString(newField.Type)
+		if oldRef != newRef {
+			criticality := CriticalityBreaking
+			if isSafeOutputTypeChange(oldField.Type, newField.Type) {
+				criticality = CriticalitySafe
+			}
+			d.add(ChangeFieldTypeChanged, criticality, coordinate, oldRef, newRef,
+				"Field %s changed type from %s to %s", coordinate, oldRef, newRef)
+		}
+
+		switch {
+		case !oldField.IsDeprecated && newField.IsDeprecated:
+			d.add(ChangeFieldDeprecationAdded, CriticalitySafe, coordinate, "", deprecationReason(newField.DeprecationReason),
+				"Field %s was deprecated", coordinate)
+		case oldField.IsDeprecated && !newField.IsDeprecated:
+			d.add(ChangeFieldDeprecationRemoved, CriticalitySafe, coordinate, deprecationReason(oldField.DeprecationReason), "",
+				"Field %s is no longer deprecated", coordinate)
+		}
+
+		d.diffArgs(coordinate, oldField.Args, newField.Args)
+	}
+
+	for _, newField := range newType.Fields {
+		if !oldFields[newField.Name] {
+			coordinate := newType.Name + "." + newField.Name
+			d.add(ChangeFieldAdded, CriticalitySafe, coordinate, "", typeRefString(newField.Type), "Field %s was added", coordinate)
+		}
+	}
+}
+
+func (d *introspectionDiffer) diffArgs(fieldCoordinate string, oldArgs, newArgs []IntrospectionArg) {
+	newIndex := make(map[string]IntrospectionArg, len(newArgs))
+	for _, arg := range newArgs {
+		newIndex[arg.Name] = arg
+	}
+	oldIndex := make(map[string]bool, len(oldArgs))
+
+	for _, oldArg := range oldArgs {
+		oldIndex[oldArg.Name] = true
+		coordinate := fieldCoordinate + "(" + oldArg.Name + ":)"
+		newArg, ok := newIndex[oldArg.Name]
+		if !ok {
+			d.add(ChangeArgumentRemoved, CriticalityBreaking, coordinate, "", "", "Argument %s was removed", coordinate)
+			continue
+		}
+
+		oldRef, newRef := typeRefString(oldArg.Type), typeRefString(newArg.Type)
+		if oldRef != newRef {
+			criticality := CriticalityBreaking
+			if isSafeInputTypeChange(oldArg.Type, newArg.Type) {
+				criticality = CriticalitySafe
+			}
+			d.add(ChangeArgumentTypeChanged, criticality, coordinate, oldRef, newRef,
+				"Argument %s changed type from %s to %s", coordinate, oldRef, newRef)
+		}
+		if oldDefault, newDefault := defaultValue(oldArg.DefaultValue), defaultValue(newArg.DefaultValue); oldDefault != newDefault {
+			d.add(ChangeArgumentDefaultChanged, CriticalityDangerous, coordinate, oldDefault, newDefault,
+				"Argument %s changed default value from %q to %q", coordinate, oldDefault, newDefault)
+		}
+	}
+
+	for _, newArg := range newArgs {
+		if oldIndex[newArg.Name] {
+			continue
+		}
+		coordinate := fieldCoordinate + "(" + newArg.Name + ":)"
+		if newArg.Type.Kind == "NON_NULL" && newArg.DefaultValue == nil {
+			d.add(ChangeArgumentAdded, CriticalityBreaking, coordinate, "", typeRefString(newArg.Type),
+				"Required argument %s was added", coordinate)
+		} else {
+			d.add(ChangeArgumentAdded, CriticalityDangerous, coordinate, "", typeRefString(newArg.Type),
+				"Optional argument %s was added", coordinate)
+		}
+	}
+}
+
+func (d *introspectionDiffer) diffInputFields(oldType, newType IntrospectionType) {
+	newFields := make(map[string]IntrospectionInput, len(newType.InputFields))
+	for _, field := range newType.InputFields {
+		newFields[field.Name] = field
+	}
+	oldFields := make(map[string]bool, len(oldType.InputFields))
+
+	for _, oldField := range oldType.InputFields {
+		oldFields[oldField.Name] = true
+		coordinate := oldType.Name + "." + oldField.Name
+		newField, ok := newFields[oldField.Name]
+		if !ok {
+			d.add(ChangeInputFieldRemoved, CriticalityBreaking, coordinate, "", "", "Input field %s was removed", coordinate)
+			continue
+		}
+
+		oldRef, newRef := typeRefString(oldField.Type), typeRefString(newField.Type)
+		if oldRef != newRef {
+			criticality := CriticalityBreaking
+			if isSafeInputTypeChange(oldField.Type, newField.Type) {
+				criticality = CriticalitySafe
+			}
+			d.add(ChangeInputFieldTypeChanged, criticality, coordinate, oldRef, newRef,
+				"Input field %s changed type from %s to %s", coordinate, oldRef, newRef)
+		}
+		if oldDefault, newDefault := defaultValue(oldField.DefaultValue), defaultValue(newField.DefaultValue); oldDefault != newDefault {
+			d.add(ChangeInputFieldDefaultChanged, CriticalityDangerous, coordinate, oldDefault, newDefault,
+				"Input field %s changed default value from %q to %q", coordinate, oldDefault, newDefault)
+		}
+	}
+
+	for _, newField := range newType.InputFields {
+		if oldFields[newField.Name] {
+			continue
+		}
+		coordinate := newType.Name + "." + newField.Name
+		if newField.Type.Kind == "NON_NULL" && newField.DefaultValue == nil {
+			d.add(ChangeInputFieldAdded, CriticalityBreaking, coordinate, "", typeRefString(newField.Type),
+				"Required input field %s was added", coordinate)
+		} else {
+			d.add(ChangeInputFieldAdded, CriticalityDangerous, coordinate, "", typeRefString(newField.Type),
+				"Optional input field %s was added", coordinate)
+		}
+	}
+}
+
+func (d *introspectionDiffer) diffEnumValues(oldType, newType IntrospectionType) {
+	newValues := make(map[string]IntrospectionEnum, len(newType.EnumValues))
+	for _, value := range newType.EnumValues {
+		newValues[value.Name] = value
+	}
+	oldValues := make(map[string]bool, len(oldType.EnumValues))
+
+	for _, oldValue := range oldType.EnumValues {
+		oldValues[oldValue.Name] = true
+		coordinate := oldType.Name + "." + oldValue.Name
+		newValue, ok := newValues[oldValue.Name]
+		if !ok {
+			d.add(ChangeEnumValueRemoved, CriticalityBreaking, coordinate, "", "", "Enum value %s was removed", coordinate)
+			continue
+		}
+
+		switch {
+		case !oldValue.IsDeprecated && newValue.IsDeprecated:
+			d.add(ChangeEnumValueDeprecationAdded, CriticalitySafe, coordinate, "", deprecationReason(newValue.DeprecationReason),
+				"Enum value %s was deprecated", coordinate)
+		case oldValue.IsDeprecated && !newValue.IsDeprecated:
+			d.add(ChangeEnumValueDeprecationRemoved, CriticalitySafe, coordinate, deprecationReason(oldValue.DeprecationReason), "",
+				"Enum value %s is no longer deprecated", coordinate)
+		}
+	}
+
+	for _, newValue := range newType.EnumValues {
+		if !oldValues[newValue.Name] {
+			coordinate := newType.Name + "." + newValue.Name
+			d.add(ChangeEnumValueAdded, CriticalityDangerous, coordinate, "", "", "Enum value %s was added", coordinate)
+		}
+	}
+}
+
+func (d *introspectionDiffer) diffUnionMembers(oldType, newType IntrospectionType) {
+	oldMembers := make(map[string]bool, len(oldType.PossibleTypes))
+	for _, member := range oldType.PossibleTypes {
+		oldMembers[member.Name] = true
+	}
+	newMembers := make(map[string]bool, len(newType.PossibleTypes))
+	for _, member := range newType.PossibleTypes {
+		newMembers[member.Name] = true
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(oldMembers)) {
+		if !newMembers[name] {
+			d.add(ChangeUnionMemberRemoved, CriticalityBreaking, oldType.Name, name, "",
+				"Member %s was removed from union %s", name, oldType.Name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(newMembers)) {
+		if !oldMembers[name] {
+			d.add(ChangeUnionMemberAdded, CriticalityDangerous, newType.Name, "", name,
+				"Member %s was added to union %s", name, newType.Name)
+		}
+	}
+}
+
+func (d *introspectionDiffer) diffInterfaces(oldType, newType IntrospectionType) {
+	oldInterfaces := make(map[string]bool, len(oldType.Interfaces))
+	for _, iface := range oldType.Interfaces {
+		oldInterfaces[iface.Name] = true
+	}
+	newInterfaces := make(map[string]bool, len(newType.Interfaces))
+	for _, iface := range newType.Interfaces {
+		newInterfaces[iface.Name] = true
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(oldInterfaces)) {
+		if !newInterfaces[name] {
+			d.add(ChangeInterfaceRemoved, CriticalityBreaking, oldType.Name, name, "",
+				"%s no longer implements interface %s", oldType.Name, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(newInterfaces)) {
+		if !oldInterfaces[name] {
+			d.add(ChangeInterfaceAdded, CriticalityDangerous, newType.Name, "", name,
+				"%s now implements interface %s", newType.Name, name)
+		}
+	}
+}
+
+// isSafeOutputTypeChange reports whether clients reading a field of type oldRef can read it as
+// newRef: the named type is unchanged and the change only makes positions non-null
+func isSafeOutputTypeChange(oldRef, newRef IntrospectionTypeRef) bool {
+	switch oldRef.Kind {
+	case "LIST":
+		if oldRef.OfType == nil {
+			return false
+		}
+		if newRef.Kind == "LIST" && newRef.OfType != nil {
+			return isSafeOutputTypeChange(*oldRef.OfType, *newRef.OfType)
+		}
+		return newRef.Kind == "NON_NULL" && newRef.OfType != nil && isSafeOutputTypeChange(oldRef, *newRef.OfType)
+	case "NON_NULL":
+		return oldRef.OfType != nil && newRef.Kind == "NON_NULL" && newRef.OfType != nil &&
+			isSafeOutputTypeChange(*oldRef.OfType, *newRef.OfType)
+	}
+	if newRef.Kind == "NON_NULL" && newRef.OfType != nil {
+		return isSafeOutputTypeChange(oldRef, *newRef.OfType)
+	}
+	return newRef.Kind != "LIST" && typeRefString(oldRef) == typeRefString(newRef)
+}
+
+// isSafeInputTypeChange reports whether values clients send for an argument or input field of type
+// oldRef are accepted as newRef: the named type is unchanged and the change only makes positions
+// nullable
+func isSafeInputTypeChange(oldRef, newRef IntrospectionTypeRef) bool {
+	switch oldRef.Kind {
+	case "LIST":
+		return oldRef.OfType != nil && newRef.Kind == "LIST" && newRef.OfType != nil &&
+			isSafeInputTypeChange(*oldRef.OfType, *newRef.OfType)
+	case "NON_NULL":
+		if oldRef.OfType == nil {
+			return false
+		}
+		if newRef.Kind == "NON_NULL" && newRef.OfType != nil {
+			return isSafeInputTypeChange(*oldRef.OfType, *newRef.OfType)
+		}
+		return isSafeInputTypeChange(*oldRef.OfType, newRef)
+	}
+	return newRef.Kind != "LIST" && newRef.Kind != "NON_NULL" && typeRefString(oldRef) == typeRefString(newRef)
+}
+
+func deprecationReason(reason *string) string {
+	if reason == nil {
+		return ""
+	}
+	return *reason
+}
+
+func defaultValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
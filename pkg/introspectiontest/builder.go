@@ -0,0 +1,436 @@
+// Package introspectiontest builds introspection query results for tests, so fixtures need not spell
+// out nested type reference chains by hand:
+//
+//	introspection := introspectiontest.NewSchema().
+//		Query("Query",
+//			Field("user", Object("User"), Arg("id", NonNull(Scalar("ID"))))).
+//		Object("User",
+//			Field("id", NonNull(Scalar("ID"))),
+//			Field("name", Scalar("String"), Description("Display name"))).
+//		Build()
+//
+// Build produces the same structures a server returns for the standard introspection query: the
+// introspection types and the built-in scalars in use are included, list-valued members are empty
+// rather than nil for the kinds that have them, and interfaces list their implementations as
+// possible types.
+package introspectiontest
+
+import (
+	"fmt"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// builtinScalars are the scalars GraphQL defines. String and Boolean are always present as introspection
+// uses them; the others only appear when referenced.
+var builtinScalars = []string{"String", "Int", "Float", "Boolean", "ID"}
+
+// SchemaBuilder accumulates the types of a schema. Its methods return the builder for chaining.
+type SchemaBuilder struct {
+	schema pkg.IntrospectionSchema
+	types  []pkg.IntrospectionType
+	index  map[string]int
+}
+
+// NewSchema returns an empty schema builder
+func NewSchema() *SchemaBuilder {
+	return &SchemaBuilder{index: make(map[string]int)}
+}
+
+// Query adds an object type and makes it the query root
+func (b *SchemaBuilder) Query(name string, opts ...TypeOption) *SchemaBuilder {
+	b.schema.QueryType = &pkg.TypeRef{Name: name}
+	return b.Object(name, opts...)
+}
+
+// Mutation adds an object type and makes it the mutation root
+func (b *SchemaBuilder) Mutation(name string, opts ...TypeOption) *SchemaBuilder {
+	b.schema.MutationType = &pkg.TypeRef{Name: name}
+	return b.Object(name, opts...)
+}
+
+// Subscription adds an object type and makes it the subscription root
+func (b *SchemaBuilder) Subscription(name string, opts ...TypeOption) *SchemaBuilder {
+	b.schema.SubscriptionType = &pkg.TypeRef{Name: name}
+	return b.Object(name, opts...)
+}
+
+// Object adds an object type with the given fields, interfaces and description
+func (b *SchemaBuilder) Object(name string, opts ...TypeOption) *SchemaBuilder {
+	return b.add(pkg.IntrospectionType{
+		Kind:       "OBJECT",
+		Name:       name,
+		Fields:     []pkg.IntrospectionField{},
+		Interfaces: []pkg.TypeRef{},
+	}, opts)
+}
+
+// Interface adds an interface type. Its possible types are the objects that implement it.
+func (b *SchemaBuilder) Interface(name string, opts ...TypeOption) *SchemaBuilder {
+	return b.add(pkg.IntrospectionType{
+		Kind:          "INTERFACE",
+		Name:          name,
+		Fields:        []pkg.IntrospectionField{},
+		Interfaces:    []pkg.TypeRef{},
+		PossibleTypes: []pkg.IntrospectionType{},
+	}, opts)
+}
+
+// Union adds a union type; use Members for its member types
+func (b *SchemaBuilder) Union(name string, opts ...TypeOption) *SchemaBuilder {
+	return b.add(pkg.IntrospectionType{
+		Kind:          "UNION",
+		Name:          name,
+		PossibleTypes: []pkg.IntrospectionType{},
+	}, opts)
+}
+
+// Enum adds an enum type; use Value for its values
+func (b *SchemaBuilder) Enum(name string, opts ...TypeOption) *SchemaBuilder {
+	return b.add(pkg.IntrospectionType{
+		Kind:       "ENUM",
+		Name:       name,
+		EnumValues: []pkg.IntrospectionEnum{},
+	}, opts)
+}
+
+// InputObject adds an input object type; use InputField for its fields
+func (b *SchemaBuilder) InputObject(name string, opts ...TypeOption) *SchemaBuilder {
+	return b.add(pkg.IntrospectionType{
+		Kind:        "INPUT_OBJECT",
+		Name:        name,
+		InputFields: []pkg.IntrospectionInput{},
+	}, opts)
+}
+
+// Scalar adds a custom scalar type. Built-in scalars are added by Build and need not be declared.
+func (b *SchemaBuilder) Scalar(name string, opts ...TypeOption) *SchemaBuilder {
+	return b.add(pkg.IntrospectionType{Kind: "SCALAR", Name: name}, opts)
+}
+
+// add appends a type, panicking when the name is taken since that is a mistake in the fixture
+func (b *SchemaBuilder) add(t pkg.IntrospectionType, opts []TypeOption) *SchemaBuilder {
+	if _, ok := b.index[t.Name]; ok {
+		panic(fmt.Sprintf("introspectiontest: type %s is defined twice", t.Name))
+	}
+	for _, opt := range opts {
+		opt.applyType(&t)
+	}
+	b.index[t.Name] = len(b.types)
+	b.types = append(b.types, t)
+	return b
+}
+
+// Build returns the introspection query result. The builder can be extended and built again;
+// results do not share memory.
+func (b *SchemaBuilder) Build() pkg.IntrospectionQuery {
+	types := make([]pkg.IntrospectionType, 0, len(b.types)+len(builtinScalars)+len(introspectionTypes.types))
+	for _, t := range b.types {
+		types = append(types, copyType(t))
+	}
+	referenced := b.referencedNames()
+	for _, name := range builtinScalars {
+		if _, ok := b.index[name]; !ok && (name == "String" || name == "Boolean" || referenced[name]) {
+			types = append(types, pkg.IntrospectionType{Kind: "SCALAR", Name: name, Description: builtinScalarDescriptions[name]})
+		}
+	}
+	for _, t := range introspectionTypes.types {
+		if _, ok := b.index[t.Name]; !ok {
+			types = append(types, copyType(t))
+		}
+	}
+
+	// Interfaces report the objects implementing them as possible types
+	for i := range types {
+		if types[i].Kind != "INTERFACE" {
+			continue
+		}
+		for _, t := range types {
+			if t.Kind != "OBJECT" {
+				continue
+			}
+			for _, iface := range t.Interfaces {
+				if iface.Name == types[i].Name {
+					types[i].PossibleTypes = append(types[i].PossibleTypes, pkg.IntrospectionType{Kind: "OBJECT", Name: t.Name})
+				}
+			}
+		}
+	}
+
+	schema := b.schema
+	schema.Types = types
+	return pkg.IntrospectionQuery{Schema: schema}
+}
+
+// referencedNames returns the names of all types referenced by fields, arguments and input fields
+func (b *SchemaBuilder) referencedNames() map[string]bool {
+	names := make(map[string]bool)
+	add := func(typeRef pkg.IntrospectionTypeRef) {
+		for typeRef.OfType != nil {
+			typeRef = *typeRef.OfType
+		}
+		if typeRef.Name != nil {
+			names[*typeRef.Name] = true
+		}
+	}
+	for _, t := range b.types {
+		for _, field := range t.Fields {
+			add(field.Type)
+			for _, arg := range field.Args {
+				add(arg.Type)
+			}
+		}
+		for _, field := range t.InputFields {
+			add(field.Type)
+		}
+	}
+	return names
+}
+
+// copyType copies the slices of t so built results can be modified independently
+func copyType(t pkg.IntrospectionType) pkg.IntrospectionType {
+	t.Fields = cloneSlice(t.Fields)
+	for i := range t.Fields {
+		t.Fields[i].Args = cloneSlice(t.Fields[i].Args)
+	}
+	t.InputFields = cloneSlice(t.InputFields)
+	t.Interfaces = cloneSlice(t.Interfaces)
+	t.EnumValues = cloneSlice(t.EnumValues)
+	t.PossibleTypes = cloneSlice(t.PossibleTypes)
+	return t
+}
+
+// cloneSlice copies s, keeping nil and empty slices apart as they marshal differently
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// TypeOption configures a type: fields, input fields, enum values, interfaces, members or a description
+type TypeOption interface {
+	applyType(t *pkg.IntrospectionType)
+}
+
+// FieldOption configures a field: arguments, a description or a deprecation
+type FieldOption interface {
+	applyField(f *pkg.IntrospectionField)
+}
+
+// InputValueOption configures an argument or input field: a description or a default value
+type InputValueOption interface {
+	applyInputValue(v *inputValue)
+}
+
+// EnumValueOption configures an enum value: a description or a deprecation
+type EnumValueOption interface {
+	applyEnumValue(v *pkg.IntrospectionEnum)
+}
+
+// FieldDef is an output field, created with Field
+type FieldDef struct {
+	field pkg.IntrospectionField
+}
+
+// Field declares an output field of an object or interface
+func Field(name string, typeRef pkg.IntrospectionTypeRef, opts ...FieldOption) FieldDef {
+	field := pkg.IntrospectionField{Name: name, Type: typeRef, Args: []pkg.IntrospectionArg{}}
+	for _, opt := range opts {
+		opt.applyField(&field)
+	}
+	return FieldDef{field: field}
+}
+
+func (d FieldDef) applyType(t *pkg.IntrospectionType) {
+	t.Fields = append(t.Fields, d.field)
+}
+
+// inputValue holds what arguments and input fields have in common
+type inputValue struct {
+	name         string
+	description  string
+	typeRef      pkg.IntrospectionTypeRef
+	defaultValue *string
+	// deprecationReason is set for deprecated arguments and input fields
+	deprecationReason *string
+}
+
+func newInputValue(name string, typeRef pkg.IntrospectionTypeRef, opts []InputValueOption) inputValue {
+	v := inputValue{name: name, typeRef: typeRef}
+	for _, opt := range opts {
+		opt.applyInputValue(&v)
+	}
+	return v
+}
+
+// ArgDef is a field argument, created with Arg
+type ArgDef struct {
+	value inputValue
+}
+
+// Arg declares an argument of a field
+func Arg(name string, typeRef pkg.IntrospectionTypeRef, opts ...InputValueOption) ArgDef {
+	return ArgDef{value: newInputValue(name, typeRef, opts)}
+}
+
+func (d ArgDef) applyField(f *pkg.IntrospectionField) {
+	f.Args = append(f.Args, pkg.IntrospectionArg{
+		Name:         d.value.name,
+		Description:  d.value.description,
+		Type:         d.value.typeRef,
+		DefaultValue: d.value.defaultValue,
+
+		IsDeprecated:      d.value.deprecationReason != nil,
+		DeprecationReason: d.value.deprecationReason,
+	})
+}
+
+// InputFieldDef is a field of an input object, created with InputField
+type InputFieldDef struct {
+	value inputValue
+}
+
+// InputField declares a field of an input object
+func InputField(name string, typeRef pkg.IntrospectionTypeRef, opts ...InputValueOption) InputFieldDef {
+	return InputFieldDef{value: newInputValue(name, typeRef, opts)}
+}
+
+func (d InputFieldDef) applyType(t *pkg.IntrospectionType) {
+	t.InputFields = append(t.InputFields, pkg.IntrospectionInput{
+		Name:         d.value.name,
+		Description:  d.value.description,
+		Type:         d.value.typeRef,
+		DefaultValue: d.value.defaultValue,
+
+		IsDeprecated:      d.value.deprecationReason != nil,
+		DeprecationReason: d.value.deprecationReason,
+	})
+}
+
+// EnumValueDef is an enum value, created with Value
+type EnumValueDef struct {
+	value pkg.IntrospectionEnum
+}
+
+// Value declares a value of an enum
+func Value(name string, opts ...EnumValueOption) EnumValueDef {
+	value := pkg.IntrospectionEnum{Name: name}
+	for _, opt := range opts {
+		opt.applyEnumValue(&value)
+	}
+	return EnumValueDef{value: value}
+}
+
+func (d EnumValueDef) applyType(t *pkg.IntrospectionType) {
+	t.EnumValues = append(t.EnumValues, d.value)
+}
+
+type implements []string
+
+// Implements declares the interfaces an object or interface implements
+func Implements(interfaces ...string) TypeOption {
+	return implements(interfaces)
+}
+
+func (names implements) applyType(t *pkg.IntrospectionType) {
+	for _, name := range names {
+		t.Interfaces = append(t.Interfaces, pkg.TypeRef{Kind: "INTERFACE", Name: name})
+	}
+}
+
+type members []string
+
+// Members declares the object types of a union
+func Members(objects ...string) TypeOption {
+	return members(objects)
+}
+
+func (names members) applyType(t *pkg.IntrospectionType) {
+	for _, name := range names {
+		t.PossibleTypes = append(t.PossibleTypes, pkg.IntrospectionType{Kind: "OBJECT", Name: name})
+	}
+}
+
+// DescriptionOption sets the description of a type, field, argument, input field or enum value
+type DescriptionOption string
+
+// Description sets the description of whatever it is passed to
+func Description(text string) DescriptionOption {
+	return DescriptionOption(text)
+}
+
+func (d DescriptionOption) applyType(t *pkg.IntrospectionType)      { t.Description = string(d) }
+func (d DescriptionOption) applyField(f *pkg.IntrospectionField)    { f.Description = string(d) }
+func (d DescriptionOption) applyInputValue(v *inputValue)           { v.description = string(d) }
+func (d DescriptionOption) applyEnumValue(v *pkg.IntrospectionEnum) { v.Description = string(d) }
+
+// DeprecatedOption marks a field, argument, input field or enum value as deprecated
+type DeprecatedOption string
+
+// Deprecated marks a field, argument, input field or enum value as deprecated with the given reason
+func Deprecated(reason string) DeprecatedOption {
+	return DeprecatedOption(reason)
+}
+
+func (d DeprecatedOption) applyField(f *pkg.IntrospectionField) {
+	reason := string(d)
+	f.IsDeprecated = true
+	f.DeprecationReason = &reason
+}
+
+func (d DeprecatedOption) applyInputValue(v *inputValue) {
+	reason := string(d)
+	v.deprecationReason = &reason
+}
+
+func (d DeprecatedOption) applyEnumValue(v *pkg.IntrospectionEnum) {
+	reason := string(d)
+	v.IsDeprecated = true
+	v.DeprecationReason = &reason
+}
+
+type defaultValue string
+
+// Default sets the default value of an argument or input field, as a GraphQL literal such as
+// "10", "\"text\"", "ACTIVE" or "{limit: 5}"
+func Default(literal string) InputValueOption {
+	return defaultValue(literal)
+}
+
+func (d defaultValue) applyInputValue(v *inputValue) {
+	literal := string(d)
+	v.defaultValue = &literal
+}
+
+// NonNull wraps a type reference as non-null
+func NonNull(ofType pkg.IntrospectionTypeRef) pkg.IntrospectionTypeRef {
+	return pkg.IntrospectionTypeRef{Kind: "NON_NULL", OfType: &ofType}
+}
+
+// List wraps a type reference as a list
+func List(ofType pkg.IntrospectionTypeRef) pkg.IntrospectionTypeRef {
+	return pkg.IntrospectionTypeRef{Kind: "LIST", OfType: &ofType}
+}
+
+// Scalar references a scalar type
+func Scalar(name string) pkg.IntrospectionTypeRef { return named("SCALAR", name) }
+
+// Object references an object type
+func Object(name string) pkg.IntrospectionTypeRef { return named("OBJECT", name) }
+
+// Interface references an interface type
+func Interface(name string) pkg.IntrospectionTypeRef { return named("INTERFACE", name) }
+
+// Union references a union type
+func Union(name string) pkg.IntrospectionTypeRef { return named("UNION", name) }
+
+// Enum references an enum type
+func Enum(name string) pkg.IntrospectionTypeRef { return named("ENUM", name) }
+
+// InputObject references an input object type
+func InputObject(name string) pkg.IntrospectionTypeRef { return named("INPUT_OBJECT", name) }
+
+func named(kind, name string) pkg.IntrospectionTypeRef {
+	return pkg.IntrospectionTypeRef{Kind: kind, Name: &name}
+}
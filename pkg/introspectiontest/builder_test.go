@@ -0,0 +1,188 @@
+package introspectiontest_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	. "github.com/robert-cronin/gql2jsonschema-go/pkg/introspectiontest"
+)
+
+const paritySDL = `
+"An instant"
+scalar DateTime
+
+type Query {
+  node(id: ID!): Node
+  search(text: String!, first: Int = 10): [SearchResult!]!
+}
+
+type Mutation { createUser(input: CreateUserInput!): User }
+
+type Subscription { userCreated: User! }
+
+interface Node { id: ID! }
+
+"A person"
+type User implements Node {
+  id: ID!
+  "Display name"
+  name: String!
+  legacyName: String @deprecated(reason: "use name")
+  joined: DateTime
+  role: Role
+  friends: [User!]
+  score: Float
+}
+
+type Post implements Node { id: ID! title: String! author: User }
+
+union SearchResult = User | Post
+
+enum Role {
+  "Full access"
+  ADMIN
+  MEMBER
+  GUEST @deprecated(reason: "no longer granted")
+}
+
+input CreateUserInput {
+  name: String!
+  role: Role = MEMBER
+  tags: [String!]
+}
+`
+
+// paritySchema builds the schema of paritySDL
+func paritySchema() *SchemaBuilder {
+	return NewSchema().
+		Scalar("DateTime", Description("An instant")).
+		Query("Query",
+			Field("node", Interface("Node"), Arg("id", NonNull(Scalar("ID")))),
+			Field("search", NonNull(List(NonNull(Union("SearchResult")))),
+				Arg("text", NonNull(Scalar("String"))),
+				Arg("first", Scalar("Int"), Default("10")))).
+		Mutation("Mutation",
+			Field("createUser", Object("User"), Arg("input", NonNull(InputObject("CreateUserInput"))))).
+		Subscription("Subscription",
+			Field("userCreated", NonNull(Object("User")))).
+		Interface("Node",
+			Field("id", NonNull(Scalar("ID")))).
+		Object("User", Description("A person"), Implements("Node"),
+			Field("id", NonNull(Scalar("ID"))),
+			Field("name", NonNull(Scalar("String")), Description("Display name")),
+			Field("legacyName", Scalar("String"), Deprecated("use name")),
+			Field("joined", Scalar("DateTime")),
+			Field("role", Enum("Role")),
+			Field("friends", List(NonNull(Object("User")))),
+			Field("score", Scalar("Float"))).
+		Object("Post", Implements("Node"),
+			Field("id", NonNull(Scalar("ID"))),
+			Field("title", NonNull(Scalar("String"))),
+			Field("author", Object("User"))).
+		Union("SearchResult", Members("User", "Post")).
+		Enum("Role",
+			Value("ADMIN", Description("Full access")),
+			Value("MEMBER"),
+			Value("GUEST", Deprecated("no longer granted"))).
+		InputObject("CreateUserInput",
+			InputField("name", NonNull(Scalar("String"))),
+			InputField("role", Enum("Role"), Default("MEMBER")),
+			InputField("tags", List(NonNull(Scalar("String")))))
+}
+
+// typesByName indexes the types of an introspection result, as their order differs between
+// builders and the SDL reader
+func typesByName(introspection pkg.IntrospectionQuery) map[string]pkg.IntrospectionType {
+	types := make(map[string]pkg.IntrospectionType, len(introspection.Schema.Types))
+	for _, t := range introspection.Schema.Types {
+		types[t.Name] = t
+	}
+	return types
+}
+
+func TestBuildMatchesSDL(t *testing.T) {
+	fromSDL, err := pkg.IntrospectionFromSDL(paritySDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	built := paritySchema().Build()
+
+	for name, root := range map[string][2]*pkg.TypeRef{
+		"query":        {built.Schema.QueryType, fromSDL.Schema.QueryType},
+		"mutation":     {built.Schema.MutationType, fromSDL.Schema.MutationType},
+		"subscription": {built.Schema.SubscriptionType, fromSDL.Schema.SubscriptionType},
+	} {
+		if !reflect.DeepEqual(root[0], root[1]) {
+			t.Errorf("%s root: built %+v, SDL %+v", name, root[0], root[1])
+		}
+	}
+
+	want, got := typesByName(*fromSDL), typesByName(built)
+	for name, wantType := range want {
+		// Servers describe the built-in scalars, as the builder does; the SDL reader leaves them bare
+		if builtin := got[name]; builtin.Kind == "SCALAR" && wantType.Description == "" && isBuiltinScalar(name) {
+			if builtin.Description == "" {
+				t.Errorf("built-in scalar %s has no description", name)
+			}
+			wantType.Description = builtin.Description
+		}
+		gotType, ok := got[name]
+		if !ok {
+			t.Errorf("built schema lacks %s", name)
+			continue
+		}
+		// Compare the JSON too, as nil and empty slices marshal differently
+		gotJSON, _ := json.Marshal(gotType)
+		wantJSON, _ := json.Marshal(wantType)
+		if !reflect.DeepEqual(gotType, wantType) || string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s differs:\nbuilt %s\nSDL   %s", name, gotJSON, wantJSON)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("built schema has extra type %s", name)
+		}
+	}
+}
+
+func isBuiltinScalar(name string) bool {
+	switch name {
+	case "String", "Int", "Float", "Boolean", "ID":
+		return true
+	}
+	return false
+}
+
+func TestBuildImplementsKind(t *testing.T) {
+	types := typesByName(paritySchema().Build())
+	if got := types["User"].Interfaces; len(got) != 1 || got[0] != (pkg.TypeRef{Kind: "INTERFACE", Name: "Node"}) {
+		t.Errorf("User interfaces = %+v", got)
+	}
+	var possible []string
+	for _, member := range types["Node"].PossibleTypes {
+		possible = append(possible, member.Kind+" "+member.Name)
+	}
+	if want := []string{"OBJECT User", "OBJECT Post"}; !reflect.DeepEqual(possible, want) {
+		t.Errorf("Node possible types = %q, want %q", possible, want)
+	}
+}
+
+func TestBuildCopies(t *testing.T) {
+	builder := NewSchema().Query("Query", Field("a", Scalar("Int")))
+	first := builder.Build()
+	first.Schema.Types[0].Fields[0].Name = "changed"
+	if second := builder.Build(); second.Schema.Types[0].Fields[0].Name != "a" {
+		t.Error("built results share memory")
+	}
+}
+
+func TestBuildDuplicateType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("defining a type twice did not panic")
+		}
+	}()
+	NewSchema().Object("User").Object("User")
+}
@@ -0,0 +1,68 @@
+package introspectiontest
+
+// builtinScalarDescriptions are the descriptions the reference implementation gives the built-in scalars
+var builtinScalarDescriptions = map[string]string{
+	"String":  "The `String` scalar type represents textual data, represented as UTF-8 character sequences. The String type is most often used by GraphQL to represent free-form human-readable text.",
+	"Int":     "The `Int` scalar type represents non-fractional signed whole numeric values. Int can represent values between -(2^31) and 2^31 - 1.",
+	"Float":   "The `Float` scalar type represents signed double-precision fractional values as specified by [IEEE 754](https://en.wikipedia.org/wiki/IEEE_floating_point).",
+	"Boolean": "The `Boolean` scalar type represents `true` or `false`.",
+	"ID":      "The `ID` scalar type represents a unique identifier, often used to refetch an object or as key for a cache. The ID type appears in a JSON response as a String; however, it is not intended to be human-readable. When expected as an input type, any string (such as `\"4\"`) or integer (such as `4`) input value will be accepted as an ID.",
+}
+
+// includeDeprecated is the argument of the introspection fields that list deprecatable members
+var includeDeprecated = Arg("includeDeprecated", Scalar("Boolean"), Default("false"))
+
+// introspectionTypes are the types every schema exposes for introspection, as of the October 2021 spec
+var introspectionTypes = NewSchema().
+	Object("__Schema",
+		Field("description", Scalar("String")),
+		Field("types", NonNull(List(NonNull(Object("__Type"))))),
+		Field("queryType", NonNull(Object("__Type"))),
+		Field("mutationType", Object("__Type")),
+		Field("subscriptionType", Object("__Type")),
+		Field("directives", NonNull(List(NonNull(Object("__Directive")))))).
+	Object("__Type",
+		Field("kind", NonNull(Enum("__TypeKind"))),
+		Field("name", Scalar("String")),
+		Field("description", Scalar("String")),
+		Field("specifiedByURL", Scalar("String")),
+		Field("fields", List(NonNull(Object("__Field"))), includeDeprecated),
+		Field("interfaces", List(NonNull(Object("__Type")))),
+		Field("possibleTypes", List(NonNull(Object("__Type")))),
+		Field("enumValues", List(NonNull(Object("__EnumValue"))), includeDeprecated),
+		Field("inputFields", List(NonNull(Object("__InputValue"))), includeDeprecated),
+		Field("ofType", Object("__Type"))).
+	Object("__Field",
+		Field("name", NonNull(Scalar("String"))),
+		Field("description", Scalar("String")),
+		Field("args", NonNull(List(NonNull(Object("__InputValue")))), includeDeprecated),
+		Field("type", NonNull(Object("__Type"))),
+		Field("isDeprecated", NonNull(Scalar("Boolean"))),
+		Field("deprecationReason", Scalar("String"))).
+	Object("__InputValue",
+		Field("name", NonNull(Scalar("String"))),
+		Field("description", Scalar("String")),
+		Field("type", NonNull(Object("__Type"))),
+		Field("defaultValue", Scalar("String")),
+		Field("isDeprecated", NonNull(Scalar("Boolean"))),
+		Field("deprecationReason", Scalar("String"))).
+	Object("__EnumValue",
+		Field("name", NonNull(Scalar("String"))),
+		Field("description", Scalar("String")),
+		Field("isDeprecated", NonNull(Scalar("Boolean"))),
+		Field("deprecationReason", Scalar("String"))).
+	Object("__Directive",
+		Field("name", NonNull(Scalar("String"))),
+		Field("description", Scalar("String")),
+		Field("isRepeatable", NonNull(Scalar("Boolean"))),
+		Field("locations", NonNull(List(NonNull(Enum("__DirectiveLocation"))))),
+		Field("args", NonNull(List(NonNull(Object("__InputValue")))), includeDeprecated)).
+	Enum("__TypeKind",
+		Value("SCALAR"), Value("OBJECT"), Value("INTERFACE"), Value("UNION"),
+		Value("ENUM"), Value("INPUT_OBJECT"), Value("LIST"), Value("NON_NULL")).
+	Enum("__DirectiveLocation",
+		Value("QUERY"), Value("MUTATION"), Value("SUBSCRIPTION"), Value("FIELD"),
+		Value("FRAGMENT_DEFINITION"), Value("FRAGMENT_SPREAD"), Value("INLINE_FRAGMENT"),
+		Value("VARIABLE_DEFINITION"), Value("SCHEMA"), Value("SCALAR"), Value("OBJECT"),
+		Value("FIELD_DEFINITION"), Value("ARGUMENT_DEFINITION"), Value("INTERFACE"), Value("UNION"),
+		Value("ENUM"), Value("ENUM_VALUE"), Value("INPUT_OBJECT"), Value("INPUT_FIELD_DEFINITION"))
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InventoryKind tells the members of a field inventory apart
+type InventoryKind string
+
+const (
+	InventoryField      InventoryKind = "field"
+	InventoryInputField InventoryKind = "input-field"
+	InventoryArgument   InventoryKind = "argument"
+)
+
+// InventoryRow describes one field, input field or argument of the schema
+type InventoryRow struct {
+	Kind InventoryKind `json:"kind"`
+	Type string        `json:"type"`
+	// Field is the field or input field, or the field taking the argument
+	Field    string `json:"field"`
+	Argument string `json:"argument,omitempty"`
+	// GraphQLType is the type in GraphQL syntax, e.g. [String!]!
+	GraphQLType string `json:"graphqlType"`
+	// JSONType is the JSON Schema type of the value as converted: a scalar's type, "array",
+	// "object" for objects, interfaces, unions and input objects, "string" for enums, or "any"
+	JSONType string `json:"jsonType"`
+	// Required is whether the member is listed in required, which is the case for non-null types
+	Required          bool   `json:"required"`
+	Nullable          bool   `json:"nullable"`
+	Deprecated        bool   `json:"deprecated"`
+	DeprecationReason string `json:"deprecationReason,omitempty"`
+	Description       string `json:"description"`
+}
+
+// FieldInventory lists every field of the object and interface types, their arguments, and the
+// input fields of the input objects, as the conversion with opts sees them: field and type filters
+// are applied and internal types are left out when the options ignore them. Types are in name
+// order and members in the order the introspection lists them, each field followed by its arguments.
+func FieldInventory(introspection IntrospectionQuery, opts *Options) ([]InventoryRow, error) {
+	introspection, types, opts, err := prepareConversion(introspection, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := filterTypes(introspection.Schema.Types, opts.IgnoreInternals)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	rows := make([]InventoryRow, 0)
+	member := func(kind InventoryKind, typeName, field, argument string, typeRef IntrospectionTypeRef, description string, isDeprecated bool, deprecationReason *string) InventoryRow {
+		row := InventoryRow{
+			Kind:        kind,
+			Type:        typeName,
+			Field:       field,
+			Argument:    argument,
+			GraphQLType: typeRefString(typeRef),
+			JSONType:    inventoryJSONType(typeRef, types, opts),
+			Required:    isRequired(typeRef),
+			Nullable:    !isRequired(typeRef),
+			Description: description,
+			Deprecated:  isDeprecated,
+		}
+		if deprecationReason != nil {
+			row.DeprecationReason = *deprecationReason
+		}
+		return row
+	}
+	for _, t := range sorted {
+		switch t.Kind {
+		case "OBJECT", "INTERFACE":
+			for _, field := range t.Fields {
+				rows = append(rows, member(InventoryField, t.Name, field.Name, "", field.Type, field.Description, field.IsDeprecated, field.DeprecationReason))
+				for _, arg := range field.Args {
+					rows = append(rows, member(InventoryArgument, t.Name, field.Name, arg.Name, arg.Type, arg.Description, arg.IsDeprecated, arg.DeprecationReason))
+				}
+			}
+		case "INPUT_OBJECT":
+			for _, field := range t.InputFields {
+				rows = append(rows, member(InventoryInputField, t.Name, field.Name, "", field.Type, field.Description, field.IsDeprecated, field.DeprecationReason))
+			}
+		}
+	}
+	return rows, nil
+}
+
+// inventoryJSONType names the JSON Schema type a type reference converts to
+func inventoryJSONType(typeRef IntrospectionTypeRef, types typeIndex, opts *Options) string {
+	switch typeRef.Kind {
+	case "NON_NULL":
+		if typeRef.OfType != nil {
+			return inventoryJSONType(*typeRef.OfType, types, opts)
+		}
+		return "any"
+	case "LIST":
+		return "array"
+	}
+	if typeRef.Name == nil {
+		return "any"
+	}
+
+	kind := typeRef.Kind
+	if t := types[*typeRef.Name]; t != nil {
+		kind = t.Kind
+	}
+	switch kind {
+	case "SCALAR":
+		switch t := processScalar(*typeRef.Name, opts).Type.(type) {
+		case string:
+			if t != "" {
+				return t
+			}
+		case []string:
+			return strings.Join(t, "|")
+		case []interface{}:
+			names := make([]string, len(t))
+			for i, name := range t {
+				names[i] = fmt.Sprint(name)
+			}
+			return strings.Join(names, "|")
+		}
+		return "any"
+	case "ENUM":
+		return "string"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,94 @@
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const inventorySDL = `
+type Query {
+  "Look a user up"
+  user(id: ID!, "Include drafts, if any" drafts: Boolean = false): User
+}
+
+type User {
+  id: ID!
+  tags: [String!]!
+  role: Role
+  email: String @deprecated(reason: "Use contact")
+  score: Float
+}
+
+enum Role { ADMIN }
+
+input UserInput { name: String! manager: UserInput }
+`
+
+func TestFieldInventory(t *testing.T) {
+	rows, err := pkg.FieldInventory(mustIntrospect(t, inventorySDL), nil)
+	if err != nil {
+		t.Fatalf("FieldInventory: %v", err)
+	}
+	want := []pkg.InventoryRow{
+		{Kind: pkg.InventoryField, Type: "Query", Field: "user", GraphQLType: "User", JSONType: "object", Nullable: true, Description: "Look a user up"},
+		{Kind: pkg.InventoryArgument, Type: "Query", Field: "user", Argument: "id", GraphQLType: "ID!", JSONType: "string", Required: true},
+		{Kind: pkg.InventoryArgument, Type: "Query", Field: "user", Argument: "drafts", GraphQLType: "Boolean", JSONType: "boolean", Nullable: true, Description: "Include drafts, if any"},
+		{Kind: pkg.InventoryField, Type: "User", Field: "id", GraphQLType: "ID!", JSONType: "string", Required: true},
+		{Kind: pkg.InventoryField, Type: "User", Field: "tags", GraphQLType: "[String!]!", JSONType: "array", Required: true},
+		{Kind: pkg.InventoryField, Type: "User", Field: "role", GraphQLType: "Role", JSONType: "string", Nullable: true},
+		{Kind: pkg.InventoryField, Type: "User", Field: "email", GraphQLType: "String", JSONType: "string", Nullable: true, Deprecated: true, DeprecationReason: "Use contact"},
+		{Kind: pkg.InventoryField, Type: "User", Field: "score", GraphQLType: "Float", JSONType: "number", Nullable: true},
+		{Kind: pkg.InventoryInputField, Type: "UserInput", Field: "name", GraphQLType: "String!", JSONType: "string", Required: true},
+		{Kind: pkg.InventoryInputField, Type: "UserInput", Field: "manager", GraphQLType: "UserInput", JSONType: "object", Nullable: true},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("%d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d:\n got %+v\nwant %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestFieldInventoryOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		set    func(*pkg.Options)
+		member string
+		json   string
+		absent string
+	}{
+		{"numeric IDs", func(o *pkg.Options) { o.IDTypeMapping = pkg.IDTypeNumber }, "User.id", "number", ""},
+		{"excluded field", func(o *pkg.Options) { o.ExcludeFields = []string{"User.email"} }, "User.id", "string", "User.email"},
+		{"excluded type", func(o *pkg.Options) { o.ExcludeTypes = []string{"UserInput"} }, "User.role", "string", "UserInput.name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := pkg.FieldInventory(mustIntrospect(t, inventorySDL), options(tt.set))
+			if err != nil {
+				t.Fatalf("FieldInventory: %v", err)
+			}
+			found := false
+			for _, row := range rows {
+				coordinate := row.Type + "." + row.Field
+				if row.Kind == pkg.InventoryArgument {
+					continue
+				}
+				if coordinate == tt.absent {
+					t.Errorf("%s was not filtered out", tt.absent)
+				}
+				if coordinate == tt.member {
+					found = true
+					if row.JSONType != tt.json {
+						t.Errorf("%s json type = %s, want %s", tt.member, row.JSONType, tt.json)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("no row for %s", tt.member)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const layoutSDL = `
+type Query {
+  user(id: ID!): User
+  users: [User!]!
+}
+
+type Mutation {
+  createUser(name: String!): User
+}
+
+type User { id: ID! name: String }
+`
+
+func TestOperationsLayoutGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		set    func(*pkg.Options)
+	}{
+		{"nested.json", func(o *pkg.Options) { o.OperationsLayout = pkg.OperationsLayoutNested }},
+		{"flat.json", func(o *pkg.Options) { o.OperationsLayout = pkg.OperationsLayoutFlat }},
+		{"both.json", func(o *pkg.Options) { o.OperationsLayout = pkg.OperationsLayoutBoth }},
+		{"flat-key.json", func(o *pkg.Options) {
+			o.OperationsLayout = pkg.OperationsLayoutFlat
+			o.OperationsKey = "ops"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			schema := mustConvert(t, layoutSDL, options(func(o *pkg.Options) {
+				o.PruneToRoots = true
+				tt.set(o)
+			}))
+			got, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "layout/"+tt.golden, append(got, '\n'))
+		})
+	}
+}
+
+func TestOperationsLayoutBothSharesSchemas(t *testing.T) {
+	schema := mustConvert(t, layoutSDL, options(func(o *pkg.Options) { o.OperationsLayout = pkg.OperationsLayoutBoth }))
+	operations := schema.Properties[pkg.DefaultOperationsKey]
+	if operations == nil {
+		t.Fatal("no operations map")
+	}
+	for _, coordinate := range []string{"Query.user", "Query.users", "Mutation.createUser"} {
+		entry := operations.Properties[coordinate]
+		if entry == nil || entry.Ref == "" {
+			t.Errorf("%s: expected a $ref to the nested field, got %+v", coordinate, entry)
+			continue
+		}
+		target, err := schema.GetByPointer(entry.Ref)
+		if err != nil {
+			t.Errorf("%s: %v", coordinate, err)
+			continue
+		}
+		root, field, _ := strings.Cut(coordinate, ".")
+		if target != schema.Properties[root].Properties[field] {
+			t.Errorf("%s: $ref %s does not lead to the nested field", coordinate, entry.Ref)
+		}
+	}
+}
+
+func TestOperationsLayoutKeyCollision(t *testing.T) {
+	introspection := mustIntrospect(t, layoutSDL)
+	_, err := pkg.FromIntrospectionQuery(introspection, options(func(o *pkg.Options) {
+		o.OperationsLayout = pkg.OperationsLayoutBoth
+		o.OperationsKey = "Query"
+	}))
+	if err == nil || !strings.Contains(err.Error(), `operations key "Query" collides with a root property`) {
+		t.Errorf("expected a collision error, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// skipMalformedTypes removes the types that fail validateType, logging a warning for each, and
+// returns the remaining types with the reason each skipped type was removed
+func skipMalformedTypes(types []IntrospectionType, logger *slog.Logger) ([]IntrospectionType, map[string]string) {
+	var skipped map[string]string
+	kept := types[:0:0]
+	for _, t := range types {
+		reason := validateType(t)
+		if reason == "" {
+			kept = append(kept, t)
+			continue
+		}
+		logger.Warn("Malformed type is skipped", LogKeyWarning, WarningSkippedType, LogKeyType, t.Name, "reason", reason)
+		if t.Name == "" {
+			continue
+		}
+		if skipped == nil {
+			skipped = make(map[string]string)
+		}
+		skipped[t.Name] = reason
+	}
+	if skipped == nil {
+		return types, nil
+	}
+	return kept, skipped
+}
+
+// validateType returns why a type is structurally malformed, or "" when it can be converted
+func validateType(t IntrospectionType) string {
+	if t.Name == "" {
+		return "type without a name"
+	}
+
+	switch t.Kind {
+	case "SCALAR":
+	case "OBJECT", "INTERFACE":
+		if t.Fields == nil {
+			return "fields is null"
+		}
+		for _, field := range t.Fields {
+			if field.Name == "" {
+				return "field without a name"
+			}
+			if reason := validateTypeRef(field.Type); reason != "" {
+				return fmt.Sprintf("field %s: %s", field.Name, reason)
+			}
+			for _, arg := range field.Args {
+				if reason := validateTypeRef(arg.Type); reason != "" {
+					return fmt.Sprintf("argument %s.%s: %s", field.Name, arg.Name, reason)
+				}
+			}
+		}
+	case "INPUT_OBJECT":
+		if t.InputFields == nil {
+			return "inputFields is null"
+		}
+		for _, field := range t.InputFields {
+			if field.Name == "" {
+				return "input field without a name"
+			}
+			if reason := validateTypeRef(field.Type); reason != "" {
+				return fmt.Sprintf("input field %s: %s", field.Name, reason)
+			}
+		}
+	case "ENUM":
+		if t.EnumValues == nil {
+			return "enumValues is null"
+		}
+	case "UNION":
+		if t.PossibleTypes == nil {
+			return "possibleTypes is null"
+		}
+		for _, member := range t.PossibleTypes {
+			if member.Name == "" {
+				return "member type without a name"
+			}
+		}
+	default:
+		return fmt.Sprintf("unknown kind %q", t.Kind)
+	}
+	return ""
+}
+
+// validateTypeRef returns why a type reference is malformed, or "" when it names a type
+func validateTypeRef(typeRef IntrospectionTypeRef) string {
+	for {
+		switch typeRef.Kind {
+		case "NON_NULL", "LIST":
+			if typeRef.OfType == nil {
+				return "truncated ofType chain in " + strings.ToLower(typeRef.Kind)
+			}
+			if typeRef.Kind == "NON_NULL" && typeRef.OfType.Kind == "NON_NULL" {
+				return "non-null of non-null"
+			}
+			typeRef = *typeRef.OfType
+		case "SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT":
+			if typeRef.Name == nil || *typeRef.Name == "" {
+				return "reference without a type name"
+			}
+			return ""
+		default:
+			return fmt.Sprintf("unknown type reference kind %q", typeRef.Kind)
+		}
+	}
+}
+
+// addSkippedPlaceholders adds a definition accepting any value, annotated with the reason, for each
+// skipped type the schema references or include selects, so that no reference to a skipped type
+// dangles. include may be nil to add placeholders for referenced types only.
+func addSkippedPlaceholders(schema *JSONSchema6, skipped map[string]string, include func(name string) bool) {
+	if len(skipped) == 0 {
+		return
+	}
+	referenced := make(map[string]bool)
+	Walk(schema, func(s *JSONSchema6) {
+		if name, ok := strings.CutPrefix(s.Ref, "#/definitions/"); ok {
+			name, _, _ = strings.Cut(name, "/")
+			referenced[unescapePointerSegment(name)] = true
+		}
+	})
+	if schema.Definitions == nil {
+		schema.Definitions = make(map[string]*JSONSchema6)
+	}
+	for name, reason := range skipped {
+		if (include != nil && include(name) && !strings.HasPrefix(name, "__")) || referenced[name] {
+			schema.Definitions[name] = &JSONSchema6{Skipped: reason}
+		}
+	}
+}
+
+// logSkippedTypes summarizes the types skipped by Options.Lenient after the conversion, so the
+// per-type warnings cannot go unnoticed
+func logSkippedTypes(logger *slog.Logger, skipped map[string]string) {
+	if len(skipped) == 0 {
+		return
+	}
+	names := make([]string, 0, len(skipped))
+	for name := range skipped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	logger.Warn("Malformed types were skipped and accept any value in the output", "count", len(names), "types", strings.Join(names, ", "))
+}
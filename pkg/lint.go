@@ -0,0 +1,310 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LintSeverity classifies how badly a lint finding affects the converted schema
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+)
+
+// Lint rule names
+const (
+	RuleUnmappedScalar   = "unmapped-scalar"
+	RuleUntaggedUnion    = "untagged-union"
+	RuleRecursiveType    = "recursive-type"
+	RuleNameCollision    = "name-collision"
+	RuleLargeEnum        = "large-enum"
+	RuleDeprecatedMember = "deprecated-member"
+)
+
+// LintRules maps each lint rule to the severity it is reported with
+var LintRules = map[string]LintSeverity{
+	RuleUnmappedScalar:   LintError,
+	RuleUntaggedUnion:    LintWarning,
+	RuleRecursiveType:    LintWarning,
+	RuleNameCollision:    LintWarning,
+	RuleLargeEnum:        LintWarning,
+	RuleDeprecatedMember: LintWarning,
+}
+
+// builtinScalars are the scalars the converter maps to a JSON Schema type
+var builtinScalars = map[string]bool{
+	"ID":      true,
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+}
+
+// LintOptions controls which rules Lint runs
+type LintOptions struct {
+	// Disabled lists rule names that are not reported
+	Disabled []string `json:"disabled"`
+	// MaxEnumValues is the number of enum values above which large-enum is reported
+	MaxEnumValues int `json:"maxEnumValues"`
+	// IgnoreInternals skips GraphQL internal types such as __Type
+	IgnoreInternals bool `json:"ignoreInternals"`
+}
+
+// DefaultLintOptions returns the default lint options
+func DefaultLintOptions() LintOptions {
+	return LintOptions{
+		MaxEnumValues:   100,
+		IgnoreInternals: true,
+	}
+}
+
+// LintFinding is a single pattern that converts poorly, located by its GraphQL coordinate
+type LintFinding struct {
+	Rule       string       `json:"rule"`
+	Severity   LintSeverity `json:"severity"`
+	Coordinate string       `json:"coordinate"`
+	Message    string       `json:"message"`
+}
+
+// Lint reports GraphQL patterns that do not translate well to JSON Schema.
+// Findings are ordered by severity, then coordinate.
+func Lint(introspection IntrospectionQuery, opts LintOptions) ([]LintFinding, error) {
+	disabled := make(map[string]bool, len(opts.Disabled))
+	for _, rule := range opts.Disabled {
+		if _, ok := LintRules[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule: %s", rule)
+		}
+		disabled[rule] = true
+	}
+
+	types := filterTypes(introspection.Schema.Types, opts.IgnoreInternals)
+	l := &linter{
+		opts:     opts,
+		disabled: disabled,
+		types:    types,
+		index:    newTypeIndex(types),
+		findings: make([]LintFinding, 0),
+	}
+	l.lintScalars()
+	l.lintUnions()
+	l.lintRecursion()
+	l.lintNames()
+	l.lintEnums()
+	l.lintDeprecations()
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		if l.findings[i].Severity != l.findings[j].Severity {
+			return l.findings[i].Severity == LintError
+		}
+		return l.findings[i].Coordinate < l.findings[j].Coordinate
+	})
+	return l.findings, nil
+}
+
+type linter struct {
+	opts     LintOptions
+	disabled map[string]bool
+	types    []IntrospectionType
+	index    typeIndex
+	findings []LintFinding
+}
+
+func (l *linter) report(rule, coordinate, format string, args ...interface{}) {
+	if l.disabled[rule] {
+		return
+	}
+	l.findings = append(l.findings, LintFinding{
+		Rule:       rule,
+		Severity:   LintRules[rule],
+		Coordinate: coordinate,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// lintScalars reports custom scalars, which convert to a schema without a type
+func (l *linter) lintScalars() {
+	for _, t := range l.types {
+		if t.Kind == "SCALAR" && !builtinScalars[t.Name] {
+			l.report(RuleUnmappedScalar, t.Name, "custom scalar has no JSON Schema mapping and accepts any value")
+		}
+	}
+}
+
+// lintUnions reports unions where an instance of one member also satisfies another member,
+// so consumers validating against oneOf cannot tell them apart
+func (l *linter) lintUnions() {
+	for _, t := range l.types {
+		if t.Kind != "UNION" {
+			continue
+		}
+		for _, a := range t.PossibleTypes {
+			for _, b := range t.PossibleTypes {
+				if a.Name >= b.Name {
+					continue
+				}
+				if l.subsumes(a.Name, b.Name) || l.subsumes(b.Name, a.Name) {
+					l.report(RuleUntaggedUnion, t.Name, "members %s and %s cannot be discriminated without __typename", a.Name, b.Name)
+				}
+			}
+		}
+	}
+}
+
+// subsumes reports whether every instance of other also satisfies the required fields of member
+func (l *linter) subsumes(member, other string) bool {
+	memberType, otherType := l.index[member], l.index[other]
+	if memberType == nil || otherType == nil {
+		return false
+	}
+	for _, field := range memberType.Fields {
+		if isRequired(field.Type) && findField(otherType.Fields, field.Name) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// lintRecursion reports composite types that reference themselves, directly or transitively
+func (l *linter) lintRecursion() {
+	edges := make(map[string][]string)
+	for _, t := range l.types {
+		for _, field := range t.Fields {
+			if named := namedTypeRef(field.Type); named != nil && named.Name != nil {
+				edges[t.Name] = append(edges[t.Name], *named.Name)
+			}
+		}
+		for _, field := range t.InputFields {
+			if named := namedTypeRef(field.Type); named != nil && named.Name != nil {
+				edges[t.Name] = append(edges[t.Name], *named.Name)
+			}
+		}
+		for _, possibleType := range t.PossibleTypes {
+			edges[t.Name] = append(edges[t.Name], possibleType.Name)
+		}
+	}
+
+	for _, component := range stronglyConnected(edges) {
+		selfReferencing := len(component) == 1 && containsString(edges[component[0]], component[0])
+		if len(component) < 2 && !selfReferencing {
+			continue
+		}
+		for _, name := range component {
+			l.report(RuleRecursiveType, name, "type is part of a reference cycle (%s) and cannot be fully inlined", strings.Join(component, ", "))
+		}
+	}
+}
+
+// lintNames reports fields whose names collide once case and separators are ignored
+func (l *linter) lintNames() {
+	for _, t := range l.types {
+		names := make([]string, 0, len(t.Fields)+len(t.InputFields))
+		for _, field := range t.Fields {
+			names = append(names, field.Name)
+		}
+		for _, field := range t.InputFields {
+			names = append(names, field.Name)
+		}
+
+		seen := make(map[string]string)
+		for _, name := range names {
+			key := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+			if previous, ok := seen[key]; ok {
+				l.report(RuleNameCollision, t.Name+"."+name, "field name collides with %s after case transforms", previous)
+				continue
+			}
+			seen[key] = name
+		}
+	}
+}
+
+// lintEnums reports enums too large to be practical as JSON Schema enum lists
+func (l *linter) lintEnums() {
+	for _, t := range l.types {
+		if t.Kind == "ENUM" && l.opts.MaxEnumValues > 0 && len(t.EnumValues) > l.opts.MaxEnumValues {
+			l.report(RuleLargeEnum, t.Name, "enum has %d values (more than %d)", len(t.EnumValues), l.opts.MaxEnumValues)
+		}
+	}
+}
+
+// lintDeprecations reports deprecated fields and enum values that are still part of the schema
+func (l *linter) lintDeprecations() {
+	for _, t := range l.types {
+		for _, field := range t.Fields {
+			if field.IsDeprecated {
+				l.report(RuleDeprecatedMember, t.Name+"."+field.Name, "deprecated field is still present%s", deprecationSuffix(field.DeprecationReason))
+			}
+		}
+		for _, value := range t.EnumValues {
+			if value.IsDeprecated {
+				l.report(RuleDeprecatedMember, t.Name+"."+value.Name, "deprecated enum value is still present%s", deprecationSuffix(value.DeprecationReason))
+			}
+		}
+	}
+}
+
+func deprecationSuffix(reason *string) string {
+	if reason == nil || *reason == "" {
+		return ""
+	}
+	return ": " + *reason
+}
+
+// stronglyConnected returns the strongly connected components of a graph using Tarjan's algorithm,
+// each sorted by name and visited in sorted order for deterministic output
+func stronglyConnected(edges map[string][]string) [][]string {
+	nodes := make([]string, 0, len(edges))
+	for name := range edges {
+		nodes = append(nodes, name)
+	}
+	sort.Strings(nodes)
+
+	index := 0
+	indices := make(map[string]int)
+	lowlinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	components := make([][]string, 0)
+
+	var connect func(node string)
+	connect = func(node string) {
+		indices[node] = index
+		lowlinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range edges[node] {
+			if _, visited := indices[next]; !visited {
+				connect(next)
+				lowlinks[node] = min(lowlinks[node], lowlinks[next])
+			} else if onStack[next] {
+				lowlinks[node] = min(lowlinks[node], indices[next])
+			}
+		}
+
+		if lowlinks[node] == indices[node] {
+			component := make([]string, 0)
+			for {
+				last := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[last] = false
+				component = append(component, last)
+				if last == node {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := indices[node]; !visited {
+			connect(node)
+		}
+	}
+	return components
+}
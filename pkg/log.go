@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// Attribute keys used in log records, so handlers can filter and index them consistently
+const (
+	LogKeyType    = "graphql.type"
+	LogKeyField   = "graphql.field"
+	LogKeyWarning = "warning.code"
+)
+
+// Warning codes logged under LogKeyWarning
+const (
+	// WarningUnknownType is a reference to a type that is not in the introspection result
+	WarningUnknownType = "unknown-type"
+	// WarningInvalidDefault is a default value that is not valid JSON, such as an enum value or an input object
+	// literal in GraphQL syntax, and is left out of the schema. It is logged at debug level as it is common.
+	WarningInvalidDefault = "invalid-default"
+	// WarningEmptyType is an enum without values or a union without members, which no value matches
+	WarningEmptyType = "empty-type"
+	// WarningDeprecatedField is a deprecated field selected by an operation
+	WarningDeprecatedField = "deprecated-field"
+	// WarningUnmatchedPattern is an include or exclude field pattern that matches no field
+	WarningUnmatchedPattern = "unmatched-pattern"
+	// WarningInvalidExample is a fenced json example in a description that is not valid JSON
+	WarningInvalidExample = "invalid-example"
+	// WarningSkippedType is a structurally malformed type left out of the schema by Options.Lenient
+	WarningSkippedType = "skipped-type"
+)
+
+// discardHandler drops every record; it backs the logger used when Options.Logger is nil
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
+
+// logger returns Options.Logger, or a logger that discards everything when it is nil
+func (o *Options) logger() *slog.Logger {
+	if o.Logger == nil {
+		return discardLogger
+	}
+	return o.Logger
+}
+
+// logTypeWarnings reports problems in the introspection that the conversion silently works around
+func logTypeWarnings(logger *slog.Logger, types []IntrospectionType, index typeIndex) {
+	if !logger.Enabled(context.Background(), slog.LevelWarn) {
+		return
+	}
+
+	checkRef := func(typeName, fieldName string, typeRef IntrospectionTypeRef) {
+		named := namedTypeRef(typeRef)
+		if named != nil && named.Name != nil && index[*named.Name] == nil {
+			logger.Warn("Reference to a type that is not in the introspection result",
+				LogKeyWarning, WarningUnknownType, LogKeyType, typeName, LogKeyField, fieldName, "reference", *named.Name)
+		}
+	}
+	checkDefault := func(typeName, fieldName string, defaultValue *string) {
+		var value interface{}
+		if defaultValue != nil && json.Unmarshal([]byte(*defaultValue), &value) != nil {
+			logger.Debug("Default value is not valid JSON and is left out",
+				LogKeyWarning, WarningInvalidDefault, LogKeyType, typeName, LogKeyField, fieldName, "default", *defaultValue)
+		}
+	}
+
+	for _, t := range types {
+		switch t.Kind {
+		case "OBJECT", "INTERFACE":
+			for _, field := range t.Fields {
+				checkRef(t.Name, field.Name, field.Type)
+				for _, arg := range field.Args {
+					checkRef(t.Name, field.Name+"."+arg.Name, arg.Type)
+					checkDefault(t.Name, field.Name+"."+arg.Name, arg.DefaultValue)
+				}
+			}
+		case "INPUT_OBJECT":
+			for _, field := range t.InputFields {
+				checkRef(t.Name, field.Name, field.Type)
+				checkDefault(t.Name, field.Name, field.DefaultValue)
+			}
+		case "ENUM":
+			if len(t.EnumValues) == 0 {
+				logger.Warn("Enum has no values", LogKeyWarning, WarningEmptyType, LogKeyType, t.Name)
+			}
+		case "UNION":
+			if len(t.PossibleTypes) == 0 {
+				logger.Warn("Union has no member types", LogKeyWarning, WarningEmptyType, LogKeyType, t.Name)
+			}
+			for _, member := range t.PossibleTypes {
+				if index[member.Name] == nil {
+					logger.Warn("Reference to a type that is not in the introspection result",
+						LogKeyWarning, WarningUnknownType, LogKeyType, t.Name, "reference", member.Name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package pkg_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// recordingHandler keeps every record it handles, with its attributes flattened into a map
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []loggedRecord
+}
+
+type loggedRecord struct {
+	level   slog.Level
+	message string
+	attrs   map[string]string
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	record := loggedRecord{level: r.Level, message: r.Message, attrs: map[string]string{}}
+	r.Attrs(func(attr slog.Attr) bool {
+		record.attrs[attr.Key] = attr.Value.String()
+		return true
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+// find returns the first record with the given warning code
+func (h *recordingHandler) find(code string) (loggedRecord, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, record := range h.records {
+		if record.attrs[pkg.LogKeyWarning] == code {
+			return record, true
+		}
+	}
+	return loggedRecord{}, false
+}
+
+const logSDL = `
+type Query {
+  user(role: Role = ADMIN): User
+  """
+  Look a user up.
+
+  ` + "```json\n  {not json}\n  ```" + `
+  """
+  lookup: User
+}
+
+type User { id: ID! name: String @deprecated(reason: "Use id") }
+
+enum Role { ADMIN MEMBER }
+`
+
+func TestLoggerWarnings(t *testing.T) {
+	introspection := mustIntrospect(t, logSDL)
+	for i := range introspection.Schema.Types {
+		switch introspection.Schema.Types[i].Name {
+		case "User":
+			// Point User.id at a type the introspection does not have
+			missing := "Missing"
+			introspection.Schema.Types[i].Fields[0].Type.OfType.Name = &missing
+		case "Role":
+			introspection.Schema.Types[i].EnumValues = nil
+		}
+	}
+
+	tests := []struct {
+		code  string
+		level slog.Level
+		attrs map[string]string
+	}{
+		{pkg.WarningUnknownType, slog.LevelWarn, map[string]string{pkg.LogKeyType: "User", pkg.LogKeyField: "id", "reference": "Missing"}},
+		{pkg.WarningEmptyType, slog.LevelWarn, map[string]string{pkg.LogKeyType: "Role"}},
+		{pkg.WarningInvalidDefault, slog.LevelDebug, map[string]string{pkg.LogKeyType: "Query", pkg.LogKeyField: "user.role", "default": "ADMIN"}},
+		{pkg.WarningInvalidExample, slog.LevelWarn, map[string]string{pkg.LogKeyType: "Query", pkg.LogKeyField: "lookup"}},
+	}
+
+	handler := &recordingHandler{}
+	opts := options(func(o *pkg.Options) {
+		o.Logger = slog.New(handler)
+		o.ExtractExamples = true
+	})
+	if _, err := pkg.FromIntrospectionQuery(introspection, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			record, ok := handler.find(tt.code)
+			if !ok {
+				t.Fatalf("no record with %s=%s", pkg.LogKeyWarning, tt.code)
+			}
+			if record.level != tt.level {
+				t.Errorf("level = %s, want %s", record.level, tt.level)
+			}
+			for key, want := range tt.attrs {
+				if got := record.attrs[key]; got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggerDeprecatedFieldInOperation(t *testing.T) {
+	introspection := mustIntrospect(t, logSDL)
+	doc, err := pkg.ParseDocument("query { lookup { id name } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &recordingHandler{}
+	opts := options(func(o *pkg.Options) { o.Logger = slog.New(handler) })
+	if _, err := pkg.OperationResponseSchema(introspection, doc, "", opts); err != nil {
+		t.Fatal(err)
+	}
+	record, ok := handler.find(pkg.WarningDeprecatedField)
+	if !ok {
+		t.Fatal("selecting a deprecated field was not logged")
+	}
+	if record.attrs[pkg.LogKeyType] != "User" || record.attrs[pkg.LogKeyField] != "name" {
+		t.Errorf("attributes = %v, want User.name", record.attrs)
+	}
+}
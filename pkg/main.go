@@ -2,7 +2,11 @@ package pkg
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"sort"
 )
 
 // IDTypeMapping represents how the GraphQL ID type should be mapped in JSON Schema
@@ -19,10 +23,73 @@ const (
 type OperationType string
 
 const (
-	OperationQuery    OperationType = "query"
-	OperationMutation OperationType = "mutation"
+	OperationQuery        OperationType = "query"
+	OperationMutation     OperationType = "mutation"
+	OperationSubscription OperationType = "subscription"
 )
 
+// OperationsLayout selects how the fields of the root operation types are laid out
+type OperationsLayout string
+
+const (
+	// OperationsLayoutNested emits a property per root type, e.g. properties.Query.properties.user
+	OperationsLayoutNested OperationsLayout = "nested"
+	// OperationsLayoutFlat emits a single map keyed by coordinate, e.g. properties.operations.properties["Query.user"]
+	OperationsLayoutFlat OperationsLayout = "flat"
+	// OperationsLayoutBoth emits the nested properties and a flat map whose entries reference them
+	OperationsLayoutBoth OperationsLayout = "both"
+)
+
+// EnumStyle selects how enum definitions list their values
+type EnumStyle string
+
+const (
+	// EnumStyleAnyOf emits an anyOf of single-value enums, each with the value's description as title
+	// and description
+	EnumStyleAnyOf EnumStyle = "anyOf"
+	// EnumStyleFlat emits a single enum of all values, with their descriptions in x-enum-descriptions and
+	// deprecated values in x-enum-deprecated
+	EnumStyleFlat EnumStyle = "flat"
+)
+
+// IsValidEnumStyle checks if the provided EnumStyle is valid; empty means anyOf
+func IsValidEnumStyle(style EnumStyle) bool {
+	return style == "" || style == EnumStyleAnyOf || style == EnumStyleFlat
+}
+
+// FieldShape selects how the fields of objects and interfaces are emitted
+type FieldShape string
+
+const (
+	// FieldShapeWrapped emits each field as an object holding the schema of its arguments under
+	// "arguments" and of its value under "return"
+	FieldShapeWrapped FieldShape = "wrapped"
+	// FieldShapePlain emits each field as the schema of its value in response data, accepting null for
+	// nullable fields, and leaves the arguments out
+	FieldShapePlain FieldShape = "plain"
+)
+
+// IsValidFieldShape checks if the provided FieldShape is valid; empty means wrapped
+func IsValidFieldShape(shape FieldShape) bool {
+	return shape == "" || shape == FieldShapeWrapped || shape == FieldShapePlain
+}
+
+// NullableFields selects how the schemas of nullable fields, arguments and input fields accept null
+type NullableFields string
+
+const (
+	// NullableFieldsOff leaves nullability to the required list, so null is not accepted
+	NullableFieldsOff NullableFields = "off"
+	// NullableFieldsTypeArray adds null to the type, e.g. {"type": ["string", "null"]}, and falls back to
+	// anyOf for references, enums and schemas without a type
+	NullableFieldsTypeArray NullableFields = "typeArray"
+	// NullableFieldsAnyOf combines the schema with null, e.g. {"anyOf": [{"$ref": ...}, {"type": "null"}]}
+	NullableFieldsAnyOf NullableFields = "anyOf"
+)
+
+// DefaultOperationsKey is the property holding the flat operations map when Options.OperationsKey is empty
+const DefaultOperationsKey = "operations"
+
 // Options contains configuration options for the conversion process
 type Options struct {
 	IgnoreInternals    bool           `json:"ignoreInternals"`
@@ -30,34 +97,256 @@ type Options struct {
 	IDTypeMapping      IDTypeMapping  `json:"idTypeMapping"`
 	Operation          *OperationType `json:"operation,omitempty"`
 	MethodName         string         `json:"methodName,omitempty"`
+	// DefinitionsOnly omits the top-level root properties and emits only definitions
+	DefinitionsOnly bool `json:"definitionsOnly,omitempty"`
+	// OnlyInputTypes omits the root properties and emits only the definitions of input object types
+	// and the enums and input types they reference. With Operation, MethodName or PruneToRoots only
+	// the input types reachable from the selected roots or fields are emitted.
+	OnlyInputTypes bool `json:"onlyInputTypes,omitempty"`
+	// Roots restricts which root operation types are emitted as properties; empty means all
+	Roots []OperationType `json:"roots,omitempty"`
+	// PruneToRoots drops definitions that are not reachable from the emitted roots
+	PruneToRoots bool `json:"pruneToRoots,omitempty"`
+	// Concurrency is the number of workers that process definitions; 0 uses GOMAXPROCS and 1 processes serially
+	Concurrency int `json:"concurrency,omitempty"`
+	// InlineRefs replaces every $ref with a copy of the referenced definition, see InlineDefinitions.
+	// References that would recurse are kept, along with the definitions they need.
+	InlineRefs bool `json:"inlineRefs,omitempty"`
+	// InlineDepth limits how deeply InlineRefs nests expanded definitions; 0 means unlimited
+	InlineDepth int `json:"inlineDepth,omitempty"`
+	// InlineOmitDefinitions leaves the definitions out with InlineRefs. The references kept at cycles
+	// and the depth limit then accept any value, see DropDefinitions.
+	InlineOmitDefinitions bool `json:"inlineOmitDefinitions,omitempty"`
+	// DedupeDefinitions hoists repeated subschemas into shared definitions, see DedupeSubschemas
+	DedupeDefinitions bool `json:"dedupeDefinitions,omitempty"`
+	// Progress is called periodically while definitions are processed with the number done so far and
+	// the total. Returning an error aborts the conversion with that error. It is never called concurrently.
+	Progress func(done, total int) error `json:"-"`
+	// ClosedComposition rejects properties not defined by the matched branch of unions and allOf-composed
+	// objects with unevaluatedProperties: false. The keyword is new in draft 2019-09, so it needs Draft202012
+	// and is an error with earlier drafts.
+	ClosedComposition bool `json:"closedComposition,omitempty"`
+	// Logger receives debug details and warnings about the introspection, with the LogKey* attributes.
+	// nil discards them.
+	Logger *slog.Logger `json:"-"`
+	// OperationsLayout selects nested root properties, a flat operations map or both; empty means nested
+	OperationsLayout OperationsLayout `json:"operationsLayout,omitempty"`
+	// OperationsKey is the property holding the flat operations map; empty means DefaultOperationsKey
+	OperationsKey string `json:"operationsKey,omitempty"`
+	// ExcludeFields drops fields matching Type.field patterns, where either side may use * wildcards
+	ExcludeFields []string `json:"excludeFields,omitempty"`
+	// IncludeFields keeps only the matching fields of the types its patterns name; other types are unaffected
+	IncludeFields []string `json:"includeFields,omitempty"`
+	// ExtractExamples moves the samples shown by Example: lines and ```json blocks in type, field and
+	// argument descriptions into examples
+	ExtractExamples bool `json:"extractExamples,omitempty"`
+	// StripExamples removes the extracted samples from the emitted descriptions
+	StripExamples bool `json:"stripExamples,omitempty"`
+	// IncludeTypes keeps only the types whose names match these patterns, besides the root types and
+	// built-in scalars, and drops the others as ExcludeTypes does. Patterns match whole names with *
+	// wildcards, or are regular expressions between slashes, such as /Input$/.
+	IncludeTypes []string `json:"includeTypes,omitempty"`
+	// IncludeTypeDependencies also keeps every type the types matching IncludeTypes transitively
+	// reference, so that their fields are kept whole
+	IncludeTypeDependencies bool `json:"includeTypeDependencies,omitempty"`
+	// ExcludeTypes drops the types whose names match these patterns, which may use * wildcards or be
+	// regular expressions between slashes, along with the fields, arguments, input fields and union
+	// members that refer to them. It applies after IncludeTypes.
+	ExcludeTypes []string `json:"excludeTypes,omitempty"`
+	// PresetExcludeTypes and PresetExcludeFields hold the patterns a Preset adds. They drop types and
+	// fields as ExcludeTypes and ExcludeFields do, except those IncludeTypes and IncludeFields select,
+	// so that the options' own filters win over the preset's.
+	PresetExcludeTypes  []string `json:"presetExcludeTypes,omitempty"`
+	PresetExcludeFields []string `json:"presetExcludeFields,omitempty"`
+	// CustomScalarSchemas gives the schema emitted for a scalar wherever it is used and as its
+	// definition, keyed by scalar name. It takes precedence over the built-in handling, ID included.
+	CustomScalarSchemas map[string]*JSONSchema6 `json:"customScalarSchemas,omitempty"`
+	// FlattenConnections replaces Relay connection types in field types with a list of their nodes,
+	// see flattenConnections
+	FlattenConnections bool `json:"flattenConnections,omitempty"`
+	// EmbedSDL attaches the GraphQL definition of each type to its definition as a $comment, see TypeSDL.
+	// Definitions of large types are truncated.
+	EmbedSDL bool `json:"embedSDL,omitempty"`
+	// Lenient skips types that are structurally malformed, such as fields with truncated ofType chains
+	// or null field lists, instead of emitting schemas for them. Each skipped type is logged as a
+	// WarningSkippedType and its definition is replaced by a schema accepting any value, annotated
+	// with the reason in x-graphql-skipped.
+	Lenient bool `json:"lenient,omitempty"`
+	// GoHints annotates the definitions and scalar usages of the named types with Go type hints for
+	// code generators, see GoTypeHint and DefaultGoHints. nil emits none.
+	GoHints map[string]GoTypeHint `json:"goHints,omitempty"`
+	// SharedPaginationArgs replaces the standard Relay pagination arguments of connection fields by a
+	// $ref to a shared RelayPaginationArgs definition, see isRelayPagination
+	SharedPaginationArgs bool `json:"sharedPaginationArgs,omitempty"`
+	// Draft selects the JSON Schema dialect of the output; empty means draft-06
+	Draft Draft `json:"draft,omitempty"`
+	// UseIntegerType emits Int as integer instead of number, so that values such as 1.5 are rejected
+	UseIntegerType bool `json:"useIntegerType,omitempty"`
+	// IntBounds limits Int to the signed 32-bit range the GraphQL spec gives it with minimum and maximum
+	IntBounds bool `json:"intBounds,omitempty"`
+	// WellKnownScalars maps the common temporal scalars DateTime, Timestamp, Date and Time to strings
+	// with a date-time, date or time format, see wellKnownScalars. CustomScalarSchemas and specifiedByURL
+	// take precedence, for servers whose Timestamp is an epoch number.
+	WellKnownScalars bool `json:"wellKnownScalars,omitempty"`
+	// AdditionalPropertiesFalse closes the schemas of object, interface and input object types and the
+	// arguments of fields with additionalProperties: false, so that unknown keys are rejected
+	AdditionalPropertiesFalse bool `json:"additionalPropertiesFalse,omitempty"`
+	// NullableFields makes the schemas of nullable fields, arguments and input fields accept null;
+	// empty means NullableFieldsOff. Nullable list items follow NullableArrayItems in the same
+	// representation.
+	NullableFields NullableFields `json:"nullableFields,omitempty"`
+	// InterfaceAllOf links object and interface types to the interfaces they implement with
+	// allOf: [{"$ref": ...}], leaving out the fields they declare exactly as an interface does, see
+	// inheritedField. additionalProperties cannot close such types or the interfaces they build on,
+	// so AdditionalPropertiesFalse leaves them open; ClosedComposition closes them instead.
+	InterfaceAllOf bool `json:"interfaceAllOf,omitempty"`
+	// InterfaceImplementations makes interface definitions reference the object types implementing
+	// them from anyOf or oneOf, as unions do; empty means InterfaceImplementationsOff. The interface's
+	// own fields are left out unless InterfaceKeepFields is set. It cannot be combined with
+	// InterfaceAllOf, whose references back to the interface would make the schemas circular.
+	InterfaceImplementations InterfaceImplementations `json:"interfaceImplementations,omitempty"`
+	// InterfaceKeepFields keeps the fields of interfaces alongside the implementations listed with
+	// InterfaceImplementations, as a base every implementation also matches
+	InterfaceKeepFields bool `json:"interfaceKeepFields,omitempty"`
+	// EnumStyle selects how enum definitions list their values; empty means EnumStyleAnyOf
+	EnumStyle EnumStyle `json:"enumStyle,omitempty"`
+	// FieldShape selects how object and interface fields are emitted; empty means FieldShapeWrapped.
+	// Options that only shape arguments, such as SharedPaginationArgs, have no effect with FieldShapePlain.
+	FieldShape FieldShape `json:"fieldShape,omitempty"`
+
+	// skipped maps the types skipped by Lenient to the reason, see skipMalformedTypes
+	skipped map[string]string
+	// specifiedBy maps custom scalars to their specifiedByURL, see withSpecifiedBy
+	specifiedBy map[string]string
+	// interfaces indexes the interface types with InterfaceAllOf, see withInterfaces
+	interfaces map[string]*IntrospectionType
+	// refs and scalars cache the $ref to each definition and the schema of each scalar, see withTypeCaches
+	refs    map[string]string
+	scalars map[string]*JSONSchema6
 }
 
 // DefaultOptions returns the default conversion options
 func DefaultOptions() Options {
 	return Options{
-		IgnoreInternals:    true,
-		NullableArrayItems: false,
-		IDTypeMapping:      IDTypeDefaultMode,
-		Operation:          nil,
-		MethodName:         "",
+		IgnoreInternals:      true,
+		NullableArrayItems:   false,
+		IDTypeMapping:        IDTypeDefaultMode,
+		Operation:            nil,
+		MethodName:           "",
+		DefinitionsOnly:      false,
+		Roots:                nil,
+		PruneToRoots:         false,
+		Concurrency:          0,
+		DedupeDefinitions:    false,
+		Progress:             nil,
+		ClosedComposition:    false,
+		Logger:               nil,
+		OperationsLayout:     OperationsLayoutNested,
+		OperationsKey:        DefaultOperationsKey,
+		ExcludeFields:        nil,
+		IncludeFields:        nil,
+		ExtractExamples:      false,
+		StripExamples:        false,
+		ExcludeTypes:         nil,
+		CustomScalarSchemas:  nil,
+		FlattenConnections:   false,
+		EmbedSDL:             false,
+		Lenient:              false,
+		GoHints:              nil,
+		SharedPaginationArgs: false,
+		Draft:                Draft06,
 	}
 }
 
+// includesRoot reports whether the given root operation type is selected by Roots
+func (o *Options) includesRoot(op OperationType) bool {
+	if len(o.Roots) == 0 {
+		return true
+	}
+	for _, root := range o.Roots {
+		if root == op {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidOperationsLayout checks if the provided OperationsLayout is valid; empty means nested
+func IsValidOperationsLayout(layout OperationsLayout) bool {
+	switch layout {
+	case "", OperationsLayoutNested, OperationsLayoutFlat, OperationsLayoutBoth:
+		return true
+	}
+	return false
+}
+
+// IsValidNullableFields checks if the provided NullableFields mode is valid; empty means off
+func IsValidNullableFields(mode NullableFields) bool {
+	switch mode {
+	case "", NullableFieldsOff, NullableFieldsTypeArray, NullableFieldsAnyOf:
+		return true
+	}
+	return false
+}
+
+// on reports whether nullable values accept null in the mode
+func (n NullableFields) on() bool {
+	return n != "" && n != NullableFieldsOff
+}
+
+// IsValidOperationType checks if the provided OperationType is valid
+func IsValidOperationType(op OperationType) bool {
+	return op == OperationQuery || op == OperationMutation || op == OperationSubscription
+}
+
 // JSONSchema6 represents a JSON Schema Draft 6 schema
 type JSONSchema6 struct {
 	Schema      string                  `json:"$schema"`
 	Type        interface{}             `json:"type,omitempty"`
 	Properties  map[string]*JSONSchema6 `json:"properties,omitempty"`
 	Items       *JSONSchema6            `json:"items,omitempty"`
+	MinItems    *int                    `json:"minItems,omitempty"`
+	Minimum     *float64                `json:"minimum,omitempty"`
+	Maximum     *float64                `json:"maximum,omitempty"`
 	Ref         string                  `json:"$ref,omitempty"`
 	Required    []string                `json:"required,omitempty"`
 	Definitions map[string]*JSONSchema6 `json:"definitions,omitempty"`
+	// Defs holds the definitions of 2020-12 documents, see SetDraft
+	Defs        map[string]*JSONSchema6 `json:"$defs,omitempty"`
 	AnyOf       []*JSONSchema6          `json:"anyOf,omitempty"`
 	OneOf       []*JSONSchema6          `json:"oneOf,omitempty"`
+	AllOf       []*JSONSchema6          `json:"allOf,omitempty"`
 	Title       string                  `json:"title,omitempty"`
 	Description string                  `json:"description,omitempty"`
+	Comment     string                  `json:"$comment,omitempty"`
 	Default     interface{}             `json:"default,omitempty"`
+	Examples    []interface{}           `json:"examples,omitempty"`
 	Enum        []string                `json:"enum,omitempty"`
+	Format      string                  `json:"format,omitempty"`
+	Pattern     string                  `json:"pattern,omitempty"`
+	// AdditionalProperties is only ever set to false, closing an object to properties it does not list
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+	// UnevaluatedProperties is only ever set to false, by Options.ClosedComposition
+	UnevaluatedProperties *bool `json:"unevaluatedProperties,omitempty"`
+	// GraphQLType is the GraphQL name of a definition that was renamed, see RenameDefinitions
+	GraphQLType string `json:"x-graphql-type,omitempty"`
+	// Skipped is why a malformed type was left out with Options.Lenient; the definition accepts any value
+	Skipped string `json:"x-graphql-skipped,omitempty"`
+	// GoType, GoTypeImport and GoName carry the Go type hints of Options.GoHints
+	GoType       string        `json:"x-go-type,omitempty"`
+	GoTypeImport *GoTypeImport `json:"x-go-type-import,omitempty"`
+	GoName       string        `json:"x-go-name,omitempty"`
+	// Pagination records the arguments replaced by a shared definition with Options.SharedPaginationArgs
+	Pagination *PaginationNote `json:"x-graphql-pagination,omitempty"`
+	// Deprecated and DeprecationReason mark deprecated fields, arguments, input fields and enum values
+	Deprecated        bool   `json:"x-deprecated,omitempty"`
+	DeprecationReason string `json:"x-deprecation-reason,omitempty"`
+	// SpecifiedBy is the specifiedByURL of a custom scalar, see specifiedByFormat
+	SpecifiedBy string `json:"x-specified-by,omitempty"`
+	// EnumDescriptions and EnumDeprecated describe the values of a flat enum, see EnumStyleFlat:
+	// the descriptions in the order of Enum, and the reasons of deprecated values keyed by value
+	EnumDescriptions []string          `json:"x-enum-descriptions,omitempty"`
+	EnumDeprecated   map[string]string `json:"x-enum-deprecated,omitempty"`
 }
 
 // IntrospectionQuery represents the root of a GraphQL introspection query result
@@ -67,13 +356,15 @@ type IntrospectionQuery struct {
 
 // IntrospectionSchema represents the schema information from an introspection query
 type IntrospectionSchema struct {
-	QueryType    *TypeRef            `json:"queryType"`
-	MutationType *TypeRef            `json:"mutationType"`
-	Types        []IntrospectionType `json:"types"`
+	QueryType        *TypeRef            `json:"queryType"`
+	MutationType     *TypeRef            `json:"mutationType"`
+	SubscriptionType *TypeRef            `json:"subscriptionType"`
+	Types            []IntrospectionType `json:"types"`
 }
 
 // TypeRef represents a reference to a type
 type TypeRef struct {
+	Kind string `json:"kind,omitempty"`
 	Name string `json:"name"`
 }
 
@@ -87,6 +378,26 @@ type IntrospectionType struct {
 	Interfaces    []TypeRef            `json:"interfaces"`
 	EnumValues    []IntrospectionEnum  `json:"enumValues"`
 	PossibleTypes []IntrospectionType  `json:"possibleTypes"`
+	// SpecifiedByURL links to the specification of a custom scalar
+	SpecifiedByURL string `json:"specifiedByURL,omitempty"`
+}
+
+// MarshalJSON writes possible types as the type references introspection returns for them, so a
+// decoded result marshals back to the same shape
+func (t IntrospectionType) MarshalJSON() ([]byte, error) {
+	type plain IntrospectionType
+	var possibleTypes []IntrospectionTypeRef
+	if t.PossibleTypes != nil {
+		possibleTypes = make([]IntrospectionTypeRef, len(t.PossibleTypes))
+		for i, member := range t.PossibleTypes {
+			name := member.Name
+			possibleTypes[i] = IntrospectionTypeRef{Kind: member.Kind, Name: &name}
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		PossibleTypes []IntrospectionTypeRef `json:"possibleTypes"`
+	}{plain(t), possibleTypes})
 }
 
 // IntrospectionField represents a field in a GraphQL type
@@ -95,6 +406,9 @@ type IntrospectionField struct {
 	Description string               `json:"description"`
 	Args        []IntrospectionArg   `json:"args"`
 	Type        IntrospectionTypeRef `json:"type"`
+
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
 }
 
 // IntrospectionInput represents an input field in a GraphQL type
@@ -103,6 +417,9 @@ type IntrospectionInput struct {
 	Description  string               `json:"description"`
 	Type         IntrospectionTypeRef `json:"type"`
 	DefaultValue *string              `json:"defaultValue"`
+
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
 }
 
 // IntrospectionArg represents an argument to a field
@@ -111,12 +428,18 @@ type IntrospectionArg struct {
 	Description  string               `json:"description"`
 	Type         IntrospectionTypeRef `json:"type"`
 	DefaultValue *string              `json:"defaultValue"`
+
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
 }
 
 // IntrospectionEnum represents an enum value in a GraphQL enum type
 type IntrospectionEnum struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
 }
 
 // IntrospectionTypeRef represents a type reference in the schema
@@ -128,13 +451,22 @@ type IntrospectionTypeRef struct {
 
 // FromIntrospectionQuery converts a GraphQL introspection query result to a JSON Schema
 func FromIntrospectionQuery(introspection IntrospectionQuery, opts *Options) (*JSONSchema6, error) {
-	if opts == nil {
-		defaultOpts := DefaultOptions()
-		opts = &defaultOpts
+	introspection, types, opts, err := prepareConversion(introspection, opts)
+	if err != nil {
+		return nil, err
 	}
+	if !IsValidOperationsLayout(opts.OperationsLayout) {
+		return nil, fmt.Errorf("invalid operations layout: %s (must be 'nested', 'flat' or 'both')", opts.OperationsLayout)
+	}
+	// Inlining keeps only the definitions reachable from the root properties, which DefinitionsOnly
+	// and OnlyInputTypes drop
+	if opts.InlineRefs && (opts.DefinitionsOnly || opts.OnlyInputTypes) {
+		return nil, errors.New("InlineRefs cannot be combined with DefinitionsOnly or OnlyInputTypes: there are no root properties to inline the definitions into")
+	}
+	logger := opts.logger()
 
 	schema := &JSONSchema6{
-		Schema:      "http://json-schema.org/draft-06/schema#",
+		Schema:      opts.Draft.schemaURI(),
 		Properties:  make(map[string]*JSONSchema6),
 		Definitions: make(map[string]*JSONSchema6),
 	}
@@ -145,36 +477,41 @@ func FromIntrospectionQuery(introspection IntrospectionQuery, opts *Options) (*J
 	if opts.MethodName != "" {
 		// Look for the method in both Query and Mutation types
 		var methodField *IntrospectionField
-		var methodType string
+		var methodType, methodTypeName string
 
 		// Check Query type
 		if introspection.Schema.QueryType != nil {
-			queryType := findType(introspection.Schema.Types, introspection.Schema.QueryType.Name)
+			queryType := types[introspection.Schema.QueryType.Name]
 			if queryType != nil {
 				if field := findField(queryType.Fields, opts.MethodName); field != nil {
 					methodField = field
-					methodType = "Query"
+					methodType, methodTypeName = "Query", queryType.Name
 				}
 			}
 		}
 
 		// Check Mutation type if not found in Query
 		if methodField == nil && introspection.Schema.MutationType != nil {
-			mutationType := findType(introspection.Schema.Types, introspection.Schema.MutationType.Name)
+			mutationType := types[introspection.Schema.MutationType.Name]
 			if mutationType != nil {
 				if field := findField(mutationType.Fields, opts.MethodName); field != nil {
 					methodField = field
-					methodType = "Mutation"
+					methodType, methodTypeName = "Mutation", mutationType.Name
 				}
 			}
 		}
 
 		if methodField == nil {
-			return nil, fmt.Errorf("method %s not found in schema", opts.MethodName)
+			return nil, notFound("method", opts.MethodName, rootFieldNames(introspection.Schema, types))
 		}
 
 		// Create a schema just for this method
-		methodSchema := processField(*methodField, opts)
+		var methodSchema *JSONSchema6
+		if opts.FieldShape == FieldShapePlain {
+			methodSchema = processFieldValue(nil, methodTypeName, *methodField, opts)
+		} else {
+			methodSchema = processField(nil, methodTypeName, *methodField, opts)
+		}
 		schema.Properties[methodType] = &JSONSchema6{
 			Type: "object",
 			Properties: map[string]*JSONSchema6{
@@ -183,54 +520,304 @@ func FromIntrospectionQuery(introspection IntrospectionQuery, opts *Options) (*J
 		}
 
 		// Collect all definitions used by this method
-		collectFieldDefinitions(*methodField, usedDefinitions)
+		collectFieldDefinitions(*methodField, usedDefinitions, opts)
 	} else if opts.Operation != nil {
 		switch *opts.Operation {
 		case OperationQuery:
 			if introspection.Schema.QueryType != nil && introspection.Schema.Types != nil {
-				queryType := findType(introspection.Schema.Types, introspection.Schema.QueryType.Name)
+				queryType := types[introspection.Schema.QueryType.Name]
 				if queryType != nil {
 					schema.Properties["Query"] = processTypeAndCollectDefs(*queryType, opts, usedDefinitions)
 				}
 			}
 		case OperationMutation:
 			if introspection.Schema.MutationType != nil && introspection.Schema.Types != nil {
-				mutationType := findType(introspection.Schema.Types, introspection.Schema.MutationType.Name)
+				mutationType := types[introspection.Schema.MutationType.Name]
 				if mutationType != nil {
 					schema.Properties["Mutation"] = processTypeAndCollectDefs(*mutationType, opts, usedDefinitions)
 				}
 			}
+		case OperationSubscription:
+			if introspection.Schema.SubscriptionType != nil && introspection.Schema.Types != nil {
+				subscriptionType := types[introspection.Schema.SubscriptionType.Name]
+				if subscriptionType != nil {
+					schema.Properties["Subscription"] = processTypeAndCollectDefs(*subscriptionType, opts, usedDefinitions)
+				}
+			}
 		}
 	} else {
-		// Process both if no specific operation is requested
-		if introspection.Schema.QueryType != nil && introspection.Schema.Types != nil {
-			queryType := findType(introspection.Schema.Types, introspection.Schema.QueryType.Name)
+		// Process every selected root if no specific operation is requested
+		if introspection.Schema.QueryType != nil && introspection.Schema.Types != nil && opts.includesRoot(OperationQuery) {
+			queryType := types[introspection.Schema.QueryType.Name]
 			if queryType != nil {
 				schema.Properties["Query"] = processTypeAndCollectDefs(*queryType, opts, usedDefinitions)
 			}
 		}
 
-		if introspection.Schema.MutationType != nil && introspection.Schema.Types != nil {
-			mutationType := findType(introspection.Schema.Types, introspection.Schema.MutationType.Name)
+		if introspection.Schema.MutationType != nil && introspection.Schema.Types != nil && opts.includesRoot(OperationMutation) {
+			mutationType := types[introspection.Schema.MutationType.Name]
 			if mutationType != nil {
 				schema.Properties["Mutation"] = processTypeAndCollectDefs(*mutationType, opts, usedDefinitions)
 			}
 		}
+
+		if introspection.Schema.SubscriptionType != nil && introspection.Schema.Types != nil && opts.includesRoot(OperationSubscription) {
+			subscriptionType := types[introspection.Schema.SubscriptionType.Name]
+			if subscriptionType != nil {
+				schema.Properties["Subscription"] = processTypeAndCollectDefs(*subscriptionType, opts, usedDefinitions)
+			}
+		}
 	}
 
-	// Add only the definitions that are actually used
+	// Add only the definitions that are actually used, including those the used ones reference
 	if introspection.Schema.Types != nil {
-		filteredTypes := filterTypes(introspection.Schema.Types, opts.IgnoreInternals)
-		for _, t := range filteredTypes {
-			if !isRootType(t.Name) && (usedDefinitions[t.Name] || (opts.Operation == nil && opts.MethodName == "")) {
-				schema.Definitions[t.Name] = processType(t, opts)
+		wholeSchema := opts.Operation == nil && opts.MethodName == ""
+		if !wholeSchema {
+			usedDefinitions = referencedTypes(types, usedDefinitions, opts)
+		}
+		if opts.OnlyInputTypes {
+			// Pruning keeps the input types the roots reach, also through the object types left out
+			var selected map[string]bool
+			if !wholeSchema {
+				selected = usedDefinitions
+			} else if opts.PruneToRoots {
+				selected = referencedTypes(types, usedDefinitions, opts)
+			}
+			usedDefinitions, wholeSchema = inputTypes(types, selected, opts), false
+		}
+		include := func(name string) bool {
+			return !isRootType(introspection.Schema, name) && (wholeSchema || usedDefinitions[name])
+		}
+		definitions, err := convertDefinitions(introspection.Schema.Types, opts, include)
+		if err != nil {
+			return nil, err
+		}
+		for name, definition := range definitions {
+			schema.Definitions[name] = definition
+		}
+		addSkippedPlaceholders(schema, opts.skipped, include)
+	}
+
+	// Drop definitions that cannot be reached from the emitted roots
+	if opts.PruneToRoots && opts.Operation == nil && opts.MethodName == "" && !opts.OnlyInputTypes {
+		reachable := reachableDefinitions(&JSONSchema6{Properties: schema.Properties}, schema.Definitions)
+		for name := range schema.Definitions {
+			if !reachable[name] {
+				delete(schema.Definitions, name)
 			}
 		}
 	}
 
+	if opts.DefinitionsOnly || opts.OnlyInputTypes {
+		schema.Properties = nil
+	} else if err := applyOperationsLayout(schema, opts); err != nil {
+		return nil, err
+	}
+	// The shared pagination arguments are added once the properties that may reference them are final
+	addPaginationDefinition(schema, opts)
+	schema = inlineRefs(schema, opts)
+
+	if opts.DedupeDefinitions {
+		stats, err := DedupeSubschemas(schema, 0)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debug("Deduplicated subschemas", "definitions", stats.Definitions, "replaced", stats.Replaced, "bytesSaved", stats.BytesSaved)
+	}
+
+	logSkippedTypes(logger, opts.skipped)
+	logger.Debug("Converted schema", "roots", len(schema.Properties), "definitions", len(schema.Definitions))
+	finishDocument(schema, opts)
+	return schema, nil
+}
+
+// ConvertType converts a single named type to a self-contained schema rooted at it, carrying only
+// the definitions the type transitively references. Options apply as for FromIntrospectionQuery;
+// those selecting roots, operations and methods have no effect.
+func ConvertType(introspection IntrospectionQuery, typeName string, opts *Options) (*JSONSchema6, error) {
+	introspection, types, opts, err := prepareConversion(introspection, opts)
+	if err != nil {
+		return nil, err
+	}
+	if reason, ok := opts.skipped[typeName]; ok {
+		return nil, fmt.Errorf("type %s is malformed: %s", typeName, reason)
+	}
+
+	available := filterTypes(introspection.Schema.Types, opts.IgnoreInternals)
+	names := make([]string, len(available))
+	for i, t := range available {
+		names[i] = t.Name
+	}
+	if !containsString(names, typeName) {
+		return nil, notFound("type", typeName, names)
+	}
+
+	used := referencedTypes(types, map[string]bool{typeName: true}, opts)
+	definitions, err := convertDefinitions(introspection.Schema.Types, opts, func(name string) bool {
+		return used[name]
+	})
+	if err != nil {
+		return nil, err
+	}
+	root := &JSONSchema6{Schema: opts.Draft.schemaURI(), Definitions: definitions}
+	addSkippedPlaceholders(root, opts.skipped, nil)
+	addPaginationDefinition(root, opts)
+	schema, err := ExtractDefinition(root, typeName)
+	if err != nil {
+		return nil, err
+	}
+	schema = inlineRefs(schema, opts)
+
+	if opts.DedupeDefinitions {
+		stats, err := DedupeSubschemas(schema, 0)
+		if err != nil {
+			return nil, err
+		}
+		opts.logger().Debug("Deduplicated subschemas", "definitions", stats.Definitions, "replaced", stats.Replaced, "bytesSaved", stats.BytesSaved)
+	}
+	opts.logger().Debug("Converted type", LogKeyType, typeName, "definitions", len(schema.Definitions))
+	finishDocument(schema, opts)
 	return schema, nil
 }
 
+// prepareConversion resolves the options and applies the field filters shared by every conversion,
+// returning the filtered introspection with its type index
+func prepareConversion(introspection IntrospectionQuery, opts *Options) (IntrospectionQuery, typeIndex, *Options, error) {
+	if opts == nil {
+		defaultOpts := DefaultOptions()
+		opts = &defaultOpts
+	}
+	if err := checkDraft(opts.Draft); err != nil {
+		return introspection, nil, nil, err
+	}
+	if !IsValidNullableFields(opts.NullableFields) {
+		return introspection, nil, nil, fmt.Errorf("invalid NullableFields: %s (must be 'off', 'typeArray' or 'anyOf')", opts.NullableFields)
+	}
+	if !IsValidEnumStyle(opts.EnumStyle) {
+		return introspection, nil, nil, fmt.Errorf("invalid EnumStyle: %s (must be 'anyOf' or 'flat')", opts.EnumStyle)
+	}
+	if !IsValidFieldShape(opts.FieldShape) {
+		return introspection, nil, nil, fmt.Errorf("invalid FieldShape: %s (must be 'wrapped' or 'plain')", opts.FieldShape)
+	}
+	if !IsValidInterfaceImplementations(opts.InterfaceImplementations) {
+		return introspection, nil, nil, fmt.Errorf("invalid InterfaceImplementations: %s (must be 'off', 'anyOf' or 'oneOf')", opts.InterfaceImplementations)
+	}
+	if opts.InterfaceAllOf && opts.InterfaceImplementations.on() {
+		return introspection, nil, nil, errors.New("InterfaceAllOf cannot be combined with InterfaceImplementations: types would reference their interfaces and the interfaces them")
+	}
+	if opts.InlineDepth < 0 {
+		return introspection, nil, nil, fmt.Errorf("invalid InlineDepth: %d (must be 0 for unlimited or positive)", opts.InlineDepth)
+	}
+	// additionalProperties cannot close a composed schema as it only sees its own properties
+	if opts.ClosedComposition && opts.Draft != Draft202012 {
+		return introspection, nil, nil, fmt.Errorf("ClosedComposition requires unevaluatedProperties from JSON Schema draft 2019-09 or later, but the output is %s", opts.Draft.name())
+	}
+
+	if err := checkTypeNames(introspection.Schema.Types); err != nil {
+		return introspection, nil, nil, err
+	}
+
+	logger := opts.logger()
+	filtered, err := filterFields(introspection.Schema.Types, opts.IncludeFields, opts.ExcludeFields, logger)
+	if err != nil {
+		return introspection, nil, nil, err
+	}
+	if filtered, err = excludePresetFields(filtered, opts.PresetExcludeFields, opts.IncludeFields); err != nil {
+		return introspection, nil, nil, err
+	}
+	if opts.FlattenConnections {
+		filtered = flattenConnections(filtered)
+	}
+	if filtered, err = includeTypes(introspection.Schema, filtered, opts, logger); err != nil {
+		return introspection, nil, nil, err
+	}
+	if filtered, err = excludeTypes(filtered, opts.ExcludeTypes, logger); err != nil {
+		return introspection, nil, nil, err
+	}
+	if filtered, err = excludePresetTypes(filtered, opts.PresetExcludeTypes, opts.IncludeTypes, logger); err != nil {
+		return introspection, nil, nil, err
+	}
+	// Unknown type warnings are checked against every type, so references to skipped types are not reported twice
+	types := newTypeIndex(filtered)
+	if opts.Lenient {
+		var skipped map[string]string
+		filtered, skipped = skipMalformedTypes(filtered, logger)
+		lenient := *opts
+		lenient.skipped = skipped
+		opts = &lenient
+	}
+	logTypeWarnings(logger, filterTypes(filtered, opts.IgnoreInternals), types)
+	opts = withSpecifiedBy(filtered, opts)
+	introspection.Schema.Types = filtered
+	types = newTypeIndex(introspection.Schema.Types)
+	opts = withInterfaces(types, opts)
+	opts = withTypeCaches(introspection.Schema.Types, opts)
+	if err := checkPaginationDefinition(types, opts); err != nil {
+		return introspection, nil, nil, err
+	}
+	return introspection, types, opts, nil
+}
+
+// convertDefinitions converts the types whose names keep accepts, leaving out internal types when
+// the options ignore them
+func convertDefinitions(types []IntrospectionType, opts *Options, keep func(name string) bool) (map[string]*JSONSchema6, error) {
+	filteredTypes := filterTypes(types, opts.IgnoreInternals)
+	selectedTypes := make([]IntrospectionType, 0, len(filteredTypes))
+	for _, t := range filteredTypes {
+		if keep(t.Name) {
+			selectedTypes = append(selectedTypes, t)
+		}
+	}
+	converted, err := processTypes(selectedTypes, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]*JSONSchema6, len(converted))
+	for i, definition := range converted {
+		definitions[selectedTypes[i].Name] = definition
+	}
+	return definitions, nil
+}
+
+// referencedTypes adds to names the types they transitively reference through fields, arguments,
+// input fields and union members, and implemented interfaces or implementations with
+// Options.InterfaceAllOf or Options.InterfaceImplementations, and returns it
+func referencedTypes(types typeIndex, names map[string]bool, opts *Options) map[string]bool {
+	queue := make([]string, 0, len(names))
+	for name := range names {
+		queue = append(queue, name)
+	}
+	for len(queue) > 0 {
+		t := types[queue[0]]
+		queue = queue[1:]
+		if t == nil {
+			continue
+		}
+		direct := make(map[string]bool)
+		collectDefinitions(*t, direct, opts)
+		for name := range direct {
+			if !names[name] {
+				names[name] = true
+				queue = append(queue, name)
+			}
+		}
+	}
+	return names
+}
+
+// inputTypes returns the input object types among selected, or all of them when selected is nil,
+// together with the enums and input types they transitively reference
+func inputTypes(types typeIndex, selected map[string]bool, opts *Options) map[string]bool {
+	inputs := make(map[string]bool)
+	for name, t := range types {
+		if t.Kind == "INPUT_OBJECT" && (selected == nil || selected[name]) {
+			inputs[name] = true
+		}
+	}
+	return referencedTypes(types, inputs, opts)
+}
+
 // Helper functions
 // findField finds a field by name in a slice of fields
 func findField(fields []IntrospectionField, name string) *IntrospectionField {
@@ -243,33 +830,77 @@ func findField(fields []IntrospectionField, name string) *IntrospectionField {
 }
 
 // collectFieldDefinitions collects all definitions used by a field
-func collectFieldDefinitions(field IntrospectionField, usedDefs map[string]bool) {
+func collectFieldDefinitions(field IntrospectionField, usedDefs map[string]bool, opts *Options) {
 	// Collect definitions from the return type
 	collectTypeRefDefinitions(field.Type, usedDefs)
 
-	// Collect definitions from arguments
+	// Collect definitions from arguments, which plain fields leave out
+	if opts.FieldShape == FieldShapePlain {
+		return
+	}
 	for _, arg := range field.Args {
 		collectTypeRefDefinitions(arg.Type, usedDefs)
 	}
 }
 
+// applyOperationsLayout replaces or supplements the nested root properties with the flat operations
+// map. In the both layout the flat entries are $refs to the nested fields, so they are not duplicated.
+func applyOperationsLayout(schema *JSONSchema6, opts *Options) error {
+	if opts.OperationsLayout == "" || opts.OperationsLayout == OperationsLayoutNested {
+		return nil
+	}
+	key := opts.OperationsKey
+	if key == "" {
+		key = DefaultOperationsKey
+	}
+	if _, exists := schema.Properties[key]; exists && opts.OperationsLayout == OperationsLayoutBoth {
+		return fmt.Errorf("operations key %q collides with a root property", key)
+	}
+
+	operations := &JSONSchema6{Type: "object", Properties: make(map[string]*JSONSchema6)}
+	for _, root := range sortedKeys(schema.Properties) {
+		for _, field := range sortedKeys(schema.Properties[root].Properties) {
+			entry := schema.Properties[root].Properties[field]
+			if opts.OperationsLayout == OperationsLayoutBoth {
+				entry = &JSONSchema6{Ref: "#/properties/" + escapePointerSegment(root) + "/properties/" + escapePointerSegment(field)}
+			}
+			operations.Properties[root+"."+field] = entry
+		}
+	}
+
+	if opts.OperationsLayout == OperationsLayoutFlat {
+		schema.Properties = make(map[string]*JSONSchema6, 1)
+	}
+	schema.Properties[key] = operations
+	return nil
+}
+
 // processTypeAndCollectDefs processes a type and tracks all definitions used
 func processTypeAndCollectDefs(t IntrospectionType, opts *Options, usedDefs map[string]bool) *JSONSchema6 {
 	schema := processType(t, opts)
-	collectDefinitions(t, usedDefs)
+	collectDefinitions(t, usedDefs, opts)
 	return schema
 }
 
 // collectDefinitions recursively collects all type definitions used by a type
-func collectDefinitions(t IntrospectionType, usedDefs map[string]bool) {
+func collectDefinitions(t IntrospectionType, usedDefs map[string]bool, opts *Options) {
 	switch t.Kind {
 	case "OBJECT", "INTERFACE":
-		for _, field := range t.Fields {
-			collectTypeRefDefinitions(field.Type, usedDefs)
-			for _, arg := range field.Args {
-				collectTypeRefDefinitions(arg.Type, usedDefs)
+		// Implemented interfaces are only referenced when they are inherited from, see implementedInterfaces,
+		// and implementations when interfaces list them, see listImplementations
+		if opts.InterfaceAllOf {
+			for _, iface := range t.Interfaces {
+				usedDefs[iface.Name] = true
 			}
 		}
+		if t.Kind == "INTERFACE" && opts.InterfaceImplementations.on() {
+			for _, possibleType := range t.PossibleTypes {
+				usedDefs[possibleType.Name] = true
+			}
+		}
+		for _, field := range t.Fields {
+			collectFieldDefinitions(field, usedDefs, opts)
+		}
 	case "INPUT_OBJECT":
 		for _, field := range t.InputFields {
 			collectTypeRefDefinitions(field.Type, usedDefs)
@@ -295,19 +926,99 @@ func collectTypeRefDefinitions(typeRef IntrospectionTypeRef, usedDefs map[string
 	}
 }
 
-func isRootType(name string) bool {
-	return name == "Query" || name == "Mutation"
+// rootFieldNames lists the field names of the query and mutation types, used for suggestions
+func rootFieldNames(schema IntrospectionSchema, types typeIndex) []string {
+	names := make([]string, 0)
+	for _, root := range []*TypeRef{schema.QueryType, schema.MutationType} {
+		if root == nil {
+			continue
+		}
+		if t := types[root.Name]; t != nil {
+			for _, field := range t.Fields {
+				names = append(names, field.Name)
+			}
+		}
+	}
+	return names
+}
+
+// isRootType reports whether the named type is one of the schema's root operation types, which
+// convert to root properties rather than definitions. The schema declares their names, which need
+// not be Query, Mutation and Subscription.
+func isRootType(schema IntrospectionSchema, name string) bool {
+	for _, root := range []*TypeRef{schema.QueryType, schema.MutationType, schema.SubscriptionType} {
+		if root != nil && root.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
-func findType(types []IntrospectionType, name string) *IntrospectionType {
+// typeIndex looks up introspection types by name
+type typeIndex map[string]*IntrospectionType
+
+// checkTypeNames rejects introspection results declaring a type name more than once, since the
+// types would share one definition and every $ref to it
+func checkTypeNames(types []IntrospectionType) error {
+	seen := make(map[string]bool, len(types))
 	for _, t := range types {
-		if t.Name == name {
-			return &t
+		if t.Name == "" {
+			continue
 		}
+		if seen[t.Name] {
+			return fmt.Errorf("type %s is defined more than once in the introspection result", t.Name)
+		}
+		seen[t.Name] = true
 	}
 	return nil
 }
 
+// newTypeIndex indexes types by name. The entries point into the types slice, not at copies.
+func newTypeIndex(types []IntrospectionType) typeIndex {
+	index := make(typeIndex, len(types))
+	for i := range types {
+		index[types[i].Name] = &types[i]
+	}
+	return index
+}
+
+// typeClosure lists typeName first, followed by every type it references in name order
+func typeClosure(types typeIndex, typeName string) []string {
+	seen := map[string]bool{typeName: true}
+	queue := []string{typeName}
+	visit := func(typeRef IntrospectionTypeRef) {
+		if named := namedTypeRef(typeRef); named != nil && named.Name != nil && !seen[*named.Name] {
+			seen[*named.Name] = true
+			queue = append(queue, *named.Name)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		t := types[queue[i]]
+		if t == nil {
+			continue
+		}
+		for _, field := range t.Fields {
+			visit(field.Type)
+		}
+		for _, field := range t.InputFields {
+			visit(field.Type)
+		}
+		for _, member := range t.PossibleTypes {
+			name := member.Name
+			visit(IntrospectionTypeRef{Kind: member.Kind, Name: &name})
+		}
+	}
+
+	names := make([]string, 0, len(queue))
+	for _, name := range queue[1:] {
+		if types[name] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{typeName}, names...)
+}
+
 func filterTypes(types []IntrospectionType, ignoreInternals bool) []IntrospectionType {
 	if !ignoreInternals {
 		return types
@@ -323,109 +1034,162 @@ func filterTypes(types []IntrospectionType, ignoreInternals bool) []Introspectio
 }
 
 func processType(t IntrospectionType, opts *Options) *JSONSchema6 {
-	schema := &JSONSchema6{
-		Type:        "object",
-		Properties:  make(map[string]*JSONSchema6),
-		Description: t.Description,
+	// Every schema node of the definition comes from one allocation
+	nodes := newSchemaSlab(typeNodeCount(t))
+	schema := nodes.next()
+	schema.Type = "object"
+	describe(schema, t.Description, t.Name, "", opts)
+	if opts.EmbedSDL {
+		schema.Comment = typeSDL(t, maxEmbeddedSDL)
 	}
+	applyGoHint(schema, t.Name, opts)
 
+	// Maps and slices are sized up front and only allocated when they will hold something,
+	// since empty ones are omitted from the output anyway
 	switch t.Kind {
 	case "OBJECT", "INTERFACE":
-		required := make([]string, 0)
-		if t.Fields != nil {
-			for _, field := range t.Fields {
-				schema.Properties[field.Name] = processField(field, opts)
-				if isRequired(field.Type) {
-					required = append(required, field.Name)
-				}
+		interfaces := implementedInterfaces(t, opts)
+		if len(interfaces) > 0 {
+			schema.AllOf = make([]*JSONSchema6, len(interfaces))
+			for i, iface := range interfaces {
+				ref := nodes.next()
+				ref.Ref = opts.definitionRef(iface.Name)
+				schema.AllOf[i] = ref
 			}
 		}
-		if len(required) > 0 {
-			schema.Required = required
+		listed := listImplementations(nodes, schema, t, opts)
+		// Interfaces are built on by their implementations, whose fields additionalProperties would reject
+		if t.Kind == "OBJECT" && len(interfaces) == 0 || t.Kind == "INTERFACE" && !opts.InterfaceAllOf && !listed {
+			closeObject(schema, opts)
+		}
+		if listed && !opts.InterfaceKeepFields {
+			break
+		}
+		schema.Properties = make(map[string]*JSONSchema6, len(t.Fields))
+		for _, field := range t.Fields {
+			if inheritedField(field, interfaces) {
+				continue
+			}
+			if opts.FieldShape == FieldShapePlain {
+				schema.Properties[field.Name] = processFieldValue(nodes, t.Name, field, opts)
+			} else {
+				schema.Properties[field.Name] = processField(nodes, t.Name, field, opts)
+			}
+			if isRequired(field.Type) {
+				schema.Required = appendRequired(schema.Required, field.Name, len(t.Fields))
+			}
 		}
 
 	case "INPUT_OBJECT":
-		required := make([]string, 0)
-		if t.InputFields != nil {
-			for _, field := range t.InputFields {
-				schema.Properties[field.Name] = processInputValue(field, opts)
-				if isRequired(field.Type) {
-					required = append(required, field.Name)
-				}
+		closeObject(schema, opts)
+		schema.Properties = make(map[string]*JSONSchema6, len(t.InputFields))
+		for _, field := range t.InputFields {
+			schema.Properties[field.Name] = processInputValue(nodes, t.Name, field, opts)
+			if isRequired(field.Type) {
+				schema.Required = appendRequired(schema.Required, field.Name, len(t.InputFields))
 			}
 		}
-		if len(required) > 0 {
-			schema.Required = required
-		}
 
 	case "ENUM":
 		schema.Type = "string"
-		anyOf := make([]*JSONSchema6, 0)
-		if t.EnumValues != nil {
-			for _, enumValue := range t.EnumValues {
-				anyOf = append(anyOf, &JSONSchema6{
-					Enum:        []string{enumValue.Name},
-					Title:       enumValue.Description,
-					Description: enumValue.Description,
-				})
-			}
+		if opts.EnumStyle == EnumStyleFlat {
+			flatEnum(schema, t.EnumValues)
+			break
+		}
+		schema.AnyOf = make([]*JSONSchema6, len(t.EnumValues))
+		for i, enumValue := range t.EnumValues {
+			branch := nodes.next()
+			branch.Enum = []string{enumValue.Name}
+			branch.Title = enumValue.Description
+			branch.Description = enumValue.Description
+			deprecate(branch, enumValue.IsDeprecated, enumValue.DeprecationReason)
+			schema.AnyOf[i] = branch
 		}
-		schema.AnyOf = anyOf
 
 	case "UNION":
-		delete(schema.Properties, "type")
-		oneOf := make([]*JSONSchema6, 0)
-		if t.PossibleTypes != nil {
-			for _, possibleType := range t.PossibleTypes {
-				oneOf = append(oneOf, &JSONSchema6{
-					Ref: fmt.Sprintf("#/definitions/%s", possibleType.Name),
-				})
+		schema.OneOf = make([]*JSONSchema6, len(t.PossibleTypes))
+		for i, possibleType := range t.PossibleTypes {
+			branch := nodes.next()
+			branch.Ref = opts.definitionRef(possibleType.Name)
+			schema.OneOf[i] = branch
+		}
+
+	case "SCALAR":
+		// A mapped scalar is defined by its mapping, as it is wherever it is used
+		_, wellKnown := wellKnownScalars[t.Name]
+		if opts.CustomScalarSchemas[t.Name] != nil || opts.WellKnownScalars && wellKnown {
+			description, comment := schema.Description, schema.Comment
+			fillScalar(schema, t.Name, opts)
+			if schema.Description == "" {
+				schema.Description = description
+			}
+			if schema.Comment == "" {
+				schema.Comment = comment
 			}
 		}
-		schema.OneOf = oneOf
 	}
 
 	return schema
 }
 
-func processField(field IntrospectionField, opts *Options) *JSONSchema6 {
-	schema := &JSONSchema6{
-		Type:        "object",
-		Properties:  make(map[string]*JSONSchema6),
-		Description: field.Description,
+// closeObject sets additionalProperties: false on an object schema with Options.AdditionalPropertiesFalse
+func closeObject(schema *JSONSchema6, opts *Options) {
+	if opts.AdditionalPropertiesFalse {
+		closed := false
+		schema.AdditionalProperties = &closed
 	}
+}
+
+func processField(nodes *schemaSlab, typeName string, field IntrospectionField, opts *Options) *JSONSchema6 {
+	schema := nodes.next()
+	schema.Type = "object"
+	schema.Properties = make(map[string]*JSONSchema6, 2)
+	describe(schema, field.Description, typeName, field.Name, opts)
+	deprecate(schema, field.IsDeprecated, field.DeprecationReason)
 
 	// Process return type
-	schema.Properties["return"] = processTypeRef(field.Type, opts)
+	schema.Properties["return"] = processValueType(nodes, field.Type, opts)
 
-	// Process arguments
-	args := &JSONSchema6{
-		Type:       "object",
-		Properties: make(map[string]*JSONSchema6),
+	// Process arguments; most fields take none, so the map is only allocated when needed
+	args := nodes.next()
+	args.Type = "object"
+	if len(field.Args) > 0 {
+		args.Properties = make(map[string]*JSONSchema6, len(field.Args))
 	}
-
-	required := make([]string, 0)
-	if field.Args != nil {
-		for _, arg := range field.Args {
-			args.Properties[arg.Name] = processArg(arg, opts)
-			if isRequired(arg.Type) {
-				required = append(required, arg.Name)
-			}
+	for _, arg := range field.Args {
+		args.Properties[arg.Name] = processArg(nodes, typeName, field.Name, arg, opts)
+		if isRequired(arg.Type) {
+			args.Required = appendRequired(args.Required, arg.Name, len(field.Args))
 		}
 	}
 
-	if len(required) > 0 {
-		args.Required = required
+	// The shared pagination arguments are combined with the others in allOf, where a closed part
+	// would reject the properties of the other, so those arguments stay open
+	if opts.SharedPaginationArgs && isRelayPagination(field.Args) {
+		args = sharePaginationArgs(args)
+	} else {
+		closeObject(args, opts)
 	}
-
 	schema.Properties["arguments"] = args
 
 	return schema
 }
 
-func processInputValue(input IntrospectionInput, opts *Options) *JSONSchema6 {
-	schema := processTypeRef(input.Type, opts)
-	schema.Description = input.Description
+// processFieldValue returns the schema of a field's value in response data
+func processFieldValue(nodes *schemaSlab, typeName string, field IntrospectionField, opts *Options) *JSONSchema6 {
+	schema := processTypeRef(nodes, field.Type, opts)
+	if !isRequired(field.Type) {
+		schema = nullable(schema, opts.NullableFields)
+	}
+	describe(schema, field.Description, typeName, field.Name, opts)
+	deprecate(schema, field.IsDeprecated, field.DeprecationReason)
+	return schema
+}
+
+func processInputValue(nodes *schemaSlab, typeName string, input IntrospectionInput, opts *Options) *JSONSchema6 {
+	schema := processValueType(nodes, input.Type, opts)
+	describe(schema, input.Description, typeName, input.Name, opts)
+	deprecate(schema, input.IsDeprecated, input.DeprecationReason)
 
 	if input.DefaultValue != nil {
 		var defaultValue interface{}
@@ -437,9 +1201,10 @@ func processInputValue(input IntrospectionInput, opts *Options) *JSONSchema6 {
 	return schema
 }
 
-func processArg(arg IntrospectionArg, opts *Options) *JSONSchema6 {
-	schema := processTypeRef(arg.Type, opts)
-	schema.Description = arg.Description
+func processArg(nodes *schemaSlab, typeName, fieldName string, arg IntrospectionArg, opts *Options) *JSONSchema6 {
+	schema := processValueType(nodes, arg.Type, opts)
+	describe(schema, arg.Description, typeName, fieldName+"."+arg.Name, opts)
+	deprecate(schema, arg.IsDeprecated, arg.DeprecationReason)
 
 	if arg.DefaultValue != nil {
 		var defaultValue interface{}
@@ -451,58 +1216,137 @@ func processArg(arg IntrospectionArg, opts *Options) *JSONSchema6 {
 	return schema
 }
 
-func processTypeRef(typeRef IntrospectionTypeRef, opts *Options) *JSONSchema6 {
+// flatEnum lists the values of an enum in a single enum keyword, with their descriptions in
+// x-enum-descriptions when any has one and their deprecation in x-enum-deprecated
+func flatEnum(schema *JSONSchema6, values []IntrospectionEnum) {
+	schema.Enum = make([]string, len(values))
+	var descriptions []string
+	for i, value := range values {
+		schema.Enum[i] = value.Name
+		if value.Description != "" && descriptions == nil {
+			descriptions = make([]string, len(values))
+		}
+		if descriptions != nil {
+			descriptions[i] = value.Description
+		}
+		if value.IsDeprecated {
+			if schema.EnumDeprecated == nil {
+				schema.EnumDeprecated = make(map[string]string)
+			}
+			schema.EnumDeprecated[value.Name] = ""
+			if value.DeprecationReason != nil {
+				schema.EnumDeprecated[value.Name] = *value.DeprecationReason
+			}
+		}
+	}
+	schema.EnumDescriptions = descriptions
+}
+
+// deprecate marks the schema of a deprecated field, argument, input field or enum value
+func deprecate(schema *JSONSchema6, isDeprecated bool, reason *string) {
+	if !isDeprecated {
+		return
+	}
+	schema.Deprecated = true
+	if reason != nil {
+		schema.DeprecationReason = *reason
+	}
+}
+
+// processValueType returns the schema of the type of a field, argument or input field, accepting
+// null when the type is nullable and Options.NullableFields asks for it
+func processValueType(nodes *schemaSlab, typeRef IntrospectionTypeRef, opts *Options) *JSONSchema6 {
+	schema := processTypeRef(nodes, typeRef, opts)
+	if opts.NullableFields.on() && !isRequired(typeRef) {
+		schema = nullable(schema, opts.NullableFields)
+	}
+	return schema
+}
+
+// nullable returns a schema that also accepts null: with NullableFieldsTypeArray null is added to
+// the type where it can be, otherwise the schema is combined with null in anyOf
+func nullable(schema *JSONSchema6, mode NullableFields) *JSONSchema6 {
+	if mode == NullableFieldsTypeArray {
+		return nullableSchema(schema)
+	}
+	return &JSONSchema6{AnyOf: []*JSONSchema6{schema, {Type: "null"}}}
+}
+
+// processTypeRef returns the schema of a type reference. It only descends through the NON_NULL and
+// LIST wrappers; named types other than scalars become a $ref to their definition and are never
+// expanded, so self-referential and mutually recursive types convert in one pass per definition.
+// Expanding references is left to InlineDefinitions, which keeps a $ref wherever it would recurse.
+func processTypeRef(nodes *schemaSlab, typeRef IntrospectionTypeRef, opts *Options) *JSONSchema6 {
 	switch typeRef.Kind {
 	case "NON_NULL":
 		if typeRef.OfType != nil {
-			return processTypeRef(*typeRef.OfType, opts)
+			return processTypeRef(nodes, *typeRef.OfType, opts)
 		}
-		return &JSONSchema6{}
+		return nodes.next()
 	case "LIST":
+		schema := nodes.next()
+		schema.Type = "array"
 		if typeRef.OfType != nil {
-			items := processTypeRef(*typeRef.OfType, opts)
-			schema := &JSONSchema6{
-				Type:  "array",
-				Items: items,
-			}
+			items := processTypeRef(nodes, *typeRef.OfType, opts)
+			schema.Items = items
 
 			if opts.NullableArrayItems && !isRequired(*typeRef.OfType) {
-				schema.Items = &JSONSchema6{
-					AnyOf: []*JSONSchema6{
-						items,
-						{Type: "null"},
-					},
-				}
+				schema.Items = nullable(items, opts.NullableFields)
 			}
-
-			return schema
 		}
-		return &JSONSchema6{Type: "array"}
+		return schema
 	case "SCALAR":
+		schema := nodes.next()
 		if typeRef.Name != nil {
-			return processScalar(*typeRef.Name, opts.IDTypeMapping)
+			if scalar := opts.scalars[*typeRef.Name]; scalar != nil {
+				copyScalar(schema, scalar)
+			} else {
+				fillScalar(schema, *typeRef.Name, opts)
+			}
 		}
-		return &JSONSchema6{}
+		return schema
 	default:
+		schema := nodes.next()
 		if typeRef.Name != nil {
-			return &JSONSchema6{
-				Ref: fmt.Sprintf("#/definitions/%s", *typeRef.Name),
-			}
+			schema.Ref = opts.definitionRef(*typeRef.Name)
 		}
-		return &JSONSchema6{}
+		return schema
 	}
 }
 
-func processScalar(name string, idMapping IDTypeMapping) *JSONSchema6 {
-	schema := &JSONSchema6{
-		Title: name,
+func processScalar(name string, opts *Options) *JSONSchema6 {
+	schema := &JSONSchema6{}
+	fillScalar(schema, name, opts)
+	return schema
+}
+
+// wellKnownScalars maps the temporal scalars most servers name alike to the JSON Schema format of
+// their values, used with Options.WellKnownScalars
+var wellKnownScalars = map[string]string{
+	"DateTime":  "date-time",
+	"Datetime":  "date-time",
+	"Timestamp": "date-time",
+	"Date":      "date",
+	"Time":      "time",
+}
+
+// fillScalar fills in the schema for a built-in or custom scalar
+func fillScalar(schema *JSONSchema6, name string, opts *Options) {
+	defer applyGoHint(schema, name, opts)
+	if custom, ok := opts.CustomScalarSchemas[name]; ok && custom != nil {
+		*schema = *cloneSchema(custom)
+		if schema.Title == "" {
+			schema.Title = name
+		}
+		return
 	}
+	schema.Title = name
 
 	switch name {
 	case "ID":
 		schema.Description = "The `ID` scalar type represents a unique identifier, often used to refetch an object or as key for a cache. The ID type appears in a JSON response as a String; however, it is not intended to be human-readable. When expected as an input type, any string (such as `\"4\"`) or integer (such as `4`) input value will be accepted as an ID."
 
-		switch idMapping {
+		switch opts.IDTypeMapping {
 		case IDTypeNumber:
 			schema.Type = "number"
 		case IDTypeBoth:
@@ -515,15 +1359,88 @@ func processScalar(name string, idMapping IDTypeMapping) *JSONSchema6 {
 		schema.Type = "string"
 		schema.Description = "The `String` scalar type represents textual data, represented as UTF-8 character sequences. The String type is most often used by GraphQL to represent free-form human-readable text."
 
-	case "Int", "Float":
+	case "Int":
+		schema.Type = "number"
+		if opts.UseIntegerType {
+			schema.Type = "integer"
+		}
+		if opts.IntBounds {
+			minimum, maximum := float64(math.MinInt32), float64(math.MaxInt32)
+			schema.Minimum, schema.Maximum = &minimum, &maximum
+		}
+
+	case "Float":
 		schema.Type = "number"
 
 	case "Boolean":
 		schema.Type = "boolean"
 		schema.Description = "The `Boolean` scalar type represents `true` or `false`."
+
+	default:
+		if url := opts.specifiedBy[name]; url != "" {
+			schema.SpecifiedBy = url
+			if format := specifiedByFormat(url); format != "" {
+				schema.Type = "string"
+				schema.Format = format
+			}
+		}
+		if schema.Format == "" && opts.WellKnownScalars {
+			if format, ok := wellKnownScalars[name]; ok {
+				schema.Type = "string"
+				schema.Format = format
+			}
+		}
 	}
+}
 
-	return schema
+// definitionRef returns the $ref pointing at the named definition, with the name escaped as a JSON
+// Pointer segment so that "~" and "/" in it do not break the pointer
+func definitionRef(name string) string {
+	return definitionsRefPrefix + escapePointerSegment(name)
+}
+
+// definitionRef returns the $ref pointing at the named definition from the cache withTypeCaches
+// builds, so that references to a type share one string
+func (o *Options) definitionRef(name string) string {
+	if ref, ok := o.refs[name]; ok {
+		return ref
+	}
+	return definitionRef(name)
+}
+
+// copyScalar sets the keywords fillScalar fills in on a zeroed node from a cached scalar schema,
+// which is cheaper than copying every keyword; it has to list any keyword fillScalar starts setting
+func copyScalar(dst, src *JSONSchema6) {
+	dst.Title, dst.Description, dst.Type, dst.Format = src.Title, src.Description, src.Type, src.Format
+	dst.Minimum, dst.Maximum, dst.SpecifiedBy = src.Minimum, src.Maximum, src.SpecifiedBy
+	dst.GoType, dst.GoName, dst.GoTypeImport = src.GoType, src.GoName, src.GoTypeImport
+}
+
+// withTypeCaches builds the $ref to each type and the schema of each scalar once per conversion,
+// rather than once per field referencing them. Scalar nodes are copies of the cached schema that
+// share its Type slice and Minimum and Maximum, which the conversion never modifies in place.
+// Custom scalar schemas are left out, as they may nest schemas of their own and are cloned for
+// every node.
+func withTypeCaches(types []IntrospectionType, opts *Options) *Options {
+	resolved := *opts
+	resolved.refs = make(map[string]string, len(types))
+	resolved.scalars = make(map[string]*JSONSchema6)
+	for _, t := range types {
+		if t.Kind != "SCALAR" {
+			resolved.refs[t.Name] = definitionRef(t.Name)
+		} else if opts.CustomScalarSchemas[t.Name] == nil {
+			resolved.scalars[t.Name] = processScalar(t.Name, opts)
+		}
+	}
+	return &resolved
+}
+
+// appendRequired appends name to required, allocating room for every member on first use
+func appendRequired(required []string, name string, members int) []string {
+	if required == nil {
+		required = make([]string, 0, members)
+	}
+	return append(required, name)
 }
 
 func isRequired(typeRef IntrospectionTypeRef) bool {
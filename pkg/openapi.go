@@ -0,0 +1,232 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// openAPIRefPrefix starts references to the schemas of an OpenAPI components object
+const openAPIRefPrefix = "#/components/schemas/"
+
+// OpenAPIOptions controls the OpenAPI export
+type OpenAPIOptions struct {
+	// Discriminator adds a required __typename property to the members of unions and a discriminator
+	// on it to the unions, so that OpenAPI tooling can tell the members apart. Interfaces get one too
+	// when they list their implementations, see Options.InterfaceImplementations.
+	Discriminator bool `json:"discriminator"`
+}
+
+// OpenAPIComponents exports types as the components object of an OpenAPI 3.0 document,
+// {"components": {"schemas": {...}}}, to be merged into an existing document. When typeName is set,
+// that type and every type it references are exported; otherwise all types are, except the root
+// operation types no other type references. Scalars are inlined where they are used, so they have no
+// schema of their own.
+//
+// Schemas have the shape of response data, as with ResponseEnvelopeSchema: object fields hold their
+// values directly. Nullable values are marked nullable: true instead of being combined with null,
+// references point to #/components/schemas/, and keywords OpenAPI 3.0 does not know are left out or
+// rewritten: $schema and $comment are dropped, examples becomes example and type arrays become anyOf.
+// Since OpenAPI 3.0 ignores the siblings of $ref, a reference with a description or nullable is
+// wrapped in allOf. Options.Draft does not apply.
+func OpenAPIComponents(introspection IntrospectionQuery, typeName string, opts *Options, openAPI OpenAPIOptions) ([]byte, error) {
+	introspection, types, opts, err := prepareConversion(introspection, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ClosedComposition {
+		return nil, errors.New("ClosedComposition is not supported in OpenAPI output")
+	}
+	dataShape := *opts
+	dataShape.FieldShape = FieldShapePlain
+	opts = &dataShape
+
+	var keep map[string]bool
+	if typeName != "" {
+		if types[typeName] == nil {
+			candidates := make([]string, 0, len(types))
+			for name := range types {
+				candidates = append(candidates, name)
+			}
+			return nil, notFound("type", typeName, candidates)
+		}
+		keep = referencedTypes(types, map[string]bool{typeName: true}, opts)
+	} else {
+		// Root operation types are left out unless another type references them
+		roots := map[string]bool{}
+		for _, root := range []*TypeRef{introspection.Schema.QueryType, introspection.Schema.MutationType, introspection.Schema.SubscriptionType} {
+			if root != nil {
+				roots[root.Name] = true
+			}
+		}
+		keep = make(map[string]bool)
+		for _, t := range introspection.Schema.Types {
+			if !roots[t.Name] {
+				keep[t.Name] = true
+			}
+		}
+		keep = referencedTypes(types, keep, opts)
+	}
+	definitions, err := convertDefinitions(introspection.Schema.Types, opts, func(name string) bool {
+		return keep[name] && types[name].Kind != "SCALAR"
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The conversion is done on the JSON form, where keywords can be dropped and added freely
+	encoded, err := json.Marshal(definitions)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling schemas: %w", err)
+	}
+	var schemas map[string]interface{}
+	if err := json.Unmarshal(encoded, &schemas); err != nil {
+		return nil, fmt.Errorf("error decoding schemas: %w", err)
+	}
+	for _, schema := range schemas {
+		if object, ok := schema.(map[string]interface{}); ok {
+			openAPISchema(object)
+		}
+	}
+	if openAPI.Discriminator {
+		for i := range introspection.Schema.Types {
+			t := &introspection.Schema.Types[i]
+			if t.Kind == "UNION" || t.Kind == "INTERFACE" && opts.InterfaceImplementations.on() && len(t.PossibleTypes) > 0 {
+				addDiscriminator(schemas, t)
+			}
+		}
+	}
+
+	document := map[string]interface{}{
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+	output, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling OpenAPI components: %w", err)
+	}
+	return output, nil
+}
+
+// openAPISchema rewrites a schema in JSON form, and its subschemas, as an OpenAPI 3.0 schema object
+func openAPISchema(schema map[string]interface{}) {
+	delete(schema, "$schema")
+	delete(schema, "$comment")
+	if examples, ok := schema["examples"].([]interface{}); ok {
+		delete(schema, "examples")
+		if len(examples) > 0 {
+			schema["example"] = examples[0]
+		}
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if name, ok := strings.CutPrefix(ref, definitionsRefPrefix); ok {
+			schema["$ref"] = openAPIRefPrefix + name
+		}
+	}
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, property := range properties {
+			if object, ok := property.(map[string]interface{}); ok {
+				openAPISchema(object)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		openAPISchema(items)
+	}
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf"} {
+		branches, _ := schema[keyword].([]interface{})
+		for _, branch := range branches {
+			if object, ok := branch.(map[string]interface{}); ok {
+				openAPISchema(object)
+			}
+		}
+	}
+
+	// {anyOf: [X, {type: null}]} becomes X with nullable: true
+	if branches, ok := schema["anyOf"].([]interface{}); ok && len(branches) == 2 {
+		for i, branch := range branches {
+			if isNullSchema(branch) {
+				if value, ok := branches[1-i].(map[string]interface{}); ok {
+					delete(schema, "anyOf")
+					for keyword, v := range value {
+						if _, set := schema[keyword]; !set {
+							schema[keyword] = v
+						}
+					}
+					schema["nullable"] = true
+				}
+				break
+			}
+		}
+	}
+
+	if list, ok := schema["type"].([]interface{}); ok {
+		var types []interface{}
+		for _, t := range list {
+			if t == "null" {
+				schema["nullable"] = true
+			} else {
+				types = append(types, t)
+			}
+		}
+		delete(schema, "type")
+		switch {
+		case len(types) == 1:
+			schema["type"] = types[0]
+		case len(types) > 1:
+			alternatives := make([]interface{}, len(types))
+			for i, t := range types {
+				alternatives[i] = map[string]interface{}{"type": t}
+			}
+			if _, set := schema["anyOf"]; set {
+				allOf, _ := schema["allOf"].([]interface{})
+				schema["allOf"] = append(allOf, map[string]interface{}{"anyOf": alternatives})
+			} else {
+				schema["anyOf"] = alternatives
+			}
+		}
+	}
+
+	if ref, ok := schema["$ref"]; ok && len(schema) > 1 {
+		delete(schema, "$ref")
+		allOf, _ := schema["allOf"].([]interface{})
+		schema["allOf"] = append([]interface{}{map[string]interface{}{"$ref": ref}}, allOf...)
+	}
+}
+
+// isNullSchema reports whether a schema in JSON form is exactly {type: null}
+func isNullSchema(schema interface{}) bool {
+	object, ok := schema.(map[string]interface{})
+	return ok && len(object) == 1 && object["type"] == "null"
+}
+
+// addDiscriminator adds a discriminator on __typename to a union, or an interface listing its
+// implementations, and the property it reads to its exported members
+func addDiscriminator(schemas map[string]interface{}, union *IntrospectionType) {
+	schema, ok := schemas[union.Name].(map[string]interface{})
+	if !ok {
+		return
+	}
+	mapping := make(map[string]interface{}, len(union.PossibleTypes))
+	for _, possibleType := range union.PossibleTypes {
+		member, ok := schemas[possibleType.Name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Escaped as in the references to the member, see definitionRef
+		mapping[possibleType.Name] = openAPIRefPrefix + escapePointerSegment(possibleType.Name)
+		properties, _ := member["properties"].(map[string]interface{})
+		if properties == nil {
+			properties = make(map[string]interface{})
+			member["properties"] = properties
+		}
+		if properties["__typename"] != nil {
+			continue
+		}
+		properties["__typename"] = map[string]interface{}{"type": "string", "enum": []interface{}{possibleType.Name}}
+		required, _ := member["required"].([]interface{})
+		member["required"] = append(required, "__typename")
+	}
+	schema["discriminator"] = map[string]interface{}{"propertyName": "__typename", "mapping": mapping}
+}
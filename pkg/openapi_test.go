@@ -0,0 +1,87 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	. "github.com/robert-cronin/gql2jsonschema-go/pkg/introspectiontest"
+)
+
+// openAPIDocument is the part of OpenAPIComponents' output the discriminator tests read
+type openAPIDocument struct {
+	Components struct {
+		Schemas map[string]struct {
+			Ref           string                     `json:"$ref"`
+			Properties    map[string]json.RawMessage `json:"properties"`
+			Required      []string                   `json:"required"`
+			Discriminator *struct {
+				PropertyName string            `json:"propertyName"`
+				Mapping      map[string]string `json:"mapping"`
+			} `json:"discriminator"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// TestOpenAPIDiscriminator uses member names with the characters JSON Pointers escape, which
+// introspection results from servers that do not validate their type names can contain
+func TestOpenAPIDiscriminator(t *testing.T) {
+	introspection := NewSchema().
+		Query("Query",
+			Field("search", List(Union("Result"))),
+			Field("node", Interface("Node"))).
+		Interface("Node", Field("id", NonNull(Scalar("ID")))).
+		Object("Post/Draft", Implements("Node"), Field("id", NonNull(Scalar("ID"))), Field("title", Scalar("String"))).
+		Object("A~B", Implements("Node"), Field("id", NonNull(Scalar("ID")))).
+		Object("User", Implements("Node"), Field("id", NonNull(Scalar("ID")))).
+		Union("Result", Members("Post/Draft", "A~B", "User")).
+		Build()
+	opts := options(func(o *pkg.Options) { o.InterfaceImplementations = pkg.InterfaceImplementationsOneOf })
+	output, err := pkg.OpenAPIComponents(introspection, "", opts, pkg.OpenAPIOptions{Discriminator: true})
+	if err != nil {
+		t.Fatalf("OpenAPIComponents: %v", err)
+	}
+	var document openAPIDocument
+	if err := json.Unmarshal(output, &document); err != nil {
+		t.Fatal(err)
+	}
+	schemas := document.Components.Schemas
+
+	want := map[string]string{
+		"Post/Draft": "#/components/schemas/Post~1Draft",
+		"A~B":        "#/components/schemas/A~0B",
+		"User":       "#/components/schemas/User",
+	}
+	for _, name := range []string{"Result", "Node"} {
+		discriminator := schemas[name].Discriminator
+		if discriminator == nil {
+			t.Fatalf("%s has no discriminator", name)
+		}
+		if discriminator.PropertyName != "__typename" || !reflect.DeepEqual(discriminator.Mapping, want) {
+			t.Errorf("%s discriminator = %+v, want mapping %v", name, *discriminator, want)
+		}
+		for member, ref := range discriminator.Mapping {
+			escaped := strings.TrimPrefix(ref, "#/components/schemas/")
+			target := strings.NewReplacer("~1", "/", "~0", "~").Replace(escaped)
+			if _, ok := schemas[target]; !ok || target != member {
+				t.Errorf("%s mapping %s -> %s does not resolve to the member", name, member, ref)
+			}
+		}
+	}
+	// The discriminator values are the type names as introspection reports them
+	for member := range want {
+		typename := string(schemas[member].Properties["__typename"])
+		if quoted, _ := json.Marshal(member); !strings.Contains(typename, string(quoted)) {
+			t.Errorf("%s __typename = %s, want the enum [%s]", member, typename, quoted)
+		}
+		if required := schemas[member].Required; len(required) == 0 || required[len(required)-1] != "__typename" {
+			t.Errorf("%s does not require __typename: %v", member, schemas[member].Required)
+		}
+	}
+	// The mapping agrees with the references the fields hold
+	if !strings.Contains(string(output), `"$ref": "#/components/schemas/Post~1Draft"`) {
+		t.Errorf("no field references Post/Draft as the mapping does:\n%s", output)
+	}
+}
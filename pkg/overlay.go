@@ -0,0 +1,182 @@
+package pkg
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OverlaySchema is the JSON Schema of an overlay file
+//
+//go:embed overlay.schema.json
+var OverlaySchema []byte
+
+// OverlayArraysKey is the entry key selecting how the entry's arrays are merged: "replace", the
+// default, or "append"
+const OverlayArraysKey = "$arrays"
+
+// Overlay enriches a generated document with keywords the GraphQL schema cannot express. Entries
+// are keyed by GraphQL coordinate (Type, Type.field or Type.field.argument) or by JSON pointer
+// (starting with / or #) and hold the keywords to merge into the schema they locate.
+type Overlay map[string]map[string]interface{}
+
+// ApplyOverlay deep-merges the entries of overlay into a generated document decoded as generic
+// JSON, in key order. Objects are merged recursively, the overlay wins on any other conflict, and
+// arrays are replaced unless the entry sets $arrays to append. A coordinate locates a definition or
+// a root property, its field's return schema, or the argument's schema. The keys that locate
+// nothing are returned sorted.
+func ApplyOverlay(document interface{}, overlay Overlay) ([]string, error) {
+	keys := make([]string, 0, len(overlay))
+	for key := range overlay {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	unmatched := make([]string, 0)
+	for _, key := range keys {
+		entry := overlay[key]
+		appendArrays := false
+		switch mode := entry[OverlayArraysKey]; mode {
+		case nil, "replace":
+		case "append":
+			appendArrays = true
+		default:
+			return nil, fmt.Errorf("overlay %s: %s must be replace or append, got %v", key, OverlayArraysKey, mode)
+		}
+
+		var target map[string]interface{}
+		if strings.HasPrefix(key, "/") || strings.HasPrefix(key, "#") {
+			target = overlayPointerTarget(document, key)
+		} else {
+			target = overlayCoordinateTarget(document, key)
+		}
+		if target == nil {
+			unmatched = append(unmatched, key)
+			continue
+		}
+
+		keywords := make(map[string]interface{}, len(entry))
+		for name, value := range entry {
+			if name != OverlayArraysKey {
+				keywords[name] = value
+			}
+		}
+		mergeOverlayValue(target, keywords, appendArrays)
+	}
+	return unmatched, nil
+}
+
+// overlayPointerTarget resolves a JSON pointer in a generic document, returning nil unless it
+// locates an object
+func overlayPointerTarget(document interface{}, pointer string) map[string]interface{} {
+	trimmed := strings.TrimPrefix(pointer, "#")
+	current := document
+	if trimmed != "" {
+		if !strings.HasPrefix(trimmed, "/") {
+			return nil
+		}
+		for _, segment := range strings.Split(trimmed[1:], "/") {
+			segment = unescapePointerSegment(segment)
+			switch node := current.(type) {
+			case map[string]interface{}:
+				current = node[segment]
+			case []interface{}:
+				index, err := strconv.Atoi(segment)
+				if err != nil || index < 0 || index >= len(node) {
+					return nil
+				}
+				current = node[index]
+			default:
+				return nil
+			}
+		}
+	}
+	target, _ := current.(map[string]interface{})
+	return target
+}
+
+// overlayCoordinateTarget resolves a GraphQL coordinate in a generated document: the definition or
+// root property of the type, then the field's return schema, or for an input object the field's
+// own schema, then the argument's schema
+func overlayCoordinateTarget(document interface{}, coordinate string) map[string]interface{} {
+	parts := strings.Split(coordinate, ".")
+	if len(parts) > 3 {
+		return nil
+	}
+	child := func(node map[string]interface{}, keyword, name string) map[string]interface{} {
+		members, _ := node[keyword].(map[string]interface{})
+		target, _ := members[name].(map[string]interface{})
+		return target
+	}
+
+	root, _ := document.(map[string]interface{})
+	target := child(root, "definitions", parts[0])
+	if target == nil {
+		target = child(root, "properties", parts[0])
+	}
+	if target == nil || len(parts) == 1 {
+		return target
+	}
+
+	field := child(target, "properties", parts[1])
+	returns, arguments := child(field, "properties", "return"), child(field, "properties", "arguments")
+	switch {
+	case field == nil:
+		return nil
+	case len(parts) == 3 && arguments != nil:
+		return child(arguments, "properties", parts[2])
+	case len(parts) == 3:
+		return nil
+	case returns != nil && arguments != nil:
+		return returns
+	}
+	return field
+}
+
+// mergeOverlayValue merges the keywords of src into dst
+func mergeOverlayValue(dst, src map[string]interface{}, appendArrays bool) {
+	names := make([]string, 0, len(src))
+	for name := range src {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := src[name]
+		switch existing := dst[name].(type) {
+		case map[string]interface{}:
+			if object, ok := value.(map[string]interface{}); ok {
+				mergeOverlayValue(existing, object, appendArrays)
+				continue
+			}
+		case []interface{}:
+			if array, ok := value.([]interface{}); ok && appendArrays {
+				dst[name] = append(existing, copyOverlayValue(array).([]interface{})...)
+				continue
+			}
+		}
+		dst[name] = copyOverlayValue(value)
+	}
+}
+
+// copyOverlayValue copies the objects and arrays of an overlay value, so that merging later
+// entries into the document never changes the overlay
+func copyOverlayValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			object[name] = copyOverlayValue(member)
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, len(v))
+		for i, element := range v {
+			array[i] = copyOverlayValue(element)
+		}
+		return array
+	}
+	return value
+}
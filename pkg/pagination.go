@@ -0,0 +1,108 @@
+package pkg
+
+import "fmt"
+
+// RelayPaginationDefinition is the definition holding the Relay pagination arguments shared by
+// connection fields with Options.SharedPaginationArgs
+const RelayPaginationDefinition = "RelayPaginationArgs"
+
+// relayPaginationArgs are the standard Relay pagination arguments and the nullable scalar each has
+var relayPaginationArgs = []struct{ name, scalar string }{
+	{"first", "Int"},
+	{"after", "String"},
+	{"last", "Int"},
+	{"before", "String"},
+}
+
+// PaginationNote records in x-graphql-pagination which arguments of a field were replaced by a
+// reference to a shared definition
+type PaginationNote struct {
+	Definition string   `json:"definition"`
+	Arguments  []string `json:"arguments"`
+}
+
+// isRelayPagination reports whether args include all four Relay pagination arguments with their
+// standard types: nullable Int for first and last, nullable String for after and before, and no
+// defaults. Fields with only some of them, or with other types or defaults, keep their arguments.
+func isRelayPagination(args []IntrospectionArg) bool {
+	for _, standard := range relayPaginationArgs {
+		found := false
+		for _, arg := range args {
+			if arg.Name != standard.name {
+				continue
+			}
+			if arg.Type.Kind != "SCALAR" || arg.Type.Name == nil || *arg.Type.Name != standard.scalar || arg.DefaultValue != nil {
+				return false
+			}
+			found = true
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sharePaginationArgs replaces the Relay pagination arguments of an arguments object by a $ref to
+// the shared definition, combined through allOf with the field's other arguments when it has any
+func sharePaginationArgs(args *JSONSchema6) *JSONSchema6 {
+	note := &PaginationNote{Definition: RelayPaginationDefinition}
+	for _, standard := range relayPaginationArgs {
+		delete(args.Properties, standard.name)
+		note.Arguments = append(note.Arguments, standard.name)
+	}
+	ref := &JSONSchema6{Ref: definitionRef(RelayPaginationDefinition)}
+	if len(args.Properties) == 0 {
+		ref.Pagination = note
+		return ref
+	}
+	return &JSONSchema6{
+		Type:       "object",
+		AllOf:      []*JSONSchema6{ref, args},
+		Pagination: note,
+	}
+}
+
+// relayPaginationSchema is the shared definition of the Relay pagination arguments
+func relayPaginationSchema(opts *Options) *JSONSchema6 {
+	schema := &JSONSchema6{
+		Type:        "object",
+		Description: "Relay cursor pagination arguments: first and after page forward, last and before page backward",
+		Properties:  make(map[string]*JSONSchema6, len(relayPaginationArgs)),
+	}
+	for _, standard := range relayPaginationArgs {
+		arg := processScalar(standard.scalar, opts)
+		// The arguments are nullable, see isRelayPagination
+		if opts.NullableFields.on() {
+			arg = nullable(arg, opts.NullableFields)
+		}
+		schema.Properties[standard.name] = arg
+	}
+	return schema
+}
+
+// addPaginationDefinition adds the shared pagination definition to schema when a subschema refers to it
+func addPaginationDefinition(schema *JSONSchema6, opts *Options) {
+	if !opts.SharedPaginationArgs {
+		return
+	}
+	ref := definitionRef(RelayPaginationDefinition)
+	used := false
+	Walk(schema, func(s *JSONSchema6) {
+		used = used || s.Ref == ref
+	})
+	if used {
+		if schema.Definitions == nil {
+			schema.Definitions = make(map[string]*JSONSchema6, 1)
+		}
+		schema.Definitions[RelayPaginationDefinition] = relayPaginationSchema(opts)
+	}
+}
+
+// checkPaginationDefinition rejects schemas that already have a type named like the shared definition
+func checkPaginationDefinition(types typeIndex, opts *Options) error {
+	if opts.SharedPaginationArgs && types[RelayPaginationDefinition] != nil {
+		return fmt.Errorf("cannot share pagination arguments: the schema already has a type named %s", RelayPaginationDefinition)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package pkg_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const relaySDL = `
+type Query {
+  users(first: Int, after: String, last: Int, before: String): UserConnection
+  posts(status: Status, first: Int, after: String, last: Int, before: String): PostConnection
+  forward(first: Int, after: String): UserConnection
+  strict(first: Int!, after: String, last: Int, before: String): UserConnection
+  paged(first: Int = 10, after: String, last: Int, before: String): UserConnection
+  user(id: ID!): User
+}
+
+type User {
+  id: ID!
+  friends(first: Int, after: String, last: Int, before: String): UserConnection
+}
+
+type UserConnection { edges: [UserEdge] pageInfo: PageInfo! }
+type UserEdge { node: User cursor: String! }
+type PostConnection { totalCount: Int }
+type PageInfo { hasNextPage: Boolean! endCursor: String }
+
+enum Status { DRAFT PUBLISHED }
+`
+
+func TestSharedPaginationArgs(t *testing.T) {
+	schema := mustConvert(t, relaySDL, options(func(o *pkg.Options) { o.SharedPaginationArgs = true }))
+	ref := "#/definitions/" + pkg.RelayPaginationDefinition
+
+	definition := schema.Definitions[pkg.RelayPaginationDefinition]
+	if definition == nil {
+		t.Fatal("no shared pagination definition")
+	}
+	if names := sortedNames(definition.Properties); !reflect.DeepEqual(names, []string{"after", "before", "first", "last"}) {
+		t.Errorf("shared definition properties = %v", names)
+	}
+
+	tests := []struct {
+		pointer string
+		shared  bool
+		// own are the arguments kept besides the shared ones
+		own []string
+	}{
+		{"#/properties/Query/properties/users/properties/arguments", true, nil},
+		{"#/definitions/User/properties/friends/properties/arguments", true, nil},
+		{"#/properties/Query/properties/posts/properties/arguments", true, []string{"status"}},
+		{"#/properties/Query/properties/forward/properties/arguments", false, []string{"after", "first"}},
+		{"#/properties/Query/properties/strict/properties/arguments", false, []string{"after", "before", "first", "last"}},
+		{"#/properties/Query/properties/paged/properties/arguments", false, []string{"after", "before", "first", "last"}},
+		{"#/properties/Query/properties/user/properties/arguments", false, []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			args := mustPointer(t, schema, tt.pointer)
+			if !tt.shared {
+				if args.Pagination != nil || args.Ref != "" || len(args.AllOf) > 0 {
+					t.Errorf("arguments were rewritten: %+v", args)
+				}
+				if names := sortedNames(args.Properties); !reflect.DeepEqual(names, tt.own) {
+					t.Errorf("arguments = %v, want %v", names, tt.own)
+				}
+				return
+			}
+
+			if args.Pagination == nil || args.Pagination.Definition != pkg.RelayPaginationDefinition ||
+				!reflect.DeepEqual(args.Pagination.Arguments, []string{"first", "after", "last", "before"}) {
+				t.Errorf("x-graphql-pagination = %+v", args.Pagination)
+			}
+			switch {
+			case len(tt.own) == 0:
+				if args.Ref != ref {
+					t.Errorf("$ref = %q, want %q", args.Ref, ref)
+				}
+			case len(args.AllOf) != 2 || args.AllOf[0].Ref != ref:
+				t.Errorf("want allOf of the shared definition and the other arguments: %+v", args)
+			default:
+				if names := sortedNames(args.AllOf[1].Properties); !reflect.DeepEqual(names, tt.own) {
+					t.Errorf("own arguments = %v, want %v", names, tt.own)
+				}
+			}
+		})
+	}
+
+	args := mustPointer(t, schema, "#/properties/Query/properties/posts/properties/arguments")
+	if errs := validateJSON(t, schema, args, `{"first": 10, "after": "abc", "status": "DRAFT"}`); len(errs) > 0 {
+		t.Errorf("valid arguments rejected: %+v", errs)
+	}
+	if errs := validateJSON(t, schema, args, `{"first": "ten", "status": "GONE"}`); len(errs) != 2 {
+		t.Errorf("want the shared and own arguments checked, got %+v", errs)
+	}
+}
+
+func TestSharedPaginationArgsUnused(t *testing.T) {
+	schema := mustConvert(t, "type Query { users(first: Int, after: String): [String] }", options(func(o *pkg.Options) {
+		o.SharedPaginationArgs = true
+	}))
+	if schema.Definitions[pkg.RelayPaginationDefinition] != nil {
+		t.Error("the shared definition is added without a field referring to it")
+	}
+	if relay := mustConvert(t, relaySDL, nil); relay.Definitions[pkg.RelayPaginationDefinition] != nil {
+		t.Error("the shared definition is added without the option")
+	}
+}
+
+func TestSharedPaginationArgsNameClash(t *testing.T) {
+	sdl := relaySDL + "\ntype RelayPaginationArgs { first: Int }\n"
+	_, err := pkg.FromIntrospectionQuery(mustIntrospect(t, sdl), options(func(o *pkg.Options) { o.SharedPaginationArgs = true }))
+	if err == nil || !strings.Contains(err.Error(), "already has a type named RelayPaginationArgs") {
+		t.Errorf("error = %v", err)
+	}
+}
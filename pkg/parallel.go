@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"runtime"
+	"sync"
+)
+
+// progressInterval is how many definitions are processed between calls to Options.Progress
+const progressInterval = 500
+
+// workerCount returns the number of workers to use for n items with the given concurrency setting
+func workerCount(concurrency, n int) int {
+	workers := concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return min(workers, n)
+}
+
+// progressTracker counts processed definitions and reports them to Options.Progress.
+// Once the callback returns an error, the error is kept and processing stops.
+type progressTracker struct {
+	mu       sync.Mutex
+	callback func(done, total int) error
+	done     int
+	total    int
+	err      error
+}
+
+// add records one processed definition and reports whether processing should continue
+func (p *progressTracker) add() bool {
+	if p.callback == nil {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return false
+	}
+	p.done++
+	if p.done%progressInterval == 0 || p.done == p.total {
+		p.err = p.callback(p.done, p.total)
+	}
+	return p.err == nil
+}
+
+// processTypes converts each type into its definition schema across a worker pool.
+// Results are returned in input order, so output does not depend on scheduling.
+func processTypes(types []IntrospectionType, opts *Options) ([]*JSONSchema6, error) {
+	results := make([]*JSONSchema6, len(types))
+	progress := &progressTracker{callback: opts.Progress, total: len(types)}
+
+	workers := workerCount(opts.Concurrency, len(types))
+	opts.logger().Debug("Processing definitions", "definitions", len(types), "workers", max(workers, 1))
+	if workers <= 1 {
+		for i, t := range types {
+			results[i] = processType(t, opts)
+			if !progress.add() {
+				break
+			}
+		}
+		return results, progress.err
+	}
+
+	indexes := make(chan int)
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = processType(types[i], opts)
+				if !progress.add() {
+					stopOnce.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+feed:
+	for i := range types {
+		select {
+		case indexes <- i:
+		case <-stop:
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, progress.err
+}
@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pointerStep is one step of a JSON pointer into a schema: a keyword and, for properties,
+// definitions, anyOf, oneOf and allOf, the unescaped name or index that follows it
+type pointerStep struct {
+	keyword string
+	key     string
+	// segment is the segment as written in the pointer, for error messages
+	segment string
+}
+
+// parsePointer splits a JSON pointer such as #/properties/a~1b/anyOf/0 into steps
+func parsePointer(pointer string) ([]pointerStep, error) {
+	trimmed := strings.TrimPrefix(pointer, "#")
+	if trimmed == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(trimmed, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	segments := strings.Split(trimmed[1:], "/")
+	steps := make([]pointerStep, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		step := pointerStep{keyword: unescapePointerSegment(segments[i]), segment: segments[i]}
+		switch step.keyword {
+		case "items":
+		case "definitions", "properties", "anyOf", "oneOf", "allOf":
+			if i+1 >= len(segments) {
+				return nil, fmt.Errorf("JSON pointer %q ends at %s", pointer, step.keyword)
+			}
+			i++
+			step.key, step.segment = unescapePointerSegment(segments[i]), segments[i]
+		default:
+			return nil, fmt.Errorf("JSON pointer %q: segment %q not found", pointer, segments[i])
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// child returns the subschema a step leads to, or nil when it does not exist
+func (s *JSONSchema6) child(step pointerStep) *JSONSchema6 {
+	switch step.keyword {
+	case "items":
+		return s.Items
+	case "definitions":
+		return s.Definitions[step.key]
+	case "properties":
+		return s.Properties[step.key]
+	}
+	list := s.AnyOf
+	switch step.keyword {
+	case "oneOf":
+		list = s.OneOf
+	case "allOf":
+		list = s.AllOf
+	}
+	index, err := strconv.Atoi(step.key)
+	if err != nil || index < 0 || index >= len(list) {
+		return nil
+	}
+	return list[index]
+}
+
+// GetByPointer returns the subschema at a JSON pointer such as /definitions/User/properties/name
+// or #/anyOf/0. Segments are unescaped as in RFC 6901, so ~1 stands for / and ~0 for ~.
+func (s *JSONSchema6) GetByPointer(pointer string) (*JSONSchema6, error) {
+	steps, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := s
+	for _, step := range steps {
+		next := current.child(step)
+		if next == nil {
+			return nil, fmt.Errorf("JSON pointer %q: segment %q not found", pointer, step.segment)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// SetByPointer replaces the subschema at a JSON pointer. Properties and definitions are added when
+// missing, and an anyOf, oneOf or allOf index one past the end, or -, appends. A nil schema removes the
+// property, definition, items or array entry. The empty pointer replaces the schema itself.
+func (s *JSONSchema6) SetByPointer(pointer string, schema *JSONSchema6) error {
+	steps, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		if schema == nil {
+			return fmt.Errorf("JSON pointer %q: cannot remove the root schema", pointer)
+		}
+		*s = *schema
+		return nil
+	}
+
+	parent := s
+	for _, step := range steps[:len(steps)-1] {
+		next := parent.child(step)
+		if next == nil {
+			return fmt.Errorf("JSON pointer %q: segment %q not found", pointer, step.segment)
+		}
+		parent = next
+	}
+
+	last := steps[len(steps)-1]
+	switch last.keyword {
+	case "items":
+		parent.Items = schema
+	case "definitions", "properties":
+		target := &parent.Properties
+		if last.keyword == "definitions" {
+			target = &parent.Definitions
+		}
+		if schema == nil {
+			delete(*target, last.key)
+		} else {
+			if *target == nil {
+				*target = make(map[string]*JSONSchema6)
+			}
+			(*target)[last.key] = schema
+		}
+	case "anyOf", "oneOf", "allOf":
+		target := &parent.AnyOf
+		switch last.keyword {
+		case "oneOf":
+			target = &parent.OneOf
+		case "allOf":
+			target = &parent.AllOf
+		}
+		index := len(*target)
+		if last.key != "-" {
+			index, err = strconv.Atoi(last.key)
+			if err != nil || index < 0 || index > len(*target) {
+				return fmt.Errorf("JSON pointer %q: segment %q not found", pointer, last.segment)
+			}
+		}
+		switch {
+		case schema == nil && index == len(*target):
+			return fmt.Errorf("JSON pointer %q: segment %q not found", pointer, last.segment)
+		case schema == nil:
+			*target = append((*target)[:index:index], (*target)[index+1:]...)
+		case index == len(*target):
+			*target = append(*target, schema)
+		default:
+			(*target)[index] = schema
+		}
+	}
+	return nil
+}
+
+func unescapePointerSegment(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+}
+
+func escapePointerSegment(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,205 @@
+package pkg_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// pointerDocument is a schema with every kind of step a pointer can take, and keys that need escaping
+func pointerDocument() *pkg.JSONSchema6 {
+	return &pkg.JSONSchema6{
+		Properties: map[string]*pkg.JSONSchema6{
+			"a/b": {Type: "string", Title: "slash"},
+			"c~d": {Type: "string", Title: "tilde"},
+			"~1":  {Type: "string", Title: "escaped escape"},
+			"list": {
+				Type:  "array",
+				Items: &pkg.JSONSchema6{Ref: "#/definitions/Item"},
+			},
+		},
+		Definitions: map[string]*pkg.JSONSchema6{
+			"Item": {
+				Type: "object",
+				AnyOf: []*pkg.JSONSchema6{
+					{Title: "any 0"},
+					{Title: "any 1", OneOf: []*pkg.JSONSchema6{{Title: "one 0"}, {Title: "one 1"}}},
+				},
+				AllOf: []*pkg.JSONSchema6{{Title: "all 0", Properties: map[string]*pkg.JSONSchema6{"x": {Title: "x"}}}},
+			},
+		},
+	}
+}
+
+func TestGetByPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		title   string
+	}{
+		{"/properties/a~1b", "slash"},
+		{"#/properties/c~0d", "tilde"},
+		{"/properties/~01", "escaped escape"},
+		{"/definitions/Item/anyOf/0", "any 0"},
+		{"/definitions/Item/anyOf/1/oneOf/1", "one 1"},
+		{"#/definitions/Item/allOf/0/properties/x", "x"},
+	}
+	document := pointerDocument()
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			if got := mustPointer(t, document, tt.pointer).Title; got != tt.title {
+				t.Errorf("title = %q, want %q", got, tt.title)
+			}
+		})
+	}
+
+	if got := mustPointer(t, document, "/properties/list/items").Ref; got != "#/definitions/Item" {
+		t.Errorf("items $ref = %q", got)
+	}
+	for _, pointer := range []string{"", "#"} {
+		if got := mustPointer(t, document, pointer); got != document {
+			t.Errorf("%q did not return the root", pointer)
+		}
+	}
+}
+
+func TestGetByPointerErrors(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{"properties/a", "invalid JSON pointer"},
+		{"/properties/missing/items", `segment "missing" not found`},
+		{"/definitions/Item/anyOf/2", `segment "2" not found`},
+		{"/definitions/Item/anyOf/-1", `segment "-1" not found`},
+		{"/definitions/Item/oneOf/0", `segment "0" not found`},
+		{"/definitions/Item/anyOf/one", `segment "one" not found`},
+		{"/definitions", "ends at definitions"},
+		{"/type", `segment "type" not found`},
+		{"/properties/a~1b/items", `segment "items" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			_, err := pointerDocument().GetByPointer(tt.pointer)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSetByPointer(t *testing.T) {
+	replacement := &pkg.JSONSchema6{Title: "new"}
+	tests := []struct {
+		name    string
+		pointer string
+		schema  *pkg.JSONSchema6
+		check   func(t *testing.T, document *pkg.JSONSchema6)
+	}{
+		{"replace an escaped property", "/properties/a~1b", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if d.Properties["a/b"] != replacement {
+				t.Error("property a/b was not replaced")
+			}
+		}},
+		{"add a definition", "/definitions/New~0Type", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if d.Definitions["New~Type"] != replacement {
+				t.Error("definition New~Type was not added")
+			}
+		}},
+		{"add a property to a schema without properties", "/definitions/Item/anyOf/0/properties/y", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if d.Definitions["Item"].AnyOf[0].Properties["y"] != replacement {
+				t.Error("property y was not added")
+			}
+		}},
+		{"replace a oneOf branch", "/definitions/Item/anyOf/1/oneOf/0", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if oneOf := d.Definitions["Item"].AnyOf[1].OneOf; len(oneOf) != 2 || oneOf[0] != replacement {
+				t.Error("oneOf/0 was not replaced")
+			}
+		}},
+		{"append with the next index", "/definitions/Item/allOf/1", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if allOf := d.Definitions["Item"].AllOf; len(allOf) != 2 || allOf[1] != replacement {
+				t.Error("allOf/1 was not appended")
+			}
+		}},
+		{"append with -", "/definitions/Item/anyOf/-", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if anyOf := d.Definitions["Item"].AnyOf; len(anyOf) != 3 || anyOf[2] != replacement {
+				t.Error("anyOf/- was not appended")
+			}
+		}},
+		{"remove an anyOf branch", "/definitions/Item/anyOf/0", nil, func(t *testing.T, d *pkg.JSONSchema6) {
+			if anyOf := d.Definitions["Item"].AnyOf; len(anyOf) != 1 || anyOf[0].Title != "any 1" {
+				t.Errorf("anyOf/0 was not removed: %d branches left", len(anyOf))
+			}
+		}},
+		{"remove a property", "/properties/c~0d", nil, func(t *testing.T, d *pkg.JSONSchema6) {
+			if _, ok := d.Properties["c~d"]; ok {
+				t.Error("property c~d was not removed")
+			}
+		}},
+		{"remove items", "/properties/list/items", nil, func(t *testing.T, d *pkg.JSONSchema6) {
+			if d.Properties["list"].Items != nil {
+				t.Error("items were not removed")
+			}
+		}},
+		{"replace the root", "", replacement, func(t *testing.T, d *pkg.JSONSchema6) {
+			if d.Title != "new" || d.Properties != nil {
+				t.Error("the root was not replaced")
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := pointerDocument()
+			if err := document.SetByPointer(tt.pointer, tt.schema); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, document)
+		})
+	}
+}
+
+func TestSetByPointerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pointer string
+		schema  *pkg.JSONSchema6
+		want    string
+	}{
+		{"missing parent", "/definitions/Missing/properties/a", &pkg.JSONSchema6{}, `segment "Missing" not found`},
+		{"index past the end", "/definitions/Item/anyOf/3", &pkg.JSONSchema6{}, `segment "3" not found`},
+		{"removing past the end", "/definitions/Item/allOf/1", nil, `segment "1" not found`},
+		{"removing the root", "#", nil, "cannot remove the root schema"},
+		{"unknown keyword", "/properties/list/minItems", &pkg.JSONSchema6{}, `segment "minItems" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pointerDocument().SetByPointer(tt.pointer, tt.schema)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestPointerIntoConvertedSchema follows pointers through the layout FromIntrospectionQuery produces
+func TestPointerIntoConvertedSchema(t *testing.T) {
+	schema := mustConvert(t, `
+type Query { search(term: String!): [Result] }
+union Result = Book | Author
+type Book { title: String }
+type Author { name: String }
+`, options(nil))
+
+	search := mustPointer(t, schema, "/properties/Query/properties/search")
+	if search.Properties["arguments"] == nil || search.Properties["return"] == nil {
+		t.Fatal("the search field has no arguments or return")
+	}
+	result := mustPointer(t, schema, "/definitions/Result")
+	for i, want := range []string{"#/definitions/Book", "#/definitions/Author"} {
+		branch := mustPointer(t, schema, fmt.Sprintf("/definitions/Result/oneOf/%d", i))
+		if branch.Ref != want || branch != result.OneOf[i] {
+			t.Errorf("oneOf/%d = %q, want %q", i, branch.Ref, want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Preset is a named bundle of options for the schemas a GraphQL server generates, leaving out the
+// helper types nobody wants in a published JSON Schema and mapping the server's custom scalars
+type Preset struct {
+	Name        string
+	Description string
+	// ExcludeTypes and ExcludeFields are added to Options.PresetExcludeTypes and
+	// Options.PresetExcludeFields
+	ExcludeTypes  []string
+	ExcludeFields []string
+	// ScalarSchemas are used for scalars the options do not already map
+	ScalarSchemas      map[string]*JSONSchema6
+	FlattenConnections bool
+}
+
+// Apply adds the preset to opts. The options' own settings win: the preset's filters spare the
+// types and fields the options include, and their scalar schemas are not replaced.
+func (p *Preset) Apply(opts *Options) {
+	opts.PresetExcludeTypes = append(append([]string{}, opts.PresetExcludeTypes...), p.ExcludeTypes...)
+	opts.PresetExcludeFields = append(append([]string{}, opts.PresetExcludeFields...), p.ExcludeFields...)
+
+	scalars := make(map[string]*JSONSchema6, len(opts.CustomScalarSchemas)+len(p.ScalarSchemas))
+	for name, schema := range p.ScalarSchemas {
+		scalars[name] = schema
+	}
+	for name, schema := range opts.CustomScalarSchemas {
+		scalars[name] = schema
+	}
+	opts.CustomScalarSchemas = scalars
+
+	if p.FlattenConnections {
+		opts.FlattenConnections = true
+	}
+}
+
+// ApplyPreset applies the named preset to opts, see Preset.Apply
+func ApplyPreset(opts *Options, name string) error {
+	preset, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset: %s (must be one of %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	preset.Apply(opts)
+	return nil
+}
+
+// PresetNames returns the names of the presets in alphabetical order
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// federationTypes are the types Apollo Federation adds to subgraph schemas
+var federationTypes = []string{"_Service", "_Any", "_Entity", "_FieldSet"}
+
+var presets = map[string]*Preset{
+	"hasura": {
+		Name:        "hasura",
+		Description: "Hasura: leaves out filter, ordering, aggregate, upsert and streaming helper types",
+		ExcludeTypes: append([]string{
+			"*_bool_exp", "*_comparison_exp", "*_order_by", "order_by", "cursor_ordering",
+			"*_aggregate", "*_aggregate_fields", "*_aggregate_bool_exp*",
+			"*_avg_fields", "*_max_fields", "*_min_fields", "*_sum_fields",
+			"*_stddev_fields", "*_stddev_pop_fields", "*_stddev_samp_fields",
+			"*_var_pop_fields", "*_var_samp_fields", "*_variance_fields",
+			"*_select_column", "*_select_column_*", "*_on_conflict", "*_constraint", "*_update_column",
+			"*_stream_cursor_input", "*_stream_cursor_value_input", "*_updates",
+			"*_inc_input", "*_append_input", "*_prepend_input",
+			"*_delete_at_path_input", "*_delete_elem_input", "*_delete_key_input",
+		}, federationTypes...),
+		ScalarSchemas: map[string]*JSONSchema6{
+			"uuid":        {Type: "string", Format: "uuid"},
+			"timestamptz": {Type: "string", Format: "date-time"},
+			"timestamp":   {Type: "string"},
+			"date":        {Type: "string", Format: "date"},
+			"time":        {Type: "string"},
+			"timetz":      {Type: "string"},
+			"citext":      {Type: "string"},
+			"bpchar":      {Type: "string"},
+			"smallint":    {Type: "integer"},
+			"bigint":      {Type: "integer"},
+			"numeric":     {Type: "number"},
+			"float8":      {Type: "number"},
+			"json":        {},
+			"jsonb":       {},
+		},
+	},
+	"postgraphile": {
+		Name:        "postgraphile",
+		Description: "PostGraphile: flattens connections and leaves out condition, filter and ordering helper types",
+		ExcludeTypes: append([]string{
+			"*Condition", "*Filter", "*OrderBy", "*Connection", "*Edge", "PageInfo",
+		}, federationTypes...),
+		// Query.query re-exposes the query type for Relay, and nodeId lookups duplicate the by-key fields
+		ExcludeFields: []string{"Query.query", "Query.node", "Query.nodeId"},
+		ScalarSchemas: map[string]*JSONSchema6{
+			"Cursor":   {Type: "string"},
+			"Datetime": {Type: "string", Format: "date-time"},
+			"Date":     {Type: "string", Format: "date"},
+			"Time":     {Type: "string"},
+			"UUID":     {Type: "string", Format: "uuid"},
+			"BigInt":   {Type: "string", Pattern: "^-?[0-9]+$"},
+			"BigFloat": {Type: "string", Pattern: "^-?[0-9]+(\\.[0-9]+)?$"},
+			"JSON":     {},
+		},
+		FlattenConnections: true,
+	},
+	"shopify-admin": {
+		Name:        "shopify-admin",
+		Description: "Shopify Admin API: flattens connections and maps Shopify's custom scalars",
+		ExcludeTypes: []string{
+			"*Connection", "*Edge", "PageInfo",
+		},
+		ScalarSchemas: map[string]*JSONSchema6{
+			"DateTime":        {Type: "string", Format: "date-time"},
+			"Date":            {Type: "string", Format: "date"},
+			"Decimal":         {Type: "string", Pattern: "^-?[0-9]+(\\.[0-9]+)?$"},
+			"Money":           {Type: "string"},
+			"UnsignedInt64":   {Type: "string", Pattern: "^[0-9]+$"},
+			"URL":             {Type: "string", Format: "uri"},
+			"HTML":            {Type: "string"},
+			"FormattedString": {Type: "string"},
+			"Color":           {Type: "string", Pattern: "^#[0-9A-Fa-f]{6}$"},
+			"UtcOffset":       {Type: "string"},
+			"ARN":             {Type: "string"},
+			"JSON":            {},
+		},
+		FlattenConnections: true,
+	},
+}
+
+// UseCasePreset is a named starting point for the options of one kind of consumer of the schema.
+// Unlike a Preset it replaces the defaults rather than adding to the options.
+type UseCasePreset struct {
+	Name        string
+	Description string
+	// Options returns the preset's options, based on DefaultOptions
+	Options func() Options
+}
+
+// FormsOptions returns options for generating forms from input types: only definitions, nullable
+// list items represented exactly, temporal scalars given formats for date pickers, flat enums for
+// select widgets and examples moved out of the descriptions used as help text
+func FormsOptions() Options {
+	opts := DefaultOptions()
+	opts.DefinitionsOnly = true
+	opts.NullableArrayItems = true
+	opts.WellKnownScalars = true
+	opts.EnumStyle = EnumStyleFlat
+	opts.ExtractExamples = true
+	opts.StripExamples = true
+	return opts
+}
+
+// LLMToolsOptions returns options for describing operations to LLM tools: fields in the shape of
+// response data, a flat map of operations, only the definitions they reach, flat enums, descriptions
+// without examples and draft 2020-12, the dialect tool-calling APIs accept
+func LLMToolsOptions() Options {
+	opts := DefaultOptions()
+	opts.FieldShape = FieldShapePlain
+	opts.Draft = Draft202012
+	opts.OperationsLayout = OperationsLayoutFlat
+	opts.PruneToRoots = true
+	opts.EnumStyle = EnumStyleFlat
+	opts.ExtractExamples = true
+	opts.StripExamples = true
+	return opts
+}
+
+// StrictValidationOptions returns options for validating payloads as strictly as the GraphQL
+// schema allows: nullable fields and list items accepting null, Int limited to 32-bit integers and
+// unknown keys rejected
+func StrictValidationOptions() Options {
+	opts := DefaultOptions()
+	opts.NullableArrayItems = true
+	opts.NullableFields = NullableFieldsTypeArray
+	opts.UseIntegerType = true
+	opts.IntBounds = true
+	opts.AdditionalPropertiesFalse = true
+	return opts
+}
+
+// DocsOptions returns options for generating documentation: the GraphQL definition of each type
+// and examples extracted from the descriptions, which keep them
+func DocsOptions() Options {
+	opts := DefaultOptions()
+	opts.EmbedSDL = true
+	opts.ExtractExamples = true
+	return opts
+}
+
+var useCasePresets = map[string]*UseCasePreset{
+	"forms": {
+		Name:        "forms",
+		Description: "form generators such as react-jsonschema-form",
+		Options:     FormsOptions,
+	},
+	"llm-tools": {
+		Name:        "llm-tools",
+		Description: "tool and function-calling definitions for LLMs",
+		Options:     LLMToolsOptions,
+	},
+	"strict-validation": {
+		Name:        "strict-validation",
+		Description: "validating payloads as strictly as the GraphQL schema allows",
+		Options:     StrictValidationOptions,
+	},
+	"docs": {
+		Name:        "docs",
+		Description: "documentation generators",
+		Options:     DocsOptions,
+	},
+}
+
+// LookupUseCasePreset returns the use case preset with the given name
+func LookupUseCasePreset(name string) (*UseCasePreset, bool) {
+	preset, ok := useCasePresets[name]
+	return preset, ok
+}
+
+// UseCasePresetNames returns the names of the use case presets in alphabetical order
+func UseCasePresetNames() []string {
+	names := make([]string, 0, len(useCasePresets))
+	for name := range useCasePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupPreset returns the preset for a GraphQL server with the given name
+func LookupPreset(name string) (*Preset, bool) {
+	preset, ok := presets[name]
+	return preset, ok
+}
@@ -0,0 +1,258 @@
+package pkg_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+// convertWithPreset converts a trimmed server schema from testdata/presets with a server preset
+func convertWithPreset(t *testing.T, name string, set func(*pkg.Options)) *pkg.JSONSchema6 {
+	t.Helper()
+	sdl, err := os.ReadFile(filepath.Join("testdata", "presets", name+".graphql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := options(set)
+	if err := pkg.ApplyPreset(opts, name); err != nil {
+		t.Fatal(err)
+	}
+	return mustConvert(t, string(sdl), opts)
+}
+
+func TestServerPresets(t *testing.T) {
+	tests := []struct {
+		preset   string
+		kept     []string
+		excluded []string
+		scalars  map[string]string
+		check    func(t *testing.T, schema *pkg.JSONSchema6)
+	}{
+		{
+			preset:   "hasura",
+			kept:     []string{"users", "users_insert_input", "users_mutation_response", "jsonb"},
+			excluded: []string{"users_bool_exp", "String_comparison_exp", "users_order_by", "order_by", "cursor_ordering", "users_aggregate", "users_aggregate_fields", "users_max_fields", "users_select_column", "users_on_conflict", "users_constraint", "users_update_column", "users_append_input"},
+			scalars:  map[string]string{"uuid": "uuid", "timestamptz": "date-time"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				if got := sortedNames(mustPointer(t, schema, "/properties/Query").Properties); !reflect.DeepEqual(got, []string{"users", "users_by_pk"}) {
+					t.Errorf("Query fields = %v, want the aggregate field dropped", got)
+				}
+				users := mustPointer(t, schema, "/properties/Query/properties/users/properties/arguments")
+				if got := sortedNames(users.Properties); !reflect.DeepEqual(got, []string{"limit", "offset"}) {
+					t.Errorf("users arguments = %v, want the filter and ordering arguments dropped", got)
+				}
+				if got := mustPointer(t, schema, "/definitions/numeric").Type; got != "number" {
+					t.Errorf("numeric type = %v", got)
+				}
+			},
+		},
+		{
+			preset:   "postgraphile",
+			kept:     []string{"Post", "Node"},
+			excluded: []string{"PostsOrderBy", "PostsConnection", "PostsEdge", "PageInfo", "PostCondition", "PostFilter", "StringFilter"},
+			scalars:  map[string]string{"UUID": "uuid", "Datetime": "date-time"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				query := mustPointer(t, schema, "/properties/Query")
+				if got := sortedNames(query.Properties); !reflect.DeepEqual(got, []string{"allPosts", "post"}) {
+					t.Errorf("Query fields = %v, want query, node and nodeId dropped", got)
+				}
+				allPosts := mustPointer(t, schema, "/properties/Query/properties/allPosts/properties/return")
+				if allPosts.Items == nil || allPosts.Items.Ref != "#/definitions/Post" {
+					t.Errorf("allPosts is not flattened to a list of Post: %+v", allPosts)
+				}
+				if got := mustPointer(t, schema, "/definitions/BigInt").Pattern; got == "" {
+					t.Error("BigInt has no pattern")
+				}
+			},
+		},
+		{
+			preset:   "shopify-admin",
+			kept:     []string{"Product", "ProductVariant", "Shop"},
+			excluded: []string{"ProductConnection", "ProductEdge", "ProductVariantConnection", "ProductVariantEdge", "PageInfo"},
+			scalars:  map[string]string{"DateTime": "date-time", "URL": "uri"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				variants := mustPointer(t, schema, "/definitions/Product/properties/variants/properties/return")
+				if variants.Type != "array" || variants.Items == nil || variants.Items.Ref != "#/definitions/ProductVariant" {
+					t.Errorf("Product.variants is not flattened to a list of ProductVariant: %+v", variants)
+				}
+				if got := mustPointer(t, schema, "/definitions/Decimal").Type; got != "string" {
+					t.Errorf("Decimal type = %v", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			schema := convertWithPreset(t, tt.preset, nil)
+			for _, name := range tt.kept {
+				if schema.Definitions[name] == nil {
+					t.Errorf("definition %s was dropped", name)
+				}
+			}
+			for _, name := range tt.excluded {
+				if schema.Definitions[name] != nil {
+					t.Errorf("definition %s was kept", name)
+				}
+			}
+			for name, format := range tt.scalars {
+				if got := mustPointer(t, schema, "/definitions/"+name).Format; got != format {
+					t.Errorf("%s format = %q, want %q", name, got, format)
+				}
+			}
+			tt.check(t, schema)
+			if refs := danglingRefs(schema); len(refs) > 0 {
+				t.Errorf("the preset left dangling references: %v", refs)
+			}
+		})
+	}
+}
+
+func TestServerPresetUserFiltersWin(t *testing.T) {
+	t.Run("included type is spared", func(t *testing.T) {
+		schema := convertWithPreset(t, "hasura", func(o *pkg.Options) {
+			o.IncludeTypes = []string{"users*"}
+		})
+		for _, name := range []string{"users", "users_aggregate", "users_bool_exp"} {
+			if schema.Definitions[name] == nil {
+				t.Errorf("%s was excluded although --include-type selects it", name)
+			}
+		}
+		if schema.Definitions["String_comparison_exp"] != nil {
+			t.Error("a type outside the include patterns was kept")
+		}
+	})
+
+	t.Run("included field is spared", func(t *testing.T) {
+		schema := convertWithPreset(t, "postgraphile", func(o *pkg.Options) {
+			o.IncludeFields = []string{"Query.node", "Query.allPosts", "Query.post"}
+		})
+		query := mustPointer(t, schema, "/properties/Query")
+		if got := sortedNames(query.Properties); !reflect.DeepEqual(got, []string{"allPosts", "node", "post"}) {
+			t.Errorf("Query fields = %v, want node kept", got)
+		}
+	})
+
+	t.Run("user exclusions add to the preset", func(t *testing.T) {
+		schema := convertWithPreset(t, "shopify-admin", func(o *pkg.Options) {
+			o.ExcludeTypes = []string{"Shop"}
+			o.ExcludeFields = []string{"Product.onlineStoreUrl"}
+		})
+		if schema.Definitions["Shop"] != nil || schema.Definitions["ProductConnection"] != nil {
+			t.Error("user or preset exclusions were not applied")
+		}
+		if mustPointer(t, schema, "/definitions/Product").Properties["onlineStoreUrl"] != nil {
+			t.Error("the excluded field was kept")
+		}
+	})
+
+	t.Run("user scalar mapping wins", func(t *testing.T) {
+		schema := convertWithPreset(t, "hasura", func(o *pkg.Options) {
+			o.CustomScalarSchemas = map[string]*pkg.JSONSchema6{"uuid": {Type: "string", Pattern: "^[0-9a-f-]{36}$"}}
+		})
+		uuid := mustPointer(t, schema, "/definitions/uuid")
+		if uuid.Format != "" || uuid.Pattern == "" {
+			t.Errorf("uuid = %+v, want the user's mapping", uuid)
+		}
+		if got := mustPointer(t, schema, "/definitions/timestamptz").Format; got != "date-time" {
+			t.Errorf("timestamptz format = %q, want the preset's mapping", got)
+		}
+	})
+}
+
+func TestApplyPresetUnknown(t *testing.T) {
+	opts := options(nil)
+	if err := pkg.ApplyPreset(opts, "prisma"); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+	if !reflect.DeepEqual(pkg.PresetNames(), []string{"hasura", "postgraphile", "shopify-admin"}) {
+		t.Errorf("PresetNames = %v", pkg.PresetNames())
+	}
+}
+
+// changedOptions lists the options that differ from DefaultOptions by field name
+func changedOptions(opts pkg.Options) map[string]interface{} {
+	defaults := reflect.ValueOf(pkg.DefaultOptions())
+	value := reflect.ValueOf(opts)
+	changed := make(map[string]interface{})
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() || field.Type.Kind() == reflect.Func {
+			continue
+		}
+		if !reflect.DeepEqual(value.Field(i).Interface(), defaults.Field(i).Interface()) {
+			changed[field.Name] = value.Field(i).Interface()
+		}
+	}
+	return changed
+}
+
+func TestUseCasePresetOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options func() pkg.Options
+		want    map[string]interface{}
+	}{
+		{"forms", pkg.FormsOptions, map[string]interface{}{
+			"DefinitionsOnly":    true,
+			"NullableArrayItems": true,
+			"WellKnownScalars":   true,
+			"EnumStyle":          pkg.EnumStyleFlat,
+			"ExtractExamples":    true,
+			"StripExamples":      true,
+		}},
+		{"llm-tools", pkg.LLMToolsOptions, map[string]interface{}{
+			"FieldShape":       pkg.FieldShapePlain,
+			"Draft":            pkg.Draft202012,
+			"OperationsLayout": pkg.OperationsLayoutFlat,
+			"PruneToRoots":     true,
+			"EnumStyle":        pkg.EnumStyleFlat,
+			"ExtractExamples":  true,
+			"StripExamples":    true,
+		}},
+		{"strict-validation", pkg.StrictValidationOptions, map[string]interface{}{
+			"NullableArrayItems":        true,
+			"NullableFields":            pkg.NullableFieldsTypeArray,
+			"UseIntegerType":            true,
+			"IntBounds":                 true,
+			"AdditionalPropertiesFalse": true,
+		}},
+		{"docs", pkg.DocsOptions, map[string]interface{}{
+			"EmbedSDL":        true,
+			"ExtractExamples": true,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedOptions(tt.options()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changed options = %v, want %v", got, tt.want)
+			}
+			preset, ok := pkg.LookupUseCasePreset(tt.name)
+			if !ok {
+				t.Fatalf("preset %s is not registered", tt.name)
+			}
+			if got := changedOptions(preset.Options()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("registered preset changes %v, want %v", got, tt.want)
+			}
+			// Every preset produces a schema
+			opts := tt.options()
+			if _, err := pkg.FromIntrospectionQuery(mustIntrospect(t, presetUseCaseSDL), &opts); err != nil {
+				t.Errorf("FromIntrospectionQuery: %v", err)
+			}
+		})
+	}
+	if got, want := pkg.UseCasePresetNames(), []string{"docs", "forms", "llm-tools", "strict-validation"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UseCasePresetNames() = %v, want %v", got, want)
+	}
+}
+
+const presetUseCaseSDL = `
+type Query { user(id: ID!, role: Role): User }
+"A person. Example: {\"name\": \"Ada\"}"
+type User { id: ID! name: String tags: [String] role: Role }
+enum Role { ADMIN MEMBER }
+input UserFilter { role: Role count: Int }
+`
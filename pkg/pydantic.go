@@ -0,0 +1,474 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PydanticOptions controls the Pydantic export
+type PydanticOptions struct {
+	// ScalarTypes maps GraphQL scalars to Python type expressions; unmapped custom scalars are Any
+	ScalarTypes map[string]string `json:"scalarTypes"`
+	// Typename adds a __typename literal to every model, so unions and interfaces become
+	// discriminated unions
+	Typename bool `json:"typename"`
+	// IgnoreInternals skips the introspection types when exporting every type
+	IgnoreInternals bool `json:"ignoreInternals"`
+}
+
+// DefaultPydanticOptions returns the default scalar mapping for the given ID representation
+func DefaultPydanticOptions(idMapping IDTypeMapping) PydanticOptions {
+	id := "str"
+	switch idMapping {
+	case IDTypeNumber:
+		id = "int"
+	case IDTypeBoth:
+		id = "Union[str, int]"
+	}
+	return PydanticOptions{
+		ScalarTypes: map[string]string{
+			"ID":      id,
+			"String":  "str",
+			"Int":     "int",
+			"Float":   "float",
+			"Boolean": "bool",
+		},
+		IgnoreInternals: true,
+	}
+}
+
+// pydanticTypenameField is the model field holding __typename, which Pydantic would treat as private
+const pydanticTypenameField = "typename__"
+
+// pythonKeywords cannot be used as identifiers
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// pydanticReservedNames are names the generated module imports or uses, so types cannot take them
+var pydanticReservedNames = map[string]bool{
+	"annotations": true, "Enum": true, "Annotated": true, "Any": true, "List": true, "Literal": true,
+	"Optional": true, "Union": true, "BaseModel": true, "ConfigDict": true, "Field": true,
+	"str": true, "int": true, "float": true, "bool": true,
+}
+
+// PydanticModule exports types as a Python module of Pydantic v2 models. When typeName is set, that
+// type and every type it references are exported; otherwise all types except the root operation
+// types are.
+//
+// Objects and input objects become models whose nullable fields are Optional with a None default,
+// enums become str Enum classes, custom scalars become aliases, and unions and interfaces become
+// Unions of their possible types, discriminated by __typename with opts.Typename. Descriptions
+// become docstrings and field descriptions. Annotations are postponed and every model is rebuilt at
+// the end of the module, so recursive and forward references resolve.
+func PydanticModule(introspection IntrospectionQuery, typeName string, opts PydanticOptions) ([]byte, error) {
+	types := newTypeIndex(introspection.Schema.Types)
+	g := &pydanticGenerator{types: types, opts: opts}
+
+	var names []string
+	if typeName != "" {
+		if types[typeName] == nil {
+			candidates := make([]string, 0, len(types))
+			for name := range types {
+				candidates = append(candidates, name)
+			}
+			return nil, notFound("type", typeName, candidates)
+		}
+		names = typeClosure(types, typeName)
+	} else {
+		roots := map[string]bool{}
+		for _, root := range []*TypeRef{introspection.Schema.QueryType, introspection.Schema.MutationType, introspection.Schema.SubscriptionType} {
+			if root != nil {
+				roots[root.Name] = true
+			}
+		}
+		for _, t := range filterTypes(introspection.Schema.Types, opts.IgnoreInternals) {
+			if !roots[t.Name] {
+				names = append(names, t.Name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	// Aliases of scalars and enums come first as models use them in defaults, and unions last as
+	// their values are evaluated when the module is imported
+	var scalars, enums, models, unions []*IntrospectionType
+	for _, name := range names {
+		t := types[name]
+		if strings.HasPrefix(t.Name, "__") {
+			g.errorf(t.Name, "names starting with __ are reserved for Python name mangling; use --ignore-internals")
+			continue
+		}
+		switch t.Kind {
+		case "SCALAR":
+			if !cueBuiltinScalars[t.Name] {
+				scalars = append(scalars, t)
+			}
+		case "ENUM":
+			enums = append(enums, t)
+		case "OBJECT", "INPUT_OBJECT":
+			models = append(models, t)
+		case "UNION", "INTERFACE":
+			unions = append(unions, t)
+		default:
+			g.errorf(t.Name, "unsupported kind %s", t.Kind)
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`"""Pydantic models generated from a GraphQL schema by gql2jsonschema."""
+
+from __future__ import annotations
+
+from enum import Enum
+from typing import Annotated, Any, List, Literal, Optional, Union
+
+from pydantic import BaseModel, ConfigDict, Field
+`)
+	for _, t := range scalars {
+		b.WriteString("\n")
+		writePythonComment(&b, t.Description, "")
+		fmt.Fprintf(&b, "%s = %s\n", g.className(t.Name), g.scalarType(t.Name))
+	}
+	for _, t := range enums {
+		b.WriteString("\n\n")
+		g.writeEnum(&b, t)
+	}
+	for _, t := range models {
+		b.WriteString("\n\n")
+		g.writeModel(&b, t)
+	}
+	for _, t := range unions {
+		b.WriteString("\n\n")
+		writePythonComment(&b, t.Description, "")
+		g.writeUnion(&b, t)
+	}
+	if len(models) > 0 {
+		b.WriteString("\n\n")
+		for _, t := range models {
+			fmt.Fprintf(&b, "%s.model_rebuild()\n", g.className(t.Name))
+		}
+	}
+
+	if len(g.errs) > 0 {
+		return nil, errors.Join(g.errs...)
+	}
+	return b.Bytes(), nil
+}
+
+type pydanticGenerator struct {
+	types typeIndex
+	opts  PydanticOptions
+	errs  []error
+}
+
+func (g *pydanticGenerator) errorf(coordinate, format string, args ...interface{}) {
+	g.errs = append(g.errs, fmt.Errorf("%s: %s", coordinate, fmt.Sprintf(format, args...)))
+}
+
+func (g *pydanticGenerator) writeEnum(b *bytes.Buffer, t *IntrospectionType) {
+	if len(t.EnumValues) == 0 {
+		g.errorf(t.Name, "enum has no values")
+		return
+	}
+	fmt.Fprintf(b, "class %s(str, Enum):\n", g.className(t.Name))
+	if t.Description != "" {
+		writePythonDocstring(b, t.Description, "    ")
+		b.WriteString("\n")
+	}
+	for _, value := range t.EnumValues {
+		writePythonComment(b, value.Description, "    ")
+		fmt.Fprintf(b, "    %s = %s\n", pythonMemberName(value.Name), strconv.Quote(value.Name))
+	}
+}
+
+func (g *pydanticGenerator) writeModel(b *bytes.Buffer, t *IntrospectionType) {
+	className := g.className(t.Name)
+	fmt.Fprintf(b, "class %s(BaseModel):\n", className)
+	if t.Description != "" {
+		writePythonDocstring(b, t.Description, "    ")
+		b.WriteString("\n")
+	}
+
+	var lines []string
+	aliased := false
+	used := map[string]string{}
+	addField := func(name, description string, typeRef IntrospectionTypeRef, defaultValue *string) {
+		coordinate := t.Name + "." + name
+		attribute := pythonFieldName(name)
+		if other, ok := used[attribute]; ok {
+			g.errorf(coordinate, "field name %s clashes with %s in Python", attribute, other)
+			return
+		}
+		used[attribute] = name
+
+		annotation, nullable := g.typeExpr(coordinate, typeRef)
+		var fieldArgs []string
+		if nullable {
+			annotation = "Optional[" + annotation + "]"
+		}
+		if defaultValue != nil {
+			literal, ok := g.pythonDefault(*defaultValue, typeRef)
+			if !ok {
+				// A default the generator cannot translate still makes the field optional
+				literal = "None"
+				if !nullable {
+					annotation = "Optional[" + annotation + "]"
+				}
+			}
+			fieldArgs = append(fieldArgs, "default="+literal)
+		} else if nullable {
+			fieldArgs = append(fieldArgs, "default=None")
+		}
+		if attribute != name {
+			aliased = true
+			fieldArgs = append(fieldArgs, "alias="+strconv.Quote(name))
+		}
+		if description != "" {
+			fieldArgs = append(fieldArgs, "description="+strconv.Quote(description))
+		}
+
+		line := "    " + attribute + ": " + annotation
+		switch {
+		case len(fieldArgs) == 1 && strings.HasPrefix(fieldArgs[0], "default="):
+			line += " = " + strings.TrimPrefix(fieldArgs[0], "default=")
+		case len(fieldArgs) > 0:
+			line += " = Field(" + strings.Join(fieldArgs, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	if g.opts.Typename && t.Kind == "OBJECT" {
+		aliased = true
+		used[pydanticTypenameField] = "__typename"
+		name := strconv.Quote(t.Name)
+		lines = append(lines, fmt.Sprintf("    %s: Literal[%s] = Field(default=%s, alias=\"__typename\")", pydanticTypenameField, name, name))
+	}
+	for _, field := range t.Fields {
+		addField(field.Name, field.Description, field.Type, nil)
+	}
+	for _, field := range t.InputFields {
+		addField(field.Name, field.Description, field.Type, field.DefaultValue)
+	}
+
+	if aliased {
+		b.WriteString("    model_config = ConfigDict(populate_by_name=True)\n")
+		if len(lines) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(lines) == 0 && !aliased {
+		b.WriteString("    pass\n")
+	}
+}
+
+// writeUnion writes a union or interface as an alias of the Union of its possible types
+func (g *pydanticGenerator) writeUnion(b *bytes.Buffer, t *IntrospectionType) {
+	if len(t.PossibleTypes) == 0 {
+		if t.Kind == "UNION" {
+			g.errorf(t.Name, "union has no member types")
+		} else {
+			g.errorf(t.Name, "interface has no implementations")
+		}
+		return
+	}
+	members := make([]string, len(t.PossibleTypes))
+	for i, member := range t.PossibleTypes {
+		if g.types[member.Name] == nil {
+			g.errorf(t.Name, "member type %s not found", member.Name)
+		}
+		members[i] = g.className(member.Name)
+	}
+
+	expr := members[0]
+	if len(members) > 1 {
+		expr = "Union[" + strings.Join(members, ", ") + "]"
+		if g.opts.Typename {
+			expr = fmt.Sprintf("Annotated[%s, Field(discriminator=%s)]", expr, strconv.Quote(pydanticTypenameField))
+		}
+	}
+	fmt.Fprintf(b, "%s = %s\n", g.className(t.Name), expr)
+}
+
+// typeExpr returns the Python annotation for a type reference and whether it accepts None
+func (g *pydanticGenerator) typeExpr(coordinate string, typeRef IntrospectionTypeRef) (string, bool) {
+	switch typeRef.Kind {
+	case "NON_NULL":
+		if typeRef.OfType == nil {
+			g.errorf(coordinate, "non-null type without an inner type")
+			return "Any", false
+		}
+		expr, _ := g.typeExpr(coordinate, *typeRef.OfType)
+		return expr, false
+	case "LIST":
+		if typeRef.OfType == nil {
+			g.errorf(coordinate, "list type without an item type")
+			return "List[Any]", true
+		}
+		item, nullable := g.typeExpr(coordinate, *typeRef.OfType)
+		if nullable {
+			item = "Optional[" + item + "]"
+		}
+		return "List[" + item + "]", true
+	}
+
+	if typeRef.Name == nil {
+		g.errorf(coordinate, "type reference has no name")
+		return "Any", true
+	}
+	t := g.types[*typeRef.Name]
+	if t == nil {
+		g.errorf(coordinate, "type %s not found", *typeRef.Name)
+		return "Any", true
+	}
+	if t.Kind == "SCALAR" && cueBuiltinScalars[t.Name] {
+		return g.scalarType(t.Name), true
+	}
+	return g.className(t.Name), true
+}
+
+// scalarType maps a scalar to its Python type; unmapped custom scalars accept any value
+func (g *pydanticGenerator) scalarType(name string) string {
+	if expr := g.opts.ScalarTypes[name]; expr != "" {
+		return expr
+	}
+	return "Any"
+}
+
+// className is the Python name of a GraphQL type, suffixed with _ when it is taken
+func (g *pydanticGenerator) className(name string) string {
+	if pythonKeywords[name] || pydanticReservedNames[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// pythonDefault translates a GraphQL default value into a Python literal. JSON-compatible values
+// and enum values are supported; other literals, such as input objects with unquoted keys, are not.
+func (g *pydanticGenerator) pythonDefault(value string, typeRef IntrospectionTypeRef) (string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err == nil && !decoder.More() {
+		return pythonLiteral(decoded), true
+	}
+
+	named := namedTypeRef(typeRef)
+	if named == nil || named.Name == nil {
+		return "", false
+	}
+	if t := g.types[*named.Name]; t != nil && t.Kind == "ENUM" {
+		for _, enumValue := range t.EnumValues {
+			if enumValue.Name == value {
+				return g.className(t.Name) + "." + pythonMemberName(value), true
+			}
+		}
+	}
+	return "", false
+}
+
+// pythonLiteral renders a decoded JSON value as a Python literal
+func pythonLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case json.Number:
+		return v.String()
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = pythonLiteral(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = strconv.Quote(key) + ": " + pythonLiteral(v[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return "None"
+}
+
+// pythonFieldName is the attribute name of a GraphQL field: keywords gain a trailing _, and leading
+// underscores, which Pydantic reserves for private attributes, move to the end
+func pythonFieldName(name string) string {
+	if trimmed := strings.TrimLeft(name, "_"); trimmed != name {
+		if trimmed == "" {
+			return "field" + name
+		}
+		return trimmed + strings.Repeat("_", len(name)-len(trimmed))
+	}
+	if pythonKeywords[name] || name == "model_config" {
+		return name + "_"
+	}
+	return name
+}
+
+// pythonMemberName is the Enum member name of a GraphQL enum value
+func pythonMemberName(name string) string {
+	if pythonKeywords[name] || strings.HasPrefix(name, "_") {
+		return name + "_"
+	}
+	return name
+}
+
+// writePythonDocstring writes a description as a docstring
+func writePythonDocstring(b *bytes.Buffer, description, indent string) {
+	text := strings.ReplaceAll(strings.TrimSpace(description), `\`, `\\`)
+	text = strings.ReplaceAll(text, `"""`, `\"\"\"`)
+	if strings.HasSuffix(text, `"`) {
+		text = strings.TrimSuffix(text, `"`) + `\"`
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s\"\"\"%s\"\"\"\n", indent, text)
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"%s\n", indent, lines[0])
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(indent + strings.TrimRight(line, " ") + "\n")
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
+
+// writePythonComment writes a description as line comments
+func writePythonComment(b *bytes.Buffer, description, indent string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(description, "\n"), "\n") {
+		b.WriteString(indent)
+		b.WriteString(strings.TrimRight("# "+line, " "))
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,159 @@
+package pkg_test
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const pydanticFixture = `
+"An instant, as an RFC 3339 string"
+scalar DateTime
+
+type Query { user(id: ID!): User }
+
+"A person using the service"
+type User implements Node {
+  id: ID!
+  "Display name"
+  name: String!
+  email: String
+  joined: DateTime
+  role: Role!
+  "Who invited them"
+  invitedBy: User
+  friends: [User!]
+  tags: [String]!
+  pinned: SearchResult
+}
+
+interface Node { id: ID! }
+
+type Post implements Node { id: ID! title: String from: String }
+
+union SearchResult = User | Post
+
+enum Role {
+  "Full access"
+  ADMIN
+  MEMBER
+  None
+}
+
+input UserFilter {
+  role: Role = MEMBER
+  limit: Int = 20
+  names: [String!] = ["ada", "grace"]
+  active: Boolean! = true
+  nested: UserFilter
+}
+`
+
+// checkPythonIndentation is a stand-in for a Python parser: every line is indented by a multiple of
+// four spaces, a line ending in a colon opens a block indented one level deeper, and a line is
+// never deeper than the block it is in
+func checkPythonIndentation(t *testing.T, source []byte) {
+	t.Helper()
+	depth, opened := 0, false
+	inDocstring := false
+	for i, line := range strings.Split(string(source), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if inDocstring || trimmed == "" {
+			if strings.Count(line, `"""`)%2 == 1 {
+				inDocstring = !inDocstring
+			}
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		if strings.HasPrefix(trimmed, "\t") || indent%4 != 0 {
+			t.Errorf("line %d: indentation is not a multiple of four spaces: %q", i+1, line)
+			continue
+		}
+		level := indent / 4
+		switch {
+		case opened && level != depth+1:
+			t.Errorf("line %d: block body at level %d, want %d: %q", i+1, level, depth+1, line)
+		case !opened && level > depth:
+			t.Errorf("line %d: unexpected indent: %q", i+1, line)
+		}
+		depth = level
+		opened = strings.HasSuffix(trimmed, ":") && !strings.HasPrefix(trimmed, "#")
+		if strings.Count(trimmed, `"""`)%2 == 1 {
+			inDocstring = true
+		}
+	}
+	if opened {
+		t.Error("the module ends with an empty block")
+	}
+}
+
+// checkPythonSyntax parses the module with Python's ast module when a python3 interpreter is
+// available; pydantic itself is not needed for that
+func checkPythonSyntax(t *testing.T, source []byte) {
+	t.Helper()
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(python, "-c", "import ast, sys; ast.parse(sys.stdin.read())")
+	cmd.Stdin = bytes.NewReader(source)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("python3 cannot parse the module: %v\n%s", err, output)
+	}
+}
+
+func TestPydanticModule(t *testing.T) {
+	tests := []struct {
+		name     string
+		golden   string
+		typeName string
+		set      func(*pkg.PydanticOptions)
+	}{
+		{name: "all types", golden: "pydantic/fixture.py"},
+		{name: "typename", golden: "pydantic/typename.py", set: func(o *pkg.PydanticOptions) { o.Typename = true }},
+		{name: "selected type", golden: "pydantic/select-filter.py", typeName: "UserFilter"},
+		{name: "scalar mapping", golden: "pydantic/scalars.py", typeName: "User", set: func(o *pkg.PydanticOptions) {
+			o.ScalarTypes["DateTime"] = "datetime.datetime"
+		}},
+	}
+
+	introspection := mustIntrospect(t, pydanticFixture)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := pkg.DefaultPydanticOptions(pkg.IDTypeString)
+			if tt.set != nil {
+				tt.set(&opts)
+			}
+			module, err := pkg.PydanticModule(introspection, tt.typeName, opts)
+			if err != nil {
+				t.Fatalf("PydanticModule: %v", err)
+			}
+			assertGolden(t, tt.golden, module)
+			checkPythonIndentation(t, module)
+			checkPythonSyntax(t, module)
+		})
+	}
+}
+
+func TestPydanticModuleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sdl      string
+		typeName string
+		want     string
+	}{
+		{"unknown type", "type Query { a: Int }", "Missing", "Missing"},
+		{"clashing field names", "type Query { a: Int }\ntype T { from: Int from_: Int }", "", "T.from_: field name from_ clashes with from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pkg.PydanticModule(mustIntrospect(t, tt.sdl), tt.typeName, pkg.DefaultPydanticOptions(pkg.IDTypeString))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
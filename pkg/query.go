@@ -0,0 +1,290 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// queryGenerator holds the state used while building a query document
+type queryGenerator struct {
+	types     typeIndex
+	maxDepth  int
+	variables []string
+	varNames  map[string]bool
+}
+
+// GenerateQuery builds a GraphQL document selecting every reachable field of a root field up to maxDepth.
+// root is the operation type ("query", "mutation" or "subscription") or the name of the root type.
+// It fails when the field's type has nothing selectable within maxDepth, as an empty selection set
+// is not valid GraphQL.
+func GenerateQuery(introspection IntrospectionQuery, root string, field string, maxDepth int) (string, error) {
+	if maxDepth < 1 {
+		return "", fmt.Errorf("max depth must be at least 1, got %d", maxDepth)
+	}
+
+	operationKeyword, rootTypeName, err := resolveRoot(introspection.Schema, root)
+	if err != nil {
+		return "", err
+	}
+
+	types := newTypeIndex(introspection.Schema.Types)
+	rootType := types[rootTypeName]
+	if rootType == nil {
+		return "", &NotFoundError{Kind: "type", Name: rootTypeName}
+	}
+
+	rootField := findField(rootType.Fields, field)
+	if rootField == nil {
+		names := make([]string, 0, len(rootType.Fields))
+		for _, f := range rootType.Fields {
+			names = append(names, rootTypeName+"."+f.Name)
+		}
+		return "", notFound("field", rootTypeName+"."+field, names)
+	}
+
+	g := &queryGenerator{
+		types:    types,
+		maxDepth: maxDepth,
+		varNames: make(map[string]bool),
+	}
+
+	var body strings.Builder
+	if !g.writeField(&body, *rootField, rootTypeName, field, 1, 1, map[string]bool{rootTypeName: true}, make(map[string]string)) {
+		return "", fmt.Errorf("%s.%s has no fields that can be selected within depth %d", rootTypeName, field, maxDepth)
+	}
+
+	var doc strings.Builder
+	doc.WriteString(operationKeyword)
+	doc.WriteString(" ")
+	doc.WriteString(operationName(field))
+	if len(g.variables) > 0 {
+		doc.WriteString("(")
+		doc.WriteString(strings.Join(g.variables, ", "))
+		doc.WriteString(")")
+	}
+	doc.WriteString(" {\n")
+	doc.WriteString(body.String())
+	doc.WriteString("}\n")
+
+	return doc.String(), nil
+}
+
+// resolveRoot maps an operation type or root type name onto the operation keyword and root type name
+func resolveRoot(schema IntrospectionSchema, root string) (string, string, error) {
+	switch strings.ToLower(root) {
+	case "", string(OperationQuery):
+		if schema.QueryType == nil {
+			return "", "", fmt.Errorf("schema has no query type")
+		}
+		return "query", schema.QueryType.Name, nil
+	case string(OperationMutation):
+		if schema.MutationType == nil {
+			return "", "", fmt.Errorf("schema has no mutation type")
+		}
+		return "mutation", schema.MutationType.Name, nil
+	case string(OperationSubscription):
+		if schema.SubscriptionType == nil {
+			return "", "", fmt.Errorf("schema has no subscription type")
+		}
+		return "subscription", schema.SubscriptionType.Name, nil
+	}
+
+	if schema.QueryType != nil && schema.QueryType.Name == root {
+		return "query", root, nil
+	}
+	if schema.MutationType != nil && schema.MutationType.Name == root {
+		return "mutation", root, nil
+	}
+	if schema.SubscriptionType != nil && schema.SubscriptionType.Name == root {
+		return "subscription", root, nil
+	}
+
+	return "", "", fmt.Errorf("unknown root %s (must be 'query', 'mutation', 'subscription' or a root type name)", root)
+}
+
+// operationName derives an operation name from the root field name
+func operationName(field string) string {
+	if field == "" {
+		return "Operation"
+	}
+	return strings.ToUpper(field[:1]) + field[1:]
+}
+
+// writeField writes a field selection of parentType, its arguments and its sub-selection.
+// scope maps the response names already selected in the enclosing selection set, including its
+// inline fragments, to the shape they were selected with; see responseName.
+func (g *queryGenerator) writeField(b *strings.Builder, field IntrospectionField, parentType, path string, depth int, level int, visiting map[string]bool, scope map[string]string) bool {
+	namedType := namedTypeRef(field.Type)
+	if namedType == nil || namedType.Name == nil {
+		return false
+	}
+
+	t := g.types[*namedType.Name]
+	var selection string
+	if t != nil && isCompositeKind(t.Kind) {
+		if depth > g.maxDepth || visiting[t.Name] {
+			return false
+		}
+		selection = g.selectionSet(*t, path, depth, level, visiting, make(map[string]string))
+		if selection == "" {
+			return false
+		}
+	}
+
+	arguments := g.arguments(field, path)
+	indent := strings.Repeat("  ", level)
+	b.WriteString(indent)
+	shape := typeRefString(field.Type) + arguments + "{" + strings.Join(strings.Fields(selection), " ") + "}"
+	if name := responseName(scope, field.Name, parentType, shape); name != field.Name {
+		b.WriteString(name)
+		b.WriteString(": ")
+	}
+	b.WriteString(field.Name)
+	b.WriteString(arguments)
+	if selection != "" {
+		b.WriteString(" {\n")
+		b.WriteString(selection)
+		b.WriteString(indent)
+		b.WriteString("}")
+	}
+	b.WriteString("\n")
+
+	return true
+}
+
+// responseName returns the name a field is selected under in a selection set. Inline fragments on
+// the members of a union or interface may select same-named fields with different types, arguments
+// or sub-selections, which GraphQL rejects as conflicting, so such fields are aliased with the name of
+// their parent type. shape identifies what the field is selected with, regardless of indentation.
+func responseName(scope map[string]string, fieldName, parentType, shape string) string {
+	name := fieldName
+	if selected, ok := scope[name]; ok && selected != shape {
+		name = fieldName + "_" + parentType
+		for i := 2; scope[name] != "" && scope[name] != shape; i++ {
+			name = fmt.Sprintf("%s_%s%d", fieldName, parentType, i)
+		}
+	}
+	scope[name] = shape
+	return name
+}
+
+// selectionSet builds the sub-selection for a composite type, returning an empty string when nothing can be selected.
+// depth counts field nesting for the depth limit while level only controls indentation. scope holds
+// the response names of the selection set, which its inline fragments share.
+func (g *queryGenerator) selectionSet(t IntrospectionType, path string, depth int, level int, visiting map[string]bool, scope map[string]string) string {
+	visiting[t.Name] = true
+	defer delete(visiting, t.Name)
+
+	var b strings.Builder
+	indent := strings.Repeat("  ", level+1)
+
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		for _, field := range t.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			g.writeField(&b, field, t.Name, path+"_"+field.Name, depth+1, level+1, visiting, scope)
+		}
+	}
+
+	if t.Kind == "UNION" || t.Kind == "INTERFACE" {
+		possibleTypes := make([]string, 0, len(t.PossibleTypes))
+		for _, possibleType := range t.PossibleTypes {
+			possibleTypes = append(possibleTypes, possibleType.Name)
+		}
+		sort.Strings(possibleTypes)
+
+		for _, name := range possibleTypes {
+			possibleType := g.types[name]
+			if possibleType == nil || visiting[name] {
+				continue
+			}
+			fragment := g.selectionSet(*possibleType, path, depth, level+1, visiting, scope)
+			if fragment == "" {
+				continue
+			}
+			b.WriteString(indent)
+			b.WriteString("... on ")
+			b.WriteString(name)
+			b.WriteString(" {\n")
+			b.WriteString(fragment)
+			b.WriteString(indent)
+			b.WriteString("}\n")
+		}
+
+		if b.Len() > 0 {
+			return indent + "__typename\n" + b.String()
+		}
+	}
+
+	if b.Len() == 0 && (t.Kind == "UNION" || t.Kind == "INTERFACE") {
+		return indent + "__typename\n"
+	}
+
+	return b.String()
+}
+
+// arguments renders the argument list for a field, declaring a variable for each required argument
+func (g *queryGenerator) arguments(field IntrospectionField, path string) string {
+	args := make([]string, 0)
+	for _, arg := range field.Args {
+		if !isRequired(arg.Type) || arg.DefaultValue != nil {
+			continue
+		}
+
+		name := g.variableName(path + "_" + arg.Name)
+		g.variables = append(g.variables, fmt.Sprintf("$%s: %s", name, typeRefString(arg.Type)))
+		args = append(args, fmt.Sprintf("%s: $%s", arg.Name, name))
+	}
+
+	if len(args) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+// variableName returns a unique variable name derived from the given base
+func (g *queryGenerator) variableName(base string) string {
+	name := base
+	for i := 2; g.varNames[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.varNames[name] = true
+	return name
+}
+
+// namedTypeRef unwraps NON_NULL and LIST wrappers to the underlying named type
+func namedTypeRef(typeRef IntrospectionTypeRef) *IntrospectionTypeRef {
+	for typeRef.Kind == "NON_NULL" || typeRef.Kind == "LIST" {
+		if typeRef.OfType == nil {
+			return nil
+		}
+		typeRef = *typeRef.OfType
+	}
+	return &typeRef
+}
+
+// typeRefString renders a type reference in GraphQL syntax, e.g. [String!]!
+func typeRefString(typeRef IntrospectionTypeRef) string {
+	switch typeRef.Kind {
+	case "NON_NULL":
+		if typeRef.OfType != nil {
+			return typeRefString(*typeRef.OfType) + "!"
+		}
+	case "LIST":
+		if typeRef.OfType != nil {
+			return "[" + typeRefString(*typeRef.OfType) + "]"
+		}
+	}
+
+	if typeRef.Name != nil {
+		return *typeRef.Name
+	}
+	return ""
+}
+
+func isCompositeKind(kind string) bool {
+	return kind == "OBJECT" || kind == "INTERFACE" || kind == "UNION"
+}
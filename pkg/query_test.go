@@ -0,0 +1,215 @@
+package pkg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const querySDL = `
+type Query {
+  search(term: String!): [SearchResult!]!
+  node(id: ID!): Node
+  employee(id: ID!): Employee
+  wrapper: Wrapper
+}
+
+type Mutation {
+  rename(id: ID!, name: String!): Employee
+}
+
+type Subscription {
+  employeeChanged(id: ID!): Employee
+}
+
+interface Node { id: ID! }
+
+type Employee implements Node {
+  id: ID!
+  name: String
+  manager: Employee
+  reports: [Employee!]
+}
+
+type Photo implements Node {
+  id: ID!
+  size: Int
+  owner: Employee
+}
+
+type Post implements Node {
+  id: ID!
+  size: String!
+  owner(active: Boolean!): Employee
+}
+
+union SearchResult = Photo | Post
+
+type Wrapper { inner: Inner }
+
+type Inner { value: String }
+`
+
+// fieldShapes flattens inline fragments into their enclosing selection set, as GraphQL does when
+// merging fields, and reports response keys selected as fields of different types
+func fieldShapes(t *testing.T, introspection pkg.IntrospectionQuery, parent string, selections []pkg.Selection) {
+	t.Helper()
+	types := make(map[string]pkg.IntrospectionType)
+	for _, typ := range introspection.Schema.Types {
+		types[typ.Name] = typ
+	}
+
+	seen := make(map[string]string)
+	var walk func(parent string, selections []pkg.Selection)
+	walk = func(parent string, selections []pkg.Selection) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *pkg.InlineFragment:
+				walk(s.TypeCondition, s.SelectionSet)
+			case *pkg.Field:
+				if s.Name == "__typename" {
+					continue
+				}
+				var shape string
+				for _, field := range types[parent].Fields {
+					if field.Name == s.Name {
+						shape = typeString(field.Type)
+					}
+				}
+				if shape == "" {
+					t.Fatalf("%s has no field %s", parent, s.Name)
+				}
+				if previous, ok := seen[s.ResponseKey()]; ok && previous != shape {
+					t.Errorf("response key %s is selected as %s and %s", s.ResponseKey(), previous, shape)
+				}
+				seen[s.ResponseKey()] = shape
+				if len(s.SelectionSet) > 0 {
+					named := types[strings.Trim(shape, "[]!")]
+					fieldShapes(t, introspection, named.Name, s.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(parent, selections)
+}
+
+// typeString prints a type reference in GraphQL syntax
+func typeString(typeRef pkg.IntrospectionTypeRef) string {
+	switch typeRef.Kind {
+	case "NON_NULL":
+		return typeString(*typeRef.OfType) + "!"
+	case "LIST":
+		return "[" + typeString(*typeRef.OfType) + "]"
+	}
+	return *typeRef.Name
+}
+
+func TestGenerateQuery(t *testing.T) {
+	introspection := mustIntrospect(t, querySDL)
+
+	tests := []struct {
+		name      string
+		root      string
+		field     string
+		depth     int
+		operation pkg.OperationType
+		rootType  string
+		contains  []string
+	}{
+		{
+			name: "required arguments become variables", root: "query", field: "employee", depth: 2,
+			operation: pkg.OperationQuery, rootType: "Query",
+			contains: []string{"query Employee($employee_id: ID!)", "employee(id: $employee_id) {", "name"},
+		},
+		{
+			name: "root type name", root: "Mutation", field: "rename", depth: 1,
+			operation: pkg.OperationMutation, rootType: "Mutation",
+			contains: []string{"mutation Rename($rename_id: ID!, $rename_name: String!)"},
+		},
+		{
+			name: "subscription", root: "subscription", field: "employeeChanged", depth: 1,
+			operation: pkg.OperationSubscription, rootType: "Subscription",
+			contains: []string{"subscription EmployeeChanged($employeeChanged_id: ID!)", "name"},
+		},
+		{
+			name: "interface fragments", root: "query", field: "node", depth: 2,
+			operation: pkg.OperationQuery, rootType: "Query",
+			contains: []string{"__typename", "... on Employee {", "... on Photo {", "... on Post {"},
+		},
+		{
+			name: "conflicting union member fields are aliased", root: "query", field: "search", depth: 2,
+			operation: pkg.OperationQuery, rootType: "Query",
+			contains: []string{"... on Photo {", "size\n", "size_Post: size\n", "owner_Post: owner(active: $search_owner_active)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := pkg.GenerateQuery(introspection, tt.root, tt.field, tt.depth)
+			if err != nil {
+				t.Fatalf("GenerateQuery: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(query, want) {
+					t.Errorf("query does not contain %q:\n%s", want, query)
+				}
+			}
+
+			doc, err := pkg.ParseDocument(query)
+			if err != nil {
+				t.Fatalf("generated query does not parse: %v\n%s", err, query)
+			}
+			if len(doc.Operations) != 1 || doc.Operations[0].Operation != tt.operation {
+				t.Fatalf("expected a single %s operation, got %+v", tt.operation, doc.Operations)
+			}
+			fieldShapes(t, introspection, tt.rootType, doc.Operations[0].SelectionSet)
+			if _, err := pkg.OperationResponseSchema(introspection, doc, "", nil); err != nil {
+				t.Errorf("generated query does not resolve against the schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateQueryCycles(t *testing.T) {
+	introspection := mustIntrospect(t, querySDL)
+	query, err := pkg.GenerateQuery(introspection, "query", "employee", 10)
+	if err != nil {
+		t.Fatalf("GenerateQuery: %v", err)
+	}
+	// Employee is not expanded again inside itself
+	if strings.Contains(query, "manager {") || strings.Contains(query, "reports {") {
+		t.Errorf("recursive fields were expanded:\n%s", query)
+	}
+}
+
+func TestGenerateQueryErrors(t *testing.T) {
+	introspection := mustIntrospect(t, querySDL)
+	noSubscription := mustIntrospect(t, "type Query { a: String }")
+
+	tests := []struct {
+		name          string
+		introspection pkg.IntrospectionQuery
+		root          string
+		field         string
+		depth         int
+		want          string
+	}{
+		{"empty selection", introspection, "query", "wrapper", 1, "no fields that can be selected"},
+		{"depth too small", introspection, "query", "employee", 0, "max depth must be at least 1"},
+		{"unknown field", introspection, "query", "employe", 2, "did you mean: Query.employee"},
+		{"unknown root", introspection, "Root", "employee", 2, "unknown root"},
+		{"missing subscription type", noSubscription, "subscription", "a", 2, "no subscription type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := pkg.GenerateQuery(tt.introspection, tt.root, tt.field, tt.depth)
+			if err == nil {
+				t.Fatalf("expected an error, got:\n%s", query)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
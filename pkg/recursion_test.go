@@ -0,0 +1,171 @@
+package pkg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	. "github.com/robert-cronin/gql2jsonschema-go/pkg/introspectiontest"
+)
+
+// recursionSchema has self-references, mutual recursion, recursion through lists, an interface
+// and union that refer back to themselves, and a recursive input
+func recursionSchema() pkg.IntrospectionQuery {
+	return NewSchema().
+		Query("Query",
+			Field("employee", Object("Employee"), Arg("id", NonNull(Scalar("ID")))),
+			Field("a", Object("A")),
+			Field("search", List(NonNull(Interface("Node"))), Arg("filter", InputObject("Filter")))).
+		Object("Employee", Implements("Node"),
+			Field("id", NonNull(Scalar("ID"))),
+			Field("manager", Object("Employee")),
+			Field("reports", NonNull(List(NonNull(Object("Employee"))))),
+			Field("teams", List(List(Object("Employee"))))).
+		Object("A", Field("b", Object("B"))).
+		Object("B", Field("a", NonNull(Object("A"))), Field("as", List(Object("A")))).
+		Interface("Node", Field("id", NonNull(Scalar("ID"))), Field("parent", Interface("Node"))).
+		Object("Group", Implements("Node"),
+			Field("id", NonNull(Scalar("ID"))),
+			Field("parent", Interface("Node")),
+			Field("members", List(NonNull(Union("Member"))))).
+		Union("Member", Members("Employee", "Group")).
+		InputObject("Filter",
+			InputField("and", List(NonNull(InputObject("Filter")))),
+			InputField("not", InputObject("Filter")),
+			InputField("name", Scalar("String"))).
+		Build()
+}
+
+// convertWithin converts the recursion fixture, failing instead of hanging when the conversion does
+// not terminate
+func convertWithin(t *testing.T, opts *pkg.Options) *pkg.JSONSchema6 {
+	t.Helper()
+	introspection := recursionSchema()
+	type result struct {
+		schema *pkg.JSONSchema6
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		schema, err := pkg.FromIntrospectionQuery(introspection, opts)
+		done <- result{schema, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("FromIntrospectionQuery: %v", r.err)
+		}
+		return r.schema
+	case <-time.After(10 * time.Second):
+		t.Fatal("the conversion did not terminate")
+		return nil
+	}
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	schema := convertWithin(t, nil)
+	if dangling := danglingRefs(schema); len(dangling) > 0 {
+		t.Errorf("dangling refs: %v", dangling)
+	}
+
+	// Recursive members refer to the definitions rather than expanding them
+	tests := []struct {
+		name    string
+		pointer string
+		ref     string
+	}{
+		{"direct self-reference", "#/definitions/Employee/properties/manager/properties/return", "#/definitions/Employee"},
+		{"through a non-null list", "#/definitions/Employee/properties/reports/properties/return/items", "#/definitions/Employee"},
+		{"through nested lists", "#/definitions/Employee/properties/teams/properties/return/items/items", "#/definitions/Employee"},
+		{"A to B", "#/definitions/A/properties/b/properties/return", "#/definitions/B"},
+		{"B back to A", "#/definitions/B/properties/a/properties/return", "#/definitions/A"},
+		{"B to a list of A", "#/definitions/B/properties/as/properties/return/items", "#/definitions/A"},
+		{"interface to itself", "#/definitions/Node/properties/parent/properties/return", "#/definitions/Node"},
+		{"union member back to its union", "#/definitions/Group/properties/members/properties/return/items", "#/definitions/Member"},
+		{"recursive input", "#/definitions/Filter/properties/not", "#/definitions/Filter"},
+		{"recursive input list", "#/definitions/Filter/properties/and/items", "#/definitions/Filter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if node := mustPointer(t, schema, tt.pointer); node.Ref != tt.ref {
+				t.Errorf("$ref = %q, want %q", node.Ref, tt.ref)
+			}
+		})
+	}
+
+	instance := `{"id": {"return": "1"}, "manager": {"return": {"id": {"return": "2"}, "reports": {"return": []}}},
+		"reports": {"return": [{"id": {"return": "3"}, "reports": {"return": []}}]}}`
+	if errs := validateJSON(t, schema, schema.Definitions["Employee"], instance); len(errs) > 0 {
+		t.Errorf("nested employee rejected: %+v", errs)
+	}
+}
+
+func TestRecursiveTypesOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*pkg.Options)
+	}{
+		{"inline", func(o *pkg.Options) { o.InlineRefs = true }},
+		{"inline with depth", func(o *pkg.Options) { o.InlineRefs, o.InlineDepth = true, 2 }},
+		{"inline without definitions", func(o *pkg.Options) { o.InlineRefs, o.InlineOmitDefinitions = true, true }},
+		{"dedupe", func(o *pkg.Options) { o.DedupeDefinitions = true }},
+		{"method", func(o *pkg.Options) { o.MethodName = "employee" }},
+		{"pruned", func(o *pkg.Options) { o.PruneToRoots = true }},
+		{"input types", func(o *pkg.Options) { o.OnlyInputTypes = true }},
+		{"interface allOf", func(o *pkg.Options) { o.InterfaceAllOf = true }},
+		{"nullable fields", func(o *pkg.Options) { o.NullableFields = pkg.NullableFieldsAnyOf }},
+		{"plain fields", func(o *pkg.Options) { o.FieldShape = pkg.FieldShapePlain }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := convertWithin(t, options(tt.set))
+			if dangling := danglingRefs(schema); len(dangling) > 0 {
+				t.Errorf("dangling refs: %v", dangling)
+			}
+		})
+	}
+}
+
+func TestRecursiveTypesInlined(t *testing.T) {
+	schema := convertWithin(t, options(func(o *pkg.Options) { o.InlineRefs = true }))
+
+	// Expansion stops where it would enter a definition already being expanded
+	manager := mustPointer(t, schema, "#/properties/Query/properties/employee/properties/return/properties/manager/properties/return")
+	if manager.Ref != "#/definitions/Employee" {
+		t.Errorf("Employee.manager = %+v, want a $ref kept at the cycle", manager)
+	}
+	a := mustPointer(t, schema, "#/properties/Query/properties/a/properties/return/properties/b/properties/return/properties/a/properties/return")
+	if a.Ref != "#/definitions/A" {
+		t.Errorf("A.b.a = %+v, want a $ref kept at the cycle", a)
+	}
+	if schema.Definitions["Employee"] == nil || schema.Definitions["A"] == nil {
+		t.Errorf("the definitions kept refs need are missing: %v", sortedNames(schema.Definitions))
+	}
+}
+
+func TestRecursiveTypesSelected(t *testing.T) {
+	for _, name := range []string{"Employee", "A", "Filter", "Member"} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := pkg.ConvertType(recursionSchema(), name, nil)
+			if err != nil {
+				t.Fatalf("ConvertType: %v", err)
+			}
+			if dangling := danglingRefs(schema); len(dangling) > 0 {
+				t.Errorf("dangling refs: %v", dangling)
+			}
+		})
+	}
+
+	// allOf through a recursive reference cannot be flattened, and says so instead of looping
+	schema := convertWithin(t, options(func(o *pkg.Options) { o.InterfaceAllOf = true }))
+	done := make(chan error, 1)
+	go func() {
+		_, err := pkg.MergeAllOf(schema)
+		done <- err
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("MergeAllOf did not terminate")
+	}
+}
@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReferenceKind is how a schema element refers to a type
+type ReferenceKind string
+
+const (
+	// ReferenceField is a field of an object or interface returning the type
+	ReferenceField ReferenceKind = "field"
+	// ReferenceArgument is a field argument taking the type
+	ReferenceArgument ReferenceKind = "argument"
+	// ReferenceInputField is an input object field taking the type
+	ReferenceInputField ReferenceKind = "input-field"
+	// ReferenceUnionMember is a union listing the type as a member
+	ReferenceUnionMember ReferenceKind = "union-member"
+	// ReferenceImplementation is an object or interface implementing the type
+	ReferenceImplementation ReferenceKind = "implementation"
+)
+
+// TypeReference is a single place a type is referenced from. Coordinate is the schema coordinate
+// of the referencing element (Type.field, Type.field(arg:), Input.field, or the union or
+// implementing type), and Type the GraphQL type expression it uses, such as [Address!]!.
+type TypeReference struct {
+	Kind       ReferenceKind `json:"kind"`
+	Coordinate string        `json:"coordinate"`
+	Type       string        `json:"type,omitempty"`
+}
+
+// TypeReferences returns every reference to the named type in the schema, sorted by coordinate.
+// References from GraphQL internal types are left out.
+func TypeReferences(introspection IntrospectionQuery, name string) ([]TypeReference, error) {
+	found := false
+	for _, t := range introspection.Schema.Types {
+		if t.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("type %s not found", name)
+	}
+
+	references := inboundReferences(introspection.Schema.Types)[name]
+	if references == nil {
+		references = []TypeReference{}
+	}
+	return references, nil
+}
+
+// UnusedTypes returns the names of the types no other type refers to, in alphabetical order. The
+// root operation types, built-in scalars and GraphQL internal types are never reported.
+func UnusedTypes(introspection IntrospectionQuery) []string {
+	roots := make(map[string]bool, 3)
+	for _, root := range []*TypeRef{
+		introspection.Schema.QueryType,
+		introspection.Schema.MutationType,
+		introspection.Schema.SubscriptionType,
+	} {
+		if root != nil {
+			roots[root.Name] = true
+		}
+	}
+
+	inbound := inboundReferences(introspection.Schema.Types)
+	unused := make([]string, 0)
+	for _, t := range introspection.Schema.Types {
+		if roots[t.Name] || builtinScalars[t.Name] || strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		// A type referring only to itself is still unused
+		external := false
+		for _, reference := range inbound[t.Name] {
+			if referencingType(reference.Coordinate) != t.Name {
+				external = true
+				break
+			}
+		}
+		if !external {
+			unused = append(unused, t.Name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// inboundReferences indexes every reference between the schema's types by referenced type name
+func inboundReferences(types []IntrospectionType) map[string][]TypeReference {
+	inbound := make(map[string][]TypeReference)
+	add := func(name string, reference TypeReference) {
+		if name != "" {
+			inbound[name] = append(inbound[name], reference)
+		}
+	}
+
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		for _, field := range t.Fields {
+			coordinate := t.Name + "." + field.Name
+			add(namedTypeName(field.Type), TypeReference{
+				Kind:       ReferenceField,
+				Coordinate: coordinate,
+				Type:       typeRefString(field.Type),
+			})
+			for _, arg := range field.Args {
+				add(namedTypeName(arg.Type), TypeReference{
+					Kind:       ReferenceArgument,
+					Coordinate: coordinate + "(" + arg.Name + ":)",
+					Type:       typeRefString(arg.Type),
+				})
+			}
+		}
+		for _, field := range t.InputFields {
+			add(namedTypeName(field.Type), TypeReference{
+				Kind:       ReferenceInputField,
+				Coordinate: t.Name + "." + field.Name,
+				Type:       typeRefString(field.Type),
+			})
+		}
+		for _, member := range t.PossibleTypes {
+			// Interfaces list their implementations as possible types, which are reported the
+			// other way round below
+			if t.Kind == "UNION" {
+				add(member.Name, TypeReference{Kind: ReferenceUnionMember, Coordinate: t.Name})
+			}
+		}
+		for _, iface := range t.Interfaces {
+			add(iface.Name, TypeReference{Kind: ReferenceImplementation, Coordinate: t.Name})
+		}
+	}
+
+	for name, references := range inbound {
+		sort.SliceStable(references, func(i, j int) bool {
+			return references[i].Coordinate < references[j].Coordinate
+		})
+		inbound[name] = references
+	}
+	return inbound
+}
+
+// referencingType returns the type part of a reference's coordinate
+func referencingType(coordinate string) string {
+	if i := strings.IndexByte(coordinate, '.'); i >= 0 {
+		return coordinate[:i]
+	}
+	return coordinate
+}
+
+// RefPointers returns the JSON pointers of every $ref to the named definition in a generated
+// schema, in document order with definitions and properties sorted by name
+func RefPointers(schema *JSONSchema6, name string) []string {
+	pointers := make([]string, 0)
+	walkPointers(schema, "", func(subschema *JSONSchema6, pointer string) {
+		if subschema.Ref == definitionRef(name) {
+			pointers = append(pointers, "#"+pointer)
+		}
+	})
+	return pointers
+}
+
+// walkPointers is Walk, also passing the JSON pointer of each subschema
+func walkPointers(schema *JSONSchema6, pointer string, fn func(*JSONSchema6, string)) {
+	if schema == nil {
+		return
+	}
+
+	fn(schema, pointer)
+	for _, name := range sortedKeys(schema.Definitions) {
+		walkPointers(schema.Definitions[name], pointer+"/definitions/"+escapePointerSegment(name), fn)
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		walkPointers(schema.Properties[name], pointer+"/properties/"+escapePointerSegment(name), fn)
+	}
+	walkPointers(schema.Items, pointer+"/items", fn)
+	for i, branch := range schema.AnyOf {
+		walkPointers(branch, fmt.Sprintf("%s/anyOf/%d", pointer, i), fn)
+	}
+	for i, branch := range schema.OneOf {
+		walkPointers(branch, fmt.Sprintf("%s/oneOf/%d", pointer, i), fn)
+	}
+	for i, branch := range schema.AllOf {
+		walkPointers(branch, fmt.Sprintf("%s/allOf/%d", pointer, i), fn)
+	}
+}
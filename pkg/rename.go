@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RenameConflict is a rename that RenameDefinitions refused, with the reason
+type RenameConflict struct {
+	From   string
+	To     string
+	Reason string
+}
+
+// RenameError lists every rename that RenameDefinitions refused; the schema is left unchanged
+type RenameError struct {
+	Conflicts []RenameConflict
+}
+
+func (e *RenameError) Error() string {
+	pairs := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		pairs[i] = fmt.Sprintf("%s -> %s (%s)", conflict.From, conflict.To, conflict.Reason)
+	}
+	return "invalid renames: " + strings.Join(pairs, ", ")
+}
+
+// RenameDefinitions renames definitions and root properties, mapping old names to new ones, and
+// rewrites every $ref to a renamed definition. Renamed definitions record their GraphQL name in
+// x-graphql-type. Renaming to an existing name that is not renamed itself, renaming two names to
+// the same one, and renaming a name that is neither a definition nor a root property are errors,
+// reported together in a RenameError before anything is changed.
+func RenameDefinitions(schema *JSONSchema6, renames map[string]string) error {
+	if len(renames) == 0 {
+		return nil
+	}
+
+	froms := make([]string, 0, len(renames))
+	sources := make(map[string][]string)
+	for from, to := range renames {
+		froms = append(froms, from)
+		sources[to] = append(sources[to], from)
+	}
+	sort.Strings(froms)
+
+	var conflicts []RenameConflict
+	for _, from := range froms {
+		to := renames[from]
+		_, isDefinition := schema.Definitions[from]
+		_, isProperty := schema.Properties[from]
+		switch {
+		case to == from:
+			continue
+		case to == "" || strings.ContainsAny(to, "/~#"):
+			conflicts = append(conflicts, RenameConflict{From: from, To: to, Reason: "invalid name"})
+		case !isDefinition && !isProperty:
+			conflicts = append(conflicts, RenameConflict{From: from, To: to, Reason: "no such definition or root property"})
+		case len(sources[to]) > 1:
+			conflicts = append(conflicts, RenameConflict{From: from, To: to, Reason: "also the target of " + strings.Join(without(sources[to], from), ", ")})
+		case renamedAway(renames, to):
+			continue
+		case isDefinition && schema.Definitions[to] != nil:
+			conflicts = append(conflicts, RenameConflict{From: from, To: to, Reason: "definition " + to + " already exists"})
+		case isProperty && schema.Properties[to] != nil:
+			conflicts = append(conflicts, RenameConflict{From: from, To: to, Reason: "root property " + to + " already exists"})
+		}
+	}
+	if len(conflicts) > 0 {
+		return &RenameError{Conflicts: conflicts}
+	}
+
+	schema.Definitions = renameKeys(schema.Definitions, renames, func(from string, definition *JSONSchema6) {
+		if definition.GraphQLType == "" {
+			definition.GraphQLType = from
+		}
+	})
+	schema.Properties = renameKeys(schema.Properties, renames, nil)
+	for i, name := range schema.Required {
+		if to, ok := renames[name]; ok {
+			schema.Required[i] = to
+		}
+	}
+
+	// Subschemas may be shared, so each is rewritten once; otherwise swapped names would swap back
+	rewritten := make(map[*JSONSchema6]bool)
+	Walk(schema, func(s *JSONSchema6) {
+		if rewritten[s] || !strings.HasPrefix(s.Ref, "#/definitions/") {
+			return
+		}
+		rewritten[s] = true
+		name, rest, nested := strings.Cut(strings.TrimPrefix(s.Ref, "#/definitions/"), "/")
+		if to, ok := renames[unescapePointerSegment(name)]; ok {
+			s.Ref = definitionRef(to)
+			if nested {
+				s.Ref += "/" + rest
+			}
+		}
+	})
+	return nil
+}
+
+// renameKeys returns schemas with its keys renamed, calling renamed for each moved schema
+func renameKeys(schemas map[string]*JSONSchema6, renames map[string]string, renamed func(from string, schema *JSONSchema6)) map[string]*JSONSchema6 {
+	if schemas == nil {
+		return nil
+	}
+	result := make(map[string]*JSONSchema6, len(schemas))
+	for name, schema := range schemas {
+		if to, ok := renames[name]; ok {
+			if renamed != nil && schema != nil {
+				renamed(name, schema)
+			}
+			name = to
+		}
+		result[name] = schema
+	}
+	return result
+}
+
+// renamedAway reports whether name is itself renamed, freeing it for another rename
+func renamedAway(renames map[string]string, name string) bool {
+	to, ok := renames[name]
+	return ok && to != name
+}
+
+// without returns names without name, sorted
+func without(names []string, name string) []string {
+	var result []string
+	for _, n := range names {
+		if n != name {
+			result = append(result, n)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
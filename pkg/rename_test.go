@@ -0,0 +1,185 @@
+package pkg_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const renameSDL = `
+type Query {
+  user(id: ID!): InternalUserRecord
+  users(filter: InternalUserFilter): [InternalUserRecord!]!
+  node(id: ID!): Node
+}
+
+type Mutation { noop: Boolean }
+
+interface Node { id: ID! }
+
+type InternalUserRecord implements Node {
+  id: ID!
+  name: String!
+  manager: InternalUserRecord
+  role: InternalRole
+}
+
+type Team implements Node { id: ID! members: [InternalUserRecord!]! }
+
+input InternalUserFilter { role: InternalRole name: String }
+
+enum InternalRole { ADMIN MEMBER }
+`
+
+func TestRenameDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     func(*pkg.Options)
+		renames map[string]string
+		check   func(t *testing.T, schema *pkg.JSONSchema6)
+	}{
+		{
+			name:    "definitions and their refs",
+			renames: map[string]string{"InternalUserRecord": "User", "InternalRole": "Role", "InternalUserFilter": "UserFilter"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				if got := mustPointer(t, schema, "/definitions/User/properties/manager/properties/return").Ref; got != "#/definitions/User" {
+					t.Errorf("self reference = %q", got)
+				}
+				if got := mustPointer(t, schema, "/definitions/Team/properties/members/properties/return/items").Ref; got != "#/definitions/User" {
+					t.Errorf("list item reference = %q", got)
+				}
+				if got := mustPointer(t, schema, "/definitions/UserFilter/properties/role").Ref; got != "#/definitions/Role" {
+					t.Errorf("input field reference = %q", got)
+				}
+				if errs := validateJSON(t, schema, mustPointer(t, schema, "/definitions/UserFilter"), `{"role": "ADMIN"}`); len(errs) > 0 {
+					t.Errorf("a valid filter was rejected after renaming: %v", errs)
+				}
+				if errs := validateJSON(t, schema, mustPointer(t, schema, "/definitions/UserFilter"), `{"role": "OWNER"}`); len(errs) == 0 {
+					t.Error("an invalid role was accepted after renaming")
+				}
+			},
+		},
+		{
+			name:    "swapped names",
+			renames: map[string]string{"InternalUserRecord": "Team", "Team": "InternalUserRecord"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				if schema.Definitions["Team"].GraphQLType != "InternalUserRecord" || schema.Definitions["InternalUserRecord"].GraphQLType != "Team" {
+					t.Error("the definitions were not swapped")
+				}
+				if got := mustPointer(t, schema, "/definitions/Team/properties/manager/properties/return").Ref; got != "#/definitions/Team" {
+					t.Errorf("self reference after the swap = %q", got)
+				}
+			},
+		},
+		{
+			name:    "root property",
+			renames: map[string]string{"Query": "Queries", "InternalUserRecord": "User"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				if schema.Properties["Query"] != nil || schema.Properties["Queries"] == nil {
+					t.Errorf("root properties = %v", sortedNames(schema.Properties))
+				}
+				if got := mustPointer(t, schema, "/properties/Queries/properties/user/properties/return").Ref; got != "#/definitions/User" {
+					t.Errorf("root field reference = %q", got)
+				}
+			},
+		},
+		{
+			name:    "flat operations keep their refs valid",
+			set:     func(o *pkg.Options) { o.OperationsLayout = pkg.OperationsLayoutBoth },
+			renames: map[string]string{"InternalUserRecord": "User"},
+			check: func(t *testing.T, schema *pkg.JSONSchema6) {
+				if mustPointer(t, schema, "/properties/operations/properties/Query.user").Ref == "" {
+					t.Error("the flat entry is no longer a $ref")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustConvert(t, renameSDL, options(tt.set))
+			if err := pkg.RenameDefinitions(schema, tt.renames); err != nil {
+				t.Fatal(err)
+			}
+			for from, to := range tt.renames {
+				renamed := schema.Definitions[to]
+				if renamed == nil {
+					if schema.Properties[to] == nil {
+						t.Errorf("%s was not renamed to %s", from, to)
+					}
+					continue
+				}
+				if renamed.GraphQLType != from {
+					t.Errorf("%s has x-graphql-type %q, want %q", to, renamed.GraphQLType, from)
+				}
+			}
+			if refs := danglingRefs(schema); len(refs) > 0 {
+				t.Errorf("dangling references after renaming: %v", refs)
+			}
+			tt.check(t, schema)
+		})
+	}
+}
+
+func TestRenameDefinitionsConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		renames map[string]string
+		want    []pkg.RenameConflict
+	}{
+		{
+			name:    "existing definition",
+			renames: map[string]string{"InternalUserRecord": "Team"},
+			want:    []pkg.RenameConflict{{From: "InternalUserRecord", To: "Team", Reason: "definition Team already exists"}},
+		},
+		{
+			name:    "existing root property",
+			renames: map[string]string{"Query": "Mutation"},
+			want:    []pkg.RenameConflict{{From: "Query", To: "Mutation", Reason: "root property Mutation already exists"}},
+		},
+		{
+			name:    "duplicate targets",
+			renames: map[string]string{"InternalUserRecord": "User", "InternalUserFilter": "User"},
+			want: []pkg.RenameConflict{
+				{From: "InternalUserFilter", To: "User", Reason: "also the target of InternalUserRecord"},
+				{From: "InternalUserRecord", To: "User", Reason: "also the target of InternalUserFilter"},
+			},
+		},
+		{
+			name:    "every offending pair",
+			renames: map[string]string{"Missing": "Found", "InternalRole": "a/b", "Team": "Node", "InternalUserRecord": "User"},
+			want: []pkg.RenameConflict{
+				{From: "InternalRole", To: "a/b", Reason: "invalid name"},
+				{From: "Missing", To: "Found", Reason: "no such definition or root property"},
+				{From: "Team", To: "Node", Reason: "definition Node already exists"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustConvert(t, renameSDL, options(nil))
+			before := mustJSON(t, schema)
+			err := pkg.RenameDefinitions(schema, tt.renames)
+			var renameErr *pkg.RenameError
+			if !errors.As(err, &renameErr) {
+				t.Fatalf("expected a RenameError, got %v", err)
+			}
+			if !reflect.DeepEqual(renameErr.Conflicts, tt.want) {
+				t.Errorf("conflicts = %+v, want %+v", renameErr.Conflicts, tt.want)
+			}
+			for _, conflict := range tt.want {
+				if !strings.Contains(err.Error(), conflict.From+" -> "+conflict.To) {
+					t.Errorf("error %q does not list %s -> %s", err, conflict.From, conflict.To)
+				}
+			}
+			if !bytes.Equal(mustJSON(t, schema), before) {
+				t.Error("a refused rename changed the schema")
+			}
+		})
+	}
+}
@@ -0,0 +1,610 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResponseSchema is the schema of the data returned by a single operation
+type ResponseSchema struct {
+	Schema *JSONSchema6
+	// Coordinates maps response paths without list indices, such as /user/posts/title,
+	// to the GraphQL field coordinate that produced them, such as Post.title
+	Coordinates map[string]string
+}
+
+// Coordinate returns the GraphQL field coordinate for a JSON pointer into the response data,
+// or an empty string for the root
+func (r *ResponseSchema) Coordinate(instancePath string) string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(instancePath, "/") {
+		// Response keys are GraphQL names and never numeric, so numeric segments are list indices
+		if segment != "" && strings.Trim(segment, "0123456789") != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return ""
+	}
+	return r.Coordinates["/"+strings.Join(segments, "/")]
+}
+
+// OperationResponseSchema builds the schema of the data returned by the named operation in doc.
+// Every selected field is required, except those that @skip or @include guard with a variable;
+// nullable fields also accept null. Directives with a literal if argument are applied.
+func OperationResponseSchema(introspection IntrospectionQuery, doc *Document, operationName string, opts *Options) (*ResponseSchema, error) {
+	return OperationResponseSchemaForVariables(introspection, doc, operationName, nil, opts)
+}
+
+// OperationResponseSchemaForVariables is OperationResponseSchema for an execution with the given
+// variable values, which @skip and @include are evaluated against so that the schema is exact.
+// Variables the directives use must be provided or have a default. A nil map leaves them unknown.
+func OperationResponseSchemaForVariables(introspection IntrospectionQuery, doc *Document, operationName string, variables map[string]interface{}, opts *Options) (*ResponseSchema, error) {
+	if opts == nil {
+		defaultOpts := DefaultOptions()
+		opts = &defaultOpts
+	}
+	if err := checkDraft(opts.Draft); err != nil {
+		return nil, err
+	}
+
+	op, err := doc.Operation(operationName)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *TypeRef
+	switch op.Operation {
+	case OperationQuery:
+		root = introspection.Schema.QueryType
+	case OperationMutation:
+		root = introspection.Schema.MutationType
+	case OperationSubscription:
+		root = introspection.Schema.SubscriptionType
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%s: schema does not support %s operations", op.Position, op.Operation)
+	}
+
+	types := newTypeIndex(introspection.Schema.Types)
+	opts = withSpecifiedBy(introspection.Schema.Types, opts)
+	rootType := types[root.Name]
+	if rootType == nil {
+		return nil, fmt.Errorf("root type %s not found", root.Name)
+	}
+
+	fragments := make(map[string]*FragmentDefinition, len(doc.Fragments))
+	for _, fragment := range doc.Fragments {
+		if fragments[fragment.Name] != nil {
+			return nil, fmt.Errorf("%s: fragment %s is defined more than once", fragment.Position, fragment.Name)
+		}
+		fragments[fragment.Name] = fragment
+	}
+	if err := checkFragmentCycles(doc.Fragments, fragments); err != nil {
+		return nil, err
+	}
+
+	b := &responseBuilder{
+		types:       types,
+		opts:        opts,
+		fragments:   fragments,
+		operation:   op,
+		variables:   variables,
+		coordinates: make(map[string]string),
+		guarded:     make(map[Selection]bool),
+	}
+	schema, err := b.selectionSchema(rootType, op.SelectionSet, "")
+	if err != nil {
+		return nil, err
+	}
+	SetDraft(schema, b.opts.Draft)
+
+	return &ResponseSchema{Schema: schema, Coordinates: b.coordinates}, nil
+}
+
+// checkFragmentCycles reports the first fragment that spreads itself, directly or through other fragments
+func checkFragmentCycles(definitions []*FragmentDefinition, fragments map[string]*FragmentDefinition) error {
+	done := make(map[string]bool, len(definitions))
+	active := make(map[string]bool)
+
+	var visit func(selections []Selection) error
+	visit = func(selections []Selection) error {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *Field:
+				if err := visit(s.SelectionSet); err != nil {
+					return err
+				}
+			case *InlineFragment:
+				if err := visit(s.SelectionSet); err != nil {
+					return err
+				}
+			case *FragmentSpread:
+				if active[s.Name] {
+					return fmt.Errorf("%s: fragment %s spreads itself", s.Position, s.Name)
+				}
+				fragment := fragments[s.Name]
+				if fragment == nil || done[s.Name] {
+					continue
+				}
+				active[s.Name] = true
+				if err := visit(fragment.SelectionSet); err != nil {
+					return err
+				}
+				active[s.Name] = false
+				done[s.Name] = true
+			}
+		}
+		return nil
+	}
+
+	for _, fragment := range definitions {
+		if done[fragment.Name] {
+			continue
+		}
+		active[fragment.Name] = true
+		if err := visit(fragment.SelectionSet); err != nil {
+			return err
+		}
+		active[fragment.Name] = false
+		done[fragment.Name] = true
+	}
+	return nil
+}
+
+type responseBuilder struct {
+	types       typeIndex
+	opts        *Options
+	fragments   map[string]*FragmentDefinition
+	operation   *OperationDefinition
+	variables   map[string]interface{}
+	coordinates map[string]string
+	// guarded marks subselections of conditional selections that were merged with other selections
+	guarded map[Selection]bool
+}
+
+// scopedField is a field selection together with the type it was selected on, which is the
+// type condition of the innermost enclosing fragment. Conditional fields are guarded by @skip or
+// @include on a variable whose value is not known, on the field or an enclosing fragment.
+type scopedField struct {
+	*Field
+	scope       *IntrospectionType
+	conditional bool
+}
+
+// included evaluates the @skip and @include directives of a selection. A selection is conditional
+// when it is not excluded but depends on a variable whose value is not known.
+func (b *responseBuilder) included(directives []*Directive) (included, conditional bool, err error) {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			continue
+		}
+		var condition *Value
+		for _, argument := range directive.Arguments {
+			if argument.Name == "if" {
+				condition = argument.Value
+			}
+		}
+		if condition == nil {
+			return false, false, fmt.Errorf("%s: directive @%s requires an if argument", directive.Position, directive.Name)
+		}
+
+		value, known, err := b.booleanValue(condition)
+		if err != nil {
+			return false, false, fmt.Errorf("%s: @%s: %w", condition.Position, directive.Name, err)
+		}
+		if !known {
+			conditional = true
+		} else if value == (directive.Name == "skip") {
+			return false, false, nil
+		}
+	}
+	return true, conditional, nil
+}
+
+// booleanValue resolves a Boolean literal or variable; known is false for a variable when no
+// variable values were given
+func (b *responseBuilder) booleanValue(v *Value) (value, known bool, err error) {
+	switch v.Kind {
+	case ValueBoolean:
+		return v.Raw == "true", true, nil
+	case ValueVariable:
+		if b.variables == nil {
+			return false, false, nil
+		}
+		provided, ok := b.variables[v.Raw]
+		if !ok {
+			for _, definition := range b.operation.Variables {
+				if definition.Name == v.Raw && definition.DefaultValue != nil {
+					return b.booleanValue(definition.DefaultValue)
+				}
+			}
+			return false, false, fmt.Errorf("variable $%s is not provided", v.Raw)
+		}
+		value, ok := provided.(bool)
+		if !ok {
+			return false, false, fmt.Errorf("variable $%s must be a Boolean, got %s", v.Raw, instanceType(provided))
+		}
+		return value, true, nil
+	}
+	return false, false, fmt.Errorf("if must be a Boolean, got %s", v.Kind)
+}
+
+// possibleTypes returns the object types a value of a composite type can have
+func (b *responseBuilder) possibleTypes(t *IntrospectionType) map[string]bool {
+	possible := make(map[string]bool)
+	if t.Kind == "OBJECT" {
+		possible[t.Name] = true
+	}
+	for _, member := range t.PossibleTypes {
+		possible[member.Name] = true
+	}
+	return possible
+}
+
+// conditionType resolves the type condition of a fragment spread in scope, rejecting conditions
+// that no value of scope can satisfy. name is the fragment's name, or empty for an inline fragment.
+func (b *responseBuilder) conditionType(scope *IntrospectionType, name, condition string, position Position) (*IntrospectionType, error) {
+	if condition == "" {
+		return scope, nil
+	}
+	t := b.types[condition]
+	if t == nil {
+		names := make([]string, 0, len(b.types))
+		for name := range b.types {
+			names = append(names, name)
+		}
+		return nil, fmt.Errorf("%s: %w", position, notFound("type", condition, names))
+	}
+	if t.Kind != "OBJECT" && t.Kind != "INTERFACE" && t.Kind != "UNION" {
+		return nil, fmt.Errorf("%s: fragment cannot be on %s type %s", position, strings.ToLower(t.Kind), t.Name)
+	}
+
+	scopeTypes := b.possibleTypes(scope)
+	for name := range b.possibleTypes(t) {
+		if scopeTypes[name] {
+			return t, nil
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%s: inline fragment on %s can never apply to %s", position, t.Name, scope.Name)
+	}
+	return nil, fmt.Errorf("%s: fragment %s on %s can never apply to %s", position, name, t.Name, scope.Name)
+}
+
+// collectFields groups the fields selected on scope by response key, in selection order, resolving
+// fragment spreads and dropping selections excluded by @skip or @include. When runtime is set, only
+// fragments whose type condition includes runtime are followed; otherwise every fragment is.
+func (b *responseBuilder) collectFields(scope, runtime *IntrospectionType, selections []Selection, conditional bool, keys *[]string, fields map[string][]scopedField) error {
+	for _, selection := range selections {
+		var (
+			name, condition string
+			subselection    []Selection
+			directives      []*Directive
+		)
+		switch s := selection.(type) {
+		case *Field:
+			directives = s.Directives
+		case *InlineFragment:
+			directives = s.Directives
+		case *FragmentSpread:
+			directives = s.Directives
+		}
+		included, guarded, err := b.included(directives)
+		if err != nil {
+			return err
+		}
+		if !included {
+			continue
+		}
+		guarded = guarded || b.guarded[selection]
+
+		switch s := selection.(type) {
+		case *Field:
+			key := s.ResponseKey()
+			if _, seen := fields[key]; !seen {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], scopedField{Field: s, scope: scope, conditional: conditional || guarded})
+			continue
+		case *InlineFragment:
+			condition, subselection = s.TypeCondition, s.SelectionSet
+		case *FragmentSpread:
+			fragment := b.fragments[s.Name]
+			if fragment == nil {
+				names := make([]string, 0, len(b.fragments))
+				for name := range b.fragments {
+					names = append(names, name)
+				}
+				return fmt.Errorf("%s: %w", s.Position, notFound("fragment", s.Name, names))
+			}
+			name, condition, subselection = fragment.Name, fragment.TypeCondition, fragment.SelectionSet
+		}
+
+		t, err := b.conditionType(scope, name, condition, selection.Pos())
+		if err != nil {
+			return err
+		}
+		if runtime != nil && !b.possibleTypes(t)[runtime.Name] {
+			continue
+		}
+		if err := b.collectFields(t, runtime, subselection, conditional || guarded, keys, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectionSchema builds the object schema for a selection set on parent. On an interface or union,
+// fragments that only apply to some of its object types make the schema a oneOf with a closed
+// branch per distinct set of selected fields, so a value only validates against the fields
+// selected for its type.
+func (b *responseBuilder) selectionSchema(parent *IntrospectionType, selections []Selection, path string) (*JSONSchema6, error) {
+	if parent.Kind == "INTERFACE" || parent.Kind == "UNION" {
+		schema, err := b.conditionalSchema(parent, selections, path)
+		if schema != nil || err != nil {
+			return schema, err
+		}
+	}
+
+	keys := make([]string, 0)
+	fields := make(map[string][]scopedField)
+	if err := b.collectFields(parent, nil, selections, false, &keys, fields); err != nil {
+		return nil, err
+	}
+	var typenames []string
+	if parent.Kind == "OBJECT" {
+		typenames = []string{parent.Name}
+	}
+	return b.objectSchema(typenames, keys, fields, path)
+}
+
+// conditionalSchema builds the oneOf schema for a selection set on an abstract type, or returns nil
+// when every possible type selects the same fields
+func (b *responseBuilder) conditionalSchema(parent *IntrospectionType, selections []Selection, path string) (*JSONSchema6, error) {
+	type branch struct {
+		typenames []string
+		keys      []string
+		fields    map[string][]scopedField
+	}
+	branches := make([]*branch, 0)
+	bySignature := make(map[string]*branch)
+	for _, member := range parent.PossibleTypes {
+		runtime := b.types[member.Name]
+		if runtime == nil {
+			return nil, fmt.Errorf("type %s not found", member.Name)
+		}
+		current := &branch{typenames: []string{runtime.Name}, fields: make(map[string][]scopedField)}
+		if err := b.collectFields(parent, runtime, selections, false, &current.keys, current.fields); err != nil {
+			return nil, err
+		}
+
+		// Types that select exactly the same fields share a branch
+		var signature strings.Builder
+		for _, key := range current.keys {
+			fmt.Fprintf(&signature, "%s:", key)
+			for _, field := range current.fields[key] {
+				fmt.Fprintf(&signature, "%p %t,", field.Field, field.conditional)
+			}
+			signature.WriteString(";")
+		}
+		if existing := bySignature[signature.String()]; existing != nil {
+			existing.typenames = append(existing.typenames, runtime.Name)
+			continue
+		}
+		bySignature[signature.String()] = current
+		branches = append(branches, current)
+	}
+	if len(branches) < 2 {
+		return nil, nil
+	}
+
+	// Different selections can still produce the same schema, e.g. when a fragment repeats a field,
+	// so branches are merged again on their schema before __typename is narrowed to their types
+	closed := false
+	objects := make([]*JSONSchema6, 0, len(branches))
+	typenames := make([][]string, 0, len(branches))
+	typenameKeys := make([][]string, 0, len(branches))
+	seen := make(map[string]int)
+	for _, branch := range branches {
+		object, err := b.objectSchema(nil, branch.keys, branch.fields, path)
+		if err != nil {
+			return nil, err
+		}
+		object.AdditionalProperties = &closed
+
+		encoded, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := seen[string(encoded)]; ok {
+			typenames[i] = append(typenames[i], branch.typenames...)
+			continue
+		}
+		seen[string(encoded)] = len(objects)
+		objects = append(objects, object)
+		typenames = append(typenames, branch.typenames)
+		keys := make([]string, 0)
+		for _, key := range branch.keys {
+			if branch.fields[key][0].Name == "__typename" {
+				keys = append(keys, key)
+			}
+		}
+		typenameKeys = append(typenameKeys, keys)
+	}
+	if len(objects) < 2 {
+		return nil, nil
+	}
+
+	for i, object := range objects {
+		for _, key := range typenameKeys[i] {
+			object.Properties[key].Enum = typenames[i]
+		}
+	}
+	return &JSONSchema6{OneOf: objects}, nil
+}
+
+// objectSchema builds the object schema for the collected fields; typenames lists the values
+// __typename can take, or is empty when it is not known. A key is required unless all of its
+// selections are conditional.
+func (b *responseBuilder) objectSchema(typenames []string, keys []string, fields map[string][]scopedField, path string) (*JSONSchema6, error) {
+	schema := &JSONSchema6{
+		Type:       "object",
+		Properties: make(map[string]*JSONSchema6, len(keys)),
+		Required:   make([]string, 0, len(keys)),
+	}
+	for _, key := range keys {
+		for _, field := range fields[key] {
+			if !field.conditional {
+				schema.Required = append(schema.Required, key)
+				break
+			}
+		}
+
+		property, err := b.fieldSchema(typenames, fields[key], path+"/"+key)
+		if err != nil {
+			return nil, err
+		}
+		schema.Properties[key] = property
+	}
+	return schema, nil
+}
+
+// fieldSchema builds the schema for all selections of one response key
+func (b *responseBuilder) fieldSchema(typenames []string, selected []scopedField, path string) (*JSONSchema6, error) {
+	field, parent := selected[0].Field, selected[0].scope
+	if field.Name == "__typename" {
+		b.coordinates[path] = parent.Name + ".__typename"
+		schema := &JSONSchema6{Type: "string"}
+		if len(typenames) > 0 {
+			schema.Enum = typenames
+		}
+		return schema, nil
+	}
+
+	var definition *IntrospectionField
+	for _, f := range selected {
+		found := findField(f.scope.Fields, f.Name)
+		if found == nil {
+			names := make([]string, 0, len(f.scope.Fields))
+			for _, candidate := range f.scope.Fields {
+				names = append(names, candidate.Name)
+			}
+			return nil, fmt.Errorf("%s: %w", f.Position, &NotFoundError{
+				Kind:        "field",
+				Name:        f.scope.Name + "." + f.Name,
+				Suggestions: closeMatches(f.Name, names),
+			})
+		}
+		if definition == nil {
+			definition = found
+		}
+	}
+	b.coordinates[path] = parent.Name + "." + field.Name
+	if definition.IsDeprecated {
+		reason := ""
+		if definition.DeprecationReason != nil {
+			reason = *definition.DeprecationReason
+		}
+		b.opts.logger().Warn("Operation selects a deprecated field", LogKeyWarning, WarningDeprecatedField,
+			LogKeyType, parent.Name, LogKeyField, field.Name, "reason", reason, "position", field.Position.String())
+	}
+
+	// When a conditional selection is merged with others, its subselections are only present when
+	// its condition holds
+	mixed := false
+	for _, f := range selected[1:] {
+		mixed = mixed || f.conditional != selected[0].conditional || f.conditional
+	}
+	subselections := make([]Selection, 0)
+	for _, f := range selected {
+		if f.Name != field.Name {
+			return nil, fmt.Errorf("%s: %s and %s are both selected as %q", f.Position, field.Name, f.Name, f.ResponseKey())
+		}
+		subselections = append(subselections, f.SelectionSet...)
+		if mixed && f.conditional {
+			for _, selection := range f.SelectionSet {
+				b.guarded[selection] = true
+				defer delete(b.guarded, selection)
+			}
+		}
+	}
+
+	schema, err := b.typeSchema(definition.Type, field, subselections, path)
+	if err != nil {
+		return nil, err
+	}
+	if definition.Description != "" {
+		schema.Description = definition.Description
+	}
+	return schema, nil
+}
+
+// typeSchema builds the schema for a field's type, accepting null unless the type is non-null
+func (b *responseBuilder) typeSchema(typeRef IntrospectionTypeRef, field *Field, subselections []Selection, path string) (*JSONSchema6, error) {
+	if typeRef.Kind == "NON_NULL" {
+		if typeRef.OfType == nil {
+			return &JSONSchema6{}, nil
+		}
+		return b.namedOrListSchema(*typeRef.OfType, field, subselections, path)
+	}
+
+	schema, err := b.namedOrListSchema(typeRef, field, subselections, path)
+	if err != nil {
+		return nil, err
+	}
+	return nullableSchema(schema), nil
+}
+
+func (b *responseBuilder) namedOrListSchema(typeRef IntrospectionTypeRef, field *Field, subselections []Selection, path string) (*JSONSchema6, error) {
+	if typeRef.Kind == "LIST" {
+		schema := &JSONSchema6{Type: "array"}
+		if typeRef.OfType != nil {
+			items, err := b.typeSchema(*typeRef.OfType, field, subselections, path)
+			if err != nil {
+				return nil, err
+			}
+			schema.Items = items
+		}
+		return schema, nil
+	}
+
+	if typeRef.Name == nil {
+		return &JSONSchema6{}, nil
+	}
+	named := b.types[*typeRef.Name]
+	if named == nil {
+		return nil, fmt.Errorf("type %s not found", *typeRef.Name)
+	}
+
+	switch named.Kind {
+	case "SCALAR", "ENUM":
+		if len(subselections) > 0 {
+			return nil, fmt.Errorf("%s: field %s of type %s cannot have a selection set", field.Position, field.Name, named.Name)
+		}
+		if named.Kind == "SCALAR" {
+			return processScalar(named.Name, b.opts), nil
+		}
+		values := make([]string, 0, len(named.EnumValues))
+		for _, value := range named.EnumValues {
+			values = append(values, value.Name)
+		}
+		return &JSONSchema6{Type: "string", Enum: values}, nil
+	}
+
+	if len(subselections) == 0 {
+		return nil, fmt.Errorf("%s: field %s of type %s must have a selection set", field.Position, field.Name, named.Name)
+	}
+	return b.selectionSchema(named, subselections, path)
+}
+
+// nullableSchema returns a schema that also accepts null
+func nullableSchema(schema *JSONSchema6) *JSONSchema6 {
+	types := schemaTypes(schema.Type)
+	if len(types) > 0 && len(schema.Enum) == 0 {
+		schema.Type = append(append([]string{}, types...), "null")
+		return schema
+	}
+	return &JSONSchema6{AnyOf: []*JSONSchema6{schema, {Type: "null"}}}
+}
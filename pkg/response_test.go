@@ -0,0 +1,260 @@
+package pkg_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const responseSDL = `
+type Query { a: String! b: String! }
+`
+
+// responseKeys lists the properties and required keys of a response object schema
+func responseKeys(schema *pkg.JSONSchema6) (properties, required []string) {
+	for key := range schema.Properties {
+		properties = append(properties, key)
+	}
+	sort.Strings(properties)
+	required = append([]string{}, schema.Required...)
+	sort.Strings(required)
+	return properties, required
+}
+
+func TestResponseSchemaDirectives(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		// properties and required are the keys of the response object; b always stays selected
+		properties []string
+		required   []string
+		err        string
+	}{
+		{name: "skip true", query: `{ a @skip(if: true) b }`, variables: map[string]interface{}{},
+			properties: []string{"b"}, required: []string{"b"}},
+		{name: "skip false", query: `{ a @skip(if: false) b }`, variables: map[string]interface{}{},
+			properties: []string{"a", "b"}, required: []string{"a", "b"}},
+		{name: "include true", query: `{ a @include(if: true) b }`, variables: map[string]interface{}{},
+			properties: []string{"a", "b"}, required: []string{"a", "b"}},
+		{name: "include false", query: `{ a @include(if: false) b }`, variables: map[string]interface{}{},
+			properties: []string{"b"}, required: []string{"b"}},
+		{name: "literal without variables", query: `{ a @include(if: false) b }`,
+			properties: []string{"b"}, required: []string{"b"}},
+		{name: "provided variable skips", query: `query ($s: Boolean!) { a @skip(if: $s) b }`,
+			variables:  map[string]interface{}{"s": true},
+			properties: []string{"b"}, required: []string{"b"}},
+		{name: "provided variable includes", query: `query ($i: Boolean!) { a @include(if: $i) b }`,
+			variables:  map[string]interface{}{"i": true},
+			properties: []string{"a", "b"}, required: []string{"a", "b"}},
+		{name: "default value", query: `query ($i: Boolean = false) { a @include(if: $i) b }`,
+			variables:  map[string]interface{}{},
+			properties: []string{"b"}, required: []string{"b"}},
+		{name: "provided value overrides the default", query: `query ($i: Boolean = false) { a @include(if: $i) b }`,
+			variables:  map[string]interface{}{"i": true},
+			properties: []string{"a", "b"}, required: []string{"a", "b"}},
+		{name: "unknown variable", query: `query ($i: Boolean!) { a @include(if: $i) b }`,
+			properties: []string{"a", "b"}, required: []string{"b"}},
+		{name: "unknown variable on a fragment", query: `query ($s: Boolean!) { ... on Query @skip(if: $s) { a } b }`,
+			properties: []string{"a", "b"}, required: []string{"b"}},
+		{name: "excluded by either directive", query: `query ($i: Boolean!) { a @include(if: $i) @skip(if: true) b }`,
+			properties: []string{"b"}, required: []string{"b"}},
+		{name: "missing variable", query: `query ($i: Boolean!) { a @include(if: $i) b }`,
+			variables: map[string]interface{}{}, err: "@include: variable $i is not provided"},
+		{name: "non-Boolean variable", query: `query ($s: Boolean!) { a @skip(if: $s) b }`,
+			variables: map[string]interface{}{"s": "yes"}, err: "@skip: variable $s must be a Boolean, got string"},
+		{name: "non-Boolean default", query: `query ($s: Boolean = 1) { a @skip(if: $s) b }`,
+			variables: map[string]interface{}{}, err: "@skip: if must be a Boolean"},
+		{name: "non-Boolean literal", query: `{ a @skip(if: "true") b }`, err: "@skip: if must be a Boolean"},
+		{name: "no if argument", query: `{ a @include b }`, err: "directive @include requires an if argument"},
+	}
+
+	introspection := mustIntrospect(t, responseSDL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := pkg.ParseDocument(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := pkg.OperationResponseSchemaForVariables(introspection, doc, "", tt.variables, nil)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want it to mention %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OperationResponseSchemaForVariables: %v", err)
+			}
+			properties, required := responseKeys(response.Schema)
+			if !reflect.DeepEqual(properties, tt.properties) || !reflect.DeepEqual(required, tt.required) {
+				t.Errorf("properties %q required %q, want properties %q required %q", properties, required, tt.properties, tt.required)
+			}
+		})
+	}
+}
+
+func TestResponseSchemaConditionalField(t *testing.T) {
+	introspection := mustIntrospect(t, responseSDL)
+	doc, err := pkg.ParseDocument(`query ($i: Boolean!) { a @include(if: $i) b }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// OperationResponseSchema leaves variables unknown, as a nil map does
+	response, err := pkg.OperationResponseSchema(introspection, doc, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for instance, valid := range map[string]bool{
+		`{"a": "x", "b": "y"}`:  true,
+		`{"b": "y"}`:            true,
+		`{"a": "x"}`:            false,
+		`{"a": null, "b": "y"}`: false,
+	} {
+		if errs := validateJSON(t, response.Schema, response.Schema, instance); (len(errs) == 0) != valid {
+			t.Errorf("%s: valid = %v, want %v: %v", instance, len(errs) == 0, valid, errs)
+		}
+	}
+}
+
+func TestResponseSchemaFieldMerging(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		valid   []string
+		invalid []string
+		// required points to an object schema whose required keys must be wantKeys
+		required string
+		wantKeys []string
+	}{
+		{
+			name: "inline fragment and named spread",
+			query: `{ employee(id: 1) { ... on Employee { manager { id } } ...Manager } }
+fragment Manager on Employee { manager { name } }`,
+			valid: []string{
+				`{"employee": {"manager": {"id": "1", "name": "a"}}}`,
+				`{"employee": {"manager": null}}`,
+				`{"employee": null}`,
+			},
+			invalid: []string{
+				`{"employee": {"manager": {"id": "1"}}}`,
+				`{"employee": {"manager": {"name": "a"}}}`,
+			},
+			required: "/properties/employee/properties/manager",
+			wantKeys: []string{"id", "name"},
+		},
+		{
+			name:  "type conditions on an interface",
+			query: `{ node(id: 1) { id ... on Employee { name } ... on Photo { size } } }`,
+			valid: []string{
+				`{"node": {"id": "1", "name": "a"}}`,
+				`{"node": {"id": "1", "size": 3}}`,
+				`{"node": {"id": "1"}}`,
+			},
+			invalid: []string{
+				`{"node": {"id": "1", "name": "a", "size": 3}}`,
+				`{"node": {"id": "1", "size": "3"}}`,
+				`{"node": {"name": "a"}}`,
+			},
+		},
+		{
+			name:  "type conditions on a union",
+			query: `{ search(term: "a") { __typename ... on Node { id } ... on Photo { size } ... on Post { size } } }`,
+			valid: []string{
+				`{"search": [{"__typename": "Photo", "id": "1", "size": null}, {"__typename": "Post", "id": "2", "size": "big"}]}`,
+			},
+			invalid: []string{
+				`{"search": [{"__typename": "Post", "id": "2", "size": null}]}`,
+				`{"search": [{"__typename": "Post", "id": "2", "size": 3}]}`,
+				`{"search": [{"__typename": "Photo", "size": 3}]}`,
+				`{"search": [{"__typename": "Employee", "id": "1", "size": 3}]}`,
+			},
+		},
+		{
+			name: "interface fragment on an object",
+			query: `{ employee(id: 1) { ... on Node { id } ...Named } }
+fragment Named on Employee { id name }`,
+			valid:    []string{`{"employee": {"id": "1", "name": null}}`},
+			invalid:  []string{`{"employee": {"name": null}}`},
+			required: "/properties/employee",
+			wantKeys: []string{"id", "name"},
+		},
+	}
+
+	introspection := mustIntrospect(t, querySDL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := pkg.ParseDocument(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := pkg.OperationResponseSchema(introspection, doc, "", nil)
+			if err != nil {
+				t.Fatalf("OperationResponseSchema: %v", err)
+			}
+			for _, instance := range tt.valid {
+				if errs := validateJSON(t, response.Schema, response.Schema, instance); len(errs) > 0 {
+					t.Errorf("%s is rejected: %v", instance, errs)
+				}
+			}
+			for _, instance := range tt.invalid {
+				if errs := validateJSON(t, response.Schema, response.Schema, instance); len(errs) == 0 {
+					t.Errorf("%s is accepted", instance)
+				}
+			}
+			if tt.required != "" {
+				_, required := responseKeys(mustPointer(t, response.Schema, tt.required))
+				if !reflect.DeepEqual(required, tt.wantKeys) {
+					t.Errorf("%s requires %q, want %q", tt.required, required, tt.wantKeys)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "self spread", query: `{ employee(id: 1) { ...A } }
+fragment A on Employee { manager { ...A } }`, want: "fragment A spreads itself"},
+		{name: "indirect cycle", query: `{ employee(id: 1) { ...A } }
+fragment A on Employee { manager { ...B } }
+fragment B on Employee { reports { ...A } }`, want: "fragment A spreads itself"},
+		{name: "unused cycle", query: `{ employee(id: 1) { id } }
+fragment A on Employee { ...B }
+fragment B on Employee { ...A }`, want: "spreads itself"},
+		{name: "duplicate fragment", query: `{ employee(id: 1) { ...A } }
+fragment A on Employee { id }
+fragment A on Employee { name }`, want: "fragment A is defined more than once"},
+		{name: "unknown fragment", query: `{ employee(id: 1) { ...Missing } }`, want: "Missing"},
+		{name: "different fields for one key", query: `{ employee(id: 1) { x: id ... on Employee { x: name } } }`,
+			want: `id and name are both selected as "x"`},
+		{name: "impossible inline fragment", query: `{ employee(id: 1) { ... on Photo { size } } }`,
+			want: "inline fragment on Photo can never apply to Employee"},
+		{name: "impossible named fragment", query: `{ search(term: "a") { ...E } }
+fragment E on Employee { id }`, want: "fragment E on Employee can never apply to SearchResult"},
+		{name: "fragment on a scalar", query: `{ employee(id: 1) { ... on String { id } } }`,
+			want: "fragment cannot be on scalar type String"},
+		{name: "unknown field in a fragment", query: `{ node(id: 1) { ... on Photo { name } } }`, want: "Photo.name"},
+	}
+
+	introspection := mustIntrospect(t, querySDL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := pkg.ParseDocument(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = pkg.OperationResponseSchema(introspection, doc, "", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package pkg_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+	. "github.com/robert-cronin/gql2jsonschema-go/pkg/introspectiontest"
+)
+
+// rootsSchema has a type reached from each root only, and one reached from none
+func rootsSchema() pkg.IntrospectionQuery {
+	return NewSchema().
+		Query("Query", Field("user", Object("User"), Arg("id", NonNull(Scalar("ID"))))).
+		Mutation("Mutation", Field("createPost", Object("Post"), Arg("input", NonNull(InputObject("PostInput"))))).
+		Subscription("Subscription", Field("commented", Object("Comment"))).
+		Object("User", Field("id", NonNull(Scalar("ID"))), Field("name", Scalar("String"))).
+		Object("Post", Field("id", NonNull(Scalar("ID"))), Field("author", Object("User"))).
+		InputObject("PostInput", InputField("title", NonNull(Scalar("String")))).
+		Object("Comment", Field("text", NonNull(Scalar("String")))).
+		Object("Orphan", Field("value", Scalar("Int"))).
+		Build()
+}
+
+func TestRootSelection(t *testing.T) {
+	// Scalar definitions are only dropped by pruning, as no $ref reaches them
+	all := []string{"Boolean", "Comment", "ID", "Int", "Orphan", "Post", "PostInput", "String", "User"}
+	tests := []struct {
+		name        string
+		set         func(*pkg.Options)
+		properties  []string
+		definitions []string
+	}{
+		{"all roots", nil, []string{"Mutation", "Query", "Subscription"}, all},
+		{"query", func(o *pkg.Options) { o.Roots = []pkg.OperationType{pkg.OperationQuery} }, []string{"Query"}, all},
+		{"mutation and subscription", func(o *pkg.Options) {
+			o.Roots = []pkg.OperationType{pkg.OperationMutation, pkg.OperationSubscription}
+		}, []string{"Mutation", "Subscription"}, all},
+		{"pruned to all roots", func(o *pkg.Options) { o.PruneToRoots = true },
+			[]string{"Mutation", "Query", "Subscription"}, []string{"Comment", "Post", "PostInput", "User"}},
+		{"query pruned", func(o *pkg.Options) {
+			o.Roots = []pkg.OperationType{pkg.OperationQuery}
+			o.PruneToRoots = true
+		}, []string{"Query"}, []string{"User"}},
+		{"mutation pruned", func(o *pkg.Options) {
+			o.Roots = []pkg.OperationType{pkg.OperationMutation}
+			o.PruneToRoots = true
+		}, []string{"Mutation"}, []string{"Post", "PostInput", "User"}},
+		{"subscription pruned", func(o *pkg.Options) {
+			o.Roots = []pkg.OperationType{pkg.OperationSubscription}
+			o.PruneToRoots = true
+		}, []string{"Subscription"}, []string{"Comment"}},
+		{"definitions only", func(o *pkg.Options) { o.DefinitionsOnly = true }, nil, all},
+		{"definitions only for a pruned root", func(o *pkg.Options) {
+			o.DefinitionsOnly = true
+			o.Roots = []pkg.OperationType{pkg.OperationMutation}
+			o.PruneToRoots = true
+		}, nil, []string{"Post", "PostInput", "User"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := pkg.FromIntrospectionQuery(rootsSchema(), options(tt.set))
+			if err != nil {
+				t.Fatalf("FromIntrospectionQuery: %v", err)
+			}
+			if got := sortedNames(schema.Properties); !reflect.DeepEqual(got, tt.properties) {
+				t.Errorf("properties = %v, want %v", got, tt.properties)
+			}
+			if got := sortedNames(schema.Definitions); !reflect.DeepEqual(got, tt.definitions) {
+				t.Errorf("definitions = %v, want %v", got, tt.definitions)
+			}
+		})
+	}
+}
+
+// sortedNames lists the keys of a schema map, nil when it is empty
+func sortedNames(schemas map[string]*pkg.JSONSchema6) []string {
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
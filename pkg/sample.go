@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"path"
+	"strings"
+)
+
+// SampleOptions bounds the live values attached as examples
+type SampleOptions struct {
+	// MaxListItems is the number of items kept from each list; 0 keeps every item
+	MaxListItems int `json:"maxListItems"`
+	// Redact lists field name patterns, with * wildcards and case-insensitive, whose values are left out
+	Redact []string `json:"redact,omitempty"`
+}
+
+// DefaultSampleOptions returns the default sample options
+func DefaultSampleOptions() SampleOptions {
+	return SampleOptions{
+		MaxListItems: 3,
+		Redact:       []string{"*password*", "*secret*", "*token*"},
+	}
+}
+
+// SampleableFields returns the fields of the query type a sample query can select without
+// variables, which are those without required arguments. A non-empty allow list keeps only the
+// fields it names.
+func SampleableFields(introspection IntrospectionQuery, allow []string) []string {
+	if introspection.Schema.QueryType == nil {
+		return nil
+	}
+	queryType := newTypeIndex(introspection.Schema.Types)[introspection.Schema.QueryType.Name]
+	if queryType == nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(queryType.Fields))
+	for _, field := range queryType.Fields {
+		if strings.HasPrefix(field.Name, "__") || (len(allow) > 0 && !containsString(allow, field.Name)) {
+			continue
+		}
+		needsVariables := false
+		for _, arg := range field.Args {
+			needsVariables = needsVariables || (isRequired(arg.Type) && arg.DefaultValue == nil)
+		}
+		if !needsVariables {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
+
+// PrepareSample shortens the lists of a sampled value and leaves out the members whose names match a
+// redaction pattern, so that it can be published as an example
+func PrepareSample(value interface{}, opts SampleOptions) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		prepared := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			if !redacted(name, opts.Redact) {
+				prepared[name] = PrepareSample(member, opts)
+			}
+		}
+		return prepared
+	case []interface{}:
+		if opts.MaxListItems > 0 && len(v) > opts.MaxListItems {
+			v = v[:opts.MaxListItems]
+		}
+		prepared := make([]interface{}, len(v))
+		for i, item := range v {
+			prepared[i] = PrepareSample(item, opts)
+		}
+		return prepared
+	}
+	return value
+}
+
+func redacted(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachQueryExample adds a sampled value to the examples of the return schema of a query field,
+// found under the nested Query property or in the flat operations map, or of the field itself with
+// FieldShapePlain. It reports whether the field was found.
+func AttachQueryExample(schema *JSONSchema6, opts *Options, field string, value interface{}) bool {
+	var fieldSchema *JSONSchema6
+	if query := schema.Properties["Query"]; query != nil {
+		fieldSchema = query.Properties[field]
+	}
+	if fieldSchema == nil && opts != nil {
+		key := opts.OperationsKey
+		if key == "" {
+			key = DefaultOperationsKey
+		}
+		if operations := schema.Properties[key]; operations != nil {
+			fieldSchema = operations.Properties["Query."+field]
+		}
+	}
+	if fieldSchema == nil {
+		return false
+	}
+
+	returns := fieldSchema.Properties["return"]
+	if opts != nil && opts.FieldShape == FieldShapePlain {
+		returns = fieldSchema
+	}
+	if returns == nil {
+		return false
+	}
+	returns.Examples = append(returns.Examples, value)
+	return true
+}
@@ -0,0 +1,116 @@
+package pkg_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const sampleSDL = `
+type Query {
+  me: User
+  users(first: Int = 10): [User!]!
+  user(id: ID!): User
+  search(term: String): [User]
+}
+
+type User {
+  id: ID!
+  name: String
+  apiToken: String
+  profile: Profile
+}
+
+type Profile { bio: String, Password: String }
+`
+
+func TestSampleableFields(t *testing.T) {
+	introspection := mustIntrospect(t, sampleSDL)
+	tests := []struct {
+		name  string
+		allow []string
+		want  []string
+	}{
+		{"fields without required arguments", nil, []string{"me", "users", "search"}},
+		{"allow list", []string{"users", "user", "missing"}, []string{"users"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkg.SampleableFields(introspection, tt.allow); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SampleableFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareSample(t *testing.T) {
+	value := []interface{}{
+		map[string]interface{}{
+			"id":       "1",
+			"apiToken": "abc",
+			"profile":  map[string]interface{}{"bio": "hi", "Password": "hunter2"},
+		},
+		map[string]interface{}{"id": "2"},
+		map[string]interface{}{"id": "3"},
+	}
+	tests := []struct {
+		name string
+		opts pkg.SampleOptions
+		want interface{}
+	}{
+		{
+			name: "defaults",
+			opts: pkg.SampleOptions{MaxListItems: 2, Redact: pkg.DefaultSampleOptions().Redact},
+			want: []interface{}{
+				map[string]interface{}{"id": "1", "profile": map[string]interface{}{"bio": "hi"}},
+				map[string]interface{}{"id": "2"},
+			},
+		},
+		{
+			name: "no limits",
+			opts: pkg.SampleOptions{},
+			want: value,
+		},
+		{
+			name: "custom redaction",
+			opts: pkg.SampleOptions{MaxListItems: 1, Redact: []string{"PROFILE", "id"}},
+			want: []interface{}{map[string]interface{}{"apiToken": "abc"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkg.PrepareSample(value, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PrepareSample = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachQueryExample(t *testing.T) {
+	example := map[string]interface{}{"id": "1"}
+	tests := []struct {
+		name    string
+		set     func(*pkg.Options)
+		pointer string
+	}{
+		{"nested layout", nil, "/properties/Query/properties/me/properties/return"},
+		{"flat layout", func(o *pkg.Options) { o.OperationsLayout = pkg.OperationsLayoutFlat }, "/properties/operations/properties/Query.me/properties/return"},
+		{"plain fields", func(o *pkg.Options) { o.FieldShape = pkg.FieldShapePlain }, "/properties/Query/properties/me"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options(tt.set)
+			schema := mustConvert(t, sampleSDL, opts)
+			if !pkg.AttachQueryExample(schema, opts, "me", example) {
+				t.Fatal("the field was not found")
+			}
+			if got := mustPointer(t, schema, tt.pointer).Examples; !reflect.DeepEqual(got, []interface{}{example}) {
+				t.Errorf("examples = %v", got)
+			}
+			if pkg.AttachQueryExample(schema, opts, "missing", example) {
+				t.Error("an example was attached to a field that does not exist")
+			}
+		})
+	}
+}
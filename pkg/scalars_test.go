@@ -0,0 +1,131 @@
+package pkg_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const scalarsSDL = `
+scalar DateTime
+scalar Decimal
+scalar Upload
+
+type Query {
+  order(id: ID!, placedAfter: DateTime): Order
+}
+
+type Mutation { attach(file: Upload!, orderId: ID!): Order }
+
+type Order {
+  id: ID!
+  placed: DateTime!
+  total: Decimal
+  history: [DateTime!]
+}
+
+input OrderInput { total: Decimal! due: DateTime }
+`
+
+func TestCustomScalarSchemas(t *testing.T) {
+	schema := mustConvert(t, scalarsSDL, options(func(o *pkg.Options) {
+		o.CustomScalarSchemas = map[string]*pkg.JSONSchema6{
+			"DateTime": {Type: "string", Format: "date-time"},
+			"Decimal":  {Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?$`},
+			"ID":       {Type: "string", Format: "uuid"},
+		}
+	}))
+
+	tests := []struct {
+		pointer string
+		typ     string
+		format  string
+		pattern string
+	}{
+		{"#/definitions/DateTime", "string", "date-time", ""},
+		{"#/definitions/Order/properties/placed/properties/return", "string", "date-time", ""},
+		{"#/definitions/Order/properties/history/properties/return/items", "string", "date-time", ""},
+		{"#/definitions/OrderInput/properties/due", "string", "date-time", ""},
+		{"#/properties/Query/properties/order/properties/arguments/properties/placedAfter", "string", "date-time", ""},
+		{"#/definitions/Decimal", "string", "", `^-?[0-9]+(\.[0-9]+)?$`},
+		{"#/definitions/Order/properties/total/properties/return", "string", "", `^-?[0-9]+(\.[0-9]+)?$`},
+		{"#/definitions/OrderInput/properties/total", "string", "", `^-?[0-9]+(\.[0-9]+)?$`},
+		// The mapping wins over the built-in ID handling
+		{"#/definitions/Order/properties/id/properties/return", "string", "uuid", ""},
+		{"#/properties/Mutation/properties/attach/properties/arguments/properties/orderId", "string", "uuid", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			node := mustPointer(t, schema, tt.pointer)
+			if node.Type != tt.typ || node.Format != tt.format || node.Pattern != tt.pattern {
+				t.Errorf("got type %v, format %q, pattern %q; want %s, %q, %q", node.Type, node.Format, node.Pattern, tt.typ, tt.format, tt.pattern)
+			}
+		})
+	}
+
+	// Scalars keep their title, and unmapped custom scalars still accept anything
+	if title := mustPointer(t, schema, "#/definitions/Order/properties/placed/properties/return").Title; title != "DateTime" {
+		t.Errorf("title = %q, want DateTime", title)
+	}
+	if upload := mustPointer(t, schema, "#/properties/Mutation/properties/attach/properties/arguments/properties/file"); upload.Type != nil {
+		t.Errorf("unmapped Upload has type %v", upload.Type)
+	}
+
+	// Every usage gets its own copy of the mapping
+	mustPointer(t, schema, "#/definitions/OrderInput/properties/due").Format = "date"
+	if format := mustPointer(t, schema, "#/definitions/DateTime").Format; format != "date-time" {
+		t.Errorf("usages share the mapped schema: definition format %q", format)
+	}
+
+	input := mustPointer(t, schema, "#/definitions/OrderInput")
+	if errs := validateJSON(t, schema, input, `{"total": "12.50"}`); len(errs) > 0 {
+		t.Errorf("valid decimal rejected: %+v", errs)
+	}
+	if errs := validateJSON(t, schema, input, `{"total": "twelve"}`); len(errs) == 0 {
+		t.Error("the Decimal pattern is not applied")
+	}
+}
+
+// TestBuiltInScalarNodes checks that the nodes copied from the cached scalar schemas stay
+// independent: making one nullable or editing it changes no other usage, and the cache does not
+// outlive the conversion
+func TestBuiltInScalarNodes(t *testing.T) {
+	set := func(o *pkg.Options) {
+		o.IDTypeMapping = pkg.IDTypeBoth
+		o.UseIntegerType = true
+		o.IntBounds = true
+		o.NullableFields = pkg.NullableFieldsTypeArray
+	}
+	const sdl = `type Query { a(id: ID!, other: ID): Item b: Item }
+type Item { id: ID! parent: ID count: Int! total: Int }`
+	schema := mustConvert(t, sdl, options(set))
+
+	for pointer, want := range map[string]string{
+		"#/definitions/Item/properties/id/properties/return":                    `["string","number"]`,
+		"#/definitions/Item/properties/parent/properties/return":                `["string","number","null"]`,
+		"#/properties/Query/properties/a/properties/arguments/properties/id":    `["string","number"]`,
+		"#/properties/Query/properties/a/properties/arguments/properties/other": `["string","number","null"]`,
+		"#/definitions/Item/properties/count/properties/return":                 `"integer"`,
+		"#/definitions/Item/properties/total/properties/return":                 `["integer","null"]`,
+	} {
+		if got := string(mustJSON(t, mustPointer(t, schema, pointer).Type)); strings.Join(strings.Fields(got), "") != want {
+			t.Errorf("%s has type %s, want %s", pointer, got, want)
+		}
+	}
+
+	count := mustPointer(t, schema, "#/definitions/Item/properties/count/properties/return")
+	count.Title = "Edited"
+	*count.Minimum = 0
+	again := mustConvert(t, sdl, options(set))
+	for _, s := range []*pkg.JSONSchema6{schema, again} {
+		total := mustPointer(t, s, "#/definitions/Item/properties/total/properties/return")
+		if total.Title != "Int" {
+			t.Errorf("total has title %q", total.Title)
+		}
+	}
+	if minimum := mustPointer(t, again, "#/definitions/Item/properties/count/properties/return").Minimum; *minimum != math.MinInt32 {
+		t.Errorf("a later conversion has minimum %v", *minimum)
+	}
+}
@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Walk calls fn for the schema and every nested subschema, parents before children
+func Walk(schema *JSONSchema6, fn func(*JSONSchema6)) {
+	if schema == nil {
+		return
+	}
+
+	fn(schema)
+	for _, name := range sortedKeys(schema.Definitions) {
+		Walk(schema.Definitions[name], fn)
+	}
+	for _, name := range sortedKeys(schema.Defs) {
+		Walk(schema.Defs[name], fn)
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		Walk(schema.Properties[name], fn)
+	}
+	Walk(schema.Items, fn)
+	for _, branch := range schema.AnyOf {
+		Walk(branch, fn)
+	}
+	for _, branch := range schema.OneOf {
+		Walk(branch, fn)
+	}
+	for _, branch := range schema.AllOf {
+		Walk(branch, fn)
+	}
+}
+
+// cloneSchema returns a deep copy of a schema
+func cloneSchema(schema *JSONSchema6) *JSONSchema6 {
+	if schema == nil {
+		return nil
+	}
+
+	clone := *schema
+	if schema.Properties != nil {
+		clone.Properties = make(map[string]*JSONSchema6, len(schema.Properties))
+		for name, property := range schema.Properties {
+			clone.Properties[name] = cloneSchema(property)
+		}
+	}
+	if schema.Definitions != nil {
+		clone.Definitions = make(map[string]*JSONSchema6, len(schema.Definitions))
+		for name, definition := range schema.Definitions {
+			clone.Definitions[name] = cloneSchema(definition)
+		}
+	}
+	if schema.Defs != nil {
+		clone.Defs = make(map[string]*JSONSchema6, len(schema.Defs))
+		for name, definition := range schema.Defs {
+			clone.Defs[name] = cloneSchema(definition)
+		}
+	}
+	clone.Items = cloneSchema(schema.Items)
+	clone.AnyOf = cloneSchemas(schema.AnyOf)
+	clone.OneOf = cloneSchemas(schema.OneOf)
+	clone.AllOf = cloneSchemas(schema.AllOf)
+	if schema.Required != nil {
+		clone.Required = append([]string{}, schema.Required...)
+	}
+	if schema.Enum != nil {
+		clone.Enum = append([]string{}, schema.Enum...)
+	}
+	if schema.EnumDescriptions != nil {
+		clone.EnumDescriptions = append([]string{}, schema.EnumDescriptions...)
+	}
+	if schema.EnumDeprecated != nil {
+		clone.EnumDeprecated = make(map[string]string, len(schema.EnumDeprecated))
+		for value, reason := range schema.EnumDeprecated {
+			clone.EnumDeprecated[value] = reason
+		}
+	}
+	return &clone
+}
+
+func cloneSchemas(schemas []*JSONSchema6) []*JSONSchema6 {
+	if schemas == nil {
+		return nil
+	}
+	clones := make([]*JSONSchema6, len(schemas))
+	for i, schema := range schemas {
+		clones[i] = cloneSchema(schema)
+	}
+	return clones
+}
+
+func sortedKeys(m map[string]*JSONSchema6) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CanonicalJSON re-serializes a JSON document with sorted keys and no insignificant whitespace,
+// so that documents differing only in formatting compare equal
+func CanonicalJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+	return canonical, nil
+}
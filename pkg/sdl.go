@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxEmbeddedSDL is the size in bytes above which the SDL embedded by Options.EmbedSDL is truncated
+const maxEmbeddedSDL = 4096
+
+// defaultDeprecationReason is the reason @deprecated implies when none is given
+const defaultDeprecationReason = "No longer supported"
+
+// TypeSDL prints the GraphQL definition of an introspected type in SDL, with its fields, arguments,
+// default values and @deprecated directives but without descriptions. Introspection does not
+// expose other directives, so they are not printed.
+func TypeSDL(t IntrospectionType) string {
+	return typeSDL(t, 0)
+}
+
+// typeSDL prints a type like TypeSDL. With a positive limit, members (fields, input fields or enum
+// values) that would take the definition past limit bytes are replaced by a comment counting them,
+// so that the result is still valid SDL.
+func typeSDL(t IntrospectionType, limit int) string {
+	var header string
+	var members []string
+	switch t.Kind {
+	case "SCALAR":
+		return "scalar " + t.Name
+	case "UNION":
+		names := make([]string, len(t.PossibleTypes))
+		for i, possibleType := range t.PossibleTypes {
+			names[i] = possibleType.Name
+		}
+		if len(names) == 0 {
+			return "union " + t.Name
+		}
+		return "union " + t.Name + " = " + strings.Join(names, " | ")
+	case "OBJECT", "INTERFACE":
+		header = "type " + t.Name
+		if t.Kind == "INTERFACE" {
+			header = "interface " + t.Name
+		}
+		if len(t.Interfaces) > 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, iface := range t.Interfaces {
+				names[i] = iface.Name
+			}
+			header += " implements " + strings.Join(names, " & ")
+		}
+		for _, field := range t.Fields {
+			members = append(members, field.Name+argumentsSDL(field.Args)+": "+typeRefString(field.Type)+
+				deprecatedSDL(field.IsDeprecated, field.DeprecationReason))
+		}
+	case "INPUT_OBJECT":
+		header = "input " + t.Name
+		for _, field := range t.InputFields {
+			members = append(members, inputValueSDL(field))
+		}
+	case "ENUM":
+		header = "enum " + t.Name
+		for _, value := range t.EnumValues {
+			members = append(members, value.Name+deprecatedSDL(value.IsDeprecated, value.DeprecationReason))
+		}
+	default:
+		return ""
+	}
+
+	if len(members) == 0 {
+		return header
+	}
+
+	var b strings.Builder
+	b.WriteString(header + " {\n")
+	for i, member := range members {
+		line := "  " + member + "\n"
+		if limit > 0 && b.Len()+len(line)+len("}") > limit {
+			fmt.Fprintf(&b, "  # %d more truncated\n", len(members)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func argumentsSDL(args []IntrospectionArg) string {
+	if len(args) == 0 {
+		return ""
+	}
+	printed := make([]string, len(args))
+	for i, arg := range args {
+		printed[i] = inputValueSDL(IntrospectionInput(arg))
+	}
+	return "(" + strings.Join(printed, ", ") + ")"
+}
+
+// inputValueSDL prints an argument or input field; introspection returns default values already
+// printed as GraphQL literals
+func inputValueSDL(value IntrospectionInput) string {
+	printed := value.Name + ": " + typeRefString(value.Type)
+	if value.DefaultValue != nil {
+		printed += " = " + *value.DefaultValue
+	}
+	return printed + deprecatedSDL(value.IsDeprecated, value.DeprecationReason)
+}
+
+func deprecatedSDL(isDeprecated bool, reason *string) string {
+	if !isDeprecated {
+		return ""
+	}
+	if reason == nil || *reason == defaultDeprecationReason {
+		return " @deprecated"
+	}
+	// JSON string escapes are valid GraphQL string escapes
+	quoted, _ := json.Marshal(*reason)
+	return " @deprecated(reason: " + string(quoted) + ")"
+}
@@ -0,0 +1,156 @@
+package pkg_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const embedSDLFixture = `
+scalar DateTime
+
+type Query {
+  "Look a user up"
+  user(id: ID!, includeDrafts: Boolean = false): User
+  search(term: String!, first: Int = 10): [SearchResult!]!
+}
+
+interface Node { id: ID! }
+
+type User implements Node {
+  id: ID!
+  name: String!
+  joined: DateTime
+  email: String @deprecated
+  login: String @deprecated(reason: "Use \"name\" instead")
+  posts(status: Status = PUBLISHED, tags: [String!] = ["a", "b"]): [Post!]
+}
+
+type Post implements Node { id: ID! title: String }
+
+union SearchResult = User | Post
+
+enum Status { DRAFT PUBLISHED ARCHIVED @deprecated(reason: "Use DRAFT") }
+
+input UserFilter {
+  name: String = "ada"
+  status: [Status!]! = [PUBLISHED]
+  nested: UserFilter
+}
+`
+
+// embeddedSDL returns the $comment of every definition, keyed by name
+func embeddedSDL(t *testing.T, schema *pkg.JSONSchema6) map[string]string {
+	t.Helper()
+	comments := make(map[string]string)
+	for name, definition := range schema.Definitions {
+		if definition.Comment == "" {
+			t.Errorf("definition %s has no embedded SDL", name)
+		}
+		comments[name] = definition.Comment
+	}
+	return comments
+}
+
+func TestEmbedSDL(t *testing.T) {
+	schema := mustConvert(t, embedSDLFixture, options(func(o *pkg.Options) { o.EmbedSDL = true }))
+	comments := embeddedSDL(t, schema)
+
+	names := make([]string, 0, len(comments))
+	for name := range comments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var golden strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&golden, "%s\n\n", comments[name])
+	}
+	assertGolden(t, "embed-sdl.graphql", []byte(golden.String()))
+
+	// The embedded definitions parse, and converting them embeds the same text again. Root types
+	// are not definitions, so the document gets a query type of its own.
+	reparsed := mustConvert(t, golden.String()+"type Query { node(id: ID!): Node }",
+		options(func(o *pkg.Options) { o.EmbedSDL = true }))
+	for name, comment := range embeddedSDL(t, reparsed) {
+		if comments[name] != comment {
+			t.Errorf("%s embeds\n%s\nafter a round trip, want\n%s", name, comment, comments[name])
+		}
+	}
+}
+
+func TestEmbedSDLOffByDefault(t *testing.T) {
+	schema := mustConvert(t, embedSDLFixture, options(nil))
+	for name, definition := range schema.Definitions {
+		if definition.Comment != "" {
+			t.Errorf("definition %s has a $comment without EmbedSDL", name)
+		}
+	}
+}
+
+func TestEmbedSDLTruncation(t *testing.T) {
+	var sdl strings.Builder
+	sdl.WriteString("type Query { wide: Wide }\ntype Wide {\n")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&sdl, "  field%03d(limit: Int = 10): [String!]\n", i)
+	}
+	sdl.WriteString("}\n")
+
+	schema := mustConvert(t, sdl.String(), options(func(o *pkg.Options) { o.EmbedSDL = true }))
+	comment := schema.Definitions["Wide"].Comment
+	if len(comment) > 4096 {
+		t.Errorf("embedded SDL is %d bytes, over the cap", len(comment))
+	}
+	if !strings.Contains(comment, " more truncated\n}") {
+		t.Errorf("embedded SDL has no truncation marker:\n%s", comment[len(comment)-200:])
+	}
+	if _, err := pkg.IntrospectionFromSDL(comment + "\ntype Query { wide: Wide }"); err != nil {
+		t.Errorf("the truncated SDL does not parse: %v", err)
+	}
+
+	if full := pkg.TypeSDL(mustIntrospectType(t, sdl.String(), "Wide")); strings.Count(full, "\n") != 501 {
+		t.Errorf("TypeSDL truncated the definition")
+	}
+}
+
+// mustIntrospectType returns one type of an SDL fixture's introspection
+func mustIntrospectType(t *testing.T, sdl, name string) pkg.IntrospectionType {
+	t.Helper()
+	for _, typ := range mustIntrospect(t, sdl).Schema.Types {
+		if typ.Name == name {
+			return typ
+		}
+	}
+	t.Fatalf("no type %s", name)
+	return pkg.IntrospectionType{}
+}
+
+func TestTypeSDL(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"DateTime", "scalar DateTime"},
+		{"SearchResult", "union SearchResult = User | Post"},
+		{"Node", "interface Node {\n  id: ID!\n}"},
+		{"Status", "enum Status {\n  DRAFT\n  PUBLISHED\n  ARCHIVED @deprecated(reason: \"Use DRAFT\")\n}"},
+		{"UserFilter", "input UserFilter {\n  name: String = \"ada\"\n  status: [Status!]! = [PUBLISHED]\n  nested: UserFilter\n}"},
+		{"User", `type User implements Node {
+  id: ID!
+  name: String!
+  joined: DateTime
+  email: String @deprecated
+  login: String @deprecated(reason: "Use \"name\" instead")
+  posts(status: Status = PUBLISHED, tags: [String!] = ["a", "b"]): [Post!]
+}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkg.TypeSDL(mustIntrospectType(t, embedSDLFixture, tt.name)); got != tt.want {
+				t.Errorf("TypeSDL =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SizeReport breaks the size of a generated document down by definition and by what the bytes are
+// spent on. Sizes are of the indented encoding the CLI writes.
+type SizeReport struct {
+	TotalBytes int `json:"totalBytes"`
+	// Definitions are sorted by size, largest first. Each is serialized on its own, so the sizes
+	// leave out the indentation it has when nested in the document.
+	Definitions []DefinitionSize `json:"definitions"`
+	// Categories are how many bytes the document would lose without each feature. They overlap, so
+	// they need not add up to the total.
+	Categories []SizeCategory `json:"categories"`
+}
+
+// DefinitionSize is the serialized size of a single definition
+type DefinitionSize struct {
+	Name    string  `json:"name"`
+	Bytes   int     `json:"bytes"`
+	Percent float64 `json:"percent"`
+}
+
+// SizeCategory is the size of one kind of content across the document, measured by serializing
+// the document again with that content stripped. Option names the option that reduces it, if any.
+type SizeCategory struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Bytes       int     `json:"bytes"`
+	Percent     float64 `json:"percent"`
+	Option      string  `json:"option,omitempty"`
+}
+
+// sizeCategories strip one kind of content from a decoded schema node. They only see schema
+// nodes, never the maps of properties or definitions, so property names are not mistaken for keywords.
+var sizeCategories = []struct {
+	name, description, option string
+	strip                     func(node map[string]interface{}) map[string]interface{}
+}{
+	{"descriptions", "description keywords", "", func(node map[string]interface{}) map[string]interface{} {
+		delete(node, "description")
+		return node
+	}},
+	{"argument-wrappers", "the {arguments, return} objects wrapping each field's return schema", "", func(node map[string]interface{}) map[string]interface{} {
+		properties, _ := node["properties"].(map[string]interface{})
+		if len(properties) != 2 || properties["arguments"] == nil || properties["return"] == nil {
+			return node
+		}
+		if returns, ok := properties["return"].(map[string]interface{}); ok {
+			return returns
+		}
+		return node
+	}},
+	{"scalar-boilerplate", "titles naming the GraphQL scalar of leaf schemas", "", func(node map[string]interface{}) map[string]interface{} {
+		if _, ok := node["type"].(string); ok && node["properties"] == nil && node["items"] == nil {
+			delete(node, "title")
+		}
+		return node
+	}},
+	{"empty-schema-keywords", "empty \"$schema\" keywords on subschemas", "", func(node map[string]interface{}) map[string]interface{} {
+		if node["$schema"] == "" {
+			delete(node, "$schema")
+		}
+		return node
+	}},
+	{"examples", "examples keywords", "--sample-examples, --extract-examples", func(node map[string]interface{}) map[string]interface{} {
+		delete(node, "examples")
+		return node
+	}},
+	{"embedded-sdl", "$comment keywords holding type SDL", "--embed-sdl", func(node map[string]interface{}) map[string]interface{} {
+		delete(node, "$comment")
+		return node
+	}},
+}
+
+// NewSizeReport measures a generated document
+func NewSizeReport(schema *JSONSchema6) (SizeReport, error) {
+	encoded, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return SizeReport{}, fmt.Errorf("error marshaling schema: %w", err)
+	}
+	total := len(encoded)
+	report := SizeReport{
+		TotalBytes:  total,
+		Definitions: make([]DefinitionSize, 0, len(schema.Definitions)),
+		Categories:  make([]SizeCategory, 0, len(sizeCategories)+1),
+	}
+
+	for name, definition := range schema.Definitions {
+		size, err := indentedSize(definition)
+		if err != nil {
+			return SizeReport{}, err
+		}
+		report.Definitions = append(report.Definitions, DefinitionSize{Name: name, Bytes: size, Percent: percentOf(size, total)})
+	}
+	sort.Slice(report.Definitions, func(i, j int) bool {
+		if report.Definitions[i].Bytes != report.Definitions[j].Bytes {
+			return report.Definitions[i].Bytes > report.Definitions[j].Bytes
+		}
+		return report.Definitions[i].Name < report.Definitions[j].Name
+	})
+
+	// Object members keep their size whatever their order, so the decoded document can be measured
+	// in place of the struct, and can lose keywords the struct always writes
+	for _, category := range sizeCategories {
+		var doc interface{}
+		if err := json.Unmarshal(encoded, &doc); err != nil {
+			return SizeReport{}, fmt.Errorf("error decoding schema: %w", err)
+		}
+		stripped, err := indentedSize(stripSchemaNodes(doc, category.strip))
+		if err != nil {
+			return SizeReport{}, err
+		}
+		report.Categories = append(report.Categories, SizeCategory{
+			Name:        category.name,
+			Description: category.description,
+			Bytes:       total - stripped,
+			Percent:     percentOf(total-stripped, total),
+			Option:      category.option,
+		})
+	}
+
+	deduped := cloneSchema(schema)
+	if _, err := DedupeSubschemas(deduped, 0); err != nil {
+		return SizeReport{}, err
+	}
+	dedupedSize, err := indentedSize(deduped)
+	if err != nil {
+		return SizeReport{}, err
+	}
+	report.Categories = append(report.Categories, SizeCategory{
+		Name:        "duplicates",
+		Description: "repeated subschemas that could be shared definitions",
+		Bytes:       total - dedupedSize,
+		Percent:     percentOf(total-dedupedSize, total),
+		Option:      "--dedupe",
+	})
+
+	return report, nil
+}
+
+// stripSchemaNodes applies strip to every schema node of a decoded document, children first
+func stripSchemaNodes(value interface{}, strip func(map[string]interface{}) map[string]interface{}) interface{} {
+	node, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for _, keyword := range []string{"properties", "definitions"} {
+		if members, ok := node[keyword].(map[string]interface{}); ok {
+			for name, member := range members {
+				members[name] = stripSchemaNodes(member, strip)
+			}
+		}
+	}
+	if items, ok := node["items"]; ok {
+		node["items"] = stripSchemaNodes(items, strip)
+	}
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf"} {
+		if branches, ok := node[keyword].([]interface{}); ok {
+			for i, branch := range branches {
+				branches[i] = stripSchemaNodes(branch, strip)
+			}
+		}
+	}
+	return strip(node)
+}
+
+func indentedSize(v interface{}) (int, error) {
+	encoded, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling schema: %w", err)
+	}
+	return len(encoded), nil
+}
+
+// percentOf returns part as a percentage of total, to one decimal place
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
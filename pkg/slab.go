@@ -0,0 +1,68 @@
+package pkg
+
+// schemaSlab hands out schema nodes from a single preallocated block. A definition is made of many
+// small nodes that all live exactly as long as the definition, so carving them from one block saves
+// an allocation per node. A nil or exhausted slab falls back to allocating nodes individually.
+type schemaSlab struct {
+	nodes []JSONSchema6
+}
+
+func newSchemaSlab(size int) *schemaSlab {
+	return &schemaSlab{nodes: make([]JSONSchema6, size)}
+}
+
+// next returns a zeroed schema node
+func (s *schemaSlab) next() *JSONSchema6 {
+	if s == nil || len(s.nodes) == 0 {
+		return &JSONSchema6{}
+	}
+	node := &s.nodes[0]
+	s.nodes = s.nodes[1:]
+	return node
+}
+
+// typeNodeCount returns the number of schema nodes processType creates for t
+func typeNodeCount(t IntrospectionType) int {
+	count := 1
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		// The allOf references to the implemented interfaces with Options.InterfaceAllOf and the
+		// references to the implementations with Options.InterfaceImplementations
+		count += len(t.Interfaces) + len(t.PossibleTypes)
+		for _, field := range t.Fields {
+			// The field schema and its arguments object
+			count += 2 + typeRefNodeCount(field.Type)
+			for _, arg := range field.Args {
+				count += typeRefNodeCount(arg.Type)
+			}
+		}
+	case "INPUT_OBJECT":
+		for _, field := range t.InputFields {
+			count += typeRefNodeCount(field.Type)
+		}
+	case "ENUM":
+		count += len(t.EnumValues)
+	case "UNION":
+		count += len(t.PossibleTypes)
+	}
+	return count
+}
+
+// typeRefNodeCount returns the number of schema nodes processTypeRef creates for a type reference,
+// not counting the wrappers added for nullable array items
+func typeRefNodeCount(typeRef IntrospectionTypeRef) int {
+	count := 0
+	for {
+		switch typeRef.Kind {
+		case "NON_NULL":
+		case "LIST":
+			count++
+		default:
+			return count + 1
+		}
+		if typeRef.OfType == nil {
+			return count + 1
+		}
+		typeRef = *typeRef.OfType
+	}
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// specifiedByRFCs maps the RFCs custom scalars commonly point their specifiedByURL at to the
+// JSON Schema format of their values
+var specifiedByRFCs = map[string]string{
+	"1034": "hostname",
+	"3339": "date-time",
+	"3986": "uri",
+	"3987": "iri",
+	"4122": "uuid",
+	"4291": "ipv6",
+	"5322": "email",
+	"6531": "idn-email",
+	"6570": "uri-template",
+	"6901": "json-pointer",
+	"9562": "uuid",
+}
+
+// specifiedByScalars maps the scalar specifications of scalars.graphql.org to formats, keyed by
+// their path
+var specifiedByScalars = map[string]string{
+	"andimarek/date-time":  "date-time",
+	"andimarek/local-date": "date",
+}
+
+// rfcPattern finds the RFC number in the URLs of the IETF and the RFC Editor, such as
+// https://tools.ietf.org/html/rfc3339 or https://www.rfc-editor.org/rfc/rfc4122.html
+var rfcPattern = regexp.MustCompile(`(?i)^https?://(?:tools\.ietf\.org|datatracker\.ietf\.org|(?:www\.)?rfc-editor\.org|(?:www\.)?ietf\.org)/.*\brfc-?(\d+)\b`)
+
+// specifiedByFormat returns the format of the values of a scalar specified by the URL, or "" when
+// the specification is not known
+func specifiedByFormat(url string) string {
+	if match := rfcPattern.FindStringSubmatch(url); match != nil {
+		return specifiedByRFCs[strings.TrimLeft(match[1], "0")]
+	}
+	if path, ok := strings.CutPrefix(url, "https://scalars.graphql.org/"); ok {
+		path, _, _ = strings.Cut(path, "#")
+		return specifiedByScalars[strings.TrimSuffix(path, "/")]
+	}
+	return ""
+}
+
+// withSpecifiedBy returns the options with the specifiedByURL of the custom scalars among types,
+// or opts itself when none declares one
+func withSpecifiedBy(types []IntrospectionType, opts *Options) *Options {
+	var specifiedBy map[string]string
+	for _, t := range types {
+		if t.Kind == "SCALAR" && t.SpecifiedByURL != "" {
+			if specifiedBy == nil {
+				specifiedBy = make(map[string]string)
+			}
+			specifiedBy[t.Name] = t.SpecifiedByURL
+		}
+	}
+	if specifiedBy == nil {
+		return opts
+	}
+	resolved := *opts
+	resolved.specifiedBy = specifiedBy
+	return &resolved
+}
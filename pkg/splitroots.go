@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// rootOperations maps the root properties of the nested layout to their operation names
+var rootOperations = map[string]OperationType{
+	"Query":        OperationQuery,
+	"Mutation":     OperationMutation,
+	"Subscription": OperationSubscription,
+}
+
+// SplitRoots splits a document with the nested operations layout into one self-contained document
+// per root operation type, keyed by operation: "query", "mutation" and "subscription". Each holds
+// its root property and a copy of only the definitions reachable from it, so the documents share
+// no subschemas and every $ref resolves within its own document. Root properties are recognized by
+// their x-graphql-type when set, and by name otherwise.
+func SplitRoots(schema *JSONSchema6) (map[string]*JSONSchema6, error) {
+	if len(schema.Properties) == 0 {
+		return nil, fmt.Errorf("cannot split roots: the document has no root properties")
+	}
+
+	root := *schema
+	root.Properties, root.Definitions, root.Required = nil, nil, nil
+
+	documents := make(map[string]*JSONSchema6)
+	var unknown []string
+	for _, name := range sortedKeys(schema.Properties) {
+		property := schema.Properties[name]
+		typeName := name
+		if property != nil && property.GraphQLType != "" {
+			typeName = property.GraphQLType
+		}
+		operation, ok := rootOperations[typeName]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		document := cloneSchema(&root)
+		document.Properties = map[string]*JSONSchema6{name: cloneSchema(property)}
+		for _, required := range schema.Required {
+			if required == name {
+				document.Required = []string{name}
+			}
+		}
+		reachable := reachableDefinitions(document, schema.Definitions)
+		if len(reachable) > 0 {
+			document.Definitions = make(map[string]*JSONSchema6, len(reachable))
+			for definition := range reachable {
+				document.Definitions[definition] = cloneSchema(schema.Definitions[definition])
+			}
+		}
+		documents[string(operation)] = document
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("cannot split roots: not a root operation type: %s; use the nested operations layout",
+			strings.Join(unknown, ", "))
+	}
+	return documents, nil
+}
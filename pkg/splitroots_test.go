@@ -0,0 +1,135 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+func TestSplitRoots(t *testing.T) {
+	schema, err := pkg.FromIntrospectionQuery(rootsSchema(), nil)
+	if err != nil {
+		t.Fatalf("FromIntrospectionQuery: %v", err)
+	}
+	documents, err := pkg.SplitRoots(schema)
+	if err != nil {
+		t.Fatalf("SplitRoots: %v", err)
+	}
+
+	tests := []struct {
+		operation   string
+		property    string
+		definitions []string
+		valid       string
+		invalid     string
+	}{
+		{
+			operation:   "query",
+			property:    "Query",
+			definitions: []string{"User"},
+			valid:       `{"Query": {"user": {"arguments": {"id": "1"}, "return": {"id": {"return": "1"}, "name": {"return": "Ada"}}}}}`,
+			invalid:     `{"Query": {"user": {"arguments": {"id": "1"}, "return": {"id": {"return": "1"}, "name": {"return": 42}}}}}`,
+		},
+		{
+			operation:   "mutation",
+			property:    "Mutation",
+			definitions: []string{"Post", "PostInput", "User"},
+			valid:       `{"Mutation": {"createPost": {"arguments": {"input": {"title": "Hi"}}, "return": {"id": {"return": "1"}, "author": {"return": {"id": {"return": "2"}}}}}}}`,
+			invalid:     `{"Mutation": {"createPost": {"arguments": {"input": {}}, "return": null}}}`,
+		},
+		{
+			operation:   "subscription",
+			property:    "Subscription",
+			definitions: []string{"Comment"},
+			valid:       `{"Subscription": {"commented": {"return": {"text": {"return": "Nice"}}}}}`,
+			invalid:     `{"Subscription": {"commented": {"return": {"text": {"return": null}}}}}`,
+		},
+	}
+
+	if len(documents) != len(tests) {
+		t.Errorf("%d documents, want %d", len(documents), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			document := documents[tt.operation]
+			if document == nil {
+				t.Fatalf("no %s document", tt.operation)
+			}
+			if properties := sortedNames(document.Properties); !reflect.DeepEqual(properties, []string{tt.property}) {
+				t.Errorf("properties = %v, want [%s]", properties, tt.property)
+			}
+			if definitions := sortedNames(document.Definitions); !reflect.DeepEqual(definitions, tt.definitions) {
+				t.Errorf("definitions = %v, want %v", definitions, tt.definitions)
+			}
+
+			// Each document is read on its own, so the refs must resolve after a round trip
+			var standalone pkg.JSONSchema6
+			if err := json.Unmarshal(mustJSON(t, document), &standalone); err != nil {
+				t.Fatal(err)
+			}
+			if dangling := danglingRefs(&standalone); len(dangling) > 0 {
+				t.Errorf("refs do not resolve within the document: %v", dangling)
+			}
+			if errs := validateJSON(t, &standalone, &standalone, tt.valid); len(errs) > 0 {
+				t.Errorf("valid instance rejected: %+v", errs)
+			}
+			errs := validateJSON(t, &standalone, &standalone, tt.invalid)
+			if len(errs) == 0 {
+				t.Error("invalid instance accepted")
+			}
+			for _, e := range errs {
+				if strings.Contains(e.Message, "$ref") {
+					t.Errorf("unresolved reference while validating: %+v", e)
+				}
+			}
+		})
+	}
+
+	// The documents share no subschemas, so changing one leaves the others and the input alone
+	documents["query"].Definitions["User"].Description = "changed"
+	if documents["mutation"].Definitions["User"].Description == "changed" || schema.Definitions["User"].Description == "changed" {
+		t.Error("the documents share the User definition")
+	}
+}
+
+func TestSplitRootsRequiresNestedLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *pkg.JSONSchema6
+		want   string
+	}{
+		{"no roots", &pkg.JSONSchema6{}, "no root properties"},
+		{"unknown root", &pkg.JSONSchema6{Properties: map[string]*pkg.JSONSchema6{
+			"Query": {Type: "object"},
+			"user":  {Type: "object"},
+		}}, "not a root operation type: user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pkg.SplitRoots(tt.schema)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRootsByGraphQLType(t *testing.T) {
+	sdl := "schema { query: RootQuery }\n\ntype RootQuery { ping: Boolean }\n"
+	documents, err := pkg.SplitRoots(mustConvert(t, sdl, nil))
+	if err != nil {
+		t.Fatalf("SplitRoots: %v", err)
+	}
+	names := make([]string, 0, len(documents))
+	for operation := range documents {
+		names = append(names, operation)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"query"}) {
+		t.Errorf("operations = %v, want [query]", names)
+	}
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import "strings"
+
+// SubscriptionPayloads returns the schema of a single event of each subscription field, keyed by
+// field name, and a combined document accepting the events of any of them. An event is the
+// envelope the transport delivers, {"data": {"<field>": <value>}}, and values have the shape of
+// response data: object fields hold their values directly and nullable fields may be null. Each
+// schema is self-contained, carrying only the definitions it references. Without a subscription
+// type both results are empty.
+func SubscriptionPayloads(introspection IntrospectionQuery, opts *Options) (map[string]*JSONSchema6, *JSONSchema6, error) {
+	introspection, types, opts, err := prepareConversion(introspection, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataShape := *opts
+	dataShape.FieldShape = FieldShapePlain
+	opts = &dataShape
+
+	payloads := make(map[string]*JSONSchema6)
+	combined := &JSONSchema6{Schema: opts.Draft.schemaURI()}
+	root := introspection.Schema.SubscriptionType
+	if root == nil || types[root.Name] == nil {
+		return payloads, combined, nil
+	}
+	subscriptionType := types[root.Name]
+
+	envelopes := make(map[string]*JSONSchema6, len(subscriptionType.Fields))
+	used := make(map[string]bool)
+	for _, field := range subscriptionType.Fields {
+		if strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+		envelopes[field.Name] = subscriptionEnvelope(subscriptionType.Name, field, opts)
+		collectTypeRefDefinitions(field.Type, used)
+	}
+
+	used = referencedTypes(types, used, opts)
+	definitions, err := convertDefinitions(introspection.Schema.Types, opts, func(name string) bool {
+		return used[name]
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Each payload is extracted from one document so that it keeps only the definitions it reaches
+	document := &JSONSchema6{Schema: opts.Draft.schemaURI(), Properties: envelopes, Definitions: definitions}
+	for _, name := range sortedKeys(envelopes) {
+		payload, err := ExtractSchema(document, "/properties/"+escapePointerSegment(name))
+		if err != nil {
+			return nil, nil, err
+		}
+		finishDocument(payload, opts)
+		payloads[name] = payload
+		combined.OneOf = append(combined.OneOf, envelopes[name])
+	}
+	if len(definitions) > 0 {
+		combined.Definitions = definitions
+	}
+	finishDocument(combined, opts)
+	return payloads, combined, nil
+}
+
+// subscriptionEnvelope returns the schema of an event of a subscription field
+func subscriptionEnvelope(typeName string, field IntrospectionField, opts *Options) *JSONSchema6 {
+	return &JSONSchema6{
+		Type: "object",
+		Properties: map[string]*JSONSchema6{
+			"data": {
+				Type:       "object",
+				Properties: map[string]*JSONSchema6{field.Name: processFieldValue(nil, typeName, field, opts)},
+				Required:   []string{field.Name},
+			},
+		},
+		Required: []string{"data"},
+	}
+}
@@ -0,0 +1,126 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const subscriptionSDL = `
+scalar DateTime
+
+type Query { order(id: ID!): Order }
+
+type Subscription {
+  orderUpdated(id: ID!): Order!
+  lineAdded(order: ID!): [OrderLine!]
+}
+
+type Order {
+  id: ID!
+  status: OrderStatus!
+  total: Float!
+  placedAt: DateTime
+  customer: Customer!
+  lines: [OrderLine!]!
+  tracking: String
+}
+
+type Customer { id: ID! name: String! email: String }
+
+type OrderLine { sku: String! quantity: Int! note: String }
+
+type Product { sku: String! }
+
+enum OrderStatus { PENDING SHIPPED DELIVERED }
+`
+
+// nextMessage is a graphql-ws "next" message, whose payload is a subscription event
+type nextMessage struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func TestSubscriptionPayloads(t *testing.T) {
+	payloads, combined, err := pkg.SubscriptionPayloads(mustIntrospect(t, subscriptionSDL), nil)
+	if err != nil {
+		t.Fatalf("SubscriptionPayloads: %v", err)
+	}
+	if got := sortedNames(payloads); !reflect.DeepEqual(got, []string{"lineAdded", "orderUpdated"}) {
+		t.Fatalf("payloads = %v", got)
+	}
+	orderUpdated := payloads["orderUpdated"]
+	if got, want := definitionNames(orderUpdated), map[string]bool{"Order": true, "Customer": true, "OrderLine": true, "OrderStatus": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orderUpdated definitions = %v, want %v", got, want)
+	}
+	for name, schema := range map[string]*pkg.JSONSchema6{"orderUpdated": orderUpdated, "combined": combined} {
+		if dangling := danglingRefs(schema); len(dangling) > 0 {
+			t.Errorf("%s has dangling refs: %q", name, dangling)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", "subscription", "next.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message nextMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != "next" {
+		t.Fatalf("fixture is a %q message", message.Type)
+	}
+	event := string(message.Payload)
+	for name, schema := range map[string]*pkg.JSONSchema6{"orderUpdated": orderUpdated, "combined": combined} {
+		if errs := validateJSON(t, schema, schema, event); len(errs) > 0 {
+			t.Errorf("the fixture event does not validate against %s: %v", name, errs)
+		}
+	}
+
+	// Break the event one way at a time
+	tests := []struct {
+		name, from, to string
+	}{
+		{"non-null field is null", `"customer": {`, `"customer": null, "x": {`},
+		{"unknown enum value", `"SHIPPED"`, `"LOST"`},
+		{"missing required field", `"total": 129.5,`, ``},
+		{"non-null list item is null", `{"sku": "BOOK-1", "quantity": 2, "note": null}`, `null`},
+		{"wrong scalar type", `"quantity": 1`, `"quantity": "1"`},
+		{"other field's data", `"orderUpdated"`, `"orderCreated"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broken := strings.Replace(event, tt.from, tt.to, 1)
+			if broken == event {
+				t.Fatalf("%q is not in the fixture", tt.from)
+			}
+			if errs := validateJSON(t, orderUpdated, orderUpdated, broken); len(errs) == 0 {
+				t.Errorf("the broken event validates against orderUpdated:\n%s", broken)
+			}
+		})
+	}
+
+	// The combined document accepts an event of any subscription field
+	if errs := validateJSON(t, combined, combined, `{"data": {"lineAdded": [{"sku": "A", "quantity": 1, "note": null}]}}`); len(errs) > 0 {
+		t.Errorf("a lineAdded event does not validate against the combined document: %v", errs)
+	}
+	if errs := validateJSON(t, combined, combined, `{"data": {"lineAdded": [{"sku": "A"}]}}`); len(errs) == 0 {
+		t.Error("an incomplete lineAdded event validates against the combined document")
+	}
+}
+
+func TestSubscriptionPayloadsWithoutSubscriptions(t *testing.T) {
+	payloads, combined, err := pkg.SubscriptionPayloads(mustIntrospect(t, "type Query { a: String }"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) != 0 || len(combined.OneOf) != 0 || len(combined.Definitions) != 0 {
+		t.Errorf("payloads = %v, combined = %+v, want both empty", payloads, combined)
+	}
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions limits how many close matches are offered for an unknown name
+const maxSuggestions = 5
+
+// closeMatches returns the candidates closest to name by edit distance, ignoring case
+func closeMatches(name string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	lowerName := strings.ToLower(name)
+	threshold := len(name) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	matches := make([]match, 0)
+	for _, candidate := range candidates {
+		lowerCandidate := strings.ToLower(candidate)
+		distance := levenshtein(lowerName, lowerCandidate)
+		if distance <= threshold || strings.Contains(lowerCandidate, lowerName) {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	result := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		result = append(result, matches[i].name)
+	}
+	return result
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,40 @@
+package schema
+
+// An instant, as an RFC 3339 string.
+// Always in UTC.
+#DateTime: _
+
+#Node: #User | #Post
+
+#Post: {
+	id: string
+	title: string
+	author?: #User | null
+	score?: number | null
+}
+
+#Role: "ADMIN" | "MEMBER"
+
+#SearchResult: #User | #Post
+
+// A person using the service
+#User: {
+	id: string
+	// Display name
+	name: string
+	email?: string | null
+	joined?: #DateTime | null
+	role: #Role
+	friends?: [...#User] | null
+	tags: [...(string | null)]
+	"if"?: bool | null
+	"_private"?: int32 | null
+	"package"?: string | null
+}
+
+#UserFilter: {
+	role?: #Role | null
+	limit?: int32
+	names?: [...string] | null
+	nested?: #UserFilter | null
+}
@@ -0,0 +1,20 @@
+// A person using the service
+#User: {
+	id: (string | int)
+	// Display name
+	name: string
+	email?: string | null
+	joined?: #DateTime | null
+	role: #Role
+	friends?: [...#User] | null
+	tags: [...(string | null)]
+	"if"?: bool | null
+	"_private"?: int32 | null
+	"package"?: string | null
+}
+
+// An instant, as an RFC 3339 string.
+// Always in UTC.
+#DateTime: string
+
+#Role: "ADMIN" | "MEMBER"
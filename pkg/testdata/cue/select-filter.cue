@@ -0,0 +1,8 @@
+#UserFilter: {
+	role?: #Role | null
+	limit?: int32
+	names?: [...string] | null
+	nested?: #UserFilter | null
+}
+
+#Role: "ADMIN" | "MEMBER"
@@ -0,0 +1,47 @@
+"""Pydantic models generated from a GraphQL schema by gql2jsonschema."""
+
+from __future__ import annotations
+
+from enum import Enum
+from typing import Annotated, Any, List, Literal, Optional, Union
+
+from pydantic import BaseModel, ConfigDict, Field
+
+# An instant, as an RFC 3339 string
+DateTime = datetime.datetime
+
+
+class Role(str, Enum):
+    # Full access
+    ADMIN = "ADMIN"
+    MEMBER = "MEMBER"
+    None_ = "None"
+
+
+class User(BaseModel):
+    """A person using the service"""
+
+    id: str
+    name: str = Field(description="Display name")
+    email: Optional[str] = None
+    joined: Optional[DateTime] = None
+    role: Role
+    invitedBy: Optional[User] = Field(default=None, description="Who invited them")
+    friends: Optional[List[User]] = None
+    tags: List[Optional[str]]
+    pinned: Optional[SearchResult] = None
+
+
+class Post(BaseModel):
+    model_config = ConfigDict(populate_by_name=True)
+
+    id: str
+    title: Optional[str] = None
+    from_: Optional[str] = Field(default=None, alias="from")
+
+
+SearchResult = Union[User, Post]
+
+
+User.model_rebuild()
+Post.model_rebuild()
@@ -0,0 +1,26 @@
+"""Pydantic models generated from a GraphQL schema by gql2jsonschema."""
+
+from __future__ import annotations
+
+from enum import Enum
+from typing import Annotated, Any, List, Literal, Optional, Union
+
+from pydantic import BaseModel, ConfigDict, Field
+
+
+class Role(str, Enum):
+    # Full access
+    ADMIN = "ADMIN"
+    MEMBER = "MEMBER"
+    None_ = "None"
+
+
+class UserFilter(BaseModel):
+    role: Optional[Role] = Role.MEMBER
+    limit: Optional[int] = 20
+    names: Optional[List[str]] = ["ada", "grace"]
+    active: bool = True
+    nested: Optional[UserFilter] = None
+
+
+UserFilter.model_rebuild()
@@ -0,0 +1,63 @@
+"""Pydantic models generated from a GraphQL schema by gql2jsonschema."""
+
+from __future__ import annotations
+
+from enum import Enum
+from typing import Annotated, Any, List, Literal, Optional, Union
+
+from pydantic import BaseModel, ConfigDict, Field
+
+# An instant, as an RFC 3339 string
+DateTime = Any
+
+
+class Role(str, Enum):
+    # Full access
+    ADMIN = "ADMIN"
+    MEMBER = "MEMBER"
+    None_ = "None"
+
+
+class Post(BaseModel):
+    model_config = ConfigDict(populate_by_name=True)
+
+    typename__: Literal["Post"] = Field(default="Post", alias="__typename")
+    id: str
+    title: Optional[str] = None
+    from_: Optional[str] = Field(default=None, alias="from")
+
+
+class User(BaseModel):
+    """A person using the service"""
+
+    model_config = ConfigDict(populate_by_name=True)
+
+    typename__: Literal["User"] = Field(default="User", alias="__typename")
+    id: str
+    name: str = Field(description="Display name")
+    email: Optional[str] = None
+    joined: Optional[DateTime] = None
+    role: Role
+    invitedBy: Optional[User] = Field(default=None, description="Who invited them")
+    friends: Optional[List[User]] = None
+    tags: List[Optional[str]]
+    pinned: Optional[SearchResult] = None
+
+
+class UserFilter(BaseModel):
+    role: Optional[Role] = Role.MEMBER
+    limit: Optional[int] = 20
+    names: Optional[List[str]] = ["ada", "grace"]
+    active: bool = True
+    nested: Optional[UserFilter] = None
+
+
+Node = Annotated[Union[User, Post], Field(discriminator="typename__")]
+
+
+SearchResult = Annotated[Union[User, Post], Field(discriminator="typename__")]
+
+
+Post.model_rebuild()
+User.model_rebuild()
+UserFilter.model_rebuild()
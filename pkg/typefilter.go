@@ -0,0 +1,311 @@
+package pkg
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// typePattern matches type names. A pattern between slashes, such as /Input$/, is a regular
+// expression matched anywhere in the name; any other pattern matches whole names, with * wildcards.
+type typePattern struct {
+	glob   string
+	regexp *regexp.Regexp
+}
+
+func (p typePattern) match(name string) bool {
+	if p.regexp != nil {
+		return p.regexp.MatchString(name)
+	}
+	matched, _ := path.Match(p.glob, name)
+	return matched
+}
+
+// CheckTypePatterns reports the first pattern that is not a valid type name pattern
+func CheckTypePatterns(patterns []string) error {
+	_, err := parseTypePatterns(patterns)
+	return err
+}
+
+// parseTypePatterns returns the valid patterns, and an error for the first invalid one
+func parseTypePatterns(patterns []string) ([]typePattern, error) {
+	parsed := make([]typePattern, 0, len(patterns))
+	var firstErr error
+	for _, pattern := range patterns {
+		var err error
+		if expr, ok := strings.CutPrefix(pattern, "/"); ok && len(expr) > 0 && strings.HasSuffix(expr, "/") {
+			var compiled *regexp.Regexp
+			if compiled, err = regexp.Compile(strings.TrimSuffix(expr, "/")); err == nil {
+				parsed = append(parsed, typePattern{regexp: compiled})
+			}
+		} else if _, err = path.Match(pattern, ""); err == nil {
+			parsed = append(parsed, typePattern{glob: pattern})
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("invalid type pattern %q: %w", pattern, err)
+		}
+	}
+	return parsed, firstErr
+}
+
+// matchesTypePattern reports whether one of the patterns matches the type name
+func matchesTypePattern(patterns []typePattern, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// includeTypes keeps only the types matching the patterns of Options.IncludeTypes, together with
+// the root types, the built-in scalars and, with Options.IncludeTypeDependencies, every type the
+// matching types transitively reference. The other types are dropped as by excludeTypes.
+func includeTypes(schema IntrospectionSchema, types []IntrospectionType, opts *Options, logger *slog.Logger) ([]IntrospectionType, error) {
+	if len(opts.IncludeTypes) == 0 {
+		return types, nil
+	}
+	patterns, err := parseTypePatterns(opts.IncludeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]bool)
+	for _, t := range types {
+		if matchesTypePattern(patterns, t.Name) {
+			included[t.Name] = true
+		}
+	}
+	if opts.IncludeTypeDependencies {
+		included = referencedTypes(newTypeIndex(types), included, opts)
+	}
+	for _, root := range []*TypeRef{schema.QueryType, schema.MutationType, schema.SubscriptionType} {
+		if root != nil {
+			included[root.Name] = true
+		}
+	}
+
+	excluded := make(map[string]bool)
+	for _, t := range types {
+		if !included[t.Name] && !(t.Kind == "SCALAR" && builtinScalars[t.Name]) {
+			excluded[t.Name] = true
+		}
+	}
+	if len(excluded) == 0 {
+		return types, nil
+	}
+	logger.Debug("Including types", "types", len(types)-len(excluded))
+	return dropTypes(types, excluded), nil
+}
+
+// excludeTypes drops the types matching the patterns, see dropTypes
+func excludeTypes(types []IntrospectionType, patterns []string, logger *slog.Logger) ([]IntrospectionType, error) {
+	if len(patterns) == 0 {
+		return types, nil
+	}
+	parsed, err := parseTypePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool)
+	for _, t := range types {
+		if matchesTypePattern(parsed, t.Name) {
+			excluded[t.Name] = true
+		}
+	}
+	if len(excluded) == 0 {
+		return types, nil
+	}
+	logger.Debug("Excluding types", "types", len(excluded))
+	return dropTypes(types, excluded), nil
+}
+
+// excludePresetTypes drops the types matching the patterns of a Preset, except those the include
+// patterns select
+func excludePresetTypes(types []IntrospectionType, patterns, include []string, logger *slog.Logger) ([]IntrospectionType, error) {
+	if len(patterns) == 0 {
+		return types, nil
+	}
+	parsed, err := parseTypePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	spared, err := parseTypePatterns(include)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool)
+	for _, t := range types {
+		if matchesTypePattern(parsed, t.Name) && !matchesTypePattern(spared, t.Name) {
+			excluded[t.Name] = true
+		}
+	}
+	if len(excluded) == 0 {
+		return types, nil
+	}
+	logger.Debug("Excluding preset types", "types", len(excluded))
+	return dropTypes(types, excluded), nil
+}
+
+// dropTypes drops the excluded types, then every field, argument, input field, union member and
+// interface that refers to a dropped type, so that no reference is left dangling
+func dropTypes(types []IntrospectionType, excluded map[string]bool) []IntrospectionType {
+	refersToExcluded := func(typeRef IntrospectionTypeRef) bool {
+		name := namedTypeName(typeRef)
+		return name != "" && excluded[name]
+	}
+
+	filtered := make([]IntrospectionType, 0, len(types)-len(excluded))
+	for _, t := range types {
+		if excluded[t.Name] {
+			continue
+		}
+		switch t.Kind {
+		case "OBJECT", "INTERFACE":
+			fields := make([]IntrospectionField, 0, len(t.Fields))
+			for _, field := range t.Fields {
+				if refersToExcluded(field.Type) {
+					continue
+				}
+				args := make([]IntrospectionArg, 0, len(field.Args))
+				for _, arg := range field.Args {
+					if !refersToExcluded(arg.Type) {
+						args = append(args, arg)
+					}
+				}
+				field.Args = args
+				fields = append(fields, field)
+			}
+			t.Fields = fields
+
+			interfaces := make([]TypeRef, 0, len(t.Interfaces))
+			for _, iface := range t.Interfaces {
+				if !excluded[iface.Name] {
+					interfaces = append(interfaces, iface)
+				}
+			}
+			t.Interfaces = interfaces
+			if t.PossibleTypes != nil {
+				t.PossibleTypes = withoutExcluded(t.PossibleTypes, excluded)
+			}
+		case "INPUT_OBJECT":
+			fields := make([]IntrospectionInput, 0, len(t.InputFields))
+			for _, field := range t.InputFields {
+				if !refersToExcluded(field.Type) {
+					fields = append(fields, field)
+				}
+			}
+			t.InputFields = fields
+		case "UNION":
+			t.PossibleTypes = withoutExcluded(t.PossibleTypes, excluded)
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
+
+// withoutExcluded returns the possible types of a union or interface that are not excluded
+func withoutExcluded(possibleTypes []IntrospectionType, excluded map[string]bool) []IntrospectionType {
+	members := make([]IntrospectionType, 0, len(possibleTypes))
+	for _, member := range possibleTypes {
+		if !excluded[member.Name] {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// namedTypeName returns the name of the named type under the wrappers of a type reference
+func namedTypeName(typeRef IntrospectionTypeRef) string {
+	named := namedTypeRef(typeRef)
+	if named == nil || named.Name == nil {
+		return ""
+	}
+	return *named.Name
+}
+
+// flattenConnections replaces every field type that is a Relay connection with a list of the
+// connection's nodes, keeping whether the field is non-null. A connection is an object type with an
+// edges field listing edge objects that have a node field, or a nodes field listing the nodes. The
+// connection types themselves are kept, unreferenced by the fields that used them.
+func flattenConnections(types []IntrospectionType) []IntrospectionType {
+	index := newTypeIndex(types)
+	nodes := make(map[string]IntrospectionTypeRef)
+	for _, t := range types {
+		if node, ok := connectionNode(t, index); ok {
+			nodes[t.Name] = node
+		}
+	}
+	if len(nodes) == 0 {
+		return types
+	}
+
+	flattened := make([]IntrospectionType, len(types))
+	for i, t := range types {
+		flattened[i] = t
+		if t.Kind != "OBJECT" && t.Kind != "INTERFACE" {
+			continue
+		}
+		fields := make([]IntrospectionField, len(t.Fields))
+		for j, field := range t.Fields {
+			if node, ok := nodes[namedTypeName(field.Type)]; ok {
+				list := &IntrospectionTypeRef{Kind: "LIST", OfType: &node}
+				if field.Type.Kind == "NON_NULL" {
+					list = &IntrospectionTypeRef{Kind: "NON_NULL", OfType: list}
+				}
+				field.Type = *list
+			}
+			fields[j] = field
+		}
+		flattened[i].Fields = fields
+	}
+	return flattened
+}
+
+// connectionNode returns the type of the nodes of a Relay connection type
+func connectionNode(t IntrospectionType, index typeIndex) (IntrospectionTypeRef, bool) {
+	if t.Kind != "OBJECT" {
+		return IntrospectionTypeRef{}, false
+	}
+	for _, field := range t.Fields {
+		if field.Name != "edges" {
+			continue
+		}
+		item, ok := listItem(field.Type)
+		if !ok {
+			continue
+		}
+		edge := index[namedTypeName(item)]
+		if edge == nil || edge.Kind != "OBJECT" {
+			continue
+		}
+		for _, edgeField := range edge.Fields {
+			if edgeField.Name == "node" {
+				return edgeField.Type, true
+			}
+		}
+	}
+	for _, field := range t.Fields {
+		if field.Name == "nodes" {
+			if item, ok := listItem(field.Type); ok {
+				return item, true
+			}
+		}
+	}
+	return IntrospectionTypeRef{}, false
+}
+
+// listItem returns the item type of a list type, which may be non-null
+func listItem(typeRef IntrospectionTypeRef) (IntrospectionTypeRef, bool) {
+	if typeRef.Kind == "NON_NULL" && typeRef.OfType != nil {
+		typeRef = *typeRef.OfType
+	}
+	if typeRef.Kind != "LIST" || typeRef.OfType == nil {
+		return IntrospectionTypeRef{}, false
+	}
+	return *typeRef.OfType, true
+}
@@ -0,0 +1,226 @@
+package pkg
+
+// UISchema is a react-jsonschema-form uiSchema: nested objects keyed by property name,
+// with ui:* keys holding widget hints
+type UISchema map[string]interface{}
+
+// UISchemaOptions controls which widget hints GenerateUISchema emits
+type UISchemaOptions struct {
+	// TextareaMinLength is the description length from which string fields get a textarea widget; 0 disables it
+	TextareaMinLength int `json:"textareaMinLength"`
+	// ScalarWidgets maps scalar names to the widget used for them, such as DateTime to datetime
+	ScalarWidgets map[string]string `json:"scalarWidgets"`
+	// EnumWidget is the widget used for enums; empty disables enum hints
+	EnumWidget string `json:"enumWidget"`
+}
+
+// DefaultUISchemaOptions returns the default uiSchema mapping rules
+func DefaultUISchemaOptions() UISchemaOptions {
+	return UISchemaOptions{
+		TextareaMinLength: 120,
+		ScalarWidgets: map[string]string{
+			"Date":     "date",
+			"DateTime": "datetime",
+		},
+		EnumWidget: "select",
+	}
+}
+
+// GenerateUISchema builds a uiSchema that mirrors the property paths of schema, which must have been
+// converted from introspection. Root properties appear at the top level and each definition under
+// "definitions". Objects list their properties in GraphQL declaration order with ui:order.
+func GenerateUISchema(introspection IntrospectionQuery, schema *JSONSchema6, opts UISchemaOptions) UISchema {
+	g := &uiSchemaGenerator{
+		types:       newTypeIndex(introspection.Schema.Types),
+		definitions: schema.Definitions,
+		opts:        opts,
+	}
+
+	root := UISchema{}
+	roots := map[string]*TypeRef{
+		"Query":        introspection.Schema.QueryType,
+		"Mutation":     introspection.Schema.MutationType,
+		"Subscription": introspection.Schema.SubscriptionType,
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		var t *IntrospectionType
+		if ref := roots[name]; ref != nil {
+			t = g.types[ref.Name]
+		}
+		g.set(root, name, g.typeNode(schema.Properties[name], t))
+	}
+
+	if len(schema.Definitions) > 0 {
+		definitions := UISchema{}
+		for _, name := range sortedKeys(schema.Definitions) {
+			g.set(definitions, name, g.typeNode(schema.Definitions[name], g.types[name]))
+		}
+		root["definitions"] = definitions
+	}
+	return root
+}
+
+type uiSchemaGenerator struct {
+	types       typeIndex
+	definitions map[string]*JSONSchema6
+	opts        UISchemaOptions
+}
+
+// set adds a child node unless it carries no hints
+func (g *uiSchemaGenerator) set(parent UISchema, key string, node UISchema) {
+	if len(node) > 0 {
+		parent[key] = node
+	}
+}
+
+// typeNode builds the node for a type's schema, or for any object when t is nil or does not match
+func (g *uiSchemaGenerator) typeNode(schema *JSONSchema6, t *IntrospectionType) UISchema {
+	if t == nil || schema.Properties == nil {
+		return g.valueNode(schema, "")
+	}
+
+	node := UISchema{}
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		order := make([]string, 0, len(t.Fields))
+		for _, field := range t.Fields {
+			property, ok := schema.Properties[field.Name]
+			if !ok {
+				continue
+			}
+			order = append(order, field.Name)
+			g.set(node, field.Name, g.fieldNode(property, field))
+		}
+		g.setOrder(node, order, len(schema.Properties))
+	case "INPUT_OBJECT":
+		order := make([]string, 0, len(t.InputFields))
+		for _, field := range t.InputFields {
+			property, ok := schema.Properties[field.Name]
+			if !ok {
+				continue
+			}
+			order = append(order, field.Name)
+			g.set(node, field.Name, g.valueNode(property, field.Description))
+		}
+		g.setOrder(node, order, len(schema.Properties))
+	default:
+		return g.valueNode(schema, "")
+	}
+	return node
+}
+
+// fieldNode builds the node for an output field, converted to an object of arguments and return
+func (g *uiSchemaGenerator) fieldNode(schema *JSONSchema6, field IntrospectionField) UISchema {
+	node := UISchema{}
+	if field.Description != "" {
+		node["ui:description"] = field.Description
+	}
+
+	if args := schema.Properties["arguments"]; args != nil {
+		argsNode := UISchema{}
+		order := make([]string, 0, len(field.Args))
+		for _, arg := range field.Args {
+			if property, ok := args.Properties[arg.Name]; ok {
+				order = append(order, arg.Name)
+				g.set(argsNode, arg.Name, g.valueNode(property, arg.Description))
+			}
+		}
+		g.setOrder(argsNode, order, len(args.Properties))
+		g.set(node, "arguments", argsNode)
+	}
+	if returns := schema.Properties["return"]; returns != nil {
+		g.set(node, "return", g.valueNode(returns, ""))
+	}
+	return node
+}
+
+// valueNode builds the node for an input value, argument or return type with the given GraphQL description.
+// Descriptions are taken from the introspection because converted scalars carry the scalar's own description.
+func (g *uiSchemaGenerator) valueNode(schema *JSONSchema6, description string) UISchema {
+	node := UISchema{}
+	if schema == nil {
+		return node
+	}
+	if description != "" {
+		node["ui:description"] = description
+	}
+
+	if schema.Items != nil {
+		g.set(node, "items", g.valueNode(schema.Items, ""))
+		return node
+	}
+	// Nullable array items are wrapped in anyOf with null; the hints belong to the other branch
+	if len(schema.AnyOf) == 2 && schema.AnyOf[1].Type == "null" {
+		for key, value := range g.valueNode(schema.AnyOf[0], "") {
+			node[key] = value
+		}
+		return node
+	}
+
+	target := schema
+	if name, ok := definitionName(schema.Ref); ok && g.definitions[name] != nil {
+		target = g.definitions[name]
+	}
+
+	if widget := g.opts.ScalarWidgets[target.Title]; widget != "" && target.Title != "" {
+		node["ui:widget"] = widget
+	} else if names, ok := enumNames(target); ok && g.opts.EnumWidget != "" {
+		node["ui:widget"] = g.opts.EnumWidget
+		node["ui:enumNames"] = names
+	} else if target.Type == "string" && g.opts.TextareaMinLength > 0 && len(description) >= g.opts.TextareaMinLength {
+		node["ui:widget"] = "textarea"
+	}
+
+	if target != schema || schema.Properties == nil {
+		return node
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		g.set(node, name, g.valueNode(schema.Properties[name], ""))
+	}
+	return node
+}
+
+// setOrder records the declaration order, appending "*" when some properties were not matched
+func (g *uiSchemaGenerator) setOrder(node UISchema, order []string, properties int) {
+	if len(order) < 2 && len(order) == properties {
+		return
+	}
+	if len(order) < properties {
+		order = append(order, "*")
+	}
+	node["ui:order"] = order
+}
+
+// enumNames returns display names for an enum schema converted from a GraphQL enum, using
+// each value's description and falling back to the value itself
+func enumNames(schema *JSONSchema6) ([]string, bool) {
+	if schema.Type != "string" {
+		return nil, false
+	}
+	// A flat enum, see EnumStyleFlat
+	if len(schema.Enum) > 0 && len(schema.AnyOf) == 0 {
+		names := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			names[i] = value
+			if i < len(schema.EnumDescriptions) && schema.EnumDescriptions[i] != "" {
+				names[i] = schema.EnumDescriptions[i]
+			}
+		}
+		return names, true
+	}
+	if len(schema.AnyOf) == 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(schema.AnyOf))
+	for _, branch := range schema.AnyOf {
+		if len(branch.Enum) != 1 {
+			return nil, false
+		}
+		name := branch.Title
+		if name == "" {
+			name = branch.Enum[0]
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
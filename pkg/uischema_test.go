@@ -0,0 +1,196 @@
+package pkg_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-cronin/gql2jsonschema-go/pkg"
+)
+
+const uiSchemaSDL = `
+scalar DateTime
+
+type Query {
+  """Find orders"""
+  orders(status: Status, since: DateTime, first: Int): [Order!]!
+}
+
+type Mutation {
+  placeOrder(input: OrderInput!): Order
+}
+
+type Order {
+  id: ID!
+  status: Status!
+  placedAt: DateTime
+  notes: String
+}
+
+input OrderInput {
+  """
+  Free-form delivery instructions shown to the courier. Keep them short, but mention gates,
+  codes and where to leave the parcel.
+  """
+  instructions: String
+  status: Status = PENDING
+  items: [LineInput!]!
+  deliverBy: DateTime
+}
+
+input LineInput {
+  sku: String!
+  quantity: Int!
+}
+
+enum Status {
+  "Waiting for payment"
+  PENDING
+  SHIPPED
+}
+`
+
+// checkMirrors verifies that every key of a uiSchema node other than the ui:* hints names a property of
+// the schema at the same path, and that ui:order only lists existing properties
+func checkMirrors(t *testing.T, node pkg.UISchema, schema *pkg.JSONSchema6, path string) {
+	t.Helper()
+	// Hints for nullable list items sit on the non-null branch
+	if schema.Properties == nil && schema.Items == nil && len(schema.AnyOf) == 2 && schema.AnyOf[1].Type == "null" {
+		schema = schema.AnyOf[0]
+	}
+	for key, value := range node {
+		if key == "ui:order" {
+			for _, name := range value.([]string) {
+				if _, ok := schema.Properties[name]; !ok && name != "*" {
+					t.Errorf("%s: ui:order lists %q, which is not a property", path, name)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(key, "ui:") {
+			continue
+		}
+		child, ok := value.(pkg.UISchema)
+		if !ok {
+			t.Errorf("%s/%s: node is a %T", path, key, value)
+			continue
+		}
+		var target *pkg.JSONSchema6
+		if key == "items" && schema.Items != nil {
+			target = schema.Items
+		} else {
+			target = schema.Properties[key]
+		}
+		if target == nil {
+			t.Errorf("%s/%s: no such property in the schema", path, key)
+			continue
+		}
+		checkMirrors(t, child, target, path+"/"+key)
+	}
+}
+
+func TestUISchemaMirrorsSchema(t *testing.T) {
+	introspection := mustIntrospect(t, uiSchemaSDL)
+	for name, set := range map[string]func(*pkg.Options){
+		"defaults":         nil,
+		"nullable items":   func(o *pkg.Options) { o.NullableArrayItems = true },
+		"flat enums":       func(o *pkg.Options) { o.EnumStyle = pkg.EnumStyleFlat },
+		"plain fields":     func(o *pkg.Options) { o.FieldShape = pkg.FieldShapePlain },
+		"definitions only": func(o *pkg.Options) { o.DefinitionsOnly = true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := pkg.FromIntrospectionQuery(introspection, options(set))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ui := pkg.GenerateUISchema(introspection, schema, pkg.DefaultUISchemaOptions())
+			definitions, _ := ui["definitions"].(pkg.UISchema)
+			for key, value := range ui {
+				if key == "definitions" {
+					continue
+				}
+				if schema.Properties[key] == nil {
+					t.Errorf("/%s: no such root property", key)
+					continue
+				}
+				checkMirrors(t, value.(pkg.UISchema), schema.Properties[key], "/"+key)
+			}
+			for key, value := range definitions {
+				if schema.Definitions[key] == nil {
+					t.Errorf("/definitions/%s: no such definition", key)
+					continue
+				}
+				checkMirrors(t, value.(pkg.UISchema), schema.Definitions[key], "/definitions/"+key)
+			}
+			if len(definitions) == 0 {
+				t.Error("no definition has hints")
+			}
+		})
+	}
+}
+
+// uiNode follows a path of keys through a uiSchema
+func uiNode(t *testing.T, ui pkg.UISchema, keys ...string) pkg.UISchema {
+	t.Helper()
+	node := ui
+	for _, key := range keys {
+		child, ok := node[key].(pkg.UISchema)
+		if !ok {
+			t.Fatalf("uiSchema has no node %s", strings.Join(keys, "/"))
+		}
+		node = child
+	}
+	return node
+}
+
+func TestUISchemaHints(t *testing.T) {
+	introspection := mustIntrospect(t, uiSchemaSDL)
+	schema := mustConvert(t, uiSchemaSDL, options(nil))
+	ui := pkg.GenerateUISchema(introspection, schema, pkg.DefaultUISchemaOptions())
+
+	input := uiNode(t, ui, "definitions", "OrderInput")
+	if got := input["ui:order"]; !reflect.DeepEqual(got, []string{"instructions", "status", "items", "deliverBy"}) {
+		t.Errorf("input ui:order = %v, want declaration order", got)
+	}
+	if got := uiNode(t, input, "instructions")["ui:widget"]; got != "textarea" {
+		t.Errorf("long description widget = %v, want textarea", got)
+	}
+	if got := uiNode(t, input, "deliverBy")["ui:widget"]; got != "datetime" {
+		t.Errorf("DateTime widget = %v, want datetime", got)
+	}
+	status := uiNode(t, input, "status")
+	if status["ui:widget"] != "select" || !reflect.DeepEqual(status["ui:enumNames"], []string{"Waiting for payment", "SHIPPED"}) {
+		t.Errorf("enum hints = %v, want a select with enum names from descriptions", status)
+	}
+
+	orders := uiNode(t, ui, "Query", "orders")
+	if got := orders["ui:description"]; got != "Find orders" {
+		t.Errorf("field ui:description = %v", got)
+	}
+	if got := uiNode(t, orders, "arguments")["ui:order"]; !reflect.DeepEqual(got, []string{"status", "since", "first"}) {
+		t.Errorf("arguments ui:order = %v, want declaration order", got)
+	}
+	if got := uiNode(t, ui, "definitions", "Order", "placedAt", "return")["ui:widget"]; got != "datetime" {
+		t.Errorf("return DateTime widget = %v, want datetime", got)
+	}
+}
+
+func TestUISchemaRules(t *testing.T) {
+	introspection := mustIntrospect(t, uiSchemaSDL)
+	schema := mustConvert(t, uiSchemaSDL, options(nil))
+	ui := pkg.GenerateUISchema(introspection, schema, pkg.UISchemaOptions{
+		EnumWidget:    "radio",
+		ScalarWidgets: map[string]string{"DateTime": "date"},
+	})
+
+	input := uiNode(t, ui, "definitions", "OrderInput")
+	if _, ok := uiNode(t, input, "instructions")["ui:widget"]; ok {
+		t.Error("a textarea was emitted with TextareaMinLength 0")
+	}
+	if got := uiNode(t, input, "status")["ui:widget"]; got != "radio" {
+		t.Errorf("enum widget = %v, want radio", got)
+	}
+	if got := uiNode(t, input, "deliverBy")["ui:widget"]; got != "date" {
+		t.Errorf("DateTime widget = %v, want date", got)
+	}
+}
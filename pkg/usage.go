@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaUsage is the part of a schema that a set of operations uses. Objects and interfaces map to
+// the fields selected on them; types used as a whole, such as the input objects, enums and scalars of
+// variables and arguments, map to nil.
+type SchemaUsage map[string]map[string]bool
+
+// TrimStats counts what TrimToUsage removed
+type TrimStats struct {
+	TypesBefore  int `json:"typesBefore"`
+	TypesAfter   int `json:"typesAfter"`
+	FieldsBefore int `json:"fieldsBefore"`
+	FieldsAfter  int `json:"fieldsAfter"`
+}
+
+// OperationsUsage walks every operation of docs and records the types and fields they touch:
+// selected fields, the types of their arguments, the types of variables, the fragments they spread
+// with their type conditions, and the members of the unions they select. Fragments are resolved
+// within the document that spreads them.
+func OperationsUsage(introspection IntrospectionQuery, docs []*Document) (SchemaUsage, error) {
+	u := &usageWalker{
+		types: newTypeIndex(introspection.Schema.Types),
+		usage: make(SchemaUsage),
+	}
+	for _, doc := range docs {
+		u.fragments = make(map[string]*FragmentDefinition, len(doc.Fragments))
+		for _, fragment := range doc.Fragments {
+			if u.fragments[fragment.Name] != nil {
+				return nil, fmt.Errorf("%s: fragment %s is defined more than once", fragment.Position, fragment.Name)
+			}
+			u.fragments[fragment.Name] = fragment
+		}
+		if err := checkFragmentCycles(doc.Fragments, u.fragments); err != nil {
+			return nil, err
+		}
+
+		for _, op := range doc.Operations {
+			var root *TypeRef
+			switch op.Operation {
+			case OperationQuery:
+				root = introspection.Schema.QueryType
+			case OperationMutation:
+				root = introspection.Schema.MutationType
+			case OperationSubscription:
+				root = introspection.Schema.SubscriptionType
+			}
+			if root == nil || u.types[root.Name] == nil {
+				return nil, fmt.Errorf("%s: schema does not support %s operations", op.Position, op.Operation)
+			}
+			for _, variable := range op.Variables {
+				name := strings.Trim(variable.Type, "[]!")
+				if u.types[name] == nil {
+					return nil, fmt.Errorf("%s: variable $%s: %w", variable.Position, variable.Name, notFound("type", name, u.typeNames()))
+				}
+				u.useWhole(name)
+			}
+			if err := u.walk(u.types[root.Name], op.SelectionSet); err != nil {
+				if op.Name != "" {
+					return nil, fmt.Errorf("operation %s: %w", op.Name, err)
+				}
+				return nil, err
+			}
+		}
+	}
+	return u.usage, nil
+}
+
+type usageWalker struct {
+	types     typeIndex
+	fragments map[string]*FragmentDefinition
+	usage     SchemaUsage
+}
+
+func (u *usageWalker) typeNames() []string {
+	names := make([]string, 0, len(u.types))
+	for name := range u.types {
+		names = append(names, name)
+	}
+	return names
+}
+
+// use records a composite type, and for a union its members, without selecting any of their fields
+func (u *usageWalker) use(t *IntrospectionType) {
+	if _, ok := u.usage[t.Name]; !ok {
+		u.usage[t.Name] = make(map[string]bool)
+	}
+	if t.Kind == "UNION" {
+		for _, member := range t.PossibleTypes {
+			if memberType := u.types[member.Name]; memberType != nil {
+				u.use(memberType)
+			}
+		}
+	}
+}
+
+// useWhole records a type used as a whole, and for an input object the types of its fields
+func (u *usageWalker) useWhole(name string) {
+	if fields, ok := u.usage[name]; ok && fields == nil {
+		return
+	}
+	u.usage[name] = nil
+	if t := u.types[name]; t != nil && t.Kind == "INPUT_OBJECT" {
+		for _, field := range t.InputFields {
+			if named := namedTypeRef(field.Type); named != nil && named.Name != nil {
+				u.useWhole(*named.Name)
+			}
+		}
+	}
+}
+
+func (u *usageWalker) walk(scope *IntrospectionType, selections []Selection) error {
+	u.use(scope)
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			field := findField(scope.Fields, s.Name)
+			if field == nil {
+				names := make([]string, len(scope.Fields))
+				for i, f := range scope.Fields {
+					names[i] = f.Name
+				}
+				return fmt.Errorf("%s: %w", s.Position, notFound("field", scope.Name+"."+s.Name, names))
+			}
+			u.usage[scope.Name][field.Name] = true
+			for _, arg := range field.Args {
+				if named := namedTypeRef(arg.Type); named != nil && named.Name != nil {
+					u.useWhole(*named.Name)
+				}
+			}
+
+			named := namedTypeRef(field.Type)
+			if named == nil || named.Name == nil {
+				continue
+			}
+			t := u.types[*named.Name]
+			if t == nil || !isCompositeKind(t.Kind) {
+				u.useWhole(*named.Name)
+				continue
+			}
+			if err := u.walk(t, s.SelectionSet); err != nil {
+				return err
+			}
+		case *InlineFragment:
+			t := scope
+			if s.TypeCondition != "" {
+				if t = u.types[s.TypeCondition]; t == nil {
+					return fmt.Errorf("%s: %w", s.Position, notFound("type", s.TypeCondition, u.typeNames()))
+				}
+			}
+			if err := u.walk(t, s.SelectionSet); err != nil {
+				return err
+			}
+		case *FragmentSpread:
+			fragment := u.fragments[s.Name]
+			if fragment == nil {
+				names := make([]string, 0, len(u.fragments))
+				for name := range u.fragments {
+					names = append(names, name)
+				}
+				return fmt.Errorf("%s: %w", s.Position, notFound("fragment", s.Name, names))
+			}
+			t := u.types[fragment.TypeCondition]
+			if t == nil {
+				return fmt.Errorf("%s: %w", fragment.Position, notFound("type", fragment.TypeCondition, u.typeNames()))
+			}
+			if err := u.walk(t, fragment.SelectionSet); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TrimToUsage returns a copy of an introspection result with only the types and fields in usage.
+// Objects and interfaces keep only their used fields, and root types no operation uses are removed
+// along with their operation type.
+func TrimToUsage(introspection IntrospectionQuery, usage SchemaUsage) (IntrospectionQuery, TrimStats) {
+	var stats TrimStats
+	keep := make(map[string]bool, len(usage))
+	types := make([]IntrospectionType, 0, len(usage))
+	for _, t := range introspection.Schema.Types {
+		stats.TypesBefore++
+		stats.FieldsBefore += len(t.Fields)
+		fields, ok := usage[t.Name]
+		if !ok {
+			continue
+		}
+		keep[t.Name] = true
+		if fields != nil && t.Fields != nil {
+			used := make([]IntrospectionField, 0, len(fields))
+			for _, field := range t.Fields {
+				if fields[field.Name] {
+					used = append(used, field)
+				}
+			}
+			t.Fields = used
+		}
+		types = append(types, t)
+	}
+	dropDanglingReferences(types, keep)
+
+	for _, root := range []**TypeRef{&introspection.Schema.QueryType, &introspection.Schema.MutationType, &introspection.Schema.SubscriptionType} {
+		if *root != nil && !keep[(*root).Name] {
+			*root = nil
+		}
+	}
+	introspection.Schema.Types = types
+
+	stats.TypesAfter = len(types)
+	for _, t := range types {
+		stats.FieldsAfter += len(t.Fields)
+	}
+	return introspection, stats
+}
@@ -0,0 +1,338 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes a single validation failure
+type ValidationError struct {
+	InstancePath string `json:"instancePath"`
+	SchemaPath   string `json:"schemaPath"`
+	Message      string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	path := e.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
+
+// validator validates instances against schemas, resolving $ref against the root document
+type validator struct {
+	root *JSONSchema6
+}
+
+// Validate checks an instance against schema, resolving $ref against root.
+// Instances should be decoded with json.Decoder.UseNumber so integers can be told apart from floats.
+func Validate(root *JSONSchema6, schema *JSONSchema6, instance interface{}) []ValidationError {
+	v := &validator{root: root}
+	return v.validate(schema, instance, "", "#")
+}
+
+// DecodeInstance decodes a JSON document for use with Validate
+func DecodeInstance(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// ResolvePointer resolves a JSON pointer such as /definitions/User against a schema document
+func ResolvePointer(root *JSONSchema6, pointer string) (*JSONSchema6, error) {
+	return root.GetByPointer(pointer)
+}
+
+func (v *validator) validate(schema *JSONSchema6, instance interface{}, instancePath, schemaPath string) []ValidationError {
+	errs, _ := v.evaluate(schema, instance, instancePath, schemaPath)
+	return errs
+}
+
+// evaluatedProperties are the properties of an object instance that a schema and the subschemas
+// applied to the same instance have evaluated, which unevaluatedProperties needs to know
+type evaluatedProperties map[string]bool
+
+func (e *evaluatedProperties) set(name string) {
+	if *e == nil {
+		*e = make(evaluatedProperties)
+	}
+	(*e)[name] = true
+}
+
+func (e *evaluatedProperties) add(names evaluatedProperties) {
+	for name := range names {
+		e.set(name)
+	}
+}
+
+// evaluate validates an instance and also returns the properties of an object instance that were evaluated
+func (v *validator) evaluate(schema *JSONSchema6, instance interface{}, instancePath, schemaPath string) ([]ValidationError, evaluatedProperties) {
+	if schema == nil {
+		return nil, nil
+	}
+
+	if schema.Ref != "" {
+		target, err := ResolvePointer(v.root, schema.Ref)
+		if err != nil {
+			return []ValidationError{{InstancePath: instancePath, SchemaPath: schemaPath, Message: err.Error()}}, nil
+		}
+		return v.evaluate(target, instance, instancePath, schema.Ref)
+	}
+
+	var evaluated evaluatedProperties
+
+	errs := make([]ValidationError, 0)
+	fail := func(keyword, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{
+			InstancePath: instancePath,
+			SchemaPath:   schemaPath + "/" + keyword,
+			Message:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	if schema.Type != nil {
+		types := schemaTypes(schema.Type)
+		if !matchesAnyType(types, instance) {
+			fail("type", "expected %s, got %s", strings.Join(types, " or "), instanceType(instance))
+			return errs, nil
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		s, ok := instance.(string)
+		if !ok || !containsString(schema.Enum, s) {
+			fail("enum", "value must be one of %s", strings.Join(schema.Enum, ", "))
+		}
+	}
+
+	if s, ok := instance.(string); ok && schema.Pattern != "" {
+		if re, err := regexp.Compile(schema.Pattern); err != nil {
+			fail("pattern", "invalid pattern %q: %v", schema.Pattern, err)
+		} else if !re.MatchString(s) {
+			fail("pattern", "value does not match %s", schema.Pattern)
+		}
+	}
+
+	if object, ok := instance.(map[string]interface{}); ok {
+		for _, name := range schema.Required {
+			if _, present := object[name]; !present {
+				fail("required", "missing required property %q", name)
+			}
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if propertySchema, ok := schema.Properties[name]; ok {
+				errs = append(errs, v.validate(propertySchema, object[name],
+					instancePath+"/"+escapePointerSegment(name),
+					schemaPath+"/properties/"+escapePointerSegment(name))...)
+			} else if schema.AdditionalProperties == nil {
+				continue
+			} else if !*schema.AdditionalProperties {
+				fail("additionalProperties", "unexpected property %q", name)
+			}
+			evaluated.set(name)
+		}
+	}
+
+	if n, ok := numberValue(instance); ok {
+		if schema.Minimum != nil && n < *schema.Minimum {
+			fail("minimum", "expected at least %s, got %s", formatNumber(*schema.Minimum), formatNumber(n))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			fail("maximum", "expected at most %s, got %s", formatNumber(*schema.Maximum), formatNumber(n))
+		}
+	}
+
+	if array, ok := instance.([]interface{}); ok && schema.MinItems != nil && len(array) < *schema.MinItems {
+		fail("minItems", "expected at least %d items, got %d", *schema.MinItems, len(array))
+	}
+	if array, ok := instance.([]interface{}); ok && schema.Items != nil {
+		for i, item := range array {
+			errs = append(errs, v.validate(schema.Items, item,
+				fmt.Sprintf("%s/%d", instancePath, i), schemaPath+"/items")...)
+		}
+	}
+
+	// Only branches the instance matches count towards the evaluated properties, so every anyOf
+	// branch is tried rather than stopping at the first match
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for i, branch := range schema.AnyOf {
+			branchErrs, branchEvaluated := v.evaluate(branch, instance, instancePath, fmt.Sprintf("%s/anyOf/%d", schemaPath, i))
+			if len(branchErrs) == 0 {
+				matched = true
+				evaluated.add(branchEvaluated)
+			}
+		}
+		if !matched {
+			fail("anyOf", "value does not match any of the allowed schemas")
+		}
+	}
+
+	for i, branch := range schema.AllOf {
+		branchErrs, branchEvaluated := v.evaluate(branch, instance, instancePath, fmt.Sprintf("%s/allOf/%d", schemaPath, i))
+		errs = append(errs, branchErrs...)
+		evaluated.add(branchEvaluated)
+	}
+
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for i, branch := range schema.OneOf {
+			branchErrs, branchEvaluated := v.evaluate(branch, instance, instancePath, fmt.Sprintf("%s/oneOf/%d", schemaPath, i))
+			if len(branchErrs) == 0 {
+				matches++
+				evaluated.add(branchEvaluated)
+			}
+		}
+		if matches != 1 {
+			fail("oneOf", "value must match exactly one schema, matched %d", matches)
+		}
+	}
+
+	if object, ok := instance.(map[string]interface{}); ok && schema.UnevaluatedProperties != nil {
+		names := make([]string, 0, len(object))
+		for name := range object {
+			if !evaluated[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !*schema.UnevaluatedProperties {
+				fail("unevaluatedProperties", "unexpected property %q", name)
+			}
+			evaluated.set(name)
+		}
+	}
+
+	return errs, evaluated
+}
+
+// schemaTypes normalizes the type keyword into a list of type names
+func schemaTypes(t interface{}) []string {
+	switch value := t.(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []interface{}:
+		types := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesAnyType(types []string, instance interface{}) bool {
+	for _, t := range types {
+		if matchesType(t, instance) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(t string, instance interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := instance.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := instance.([]interface{})
+		return ok
+	case "string":
+		_, ok := instance.(string)
+		return ok
+	case "boolean":
+		_, ok := instance.(bool)
+		return ok
+	case "null":
+		return instance == nil
+	case "number":
+		switch instance.(type) {
+		case json.Number, float64:
+			return true
+		}
+		return false
+	case "integer":
+		return isInteger(instance)
+	}
+	return false
+}
+
+func isInteger(instance interface{}) bool {
+	switch n := instance.(type) {
+	case json.Number:
+		f, ok := new(big.Float).SetString(n.String())
+		return ok && f.IsInt()
+	case float64:
+		return n == float64(int64(n))
+	}
+	return false
+}
+
+// numberValue returns the value of a number instance
+func numberValue(instance interface{}) (float64, bool) {
+	switch n := instance.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// formatNumber prints a number without an exponent
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func instanceType(instance interface{}) string {
+	switch instance.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case json.Number, float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", instance)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}